@@ -495,13 +495,23 @@ func (b *Reader) ReadString(delim byte) (string, error) {
 // WriteTo implements io.WriterTo.
 // This may make multiple calls to the Read method of the underlying Reader.
 // If the underlying reader supports the WriteTo method,
-// this calls the underlying WriteTo without buffering.
+// this calls the underlying WriteTo without buffering; if it supports
+// the WriteStringTo method and w is an io.StringWriter, that is
+// preferred.
 func (b *Reader) WriteTo(w io.Writer) (n int64, err error) {
 	n, err = b.writeBuf(w)
 	if err != nil {
 		return
 	}
 
+	if r, ok := b.rd.(io.StringWriterTo); ok {
+		if w, ok := w.(io.StringWriter); ok {
+			m, err := r.WriteStringTo(w)
+			n += m
+			return n, err
+		}
+	}
+
 	if r, ok := b.rd.(io.WriterTo); ok {
 		m, err := r.WriteTo(w)
 		n += m
@@ -704,11 +714,18 @@ func (b *Writer) WriteRune(r rune) (size int, err error) {
 func (b *Writer) WriteString(s string) (int, error) {
 	nn := 0
 	for len(s) > b.Available() && b.err == nil {
-		n := copy(b.buf[b.n:], s)
-		b.n += n
+		var n int
+		if sw, ok := b.wr.(io.StringWriter); ok && b.Buffered() == 0 {
+			// Large write, empty buffer, and the underlying writer
+			// accepts strings. Write directly from s to avoid a copy.
+			n, b.err = sw.WriteString(s)
+		} else {
+			n = copy(b.buf[b.n:], s)
+			b.n += n
+			b.Flush()
+		}
 		nn += n
 		s = s[n:]
-		b.Flush()
 	}
 	if b.err != nil {
 		return nn, b.err
@@ -721,7 +738,8 @@ func (b *Writer) WriteString(s string) (int, error) {
 
 // ReadFrom implements io.ReaderFrom. If the underlying writer
 // supports the ReadFrom method, and b has no buffered data yet,
-// this calls the underlying ReadFrom without buffering.
+// this calls the underlying ReadFrom without buffering. Otherwise,
+// if r implements io.StringWriterTo, its data is written with WriteString.
 func (b *Writer) ReadFrom(r io.Reader) (n int64, err error) {
 	if b.err != nil {
 		return 0, b.err
@@ -733,6 +751,9 @@ func (b *Writer) ReadFrom(r io.Reader) (n int64, err error) {
 			return n, err
 		}
 	}
+	if r, ok := r.(io.StringWriterTo); ok {
+		return r.WriteStringTo(b)
+	}
 	var m int
 	for {
 		if b.Available() == 0 {
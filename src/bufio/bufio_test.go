@@ -695,6 +695,45 @@ func TestWriteString(t *testing.T) {
 	}
 }
 
+// stringWriteCountingDiscard counts the calls to its WriteString method.
+type stringWriteCountingDiscard struct {
+	writeCountingDiscard
+	strings int
+}
+
+func (w *stringWriteCountingDiscard) WriteString(s string) (int, error) {
+	w.strings++
+	return len(s), nil
+}
+
+func TestWriteStringUnderlyingStringWriter(t *testing.T) {
+	var w stringWriteCountingDiscard
+	b := NewWriterSize(&w, 16)
+	b.WriteString(strings.Repeat("x", 100))
+	if w.writeCountingDiscard != 0 || w.strings != 1 {
+		t.Fatalf("large WriteString to empty buffer: got %d writes, %d WriteStrings; want 0, 1", w.writeCountingDiscard, w.strings)
+	}
+	b.WriteString("y")
+	b.WriteString(strings.Repeat("x", 100))
+	if w.writeCountingDiscard != 1 || w.strings != 2 {
+		t.Fatalf("large WriteString to non-empty buffer: got %d writes, %d WriteStrings; want 1, 2", w.writeCountingDiscard, w.strings)
+	}
+}
+
+func TestWriterReadFromStringWriterTo(t *testing.T) {
+	var buf strings.Builder
+	b := NewWriterSize(struct{ io.Writer }{&buf}, 16)
+	b.WriteString("a")
+	n, err := b.ReadFrom(strings.NewReader(strings.Repeat("x", 100)))
+	if n != 100 || err != nil {
+		t.Fatalf("ReadFrom = %d, %v; want 100, nil", n, err)
+	}
+	b.Flush()
+	if want := "a" + strings.Repeat("x", 100); buf.String() != want {
+		t.Errorf("got %q; want %q", buf.String(), want)
+	}
+}
+
 func TestBufferFull(t *testing.T) {
 	const longString = "And now, hello, world! It is the time for all good men to come to the aid of their party"
 	buf := NewReaderSize(strings.NewReader(longString), minReadBufferSize)
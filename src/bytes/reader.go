@@ -36,6 +36,24 @@ func (r *Reader) Len() int {
 // to any other method.
 func (r *Reader) Size() int64 { return int64(len(r.s)) }
 
+// Next returns a slice containing the next n bytes from the reader,
+// advancing the reader as if the bytes had been returned by Read.
+// If there are fewer than n bytes in the reader, Next returns the
+// entire unread portion. The slice refers to the slice the Reader
+// reads from; it is not a copy.
+func (r *Reader) Next(n int) []byte {
+	r.prevRune = -1
+	if m := r.Len(); n > m {
+		n = m
+	}
+	if n <= 0 {
+		return nil
+	}
+	b := r.s[r.i : r.i+int64(n)]
+	r.i += int64(n)
+	return b
+}
+
 // Read implements the io.Reader interface.
 func (r *Reader) Read(b []byte) (n int, err error) {
 	if r.i >= int64(len(r.s)) {
@@ -317,3 +317,26 @@ func TestReaderZero(t *testing.T) {
 		t.Errorf("WriteTo: got %d, %v; want 0, nil", n, err)
 	}
 }
+
+func TestReaderNext(t *testing.T) {
+	b := []byte("0123456789")
+	r := NewReader(b)
+	if got := r.Next(3); string(got) != "012" || &got[0] != &b[0] {
+		t.Errorf("Next(3) = %q; want %q sharing the slice of the Reader", got, "012")
+	}
+	if r.Len() != 7 {
+		t.Errorf("Len after Next = %d; want 7", r.Len())
+	}
+	if r.UnreadRune() == nil {
+		t.Errorf("UnreadRune after Next: got nil, want error")
+	}
+	if got := r.Next(100); string(got) != "3456789" {
+		t.Errorf("Next(100) = %q; want %q", got, "3456789")
+	}
+	if got := r.Next(1); len(got) != 0 {
+		t.Errorf("Next at end = %q; want empty", got)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("Read after Next = %d, %v; want 0, io.EOF", n, err)
+	}
+}
@@ -274,6 +274,11 @@ type ConnectionState struct {
 	// RFC 7627, and https://mitls.org/pages/attacks/3SHAKE#channelbindings.
 	TLSUnique []byte
 
+	// KernelTX and KernelRX report whether the encryption of the
+	// records sent and the decryption of the records received are
+	// offloaded to the kernel. See Config.KernelTLS.
+	KernelTX, KernelRX bool
+
 	// ekm is a closure exposed via ExportKeyingMaterial.
 	ekm func(label string, context []byte, length int) ([]byte, error)
 }
@@ -706,6 +711,22 @@ type Config struct {
 	// The default, none, is correct for the vast majority of applications.
 	Renegotiation RenegotiationSupport
 
+	// KernelTLS enables kernel TLS offload. After the handshake, the
+	// keys of the connection are handed to the operating system, which
+	// then encrypts and decrypts the records itself. Data written with
+	// Conn.ReadFrom from an *os.File is then sent without being copied
+	// into user space. Each direction is offloaded only if the
+	// underlying connection is a TCP connection, the operating system
+	// supports the negotiated version and cipher suite, and, for
+	// reading, no data arrived before the end of the handshake;
+	// otherwise the connection silently keeps encrypting in user space.
+	// Offloaded connections cannot take part in renegotiation or TLS
+	// 1.3 key updates; a key update from the peer fails the connection.
+	//
+	// Kernel TLS is only supported on Linux, for AES-GCM and
+	// ChaCha20-Poly1305 cipher suites in TLS 1.2 and 1.3.
+	KernelTLS bool
+
 	// KeyLogWriter optionally specifies a destination for TLS master secrets
 	// in NSS key log format that can be used to allow external programs
 	// such as Wireshark to decrypt TLS connections.
@@ -802,6 +823,7 @@ func (c *Config) Clone() *Config {
 		CurvePreferences:            c.CurvePreferences,
 		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
 		Renegotiation:               c.Renegotiation,
+		KernelTLS:                   c.KernelTLS,
 		KeyLogWriter:                c.KeyLogWriter,
 		sessionTicketKeys:           c.sessionTicketKeys,
 		autoSessionTicketKeys:       c.autoSessionTicketKeys,
@@ -669,8 +669,8 @@ func (hs *clientHandshakeState) establishKeys() error {
 		serverCipher = hs.suite.aead(serverKey, serverIV)
 	}
 
-	c.in.prepareCipherSpec(c.vers, serverCipher, serverHash)
-	c.out.prepareCipherSpec(c.vers, clientCipher, clientHash)
+	c.in.prepareCipherSpec(c.vers, serverCipher, serverHash, serverKey, serverIV)
+	c.out.prepareCipherSpec(c.vers, clientCipher, clientHash, clientKey, clientIV)
 	return nil
 }
 
@@ -694,8 +694,8 @@ func (hs *serverHandshakeState) establishKeys() error {
 		serverCipher = hs.suite.aead(serverKey, serverIV)
 	}
 
-	c.in.prepareCipherSpec(c.vers, clientCipher, clientHash)
-	c.out.prepareCipherSpec(c.vers, serverCipher, serverHash)
+	c.in.prepareCipherSpec(c.vers, clientCipher, clientHash, clientKey, clientIV)
+	c.out.prepareCipherSpec(c.vers, serverCipher, serverHash, serverKey, serverIV)
 
 	return nil
 }
@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// ReadFrom implements the io.ReaderFrom interface. When the sending
+// side of c is offloaded to the kernel (see Config.KernelTLS), the data
+// is handed to the underlying connection as is, so that the data of an
+// *os.File is sent to a *net.TCPConn with sendfile, encrypted by the
+// kernel. Otherwise ReadFrom copies the data to c with Write.
+func (c *Conn) ReadFrom(r io.Reader) (int64, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	c.out.Lock()
+	kernel := c.out.kernel
+	c.out.Unlock()
+	if !kernel {
+		// Each Write interlocks with Close by itself, so that
+		// a long copy does not keep Close from sending its alert.
+		return io.Copy(writerOnly{c}, r)
+	}
+
+	// interlock with Close, as in Write
+	for {
+		x := atomic.LoadInt32(&c.activeCall)
+		if x&1 != 0 {
+			return 0, net.ErrClosed
+		}
+		if atomic.CompareAndSwapInt32(&c.activeCall, x, x+2) {
+			break
+		}
+	}
+	defer atomic.AddInt32(&c.activeCall, -2)
+
+	c.out.Lock()
+	defer c.out.Unlock()
+
+	if err := c.out.err; err != nil {
+		return 0, err
+	}
+	if c.closeNotifySent {
+		return 0, errShutdown
+	}
+	if _, err := c.flush(); err != nil {
+		return 0, c.out.setErrorLocked(err)
+	}
+	n, err := io.Copy(c.conn, r)
+	c.bytesSent += n
+	return n, err
+}
+
+// writerOnly hides the ReadFrom method of a Writer,
+// so that io.Copy does not call it again.
+type writerOnly struct {
+	io.Writer
+}
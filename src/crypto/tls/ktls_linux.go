@@ -0,0 +1,233 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"internal/syscall/unix"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Kernel TLS constants from linux/tcp.h and linux/tls.h.
+const (
+	tcpULP = 31 // TCP_ULP
+
+	solTLS = 282 // SOL_TLS
+	tlsTX  = 1   // TLS_TX
+	tlsRX  = 2   // TLS_RX
+
+	tlsSetRecordType = 1 // TLS_SET_RECORD_TYPE
+	tlsGetRecordType = 2 // TLS_GET_RECORD_TYPE
+
+	tlsCipherAESGCM128        = 51 // TLS_CIPHER_AES_GCM_128
+	tlsCipherAESGCM256        = 52 // TLS_CIPHER_AES_GCM_256
+	tlsCipherChaCha20Poly1305 = 54 // TLS_CIPHER_CHACHA20_POLY1305
+)
+
+// enableKernelTLS hands the keys of each direction of c to the kernel
+// where it can take them, after a successful handshake. Directions it
+// cannot hand over stay in user space.
+func (c *Conn) enableKernelTLS() {
+	if c.vers < VersionTLS12 || c.vers < VersionTLS13 && c.config.Renegotiation != RenegotiateNever {
+		return
+	}
+	sc, ok := c.conn.(syscall.Conn)
+	if !ok {
+		return
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return
+	}
+
+	c.out.Lock()
+	defer c.out.Unlock()
+
+	tx := kernelCryptoInfo(c.vers, c.cipherSuite, &c.out)
+	var rx []byte
+	// Records received along with the end of the handshake have
+	// already been read from the socket, so the kernel would
+	// not see them.
+	if c.rawInput.Len() == 0 && c.input.Len() == 0 && c.hand.Len() == 0 {
+		rx = kernelCryptoInfo(c.vers, c.cipherSuite, &c.in)
+	}
+	defer func() {
+		for i := range tx {
+			tx[i] = 0
+		}
+		for i := range rx {
+			rx[i] = 0
+		}
+	}()
+	if tx == nil && rx == nil || len(c.sendBuf) > 0 {
+		return
+	}
+
+	rc.Control(func(fd uintptr) {
+		if syscall.SetsockoptString(int(fd), syscall.IPPROTO_TCP, tcpULP, "tls") != nil {
+			return
+		}
+		if tx != nil && unix.Setsockopt(int(fd), solTLS, tlsTX, unsafe.Pointer(&tx[0]), uintptr(len(tx))) == nil {
+			c.out.kernel = true
+		}
+		if rx != nil && unix.Setsockopt(int(fd), solTLS, tlsRX, unsafe.Pointer(&rx[0]), uintptr(len(rx))) == nil {
+			c.in.kernel = true
+		}
+	})
+	if c.out.kernel || c.in.kernel {
+		c.kernelConn = rc
+	}
+	if c.in.kernel {
+		c.kernelIn = make([]byte, maxPlaintext)
+	}
+}
+
+// kernelCryptoInfo returns the tls12_crypto_info structure that sets
+// up the kernel to process the records of hc, or nil if the kernel
+// does not support its cipher suite.
+func kernelCryptoInfo(vers, suite uint16, hc *halfConn) []byte {
+	var cipherType uint16
+	var keyLen int
+	switch suite {
+	case TLS_AES_128_GCM_SHA256, TLS_RSA_WITH_AES_128_GCM_SHA256,
+		TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256:
+		cipherType, keyLen = tlsCipherAESGCM128, 16
+	case TLS_AES_256_GCM_SHA384, TLS_RSA_WITH_AES_256_GCM_SHA384,
+		TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384:
+		cipherType, keyLen = tlsCipherAESGCM256, 32
+	case TLS_CHACHA20_POLY1305_SHA256,
+		TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256:
+		cipherType, keyLen = tlsCipherChaCha20Poly1305, 32
+	default:
+		return nil
+	}
+	if len(hc.key) != keyLen {
+		return nil
+	}
+
+	// The structures for each cipher hold, after the version and
+	// cipher type, the iv, key, salt and rec_seq fields in that order.
+	var iv, salt []byte
+	switch {
+	case cipherType == tlsCipherChaCha20Poly1305:
+		// The nonce is the 12-byte iv XORed with the sequence number.
+		if len(hc.iv) != 12 {
+			return nil
+		}
+		iv = hc.iv
+	case vers == VersionTLS13:
+		// The nonce is the 12-byte iv XORed with the sequence
+		// number; the kernel takes its first 4 bytes as the salt.
+		if len(hc.iv) != 12 {
+			return nil
+		}
+		salt, iv = hc.iv[:4], hc.iv[4:]
+	default:
+		// The nonce is the 4-byte fixed iv followed by an explicit
+		// part, sent with each record, which the kernel counts up
+		// from iv. Go uses the sequence number, and so does this.
+		if len(hc.iv) != 4 {
+			return nil
+		}
+		salt, iv = hc.iv, hc.seq[:]
+	}
+	info := make([]byte, 4, 4+len(iv)+keyLen+len(salt)+len(hc.seq))
+	*(*uint16)(unsafe.Pointer(&info[0])) = vers
+	*(*uint16)(unsafe.Pointer(&info[2])) = cipherType
+	info = append(info, iv...)
+	info = append(info, hc.key...)
+	info = append(info, salt...)
+	info = append(info, hc.seq[:]...)
+	return info
+}
+
+// readKernelRecord reads the plaintext of a record decrypted by the
+// kernel, or of several application data records, into c.kernelIn.
+func (c *Conn) readKernelRecord() (recordType, []byte, error) {
+	var oob [64]byte
+	var n, oobn int
+	var err error
+	rerr := c.kernelConn.Read(func(fd uintptr) bool {
+		n, oobn, _, _, err = syscall.Recvmsg(int(fd), c.kernelIn, oob[:], 0)
+		return err != syscall.EAGAIN
+	})
+	if rerr != nil {
+		err = rerr
+	} else if err != nil {
+		switch err {
+		case syscall.EBADMSG:
+			return 0, nil, c.in.setErrorLocked(c.sendAlert(alertBadRecordMAC))
+		case syscall.EMSGSIZE:
+			return 0, nil, c.in.setErrorLocked(c.sendAlert(alertRecordOverflow))
+		}
+		err = &net.OpError{Op: "read", Net: c.conn.LocalAddr().Network(), Source: c.conn.LocalAddr(), Addr: c.conn.RemoteAddr(), Err: os.NewSyscallError("recvmsg", err)}
+	} else if n == 0 && oobn == 0 {
+		// The peer closed the connection at a record boundary;
+		// see readRecordData.
+		err = io.EOF
+	}
+	if err != nil {
+		if e, ok := err.(net.Error); !ok || !e.Temporary() {
+			c.in.setErrorLocked(err)
+		}
+		return 0, nil, err
+	}
+
+	typ := recordTypeApplicationData
+	if cmsgs, err := syscall.ParseSocketControlMessage(oob[:oobn]); err == nil {
+		for _, m := range cmsgs {
+			if m.Header.Level == solTLS && m.Header.Type == tlsGetRecordType && len(m.Data) >= 1 {
+				typ = recordType(m.Data[0])
+			}
+		}
+	}
+	return typ, c.kernelIn[:n], nil
+}
+
+// writeKernelRecord has the kernel send data as records of type typ.
+func (c *Conn) writeKernelRecord(typ recordType, data []byte) (int, error) {
+	if _, err := c.flush(); err != nil {
+		return 0, err
+	}
+	if typ == recordTypeApplicationData {
+		return c.write(data)
+	}
+
+	// Other records are sent one at a time,
+	// with their type in a control message.
+	oob := make([]byte, syscall.CmsgSpace(1))
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	h.Level = solTLS
+	h.Type = tlsSetRecordType
+	h.SetLen(syscall.CmsgLen(1))
+	oob[syscall.CmsgLen(0)] = byte(typ)
+
+	var n int
+	for len(data) > 0 {
+		m := len(data)
+		if m > maxPlaintext {
+			m = maxPlaintext
+		}
+		var sent int
+		var err error
+		werr := c.kernelConn.Write(func(fd uintptr) bool {
+			sent, err = syscall.SendmsgN(int(fd), data[:m], oob, nil, 0)
+			return err != syscall.EAGAIN
+		})
+		if werr != nil {
+			return n, werr
+		}
+		if err != nil {
+			return n, &net.OpError{Op: "write", Net: c.conn.LocalAddr().Network(), Source: c.conn.LocalAddr(), Addr: c.conn.RemoteAddr(), Err: os.NewSyscallError("sendmsg", err)}
+		}
+		n += sent
+		c.bytesSent += int64(sent)
+		data = data[sent:]
+	}
+	return n, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"internal/syscall/unix"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestKernelTLS(t *testing.T) {
+	want := make([]byte, 1<<20)
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+	name := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(name, want, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		version uint16
+		suite   uint16
+	}{
+		{"TLSv12-AES128GCM", VersionTLS12, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		{"TLSv12-AES256GCM", VersionTLS12, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+		{"TLSv12-ChaCha20Poly1305", VersionTLS12, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256},
+		{"TLSv13", VersionTLS13, 0},
+	}
+	for _, tt := range tests {
+		for _, kernelServer := range []bool{true, false} {
+			side := "-client"
+			if kernelServer {
+				side = "-server"
+			}
+			t.Run(tt.name+side, func(t *testing.T) {
+				testKernelTLS(t, tt.version, tt.suite, kernelServer, name, want)
+			})
+		}
+	}
+}
+
+func testKernelTLS(t *testing.T, version, suite uint16, kernelServer bool, name string, want []byte) {
+	clientConfig := testConfig.Clone()
+	clientConfig.MinVersion = version
+	clientConfig.MaxVersion = version
+	if suite != 0 {
+		clientConfig.CipherSuites = []uint16{suite}
+	}
+	serverConfig := clientConfig.Clone()
+	serverConfig.KernelTLS = kernelServer
+	clientConfig.KernelTLS = !kernelServer
+
+	c, s := localPipe(t)
+	cli := Client(c, clientConfig)
+	srv := Server(s, serverConfig)
+	defer cli.Close()
+	defer srv.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Handshake()
+	}()
+	if err := cli.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	sender, receiver := srv, cli
+	if !kernelServer {
+		sender, receiver = cli, srv
+	}
+	// Without kernel support the connection falls back
+	// to user space, which is tested all the same.
+	state := sender.ConnectionState()
+	t.Logf("KernelTX: %v, KernelRX: %v", state.KernelTX, state.KernelRX)
+	if !state.KernelTX && kernelTXSupported(t, state.Version, state.CipherSuite) {
+		t.Fatal("the kernel supports TLS offload of the connection, but KernelTX is not reported")
+	}
+	if receiver.ConnectionState().KernelTX {
+		t.Fatal("KernelTX reported on a connection without Config.KernelTLS")
+	}
+
+	// Data from the peer is decrypted by the kernel.
+	go func() {
+		_, err := receiver.Write([]byte("hello"))
+		errc <- err
+	}()
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(sender, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("read %q, want %q", b, "hello")
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// A file is sent with sendfile and encrypted by the kernel.
+	go func() {
+		f, err := os.Open(name)
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer f.Close()
+		n, err := sender.ReadFrom(f)
+		if err == nil && n != int64(len(want)) {
+			err = io.ErrShortWrite
+		}
+		if err == nil {
+			_, err = sender.Write([]byte("end"))
+		}
+		if err == nil {
+			err = sender.Close()
+		}
+		errc <- err
+	}()
+	got, err := io.ReadAll(receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, append(want, "end"...)) {
+		t.Errorf("received %d bytes, want %d bytes as sent", len(got), len(want)+3)
+	}
+}
+
+// kernelTXSupported reports whether the kernel takes the sending side
+// of a TCP connection of version vers and cipher suite suite.
+func kernelTXSupported(t *testing.T, vers, suite uint16) bool {
+	c, s := localPipe(t)
+	defer c.Close()
+	defer s.Close()
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var hc halfConn
+	if vers == VersionTLS13 {
+		cs := cipherSuiteTLS13ByID(suite)
+		if cs == nil {
+			return false
+		}
+		hc.key, hc.iv = make([]byte, cs.keyLen), make([]byte, 12)
+	} else {
+		cs := cipherSuiteByID(suite)
+		if cs == nil {
+			return false
+		}
+		hc.key, hc.iv = make([]byte, cs.keyLen), make([]byte, cs.ivLen)
+	}
+	info := kernelCryptoInfo(vers, suite, &hc)
+	if info == nil {
+		return false
+	}
+	supported := false
+	rc.Control(func(fd uintptr) {
+		supported = syscall.SetsockoptString(int(fd), syscall.IPPROTO_TCP, tcpULP, "tls") == nil &&
+			unix.Setsockopt(int(fd), solTLS, tlsTX, unsafe.Pointer(&info[0]), uintptr(len(info))) == nil
+	})
+	return supported
+}
@@ -0,0 +1,22 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package tls
+
+import "errors"
+
+var errKernelTLS = errors.New("tls: kernel TLS not supported")
+
+func (c *Conn) enableKernelTLS() {}
+
+func (c *Conn) readKernelRecord() (recordType, []byte, error) {
+	return 0, nil, errKernelTLS
+}
+
+func (c *Conn) writeKernelRecord(typ recordType, data []byte) (int, error) {
+	return 0, errKernelTLS
+}
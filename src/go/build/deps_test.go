@@ -102,6 +102,7 @@ var depsRules = `
 	< container/heap;
 
 	RUNTIME
+	< internal/iodrain
 	< io;
 
 	syscall !< io;
@@ -162,7 +163,10 @@ var depsRules = `
 	# OS is basic OS access, including helpers (path/filepath, os/exec, etc).
 	# OS includes string routines, but those must be layered above package os.
 	# OS does not include reflection.
-	io/fs
+	RUNTIME
+	< internal/osexec;
+
+	io/fs, internal/osexec
 	< internal/testlog
 	< internal/poll
 	< os
@@ -175,7 +179,8 @@ var depsRules = `
 
 	os/signal, STR
 	< path/filepath
-	< io/ioutil, os/exec;
+	< io/ioutil, os/pty
+	< os/exec;
 
 	io/ioutil, os/exec, os/signal
 	< OS;
@@ -237,6 +242,10 @@ var depsRules = `
 	< hash
 	< hash/adler32, hash/crc32, hash/crc64, hash/fnv, hash/maphash;
 
+	# write-ahead log
+	OS, hash/crc32
+	< os/wal;
+
 	# math/big
 	FMT, encoding/binary, math/rand
 	< math/big;
@@ -410,6 +419,7 @@ var depsRules = `
 	< encoding/asn1
 	< golang.org/x/crypto/cryptobyte/asn1
 	< golang.org/x/crypto/cryptobyte
+	< golang.org/x/crypto/curve25519/internal/field
 	< golang.org/x/crypto/curve25519
 	< crypto/dsa, crypto/elliptic, crypto/rsa
 	< crypto/ecdsa
@@ -419,9 +429,9 @@ var depsRules = `
 
 	# TLS, Prince of Dependencies.
 	CRYPTO-MATH, NET, container/list, encoding/hex, encoding/pem
-	< golang.org/x/crypto/internal/subtle
+	< golang.org/x/crypto/internal/alias
 	< golang.org/x/crypto/chacha20
-	< golang.org/x/crypto/poly1305
+	< golang.org/x/crypto/internal/poly1305
 	< golang.org/x/crypto/chacha20poly1305
 	< golang.org/x/crypto/hkdf
 	< crypto/x509/internal/macos
@@ -472,7 +482,7 @@ var depsRules = `
 	< expvar;
 
 	net/http, net/http/internal/ascii
-	< net/http/cookiejar, net/http/httputil;
+	< net/http/cookiejar, net/http/httputil, net/http/websocket;
 
 	net/http, flag
 	< net/http/httptest;
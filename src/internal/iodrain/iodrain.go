@@ -0,0 +1,34 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package iodrain lets packages that own file descriptors, such as os
+// and net, tell io.Discard how to consume their data in the kernel
+// instead of reading it into a buffer that is thrown away.
+package iodrain
+
+// A Func discards at most remain bytes of data from r.
+//
+// If r is not a type the function knows about, or the data of r cannot
+// be discarded in the kernel, it must return handled == false without
+// having consumed any data. Otherwise it returns the number of bytes
+// discarded; if err is nil and n < remain, r reached EOF.
+type Func func(r interface{}, remain int64) (n int64, handled bool, err error)
+
+var funcs []Func
+
+// Register adds f to the functions consulted by Drain.
+// It must only be called from package initialization.
+func Register(f Func) {
+	funcs = append(funcs, f)
+}
+
+// Drain calls the registered functions until one of them handles r.
+func Drain(r interface{}, remain int64) (n int64, handled bool, err error) {
+	for _, f := range funcs {
+		if n, handled, err = f(r, remain); handled {
+			return n, true, err
+		}
+	}
+	return 0, false, nil
+}
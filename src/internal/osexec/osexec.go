@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package osexec lets package os give package os/exec access to state
+// of an *os.Process that is not part of the API of package os.
+package osexec
+
+// ProcessPidFD returns the pidfd of p, an *os.Process, or -1 if p has
+// none. It is set by package os on Linux, and nil elsewhere.
+var ProcessPidFD func(p interface{}) int
+
+// ProcessBlockUntilWaitable blocks until p, an *os.Process, has exited,
+// without reaping it, and reports whether it did; it reports false at
+// once on systems that cannot wait without reaping. It is set by
+// package os on Unix systems, and nil elsewhere.
+var ProcessBlockUntilWaitable func(p interface{}) bool
@@ -13,6 +13,8 @@ var (
 	PutPipe     = putPipe
 	NewPipe     = newPipe
 	DestroyPipe = destroyPipe
+
+	ZeroCopyOverlap = zeroCopyOverlap
 )
 
 func GetPipeFds(p *SplicePipe) (int, int) {
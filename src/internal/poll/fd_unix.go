@@ -44,6 +44,10 @@ type FD struct {
 
 	// Whether this is a file rather than a network socket.
 	isFile bool
+
+	// Sequence number that the kernel gives the next MSG_ZEROCOPY
+	// send on the socket. Guarded by the write lock.
+	zeroCopySeq uint32
 }
 
 // Init initializes the FD. The Sysfd field should already be set.
@@ -132,6 +136,12 @@ func (fd *FD) SetBlocking() error {
 	return syscall.SetNonblock(fd.Sysfd, false)
 }
 
+// Pollable reports whether fd is registered with the runtime poller,
+// so that deadlines are supported for it.
+func (fd *FD) Pollable() bool {
+	return fd.pd.pollable()
+}
+
 // Darwin and FreeBSD can't read or write 2GB+ files at a time,
 // even on 64-bit systems.
 // The same is true of socket implementations on many systems.
@@ -456,6 +466,15 @@ func (fd *FD) Fchmod(mode uint32) error {
 	})
 }
 
+// Fcntl performs the fcntl system call on fd with an integer argument.
+func (fd *FD) Fcntl(cmd int, arg int) (int, error) {
+	if err := fd.incref(); err != nil {
+		return 0, err
+	}
+	defer fd.decref()
+	return fcntl(fd.Sysfd, cmd, arg)
+}
+
 // Fchdir wraps syscall.Fchdir.
 func (fd *FD) Fchdir() error {
 	if err := fd.incref(); err != nil {
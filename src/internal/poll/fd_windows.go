@@ -399,6 +399,12 @@ func (fd *FD) Close() error {
 	return err
 }
 
+// Pollable reports whether fd is registered with the runtime poller,
+// so that deadlines are supported for it.
+func (fd *FD) Pollable() bool {
+	return fd.pd.pollable()
+}
+
 // Windows ReadFile and WSARecv use DWORD (uint32) parameter to pass buffer length.
 // This prevents us reading blocks larger than 4GB.
 // See golang.org/issue/26923.
@@ -886,6 +892,17 @@ func (fd *FD) FindNextFile(data *syscall.Win32finddata) error {
 	return syscall.FindNextFile(fd.Sysfd, data)
 }
 
+// GetHandleInformation wraps the GetHandleInformation call.
+func (fd *FD) GetHandleInformation() (uint32, error) {
+	if err := fd.incref(); err != nil {
+		return 0, err
+	}
+	defer fd.decref()
+	var flags uint32
+	err := windows.GetHandleInformation(fd.Sysfd, &flags)
+	return flags, err
+}
+
 // Fchmod updates syscall.ByHandleFileInformation.Fileattributes when needed.
 func (fd *FD) Fchmod(mode uint32) error {
 	if err := fd.incref(); err != nil {
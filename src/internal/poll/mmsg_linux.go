@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+)
+
+// RecvMmsg wraps the recvmmsg network call. It returns once at least
+// one message has been received, with the number of messages received.
+func (fd *FD) RecvMmsg(msgs []unix.Mmsghdr, flags int) (int, error) {
+	if err := fd.readLock(); err != nil {
+		return 0, err
+	}
+	defer fd.readUnlock()
+	if err := fd.pd.prepareRead(fd.isFile); err != nil {
+		return 0, err
+	}
+	for {
+		n, err := unix.RecvMmsg(fd.Sysfd, msgs, flags)
+		if err != nil {
+			n = 0
+			if err == syscall.EINTR {
+				continue
+			}
+			if err == syscall.EAGAIN && fd.pd.pollable() {
+				if err = fd.pd.waitRead(fd.isFile); err == nil {
+					continue
+				}
+			}
+		}
+		return n, err
+	}
+}
+
+// SendMmsg wraps the sendmmsg network call. It returns once all the
+// messages have been sent or an error occurs, with the number of
+// messages sent.
+func (fd *FD) SendMmsg(msgs []unix.Mmsghdr, flags int) (int, error) {
+	if err := fd.writeLock(); err != nil {
+		return 0, err
+	}
+	defer fd.writeUnlock()
+	if err := fd.pd.prepareWrite(fd.isFile); err != nil {
+		return 0, err
+	}
+	var nn int
+	for nn < len(msgs) {
+		n, err := unix.SendMmsg(fd.Sysfd, msgs[nn:], flags)
+		if n > 0 {
+			nn += n
+		}
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN && fd.pd.pollable() {
+			if err = fd.pd.waitWrite(fd.isFile); err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return nn, err
+		}
+	}
+	return nn, nil
+}
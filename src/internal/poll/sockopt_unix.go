@@ -17,3 +17,12 @@ func (fd *FD) SetsockoptByte(level, name int, arg byte) error {
 	defer fd.decref()
 	return syscall.SetsockoptByte(fd.Sysfd, level, name, arg)
 }
+
+// GetsockoptInt wraps the getsockopt network call with an int argument.
+func (fd *FD) GetsockoptInt(level, name int) (int, error) {
+	if err := fd.incref(); err != nil {
+		return 0, err
+	}
+	defer fd.decref()
+	return syscall.GetsockoptInt(fd.Sysfd, level, name)
+}
@@ -4,7 +4,10 @@
 
 package poll
 
-import "syscall"
+import (
+	"syscall"
+	"unsafe"
+)
 
 // Setsockopt wraps the setsockopt network call.
 func (fd *FD) Setsockopt(level, optname int32, optval *byte, optlen int32) error {
@@ -15,6 +18,18 @@ func (fd *FD) Setsockopt(level, optname int32, optval *byte, optlen int32) error
 	return syscall.Setsockopt(fd.Sysfd, level, optname, optval, optlen)
 }
 
+// GetsockoptInt wraps the getsockopt network call with an int argument.
+func (fd *FD) GetsockoptInt(level, name int) (int, error) {
+	if err := fd.incref(); err != nil {
+		return 0, err
+	}
+	defer fd.decref()
+	var v int32
+	l := int32(4)
+	err := syscall.Getsockopt(fd.Sysfd, int32(level), int32(name), (*byte)(unsafe.Pointer(&v)), &l)
+	return int(v), err
+}
+
 // WSAIoctl wraps the WSAIoctl network call.
 func (fd *FD) WSAIoctl(iocc uint32, inbuf *byte, cbif uint32, outbuf *byte, cbob uint32, cbbr *uint32, overlapped *syscall.Overlapped, completionRoutine uintptr) error {
 	if err := fd.incref(); err != nil {
@@ -6,6 +6,7 @@ package poll
 
 import (
 	"internal/syscall/unix"
+	"io"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -28,7 +29,9 @@ const (
 // Splice gets a pipe buffer from the pool or creates a new one if needed, to serve as a buffer for the data transfer.
 // src and dst must both be stream-oriented sockets.
 //
-// If err != nil, sc is the system call which caused the error.
+// If err != nil, sc is the system call which caused the error. Errors
+// from splice itself are wrapped in an *io.CopyError telling whether
+// reading from src or writing to dst failed.
 func Splice(dst, src *FD, remain int64) (written int64, handled bool, sc string, err error) {
 	p, sc, err := getPipe()
 	if err != nil {
@@ -36,11 +39,13 @@ func Splice(dst, src *FD, remain int64) (written int64, handled bool, sc string,
 	}
 	defer putPipe(p)
 	var inPipe, n int
+	op := "read"
 	for err == nil && remain > 0 {
 		max := maxSpliceSize
 		if int64(max) > remain {
 			max = int(remain)
 		}
+		op = "read"
 		inPipe, err = spliceDrain(p.wfd, src, max)
 		// The operation is considered handled if splice returns no
 		// error, or an error other than EINVAL. An EINVAL means the
@@ -59,6 +64,7 @@ func Splice(dst, src *FD, remain int64) (written int64, handled bool, sc string,
 		}
 		p.data += inPipe
 
+		op = "write"
 		n, err = splicePump(dst, p.rfd, inPipe)
 		if n > 0 {
 			written += int64(n)
@@ -67,11 +73,67 @@ func Splice(dst, src *FD, remain int64) (written int64, handled bool, sc string,
 		}
 	}
 	if err != nil {
-		return written, handled, "splice", err
+		return written, handled, "splice", &io.CopyError{Op: op, Err: err}
 	}
 	return written, true, "", nil
 }
 
+// devNull is the /dev/null descriptor SpliceDiscard splices data into.
+// fd must be first so that its 64-bit atomic state is 64-bit aligned
+// on 32-bit systems.
+var devNull struct {
+	fd   FD
+	once sync.Once
+	err  error
+}
+
+// SpliceDiscard discards at most remain bytes of data from src by
+// splicing them into /dev/null, so that the data is never copied to
+// userspace. src may be a socket, a pipe or a file.
+//
+// If handled == false, SpliceDiscard has performed no work.
+func SpliceDiscard(src *FD, remain int64) (written int64, handled bool, sc string, err error) {
+	devNull.once.Do(func() {
+		fd, err := syscall.Open("/dev/null", syscall.O_WRONLY|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			devNull.err = err
+			return
+		}
+		devNull.fd = FD{Sysfd: fd, IsStream: true}
+		devNull.err = devNull.fd.Init("file", false)
+	})
+	if devNull.err != nil {
+		return 0, false, "", nil
+	}
+	written, handled, sc, err = Splice(&devNull.fd, src, remain)
+	if ce, ok := err.(*io.CopyError); ok {
+		err = ce.Err
+	}
+	return written, handled, sc, err
+}
+
+// SpliceToFile transfers at most remain bytes of data from src to the
+// file dst, at its current offset, using the splice system call to
+// minimize copies of data from and to userspace. The caller must hold
+// the write lock of dst, if it has one.
+//
+// If handled == false, SpliceToFile has performed no work: dst is not
+// a regular file, or it was opened with O_APPEND, which splice(2) does
+// not support.
+func SpliceToFile(dst int, src *FD, remain int64) (written int64, handled bool, sc string, err error) {
+	var st syscall.Stat_t
+	if err := syscall.Fstat(dst, &st); err != nil || st.Mode&syscall.S_IFMT != syscall.S_IFREG {
+		return 0, false, "", nil
+	}
+	if flags, err := fcntl(dst, syscall.F_GETFL, 0); err != nil || flags&syscall.O_APPEND != 0 {
+		return 0, false, "", nil
+	}
+	// Writes to a regular file never block, so the FD Splice
+	// writes to needs no poller, and is not closed.
+	fd := FD{Sysfd: dst, IsStream: true, isFile: true}
+	return Splice(&fd, src, remain)
+}
+
 // spliceDrain moves data from a socket to a pipe.
 //
 // Invariant: when entering spliceDrain, the pipe is empty. It is either in its
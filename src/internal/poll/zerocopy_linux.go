@@ -0,0 +1,178 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll
+
+import (
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// msgZerocopy is MSG_ZEROCOPY, which asks send(2) to transmit
+	// from the caller's pages instead of from a copy.
+	msgZerocopy = 0x4000000
+
+	// soEEOriginZerocopy is SO_EE_ORIGIN_ZEROCOPY, the origin of
+	// the notifications that zerocopy sends have completed.
+	soEEOriginZerocopy = 5
+)
+
+// sockExtendedErr is struct sock_extended_err from linux/errqueue.h.
+type sockExtendedErr struct {
+	Errno  uint32
+	Origin uint8
+	Type   uint8
+	Code   uint8
+	Pad    uint8
+	Info   uint32
+	Data   uint32
+}
+
+// WriteZeroCopy is like Write, but it sends p with MSG_ZEROCOPY, so the
+// kernel transmits from the pages of p directly. The socket must have
+// SO_ZEROCOPY set. Because the kernel keeps using p after the system call,
+// WriteZeroCopy does not return until the kernel reports on the error
+// queue of the socket that it is done with every part of p. If that wait
+// fails, for example because the write deadline passes, the kernel may
+// still read from p.
+//
+// If the kernel runs out of memory to pin the pages of p, WriteZeroCopy
+// writes the rest of p as Write does.
+func (fd *FD) WriteZeroCopy(p []byte) (int, error) {
+	if err := fd.writeLock(); err != nil {
+		return 0, err
+	}
+	defer fd.writeUnlock()
+	if err := fd.pd.prepareWrite(fd.isFile); err != nil {
+		return 0, err
+	}
+	var (
+		nn       int
+		first    = fd.zeroCopySeq // sequence number of our first zerocopy send
+		copyRest bool
+		err      error
+	)
+	for nn < len(p) {
+		max := len(p)
+		if max-nn > maxRW {
+			max = nn + maxRW
+		}
+		var n int
+		if copyRest {
+			n, err = ignoringEINTRIO(syscall.Write, fd.Sysfd, p[nn:max])
+		} else {
+			n, err = ignoringEINTRIO(sendZeroCopy, fd.Sysfd, p[nn:max])
+			if n > 0 {
+				// The kernel numbers the zerocopy sends
+				// of each socket in order, from zero.
+				fd.zeroCopySeq++
+			}
+		}
+		if n > 0 {
+			nn += n
+			continue
+		}
+		if err == syscall.EAGAIN && fd.pd.pollable() {
+			if err = fd.pd.waitWrite(fd.isFile); err == nil {
+				continue
+			}
+		}
+		if err == syscall.ENOBUFS && !copyRest {
+			// The socket has used up its allowance of pinned memory.
+			copyRest = true
+			continue
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		break
+	}
+	if pending := fd.zeroCopySeq - first; pending > 0 {
+		if werr := fd.waitZeroCopy(first, pending); err == nil {
+			err = werr
+		}
+	}
+	return nn, err
+}
+
+func sendZeroCopy(fd int, p []byte) (int, error) {
+	return syscall.SendmsgN(fd, p, nil, nil, msgZerocopy)
+}
+
+// waitZeroCopy reads notifications from the error queue of the socket
+// until the n zerocopy sends numbered from first have completed.
+// Notifications for earlier sends, whose wait failed, are skipped.
+func (fd *FD) waitZeroCopy(first, n uint32) error {
+	var oob [128]byte
+	total := n
+	for n > 0 {
+		_, oobn, _, _, err := syscall.Recvmsg(fd.Sysfd, nil, oob[:], syscall.MSG_ERRQUEUE)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN && fd.pd.pollable() {
+			// A non-empty error queue makes the poller
+			// report the socket as ready for writing.
+			if err = fd.pd.waitWrite(fd.isFile); err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if !(m.Header.Level == syscall.SOL_IP && m.Header.Type == syscall.IP_RECVERR ||
+				m.Header.Level == syscall.SOL_IPV6 && m.Header.Type == syscall.IPV6_RECVERR) {
+				continue
+			}
+			var ee sockExtendedErr
+			if len(m.Data) < int(unsafe.Sizeof(ee)) {
+				continue
+			}
+			// m.Data need not be aligned for ee.
+			copy((*[unsafe.Sizeof(ee)]byte)(unsafe.Pointer(&ee))[:], m.Data)
+			if ee.Origin != soEEOriginZerocopy {
+				if ee.Errno != 0 {
+					return syscall.Errno(ee.Errno)
+				}
+				continue
+			}
+			// Info and Data are the sequence numbers of the
+			// first and the last of a range of completed
+			// sends. Count the part of the range that falls in
+			// ours; the arithmetic is modulo 2^32, like the
+			// kernel's counter.
+			done := zeroCopyOverlap(ee.Info-first, ee.Data-first, total)
+			if done > n {
+				done = n
+			}
+			n -= done
+		}
+	}
+	return nil
+}
+
+// zeroCopyOverlap returns how many of the sequence numbers lo through hi
+// are in [0, end). All three are relative to the first sequence number
+// of the current write, so a range that starts before it has a lo that
+// wrapped around to a value of at least end.
+func zeroCopyOverlap(lo, hi, end uint32) uint32 {
+	if lo >= end {
+		if hi >= lo {
+			// The range ends before ours starts.
+			return 0
+		}
+		lo = 0
+	}
+	if hi >= end {
+		hi = end - 1
+	}
+	return hi - lo + 1
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poll_test
+
+import (
+	"internal/poll"
+	"testing"
+)
+
+func TestZeroCopyOverlap(t *testing.T) {
+	const max = 1<<32 - 1
+	for _, tt := range []struct {
+		lo, hi, end uint32
+		want        uint32
+	}{
+		{0, 0, 1, 1},
+		{0, 2, 3, 3},
+		{1, 2, 3, 2},
+		{0, 5, 3, 3},
+		{max - 1, max, 3, 0}, // an earlier write
+		{max - 1, 1, 3, 2},   // an earlier write and ours
+		{max - 1, 4, 3, 3},
+		{3, 4, 3, 0},
+	} {
+		if got := poll.ZeroCopyOverlap(tt.lo, tt.hi, tt.end); got != tt.want {
+			t.Errorf("ZeroCopyOverlap(%d, %d, %d) = %d; want %d", tt.lo, tt.hi, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package unix
+
+import "unsafe"
+
+// Getsockopt reads the value of the socket option name at level of
+// the socket s into the *vallen bytes at val, setting *vallen to the
+// length of the value. It is for options whose values are structures,
+// which package syscall has no general way to read.
+func Getsockopt(s, level, name int, val unsafe.Pointer, vallen *uint32) error {
+	return getsockopt(s, level, name, val, vallen)
+}
+
+//go:linkname getsockopt syscall.getsockopt
+func getsockopt(s int, level int, name int, val unsafe.Pointer, vallen *uint32) error
@@ -0,0 +1,55 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// landlockRulePathBeneath is LANDLOCK_RULE_PATH_BENEATH.
+const landlockRulePathBeneath = 1
+
+// oPath is O_PATH, whose value is the same on all Linux ports.
+const oPath = 0x200000
+
+// landlockRulesetAttr is struct landlock_ruleset_attr.
+type landlockRulesetAttr struct {
+	handledAccessFS uint64
+}
+
+// landlockPathBeneathAttr is struct landlock_path_beneath_attr.
+// The C struct is packed, so only its first 12 bytes are read.
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFD      int32
+}
+
+// LandlockCreateRuleset returns a new Landlock ruleset handling the
+// file system access rights in handled. The descriptor is close-on-exec.
+func LandlockCreateRuleset(handled uint64) (int, error) {
+	attr := landlockRulesetAttr{handledAccessFS: handled}
+	r1, _, errno := syscall.Syscall(landlockCreateRulesetTrap, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(r1), nil
+}
+
+// LandlockAllowPath adds a rule to the ruleset rulesetFD allowing the
+// access rights in allowed to the file hierarchy at path.
+func LandlockAllowPath(rulesetFD int, path string, allowed uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	attr := landlockPathBeneathAttr{allowedAccess: allowed, parentFD: int32(fd)}
+	_, _, errno := syscall.Syscall6(landlockAddRuleTrap, uintptr(rulesetFD), landlockRulePathBeneath, uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Mmsghdr is struct mmsghdr from sys/socket.h, one message
+// of a recvmmsg or sendmmsg system call.
+type Mmsghdr struct {
+	Hdr syscall.Msghdr
+	Len uint32
+}
+
+// SetIovlen sets the number of entries of the message's iovec array.
+func (h *Mmsghdr) SetIovlen(n int) {
+	// Iovlen is a size_t, which is the size of a uintptr.
+	*(*uintptr)(unsafe.Pointer(&h.Hdr.Iovlen)) = uintptr(n)
+}
+
+func RecvMmsg(fd int, msgs []Mmsghdr, flags int) (n int, err error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	r1, _, errno := syscall.Syscall6(recvmmsgTrap,
+		uintptr(fd),
+		uintptr(unsafe.Pointer(&msgs[0])),
+		uintptr(len(msgs)),
+		uintptr(flags),
+		0, // no timeout
+		0,
+	)
+	n = int(r1)
+	if errno != 0 {
+		err = errno
+	}
+	return
+}
+
+func SendMmsg(fd int, msgs []Mmsghdr, flags int) (n int, err error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+	r1, _, errno := syscall.Syscall6(sendmmsgTrap,
+		uintptr(fd),
+		uintptr(unsafe.Pointer(&msgs[0])),
+		uintptr(len(msgs)),
+		uintptr(flags),
+		0,
+		0,
+	)
+	n = int(r1)
+	if errno != 0 {
+		err = errno
+	}
+	return
+}
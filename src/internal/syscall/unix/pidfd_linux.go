@@ -0,0 +1,28 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import "syscall"
+
+// PidFDOpen returns a pidfd referring to the process pid. It is only
+// guaranteed to refer to the intended process if that process is a
+// child of the caller that has not been waited for.
+func PidFDOpen(pid int, flags int) (int, error) {
+	r1, _, errno := syscall.Syscall(pidfdOpenTrap, uintptr(pid), uintptr(flags), 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(r1), nil
+}
+
+// PidFDSendSignal sends the signal sig to the process referred to by
+// pidfd.
+func PidFDSendSignal(pidfd int, sig syscall.Signal) error {
+	_, _, errno := syscall.Syscall6(pidfdSendSignalTrap, uintptr(pidfd), uintptr(sig), 0, 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
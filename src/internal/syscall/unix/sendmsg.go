@@ -0,0 +1,22 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package unix
+
+import (
+	"syscall"
+	_ "unsafe" // for linkname
+)
+
+// SendmsgBuffers is like syscall.SendmsgN, but gathers the data to
+// send from the buffers in bufs, with a single sendmsg system call.
+func SendmsgBuffers(fd int, bufs [][]byte, oob []byte, to syscall.Sockaddr, flags int) (n int, err error) {
+	return sendmsgBuffers(fd, bufs, oob, to, flags)
+}
+
+//go:linkname sendmsgBuffers syscall.sendmsgBuffers
+func sendmsgBuffers(fd int, bufs [][]byte, oob []byte, to syscall.Sockaddr, flags int) (n int, err error)
@@ -0,0 +1,20 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || freebsd || linux
+// +build darwin freebsd linux
+
+package unix
+
+import "unsafe"
+
+// Setsockopt sets the socket option name at level of the socket s
+// to the vallen bytes at val. It is for options whose values are
+// structures, which package syscall has no general way to set.
+func Setsockopt(s, level, name int, val unsafe.Pointer, vallen uintptr) error {
+	return setsockopt(s, level, name, val, vallen)
+}
+
+//go:linkname setsockopt syscall.setsockopt
+func setsockopt(s int, level int, name int, val unsafe.Pointer, vallen uintptr) error
@@ -5,6 +5,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 355
-	copyFileRangeTrap uintptr = 377
+	getrandomTrap             uintptr = 355
+	copyFileRangeTrap         uintptr = 377
+	recvmmsgTrap              uintptr = 337
+	sendmmsgTrap              uintptr = 345
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
@@ -5,6 +5,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 318
-	copyFileRangeTrap uintptr = 326
+	getrandomTrap             uintptr = 318
+	copyFileRangeTrap         uintptr = 326
+	recvmmsgTrap              uintptr = 299
+	sendmmsgTrap              uintptr = 307
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
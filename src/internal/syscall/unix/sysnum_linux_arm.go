@@ -5,6 +5,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 384
-	copyFileRangeTrap uintptr = 391
+	getrandomTrap             uintptr = 384
+	copyFileRangeTrap         uintptr = 391
+	recvmmsgTrap              uintptr = 365
+	sendmmsgTrap              uintptr = 374
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
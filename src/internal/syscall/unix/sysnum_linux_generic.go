@@ -13,6 +13,12 @@ package unix
 // means only arm64 and riscv64 use the standard numbers.
 
 const (
-	getrandomTrap     uintptr = 278
-	copyFileRangeTrap uintptr = 285
+	getrandomTrap             uintptr = 278
+	copyFileRangeTrap         uintptr = 285
+	recvmmsgTrap              uintptr = 243
+	sendmmsgTrap              uintptr = 269
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
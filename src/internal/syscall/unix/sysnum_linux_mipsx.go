@@ -8,6 +8,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 4353
-	copyFileRangeTrap uintptr = 4360
+	getrandomTrap             uintptr = 4353
+	copyFileRangeTrap         uintptr = 4360
+	recvmmsgTrap              uintptr = 4335
+	sendmmsgTrap              uintptr = 4343
+	pidfdSendSignalTrap       uintptr = 4424
+	pidfdOpenTrap             uintptr = 4434
+	landlockCreateRulesetTrap uintptr = 4444
+	landlockAddRuleTrap       uintptr = 4445
 )
@@ -8,6 +8,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 359
-	copyFileRangeTrap uintptr = 379
+	getrandomTrap             uintptr = 359
+	copyFileRangeTrap         uintptr = 379
+	recvmmsgTrap              uintptr = 343
+	sendmmsgTrap              uintptr = 349
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
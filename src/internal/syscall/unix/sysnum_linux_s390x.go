@@ -5,6 +5,12 @@
 package unix
 
 const (
-	getrandomTrap     uintptr = 349
-	copyFileRangeTrap uintptr = 375
+	getrandomTrap             uintptr = 349
+	copyFileRangeTrap         uintptr = 375
+	recvmmsgTrap              uintptr = 357
+	sendmmsgTrap              uintptr = 358
+	pidfdSendSignalTrap       uintptr = 424
+	pidfdOpenTrap             uintptr = 434
+	landlockCreateRulesetTrap uintptr = 444
+	landlockAddRuleTrap       uintptr = 445
 )
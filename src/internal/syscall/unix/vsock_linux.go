@@ -0,0 +1,29 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"syscall"
+	_ "unsafe" // for go:linkname
+)
+
+// NewSockaddrVM returns an AF_VSOCK socket address for the
+// context ID cid and port. Package syscall is frozen and does not
+// export a type for it.
+func NewSockaddrVM(cid, port uint32) syscall.Sockaddr {
+	return newSockaddrVM(cid, port)
+}
+
+// SockaddrVMAddr reports the context ID and port of sa, and
+// whether sa is an AF_VSOCK socket address.
+func SockaddrVMAddr(sa syscall.Sockaddr) (cid, port uint32, ok bool) {
+	return sockaddrVMAddr(sa)
+}
+
+//go:linkname newSockaddrVM syscall.newSockaddrVM
+func newSockaddrVM(cid, port uint32) syscall.Sockaddr
+
+//go:linkname sockaddrVMAddr syscall.sockaddrVMAddr
+func sockaddrVMAddr(sa syscall.Sockaddr) (cid, port uint32, ok bool)
@@ -125,4 +125,23 @@ type UserInfo4 struct {
 	PasswordExpired uint32
 }
 
+// The filter of NetUserEnum for the accounts of ordinary users.
+const FILTER_NORMAL_ACCOUNT = 0x2
+
+// Errors of the lookups of accounts that do not exist.
+const (
+	ERROR_NONE_MAPPED syscall.Errno = 1332
+	NERR_UserNotFound syscall.Errno = 2221
+)
+
+type UserInfo0 struct {
+	Name *uint16
+}
+
+type LocalGroupInfo0 struct {
+	Name *uint16
+}
+
+//sys	NetUserEnum(serverName *uint16, level uint32, filter uint32, buf **byte, prefMaxLen uint32, entriesRead *uint32, totalEntries *uint32, resumeHandle *uint32) (neterr error) = netapi32.NetUserEnum
+//sys	NetLocalGroupEnum(serverName *uint16, level uint32, buf **byte, prefMaxLen uint32, entriesRead *uint32, totalEntries *uint32, resumeHandle *uintptr) (neterr error) = netapi32.NetLocalGroupEnum
 //sys	NetUserGetLocalGroups(serverName *uint16, userName *uint16, level uint32, flags uint32, buf **byte, prefMaxLen uint32, entriesRead *uint32, totalEntries *uint32) (neterr error) = netapi32.NetUserGetLocalGroups
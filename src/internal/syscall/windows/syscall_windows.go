@@ -139,6 +139,15 @@ type FILE_BASIC_INFO struct {
 	FileAttributes uint32
 }
 
+// Notification types of the callbacks of NotifyIpInterfaceChange and
+// NotifyUnicastIpAddressChange; see MIB_NOTIFICATION_TYPE.
+const (
+	MibParameterNotification = 0
+	MibAddInstance           = 1
+	MibDeleteInstance        = 2
+	MibInitialNotification   = 3
+)
+
 const (
 	IfOperStatusUp             = 1
 	IfOperStatusDown           = 2
@@ -150,6 +159,9 @@ const (
 )
 
 //sys	GetAdaptersAddresses(family uint32, flags uint32, reserved uintptr, adapterAddresses *IpAdapterAddresses, sizePointer *uint32) (errcode error) = iphlpapi.GetAdaptersAddresses
+//sys	NotifyIpInterfaceChange(family uint16, callback uintptr, callerContext uintptr, initialNotification bool, handle *syscall.Handle) (errcode error) = iphlpapi.NotifyIpInterfaceChange
+//sys	NotifyUnicastIpAddressChange(family uint16, callback uintptr, callerContext uintptr, initialNotification bool, handle *syscall.Handle) (errcode error) = iphlpapi.NotifyUnicastIpAddressChange
+//sys	CancelMibChangeNotify2(handle syscall.Handle) (errcode error) = iphlpapi.CancelMibChangeNotify2
 //sys	GetComputerNameEx(nameformat uint32, buf *uint16, n *uint32) (err error) = GetComputerNameExW
 //sys	MoveFileEx(from *uint16, to *uint16, flags uint32) (err error) = MoveFileExW
 //sys	GetModuleFileName(module syscall.Handle, fn *uint16, len uint32) (n uint32, err error) = kernel32.GetModuleFileNameW
@@ -295,12 +307,19 @@ func Rename(oldpath, newpath string) error {
 
 //sys LockFileEx(file syscall.Handle, flags uint32, reserved uint32, bytesLow uint32, bytesHigh uint32, overlapped *syscall.Overlapped) (err error) = kernel32.LockFileEx
 //sys UnlockFileEx(file syscall.Handle, reserved uint32, bytesLow uint32, bytesHigh uint32, overlapped *syscall.Overlapped) (err error) = kernel32.UnlockFileEx
+//sys GetHandleInformation(handle syscall.Handle, flags *uint32) (err error) = kernel32.GetHandleInformation
 
 const (
 	LOCKFILE_FAIL_IMMEDIATELY = 0x00000001
 	LOCKFILE_EXCLUSIVE_LOCK   = 0x00000002
 )
 
+const (
+	PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
+
+	STILL_ACTIVE = 259
+)
+
 const MB_ERR_INVALID_CHARS = 8
 
 //sys	GetACP() (acp uint32) = kernel32.GetACP
@@ -344,3 +363,82 @@ func LoadGetFinalPathNameByHandle() error {
 //sys	DestroyEnvironmentBlock(block *uint16) (err error) = userenv.DestroyEnvironmentBlock
 
 //sys	RtlGenRandom(buf []byte) (err error) = advapi32.SystemFunction036
+
+const (
+	PROCESS_SET_QUOTA      = 0x0100
+	PROCESS_SUSPEND_RESUME = 0x0800
+
+	CREATE_SUSPENDED = 0x00000004
+)
+
+//sys	CreateJobObject(jobAttrs *syscall.SecurityAttributes, name *uint16) (job syscall.Handle, err error) [failretval==0] = kernel32.CreateJobObjectW
+//sys	AssignProcessToJobObject(job syscall.Handle, process syscall.Handle) (err error) = kernel32.AssignProcessToJobObject
+//sys	TerminateJobObject(job syscall.Handle, exitCode uint32) (err error) = kernel32.TerminateJobObject
+//sys	GenerateConsoleCtrlEvent(ctrlEvent uint32, processGroupID uint32) (err error) = kernel32.GenerateConsoleCtrlEvent
+
+// NTStatus is the status code that native system calls, such as those
+// of ntdll.dll, return instead of setting the last error.
+type NTStatus uint32
+
+func (s NTStatus) Error() string {
+	const hex = "0123456789abcdef"
+	b := []byte("NTSTATUS 0x00000000")
+	for i := len(b) - 1; s != 0; i-- {
+		b[i] = hex[s&0xf]
+		s >>= 4
+	}
+	return string(b)
+}
+
+// NtResumeProcess resumes all the threads of a process created with
+// CREATE_SUSPENDED.
+//sys	NtResumeProcess(process syscall.Handle) (ntstatus error) = ntdll.NtResumeProcess
+
+const (
+	JobObjectBasicProcessIdList       = 3
+	JobObjectExtendedLimitInformation = 9
+
+	JOB_OBJECT_LIMIT_PROCESS_TIME      = 0x00000002
+	JOB_OBJECT_LIMIT_PROCESS_MEMORY    = 0x00000100
+	JOB_OBJECT_LIMIT_BREAKAWAY_OK      = 0x00000800
+	JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE = 0x00002000
+)
+
+// JOBOBJECT_BASIC_PROCESS_ID_LIST is followed in memory by the rest of
+// the NumberOfProcessIdsInList process ids.
+type JOBOBJECT_BASIC_PROCESS_ID_LIST struct {
+	NumberOfAssignedProcesses uint32
+	NumberOfProcessIdsInList  uint32
+	ProcessIdList             [1]uintptr
+}
+
+type IO_COUNTERS struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type JOBOBJECT_EXTENDED_LIMIT_INFORMATION struct {
+	BasicLimitInformation JOBOBJECT_BASIC_LIMIT_INFORMATION
+	IoInfo                IO_COUNTERS
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+//sys	SetInformationJobObject(job syscall.Handle, infoClass uint32, info unsafe.Pointer, infoLen uint32) (err error) = kernel32.SetInformationJobObject
+//sys	QueryInformationJobObject(job syscall.Handle, infoClass uint32, info unsafe.Pointer, infoLen uint32, returnLen *uint32) (err error) = kernel32.QueryInformationJobObject
+
+//sys	CreatePseudoConsole(size uint32, in syscall.Handle, out syscall.Handle, flags uint32, pconsole *syscall.Handle) (hr error) = kernel32.CreatePseudoConsole
+//sys	ResizePseudoConsole(pconsole syscall.Handle, size uint32) (hr error) = kernel32.ResizePseudoConsole
+//sys	ClosePseudoConsole(console syscall.Handle) = kernel32.ClosePseudoConsole
+
+// LoadPseudoConsole returns an error if the pseudo console functions,
+// which were added in Windows 10 version 1809, are not available.
+func LoadPseudoConsole() error {
+	return procCreatePseudoConsole.Find()
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package io
+
+import "sync/atomic"
+
+// A CountingReader reads from an underlying Reader and counts the bytes
+// read. Count may be called concurrently with reads.
+//
+// CountingReader implements WriterTo by copying from the underlying
+// Reader, so wrapping a source for metrics does not disable the fast
+// paths Copy would otherwise use, such as sendfile or splice.
+// Bytes transferred that way are counted when the copy returns.
+type CountingReader struct {
+	n int64 // accessed atomically; first for 64-bit alignment
+	r Reader
+}
+
+// NewCountingReader returns a CountingReader that reads from r.
+func NewCountingReader(r Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+func (c *CountingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return
+}
+
+// WriteTo implements WriterTo by copying from the underlying Reader to w.
+func (c *CountingReader) WriteTo(w Writer) (n int64, err error) {
+	n, err = Copy(w, c.r)
+	atomic.AddInt64(&c.n, n)
+	return
+}
+
+// Count returns the number of bytes read so far.
+func (c *CountingReader) Count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// A CountingWriter writes to an underlying Writer and counts the bytes
+// written. Count may be called concurrently with writes.
+//
+// CountingWriter implements ReaderFrom by copying to the underlying
+// Writer, so wrapping a destination for metrics does not disable the
+// fast paths Copy would otherwise use, such as sendfile or splice.
+// Bytes transferred that way are counted when the copy returns.
+type CountingWriter struct {
+	n int64 // accessed atomically; first for 64-bit alignment
+	w Writer
+}
+
+// NewCountingWriter returns a CountingWriter that writes to w.
+func NewCountingWriter(w Writer) *CountingWriter {
+	return &CountingWriter{w: w}
+}
+
+func (c *CountingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return
+}
+
+// WriteString writes s to the underlying Writer, using its WriteString
+// method if it has one.
+func (c *CountingWriter) WriteString(s string) (n int, err error) {
+	n, err = WriteString(c.w, s)
+	atomic.AddInt64(&c.n, int64(n))
+	return
+}
+
+// ReadFrom implements ReaderFrom by copying from r to the underlying Writer.
+func (c *CountingWriter) ReadFrom(r Reader) (n int64, err error) {
+	n, err = Copy(c.w, r)
+	atomic.AddInt64(&c.n, n)
+	return
+}
+
+// Count returns the number of bytes written so far.
+func (c *CountingWriter) Count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package io_test
+
+import (
+	"bytes"
+	. "io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCountingReader(t *testing.T) {
+	cr := NewCountingReader(strings.NewReader("hello, world"))
+	buf := make([]byte, 5)
+	if _, err := ReadFull(cr, buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := cr.Count(); n != 5 {
+		t.Fatalf("Count after Read = %d; want 5", n)
+	}
+	var sb strings.Builder
+	if _, err := Copy(&sb, cr); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != ", world" {
+		t.Errorf("copied %q; want %q", got, ", world")
+	}
+	if n := cr.Count(); n != 12 {
+		t.Errorf("Count after Copy = %d; want 12", n)
+	}
+}
+
+// readFromRecorder records whether its ReadFrom method was used.
+type readFromRecorder struct {
+	bytes.Buffer
+	usedReadFrom bool
+}
+
+func (w *readFromRecorder) ReadFrom(r Reader) (int64, error) {
+	w.usedReadFrom = true
+	return w.Buffer.ReadFrom(r)
+}
+
+func TestCountingPassthrough(t *testing.T) {
+	// The reader is not a WriterTo, so Copy must reach the
+	// destination's ReadFrom through both counting wrappers.
+	src := struct{ Reader }{strings.NewReader("passthrough")}
+	cr := NewCountingReader(src)
+	dst := new(readFromRecorder)
+	cw := NewCountingWriter(dst)
+	n, err := Copy(cw, cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 11 || cr.Count() != 11 || cw.Count() != 11 {
+		t.Errorf("Copy = %d, reader Count = %d, writer Count = %d; want 11", n, cr.Count(), cw.Count())
+	}
+	if !dst.usedReadFrom {
+		t.Error("destination ReadFrom was not used")
+	}
+	if got := dst.String(); got != "passthrough" {
+		t.Errorf("copied %q; want %q", got, "passthrough")
+	}
+}
+
+func TestCountingWriterConcurrentCount(t *testing.T) {
+	cw := NewCountingWriter(Discard)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			cw.Write([]byte("x"))
+			WriteString(cw, "yz")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		cw.Count()
+	}
+	wg.Wait()
+	if n := cw.Count(); n != 3000 {
+		t.Errorf("Count = %d; want 3000", n)
+	}
+}
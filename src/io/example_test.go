@@ -11,6 +11,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 func ExampleCopy() {
@@ -24,6 +25,24 @@ func ExampleCopy() {
 	// some io.Reader stream to be read
 }
 
+func ExamplePipe_timeout() {
+	r, w := io.Pipe()
+
+	// Nothing is ever written, so give up on the read after a while.
+	t := time.AfterFunc(10*time.Millisecond, func() {
+		w.CloseWithError(os.ErrDeadlineExceeded)
+	})
+	defer t.Stop()
+
+	buf := make([]byte, 64)
+	if _, err := r.Read(buf); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// i/o timeout
+}
+
 func ExampleCopyBuffer() {
 	r1 := strings.NewReader("first reader\n")
 	r2 := strings.NewReader("second reader\n")
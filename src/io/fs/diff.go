@@ -0,0 +1,199 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"internal/itoa"
+	"io"
+	"path"
+)
+
+// A DiffKind says how a file differs between two file systems.
+type DiffKind int
+
+const (
+	DiffOnlyInA DiffKind = iota // the file exists only in the first file system
+	DiffOnlyInB                 // the file exists only in the second file system
+	DiffType                    // the file has different types, such as file and directory
+	DiffMode                    // the file has different mode bits
+	DiffSize                    // the regular file has different sizes
+	DiffContent                 // the regular file has the same size but different content
+)
+
+var diffKindNames = []string{
+	DiffOnlyInA: "only in a",
+	DiffOnlyInB: "only in b",
+	DiffType:    "type differs",
+	DiffMode:    "mode differs",
+	DiffSize:    "size differs",
+	DiffContent: "content differs",
+}
+
+func (k DiffKind) String() string {
+	if 0 <= k && int(k) < len(diffKindNames) {
+		return diffKindNames[k]
+	}
+	return "DiffKind(" + itoa.Itoa(int(k)) + ")"
+}
+
+// A Difference describes one way in which a file differs
+// between two file systems.
+type Difference struct {
+	Path string   // path of the file, as passed to Open
+	Kind DiffKind // how the file differs
+}
+
+func (d Difference) String() string {
+	return d.Path + ": " + d.Kind.String()
+}
+
+// Diff compares the trees rooted at root in the file systems a and b,
+// calling fn for each difference it finds. Files are visited in lexical
+// order, as by WalkDir, and a file is reported at most once, with the
+// first of these kinds that applies: DiffOnlyInA or DiffOnlyInB, DiffType,
+// DiffMode, DiffSize, DiffContent. Diff does not descend into a directory
+// that exists in only one of the file systems, or that is a directory in
+// only one of them. Modification times are not compared.
+//
+// If fn returns a non-nil error, Diff stops and returns that error.
+// Diff also stops and returns the first error encountered reading either
+// file system.
+func Diff(a, b FS, root string, fn func(Difference) error) error {
+	ia, err := Stat(a, root)
+	if err != nil {
+		return err
+	}
+	ib, err := Stat(b, root)
+	if err != nil {
+		return err
+	}
+	return diffFile(a, b, root, ia, ib, fn)
+}
+
+// errDiffFound stops Equal at the first difference.
+var errDiffFound = errors.New("difference found")
+
+// Equal reports whether the trees rooted at root in the file systems
+// a and b are the same, as compared by Diff.
+func Equal(a, b FS, root string) (bool, error) {
+	err := Diff(a, b, root, func(Difference) error { return errDiffFound })
+	if err == errDiffFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// diffFile compares the file name, described by ia and ib,
+// in a and b.
+func diffFile(a, b FS, name string, ia, ib FileInfo, fn func(Difference) error) error {
+	ma, mb := ia.Mode(), ib.Mode()
+	switch {
+	case ma.Type() != mb.Type():
+		return fn(Difference{name, DiffType})
+	case ma != mb:
+		if err := fn(Difference{name, DiffMode}); err != nil {
+			return err
+		}
+		if ma.IsDir() {
+			return diffDir(a, b, name, fn)
+		}
+		return nil
+	case ma.IsDir():
+		return diffDir(a, b, name, fn)
+	case !ma.IsRegular():
+		return nil
+	case ia.Size() != ib.Size():
+		return fn(Difference{name, DiffSize})
+	}
+	same, err := sameContent(a, b, name)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return fn(Difference{name, DiffContent})
+	}
+	return nil
+}
+
+// diffDir compares the entries of the directory name in a and b.
+func diffDir(a, b FS, name string, fn func(Difference) error) error {
+	da, err := ReadDir(a, name)
+	if err != nil {
+		return err
+	}
+	db, err := ReadDir(b, name)
+	if err != nil {
+		return err
+	}
+	for len(da) > 0 || len(db) > 0 {
+		var err error
+		switch {
+		case len(db) == 0 || len(da) > 0 && da[0].Name() < db[0].Name():
+			err = fn(Difference{path.Join(name, da[0].Name()), DiffOnlyInA})
+			da = da[1:]
+		case len(da) == 0 || db[0].Name() < da[0].Name():
+			err = fn(Difference{path.Join(name, db[0].Name()), DiffOnlyInB})
+			db = db[1:]
+		default:
+			err = diffEntry(a, b, path.Join(name, da[0].Name()), da[0], db[0], fn)
+			da, db = da[1:], db[1:]
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffEntry compares the file name, described by the directory
+// entries ea and eb, in a and b.
+func diffEntry(a, b FS, name string, ea, eb DirEntry, fn func(Difference) error) error {
+	ia, err := ea.Info()
+	if err != nil {
+		return err
+	}
+	ib, err := eb.Info()
+	if err != nil {
+		return err
+	}
+	return diffFile(a, b, name, ia, ib, fn)
+}
+
+// sameContent reports whether the file name has the same content
+// in a and b.
+func sameContent(a, b FS, name string) (bool, error) {
+	fa, err := a.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := b.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufa := make([]byte, 32*1024)
+	bufb := make([]byte, len(bufa))
+	for {
+		na, erra := io.ReadFull(fa, bufa)
+		nb, errb := io.ReadFull(fb, bufb)
+		if string(bufa[:na]) != string(bufb[:nb]) {
+			return false, nil
+		}
+		doneA := erra == io.EOF || erra == io.ErrUnexpectedEOF
+		doneB := errb == io.EOF || errb == io.ErrUnexpectedEOF
+		if erra != nil && !doneA {
+			return false, erra
+		}
+		if errb != nil && !doneB {
+			return false, errb
+		}
+		if doneA || doneB {
+			return doneA == doneB, nil
+		}
+	}
+}
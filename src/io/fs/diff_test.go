@@ -0,0 +1,80 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	. "io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiff(t *testing.T) {
+	a := fstest.MapFS{
+		"same":         {Data: []byte("same")},
+		"content":      {Data: []byte("aaaa")},
+		"size":         {Data: []byte("a")},
+		"mode":         {Data: []byte("m"), Mode: 0644},
+		"type":         {Data: []byte("t")},
+		"onlya/x":      {Data: []byte("x")},
+		"dir/same":     {Data: []byte("same")},
+		"dir/content":  {Data: []byte("a")},
+		"dir/zz-onlya": {},
+	}
+	b := fstest.MapFS{
+		"same":        {Data: []byte("same")},
+		"content":     {Data: []byte("bbbb")},
+		"size":        {Data: []byte("bb")},
+		"mode":        {Data: []byte("m"), Mode: 0600},
+		"type/x":      {},
+		"onlyb":       {},
+		"dir/same":    {Data: []byte("same")},
+		"dir/content": {Data: []byte("b")},
+	}
+
+	var got []Difference
+	err := Diff(a, b, ".", func(d Difference) error {
+		got = append(got, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Difference{
+		{"content", DiffContent},
+		{"dir/content", DiffContent},
+		{"dir/zz-onlya", DiffOnlyInA},
+		{"mode", DiffMode},
+		{"onlya", DiffOnlyInA},
+		{"onlyb", DiffOnlyInB},
+		{"size", DiffSize},
+		{"type", DiffType},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff:\nhave %v\nwant %v", got, want)
+	}
+
+	if eq, err := Equal(a, b, "."); eq || err != nil {
+		t.Errorf("Equal(a, b) = %v, %v, want false, nil", eq, err)
+	}
+	if eq, err := Equal(a, b, "dir/same"); !eq || err != nil {
+		t.Errorf(`Equal(a, b, "dir/same") = %v, %v, want true, nil`, eq, err)
+	}
+	if eq, err := Equal(a, a, "."); !eq || err != nil {
+		t.Errorf("Equal(a, a) = %v, %v, want true, nil", eq, err)
+	}
+
+	// Test that an error from fn stops the comparison.
+	errStop := errors.New("stop")
+	n := 0
+	err = Diff(a, b, ".", func(Difference) error {
+		n++
+		return errStop
+	})
+	if err != errStop || n != 1 {
+		t.Errorf("Diff stopping early made %d calls and returned %v, want 1, %v", n, err, errStop)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"internal/bytealg"
+	"path"
+)
+
+// CaseInsensitive returns an FS that looks up names in fsys without
+// regard to case, as on Windows and macOS file systems.
+//
+// A name that exists in fsys exactly as given is always used as is.
+// Otherwise each element of the name is looked up in its directory,
+// matching ASCII letters without regard to case and preferring an
+// exact match and then the first entry in directory order that
+// matches. Non-ASCII letters must match exactly, since package io/fs
+// does not carry the Unicode tables. Directory listings and Glob
+// results use the names as they are stored in fsys, and Glob patterns
+// are still matched with case.
+//
+// The files returned by Open are those of fsys, so any methods they
+// have beyond File, such as Seek, ReadAt or WriteTo, remain available.
+func CaseInsensitive(fsys FS) FS {
+	return &foldFS{fsys}
+}
+
+type foldFS struct {
+	fsys FS
+}
+
+// retry reports the name to use in place of name after a call
+// with name failed with err, or false if there is no other name
+// to try.
+func (f *foldFS) retry(name string, err error) (string, bool) {
+	if !errors.Is(err, ErrNotExist) || !ValidPath(name) {
+		return "", false
+	}
+	real, ok := f.lookup(name)
+	if !ok || real == name {
+		return "", false
+	}
+	return real, true
+}
+
+// lookup returns the name in fsys that matches name without regard
+// to case, or false if there is none.
+func (f *foldFS) lookup(name string) (string, bool) {
+	dir := "."
+	for name != "." {
+		elem := name
+		if i := bytealg.IndexByteString(name, '/'); i >= 0 {
+			elem, name = name[:i], name[i+1:]
+		} else {
+			name = "."
+		}
+		list, err := ReadDir(f.fsys, dir)
+		if err != nil {
+			return "", false
+		}
+		match := ""
+		for _, d := range list {
+			if d.Name() == elem {
+				match = elem
+				break
+			}
+			if match == "" && equalFold(d.Name(), elem) {
+				match = d.Name()
+			}
+		}
+		if match == "" {
+			return "", false
+		}
+		dir = path.Join(dir, match)
+	}
+	return dir, true
+}
+
+// fixFoldErr replaces the name reported in a PathError from
+// fsys with the name the caller used.
+func fixFoldErr(err error, name string) error {
+	if e, ok := err.(*PathError); ok {
+		e.Path = name
+	}
+	return err
+}
+
+func (f *foldFS) Open(name string) (File, error) {
+	file, err := f.fsys.Open(name)
+	if real, ok := f.retry(name, err); ok {
+		file, err = f.fsys.Open(real)
+		err = fixFoldErr(err, name)
+	}
+	return file, err
+}
+
+func (f *foldFS) Stat(name string) (FileInfo, error) {
+	info, err := Stat(f.fsys, name)
+	if real, ok := f.retry(name, err); ok {
+		info, err = Stat(f.fsys, real)
+		err = fixFoldErr(err, name)
+	}
+	return info, err
+}
+
+func (f *foldFS) ReadDir(name string) ([]DirEntry, error) {
+	list, err := ReadDir(f.fsys, name)
+	if real, ok := f.retry(name, err); ok {
+		list, err = ReadDir(f.fsys, real)
+		err = fixFoldErr(err, name)
+	}
+	return list, err
+}
+
+func (f *foldFS) ReadFile(name string) ([]byte, error) {
+	data, err := ReadFile(f.fsys, name)
+	if real, ok := f.retry(name, err); ok {
+		data, err = ReadFile(f.fsys, real)
+		err = fixFoldErr(err, name)
+	}
+	return data, err
+}
+
+// equalFold reports whether s and t are equal
+// under ASCII case-folding.
+func equalFold(s, t string) bool {
+	if len(s) != len(t) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if lowerASCII(s[i]) != lowerASCII(t[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
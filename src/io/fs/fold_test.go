@@ -0,0 +1,59 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"io"
+	. "io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	fsys := CaseInsensitive(fstest.MapFS{
+		"Dir/Hello.txt": {Data: []byte("hello, world")},
+		"Dir/hello.go":  {Data: []byte("package hello")},
+		"Ärger/x":       {Data: []byte("x")},
+	})
+	if err := fstest.TestFS(fsys, "Dir/Hello.txt", "Dir/hello.go", "Ärger/x"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Dir/Hello.txt", "dir/hello.TXT", "DIR/HELLO.TXT"} {
+		data, err := ReadFile(fsys, name)
+		if string(data) != "hello, world" || err != nil {
+			t.Errorf("ReadFile(%q) = %q, %v, want %q, nil", name, data, err, "hello, world")
+		}
+	}
+	if data, err := ReadFile(fsys, "dir/hello.go"); string(data) != "package hello" || err != nil {
+		t.Errorf("ReadFile(dir/hello.go) = %q, %v, want exact match %q, nil", data, err, "package hello")
+	}
+	if info, err := Stat(fsys, "ärger/x"); err == nil {
+		t.Errorf("Stat(ärger/x) = %v, want error: only ASCII letters fold", info.Name())
+	}
+	if info, err := Stat(fsys, "ÄRGER/X"); err != nil || info.Name() != "x" {
+		t.Errorf("Stat(ÄRGER/X) = %v, want x, nil", err)
+	}
+	if list, err := ReadDir(fsys, "dIR"); err != nil || len(list) != 2 {
+		t.Errorf("ReadDir(dIR) = %d entries, %v, want 2, nil", len(list), err)
+	}
+
+	f, err := fsys.Open("dir/HELLO.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, ok := f.(io.ReaderAt); !ok {
+		t.Errorf("Open returned %T, want io.ReaderAt", f)
+	}
+	if _, ok := f.(io.Seeker); !ok {
+		t.Errorf("Open returned %T, want io.Seeker", f)
+	}
+
+	_, err = fsys.Open("dir/missing")
+	if pe, ok := err.(*PathError); !ok || pe.Path != "dir/missing" {
+		t.Errorf("Open(dir/missing) error = %v, want *PathError for dir/missing", err)
+	}
+}
@@ -6,6 +6,7 @@ package fs
 
 import (
 	"path"
+	"sort"
 )
 
 // A GlobFS is a file system with a Glob method.
@@ -72,6 +73,197 @@ func Glob(fsys FS, pattern string) (matches []string, err error) {
 	return
 }
 
+// GlobFunc is like Glob but, instead of collecting the matches in a slice,
+// calls fn for each match as soon as it is found, in the same order as
+// Glob would return them. If fn returns a non-nil error, GlobFunc stops
+// and returns that error, so callers can stop early without reading the
+// rest of the tree. Otherwise the only possible returned error is
+// path.ErrBadPattern.
+//
+// If fs implements GlobFS, GlobFunc calls fs.Glob and then calls fn
+// for each match it returned.
+func GlobFunc(fsys FS, pattern string, fn func(name string) error) error {
+	if fsys, ok := fsys.(GlobFS); ok {
+		matches, err := fsys.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Check pattern is well-formed.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	return globFunc(fsys, pattern, fn)
+}
+
+// globFunc implements GlobFunc for a well-formed pattern.
+func globFunc(fsys FS, pattern string, fn func(string) error) error {
+	if !hasMeta(pattern) {
+		if _, err := Stat(fsys, pattern); err != nil {
+			return nil
+		}
+		return fn(pattern)
+	}
+
+	dir, file := path.Split(pattern)
+	dir = cleanGlobPath(dir)
+
+	if !hasMeta(dir) {
+		return globDirFunc(fsys, dir, file, fn)
+	}
+
+	// Prevent infinite recursion. See issue 15879.
+	if dir == pattern {
+		return path.ErrBadPattern
+	}
+
+	return globFunc(fsys, dir, func(d string) error {
+		return globDirFunc(fsys, d, file, fn)
+	})
+}
+
+// globDirFunc calls fn for the files matching pattern in the directory dir,
+// in lexicographical order. If the directory cannot be opened,
+// globDirFunc does nothing.
+func globDirFunc(fsys FS, dir, pattern string, fn func(string) error) error {
+	infos, err := ReadDir(fsys, dir)
+	if err != nil {
+		return nil // ignore I/O error
+	}
+
+	for _, info := range infos {
+		n := info.Name()
+		matched, err := path.Match(pattern, n)
+		if err != nil {
+			return err
+		}
+		if matched {
+			if err := fn(path.Join(dir, n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GlobRecursive is like Glob but also accepts "**" as a path element of
+// pattern, matching zero or more path elements. For example, "a/**/*.go"
+// matches a/x.go, a/b/x.go and a/b/c/x.go. A "**" element is only
+// special when it makes up a whole path element: "a**" is the same as "a*".
+//
+// To keep the traversal bounded, the part of pattern before the first
+// "**" is expanded with Glob, and "**" only descends into directories
+// found by ReadDir; it does not follow symbolic links. The remaining
+// pattern elements prune the traversal as they are matched.
+//
+// The matches are returned in lexical order, without duplicates.
+// Like Glob, GlobRecursive ignores file system errors, and the only
+// possible returned error is path.ErrBadPattern.
+func GlobRecursive(fsys FS, pattern string) (matches []string, err error) {
+	// Check pattern is well-formed.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	head, tail := splitDoubleStar(pattern)
+	if tail == nil {
+		return Glob(fsys, pattern)
+	}
+
+	dirs := []string{"."}
+	if head != "" {
+		if dirs, err = Glob(fsys, head); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		matches = globTail(fsys, dir, tail, matches)
+	}
+	sort.Strings(matches)
+	return dedupSorted(matches), nil
+}
+
+// splitDoubleStar splits pattern before its first "**" element,
+// returning the pattern for the directories above it and the
+// remaining elements. If there is no "**" element, tail is nil.
+func splitDoubleStar(pattern string) (head string, tail []string) {
+	start := 0
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) && pattern[i] != '/' {
+			continue
+		}
+		if pattern[start:i] == "**" {
+			if start > 0 {
+				head = pattern[:start-1]
+			}
+			for j := start; j <= len(pattern); j++ {
+				if j == len(pattern) || pattern[j] == '/' {
+					tail = append(tail, pattern[start:j])
+					start = j + 1
+				}
+			}
+			return head, tail
+		}
+		start = i + 1
+	}
+	return pattern, nil
+}
+
+// globTail appends to matches the names below dir matching the
+// pattern elements elems, returning the updated slice.
+func globTail(fsys FS, dir string, elems []string, matches []string) []string {
+	if len(elems) == 0 {
+		if dir != "." {
+			matches = append(matches, dir)
+		}
+		return matches
+	}
+	entries, err := ReadDir(fsys, dir)
+	if elems[0] == "**" {
+		for len(elems) > 1 && elems[1] == "**" {
+			elems = elems[1:]
+		}
+		matches = globTail(fsys, dir, elems[1:], matches)
+		for _, e := range entries {
+			switch {
+			case e.IsDir():
+				matches = globTail(fsys, path.Join(dir, e.Name()), elems, matches)
+			case len(elems) == 1:
+				// A trailing "**" also matches files.
+				matches = append(matches, path.Join(dir, e.Name()))
+			}
+		}
+		return matches
+	}
+	if err != nil {
+		return matches // ignore I/O error
+	}
+	for _, e := range entries {
+		// The pattern was checked by GlobRecursive.
+		if ok, _ := path.Match(elems[0], e.Name()); ok {
+			matches = globTail(fsys, path.Join(dir, e.Name()), elems[1:], matches)
+		}
+	}
+	return matches
+}
+
+// dedupSorted removes adjacent duplicates from the sorted slice list.
+func dedupSorted(list []string) []string {
+	out := list[:0]
+	for i, s := range list {
+		if i == 0 || s != list[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // cleanGlobPath prepares path for glob matching.
 func cleanGlobPath(path string) string {
 	switch path {
@@ -5,10 +5,13 @@
 package fs_test
 
 import (
+	"errors"
 	. "io/fs"
 	"os"
 	"path"
+	"reflect"
 	"testing"
+	"testing/fstest"
 )
 
 var globTests = []struct {
@@ -85,3 +88,80 @@ func TestGlobMethod(t *testing.T) {
 	names, err = Glob(openOnly{testFsys}, "*.txt")
 	check("openOnly", names, err)
 }
+
+func TestGlobRecursive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":       {},
+		"a/b.go":     {},
+		"a/b/c.go":   {},
+		"a/b/c.txt":  {},
+		"a/a/a.go":   {},
+		"x/a/b/c.go": {},
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.go", []string{"a.go", "a/a/a.go", "a/b.go", "a/b/c.go", "x/a/b/c.go"}},
+		{"a/**/*.go", []string{"a/a/a.go", "a/b.go", "a/b/c.go"}},
+		{"a/**", []string{"a", "a/a", "a/a/a.go", "a/b", "a/b.go", "a/b/c.go", "a/b/c.txt"}},
+		{"**/a/**/c.*", []string{"a/b/c.go", "a/b/c.txt", "x/a/b/c.go"}},
+		{"*/**/b", []string{"a/b", "x/a/b"}},
+		{"**/**/a.go", []string{"a.go", "a/a/a.go"}},
+		{"a/b/c.go", []string{"a/b/c.go"}},
+		{"a**.go", []string{"a.go"}},
+		{"**/nothing", nil},
+	}
+	for _, tt := range tests {
+		matches, err := GlobRecursive(fsys, tt.pattern)
+		if err != nil {
+			t.Errorf("GlobRecursive(%#q) error: %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(matches, tt.want) {
+			t.Errorf("GlobRecursive(%#q) = %q, want %q", tt.pattern, matches, tt.want)
+		}
+	}
+
+	if _, err := GlobRecursive(fsys, "**/[]"); err != path.ErrBadPattern {
+		t.Errorf("GlobRecursive(%#q) error = %v, want path.ErrBadPattern", "**/[]", err)
+	}
+}
+
+func TestGlobFunc(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/x.txt": {},
+		"a/y.txt": {},
+		"b/x.txt": {},
+		"b/z.go":  {},
+	}
+	for _, pattern := range []string{"*/*.txt", "*/x.txt", "a/*", "a/x.txt", "nothing/*"} {
+		want, err := Glob(fsys, pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		err = GlobFunc(fsys, pattern, func(name string) error {
+			got = append(got, name)
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("GlobFunc(%#q) visited %q, %v, want %q, nil", pattern, got, err, want)
+		}
+	}
+
+	// Test that returning an error stops the glob.
+	errStop := errors.New("stop")
+	var got []string
+	err := GlobFunc(fsys, "*/*.txt", func(name string) error {
+		got = append(got, name)
+		return errStop
+	})
+	if err != errStop || len(got) != 1 || got[0] != "a/x.txt" {
+		t.Errorf("GlobFunc stopping early visited %q, %v, want [a/x.txt], %v", got, err, errStop)
+	}
+
+	if err := GlobFunc(fsys, "[]", func(string) error { return nil }); err != path.ErrBadPattern {
+		t.Errorf("GlobFunc(%#q) error = %v, want path.ErrBadPattern", "[]", err)
+	}
+}
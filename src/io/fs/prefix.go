@@ -0,0 +1,241 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"errors"
+	"io"
+	"path"
+	"time"
+)
+
+// Prefix returns an FS that presents the tree of fsys under the
+// directory dir. It is the inverse of Sub: Prefix(fsys, dir) implements
+// Open(path.Join(dir, name)) as fsys.Open(name). The directory dir and
+// its parents are read-only directories listing only the next element
+// of dir, and no other names exist.
+//
+// If dir is ".", Prefix returns fsys unchanged.
+//
+// The files of fsys are returned as is, so any methods they have beyond
+// File, such as Seek, ReadAt or WriteTo, remain available. The exception
+// is dir itself, which is fsys's root directory under a new name.
+func Prefix(fsys FS, dir string) (FS, error) {
+	if !ValidPath(dir) {
+		return nil, &PathError{Op: "prefix", Path: dir, Err: errors.New("invalid name")}
+	}
+	if dir == "." {
+		return fsys, nil
+	}
+	return &prefixFS{fsys, dir}, nil
+}
+
+type prefixFS struct {
+	fsys FS
+	dir  string
+}
+
+// innerName maps name, which should start with f.dir,
+// to the name in f.fsys.
+func (f *prefixFS) innerName(name string) (string, bool) {
+	if name == f.dir {
+		return ".", true
+	}
+	if len(name) >= len(f.dir)+2 && name[len(f.dir)] == '/' && name[:len(f.dir)] == f.dir {
+		return name[len(f.dir)+1:], true
+	}
+	return "", false
+}
+
+// child reports the only entry of name if it is f.dir
+// or one of its parents.
+func (f *prefixFS) child(name string) (string, bool) {
+	rest := f.dir
+	if name != "." {
+		if len(f.dir) <= len(name)+1 || f.dir[len(name)] != '/' || f.dir[:len(name)] != name {
+			return "", false
+		}
+		rest = f.dir[len(name)+1:]
+	}
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '/' {
+			return rest[:i], true
+		}
+	}
+	return rest, true
+}
+
+// fixErr lengthens any reported names in PathErrors
+// from f.fsys by prepending f.dir.
+func (f *prefixFS) fixErr(err error) error {
+	if e, ok := err.(*PathError); ok && ValidPath(e.Path) {
+		e.Path = path.Join(f.dir, e.Path)
+	}
+	return err
+}
+
+// rootInfo returns the information about f.dir, which is
+// that of the root of f.fsys with the name of f.dir.
+func (f *prefixFS) rootInfo() (FileInfo, error) {
+	info, err := Stat(f.fsys, ".")
+	if err != nil {
+		return nil, f.fixErr(err)
+	}
+	return renamedInfo{info, path.Base(f.dir)}, nil
+}
+
+// parentInfo returns the information about name,
+// which is a parent of f.dir.
+func (f *prefixFS) parentInfo(name string) FileInfo {
+	return prefixDirInfo{path.Base(name)}
+}
+
+func (f *prefixFS) Open(name string) (File, error) {
+	if !ValidPath(name) {
+		return nil, &PathError{Op: "open", Path: name, Err: ErrInvalid}
+	}
+	if inner, ok := f.innerName(name); ok {
+		file, err := f.fsys.Open(inner)
+		if err != nil {
+			return nil, f.fixErr(err)
+		}
+		if inner == "." {
+			file = &prefixRoot{file, path.Base(f.dir)}
+		}
+		return file, nil
+	}
+	if _, ok := f.child(name); ok {
+		list, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &prefixDir{f.parentInfo(name), list, 0}, nil
+	}
+	return nil, &PathError{Op: "open", Path: name, Err: ErrNotExist}
+}
+
+func (f *prefixFS) Stat(name string) (FileInfo, error) {
+	if !ValidPath(name) {
+		return nil, &PathError{Op: "stat", Path: name, Err: ErrInvalid}
+	}
+	if inner, ok := f.innerName(name); ok {
+		if inner == "." {
+			return f.rootInfo()
+		}
+		info, err := Stat(f.fsys, inner)
+		return info, f.fixErr(err)
+	}
+	if _, ok := f.child(name); ok {
+		return f.parentInfo(name), nil
+	}
+	return nil, &PathError{Op: "stat", Path: name, Err: ErrNotExist}
+}
+
+func (f *prefixFS) ReadDir(name string) ([]DirEntry, error) {
+	if !ValidPath(name) {
+		return nil, &PathError{Op: "readdir", Path: name, Err: ErrInvalid}
+	}
+	if inner, ok := f.innerName(name); ok {
+		list, err := ReadDir(f.fsys, inner)
+		return list, f.fixErr(err)
+	}
+	if child, ok := f.child(name); ok {
+		var info FileInfo
+		if full := path.Join(name, child); full == f.dir {
+			var err error
+			if info, err = f.rootInfo(); err != nil {
+				return nil, err
+			}
+		} else {
+			info = f.parentInfo(full)
+		}
+		return []DirEntry{FileInfoToDirEntry(info)}, nil
+	}
+	return nil, &PathError{Op: "readdir", Path: name, Err: ErrNotExist}
+}
+
+func (f *prefixFS) ReadFile(name string) ([]byte, error) {
+	if !ValidPath(name) {
+		return nil, &PathError{Op: "read", Path: name, Err: ErrInvalid}
+	}
+	if inner, ok := f.innerName(name); ok {
+		data, err := ReadFile(f.fsys, inner)
+		return data, f.fixErr(err)
+	}
+	if _, ok := f.child(name); ok {
+		return nil, &PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return nil, &PathError{Op: "read", Path: name, Err: ErrNotExist}
+}
+
+// A renamedInfo is a FileInfo with a different name.
+type renamedInfo struct {
+	FileInfo
+	name string
+}
+
+func (i renamedInfo) Name() string { return i.name }
+
+// A prefixDirInfo describes a parent of the directory of a prefixFS.
+type prefixDirInfo struct {
+	name string
+}
+
+func (i prefixDirInfo) Name() string       { return i.name }
+func (i prefixDirInfo) Size() int64        { return 0 }
+func (i prefixDirInfo) Mode() FileMode     { return ModeDir | 0555 }
+func (i prefixDirInfo) ModTime() time.Time { return time.Time{} }
+func (i prefixDirInfo) IsDir() bool        { return true }
+func (i prefixDirInfo) Sys() interface{}   { return nil }
+
+// A prefixRoot is the root directory of the file system
+// wrapped by a prefixFS, opened under the name of its directory.
+type prefixRoot struct {
+	File
+	name string
+}
+
+func (d *prefixRoot) Stat() (FileInfo, error) {
+	info, err := d.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return renamedInfo{info, d.name}, nil
+}
+
+func (d *prefixRoot) ReadDir(count int) ([]DirEntry, error) {
+	dir, ok := d.File.(ReadDirFile)
+	if !ok {
+		return nil, &PathError{Op: "readdir", Path: d.name, Err: errors.New("not implemented")}
+	}
+	return dir.ReadDir(count)
+}
+
+// A prefixDir is an open parent of the directory of a prefixFS.
+type prefixDir struct {
+	info   FileInfo
+	list   []DirEntry
+	offset int
+}
+
+func (d *prefixDir) Stat() (FileInfo, error) { return d.info, nil }
+func (d *prefixDir) Close() error            { return nil }
+func (d *prefixDir) Read(b []byte) (int, error) {
+	return 0, &PathError{Op: "read", Path: d.info.Name(), Err: ErrInvalid}
+}
+
+func (d *prefixDir) ReadDir(count int) ([]DirEntry, error) {
+	n := len(d.list) - d.offset
+	if n == 0 && count > 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && n > count {
+		n = count
+	}
+	list := make([]DirEntry, n)
+	copy(list, d.list[d.offset:])
+	d.offset += n
+	return list, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"io"
+	. "io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPrefix(t *testing.T) {
+	fsys, err := Prefix(testFsys, "static/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "static/v1/hello.txt", "static/v1/sub/goodbye.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadFile(fsys, "static/v1/sub/goodbye.txt")
+	if string(data) != "goodbye, world" || err != nil {
+		t.Errorf("ReadFile = %q, %v, want %q, nil", data, err, "goodbye, world")
+	}
+	if list, err := ReadDir(fsys, "static"); err != nil || len(list) != 1 || list[0].Name() != "v1" || !list[0].IsDir() {
+		t.Errorf("ReadDir(static) = %v, %v, want [v1], nil", list, err)
+	}
+	if info, err := Stat(fsys, "static/v1"); err != nil || info.Name() != "v1" || !info.IsDir() {
+		t.Errorf("Stat(static/v1) = %v, %v, want directory v1", info, err)
+	}
+
+	f, err := fsys.Open("static/v1/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, ok := f.(io.ReaderAt); !ok {
+		t.Errorf("Open returned %T, want io.ReaderAt", f)
+	}
+
+	for _, name := range []string{"hello.txt", "static/hello.txt", "static/v2", "static/v1/nonexist"} {
+		_, err := fsys.Open(name)
+		if pe, ok := err.(*PathError); !ok || pe.Path != name {
+			t.Errorf("Open(%s) error = %v, want *PathError for %s", name, err, name)
+		}
+	}
+
+	if same, err := Prefix(testFsys, "."); same == nil || err != nil {
+		t.Errorf(`Prefix(fsys, ".") = %v, %v`, same, err)
+	}
+	if _, err := Prefix(testFsys, "/x"); err == nil {
+		t.Errorf(`Prefix(fsys, "/x") succeeded, want error`)
+	}
+}
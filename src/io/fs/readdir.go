@@ -46,6 +46,44 @@ func ReadDir(fsys FS, name string) ([]DirEntry, error) {
 	return list, err
 }
 
+// StatDirEntriesFS is the interface implemented by a file system
+// that can return full information about all the entries of a directory
+// more cheaply than by calling Info on each DirEntry.
+type StatDirEntriesFS interface {
+	FS
+
+	// StatDirEntries reads the named directory and returns a FileInfo
+	// for each of its entries, sorted by filename. Like DirEntry.Info,
+	// it does not follow symbolic links.
+	StatDirEntries(name string) ([]FileInfo, error)
+}
+
+// StatDirEntries reads the named directory and returns a FileInfo
+// for each of its entries, sorted by filename.
+//
+// If fs implements StatDirEntriesFS, StatDirEntries calls
+// fs.StatDirEntries. Otherwise StatDirEntries calls ReadDir and then Info
+// on each returned entry, skipping entries removed in the meantime.
+func StatDirEntries(fsys FS, name string) ([]FileInfo, error) {
+	if fsys, ok := fsys.(StatDirEntriesFS); ok {
+		return fsys.StatDirEntries(name)
+	}
+
+	list, err := ReadDir(fsys, name)
+	infos := make([]FileInfo, 0, len(list))
+	for _, d := range list {
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, ErrNotExist) {
+				continue
+			}
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, err
+}
+
 // dirInfo is a DirEntry based on a FileInfo.
 type dirInfo struct {
 	fileInfo FileInfo
@@ -7,6 +7,7 @@ package fs_test
 import (
 	. "io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -91,3 +92,30 @@ func TestFileInfoToDirEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestStatDirEntries(t *testing.T) {
+	check := func(desc string, infos []FileInfo, err error) {
+		t.Helper()
+		if err != nil || len(infos) != 2 || infos[0].Name() != "hello.txt" || infos[1].Name() != "sub" ||
+			infos[0].Size() != int64(len("hello, world")) || !infos[1].IsDir() {
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			t.Errorf("StatDirEntries(%s) = %v, %v, want [hello.txt sub], nil", desc, names, err)
+		}
+	}
+
+	infos, err := StatDirEntries(testFsys, ".")
+	check("mapFS", infos, err)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello, world"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	infos, err = StatDirEntries(os.DirFS(dir), ".")
+	check("DirFS", infos, err)
+}
@@ -7,6 +7,8 @@ package fs
 import (
 	"errors"
 	"path"
+	"sync"
+	"sync/atomic"
 )
 
 // SkipDir is used as a return value from WalkDirFuncs to indicate that
@@ -117,6 +119,119 @@ func WalkDir(fsys FS, root string, fn WalkDirFunc) error {
 	return err
 }
 
+// WalkDirConcurrent is like WalkDir but walks independent subdirectories
+// in parallel, using at most workers goroutines, including the calling one,
+// to read directories and call fn. If workers is less than 2,
+// WalkDirConcurrent walks the tree sequentially, as WalkDir does.
+//
+// Since fn may be called from several goroutines at once, it must be safe
+// for concurrent use. The calls for the entries of one directory are still
+// made one at a time, in lexical order, and the call for a directory is
+// made before the calls for anything in it; there is no ordering between
+// calls for entries of different directories.
+//
+// The result of fn is interpreted as by WalkDir, with two differences.
+// Because the parent directory may already have moved on, SkipDir returned
+// by the second call for a directory whose ReadDir failed only skips that
+// directory. And once fn returns an error other than SkipDir, the walk stops
+// as soon as the calls already in progress finish; WalkDirConcurrent then
+// returns the first such error.
+func WalkDirConcurrent(fsys FS, root string, workers int, fn WalkDirFunc) error {
+	info, err := Stat(fsys, root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		d := &statDirEntry{info}
+		err = fn(root, d, nil)
+		if err == nil && d.IsDir() {
+			if workers < 1 {
+				workers = 1
+			}
+			w := &concurrentWalker{
+				fsys: fsys,
+				fn:   fn,
+				sem:  make(chan struct{}, workers-1),
+			}
+			w.walk(root, d)
+			w.wg.Wait()
+			err = w.err
+		}
+	}
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// A concurrentWalker holds the state shared by the goroutines
+// of a WalkDirConcurrent call.
+type concurrentWalker struct {
+	fsys FS
+	fn   WalkDirFunc
+	sem  chan struct{} // one token per extra goroutine
+	wg   sync.WaitGroup
+
+	stopped int32 // atomic; set once err is set
+	once    sync.Once
+	err     error
+}
+
+// stop records err as the result of the walk, unless one was already
+// recorded, and tells all goroutines to stop.
+func (w *concurrentWalker) stop(err error) {
+	w.once.Do(func() {
+		w.err = err
+		atomic.StoreInt32(&w.stopped, 1)
+	})
+}
+
+// walk visits the entries of the directory name, whose own fn call has
+// already been made. Subdirectories are walked by a new goroutine when
+// one is available and by the current one otherwise.
+func (w *concurrentWalker) walk(name string, d DirEntry) {
+	dirs, err := ReadDir(w.fsys, name)
+	if err != nil {
+		// Second call, to report ReadDir error.
+		if err = w.fn(name, d, err); err != nil {
+			if err != SkipDir {
+				w.stop(err)
+			}
+			return
+		}
+	}
+
+	for _, d1 := range dirs {
+		if atomic.LoadInt32(&w.stopped) != 0 {
+			return
+		}
+		name1 := path.Join(name, d1.Name())
+		if err := w.fn(name1, d1, nil); err != nil {
+			if err == SkipDir {
+				if d1.IsDir() {
+					continue
+				}
+				return
+			}
+			w.stop(err)
+			return
+		}
+		if !d1.IsDir() {
+			continue
+		}
+		select {
+		case w.sem <- struct{}{}:
+			w.wg.Add(1)
+			go func(name string, d DirEntry) {
+				defer w.wg.Done()
+				w.walk(name, d)
+				<-w.sem
+			}(name1, d1)
+		default:
+			w.walk(name1, d1)
+		}
+	}
+}
+
 type statDirEntry struct {
 	info FileInfo
 }
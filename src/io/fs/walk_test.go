@@ -5,9 +5,13 @@
 package fs_test
 
 import (
+	"errors"
 	. "io/fs"
 	"os"
 	pathpkg "path"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"testing/fstest"
 )
@@ -122,3 +126,80 @@ func TestWalkDir(t *testing.T) {
 	}
 	checkMarks(t, true)
 }
+
+func TestWalkDirConcurrent(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var want []string
+	for _, d := range []string{"a", "b", "c", "d"} {
+		for _, e := range []string{"1", "2", "3"} {
+			fsys[d+"/"+e+"/f"] = &fstest.MapFile{}
+		}
+	}
+	if err := WalkDir(fsys, ".", func(path string, d DirEntry, err error) error {
+		want = append(want, path)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{0, 1, 2, 8} {
+		var mu sync.Mutex
+		var got []string
+		seen := make(map[string]bool)
+		err := WalkDirConcurrent(fsys, ".", workers, func(path string, d DirEntry, err error) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if dir := pathpkg.Dir(path); path != "." && !seen[dir] {
+				t.Errorf("workers=%d: visited %s before its directory", workers, path)
+			}
+			seen[path] = true
+			got = append(got, path)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: visited %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestWalkDirConcurrentSkipAndStop(t *testing.T) {
+	fsys := makeTree(t)
+	errStop := errors.New("stop")
+
+	var mu sync.Mutex
+	var got []string
+	err := WalkDirConcurrent(fsys, "testdata", 4, func(path string, d DirEntry, err error) error {
+		mu.Lock()
+		got = append(got, path)
+		mu.Unlock()
+		switch path {
+		case "testdata/d/y":
+			return SkipDir // skips only d/y
+		case "testdata/d/z/u":
+			return SkipDir // skips the rest of d/z
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := []string{"testdata", "testdata/a", "testdata/b", "testdata/c", "testdata/d", "testdata/d/x", "testdata/d/y", "testdata/d/z", "testdata/d/z/u"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("visited %v, want %v", got, want)
+	}
+
+	err = WalkDirConcurrent(fsys, "testdata", 4, func(path string, d DirEntry, err error) error {
+		if path == "testdata/d/z" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("WalkDirConcurrent = %v, want %v", err, errStop)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import (
+	"syscall"
+	"time"
+)
+
+// A WritableFile is a File that can also be written to.
+// Files opened for writing by an OpenFileFS implement it.
+type WritableFile interface {
+	File
+	Write(p []byte) (n int, err error)
+}
+
+// OpenFileFS is the interface implemented by a file system
+// that can open files for writing.
+type OpenFileFS interface {
+	FS
+
+	// OpenFile opens the named file with the specified flag, which
+	// takes the values of package os (O_RDONLY, O_WRONLY, O_CREATE and
+	// so on). If the file does not exist and O_CREATE is passed, it is
+	// created with mode perm. A file opened for writing must implement
+	// WritableFile.
+	// If there is an error, it should be of type *PathError.
+	OpenFile(name string, flag int, perm FileMode) (File, error)
+}
+
+// WriteFileFS is the interface implemented by a file system
+// that provides an optimized implementation of WriteFile.
+type WriteFileFS interface {
+	FS
+
+	// WriteFile writes data to the named file, creating it if
+	// necessary. If the file does not exist, WriteFile creates it with
+	// permissions perm; otherwise WriteFile truncates it before writing,
+	// without changing permissions.
+	WriteFile(name string, data []byte, perm FileMode) error
+}
+
+// MkdirFS is the interface implemented by a file system
+// that can create directories.
+type MkdirFS interface {
+	FS
+
+	// Mkdir creates a new directory with the specified name and
+	// permission bits. The parent directory must exist.
+	// If there is an error, it should be of type *PathError.
+	Mkdir(name string, perm FileMode) error
+}
+
+// RemoveFS is the interface implemented by a file system
+// that can remove files.
+type RemoveFS interface {
+	FS
+
+	// Remove removes the named file or (empty) directory.
+	// If there is an error, it should be of type *PathError.
+	Remove(name string) error
+}
+
+// RenameFS is the interface implemented by a file system
+// that can rename files.
+type RenameFS interface {
+	FS
+
+	// Rename renames (moves) oldname to newname.
+	// If newname already exists and is not a directory, Rename replaces it.
+	Rename(oldname, newname string) error
+}
+
+// ChmodFS is the interface implemented by a file system
+// that can change the mode of files.
+type ChmodFS interface {
+	FS
+
+	// Chmod changes the mode of the named file to mode.
+	// If there is an error, it should be of type *PathError.
+	Chmod(name string, mode FileMode) error
+}
+
+// ChownFS is the interface implemented by a file system
+// that can change the owner of files.
+type ChownFS interface {
+	FS
+
+	// Chown changes the numeric uid and gid of the named file.
+	// A uid or gid of -1 means to not change that value.
+	// If there is an error, it should be of type *PathError.
+	Chown(name string, uid, gid int) error
+}
+
+// ChtimesFS is the interface implemented by a file system
+// that can change the access and modification times of files.
+type ChtimesFS interface {
+	FS
+
+	// Chtimes changes the access and modification times of the named file.
+	// If there is an error, it should be of type *PathError.
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// SymlinkFS is the interface implemented by a file system
+// that can create symbolic links.
+type SymlinkFS interface {
+	FS
+
+	// Symlink creates newname as a symbolic link to oldname.
+	// The link target oldname is stored as is; it is not
+	// interpreted as a path in the file system.
+	Symlink(oldname, newname string) error
+}
+
+// LinkFS is the interface implemented by a file system
+// that can create hard links.
+type LinkFS interface {
+	FS
+
+	// Link creates newname as a hard link to the oldname file.
+	Link(oldname, newname string) error
+}
+
+// OpenFile opens the named file from the file system with the
+// specified flag, which takes the values of package os.
+//
+// If fsys implements OpenFileFS, OpenFile calls fsys.OpenFile.
+// Otherwise, files can only be opened for reading: OpenFile calls
+// fsys.Open unless flag asks for writing, creating, truncating or
+// appending, in which case it fails with ErrPermission.
+func OpenFile(fsys FS, name string, flag int, perm FileMode) (File, error) {
+	if fsys, ok := fsys.(OpenFileFS); ok {
+		return fsys.OpenFile(name, flag, perm)
+	}
+	if flag&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_CREAT|syscall.O_TRUNC|syscall.O_APPEND) != 0 {
+		return nil, &PathError{Op: "open", Path: name, Err: ErrPermission}
+	}
+	return fsys.Open(name)
+}
+
+// WriteFile writes data to the named file in the file system,
+// creating it if necessary. If the file does not exist, WriteFile
+// creates it with permissions perm; otherwise WriteFile truncates it
+// before writing, without changing permissions.
+//
+// If fsys implements WriteFileFS, WriteFile calls fsys.WriteFile.
+// Otherwise WriteFile opens the file with OpenFile and writes to it.
+func WriteFile(fsys FS, name string, data []byte, perm FileMode) error {
+	if fsys, ok := fsys.(WriteFileFS); ok {
+		return fsys.WriteFile(name, data, perm)
+	}
+
+	file, err := OpenFile(fsys, name, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	w, ok := file.(WritableFile)
+	if !ok {
+		file.Close()
+		return &PathError{Op: "write", Path: name, Err: ErrInvalid}
+	}
+	_, err = w.Write(data)
+	if err1 := file.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// Mkdir creates a new directory in the file system with the specified
+// name and permission bits.
+//
+// If fsys implements MkdirFS, Mkdir calls fsys.Mkdir.
+// Otherwise Mkdir fails with ErrPermission.
+func Mkdir(fsys FS, name string, perm FileMode) error {
+	if fsys, ok := fsys.(MkdirFS); ok {
+		return fsys.Mkdir(name, perm)
+	}
+	return &PathError{Op: "mkdir", Path: name, Err: ErrPermission}
+}
+
+// Remove removes the named file or (empty) directory from the file system.
+//
+// If fsys implements RemoveFS, Remove calls fsys.Remove.
+// Otherwise Remove fails with ErrPermission.
+func Remove(fsys FS, name string) error {
+	if fsys, ok := fsys.(RemoveFS); ok {
+		return fsys.Remove(name)
+	}
+	return &PathError{Op: "remove", Path: name, Err: ErrPermission}
+}
+
+// Rename renames (moves) oldname to newname in the file system.
+//
+// If fsys implements RenameFS, Rename calls fsys.Rename.
+// Otherwise Rename fails with ErrPermission.
+func Rename(fsys FS, oldname, newname string) error {
+	if fsys, ok := fsys.(RenameFS); ok {
+		return fsys.Rename(oldname, newname)
+	}
+	return &PathError{Op: "rename", Path: oldname, Err: ErrPermission}
+}
+
+// Chmod changes the mode of the named file in the file system to mode.
+//
+// If fsys implements ChmodFS, Chmod calls fsys.Chmod.
+// Otherwise Chmod fails with ErrPermission.
+func Chmod(fsys FS, name string, mode FileMode) error {
+	if fsys, ok := fsys.(ChmodFS); ok {
+		return fsys.Chmod(name, mode)
+	}
+	return &PathError{Op: "chmod", Path: name, Err: ErrPermission}
+}
+
+// Chown changes the numeric uid and gid of the named file in the file system.
+// A uid or gid of -1 means to not change that value.
+//
+// If fsys implements ChownFS, Chown calls fsys.Chown.
+// Otherwise Chown fails with ErrPermission.
+func Chown(fsys FS, name string, uid, gid int) error {
+	if fsys, ok := fsys.(ChownFS); ok {
+		return fsys.Chown(name, uid, gid)
+	}
+	return &PathError{Op: "chown", Path: name, Err: ErrPermission}
+}
+
+// Chtimes changes the access and modification times of the named file
+// in the file system.
+//
+// If fsys implements ChtimesFS, Chtimes calls fsys.Chtimes.
+// Otherwise Chtimes fails with ErrPermission.
+func Chtimes(fsys FS, name string, atime, mtime time.Time) error {
+	if fsys, ok := fsys.(ChtimesFS); ok {
+		return fsys.Chtimes(name, atime, mtime)
+	}
+	return &PathError{Op: "chtimes", Path: name, Err: ErrPermission}
+}
+
+// Symlink creates newname in the file system as a symbolic link to oldname.
+//
+// If fsys implements SymlinkFS, Symlink calls fsys.Symlink.
+// Otherwise Symlink fails with ErrPermission.
+func Symlink(fsys FS, oldname, newname string) error {
+	if fsys, ok := fsys.(SymlinkFS); ok {
+		return fsys.Symlink(oldname, newname)
+	}
+	return &PathError{Op: "symlink", Path: newname, Err: ErrPermission}
+}
+
+// Link creates newname in the file system as a hard link to the oldname file.
+//
+// If fsys implements LinkFS, Link calls fsys.Link.
+// Otherwise Link fails with ErrPermission.
+func Link(fsys FS, oldname, newname string) error {
+	if fsys, ok := fsys.(LinkFS); ok {
+		return fsys.Link(oldname, newname)
+	}
+	return &PathError{Op: "link", Path: newname, Err: ErrPermission}
+}
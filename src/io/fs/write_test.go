@@ -0,0 +1,176 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	"internal/testenv"
+	. "io/fs"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// openFileOnly hides all methods of a writable file system
+// except Open and OpenFile.
+type openFileOnly struct{ OpenFileFS }
+
+func TestWriteFile(t *testing.T) {
+	fsys := os.DirFS(t.TempDir())
+
+	// Test that WriteFile uses the method when present.
+	if err := WriteFile(fsys, "a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatalf(`WriteFile(DirFS, "a.txt") = %v`, err)
+	}
+	if data, err := ReadFile(fsys, "a.txt"); string(data) != "hello" || err != nil {
+		t.Fatalf(`ReadFile(DirFS, "a.txt") = %q, %v, want %q, nil`, data, err, "hello")
+	}
+
+	// Test that WriteFile uses OpenFile when the method is not present,
+	// and that it truncates an existing file.
+	if err := WriteFile(openFileOnly{fsys.(OpenFileFS)}, "a.txt", []byte("bye"), 0644); err != nil {
+		t.Fatalf(`WriteFile(openFileOnly, "a.txt") = %v`, err)
+	}
+	if data, err := ReadFile(fsys, "a.txt"); string(data) != "bye" || err != nil {
+		t.Fatalf(`ReadFile(DirFS, "a.txt") = %q, %v, want %q, nil`, data, err, "bye")
+	}
+
+	// Test that WriteFile fails on a file system without the methods.
+	err := WriteFile(openOnly{testFsys}, "new.txt", []byte("x"), 0644)
+	if !errors.Is(err, ErrPermission) {
+		t.Fatalf(`WriteFile(openOnly, "new.txt") = %v, want ErrPermission`, err)
+	}
+
+	// Test that invalid paths are rejected.
+	if err := WriteFile(fsys, "../escape.txt", nil, 0644); err == nil {
+		t.Fatalf(`WriteFile(DirFS, "../escape.txt") succeeded`)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	// Test that read-only opens fall back to Open.
+	f, err := OpenFile(openOnly{testFsys}, "hello.txt", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := OpenFile(openOnly{testFsys}, "hello.txt", os.O_RDWR, 0); !errors.Is(err, ErrPermission) {
+		t.Fatalf(`OpenFile(openOnly, "hello.txt", O_RDWR) = %v, want ErrPermission`, err)
+	}
+}
+
+func TestMkdirRenameRemove(t *testing.T) {
+	fsys := os.DirFS(t.TempDir())
+
+	if err := Mkdir(fsys, "dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(fsys, "dir/a", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rename(fsys, "dir/a", "dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ReadFile(fsys, "dir/b"); string(data) != "a" || err != nil {
+		t.Fatalf(`ReadFile(DirFS, "dir/b") = %q, %v, want %q, nil`, data, err, "a")
+	}
+	if err := Remove(fsys, "dir"); err == nil {
+		t.Fatalf(`Remove(DirFS, "dir") of non-empty directory succeeded`)
+	}
+	if err := Remove(fsys, "dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(fsys, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(fsys, "dir"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf(`Stat(DirFS, "dir") after Remove = %v, want ErrNotExist`, err)
+	}
+
+	// Test that the helpers fail on a file system without the methods.
+	for name, err := range map[string]error{
+		"Mkdir":  Mkdir(openOnly{testFsys}, "dir", 0755),
+		"Remove": Remove(openOnly{testFsys}, "hello.txt"),
+		"Rename": Rename(openOnly{testFsys}, "hello.txt", "x"),
+	} {
+		if !errors.Is(err, ErrPermission) {
+			t.Errorf("%s(openOnly) = %v, want ErrPermission", name, err)
+		}
+	}
+}
+
+func TestChmodChtimes(t *testing.T) {
+	fsys := os.DirFS(t.TempDir())
+	if err := WriteFile(fsys, "a", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Chmod(fsys, "a", 0400); err != nil {
+		t.Fatal(err)
+	}
+	info, err := Stat(fsys, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := FileMode(0400); runtime.GOOS != "windows" && runtime.GOOS != "plan9" && info.Mode().Perm() != want {
+		t.Errorf("mode after Chmod = %v, want %v", info.Mode().Perm(), want)
+	}
+
+	mtime := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	if err := Chtimes(fsys, "a", mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = Stat(fsys, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("modification time after Chtimes = %v, want %v", info.ModTime(), mtime)
+	}
+
+	// Test that the helpers fail on a file system without the methods.
+	for name, err := range map[string]error{
+		"Chmod":   Chmod(openOnly{testFsys}, "hello.txt", 0644),
+		"Chown":   Chown(openOnly{testFsys}, "hello.txt", -1, -1),
+		"Chtimes": Chtimes(openOnly{testFsys}, "hello.txt", mtime, mtime),
+	} {
+		if !errors.Is(err, ErrPermission) {
+			t.Errorf("%s(openOnly) = %v, want ErrPermission", name, err)
+		}
+	}
+}
+
+func TestSymlinkLink(t *testing.T) {
+	fsys := os.DirFS(t.TempDir())
+	if err := WriteFile(fsys, "a", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if testenv.HasLink() {
+		if err := Link(fsys, "a", "hard"); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := ReadFile(fsys, "hard"); string(data) != "a" || err != nil {
+			t.Errorf(`ReadFile(DirFS, "hard") = %q, %v, want %q, nil`, data, err, "a")
+		}
+	}
+	if testenv.HasSymlink() {
+		if err := Symlink(fsys, "a", "sym"); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := ReadFile(fsys, "sym"); string(data) != "a" || err != nil {
+			t.Errorf(`ReadFile(DirFS, "sym") = %q, %v, want %q, nil`, data, err, "a")
+		}
+	}
+
+	// Test that the helpers fail on a file system without the methods.
+	if err := Symlink(openOnly{testFsys}, "hello.txt", "x"); !errors.Is(err, ErrPermission) {
+		t.Errorf("Symlink(openOnly) = %v, want ErrPermission", err)
+	}
+	if err := Link(openOnly{testFsys}, "hello.txt", "x"); !errors.Is(err, ErrPermission) {
+		t.Errorf("Link(openOnly) = %v, want ErrPermission", err)
+	}
+}
@@ -14,6 +14,7 @@ package io
 
 import (
 	"errors"
+	"internal/iodrain"
 	"sync"
 )
 
@@ -31,6 +32,10 @@ var ErrShortWrite = errors.New("short write")
 // errInvalidWrite means that a write returned an impossible count.
 var errInvalidWrite = errors.New("invalid write result")
 
+// errNegativeOffset is returned for negative offsets or lengths
+// passed to the ReaderAt and WriterAt helpers.
+var errNegativeOffset = errors.New("negative offset")
+
 // ErrShortBuffer means that a read required a longer buffer than was provided.
 var ErrShortBuffer = errors.New("short buffer")
 
@@ -196,6 +201,58 @@ type WriterTo interface {
 	WriteTo(w Writer) (n int64, err error)
 }
 
+// ReaderUnwrapper is the interface that wraps the UnwrapReader method.
+//
+// UnwrapReader returns the Reader that the implementation reads from,
+// such as an *os.File or a network connection. By implementing it, a
+// wrapper declares that reading from it is equivalent to reading from
+// the returned Reader: data passes through unchanged, and the wrapper
+// does not need to see it. Copy and the sendfile, splice and
+// copy_file_range paths of packages os and net then use the returned
+// Reader directly, so that wrapping a file or connection does not
+// prevent zero-copy transfers. If the wrapper implements WriterTo
+// itself, Copy calls that method instead of unwrapping it.
+type ReaderUnwrapper interface {
+	UnwrapReader() Reader
+}
+
+// WriterUnwrapper is the interface that wraps the UnwrapWriter method.
+//
+// UnwrapWriter returns the Writer that the implementation writes to. By
+// implementing it, a wrapper declares that writing to it is equivalent
+// to writing to the returned Writer, with the same meaning as for
+// ReaderUnwrapper. If the wrapper implements ReaderFrom itself, Copy
+// calls that method instead of unwrapping it.
+type WriterUnwrapper interface {
+	UnwrapWriter() Writer
+}
+
+// UnwrapReader calls the UnwrapReader method of r, and of the Reader
+// that returns, and so on, until it reaches a Reader that does not
+// implement ReaderUnwrapper, and returns that Reader.
+func UnwrapReader(r Reader) Reader {
+	for {
+		u, ok := r.(ReaderUnwrapper)
+		if !ok {
+			return r
+		}
+		r = u.UnwrapReader()
+	}
+}
+
+// UnwrapWriter calls the UnwrapWriter method of w, and of the Writer
+// that returns, and so on, until it reaches a Writer that does not
+// implement WriterUnwrapper, and returns that Writer.
+func UnwrapWriter(w Writer) Writer {
+	for {
+		u, ok := w.(WriterUnwrapper)
+		if !ok {
+			return w
+		}
+		w = u.UnwrapWriter()
+	}
+}
+
 // ReaderAt is the interface that wraps the basic ReadAt method.
 //
 // ReadAt reads len(p) bytes into p starting at offset off in the
@@ -301,6 +358,19 @@ type StringWriter interface {
 	WriteString(s string) (n int, err error)
 }
 
+// StringWriterTo is the interface that wraps the WriteStringTo method.
+//
+// WriteStringTo writes data to w until there's no more data to write or
+// when an error occurs, like WriterTo, but hands the data to w as
+// strings. Readers whose data is held in strings implement it so that
+// copying them to a StringWriter needs no conversion to a []byte.
+//
+// Copy uses WriteStringTo in preference to WriteTo when the destination
+// implements StringWriter.
+type StringWriterTo interface {
+	WriteStringTo(w StringWriter) (n int64, err error)
+}
+
 // WriteString writes the contents of the string s to w, which accepts a slice of bytes.
 // If w implements StringWriter, its WriteString method is invoked directly.
 // Otherwise, w.Write is called exactly once.
@@ -311,6 +381,46 @@ func WriteString(w Writer, s string) (n int, err error) {
 	return w.Write([]byte(s))
 }
 
+// VecWriter is the interface that wraps the WriteVec method.
+//
+// WriteVec writes the contents of bufs, in order, as if they had been
+// concatenated into a single buffer, using one system call such as
+// writev where the implementation can. It returns the total number of
+// bytes written and any error encountered that caused the write to stop
+// early. WriteVec must not modify bufs or the data it refers to, even
+// temporarily, and implementations must not retain bufs.
+//
+// Writers that issue a system call per Write, such as files and network
+// connections, implement VecWriter so that framed messages (a header and
+// a payload, say) can be sent without a copy into a single buffer and
+// without a system call per segment.
+type VecWriter interface {
+	WriteVec(bufs [][]byte) (n int64, err error)
+}
+
+// WriteVec writes the contents of bufs to w, in order.
+// If w implements VecWriter, its WriteVec method is invoked directly.
+// Otherwise, w.Write is called once for each non-empty buffer.
+func WriteVec(w Writer, bufs [][]byte) (n int64, err error) {
+	if vw, ok := w.(VecWriter); ok {
+		return vw.WriteVec(bufs)
+	}
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		nb, err := w.Write(b)
+		n += int64(nb)
+		if err != nil {
+			return n, err
+		}
+		if nb != len(b) {
+			return n, ErrShortWrite
+		}
+	}
+	return n, nil
+}
+
 // ReadAtLeast reads from r into buf until it has read at least min bytes.
 // It returns the number of bytes copied and an error if fewer bytes were read.
 // The error is EOF only if no bytes were read.
@@ -347,6 +457,37 @@ func ReadFull(r Reader, buf []byte) (n int, err error) {
 	return ReadAtLeast(r, buf, len(buf))
 }
 
+// ReadFullAt reads exactly len(buf) bytes from r at offset off into buf.
+// It returns the number of bytes copied and an error if fewer bytes were read.
+// The error is EOF only if no bytes were read.
+// If an EOF happens after reading some but not all the bytes,
+// ReadFullAt returns ErrUnexpectedEOF.
+// On return, n == len(buf) if and only if err == nil.
+// If r returns an error having read len(buf) bytes, the error is dropped.
+//
+// ReadFullAt tolerates ReaderAt implementations that return short
+// reads without an error by issuing further reads for the remainder.
+// Like ReadAt itself, it may be called in parallel on the same r.
+func ReadFullAt(r ReaderAt, buf []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	}
+	for n < len(buf) && err == nil {
+		var nn int
+		nn, err = r.ReadAt(buf[n:], off+int64(n))
+		if nn == 0 && err == nil {
+			err = ErrNoProgress
+		}
+		n += nn
+	}
+	if n == len(buf) {
+		err = nil
+	} else if n > 0 && err == EOF {
+		err = ErrUnexpectedEOF
+	}
+	return
+}
+
 // CopyN copies n bytes (or until an error) from src to dst.
 // It returns the number of bytes copied and the earliest
 // error encountered while copying.
@@ -366,6 +507,62 @@ func CopyN(dst Writer, src Reader, n int64) (written int64, err error) {
 	return
 }
 
+// CopyAt copies n bytes (or until an error) from src starting at offset off
+// to dst at the same offset. It returns the number of bytes copied and the
+// earliest error encountered while copying.
+// On return, written == n if and only if err == nil.
+// If src ends before n bytes have been copied, the error is EOF.
+//
+// CopyAt uses only the ReadAt and WriteAt methods of src and dst, and
+// neither depends on nor changes their offsets for Read and Write.
+// Copies of non-overlapping ranges may therefore run in parallel, which
+// is how large transfers between files can be split among goroutines.
+// For *os.File values, ReadAt and WriteAt use pread and pwrite
+// where available.
+func CopyAt(dst WriterAt, src ReaderAt, off, n int64) (written int64, err error) {
+	if off < 0 || n < 0 {
+		return 0, errNegativeOffset
+	}
+	size := 32 * 1024
+	if int64(size) > n {
+		size = int(n)
+	}
+	buf := make([]byte, size)
+	for written < n {
+		chunk := buf
+		if remain := n - written; remain < int64(len(chunk)) {
+			chunk = chunk[:remain]
+		}
+		nr, er := ReadFullAt(src, chunk, off+written)
+		if nr > 0 {
+			nw, ew := dst.WriteAt(chunk[:nr], off+written)
+			if nw < 0 || nr < nw {
+				nw = 0
+				if ew == nil {
+					ew = errInvalidWrite
+				}
+			}
+			written += int64(nw)
+			if ew != nil {
+				err = ew
+				break
+			}
+			if nr != nw {
+				err = ErrShortWrite
+				break
+			}
+		}
+		if er != nil {
+			if er == ErrUnexpectedEOF {
+				er = EOF
+			}
+			err = er
+			break
+		}
+	}
+	return written, err
+}
+
 // Copy copies from src to dst until either EOF is reached
 // on src or an error occurs. It returns the number of bytes
 // copied and the first error encountered while copying, if any.
@@ -379,7 +576,47 @@ func CopyN(dst Writer, src Reader, n int64) (written int64, err error) {
 // Otherwise, if dst implements the ReaderFrom interface,
 // the copy is implemented by calling dst.ReadFrom(src).
 func Copy(dst Writer, src Reader) (written int64, err error) {
-	return copyBuffer(dst, src, nil)
+	return copyBuffer(dst, src, nil, false)
+}
+
+// CopyError records which side of a copy failed, so that a caller
+// such as a proxy can tell whether to reset the source or the
+// destination.
+type CopyError struct {
+	Op  string // "read" or "write"
+	Err error
+}
+
+func (e *CopyError) Error() string { return e.Op + ": " + e.Err.Error() }
+
+func (e *CopyError) Unwrap() error { return e.Err }
+
+// Timeout reports whether Err is a timeout, so that wrapping an error
+// in a *CopyError does not hide it from callers that check for one,
+// such as those of net.Error.
+func (e *CopyError) Timeout() bool {
+	t, ok := e.Err.(interface{ Timeout() bool })
+	return ok && t.Timeout()
+}
+
+// Temporary reports whether Err is temporary, like Timeout.
+func (e *CopyError) Temporary() bool {
+	t, ok := e.Err.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+// CopyAttributed is like Copy, but reports whether reading from src or
+// writing to dst failed: an error from src.Read is returned wrapped in a
+// *CopyError with Op "read", and an error from dst.Write, including
+// ErrShortWrite, in one with Op "write".
+//
+// Errors from the WriteTo and ReadFrom methods used for the copy are
+// returned as they are, because the side that failed is in general
+// unknown. Implementations that know it, such as the splice path of
+// net.TCPConn.ReadFrom, include a *CopyError in the errors they return,
+// which can be retrieved with errors.As.
+func CopyAttributed(dst Writer, src Reader) (written int64, err error) {
+	return copyBuffer(dst, src, nil, true)
 }
 
 // CopyBuffer is identical to Copy except that it stages through the
@@ -393,12 +630,24 @@ func CopyBuffer(dst Writer, src Reader, buf []byte) (written int64, err error) {
 	if buf != nil && len(buf) == 0 {
 		panic("empty buffer in CopyBuffer")
 	}
-	return copyBuffer(dst, src, buf)
+	return copyBuffer(dst, src, buf, false)
 }
 
-// copyBuffer is the actual implementation of Copy and CopyBuffer.
-// if buf is nil, one is allocated.
-func copyBuffer(dst Writer, src Reader, buf []byte) (written int64, err error) {
+// copyBuffer is the actual implementation of Copy, CopyBuffer and
+// CopyAttributed. If buf is nil, one is allocated. If attribute is set,
+// errors from the copy loop are wrapped in a *CopyError.
+func copyBuffer(dst Writer, src Reader, buf []byte, attribute bool) (written int64, err error) {
+	// Copy to and from what pass-through wrappers wrap, so that its
+	// fast paths below are found, unless a wrapper has a fast path of
+	// its own.
+	dst, src = unwrapCopyWriter(dst), unwrapCopyReader(src)
+	// If the reader can hand its data over as strings and the writer
+	// accepts them, do so; the data is never converted to a []byte.
+	if st, ok := src.(StringWriterTo); ok {
+		if sw, ok := dst.(StringWriter); ok {
+			return st.WriteStringTo(sw)
+		}
+	}
 	// If the reader has a WriteTo method, use it to do the copy.
 	// Avoids an allocation and a copy.
 	if wt, ok := src.(WriterTo); ok {
@@ -430,18 +679,23 @@ func copyBuffer(dst Writer, src Reader, buf []byte) (written int64, err error) {
 				}
 			}
 			written += int64(nw)
+			if ew == nil && nr != nw {
+				ew = ErrShortWrite
+			}
 			if ew != nil {
 				err = ew
-				break
-			}
-			if nr != nw {
-				err = ErrShortWrite
+				if attribute {
+					err = &CopyError{Op: "write", Err: ew}
+				}
 				break
 			}
 		}
 		if er != nil {
 			if er != EOF {
 				err = er
+				if attribute {
+					err = &CopyError{Op: "read", Err: er}
+				}
 			}
 			break
 		}
@@ -449,6 +703,37 @@ func copyBuffer(dst Writer, src Reader, buf []byte) (written int64, err error) {
 	return written, err
 }
 
+// unwrapCopyReader is like UnwrapReader, but stops at a Reader that
+// implements WriterTo or StringWriterTo, whose method Copy uses.
+func unwrapCopyReader(r Reader) Reader {
+	for {
+		switch r.(type) {
+		case WriterTo, StringWriterTo:
+			return r
+		}
+		u, ok := r.(ReaderUnwrapper)
+		if !ok {
+			return r
+		}
+		r = u.UnwrapReader()
+	}
+}
+
+// unwrapCopyWriter is like UnwrapWriter, but stops at a Writer that
+// implements ReaderFrom, whose method Copy uses.
+func unwrapCopyWriter(w Writer) Writer {
+	for {
+		if _, ok := w.(ReaderFrom); ok {
+			return w
+		}
+		u, ok := w.(WriterUnwrapper)
+		if !ok {
+			return w
+		}
+		w = u.UnwrapWriter()
+	}
+}
+
 // LimitReader returns a Reader that reads from r
 // but stops with EOF after n bytes.
 // The underlying implementation is a *LimitedReader.
@@ -542,6 +827,75 @@ func (s *SectionReader) ReadAt(p []byte, off int64) (n int, err error) {
 // Size returns the size of the section in bytes.
 func (s *SectionReader) Size() int64 { return s.limit - s.base }
 
+// NewSectionWriter returns a SectionWriter that writes to w
+// starting at offset off and stops with ErrShortWrite after n bytes.
+func NewSectionWriter(w WriterAt, off int64, n int64) *SectionWriter {
+	return &SectionWriter{w, off, off, off + n}
+}
+
+// SectionWriter implements Write, Seek, and WriteAt on a section
+// of an underlying WriterAt. Several SectionWriters on different
+// sections of the same WriterAt, such as an *os.File, may be used
+// concurrently if the WriterAt supports concurrent WriteAt calls.
+type SectionWriter struct {
+	w     WriterAt
+	base  int64
+	off   int64
+	limit int64
+}
+
+func (s *SectionWriter) Write(p []byte) (n int, err error) {
+	if s.off >= s.limit {
+		return 0, ErrShortWrite
+	}
+	if max := s.limit - s.off; int64(len(p)) > max {
+		p = p[0:max]
+		err = ErrShortWrite
+	}
+	n, werr := s.w.WriteAt(p, s.off)
+	s.off += int64(n)
+	if werr != nil {
+		err = werr
+	}
+	return n, err
+}
+
+func (s *SectionWriter) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	default:
+		return 0, errWhence
+	case SeekStart:
+		offset += s.base
+	case SeekCurrent:
+		offset += s.off
+	case SeekEnd:
+		offset += s.limit
+	}
+	if offset < s.base {
+		return 0, errOffset
+	}
+	s.off = offset
+	return offset - s.base, nil
+}
+
+func (s *SectionWriter) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off >= s.limit-s.base {
+		return 0, ErrShortWrite
+	}
+	off += s.base
+	if max := s.limit - off; int64(len(p)) > max {
+		n, err = s.w.WriteAt(p[0:max], off)
+		if err == nil {
+			err = ErrShortWrite
+		}
+		return n, err
+	}
+	return s.w.WriteAt(p, off)
+}
+
+// Size returns the size of the section in bytes.
+func (s *SectionWriter) Size() int64 { return s.limit - s.base }
+
 // TeeReader returns a Reader that writes to w what it reads from r.
 // All reads from r performed through it are matched with
 // corresponding writes to w. There is no internal buffering -
@@ -592,6 +946,9 @@ var blackHolePool = sync.Pool{
 }
 
 func (discard) ReadFrom(r Reader) (n int64, err error) {
+	if n, err, handled := drain(r); handled {
+		return n, err
+	}
 	bufp := blackHolePool.Get().(*[]byte)
 	readSize := 0
 	for {
@@ -607,6 +964,27 @@ func (discard) ReadFrom(r Reader) (n int64, err error) {
 	}
 }
 
+// drain discards the data of r without reading it into memory, if the
+// package implementing r registered a way to do so with internal/iodrain,
+// such as splicing a socket into /dev/null.
+//
+// If drain returns handled == false, it has performed no work.
+func drain(r Reader) (n int64, err error, handled bool) {
+	var remain int64 = 1<<63 - 1 // by default, discard until EOF
+	lr, ok := r.(*LimitedReader)
+	if ok {
+		remain, r = lr.N, lr.R
+		if remain <= 0 {
+			return 0, nil, true
+		}
+	}
+	n, handled, err = iodrain.Drain(unwrapCopyReader(r), remain)
+	if lr != nil {
+		lr.N -= n
+	}
+	return n, err, handled
+}
+
 // NopCloser returns a ReadCloser with a no-op Close method wrapping
 // the provided Reader r.
 func NopCloser(r Reader) ReadCloser {
@@ -619,6 +997,38 @@ type nopCloser struct {
 
 func (nopCloser) Close() error { return nil }
 
+// CloseAll closes each of closers in order, skipping nil ones, and
+// returns the errors from their Close methods joined with errors.Join.
+// Unlike a sequence of deferred or checked Close calls that stops at the
+// first failure, CloseAll always attempts to close every value.
+func CloseAll(closers ...Closer) error {
+	var errs []error
+	for _, c := range closers {
+		if c == nil {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Closers is a list of values to be closed together, such as the
+// resources acquired while setting something up.
+type Closers []Closer
+
+// Close closes the values in cs in reverse order, so that resources are
+// released in the opposite order to that in which they were appended,
+// and returns the errors joined with errors.Join. See CloseAll.
+func (cs Closers) Close() error {
+	rev := make([]Closer, len(cs))
+	for i, c := range cs {
+		rev[len(cs)-1-i] = c
+	}
+	return CloseAll(rev...)
+}
+
 // ReadAll reads from r until an error or EOF and returns the data it read.
 // A successful call returns err == nil, not err == EOF. Because ReadAll is
 // defined to read from src until EOF, it does not treat an EOF from Read
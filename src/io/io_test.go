@@ -87,6 +87,166 @@ func TestCopyWriteTo(t *testing.T) {
 	}
 }
 
+// writeStringToChecker is a strings.Reader that records which of its
+// WriteTo and WriteStringTo methods was called.
+type writeStringToChecker struct {
+	strings.Reader
+	called string
+}
+
+func (r *writeStringToChecker) WriteTo(w Writer) (int64, error) {
+	r.called = "WriteTo"
+	return r.Reader.WriteTo(w)
+}
+
+func (r *writeStringToChecker) WriteStringTo(w StringWriter) (int64, error) {
+	r.called = "WriteStringTo"
+	return r.Reader.WriteStringTo(w)
+}
+
+type passThroughReader struct{ r Reader }
+
+func (p passThroughReader) Read(b []byte) (int, error) { return p.r.Read(b) }
+func (p passThroughReader) UnwrapReader() Reader       { return p.r }
+
+type passThroughWriter struct{ w Writer }
+
+func (p passThroughWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+func (p passThroughWriter) UnwrapWriter() Writer        { return p.w }
+
+func TestCopyUnwraps(t *testing.T) {
+	rb := new(writeToChecker)
+	rb.WriteString("hello, world.")
+	wb := new(bytes.Buffer)
+	src := passThroughReader{passThroughReader{rb}}
+	if UnwrapReader(src) != rb {
+		t.Fatalf("UnwrapReader did not return the innermost reader")
+	}
+	Copy(passThroughWriter{wb}, src)
+	if !rb.writeToCalled {
+		t.Error("WriteTo of the wrapped reader was not used")
+	}
+	if wb.String() != "hello, world." {
+		t.Errorf("Copy did not work properly")
+	}
+}
+
+// countingPassThroughReader unwraps to its Reader, but also counts the
+// bytes copied through its own WriteTo.
+type countingPassThroughReader struct {
+	passThroughReader
+	n int64
+}
+
+func (c *countingPassThroughReader) WriteTo(w Writer) (int64, error) {
+	n, err := Copy(w, c.r)
+	c.n += n
+	return n, err
+}
+
+// countingPassThroughWriter unwraps to its Writer, but also counts the
+// bytes copied through its own ReadFrom.
+type countingPassThroughWriter struct {
+	passThroughWriter
+	n int64
+}
+
+func (c *countingPassThroughWriter) ReadFrom(r Reader) (int64, error) {
+	n, err := Copy(c.w, r)
+	c.n += n
+	return n, err
+}
+
+func TestCopyWrapperFastPaths(t *testing.T) {
+	rb := new(writeToChecker)
+	rb.WriteString("hello, world.")
+	src := &countingPassThroughReader{passThroughReader: passThroughReader{rb}}
+	wb := new(Buffer)
+	if n, err := Copy(passThroughWriter{wb}, passThroughReader{src}); n != 13 || err != nil {
+		t.Fatalf("Copy = %d, %v; want 13, nil", n, err)
+	}
+	if src.n != 13 {
+		t.Errorf("WriteTo of the wrapper copied %d bytes; want 13", src.n)
+	}
+	if !rb.writeToCalled {
+		t.Error("WriteTo of the wrapped reader was not used")
+	}
+
+	dst := &countingPassThroughWriter{passThroughWriter: passThroughWriter{new(bytes.Buffer)}}
+	rb2 := new(Buffer)
+	rb2.WriteString("hello, world.")
+	if n, err := Copy(passThroughWriter{dst}, passThroughReader{rb2}); n != 13 || err != nil {
+		t.Fatalf("Copy = %d, %v; want 13, nil", n, err)
+	}
+	if dst.n != 13 {
+		t.Errorf("ReadFrom of the wrapper copied %d bytes; want 13", dst.n)
+	}
+}
+
+func TestCopyWriteStringTo(t *testing.T) {
+	tests := []struct {
+		dst  Writer
+		want string
+	}{
+		{new(strings.Builder), "WriteStringTo"},
+		{struct{ Writer }{new(strings.Builder)}, "WriteTo"},
+	}
+	for _, tt := range tests {
+		src := &writeStringToChecker{Reader: *strings.NewReader("hello, world.")}
+		n, err := Copy(tt.dst, src)
+		if n != 13 || err != nil {
+			t.Errorf("Copy to %T = %d, %v; want 13, nil", tt.dst, n, err)
+		}
+		if src.called != tt.want {
+			t.Errorf("Copy to %T called %s; want %s", tt.dst, src.called, tt.want)
+		}
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestCopyAttributed(t *testing.T) {
+	errRead := errors.New("read failed")
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		dst    Writer
+		src    Reader
+		op     string
+		err    error
+		copied int64
+	}{
+		{new(Buffer), errReader{errRead}, "read", errRead, 0},
+		{errWriter{errWrite}, struct{ Reader }{strings.NewReader("x")}, "write", errWrite, 0},
+		{struct{ Writer }{shortWriter{}}, struct{ Reader }{strings.NewReader("xyz")}, "write", ErrShortWrite, 2},
+	}
+	for _, tt := range tests {
+		n, err := CopyAttributed(tt.dst, tt.src)
+		var ce *CopyError
+		if !errors.As(err, &ce) || ce.Op != tt.op || !errors.Is(err, tt.err) {
+			t.Errorf("CopyAttributed(%T, %T) error = %v; want %s error wrapping %v", tt.dst, tt.src, err, tt.op, tt.err)
+		}
+		if n != tt.copied {
+			t.Errorf("CopyAttributed(%T, %T) = %d; want %d", tt.dst, tt.src, n, tt.copied)
+		}
+	}
+
+	// Copy itself still returns errors as they are.
+	if _, err := Copy(new(Buffer), errReader{errRead}); err != errRead {
+		t.Errorf("Copy error = %v; want %v", err, errRead)
+	}
+	// A successful copy reports no error.
+	if n, err := CopyAttributed(new(Buffer), strings.NewReader("hello")); n != 5 || err != nil {
+		t.Errorf("CopyAttributed = %d, %v; want 5, nil", n, err)
+	}
+}
+
+// shortWriter accepts all but the last byte of each write.
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) { return len(p) - 1, nil }
+
 // Version of bytes.Buffer that checks whether WriteTo was called or not
 type writeToChecker struct {
 	bytes.Buffer
@@ -430,6 +590,53 @@ func TestSectionReader_Size(t *testing.T) {
 	}
 }
 
+// writerAt is a WriterAt writing into a byte slice.
+type writerAt []byte
+
+func (w writerAt) WriteAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(w)) {
+		return 0, ErrShortWrite
+	}
+	n := copy(w[off:], p)
+	if n < len(p) {
+		return n, ErrShortWrite
+	}
+	return n, nil
+}
+
+func TestSectionWriter(t *testing.T) {
+	buf := writerAt(bytes.Repeat([]byte("."), 10))
+	s := NewSectionWriter(buf, 2, 5)
+	if n, err := s.Write([]byte("ab")); n != 2 || err != nil {
+		t.Fatalf("Write = %d, %v; want 2, nil", n, err)
+	}
+	if n, err := s.Write([]byte("cdef")); n != 3 || err != ErrShortWrite {
+		t.Fatalf("Write past end = %d, %v; want 3, ErrShortWrite", n, err)
+	}
+	if n, err := s.Write([]byte("x")); n != 0 || err != ErrShortWrite {
+		t.Fatalf("Write at end = %d, %v; want 0, ErrShortWrite", n, err)
+	}
+	if n, err := s.WriteAt([]byte("XY"), 4); n != 1 || err != ErrShortWrite {
+		t.Fatalf("WriteAt(4) = %d, %v; want 1, ErrShortWrite", n, err)
+	}
+	if n, err := s.WriteAt([]byte("Z"), 0); n != 1 || err != nil {
+		t.Fatalf("WriteAt(0) = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := s.WriteAt([]byte("Z"), -1); n != 0 || err != ErrShortWrite {
+		t.Fatalf("WriteAt(-1) = %d, %v; want 0, ErrShortWrite", n, err)
+	}
+	if off, err := s.Seek(1, SeekStart); off != 1 || err != nil {
+		t.Fatalf("Seek = %d, %v; want 1, nil", off, err)
+	}
+	s.Write([]byte("q"))
+	if got, want := string(buf), "..ZqcdX..."; got != want {
+		t.Errorf("buffer = %q; want %q", got, want)
+	}
+	if s.Size() != 5 {
+		t.Errorf("Size = %d; want 5", s.Size())
+	}
+}
+
 // largeWriter returns an invalid count that is larger than the number
 // of bytes provided (issue 39978).
 type largeWriter struct {
@@ -457,3 +664,132 @@ func TestCopyLargeWriter(t *testing.T) {
 		t.Errorf("Copy error: got %v, want %v", err, want)
 	}
 }
+
+// shortReaderAt returns at most max bytes per ReadAt call, without an error.
+type shortReaderAt struct {
+	r   ReaderAt
+	max int
+}
+
+func (s shortReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) > s.max {
+		p = p[:s.max]
+	}
+	n, err := s.r.ReadAt(p, off)
+	if err == EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func TestReadFullAt(t *testing.T) {
+	const data = "0123456789"
+	r := shortReaderAt{strings.NewReader(data), 3}
+	tests := []struct {
+		off  int64
+		size int
+		want string
+		err  error
+	}{
+		{0, 10, data, nil},
+		{2, 5, "23456", nil},
+		{5, 10, "56789", ErrUnexpectedEOF},
+		{10, 1, "", EOF},
+		{3, 0, "", nil},
+	}
+	for _, tt := range tests {
+		buf := make([]byte, tt.size)
+		n, err := ReadFullAt(r, buf, tt.off)
+		if got := string(buf[:n]); got != tt.want || err != tt.err {
+			t.Errorf("ReadFullAt(off=%d, len=%d) = %q, %v; want %q, %v", tt.off, tt.size, got, err, tt.want, tt.err)
+		}
+	}
+	if _, err := ReadFullAt(r, make([]byte, 1), -1); err == nil {
+		t.Error("ReadFullAt with negative offset succeeded")
+	}
+}
+
+// bufferAt is a WriterAt backed by a byte slice.
+type bufferAt struct {
+	buf []byte
+}
+
+func (b *bufferAt) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(b.buf) {
+		b.buf = append(b.buf, make([]byte, end-len(b.buf))...)
+	}
+	return copy(b.buf[off:], p), nil
+}
+
+func TestCopyAt(t *testing.T) {
+	data := strings.Repeat("abcdefghij", 10000)
+	src := strings.NewReader(data)
+	dst := &bufferAt{buf: make([]byte, len(data))}
+
+	// Copy in reverse order of chunks to show that offsets,
+	// not stream positions, decide where data goes.
+	const chunk = 40000
+	for off := int64(len(data) / chunk * chunk); off >= 0; off -= chunk {
+		n := int64(chunk)
+		if off+n > int64(len(data)) {
+			n = int64(len(data)) - off
+		}
+		written, err := CopyAt(dst, src, off, n)
+		if written != n || err != nil {
+			t.Fatalf("CopyAt(off=%d, n=%d) = %d, %v", off, n, written, err)
+		}
+	}
+	if string(dst.buf) != data {
+		t.Fatal("CopyAt produced wrong contents")
+	}
+
+	written, err := CopyAt(&bufferAt{}, src, int64(len(data)-5), 10)
+	if written != 5 || err != EOF {
+		t.Errorf("CopyAt past end = %d, %v; want 5, EOF", written, err)
+	}
+}
+
+type recordingCloser struct {
+	name   string
+	err    error
+	closed *[]string
+}
+
+func (c recordingCloser) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return c.err
+}
+
+func TestCloseAll(t *testing.T) {
+	var closed []string
+	errA := errors.New("a failed")
+	errC := errors.New("c failed")
+	err := CloseAll(
+		recordingCloser{"a", errA, &closed},
+		nil,
+		recordingCloser{"b", nil, &closed},
+		recordingCloser{"c", errC, &closed},
+	)
+	if got := strings.Join(closed, ","); got != "a,b,c" {
+		t.Errorf("closed %s; want a,b,c", got)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errC) {
+		t.Errorf("CloseAll error = %v; want both close errors", err)
+	}
+	if err := CloseAll(recordingCloser{"d", nil, &closed}); err != nil {
+		t.Errorf("CloseAll error = %v; want nil", err)
+	}
+}
+
+func TestClosers(t *testing.T) {
+	var closed []string
+	var cs Closers
+	cs = append(cs, recordingCloser{"a", nil, &closed})
+	cs = append(cs, recordingCloser{"b", nil, &closed})
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(closed, ","); got != "b,a" {
+		t.Errorf("closed %s; want b,a", got)
+	}
+}
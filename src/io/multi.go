@@ -41,6 +41,93 @@ func (mr *multiReader) Read(p []byte) (n int, err error) {
 	return 0, EOF
 }
 
+// WriteTo implements WriterTo. When w implements VecWriter, the
+// contents of consecutive readers that hold them in memory and hand
+// them out without a copy (they have Len and Next methods, as
+// *bytes.Reader and *bytes.Buffer do) are passed to a single WriteVec
+// call rather than written one at a time. Other readers are copied
+// with Copy.
+func (mr *multiReader) WriteTo(w Writer) (n int64, err error) {
+	vw, vec := w.(VecWriter)
+	var bufs [][]byte
+	for len(mr.readers) > 0 {
+		if len(mr.readers) == 1 {
+			if r, ok := mr.readers[0].(*multiReader); ok {
+				mr.readers = r.readers
+				continue
+			}
+		}
+		if vec {
+			bufs = mr.segments(bufs[:0])
+			if len(bufs) > 0 {
+				nw, err := vw.WriteVec(bufs)
+				n += nw
+				if err != nil {
+					return n, err
+				}
+				continue
+			}
+			if len(mr.readers) == 0 {
+				break
+			}
+		}
+		nc, err := Copy(w, mr.readers[0])
+		n += nc
+		if err != nil {
+			return n, err
+		}
+		mr.readers[0] = eofReader{} // permit earlier GC
+		mr.readers = mr.readers[1:]
+	}
+	return n, nil
+}
+
+// nexter is implemented by readers whose remaining contents are held
+// in memory and can be handed out without a copy.
+type nexter interface {
+	Len() int
+	Next(n int) []byte
+}
+
+// segments appends the contents of the leading readers of mr that are
+// nexters to bufs, and removes them from mr once they return EOF. Len
+// only sizes what a reader hands out: a reader whose Len is stale, and
+// which still holds data after handing out Len bytes, ends the
+// segments and stays in mr. segments does nothing unless at least two
+// readers lead mr.
+func (mr *multiReader) segments(bufs [][]byte) [][]byte {
+	count := 0
+	for _, r := range mr.readers {
+		if _, ok := r.(nexter); !ok {
+			break
+		}
+		count++
+	}
+	if count < 2 {
+		return bufs
+	}
+	probe := make([]byte, 1)
+	for i := 0; i < count; i++ {
+		r := mr.readers[0]
+		nr := r.(nexter)
+		if b := nr.Next(nr.Len()); len(b) > 0 {
+			bufs = append(bufs, b)
+		}
+		m, err := r.Read(probe)
+		if m > 0 {
+			bufs = append(bufs, probe[:m])
+			break
+		}
+		if err != EOF {
+			// Copy reports any error.
+			break
+		}
+		mr.readers[0] = eofReader{}
+		mr.readers = mr.readers[1:]
+	}
+	return bufs
+}
+
 // MultiReader returns a Reader that's the logical concatenation of
 // the provided input readers. They're read sequentially. Once all
 // inputs have returned EOF, Read will return EOF.  If any of the readers
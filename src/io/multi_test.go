@@ -352,3 +352,105 @@ func TestInterleavedMultiReader(t *testing.T) {
 		t.Errorf(`ReadFull(mr1) = (%q, %v), want ("5678", nil)`, got, err)
 	}
 }
+
+// vecWriter records the writes made to it and implements VecWriter.
+type vecWriter struct {
+	writes []string
+	vecs   [][][]byte // the arguments of WriteVec
+}
+
+func (w *vecWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *vecWriter) WriteVec(bufs [][]byte) (int64, error) {
+	w.vecs = append(w.vecs, append([][]byte(nil), bufs...))
+	var s string
+	for _, b := range bufs {
+		s += string(b)
+	}
+	w.writes = append(w.writes, s)
+	return int64(len(s)), nil
+}
+
+func TestWriteVec(t *testing.T) {
+	bufs := [][]byte{[]byte("a"), nil, []byte("bc")}
+	var plain bytes.Buffer
+	if n, err := WriteVec(&plain, bufs); n != 3 || err != nil || plain.String() != "abc" {
+		t.Errorf("WriteVec(bytes.Buffer) = %d, %v, wrote %q; want 3, nil, %q", n, err, plain.String(), "abc")
+	}
+	vw := new(vecWriter)
+	if n, err := WriteVec(vw, bufs); n != 3 || err != nil || len(vw.writes) != 1 {
+		t.Errorf("WriteVec(VecWriter) = %d, %v with writes %q; want a single write", n, err, vw.writes)
+	}
+}
+
+func TestMultiReaderWriteToVec(t *testing.T) {
+	head := []byte("head")
+	big := bytes.Repeat([]byte("x"), 100<<10)
+	mr := MultiReader(
+		bytes.NewReader(head),
+		bytes.NewReader([]byte("er ")),
+		bytes.NewBufferString("payload"),
+		struct{ Reader }{bytes.NewReader([]byte(" unsized"))},
+		strings.NewReader(" a"),
+		bytes.NewReader([]byte(" b")),
+		bytes.NewReader(big),
+	)
+	vw := new(vecWriter)
+	n, err := Copy(vw, mr)
+	want := "header payload unsized a b" + string(big)
+	if got := strings.Join(vw.writes, ""); got != want || n != int64(len(want)) || err != nil {
+		t.Fatalf("Copy = %d, %v; want %d, nil and matching contents", n, err, len(want))
+	}
+	wantWrites := []string{"header payload", " unsized", " a", " b" + string(big)}
+	if len(vw.writes) != len(wantWrites) {
+		t.Fatalf("got %d writes; want %d", len(vw.writes), len(wantWrites))
+	}
+	for i, w := range wantWrites {
+		if vw.writes[i] != w {
+			t.Errorf("write %d = %.20q; want %.20q", i, vw.writes[i], w)
+		}
+	}
+	if len(vw.vecs) != 2 || len(vw.vecs[0]) != 3 || &vw.vecs[0][0][0] != &head[0] {
+		t.Errorf("WriteVec got %d calls; want 2, the first passing the readers' own slices", len(vw.vecs))
+	}
+
+	// Empty readers need no write.
+	vw = new(vecWriter)
+	mr = MultiReader(bytes.NewReader(nil), new(bytes.Buffer))
+	if n, err := Copy(vw, mr); n != 0 || err != nil || len(vw.writes) != 0 {
+		t.Errorf("Copy of empty readers = %d, %v with writes %q; want 0, nil and no writes", n, err, vw.writes)
+	}
+
+	// Writers that do not implement VecWriter see the readers one at a time.
+	var buf bytes.Buffer
+	mr = MultiReader(bytes.NewReader([]byte("a")), bytes.NewReader([]byte("b")))
+	if _, err := Copy(&buf, mr); err != nil || buf.String() != "ab" {
+		t.Errorf("Copy to bytes.Buffer = %q, %v", buf.String(), err)
+	}
+}
+
+// staleLenReader reports a Len that is smaller than what it holds.
+type staleLenReader struct {
+	*bytes.Reader
+}
+
+func (r staleLenReader) Len() int { return r.Reader.Len() / 2 }
+
+func TestMultiReaderWriteToStaleLen(t *testing.T) {
+	big := strings.Repeat("y", 64<<10)
+	mr := MultiReader(
+		staleLenReader{bytes.NewReader([]byte("abcdef"))},
+		bytes.NewReader([]byte("gh")),
+		staleLenReader{bytes.NewReader([]byte(big))},
+		bytes.NewReader([]byte("z")),
+	)
+	vw := new(vecWriter)
+	n, err := Copy(vw, mr)
+	want := "abcdefgh" + big + "z"
+	if got := strings.Join(vw.writes, ""); got != want || n != int64(len(want)) || err != nil {
+		t.Fatalf("Copy = %d, %v, wrote %d bytes; want %d, nil and matching contents", n, err, len(got), len(want))
+	}
+}
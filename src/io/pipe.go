@@ -194,6 +194,12 @@ func (w *PipeWriter) CloseWithError(err error) error {
 // It is safe to call Read and Write in parallel with each other or with Close.
 // Parallel calls to Read and parallel calls to Write are also safe:
 // the individual calls will be gated sequentially.
+//
+// The pipe has no read or write deadlines: package io cannot depend on
+// package time. A blocked Read or Write can be interrupted by closing
+// the other end with CloseWithError, for instance from a time.AfterFunc
+// callback. Callers that need resettable deadlines should use net.Pipe,
+// which implements the full net.Conn interface.
 func Pipe() (*PipeReader, *PipeWriter) {
 	p := &pipe{
 		wrCh: make(chan []byte),
@@ -0,0 +1,187 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "syscall"
+
+// A Message is a datagram and its ancillary data, as read by the
+// ReadBatch and written by the WriteBatch methods of UDPConn and IPConn.
+type Message struct {
+	// Buffers holds the payload. ReadBatch scatters a datagram
+	// across the buffers in order and WriteBatch gathers it from them.
+	Buffers [][]byte
+
+	// OOB holds the ancillary data (control messages).
+	OOB []byte
+
+	// Addr is the address a datagram is sent to, which must be nil
+	// on a connected socket, or the address it was received from.
+	// For UDPConn it is a *UDPAddr and for IPConn an *IPAddr.
+	//
+	// When Addr already holds an address of that type, ReadBatch
+	// stores the sender's address in it, reusing its IP slice if it
+	// is large enough, instead of allocating a new one.
+	Addr Addr
+
+	// N is the number of bytes of Buffers read or written.
+	N int
+
+	// NN is the number of bytes of OOB read.
+	NN int
+
+	// Flags is the set of flags of a received message, as
+	// returned by the recvmsg system call.
+	Flags int
+
+	// SegmentSize is the size of the UDP datagrams that make up the
+	// payload, or 0 if it is a single datagram. For WriteBatch, a
+	// non-zero SegmentSize splits the payload into datagrams of that
+	// size, the last of which may be shorter; on Linux the kernel does
+	// the splitting (UDP generic segmentation offload). ReadBatch sets
+	// SegmentSize when the kernel has joined several received datagrams
+	// into one message, which happens only after SetReceiveOffload(true)
+	// and needs room in OOB for the control message reporting it.
+	SegmentSize int
+
+	// ECN is the ECN codepoint of the datagrams. For WriteBatch, a
+	// non-zero ECN marks the datagrams with it in place of the
+	// codepoint set with UDPConn.SetECN. ReadBatch sets ECN only after
+	// UDPConn.SetReceiveECN(true) and needs room in OOB for the control
+	// message reporting it. ECN is supported on Linux only.
+	ECN ECN
+}
+
+func (c *UDPConn) readBatch(ms []Message, flags int) (int, error) {
+	if n, err, handled := readBatch(c.fd, ms, flags); handled {
+		return n, err
+	}
+	if flags != 0 {
+		return 0, syscall.EINVAL
+	}
+	return readBatchOne(ms, func(b, oob []byte) (n, oobn, flags int, addr Addr, err error) {
+		n, oobn, flags, a, err := c.readMsg(b, oob)
+		if a != nil {
+			addr = a
+		}
+		return n, oobn, flags, addr, err
+	})
+}
+
+func (c *UDPConn) writeBatch(ms []Message, flags int) (int, error) {
+	if n, err, handled := writeBatch(c.fd, ms, flags); handled {
+		return n, err
+	}
+	if flags != 0 {
+		return 0, syscall.EINVAL
+	}
+	return writeBatchOne(ms, func(b, oob []byte, addr Addr) (int, error) {
+		a, ok := addr.(*UDPAddr)
+		if !ok && addr != nil {
+			return 0, syscall.EINVAL
+		}
+		n, _, err := c.writeMsg(b, oob, a)
+		return n, err
+	})
+}
+
+func (c *IPConn) readBatch(ms []Message, flags int) (int, error) {
+	if n, err, handled := readBatch(c.fd, ms, flags); handled {
+		return n, err
+	}
+	if flags != 0 {
+		return 0, syscall.EINVAL
+	}
+	return readBatchOne(ms, func(b, oob []byte) (n, oobn, flags int, addr Addr, err error) {
+		n, oobn, flags, a, err := c.readMsg(b, oob)
+		if a != nil {
+			addr = a
+		}
+		return n, oobn, flags, addr, err
+	})
+}
+
+func (c *IPConn) writeBatch(ms []Message, flags int) (int, error) {
+	if n, err, handled := writeBatch(c.fd, ms, flags); handled {
+		return n, err
+	}
+	if flags != 0 {
+		return 0, syscall.EINVAL
+	}
+	return writeBatchOne(ms, func(b, oob []byte, addr Addr) (int, error) {
+		a, ok := addr.(*IPAddr)
+		if !ok && addr != nil {
+			return 0, syscall.EINVAL
+		}
+		n, _, err := c.writeMsg(b, oob, a)
+		return n, err
+	})
+}
+
+// readBatchOne reads a single message into ms[0] with read,
+// for systems that cannot read several messages at once.
+func readBatchOne(ms []Message, read func(b, oob []byte) (n, oobn, flags int, addr Addr, err error)) (int, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	m := &ms[0]
+	var b []byte
+	if len(m.Buffers) == 1 {
+		b = m.Buffers[0]
+	} else {
+		size := 0
+		for _, buf := range m.Buffers {
+			size += len(buf)
+		}
+		b = make([]byte, size)
+	}
+	n, oobn, flags, addr, err := read(b, m.OOB)
+	if err != nil {
+		return 0, err
+	}
+	if len(m.Buffers) != 1 {
+		rest := b[:n]
+		for _, buf := range m.Buffers {
+			rest = rest[copy(buf, rest):]
+		}
+	}
+	m.N, m.NN, m.Flags, m.Addr = n, oobn, flags, addr
+	m.SegmentSize = 0
+	m.ECN = 0
+	return 1, nil
+}
+
+// writeBatchOne writes the messages in ms one at a time with write,
+// for systems that cannot write several messages at once. It splits
+// messages with a SegmentSize into datagrams itself.
+func writeBatchOne(ms []Message, write func(b, oob []byte, addr Addr) (int, error)) (int, error) {
+	for i := range ms {
+		m := &ms[i]
+		var b []byte
+		if len(m.Buffers) == 1 {
+			b = m.Buffers[0]
+		} else {
+			for _, buf := range m.Buffers {
+				b = append(b, buf...)
+			}
+		}
+		m.N = 0
+		for {
+			seg := b
+			if m.SegmentSize > 0 && len(seg) > m.SegmentSize {
+				seg = seg[:m.SegmentSize]
+			}
+			n, err := write(seg, m.OOB, m.Addr)
+			m.N += n
+			if err != nil {
+				return i, err
+			}
+			b = b[len(seg):]
+			if len(b) == 0 {
+				break
+			}
+		}
+	}
+	return len(ms), nil
+}
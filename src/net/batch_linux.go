@@ -0,0 +1,309 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// mmsgBuffers holds the system call structures for a batch of messages.
+// They are kept in mmsgPool so that batches do not allocate.
+type mmsgBuffers struct {
+	hdrs  []unix.Mmsghdr
+	iovs  []syscall.Iovec
+	names []syscall.RawSockaddrAny
+	oob   []byte // control messages built for WriteBatch
+}
+
+var mmsgPool = sync.Pool{
+	New: func() interface{} { return new(mmsgBuffers) },
+}
+
+// getMmsgBuffers returns buffers set up to read
+// or write the payload and ancillary data of ms.
+func getMmsgBuffers(ms []Message) *mmsgBuffers {
+	b := mmsgPool.Get().(*mmsgBuffers)
+	niov := 0
+	for i := range ms {
+		niov += len(ms[i].Buffers)
+	}
+	if cap(b.hdrs) < len(ms) {
+		b.hdrs = make([]unix.Mmsghdr, len(ms))
+		b.names = make([]syscall.RawSockaddrAny, len(ms))
+	}
+	if cap(b.iovs) < niov {
+		b.iovs = make([]syscall.Iovec, niov)
+	}
+	b.hdrs = b.hdrs[:len(ms)]
+	b.names = b.names[:len(ms)]
+	b.iovs = b.iovs[:niov]
+
+	iovs := b.iovs
+	for i := range ms {
+		m := &ms[i]
+		h := &b.hdrs[i]
+		*h = unix.Mmsghdr{}
+		if len(m.Buffers) > 0 {
+			h.Hdr.Iov = &iovs[0]
+			h.SetIovlen(len(m.Buffers))
+			for j, buf := range m.Buffers {
+				iovs[j] = syscall.Iovec{}
+				if len(buf) > 0 {
+					iovs[j].Base = &buf[0]
+					iovs[j].SetLen(len(buf))
+				}
+			}
+			iovs = iovs[len(m.Buffers):]
+		}
+		if len(m.OOB) > 0 {
+			h.Hdr.Control = &m.OOB[0]
+			h.Hdr.SetControllen(len(m.OOB))
+		}
+	}
+	return b
+}
+
+// putMmsgBuffers drops the references b holds
+// to the caller's memory and returns it to the pool.
+func putMmsgBuffers(b *mmsgBuffers) {
+	for i := range b.hdrs {
+		b.hdrs[i] = unix.Mmsghdr{}
+	}
+	for i := range b.iovs {
+		b.iovs[i] = syscall.Iovec{}
+	}
+	mmsgPool.Put(b)
+}
+
+// readBatch reads up to len(ms) messages with the recvmmsg system call.
+func readBatch(fd *netFD, ms []Message, flags int) (int, error, bool) {
+	if len(ms) == 0 {
+		return 0, nil, true
+	}
+	b := getMmsgBuffers(ms)
+	defer putMmsgBuffers(b)
+	for i := range b.hdrs {
+		b.hdrs[i].Hdr.Name = (*byte)(unsafe.Pointer(&b.names[i]))
+		b.hdrs[i].Hdr.Namelen = syscall.SizeofSockaddrAny
+	}
+	n, err := fd.pfd.RecvMmsg(b.hdrs, flags)
+	runtime.KeepAlive(fd)
+	for i := 0; i < n; i++ {
+		m := &ms[i]
+		h := &b.hdrs[i]
+		m.N = int(h.Len)
+		m.NN = int(h.Hdr.Controllen)
+		m.Flags = int(h.Hdr.Flags)
+		m.Addr = fd.batchAddr(&b.names[i], m.Addr)
+		m.SegmentSize = groSegmentSize(m.OOB[:m.NN])
+		m.ECN = receivedECN(m.OOB[:m.NN])
+	}
+	return n, wrapSyscallError("recvmmsg", err), true
+}
+
+// writeBatch writes the messages in ms with the sendmmsg system call.
+func writeBatch(fd *netFD, ms []Message, flags int) (int, error, bool) {
+	if len(ms) == 0 {
+		return 0, nil, true
+	}
+	b := getMmsgBuffers(ms)
+	defer putMmsgBuffers(b)
+	for i := range ms {
+		namelen, err := fd.batchSockaddr(ms[i].Addr, &b.names[i])
+		if err != nil {
+			return 0, err, true
+		}
+		if namelen > 0 {
+			b.hdrs[i].Hdr.Name = (*byte)(unsafe.Pointer(&b.names[i]))
+			b.hdrs[i].Hdr.Namelen = namelen
+		}
+	}
+	if err := b.setControlMessages(fd, ms); err != nil {
+		return 0, err, true
+	}
+	n, err := fd.pfd.SendMmsg(b.hdrs, flags)
+	runtime.KeepAlive(fd)
+	for i := 0; i < n; i++ {
+		ms[i].N = int(b.hdrs[i].Len)
+	}
+	return n, wrapSyscallError("sendmmsg", err), true
+}
+
+// setControlMessages adds to the ancillary data of each message in ms
+// a UDP_SEGMENT control message if it has a SegmentSize, and control
+// messages setting its TOS or traffic class if it has an ECN.
+func (b *mmsgBuffers) setControlMessages(fd *netFD, ms []Message) error {
+	size := 0
+	hasECN := false
+	for i := range ms {
+		m := &ms[i]
+		if m.ECN > ECNCE {
+			return syscall.EINVAL
+		}
+		extra := 0
+		if m.SegmentSize > 0 {
+			extra += segmentCmsgSpace
+		}
+		if m.ECN != 0 {
+			extra += tosCmsgSpace(fd)
+			hasECN = true
+		}
+		if extra > 0 {
+			size += cmsgAlign(len(m.OOB)) + extra
+		}
+	}
+	if size == 0 {
+		return nil
+	}
+	var tos int
+	if hasECN {
+		var err error
+		if tos, err = socketTOS(fd); err != nil {
+			return err
+		}
+	}
+	if cap(b.oob) < size {
+		b.oob = make([]byte, 0, size)
+	}
+	// oob has room for everything, so it does not move
+	// and the pointers taken into it stay valid.
+	oob := b.oob[:0]
+	for i := range ms {
+		m := &ms[i]
+		if m.SegmentSize <= 0 && m.ECN == 0 {
+			continue
+		}
+		start := len(oob)
+		oob = append(oob, m.OOB...)
+		if m.SegmentSize > 0 {
+			oob = appendSegmentCmsg(oob, m.SegmentSize)
+		}
+		if m.ECN != 0 {
+			oob = appendTOSCmsg(oob, fd, tos&^ecnMask|int(m.ECN))
+		}
+		h := &b.hdrs[i]
+		h.Hdr.Control = &oob[start]
+		h.Hdr.SetControllen(len(oob) - start)
+	}
+	return nil
+}
+
+// batchAddr returns the address in rsa as a *UDPAddr or *IPAddr,
+// according to the type of fd, reusing old if it is of that type.
+func (fd *netFD) batchAddr(rsa *syscall.RawSockaddrAny, old Addr) Addr {
+	var (
+		ip   []byte
+		port int
+		zone string
+	)
+	switch rsa.Addr.Family {
+	case syscall.AF_INET:
+		sa := (*syscall.RawSockaddrInet4)(unsafe.Pointer(rsa))
+		p := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		ip, port = sa.Addr[:], int(p[0])<<8+int(p[1])
+	case syscall.AF_INET6:
+		sa := (*syscall.RawSockaddrInet6)(unsafe.Pointer(rsa))
+		p := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		ip, port = sa.Addr[:], int(p[0])<<8+int(p[1])
+		zone = zoneCache.name(int(sa.Scope_id))
+	default:
+		return nil
+	}
+	if fd.sotype == syscall.SOCK_RAW {
+		a, ok := old.(*IPAddr)
+		if !ok || a == nil {
+			a = new(IPAddr)
+		}
+		a.IP = append(a.IP[:0], ip...)
+		a.Zone = zone
+		return a
+	}
+	a, ok := old.(*UDPAddr)
+	if !ok || a == nil {
+		a = new(UDPAddr)
+	}
+	a.IP = append(a.IP[:0], ip...)
+	a.Port = port
+	a.Zone = zone
+	return a
+}
+
+// batchSockaddr stores addr in rsa for sending to it on fd and
+// returns its length, or 0 if fd is connected and addr is nil.
+func (fd *netFD) batchSockaddr(addr Addr, rsa *syscall.RawSockaddrAny) (uint32, error) {
+	var (
+		ip   IP
+		port int
+		zone string
+	)
+	switch a := addr.(type) {
+	case nil:
+	case *UDPAddr:
+		if fd.sotype == syscall.SOCK_RAW {
+			return 0, syscall.EINVAL
+		}
+		if a != nil {
+			ip, port, zone = a.IP, a.Port, a.Zone
+		} else {
+			addr = nil
+		}
+	case *IPAddr:
+		if fd.sotype != syscall.SOCK_RAW {
+			return 0, syscall.EINVAL
+		}
+		if a != nil {
+			ip, zone = a.IP, a.Zone
+		} else {
+			addr = nil
+		}
+	default:
+		return 0, syscall.EINVAL
+	}
+	if addr == nil {
+		if !fd.isConnected {
+			return 0, errMissingAddress
+		}
+		return 0, nil
+	}
+	if fd.isConnected {
+		return 0, ErrWriteToConnected
+	}
+
+	switch fd.family {
+	case syscall.AF_INET:
+		if len(ip) == 0 {
+			ip = IPv4zero
+		}
+		ip4 := ip.To4()
+		if ip4 == nil {
+			return 0, &AddrError{Err: "non-IPv4 address", Addr: ip.String()}
+		}
+		sa := (*syscall.RawSockaddrInet4)(unsafe.Pointer(rsa))
+		*sa = syscall.RawSockaddrInet4{Family: syscall.AF_INET}
+		p := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		p[0], p[1] = byte(port>>8), byte(port)
+		copy(sa.Addr[:], ip4)
+		return syscall.SizeofSockaddrInet4, nil
+	case syscall.AF_INET6:
+		if len(ip) == 0 || ip.Equal(IPv4zero) {
+			ip = IPv6zero
+		}
+		ip6 := ip.To16()
+		if ip6 == nil {
+			return 0, &AddrError{Err: "non-IPv6 address", Addr: ip.String()}
+		}
+		sa := (*syscall.RawSockaddrInet6)(unsafe.Pointer(rsa))
+		*sa = syscall.RawSockaddrInet6{Family: syscall.AF_INET6, Scope_id: uint32(zoneCache.index(zone))}
+		p := (*[2]byte)(unsafe.Pointer(&sa.Port))
+		p[0], p[1] = byte(port>>8), byte(port)
+		copy(sa.Addr[:], ip6)
+		return syscall.SizeofSockaddrInet6, nil
+	}
+	return 0, &AddrError{Err: "invalid address family", Addr: ip.String()}
+}
@@ -0,0 +1,16 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package net
+
+func readBatch(fd *netFD, ms []Message, flags int) (int, error, bool) {
+	return 0, nil, false
+}
+
+func writeBatch(fd *netFD, ms []Message, flags int) (int, error, bool) {
+	return 0, nil, false
+}
@@ -73,6 +73,9 @@ func cgoLookupPort(ctx context.Context, network, service string) (port int, err
 	case "udp", "udp4", "udp6":
 		hints.ai_socktype = C.SOCK_DGRAM
 		hints.ai_protocol = C.IPPROTO_UDP
+	case "sctp", "sctp4", "sctp6":
+		hints.ai_socktype = C.SOCK_STREAM
+		hints.ai_protocol = ipprotoSCTP
 	default:
 		return 0, &DNSError{Err: "unknown network", Name: network + "/" + service}, true
 	}
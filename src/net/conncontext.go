@@ -0,0 +1,171 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConnWithContext returns a Conn that reads from and writes to c
+// until ctx is done.
+//
+// If ctx has a deadline, the deadlines of the returned Conn are no
+// later than it. When ctx is canceled, the Read and Write calls in
+// progress on the returned Conn return, and later calls fail at once.
+// The errors returned because ctx is done are *OpErrors that wrap
+// ctx.Err(), so that errors.Is(err, context.Canceled) or
+// errors.Is(err, context.DeadlineExceeded) reports true for them.
+//
+// Until ctx is done or the returned Conn is closed, a goroutine
+// watches ctx if it can be canceled, so the returned Conn should be
+// closed when no longer needed. Closing it closes c. The deadlines of
+// c should not be set directly afterward, as the returned Conn
+// overrides them.
+func ConnWithContext(ctx context.Context, c Conn) Conn {
+	cc := &ctxConn{Conn: c, ctx: ctx, closed: make(chan struct{})}
+	if _, ok := ctx.Deadline(); ok {
+		cc.setDeadlines()
+	}
+	if done := ctx.Done(); done != nil {
+		select {
+		case <-done:
+			cc.expire()
+		default:
+			go func() {
+				select {
+				case <-done:
+					cc.expire()
+				case <-cc.closed:
+				}
+			}()
+		}
+	}
+	return cc
+}
+
+// A ctxConn is a Conn returned by ConnWithContext.
+type ctxConn struct {
+	Conn
+	ctx context.Context
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu      sync.Mutex
+	rd, wd  time.Time // deadlines set by the user
+	expired bool      // whether ctx is done
+}
+
+func (c *ctxConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		err = c.mapErr("read", err)
+	}
+	return n, err
+}
+
+func (c *ctxConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if err != nil {
+		err = c.mapErr("write", err)
+	}
+	return n, err
+}
+
+func (c *ctxConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+func (c *ctxConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rd, c.wd = t, t
+	return c.setDeadlinesLocked()
+}
+
+func (c *ctxConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rd = t
+	return c.setDeadlinesLocked()
+}
+
+func (c *ctxConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wd = t
+	return c.setDeadlinesLocked()
+}
+
+// expire interrupts the I/O on c, once ctx is done.
+func (c *ctxConn) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expired = true
+	c.setDeadlinesLocked()
+}
+
+func (c *ctxConn) setDeadlines() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setDeadlinesLocked()
+}
+
+// setDeadlinesLocked sets the deadlines of the underlying Conn to the
+// earlier of those set by the user and the deadline of ctx.
+func (c *ctxConn) setDeadlinesLocked() error {
+	rd, wd := c.rd, c.wd
+	if c.expired {
+		rd, wd = aLongTimeAgo, aLongTimeAgo
+	} else if d, ok := c.ctx.Deadline(); ok {
+		if rd.IsZero() || d.Before(rd) {
+			rd = d
+		}
+		if wd.IsZero() || d.Before(wd) {
+			wd = d
+		}
+	}
+	if rd.Equal(wd) {
+		return c.Conn.SetDeadline(rd)
+	}
+	if err := c.Conn.SetReadDeadline(rd); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(wd)
+}
+
+// mapErr returns the error to report for err, which an operation op
+// on the underlying Conn returned: an error that wraps ctx.Err() if
+// the operation failed because ctx is done.
+func (c *ctxConn) mapErr(op string, err error) error {
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		return err
+	}
+	cerr := c.ctx.Err()
+	if cerr == nil {
+		// The deadline of ctx may have passed before the timer
+		// of ctx fired.
+		d, ok := c.ctx.Deadline()
+		if !ok || time.Now().Before(d) {
+			return err
+		}
+		cerr = context.DeadlineExceeded
+	}
+	if oe, ok := err.(*OpError); ok {
+		e := *oe
+		e.Err = cerr
+		return &e
+	}
+	e := &OpError{Op: op, Source: c.LocalAddr(), Addr: c.RemoteAddr(), Err: cerr}
+	if e.Source != nil {
+		e.Net = e.Source.Network()
+	}
+	return e
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConnWithContextCancel(t *testing.T) {
+	c1, c2 := Pipe()
+	defer c2.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := ConnWithContext(ctx, c1)
+	defer c.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Read(make([]byte, 1))
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Read after cancel: %v; want an error wrapping %v", err, context.Canceled)
+		}
+		var oe *OpError
+		if !errors.As(err, &oe) || oe.Op != "read" {
+			t.Errorf("Read after cancel: %#v; want a read *OpError", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read not interrupted by cancel")
+	}
+	if _, err := c.Write([]byte("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write after cancel: %v; want an error wrapping %v", err, context.Canceled)
+	}
+	// Setting a deadline does not revive the connection.
+	c.SetDeadline(time.Time{})
+	if _, err := c.Write([]byte("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write after cancel and SetDeadline: %v; want an error wrapping %v", err, context.Canceled)
+	}
+}
+
+func TestConnWithContextCanceled(t *testing.T) {
+	c1, c2 := Pipe()
+	defer c2.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := ConnWithContext(ctx, c1)
+	defer c.Close()
+	if _, err := c.Write([]byte("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write: %v; want an error wrapping %v", err, context.Canceled)
+	}
+}
+
+func TestConnWithContextDeadline(t *testing.T) {
+	c1, c2 := Pipe()
+	defer c2.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := ConnWithContext(ctx, c1)
+	defer c.Close()
+
+	// A later deadline does not extend that of ctx.
+	c.SetReadDeadline(time.Now().Add(time.Hour))
+	_, err := c.Read(make([]byte, 1))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Read: %v; want an error wrapping %v", err, context.DeadlineExceeded)
+	}
+	if ne, ok := err.(Error); !ok || !ne.Timeout() {
+		t.Errorf("Read: %v; want a timeout", err)
+	}
+}
+
+func TestConnWithContextUserDeadline(t *testing.T) {
+	c1, c2 := Pipe()
+	defer c2.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	c := ConnWithContext(ctx, c1)
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err := c.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Read: %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	// The data flows while ctx is not done.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c2.Write([]byte("hello"))
+		errc <- err
+	}()
+	c.SetReadDeadline(time.Time{})
+	b := make([]byte, 5)
+	if n, err := c.Read(b); err != nil || string(b[:n]) != "hello" {
+		t.Errorf("Read = %q, %v; want %q, nil", b[:n], err, "hello")
+	}
+	if err := <-errc; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// CopyTimeout copies from src to dst like io.Copy, but gives up once no
+// data has been read or written for the idle duration. This is the
+// idle timeout a proxy forwarding between two connections needs.
+// It returns the number of bytes copied and the first error encountered
+// while copying, if any. A copy that timed out returns an error that
+// wraps os.ErrDeadlineExceeded.
+//
+// The idle timeout is implemented with deadlines: if src has a
+// SetReadDeadline method, or dst has a SetWriteDeadline method, as
+// connections and pollable files do, the deadline is moved forward
+// before every read or write, and cleared when CopyTimeout returns.
+// Reads or writes on a side without deadline support are not bounded by
+// the timeout. CopyTimeout never closes src or dst.
+//
+// Unlike io.Copy, CopyTimeout does not use the WriterTo or ReaderFrom
+// methods of src and dst, since those may move arbitrary amounts of
+// data in one call.
+func CopyTimeout(dst io.Writer, src io.Reader, idle time.Duration) (written int64, err error) {
+	rd, rok := src.(interface{ SetReadDeadline(time.Time) error })
+	wd, wok := dst.(interface{ SetWriteDeadline(time.Time) error })
+	// Deadlines are not supported on every file; check up front.
+	if rok && rd.SetReadDeadline(time.Time{}) == nil {
+		defer rd.SetReadDeadline(time.Time{})
+	} else {
+		rok = false
+	}
+	if wok && wd.SetWriteDeadline(time.Time{}) == nil {
+		defer wd.SetWriteDeadline(time.Time{})
+	} else {
+		wok = false
+	}
+	return copyIdle(dst, src, func(reading bool) {
+		if reading && rok {
+			rd.SetReadDeadline(time.Now().Add(idle))
+		} else if !reading && wok {
+			wd.SetWriteDeadline(time.Now().Add(idle))
+		}
+	})
+}
+
+// errInvalidWrite means that a write returned an impossible count.
+var errInvalidWrite = errors.New("invalid write result")
+
+// copyIdle is the copy loop of CopyTimeout. It calls arm before every
+// read and write, with reading reporting which one is next.
+func copyIdle(dst io.Writer, src io.Reader, arm func(reading bool)) (written int64, err error) {
+	buf := make([]byte, 32*1024)
+	for {
+		arm(true)
+		nr, er := src.Read(buf)
+		if nr > 0 {
+			arm(false)
+			nw, ew := dst.Write(buf[:nr])
+			if nw < 0 || nr < nw {
+				nw = 0
+				if ew == nil {
+					ew = errInvalidWrite
+				}
+			}
+			written += int64(nw)
+			if ew != nil {
+				err = ew
+				break
+			}
+			if nr != nw {
+				err = io.ErrShortWrite
+				break
+			}
+		}
+		if er != nil {
+			if er != io.EOF {
+				err = er
+			}
+			break
+		}
+	}
+	return written, err
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyTimeoutDeadlines(t *testing.T) {
+	src, srcPeer := Pipe()
+	dst, dstPeer := Pipe()
+	defer src.Close()
+	defer dst.Close()
+
+	go func() {
+		srcPeer.Write([]byte("hello"))
+		// Stay idle without closing, so the copy must time out.
+	}()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(dstPeer)
+		got <- b
+	}()
+
+	n, err := CopyTimeout(dst, src, 50*time.Millisecond)
+	if n != 5 || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("CopyTimeout = %d, %v; want 5, timeout", n, err)
+	}
+	dst.Close()
+	if b := <-got; string(b) != "hello" {
+		t.Errorf("copied %q; want %q", b, "hello")
+	}
+	srcPeer.Close()
+	dstPeer.Close()
+}
+
+func TestCopyTimeoutEOF(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := CopyTimeout(&buf, strings.NewReader("hello"), time.Minute)
+	if n != 5 || err != nil || buf.String() != "hello" {
+		t.Fatalf("CopyTimeout = %d, %v, %q; want 5, nil, %q", n, err, buf.String(), "hello")
+	}
+}
+
+// closeRecorder is a pipe end without deadline support that records
+// whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestCopyTimeoutOneDeadline(t *testing.T) {
+	// Only dst supports deadlines: the copy times out on the write
+	// and leaves src open.
+	dst, dstPeer := Pipe()
+	defer dst.Close()
+	defer dstPeer.Close()
+	src := &closeRecorder{Reader: strings.NewReader("hello")}
+	n, err := CopyTimeout(dst, src, 50*time.Millisecond)
+	if n != 0 || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("CopyTimeout = %d, %v; want 0, timeout", n, err)
+	}
+	if src.closed {
+		t.Errorf("CopyTimeout closed src")
+	}
+}
@@ -70,6 +70,14 @@ type Dialer struct {
 	// A negative value disables Fast Fallback support.
 	FallbackDelay time.Duration
 
+	// AddrPreference controls which of the IPv4 and IPv6 addresses
+	// of a host are tried first: the primary addresses, while
+	// those of the other family are the fallback addresses of Fast
+	// Fallback. By default, the family of the first address that
+	// the resolver returns is preferred, which is normally IPv6
+	// when the host has working IPv6 connectivity (RFC 6724).
+	AddrPreference AddrPreference
+
 	// KeepAlive specifies the interval between keep-alive
 	// probes for an active network connection.
 	// If zero, keep-alive probes are sent with a default value
@@ -96,6 +104,53 @@ type Dialer struct {
 	// necessarily the ones passed to Dial. For example, passing "tcp" to Dial
 	// will cause the Control function to be called with "tcp4" or "tcp6".
 	Control func(network, address string, c syscall.RawConn) error
+
+	// MultipathTCP makes TCP dials use Multipath TCP (RFC 8684),
+	// which lets a connection run over several paths at once, such
+	// as the paths through several network interfaces. The
+	// connection falls back to regular TCP if the system or the
+	// peer does not support Multipath TCP; TCPConn.MultipathTCP
+	// reports which one is used. Only Linux supports Multipath
+	// TCP; MultipathTCP is ignored on other systems.
+	MultipathTCP bool
+
+	// Mark, if not zero, sets the mark of the TCP and UDP sockets
+	// created by the dialer, which firewall and routing rules can
+	// match, as with the SO_MARK socket option. Setting a mark
+	// needs the CAP_NET_ADMIN capability on Linux. On FreeBSD, the
+	// mark is the socket cookie (SO_USER_COOKIE) that ipfw rules
+	// can match. Dialing with Mark set fails on other systems.
+	Mark uint32
+
+	// BindToDevice, if not empty, binds the TCP and UDP sockets
+	// created by the dialer to the named network interface, so that
+	// their traffic goes only through it. On Linux, binding to the
+	// master device of a VRF (virtual routing and forwarding
+	// domain) makes the sockets use the routing table of the VRF.
+	// BindToDevice uses SO_BINDTODEVICE on Linux and IP_BOUND_IF on
+	// macOS. Dialing with BindToDevice set fails on other systems.
+	BindToDevice string
+
+	// FreeBind lets the TCP and UDP sockets created by the dialer
+	// bind to a local address that is not, or not yet, assigned to
+	// any interface, as with the IP_FREEBIND socket option on Linux
+	// and IP_BINDANY on FreeBSD and OpenBSD. Dialing with FreeBind
+	// set fails on other systems.
+	FreeBind bool
+
+	// Transparent lets the TCP and UDP sockets created by the
+	// dialer bind to, and send from, any local address, including
+	// the address of the client for which a transparent proxy
+	// connects, as with the IP_TRANSPARENT socket option on Linux
+	// and IP_BINDANY on FreeBSD and OpenBSD. It needs the
+	// CAP_NET_ADMIN capability on Linux and privileges on the BSD
+	// systems. Dialing with Transparent set fails on other systems.
+	Transparent bool
+
+	// Trace, if not nil, is called as the dialer tries the
+	// addresses of the host, to let programs observe the attempts
+	// it makes to connect.
+	Trace *DialTrace
 }
 
 func (d *Dialer) dualStack() bool { return d.FallbackDelay >= 0 }
@@ -170,6 +225,8 @@ func parseNetwork(ctx context.Context, network string, needsProto bool) (afnet s
 		switch network {
 		case "tcp", "tcp4", "tcp6":
 		case "udp", "udp4", "udp6":
+		case "sctp", "sctp4", "sctp6":
+		case "vsock":
 		case "ip", "ip4", "ip6":
 			if needsProto {
 				return "", 0, UnknownNetworkError(network)
@@ -217,6 +274,15 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 			return nil, &AddrError{Err: "mismatched local address type", Addr: hint.String()}
 		}
 		return addrList{addr}, nil
+	case "vsock":
+		addr, err := ResolveVsockAddr(afnet, addr)
+		if err != nil {
+			return nil, err
+		}
+		if op == "dial" && hint != nil && addr.Network() != hint.Network() {
+			return nil, &AddrError{Err: "mismatched local address type", Addr: hint.String()}
+		}
+		return addrList{addr}, nil
 	}
 	addrs, err := r.internetAddrList(ctx, afnet, addr)
 	if err != nil || op != "dial" || hint == nil {
@@ -225,6 +291,7 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 	var (
 		tcp      *TCPAddr
 		udp      *UDPAddr
+		sctp     *SCTPAddr
 		ip       *IPAddr
 		wildcard bool
 	)
@@ -235,6 +302,9 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 	case *UDPAddr:
 		udp = hint
 		wildcard = udp.isWildcard()
+	case *SCTPAddr:
+		sctp = hint
+		wildcard = sctp.isWildcard()
 	case *IPAddr:
 		ip = hint
 		wildcard = ip.isWildcard()
@@ -255,6 +325,11 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 				continue
 			}
 			naddrs = append(naddrs, addr)
+		case *SCTPAddr:
+			if !wildcard && !addr.isWildcard() && !addr.IP.matchAddrFamily(sctp.IP) {
+				continue
+			}
+			naddrs = append(naddrs, addr)
 		case *IPAddr:
 			if !wildcard && !addr.isWildcard() && !addr.IP.matchAddrFamily(ip.IP) {
 				continue
@@ -271,11 +346,11 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 // Dial connects to the address on the named network.
 //
 // Known networks are "tcp", "tcp4" (IPv4-only), "tcp6" (IPv6-only),
-// "udp", "udp4" (IPv4-only), "udp6" (IPv6-only), "ip", "ip4"
-// (IPv4-only), "ip6" (IPv6-only), "unix", "unixgram" and
-// "unixpacket".
+// "udp", "udp4" (IPv4-only), "udp6" (IPv6-only), "sctp", "sctp4"
+// (IPv4-only), "sctp6" (IPv6-only), "ip", "ip4" (IPv4-only), "ip6"
+// (IPv6-only), "unix", "unixgram", "unixpacket" and "vsock".
 //
-// For TCP and UDP networks, the address has the form "host:port".
+// For TCP, UDP and SCTP networks, the address has the form "host:port".
 // The host must be a literal IP address, or a host name that can be
 // resolved to IP addresses.
 // The port must be a literal port number or a service name.
@@ -314,6 +389,9 @@ func (r *Resolver) resolveAddrList(ctx context.Context, op, network, addr string
 // assumed.
 //
 // For Unix networks, the address must be a file system path.
+//
+// For the "vsock" network, the address has the form "cid:port", as
+// described for ResolveVsockAddr.
 func Dial(network, address string) (Conn, error) {
 	var d Dialer
 	return d.Dial(network, address)
@@ -338,6 +416,8 @@ func DialTimeout(network, address string, timeout time.Duration) (Conn, error) {
 type sysDialer struct {
 	Dialer
 	network, address string
+	fastOpen         []byte // data for DialFastOpen, or nil
+	fallback         bool   // whether dialing the fallback addresses
 }
 
 // Dial connects to the address on the named network.
@@ -370,9 +450,53 @@ func (d *Dialer) Dial(network, address string) (Conn, error) {
 // See func Dial for a description of the network and address
 // parameters.
 func (d *Dialer) DialContext(ctx context.Context, network, address string) (Conn, error) {
+	return d.dialContext(ctx, network, address, nil)
+}
+
+// DialFastOpen connects to the address on the TCP network using the
+// provided context, as DialContext does, and writes data as the first
+// bytes sent on the connection. The network must be "tcp", "tcp4" or
+// "tcp6".
+//
+// On Linux 4.11 and later, DialFastOpen uses TCP Fast Open (RFC 7413):
+// if the host has connected to the server before and the server
+// supports Fast Open, data is carried in the SYN segment and reaches
+// the server a round trip earlier than with a Write after DialContext.
+// Otherwise, and on other systems, data is sent once the connection
+// is established. The server may receive the data in a SYN more than
+// once if the network duplicates it, so data should be idempotent.
+// For the same reason, DialFastOpen tries the addresses of the host
+// one after another, without the Fast Fallback racing of DialContext.
+//
+// DialFastOpen returns once data has been written, and an error if
+// either the connection or the write fails.
+func (d *Dialer) DialFastOpen(ctx context.Context, network, address string, data []byte) (Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, &OpError{Op: "dial", Net: network, Source: nil, Addr: nil, Err: UnknownNetworkError(network)}
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return d.dialContext(ctx, network, address, data)
+}
+
+// dialContext implements DialContext, and DialFastOpen if fastOpen
+// is not nil.
+func (d *Dialer) dialContext(ctx context.Context, network, address string, fastOpen []byte) (c Conn, err error) {
 	if ctx == nil {
 		panic("nil context")
 	}
+	if d.Trace != nil && d.Trace.DialDone != nil {
+		defer func() {
+			var ra Addr
+			if err == nil {
+				ra = c.RemoteAddr()
+			}
+			d.Trace.DialDone(network, address, ra, err)
+		}()
+	}
 	deadline := d.deadline(ctx, time.Now())
 	if !deadline.IsZero() {
 		if d, ok := ctx.Deadline(); !ok || deadline.Before(d) {
@@ -409,19 +533,26 @@ func (d *Dialer) DialContext(ctx context.Context, network, address string) (Conn
 	}
 
 	sd := &sysDialer{
-		Dialer:  *d,
-		network: network,
-		address: address,
+		Dialer:   *d,
+		network:  network,
+		address:  address,
+		fastOpen: fastOpen,
+	}
+
+	switch d.AddrPreference {
+	case PreferIPv4:
+		addrs = addrs.preferFamily(true)
+	case PreferIPv6:
+		addrs = addrs.preferFamily(false)
 	}
 
 	var primaries, fallbacks addrList
-	if d.dualStack() && network == "tcp" {
+	if d.dualStack() && network == "tcp" && fastOpen == nil {
 		primaries, fallbacks = addrs.partition(isIPv4)
 	} else {
 		primaries = addrs
 	}
 
-	var c Conn
 	if len(fallbacks) > 0 {
 		c, err = sd.dialParallel(ctx, primaries, fallbacks)
 	} else {
@@ -464,11 +595,13 @@ func (sd *sysDialer) dialParallel(ctx context.Context, primaries, fallbacks addr
 	results := make(chan dialResult) // unbuffered
 
 	startRacer := func(ctx context.Context, primary bool) {
-		ras := primaries
+		ras, rsd := primaries, sd
 		if !primary {
-			ras = fallbacks
+			fsd := *sd
+			fsd.fallback = true
+			ras, rsd = fallbacks, &fsd
 		}
-		c, err := sd.dialSerial(ctx, ras)
+		c, err := rsd.dialSerial(ctx, ras)
 		select {
 		case results <- dialResult{Conn: c, error: err, primary: primary, done: true}:
 		case <-returned:
@@ -576,20 +709,41 @@ func (sd *sysDialer) dialSingle(ctx context.Context, ra Addr) (c Conn, err error
 			defer func() { trace.ConnectDone(sd.network, raStr, err) }()
 		}
 	}
+	if t := sd.Trace; t != nil {
+		a := DialAttempt{Network: sd.network, Addr: ra, Fallback: sd.fallback, Start: time.Now()}
+		if t.AttemptStart != nil {
+			t.AttemptStart(a)
+		}
+		if t.AttemptDone != nil {
+			defer func() {
+				a.Duration, a.Err = time.Since(a.Start), err
+				t.AttemptDone(a)
+			}()
+		}
+	}
 	la := sd.LocalAddr
 	switch ra := ra.(type) {
 	case *TCPAddr:
 		la, _ := la.(*TCPAddr)
 		c, err = sd.dialTCP(ctx, la, ra)
+		if err == nil && sd.fastOpen != nil {
+			err = sd.writeFastOpen(ctx, c.(*TCPConn))
+		}
 	case *UDPAddr:
 		la, _ := la.(*UDPAddr)
 		c, err = sd.dialUDP(ctx, la, ra)
+	case *SCTPAddr:
+		la, _ := la.(*SCTPAddr)
+		c, err = sd.dialSCTP(ctx, la, ra)
 	case *IPAddr:
 		la, _ := la.(*IPAddr)
 		c, err = sd.dialIP(ctx, la, ra)
 	case *UnixAddr:
 		la, _ := la.(*UnixAddr)
 		c, err = sd.dialUnix(ctx, la, ra)
+	case *VsockAddr:
+		la, _ := la.(*VsockAddr)
+		c, err = sd.dialVsock(ctx, la, ra)
 	default:
 		return nil, &OpError{Op: "dial", Net: sd.network, Source: la, Addr: ra, Err: &AddrError{Err: "unexpected address type", Addr: sd.address}}
 	}
@@ -599,6 +753,16 @@ func (sd *sysDialer) dialSingle(ctx context.Context, ra Addr) (c Conn, err error
 	return c, nil
 }
 
+// writeFastOpen writes the data of DialFastOpen to the newly dialed
+// connection c, closing c if that fails.
+func (sd *sysDialer) writeFastOpen(ctx context.Context, c *TCPConn) error {
+	if err := c.fd.writeFastOpen(ctx, sd.fastOpen); err != nil {
+		c.Close()
+		return err
+	}
+	return nil
+}
+
 // ListenConfig contains options for listening to an address.
 type ListenConfig struct {
 	// If Control is not nil, it is called after creating the network
@@ -609,6 +773,18 @@ type ListenConfig struct {
 	// Listen will cause the Control function to be called with "tcp4" or "tcp6".
 	Control func(network, address string, c syscall.RawConn) error
 
+	// If ConnControl is not nil, it is called with each connection
+	// accepted by the stream listeners of this configuration
+	// before Accept returns it, so that options can be set on the
+	// socket of the connection before it is used. The network
+	// parameter is as for Control, and the address parameter is
+	// the remote address of the connection. ConnControl is called
+	// after the keep-alive settings are made, which it may change.
+	// If it returns an error, the connection is closed and Accept
+	// returns the error. On Plan 9 and js/wasm, ConnControl is
+	// ignored.
+	ConnControl func(network, address string, c syscall.RawConn) error
+
 	// KeepAlive specifies the keep-alive period for network
 	// connections accepted by this listener.
 	// If zero, keep-alives are enabled if supported by the protocol
@@ -616,6 +792,89 @@ type ListenConfig struct {
 	// that do not support keep-alives ignore this field.
 	// If negative, keep-alives are disabled.
 	KeepAlive time.Duration
+
+	// ReusePort specifies whether TCP and UDP sockets created by
+	// this configuration allow other sockets to bind to the same
+	// address and port, as with the SO_REUSEPORT socket option.
+	// Each of the sockets sharing the address must set ReusePort,
+	// and on most systems they must belong to the same user.
+	//
+	// On Linux, and on FreeBSD 12 and later, the kernel balances
+	// incoming connections and datagrams across the sockets
+	// listening on the address, so several processes or goroutines
+	// can each accept from their own listener. On the other BSD
+	// systems, including macOS, the sockets share the address but
+	// connections are not balanced: they go to only one of them.
+	// On Plan 9 and js/wasm, ReusePort is ignored, as is Control.
+	// On systems without support for the option, such as Solaris
+	// and Windows, listening with ReusePort set fails.
+	ReusePort bool
+
+	// FastOpen enables TCP Fast Open (RFC 7413) on TCP listeners.
+	// It lets clients that have connected before send data in their
+	// SYN segment, as with Dialer.DialFastOpen, which the listener's
+	// connections can read as soon as they are accepted. Because the
+	// network may duplicate a SYN, such data can be received more
+	// than once, so FastOpen should be enabled only for protocols
+	// whose first request is idempotent.
+	//
+	// FastOpen is supported on Linux, FreeBSD, macOS and Windows and
+	// is ignored on other systems. On Linux, server Fast Open must
+	// also be allowed by the net.ipv4.tcp_fastopen sysctl. On FreeBSD,
+	// listeners are created without Fast Open if the kernel does not
+	// support it or the net.inet.tcp.fastopen.server_enable sysctl
+	// disables it.
+	FastOpen bool
+
+	// FastOpenQueueLen limits the number of connections opened with
+	// data in their SYN that have not yet completed the handshake.
+	// If zero, the listen backlog is used. Only Linux honors
+	// FastOpenQueueLen; other systems size the queue themselves.
+	FastOpenQueueLen int
+
+	// MultipathTCP makes TCP listeners accept Multipath TCP
+	// (RFC 8684) connections, as well as regular TCP connections
+	// from clients that do not use Multipath TCP. As with
+	// Dialer.MultipathTCP, listening falls back to regular TCP if
+	// the system does not support Multipath TCP, and only Linux
+	// supports it.
+	MultipathTCP bool
+
+	// DeferAccept, if positive, makes TCP listeners hold a new
+	// connection back from Accept until the client has sent data,
+	// so that servers are not woken for connections that stay
+	// idle. On Linux, it sets the TCP_DEFER_ACCEPT socket option,
+	// and the kernel gives up waiting for data after DeferAccept,
+	// rounded up to a second; it then completes the connection if
+	// the client acknowledges another SYN-ACK segment. On FreeBSD,
+	// DragonFly BSD and NetBSD, it installs the "dataready" accept
+	// filter, which waits for data without a time limit. Other
+	// systems ignore DeferAccept.
+	DeferAccept time.Duration
+
+	// AcceptFilter names an accept filter, such as "dataready" or
+	// "httpready", to install on TCP listeners with the
+	// SO_ACCEPTFILTER socket option. The filter holds connections
+	// back from Accept until they carry data it is satisfied with;
+	// "httpready" waits for a complete HTTP request header. If set,
+	// it takes the place of DeferAccept. Accept filters exist only
+	// on FreeBSD, DragonFly BSD and NetBSD, where their kernel
+	// modules, such as accf_http, must be loaded; listening with
+	// AcceptFilter set fails elsewhere.
+	AcceptFilter string
+
+	// Mark, BindToDevice, FreeBind and Transparent set the
+	// options of the TCP and UDP sockets created by this
+	// configuration as the fields of the same name of Dialer do.
+	// A listener made with Transparent set on Linux accepts
+	// connections to any address that firewall rules, such as
+	// iptables TPROXY rules, redirect to it; the local address
+	// of each accepted connection is then the original
+	// destination address.
+	Mark         uint32
+	BindToDevice string
+	FreeBind     bool
+	Transparent  bool
 }
 
 // Listen announces on the local network address.
@@ -637,8 +896,12 @@ func (lc *ListenConfig) Listen(ctx context.Context, network, address string) (Li
 	switch la := la.(type) {
 	case *TCPAddr:
 		l, err = sl.listenTCP(ctx, la)
+	case *SCTPAddr:
+		l, err = sl.listenSCTP(ctx, la)
 	case *UnixAddr:
 		l, err = sl.listenUnix(ctx, la)
+	case *VsockAddr:
+		l, err = sl.listenVsock(ctx, la)
 	default:
 		return nil, &OpError{Op: "listen", Net: sl.network, Source: nil, Addr: la, Err: &AddrError{Err: "unexpected address type", Addr: address}}
 	}
@@ -688,9 +951,10 @@ type sysListener struct {
 
 // Listen announces on the local network address.
 //
-// The network must be "tcp", "tcp4", "tcp6", "unix" or "unixpacket".
+// The network must be "tcp", "tcp4", "tcp6", "sctp", "sctp4", "sctp6",
+// "unix", "unixpacket" or "vsock".
 //
-// For TCP networks, if the host in the address parameter is empty or
+// For TCP and SCTP networks, if the host in the address parameter is empty or
 // a literal unspecified IP address, Listen listens on all available
 // unicast and anycast IP addresses of the local system.
 // To only use IPv4, use network "tcp4".
@@ -13,6 +13,7 @@ import (
 	"internal/testenv"
 	"io"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
@@ -998,3 +999,106 @@ func TestDialWithNonZeroDeadline(t *testing.T) {
 	}
 	c.Close()
 }
+
+func TestDialerTrace(t *testing.T) {
+	if !supportsIPv4() {
+		t.Skip("IPv4 is not supported")
+	}
+	ln, err := newLocalListener("tcp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed, err := newLocalListener("tcp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr()
+	closed.Close()
+
+	var (
+		mu       sync.Mutex
+		started  []DialAttempt
+		done     []DialAttempt
+		dialDone []Addr
+	)
+	d := Dialer{Trace: &DialTrace{
+		AttemptStart: func(a DialAttempt) {
+			mu.Lock()
+			started = append(started, a)
+			mu.Unlock()
+		},
+		AttemptDone: func(a DialAttempt) {
+			mu.Lock()
+			done = append(done, a)
+			mu.Unlock()
+		},
+		DialDone: func(network, address string, addr Addr, err error) {
+			if err != nil {
+				t.Errorf("DialDone: %v", err)
+			}
+			dialDone = append(dialDone, addr)
+		},
+	}}
+	c, err := d.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if len(started) != 1 || len(done) != 1 || len(dialDone) != 1 {
+		t.Fatalf("got %d, %d and %d calls of AttemptStart, AttemptDone and DialDone; want 1 of each", len(started), len(done), len(dialDone))
+	}
+	if a := done[0]; a.Addr.String() != ln.Addr().String() || a.Fallback || a.Err != nil || a.Duration <= 0 {
+		t.Errorf("AttemptDone: got %+v", a)
+	}
+	if dialDone[0].String() != ln.Addr().String() {
+		t.Errorf("DialDone: got %v; want %v", dialDone[0], ln.Addr())
+	}
+
+	// Race a closed port against the listener.
+	started, done = nil, nil
+	sd := &sysDialer{Dialer: d, network: "tcp", address: "?"}
+	c, err = sd.dialParallel(context.Background(), addrList{closedAddr}, addrList{ln.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(done) != 2 {
+		t.Fatalf("got %d attempts; want 2", len(done))
+	}
+	for _, a := range done {
+		if a.Fallback {
+			if a.Addr != ln.Addr() || a.Err != nil {
+				t.Errorf("fallback attempt: got %+v", a)
+			}
+		} else if a.Addr != closedAddr || a.Err == nil {
+			t.Errorf("primary attempt: got %+v", a)
+		}
+	}
+}
+
+func TestAddrListPreferFamily(t *testing.T) {
+	addrs := addrList{
+		&TCPAddr{IP: ParseIP("2001:db8::1")},
+		&TCPAddr{IP: ParseIP("192.0.2.1")},
+		&TCPAddr{IP: ParseIP("2001:db8::2")},
+		&TCPAddr{IP: ParseIP("192.0.2.2")},
+	}
+	for _, tt := range []struct {
+		ipv4 bool
+		want []string
+	}{
+		{true, []string{"192.0.2.1:0", "192.0.2.2:0", "[2001:db8::1]:0", "[2001:db8::2]:0"}},
+		{false, []string{"[2001:db8::1]:0", "[2001:db8::2]:0", "192.0.2.1:0", "192.0.2.2:0"}},
+	} {
+		var got []string
+		for _, a := range addrs.preferFamily(tt.ipv4) {
+			got = append(got, a.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("preferFamily(%v) = %v; want %v", tt.ipv4, got, tt.want)
+		}
+	}
+}
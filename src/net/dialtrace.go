@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "time"
+
+// An AddrPreference is the address family whose addresses a Dialer
+// tries first.
+type AddrPreference int
+
+const (
+	// PreferResolverOrder tries the addresses of a host in the
+	// order in which the resolver returns them.
+	PreferResolverOrder AddrPreference = iota
+
+	// PreferIPv6 tries the IPv6 addresses of a host first.
+	PreferIPv6
+
+	// PreferIPv4 tries the IPv4 addresses of a host first.
+	PreferIPv4
+)
+
+// A DialTrace is a set of hooks that a Dialer calls as it connects.
+// It lets programs see which addresses were tried, how long each
+// attempt took and why it failed, which helps to debug connections
+// to hosts with both IPv4 and IPv6 addresses. Any of the hooks may
+// be nil.
+//
+// With Fast Fallback, the primary and the fallback addresses are
+// tried concurrently, so the hooks may be called concurrently.
+type DialTrace struct {
+	// AttemptStart is called when the dialer starts to connect to
+	// an address.
+	AttemptStart func(DialAttempt)
+
+	// AttemptDone is called when an attempt to connect to an
+	// address ends. Attempts still in progress when another one
+	// succeeds are canceled: they end, possibly after the dial
+	// returns, with an error saying that the operation was
+	// canceled.
+	AttemptDone func(DialAttempt)
+
+	// DialDone is called when the dial returns, with the address
+	// connected to, or the error of the dial.
+	DialDone func(network, address string, addr Addr, err error)
+}
+
+// A DialAttempt describes an attempt of a Dialer to connect to one
+// of the addresses of a host.
+type DialAttempt struct {
+	Network string
+	Addr    Addr
+
+	// Fallback reports whether Addr is one of the fallback
+	// addresses of Fast Fallback, tried after the delay of
+	// Dialer.FallbackDelay or after the primary addresses failed.
+	Fallback bool
+
+	// Start is the time the attempt started, and Duration how
+	// long it lasted. Duration is zero in AttemptStart.
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is the error that ended the attempt, or nil if it
+	// connected. It is nil in AttemptStart.
+	Err error
+}
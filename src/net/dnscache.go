@@ -0,0 +1,233 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultDNSCacheEntries is the number of answers a DNSCache holds if
+// its MaxEntries is zero.
+const defaultDNSCacheEntries = 1024
+
+// A DNSCache caches the answers of Go's built-in DNS resolver for as
+// long as their time to live (TTL). It also caches the answers that a
+// name does not exist or has no records of a type, as described in
+// RFC 2308.
+//
+// The zero value is an empty cache ready to use. A DNSCache may be
+// shared by several Resolvers and is safe for concurrent use. It must
+// not be copied after first use.
+type DNSCache struct {
+	// MaxEntries is the most answers the cache holds; the least
+	// recently used are evicted to make room for new ones.
+	// If zero, 1024 answers are held.
+	MaxEntries int
+
+	// MaxTTL, if positive, is the longest an answer is cached,
+	// whatever its TTL.
+	MaxTTL time.Duration
+
+	// MaxNegativeTTL, if positive, is the longest an answer that
+	// a name does not exist or has no records is cached. If zero,
+	// MaxTTL applies.
+	MaxNegativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[dnsCacheKey]*dnsCacheEntry
+	lru     dnsCacheEntry // sentinel; lru.next is the most recently used
+	stats   DNSCacheStats
+}
+
+// DNSCacheStats holds statistics of a DNSCache.
+type DNSCacheStats struct {
+	Entries   int    // number of answers in the cache
+	Hits      uint64 // lookups answered by the cache
+	Misses    uint64 // lookups sent to a name server
+	Evictions uint64 // answers evicted before they expired
+}
+
+type dnsCacheKey struct {
+	name  string
+	qtype dnsmessage.Type
+}
+
+type dnsCacheEntry struct {
+	key        dnsCacheKey
+	expires    time.Time
+	p          dnsmessage.Parser // positioned at the answer to return
+	server     string
+	err        *DNSError // non-nil for negative answers
+	prev, next *dnsCacheEntry
+}
+
+func (r *Resolver) cache() *DNSCache {
+	if r == nil {
+		return nil
+	}
+	return r.Cache
+}
+
+// Stats returns statistics of the cache.
+func (c *DNSCache) Stats() DNSCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = len(c.entries)
+	return s
+}
+
+// Flush removes all answers from the cache.
+func (c *DNSCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.lru.prev, c.lru.next = nil, nil
+}
+
+// lookup returns the cached answer to the question of type qtype about
+// name, if any.
+func (c *DNSCache) lookup(name string, qtype dnsmessage.Type) (p dnsmessage.Parser, server string, err error, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[dnsCacheKey{name, qtype}]
+	if e != nil && !time.Now().Before(e.expires) {
+		c.remove(e)
+		e = nil
+	}
+	if e == nil {
+		c.stats.Misses++
+		return dnsmessage.Parser{}, "", nil, false
+	}
+	c.stats.Hits++
+	c.unlink(e)
+	c.pushFront(e)
+	if e.err != nil {
+		dnsErr := *e.err
+		return e.p, e.server, &dnsErr, true
+	}
+	return e.p, e.server, nil, true
+}
+
+// add caches the answer p or the negative answer err from server to
+// the question of type qtype about name. The parser resp must be
+// positioned at the start of the answer section of the message.
+func (c *DNSCache) add(name string, qtype dnsmessage.Type, resp, p dnsmessage.Parser, server string, err *DNSError) {
+	ttl, ok := dnsResponseTTL(resp, err != nil)
+	if !ok {
+		return
+	}
+	d := time.Duration(ttl) * time.Second
+	maxTTL := c.MaxTTL
+	if err != nil && c.MaxNegativeTTL > 0 {
+		maxTTL = c.MaxNegativeTTL
+	}
+	if maxTTL > 0 && d > maxTTL {
+		d = maxTTL
+	}
+	if d <= 0 {
+		return
+	}
+	if err != nil {
+		dnsErr := *err
+		err = &dnsErr
+	}
+	e := &dnsCacheEntry{
+		key:     dnsCacheKey{name, qtype},
+		expires: time.Now().Add(d),
+		p:       p,
+		server:  server,
+		err:     err,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[dnsCacheKey]*dnsCacheEntry)
+		c.lru.prev, c.lru.next = &c.lru, &c.lru
+	}
+	if old := c.entries[e.key]; old != nil {
+		c.remove(old)
+	}
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultDNSCacheEntries
+	}
+	for len(c.entries) >= maxEntries {
+		c.remove(c.lru.prev)
+		c.stats.Evictions++
+	}
+	c.entries[e.key] = e
+	c.pushFront(e)
+}
+
+func (c *DNSCache) remove(e *dnsCacheEntry) {
+	c.unlink(e)
+	delete(c.entries, e.key)
+}
+
+func (c *DNSCache) unlink(e *dnsCacheEntry) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next = nil, nil
+}
+
+func (c *DNSCache) pushFront(e *dnsCacheEntry) {
+	e.prev, e.next = &c.lru, c.lru.next
+	c.lru.next.prev = e
+	c.lru.next = e
+}
+
+// dnsResponseTTL returns how long, in seconds, the response whose
+// answer section p is positioned at may be cached: the lowest TTL of
+// its answers or, for a negative response, the TTL given by its SOA
+// record as described in RFC 2308, section 5. It reports false if the
+// response must not be cached.
+func dnsResponseTTL(p dnsmessage.Parser, negative bool) (uint32, bool) {
+	var ttl uint32
+	found := false
+	for {
+		h, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return 0, false
+		}
+		if !negative && (!found || h.TTL < ttl) {
+			ttl, found = h.TTL, true
+		}
+		if err := p.SkipAnswer(); err != nil {
+			return 0, false
+		}
+	}
+	if !negative {
+		return ttl, found
+	}
+	for {
+		h, err := p.AuthorityHeader()
+		if err != nil {
+			return 0, false
+		}
+		if h.Type != dnsmessage.TypeSOA {
+			if err := p.SkipAuthority(); err != nil {
+				return 0, false
+			}
+			continue
+		}
+		soa, err := p.SOAResource()
+		if err != nil {
+			return 0, false
+		}
+		ttl = h.TTL
+		if soa.MinTTL < ttl {
+			ttl = soa.MinTTL
+		}
+		return ttl, true
+	}
+}
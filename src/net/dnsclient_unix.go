@@ -122,8 +122,14 @@ func dnsStreamRoundTrip(c Conn, id uint16, query dnsmessage.Question, b []byte)
 	if err != nil {
 		return dnsmessage.Parser{}, dnsmessage.Header{}, err
 	}
+	return parseDNSResponse(id, query, b[:n])
+}
+
+// parseDNSResponse parses the response b to the query with the given
+// ID and question, up to the start of its question section.
+func parseDNSResponse(id uint16, query dnsmessage.Question, b []byte) (dnsmessage.Parser, dnsmessage.Header, error) {
 	var p dnsmessage.Parser
-	h, err := p.Start(b[:n])
+	h, err := p.Start(b)
 	if err != nil {
 		return dnsmessage.Parser{}, dnsmessage.Header{}, errCannotUnmarshalDNSMessage
 	}
@@ -144,6 +150,9 @@ func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Que
 	if err != nil {
 		return dnsmessage.Parser{}, dnsmessage.Header{}, errCannotMarshalDNSMessage
 	}
+	if e := r.encrypted(); e != nil {
+		return r.exchangeEncrypted(ctx, e, id, q, tcpReq, timeout)
+	}
 	var networks []string
 	if useTCP {
 		networks = []string{"tcp"}
@@ -183,6 +192,36 @@ func (r *Resolver) exchange(ctx context.Context, server string, q dnsmessage.Que
 	return dnsmessage.Parser{}, dnsmessage.Header{}, errNoAnswerFromDNSServer
 }
 
+// exchangeEncrypted sends a query to the encrypted upstream server e.
+func (r *Resolver) exchangeEncrypted(ctx context.Context, e *EncryptedDNS, id uint16, q dnsmessage.Question, tcpReq []byte, timeout time.Duration) (dnsmessage.Parser, dnsmessage.Header, error) {
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(timeout))
+	defer cancel()
+
+	c, err := e.connect(ctx, r)
+	if err != nil {
+		return dnsmessage.Parser{}, dnsmessage.Header{}, mapErr(err)
+	}
+	defer c.Close()
+	var p dnsmessage.Parser
+	var h dnsmessage.Header
+	if e.Protocol == "https" {
+		var resp []byte
+		resp, err = e.dohRoundTrip(c, tcpReq[2:])
+		if err == nil {
+			p, h, err = parseDNSResponse(id, q, resp)
+		}
+	} else {
+		p, h, err = dnsStreamRoundTrip(c, id, q, tcpReq)
+	}
+	if err != nil {
+		return dnsmessage.Parser{}, dnsmessage.Header{}, mapErr(err)
+	}
+	if err := p.SkipQuestion(); err != dnsmessage.ErrSectionDone {
+		return dnsmessage.Parser{}, dnsmessage.Header{}, errInvalidDNSResponse
+	}
+	return p, h, nil
+}
+
 // checkHeader performs basic sanity checks on the header.
 func checkHeader(p *dnsmessage.Parser, h dnsmessage.Header) error {
 	if h.RCode == dnsmessage.RCodeNameError {
@@ -250,9 +289,21 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 		Class: dnsmessage.ClassINET,
 	}
 
+	servers := cfg.servers
+	if e := r.encrypted(); e != nil {
+		servers, serverOffset, sLen = []string{e.address()}, 0, 1
+	}
+
+	cache := r.cache()
+	if cache != nil {
+		if p, server, err, ok := cache.lookup(name, qtype); ok {
+			return p, server, err
+		}
+	}
+
 	for i := 0; i < cfg.attempts; i++ {
 		for j := uint32(0); j < sLen; j++ {
-			server := cfg.servers[(serverOffset+j)%sLen]
+			server := servers[(serverOffset+j)%sLen]
 
 			p, h, err := r.exchange(ctx, server, q, cfg.timeout, cfg.useTCP)
 			if err != nil {
@@ -273,6 +324,7 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 				continue
 			}
 
+			resp := p
 			if err := checkHeader(&p, h); err != nil {
 				dnsErr := &DNSError{
 					Err:    err.Error(),
@@ -287,6 +339,9 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 					// another server won't help.
 
 					dnsErr.IsNotFound = true
+					if cache != nil {
+						cache.add(name, qtype, resp, p, server, dnsErr)
+					}
 					return p, server, dnsErr
 				}
 				lastErr = dnsErr
@@ -295,18 +350,25 @@ func (r *Resolver) tryOneName(ctx context.Context, cfg *dnsConfig, name string,
 
 			err = skipToAnswer(&p, qtype)
 			if err == nil {
+				if cache != nil {
+					cache.add(name, qtype, resp, p, server, nil)
+				}
 				return p, server, nil
 			}
-			lastErr = &DNSError{
+			dnsErr := &DNSError{
 				Err:    err.Error(),
 				Name:   name,
 				Server: server,
 			}
+			lastErr = dnsErr
 			if err == errNoSuchHost {
 				// The name does not exist, so trying another
 				// server won't help.
 
-				lastErr.(*DNSError).IsNotFound = true
+				dnsErr.IsNotFound = true
+				if cache != nil {
+					cache.add(name, qtype, resp, p, server, dnsErr)
+				}
 				return p, server, lastErr
 			}
 		}
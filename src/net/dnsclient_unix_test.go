@@ -8,12 +8,15 @@
 package net
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -2121,3 +2124,262 @@ func TestNullMX(t *testing.T) {
 		t.Errorf("records = [%v]; want [%v]", strings.Join(records, " "), want[0])
 	}
 }
+
+func TestEncryptedDNS(t *testing.T) {
+	for _, proto := range []string{"tls", "https"} {
+		t.Run(proto, func(t *testing.T) {
+			ln, err := newLocalListener("tcp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			go serveEncryptedDNS(ln, proto)
+
+			var serverName string
+			var nextProtos []string
+			r := &Resolver{
+				Encrypted: &EncryptedDNS{
+					Protocol:   proto,
+					ServerName: "dns.example",
+					Port:       ln.Addr().(*TCPAddr).Port,
+					Bootstrap:  []IP{IPv4(127, 0, 0, 1)},
+					// The connection is left in the clear; the
+					// test is of the framing around TLS.
+					TLSClient: func(ctx context.Context, c Conn, name string, protos []string) (Conn, error) {
+						serverName, nextProtos = name, protos
+						return c, nil
+					},
+				},
+			}
+			ips, err := r.LookupIPAddr(context.Background(), "www.example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ips) != 1 || !ips[0].IP.Equal(IP(TestAddr[:])) {
+				t.Errorf("got %v; want [%v]", ips, IP(TestAddr[:]))
+			}
+			if serverName != "dns.example" {
+				t.Errorf("got server name %q; want %q", serverName, "dns.example")
+			}
+			if want := map[string]string{"tls": "dot", "https": "http/1.1"}[proto]; len(nextProtos) != 1 || nextProtos[0] != want {
+				t.Errorf("got ALPN protocols %q; want [%q]", nextProtos, want)
+			}
+		})
+	}
+}
+
+// serveEncryptedDNS answers the A and AAAA queries sent to ln over
+// proto, "tls" or "https", with TestAddr and no address, respectively.
+func serveEncryptedDNS(ln Listener, proto string) {
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer c.Close()
+			br := bufio.NewReader(c)
+			var req []byte
+			if proto == "https" {
+				var length int
+				for {
+					line, err := br.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == "\r\n" {
+						break
+					}
+					if v := strings.TrimPrefix(line, "Content-Length: "); v != line {
+						length, _ = strconv.Atoi(strings.TrimSpace(v))
+					}
+				}
+				req = make([]byte, length)
+			} else {
+				var l [2]byte
+				if _, err := io.ReadFull(br, l[:]); err != nil {
+					return
+				}
+				req = make([]byte, int(l[0])<<8|int(l[1]))
+			}
+			if _, err := io.ReadFull(br, req); err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(req); err != nil || len(q.Questions) != 1 {
+				return
+			}
+			resp := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:                 q.Header.ID,
+					Response:           true,
+					RecursionAvailable: true,
+				},
+				Questions: q.Questions,
+			}
+			if q.Questions[0].Type == dnsmessage.TypeA {
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{
+						Name:  q.Questions[0].Name,
+						Type:  dnsmessage.TypeA,
+						Class: dnsmessage.ClassINET,
+					},
+					Body: &dnsmessage.AResource{A: TestAddr},
+				}}
+			}
+			b, err := resp.Pack()
+			if err != nil {
+				return
+			}
+			if proto == "https" {
+				// Send the body chunked, to exercise the decoder.
+				fmt.Fprintf(c, "HTTP/1.1 200 OK\r\nContent-Type: application/dns-message\r\nTransfer-Encoding: chunked\r\n\r\n")
+				fmt.Fprintf(c, "%x\r\n%s\r\n%x\r\n%s\r\n0\r\n\r\n", 5, b[:5], len(b)-5, b[5:])
+			} else {
+				c.Write(append([]byte{byte(len(b) >> 8), byte(len(b))}, b...))
+			}
+		}()
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	var queries int32
+	fake := fakeDNSServer{
+		rh: func(n, _ string, q dnsmessage.Message, _ time.Time) (dnsmessage.Message, error) {
+			atomic.AddInt32(&queries, 1)
+			r := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:       q.Header.ID,
+					Response: true,
+					RCode:    dnsmessage.RCodeSuccess,
+				},
+				Questions: q.Questions,
+			}
+			if q.Questions[0].Name.String() == "nx.golang.org." {
+				r.Header.RCode = dnsmessage.RCodeNameError
+				r.Authorities = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{
+						Name:  dnsmessage.MustNewName("golang.org."),
+						Type:  dnsmessage.TypeSOA,
+						Class: dnsmessage.ClassINET,
+						TTL:   3600,
+					},
+					Body: &dnsmessage.SOAResource{
+						NS:     dnsmessage.MustNewName("ns.golang.org."),
+						MBox:   dnsmessage.MustNewName("hostmaster.golang.org."),
+						MinTTL: 60,
+					},
+				}}
+				return r, nil
+			}
+			r.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{
+					Name:  q.Questions[0].Name,
+					Type:  dnsmessage.TypeMX,
+					Class: dnsmessage.ClassINET,
+					TTL:   300,
+				},
+				Body: &dnsmessage.MXResource{
+					Pref: 10,
+					MX:   dnsmessage.MustNewName("mx.golang.org."),
+				},
+			}}
+			return r, nil
+		},
+	}
+	cache := &DNSCache{}
+	r := Resolver{PreferGo: true, Dial: fake.DialContext, Cache: cache}
+	for i := 0; i < 3; i++ {
+		mxs, err := r.LookupMX(context.Background(), "golang.org.")
+		if err != nil {
+			t.Fatalf("LookupMX: %v", err)
+		}
+		if len(mxs) != 1 || mxs[0].Host != "mx.golang.org." {
+			t.Errorf("got %v; want [mx.golang.org.]", mxs)
+		}
+		_, err = r.LookupMX(context.Background(), "nx.golang.org.")
+		if dnsErr, ok := err.(*DNSError); !ok || !dnsErr.IsNotFound {
+			t.Errorf("got %v; want not found error", err)
+		}
+	}
+	if n := atomic.LoadInt32(&queries); n != 2 {
+		t.Errorf("got %d queries; want 2", n)
+	}
+	if s := cache.Stats(); s.Entries != 2 || s.Hits != 4 || s.Misses != 2 {
+		t.Errorf("got %+v; want 2 entries, 4 hits and 2 misses", s)
+	}
+
+	cache.Flush()
+	if _, err := r.LookupMX(context.Background(), "golang.org."); err != nil {
+		t.Fatalf("LookupMX: %v", err)
+	}
+	if n := atomic.LoadInt32(&queries); n != 3 {
+		t.Errorf("got %d queries after Flush; want 3", n)
+	}
+
+	cache.Flush()
+	cache.MaxEntries = 1
+	for _, name := range []string{"golang.org.", "go.dev.", "golang.org."} {
+		if _, err := r.LookupMX(context.Background(), name); err != nil {
+			t.Fatalf("LookupMX: %v", err)
+		}
+	}
+	if s := cache.Stats(); s.Entries != 1 || s.Evictions != 2 {
+		t.Errorf("got %+v; want 1 entry and 2 evictions", s)
+	}
+}
+
+func TestLookupHTTPS(t *testing.T) {
+	rdata := [][]byte{
+		{0, 2, 0, 0, 1, 0, 3, 2, 'h', '2'},
+		{0, 1, 0, 0, 1, 0, 3, 2, 'h', '3', 0, 3, 0, 2, 0x20, 0xfb},
+		{0, 1, 3, 'b', 'a', 'd', 1, '%', 0},
+	}
+	fake := fakeDNSServer{
+		rh: func(_, _ string, q dnsmessage.Message, _ time.Time) (dnsmessage.Message, error) {
+			r := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:       q.Header.ID,
+					Response: true,
+					RCode:    dnsmessage.RCodeSuccess,
+				},
+				Questions: q.Questions,
+			}
+			typ := q.Questions[0].Type
+			for _, data := range rdata {
+				r.Answers = append(r.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{
+						Name:  q.Questions[0].Name,
+						Type:  typ,
+						Class: dnsmessage.ClassINET,
+						TTL:   300,
+					},
+					Body: &dnsmessage.UnknownResource{Type: typ, Data: data},
+				})
+			}
+			return r, nil
+		},
+	}
+	r := Resolver{PreferGo: true, Dial: fake.DialContext}
+	for _, lookup := range []struct {
+		name string
+		fn   func(context.Context, string) ([]*SVCB, error)
+	}{
+		{"LookupHTTPS", r.LookupHTTPS},
+		{"LookupSVCB", r.LookupSVCB},
+	} {
+		recs, err := lookup.fn(context.Background(), "golang.org.")
+		if dnsErr, ok := err.(*DNSError); !ok || dnsErr.Err != errMalformedDNSRecordsDetail {
+			t.Errorf("%s error = %v; want %q", lookup.name, err, errMalformedDNSRecordsDetail)
+		}
+		if len(recs) != 2 {
+			t.Fatalf("%s: got %d records; want 2", lookup.name, len(recs))
+		}
+		if recs[0].Priority != 1 || recs[0].Port != 8443 || len(recs[0].ALPN) != 1 || recs[0].ALPN[0] != "h3" {
+			t.Errorf("%s: first record = %+v; want priority 1, port 8443 and ALPN h3", lookup.name, recs[0])
+		}
+		if recs[1].Priority != 2 || recs[1].Target != "." || len(recs[1].ALPN) != 1 || recs[1].ALPN[0] != "h2" {
+			t.Errorf("%s: second record = %+v; want priority 2, target . and ALPN h2", lookup.name, recs[1])
+		}
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/bytealg"
+	"internal/itoa"
+	"io"
+)
+
+var (
+	errUnknownEncryptedDNSProtocol = errors.New("unknown encrypted DNS protocol")
+	errNoTLSClient                 = errors.New("encrypted DNS server has no TLSClient")
+	errNoBootstrapAddress          = errors.New("encrypted DNS server has no bootstrap address")
+	errDoHResponseTooLarge         = errors.New("DNS over HTTPS response too large")
+	errDoHMalformedResponse        = errors.New("malformed DNS over HTTPS response")
+	errDoHUnexpectedMediaType      = errors.New("DNS over HTTPS response is not a DNS message")
+)
+
+// maxDoHResponse is the most bytes of a DNS over HTTPS response that
+// are read: room for the header and the largest DNS message.
+const maxDoHResponse = 8<<10 + 65535
+
+// EncryptedDNS describes an upstream server that Go's built-in DNS
+// resolver reaches over DNS over TLS (RFC 7858) or DNS over HTTPS
+// (RFC 8484). See the Encrypted field of Resolver.
+type EncryptedDNS struct {
+	// Protocol is "tls" for DNS over TLS or "https" for DNS over
+	// HTTPS.
+	Protocol string
+
+	// ServerName is the host name of the server, which its
+	// certificate must be valid for. It may be an IP address.
+	ServerName string
+
+	// Port is the TCP port of the server. If zero, it is 853 for
+	// DNS over TLS and 443 for DNS over HTTPS.
+	Port int
+
+	// Path is the path of the DNS over HTTPS endpoint.
+	// If empty, "/dns-query" is used.
+	Path string
+
+	// Bootstrap lists IP addresses of the server, which are
+	// dialed without resolving ServerName. IPv4 and IPv6 addresses
+	// are raced as described in RFC 6555 ("Happy Eyeballs").
+	// If Bootstrap is empty, ServerName must be an IP address.
+	Bootstrap []IP
+
+	// TLSClient starts a TLS client session on c and completes
+	// its handshake, verifying that the server's certificate is
+	// valid for serverName and offering the ALPN protocols in
+	// nextProtos. Package net does not implement TLS, so
+	// TLSClient must be set; it is usually
+	//
+	//	func(ctx context.Context, c net.Conn, serverName string, nextProtos []string) (net.Conn, error) {
+	//		tc := tls.Client(c, &tls.Config{ServerName: serverName, NextProtos: nextProtos})
+	//		if err := tc.HandshakeContext(ctx); err != nil {
+	//			return nil, err
+	//		}
+	//		return tc, nil
+	//	}
+	TLSClient func(ctx context.Context, c Conn, serverName string, nextProtos []string) (Conn, error)
+}
+
+func (r *Resolver) encrypted() *EncryptedDNS {
+	if r == nil {
+		return nil
+	}
+	return r.Encrypted
+}
+
+func (e *EncryptedDNS) port() int {
+	if e.Port != 0 {
+		return e.Port
+	}
+	if e.Protocol == "https" {
+		return 443
+	}
+	return 853
+}
+
+// address returns the address of the server, as reported in the
+// Server field of DNSError.
+func (e *EncryptedDNS) address() string {
+	return JoinHostPort(e.ServerName, itoa.Itoa(e.port()))
+}
+
+// connect dials the server and starts a TLS session with it.
+func (e *EncryptedDNS) connect(ctx context.Context, r *Resolver) (Conn, error) {
+	var nextProtos []string
+	switch e.Protocol {
+	case "tls":
+		nextProtos = []string{"dot"}
+	case "https":
+		nextProtos = []string{"http/1.1"}
+	default:
+		return nil, errUnknownEncryptedDNSProtocol
+	}
+	if e.TLSClient == nil {
+		return nil, errNoTLSClient
+	}
+	addrs := make(addrList, 0, len(e.Bootstrap))
+	for _, ip := range e.Bootstrap {
+		addrs = append(addrs, &TCPAddr{IP: ip, Port: e.port()})
+	}
+	if len(addrs) == 0 {
+		ip, zone := splitHostZone(e.ServerName)
+		ip6 := ParseIP(ip)
+		if ip6 == nil {
+			return nil, errNoBootstrapAddress
+		}
+		addrs = append(addrs, &TCPAddr{IP: ip6, Port: e.port(), Zone: zone})
+	}
+
+	// Dialing must not resolve a name, which would call back into
+	// the resolver; all the addresses are IP addresses.
+	var c Conn
+	var err error
+	if r.Dial != nil {
+		for _, addr := range addrs {
+			if c, err = r.Dial(ctx, "tcp", addr.String()); err == nil {
+				break
+			}
+		}
+	} else {
+		sd := &sysDialer{network: "tcp", address: e.address()}
+		primaries, fallbacks := addrs.partition(isIPv4)
+		c, err = sd.dialParallel(ctx, primaries, fallbacks)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := ctx.Deadline(); ok && !d.IsZero() {
+		c.SetDeadline(d)
+	}
+	tc, err := e.TLSClient(ctx, c, e.ServerName, nextProtos)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// dohRoundTrip sends the DNS message req to the DNS over HTTPS
+// endpoint of e on c, in an HTTP/1.1 POST request, and returns the DNS
+// message of the response.
+func (e *EncryptedDNS) dohRoundTrip(c Conn, req []byte) ([]byte, error) {
+	path := e.Path
+	if path == "" {
+		path = "/dns-query"
+	}
+	host := e.ServerName
+	if count(host, ':') > 0 {
+		host = "[" + host + "]"
+	}
+	if e.port() != 443 {
+		host += ":" + itoa.Itoa(e.port())
+	}
+	b := make([]byte, 0, 256+len(req))
+	b = append(b, "POST "+path+" HTTP/1.1\r\nHost: "+host+"\r\n"...)
+	b = append(b, "Content-Type: application/dns-message\r\nAccept: application/dns-message\r\n"...)
+	b = append(b, "Content-Length: "+itoa.Itoa(len(req))+"\r\nConnection: close\r\n\r\n"...)
+	b = append(b, req...)
+	if _, err := c.Write(b); err != nil {
+		return nil, err
+	}
+	return readDoHResponse(c)
+}
+
+// readDoHResponse reads an HTTP/1.1 response carrying a DNS message
+// from c and returns the message.
+func readDoHResponse(c Conn) ([]byte, error) {
+	var b []byte
+	buf := make([]byte, 4096)
+	eof := false
+	// fill reads more of the response into b, reporting false at
+	// the end of the response.
+	fill := func() (bool, error) {
+		if eof {
+			return false, nil
+		}
+		if len(b) >= maxDoHResponse {
+			return false, errDoHResponseTooLarge
+		}
+		n, err := c.Read(buf)
+		b = append(b, buf[:n]...)
+		if err != nil {
+			if err != io.EOF {
+				return false, err
+			}
+			eof = true
+		}
+		return n > 0 || !eof, nil
+	}
+
+	hdrEnd := -1
+	for hdrEnd < 0 {
+		more, err := fill()
+		if err != nil {
+			return nil, err
+		}
+		if hdrEnd = indexCRLFCRLF(b); hdrEnd < 0 && !more {
+			return nil, errDoHMalformedResponse
+		}
+	}
+	lines := splitAtBytes(string(b[:hdrEnd]), "\r\n")
+	if len(lines) == 0 {
+		return nil, errDoHMalformedResponse
+	}
+	status := getFields(lines[0])
+	if len(status) < 2 || !stringsHasPrefix(status[0], "HTTP/1.") {
+		return nil, errDoHMalformedResponse
+	}
+	if status[1] != "200" {
+		return nil, errors.New("DNS over HTTPS server returned HTTP status " + status[1])
+	}
+	length, chunked := -1, false
+	mediaType := ""
+	for _, line := range lines[1:] {
+		i := bytealg.IndexByteString(line, ':')
+		if i < 0 {
+			return nil, errDoHMalformedResponse
+		}
+		key, value := line[:i], string(trimSpace([]byte(line[i+1:])))
+		switch {
+		case stringsEqualFold(key, "Content-Length"):
+			n, i, ok := dtoi(value)
+			if !ok || i != len(value) {
+				return nil, errDoHMalformedResponse
+			}
+			length = n
+		case stringsEqualFold(key, "Transfer-Encoding"):
+			chunked = stringsEqualFold(value, "chunked")
+		case stringsEqualFold(key, "Content-Type"):
+			mediaType = value
+		}
+	}
+	if semi := bytealg.IndexByteString(mediaType, ';'); semi >= 0 {
+		mediaType = string(trimSpace([]byte(mediaType[:semi])))
+	}
+	if !stringsEqualFold(mediaType, "application/dns-message") {
+		return nil, errDoHUnexpectedMediaType
+	}
+
+	bodyStart := hdrEnd + 4
+	for {
+		body := b[bodyStart:]
+		if chunked {
+			msg, done, err := decodeChunked(body)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return msg, nil
+			}
+		} else if length >= 0 && len(body) >= length {
+			return body[:length], nil
+		}
+		more, err := fill()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			if chunked || length >= 0 {
+				return nil, errDoHMalformedResponse
+			}
+			return b[bodyStart:], nil
+		}
+	}
+}
+
+// decodeChunked decodes the chunked transfer coding of b. It reports
+// false if b does not hold the last chunk yet.
+func decodeChunked(b []byte) (msg []byte, done bool, err error) {
+	for {
+		i := indexCRLF(b)
+		if i < 0 {
+			return nil, false, nil
+		}
+		line := b[:i]
+		if semi := bytealg.IndexByteString(string(line), ';'); semi >= 0 {
+			line = line[:semi]
+		}
+		line = trimSpace(line)
+		n, j, ok := xtoi(string(line))
+		if !ok || j != len(line) || n > maxDoHResponse {
+			return nil, false, errDoHMalformedResponse
+		}
+		b = b[i+2:]
+		if n == 0 {
+			// Trailers, if any, are of no interest.
+			return msg, true, nil
+		}
+		if len(b) < n+2 {
+			return nil, false, nil
+		}
+		msg = append(msg, b[:n]...)
+		b = b[n+2:]
+	}
+}
+
+func indexCRLF(b []byte) int {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == '\r' && b[i+1] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexCRLFCRLF(b []byte) int {
+	for i := 0; i+3 < len(b); i++ {
+		if b[i] == '\r' && b[i+1] == '\n' && b[i+2] == '\r' && b[i+3] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
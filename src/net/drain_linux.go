@@ -0,0 +1,39 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/iodrain"
+	"internal/poll"
+)
+
+func init() {
+	iodrain.Register(drain)
+}
+
+// drain discards the data of a TCP or stream-oriented Unix connection
+// for io.Discard by splicing it into /dev/null.
+func drain(r interface{}, remain int64) (n int64, handled bool, err error) {
+	var fd *netFD
+	switch c := r.(type) {
+	case *TCPConn:
+		if !c.ok() {
+			return 0, false, nil
+		}
+		fd = c.fd
+	case *UnixConn:
+		if !c.ok() || c.fd.net != "unix" {
+			return 0, false, nil
+		}
+		fd = c.fd
+	default:
+		return 0, false, nil
+	}
+	n, handled, sc, err := poll.SpliceDiscard(&fd.pfd, remain)
+	if err != nil {
+		err = &OpError{Op: "read", Net: fd.net, Source: fd.laddr, Addr: fd.raddr, Err: wrapSyscallError(sc, err)}
+	}
+	return n, handled, err
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDiscardDrainsTCP(t *testing.T) {
+	client, server, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	const size = 1 << 20
+	go func() {
+		client.Write(bytes.Repeat([]byte("x"), size))
+		client.Close()
+	}()
+
+	n, handled, err := drain(server, 1000)
+	if err != nil || !handled || n != 1000 {
+		t.Fatalf("drain = %d, %v, %v; want 1000, true, nil", n, handled, err)
+	}
+	n, err = io.Copy(io.Discard, server)
+	if err != nil || n != size-1000 {
+		t.Fatalf("Copy = %d, %v; want %d, nil", n, err, size-1000)
+	}
+}
+
+func TestDiscardDoesNotDrainUnixgram(t *testing.T) {
+	if !testableNetwork("unixgram") {
+		t.Skip("unixgram not testable")
+	}
+	addr := testUnixAddr()
+	c, err := ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, handled, _ := drain(c, 1); handled {
+		t.Error("drain of unixgram connection reported handled")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"syscall"
+)
+
+// tcpFastOpenConnect is TCP_FASTOPEN_CONNECT, added in Linux 4.11.
+const tcpFastOpenConnect = 0x1e
+
+// setFastOpenConnect asks for the connect on the socket s to be
+// deferred to the first write, which then carries its data in the
+// SYN if the kernel has a Fast Open cookie for the peer. Older
+// kernels reject the option and connect as usual.
+func setFastOpenConnect(s uintptr) {
+	syscall.SetsockoptInt(int(s), syscall.IPPROTO_TCP, tcpFastOpenConnect, 1)
+}
+
+// writeFastOpen writes data to the newly dialed fd, whose connect
+// may have been deferred by setFastOpenConnect. Without a cookie for
+// the peer, the first write sends a SYN requesting one and data is
+// written once the connection is established. Depending on the kernel
+// version, that first write fails with EAGAIN, which fd.pfd.Write
+// handles, or with EINPROGRESS.
+func (fd *netFD) writeFastOpen(ctx context.Context, data []byte) (ret error) {
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		fd.pfd.SetWriteDeadline(deadline)
+		defer fd.pfd.SetWriteDeadline(noDeadline)
+	}
+
+	// As in connect, interrupt the write if the context is canceled.
+	if ctx != context.Background() {
+		done := make(chan struct{})
+		interruptRes := make(chan error)
+		defer func() {
+			close(done)
+			if ctxErr := <-interruptRes; ctxErr != nil && ret == nil {
+				ret = mapErr(ctxErr)
+			}
+		}()
+		go func() {
+			select {
+			case <-ctx.Done():
+				fd.pfd.SetWriteDeadline(aLongTimeAgo)
+				interruptRes <- ctx.Err()
+			case <-done:
+				interruptRes <- nil
+			}
+		}()
+	}
+
+	for len(data) > 0 {
+		n, err := fd.pfd.Write(data)
+		data = data[n:]
+		switch err {
+		case nil:
+		case syscall.EINPROGRESS:
+			if err := fd.pfd.WaitWrite(); err != nil {
+				select {
+				case <-ctx.Done():
+					return mapErr(ctx.Err())
+				default:
+				}
+				return err
+			}
+		default:
+			select {
+			case <-ctx.Done():
+				return mapErr(ctx.Err())
+			default:
+			}
+			return wrapSyscallError("write", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package net
+
+import "context"
+
+func setFastOpenConnect(s uintptr) {}
+
+// writeFastOpen writes data to the newly dialed fd. Connections
+// are never deferred here, so it is an ordinary write.
+func (fd *netFD) writeFastOpen(ctx context.Context, data []byte) error {
+	_, err := fd.Write(data)
+	return err
+}
@@ -25,6 +25,10 @@ type netFD struct {
 	net         string
 	laddr       Addr
 	raddr       Addr
+
+	// zeroCopy is non-zero if large writes are sent with
+	// MSG_ZEROCOPY. Accessed atomically.
+	zeroCopy uint32
 }
 
 func (fd *netFD) setAddr(laddr, raddr Addr) {
@@ -71,6 +75,9 @@ func (fd *netFD) readMsg(p []byte, oob []byte, flags int) (n, oobn, retflags int
 }
 
 func (fd *netFD) Write(p []byte) (nn int, err error) {
+	if n, err, handled := writeZeroCopy(fd, p); handled {
+		return n, err
+	}
 	nn, err = fd.pfd.Write(p)
 	runtime.KeepAlive(fd)
 	return nn, wrapSyscallError(writeSyscallName, err)
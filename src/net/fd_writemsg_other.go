@@ -0,0 +1,24 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (js && wasm) || windows
+// +build js,wasm windows
+
+package net
+
+import "syscall"
+
+// writeMsgBuffers concatenates the buffers in v, for systems that
+// cannot send them with a single call as they are.
+func (fd *netFD) writeMsgBuffers(v Buffers, oob []byte, sa syscall.Sockaddr) (n int, oobn int, err error) {
+	var b []byte
+	if len(v) == 1 {
+		b = v[0]
+	} else {
+		for _, buf := range v {
+			b = append(b, buf...)
+		}
+	}
+	return fd.writeMsg(b, oob, sa)
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// altSvcBrokenDuration is how long the Transport avoids an HTTP/3
+// endpoint after a request to it failed.
+const altSvcBrokenDuration = 5 * time.Minute
+
+// altSvcDefaultMaxAge is the freshness lifetime of an Alt-Svc entry
+// without an "ma" parameter, per RFC 7838, section 3.1.
+const altSvcDefaultMaxAge = 24 * time.Hour
+
+// altSvcCache records the HTTP/3 endpoints that origins advertise with
+// the Alt-Svc response header. Its zero value is ready to use.
+type altSvcCache struct {
+	mu sync.Mutex
+	m  map[string]*altSvcEntry // keyed by canonicalAddr of the origin
+}
+
+type altSvcEntry struct {
+	addr        string    // host:port of the HTTP/3 endpoint
+	expires     time.Time // end of the freshness lifetime
+	brokenUntil time.Time // endpoint is not used before this time
+}
+
+// lookup returns the HTTP/3 endpoint to use for requests to the
+// origin of u, if any.
+func (c *altSvcCache) lookup(u *url.URL) (addr string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.m[canonicalAddr(u)]
+	if e == nil {
+		return "", false
+	}
+	now := time.Now()
+	if now.After(e.expires) {
+		delete(c.m, canonicalAddr(u))
+		return "", false
+	}
+	if now.Before(e.brokenUntil) {
+		return "", false
+	}
+	return e.addr, true
+}
+
+// markBroken records that a request to the HTTP/3 endpoint of the
+// origin of u failed.
+func (c *altSvcCache) markBroken(u *url.URL) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.m[canonicalAddr(u)]; e != nil {
+		e.brokenUntil = time.Now().Add(altSvcBrokenDuration)
+	}
+}
+
+// update processes the Alt-Svc header values of a response from the
+// origin of u.
+func (c *altSvcCache) update(u *url.URL, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	key := canonicalAddr(u)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range values {
+		alts, clear := parseAltSvc(v)
+		if clear {
+			delete(c.m, key)
+			return
+		}
+		for _, alt := range alts {
+			if alt.proto != "h3" {
+				continue
+			}
+			host, port, err := net.SplitHostPort(alt.authority)
+			if err != nil {
+				continue
+			}
+			if host != "" && host != u.Hostname() {
+				continue
+			}
+			if c.m == nil {
+				c.m = make(map[string]*altSvcEntry)
+			}
+			addr := net.JoinHostPort(u.Hostname(), port)
+			e := c.m[key]
+			if e == nil || e.addr != addr {
+				e = &altSvcEntry{addr: addr}
+				c.m[key] = e
+			}
+			e.expires = time.Now().Add(alt.maxAge)
+			return
+		}
+	}
+}
+
+// An altSvc is an alternative service advertised with Alt-Svc.
+type altSvc struct {
+	proto     string // ALPN protocol ID, such as "h3"
+	authority string // [host]:port
+	maxAge    time.Duration
+}
+
+// parseAltSvc parses the value of an Alt-Svc header, as defined in
+// RFC 7838, section 3. It reports whether the value is "clear".
+// Malformed alternatives are skipped.
+func parseAltSvc(v string) (alts []altSvc, clear bool) {
+	v = textproto.TrimString(v)
+	if v == "clear" {
+		return nil, true
+	}
+	for v != "" {
+		var alt string
+		alt, v = cutAltSvcList(v)
+		params := strings.Split(alt, ";")
+		pv := textproto.TrimString(params[0])
+		i := strings.IndexByte(pv, '=')
+		if i < 0 {
+			continue
+		}
+		proto, err := url.PathUnescape(pv[:i])
+		if err != nil || proto == "" {
+			continue
+		}
+		authority, ok := unquoteAltSvc(pv[i+1:])
+		if !ok {
+			continue
+		}
+		a := altSvc{proto: proto, authority: authority, maxAge: altSvcDefaultMaxAge}
+		for _, p := range params[1:] {
+			p = textproto.TrimString(p)
+			i := strings.IndexByte(p, '=')
+			if i < 0 || !ascii.EqualFold(p[:i], "ma") {
+				continue
+			}
+			value, _ := unquoteAltSvc(p[i+1:])
+			if secs, err := strconv.ParseUint(value, 10, 32); err == nil {
+				a.maxAge = time.Duration(secs) * time.Second
+			}
+		}
+		alts = append(alts, a)
+	}
+	return alts, false
+}
+
+// cutAltSvcList returns the element of the comma-separated list v
+// before the first unquoted comma, and the rest of the list.
+func cutAltSvcList(v string) (elem, rest string) {
+	quoted := false
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted:
+			i++
+		case c == ',' && !quoted:
+			return v[:i], v[i+1:]
+		}
+	}
+	return v, ""
+}
+
+// unquoteAltSvc unquotes a token or quoted-string.
+func unquoteAltSvc(s string) (string, bool) {
+	s = textproto.TrimString(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s, s != "" && strings.IndexFunc(s, func(r rune) bool { return !httpguts.IsTokenRune(r) }) < 0
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), true
+}
+
+// http3Request returns a shallow copy of req to send to the HTTP/3
+// endpoint addr of its origin.
+func http3Request(req *Request, addr string) *Request {
+	r := new(Request)
+	*r = *req
+	u := *req.URL
+	u.Host = addr
+	r.URL = &u
+	if r.Host == "" {
+		r.Host = req.URL.Host
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAltSvc(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  []altSvc
+		clear bool
+	}{
+		{in: "clear", clear: true},
+		{in: " clear ", clear: true},
+		{
+			in:   `h3=":443"`,
+			want: []altSvc{{"h3", ":443", altSvcDefaultMaxAge}},
+		},
+		{
+			in: `h3=":8443"; ma=60, h2="alt.example.com:443"; persist=1`,
+			want: []altSvc{
+				{"h3", ":8443", time.Minute},
+				{"h2", "alt.example.com:443", altSvcDefaultMaxAge},
+			},
+		},
+		{
+			in:   `h3%2D29=":443";ma="30", bogus, h3=":444"`,
+			want: []altSvc{{"h3-29", ":443", 30 * time.Second}, {"h3", ":444", altSvcDefaultMaxAge}},
+		},
+		{
+			in:   `h3="a,b:1"`,
+			want: []altSvc{{"h3", "a,b:1", altSvcDefaultMaxAge}},
+		},
+		{in: `=":443"`},
+	}
+	for _, tt := range tests {
+		got, clear := parseAltSvc(tt.in)
+		if clear != tt.clear || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAltSvc(%q) = %v, %v; want %v, %v", tt.in, got, clear, tt.want, tt.clear)
+		}
+	}
+}
+
+func TestAltSvcCache(t *testing.T) {
+	u, _ := url.Parse("https://example.com/path")
+	var c altSvcCache
+	if _, ok := c.lookup(u); ok {
+		t.Fatal("lookup succeeded on empty cache")
+	}
+
+	c.update(u, []string{`h2=":443", h3="other.example.com:443", h3=":8443"`})
+	if addr, ok := c.lookup(u); !ok || addr != "example.com:8443" {
+		t.Fatalf("lookup = %q, %v; want %q, true", addr, ok, "example.com:8443")
+	}
+
+	other, _ := url.Parse("https://example.com:8080/")
+	if _, ok := c.lookup(other); ok {
+		t.Error("lookup succeeded for a different origin")
+	}
+
+	c.markBroken(u)
+	if _, ok := c.lookup(u); ok {
+		t.Error("lookup succeeded for a broken endpoint")
+	}
+
+	c.update(u, []string{`h3=":8443"; ma=0`})
+	if _, ok := c.lookup(u); ok {
+		t.Error("lookup succeeded after the entry expired")
+	}
+
+	c.update(u, []string{`h3=":9443"`})
+	if addr, ok := c.lookup(u); !ok || addr != "example.com:9443" {
+		t.Fatalf("lookup = %q, %v; want %q, true", addr, ok, "example.com:9443")
+	}
+
+	c.update(u, []string{"clear"})
+	if _, ok := c.lookup(u); ok {
+		t.Error("lookup succeeded after clear")
+	}
+}
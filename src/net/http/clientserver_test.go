@@ -12,6 +12,7 @@ import (
 	"crypto/rand"
 	"crypto/sha1"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -1582,3 +1583,237 @@ func TestH12_WebSocketUpgrade(t *testing.T) {
 		},
 	}.run(t)
 }
+
+// TODO: add an HTTP/2 variant once golang.org/x/net/http2 uses the
+// registered decoders.
+func TestTransportRegisterDecoder_h1(t *testing.T) { testTransportRegisterDecoder(t, h1Mode) }
+func testTransportRegisterDecoder(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	const body = "some text"
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if got, want := r.Header.Get("Accept-Encoding"), "zstd, gzip"; got != want {
+			t.Errorf("Accept-Encoding = %q; want %q", got, want)
+		}
+		// A stand-in for zstd: the decoder upper-cases the body.
+		w.Header().Set("Content-Encoding", "zstd")
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		io.WriteString(w, body)
+	}))
+	defer cst.close()
+	var closed int32
+	cst.tr.RegisterDecoder("zstd", func(r io.Reader) (io.ReadCloser, error) {
+		return upperCaseReadCloser{r, &closed}, nil
+	})
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if want := strings.ToUpper(body); string(got) != want {
+		t.Errorf("body = %q; want %q", got, want)
+	}
+	if !res.Uncompressed {
+		t.Error("Uncompressed = false; want true")
+	}
+	if ce := res.Header.Get("Content-Encoding"); ce != "" {
+		t.Errorf("Content-Encoding = %q; want none", ce)
+	}
+	if res.ContentLength != -1 {
+		t.Errorf("ContentLength = %d; want -1", res.ContentLength)
+	}
+	if atomic.LoadInt32(&closed) != 1 {
+		t.Error("decoder was not closed")
+	}
+}
+
+type upperCaseReadCloser struct {
+	r      io.Reader
+	closed *int32
+}
+
+func (u upperCaseReadCloser) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	copy(p, bytes.ToUpper(p[:n]))
+	return n, err
+}
+
+func (u upperCaseReadCloser) Close() error {
+	atomic.AddInt32(u.closed, 1)
+	return nil
+}
+
+func TestServerMaxRequestBodyBytes_h1(t *testing.T) { testServerMaxRequestBodyBytes(t, h1Mode) }
+func TestServerMaxRequestBodyBytes_h2(t *testing.T) { testServerMaxRequestBodyBytes(t, h2Mode) }
+func testServerMaxRequestBodyBytes(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	readBody := HandlerFunc(func(w ResponseWriter, r *Request) {
+		n, err := io.Copy(io.Discard, r.Body)
+		var mbe *MaxBytesError
+		if errors.As(err, &mbe) {
+			w.WriteHeader(StatusRequestEntityTooLarge)
+			fmt.Fprintf(w, "limit %d", mbe.Limit)
+			return
+		}
+		fmt.Fprintf(w, "read %d", n)
+	})
+	mux := NewServeMux()
+	mux.Handle("/", readBody)
+	mux.Handle("/upload", MaxBytesHandler(readBody, 100))
+	mux.Handle("/tiny", MaxBytesHandler(readBody, 5))
+	mux.HandleFunc("/late", func(w ResponseWriter, r *Request) {
+		// Lower the limit below what was already read.
+		io.ReadFull(r.Body, make([]byte, 4))
+		r.Body = MaxBytesReader(w, r.Body, 2)
+		readBody(w, r)
+	})
+	infoc := make(chan RequestInfo, 1)
+	cst := newClientServerTest(t, h2, mux, func(ts *httptest.Server) {
+		ts.Config.MaxRequestBodyBytes = 10
+		ts.Config.RequestDone = func(info RequestInfo) { infoc <- info }
+	})
+	defer cst.close()
+
+	tests := []struct {
+		path     string
+		size     int
+		want     string
+		tooLarge bool
+	}{
+		{"/", 10, "read 10", false},
+		{"/", 11, "limit 10", true},
+		{"/upload", 10, "read 10", false},
+		{"/upload", 50, "limit 10", true},
+		{"/tiny", 6, "limit 5", true},
+		{"/late", 6, "limit 2", true},
+	}
+	for _, tt := range tests {
+		res, err := cst.c.Post(cst.ts.URL+tt.path, "text/plain", strings.NewReader(strings.Repeat("x", tt.size)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		info := <-infoc
+		if string(body) != tt.want {
+			t.Errorf("%s with %d bytes: got %q; want %q", tt.path, tt.size, body, tt.want)
+		}
+		if info.BodyTooLarge != tt.tooLarge || info.Pattern != tt.path {
+			t.Errorf("%s with %d bytes: BodyTooLarge = %v, Pattern = %q; want %v, %q",
+				tt.path, tt.size, info.BodyTooLarge, info.Pattern, tt.tooLarge, tt.path)
+		}
+	}
+}
+
+func TestServerRequestDone_h1(t *testing.T) { testServerRequestDone(t, h1Mode) }
+func TestServerRequestDone_h2(t *testing.T) { testServerRequestDone(t, h2Mode) }
+func testServerRequestDone(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	mux := NewServeMux()
+	mux.HandleFunc("/api/", func(w ResponseWriter, r *Request) {
+		// Handlers may copy the request.
+		r = r.WithContext(r.Context())
+		w.WriteHeader(StatusCreated)
+		io.WriteString(w, "hello")
+	})
+	infoc := make(chan RequestInfo, 1)
+	cst := newClientServerTest(t, h2, mux, func(ts *httptest.Server) {
+		ts.Config.RequestDone = func(info RequestInfo) { infoc <- info }
+	})
+	defer cst.close()
+
+	start := time.Now()
+	res, err := cst.c.Get(cst.ts.URL + "/api/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	info := <-infoc
+	if info.Request == nil || info.Request.URL.Path != "/api/users" {
+		t.Errorf("Request = %v; want request for /api/users", info.Request)
+	}
+	if info.Pattern != "/api/" {
+		t.Errorf("Pattern = %q; want /api/", info.Pattern)
+	}
+	if info.StatusCode != StatusCreated {
+		t.Errorf("StatusCode = %d; want %d", info.StatusCode, StatusCreated)
+	}
+	if info.BytesWritten != 5 {
+		t.Errorf("BytesWritten = %d; want 5", info.BytesWritten)
+	}
+	if info.Hijacked {
+		t.Error("Hijacked = true; want false")
+	}
+	if info.Start.Before(start) || info.FirstByte <= 0 || info.ReadHeader < 0 ||
+		info.Total < info.FirstByte || info.FirstByte < info.ReadHeader {
+		t.Errorf("bad timing: Start = %v (request sent at %v), ReadHeader = %v, FirstByte = %v, Total = %v",
+			info.Start, start, info.ReadHeader, info.FirstByte, info.Total)
+	}
+
+	res, err = cst.c.Get(cst.ts.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	info = <-infoc
+	if info.Pattern != "" || info.StatusCode != StatusNotFound {
+		t.Errorf("not found: Pattern = %q, StatusCode = %d; want empty pattern, 404", info.Pattern, info.StatusCode)
+	}
+}
+
+// TODO: test HTTP/2 as well, once the bundled HTTP/2 server and
+// transport support informational responses.
+func TestEarlyHints(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		h := w.Header()
+		h.Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(StatusEarlyHints)
+		h.Add("Link", "</script.js>; rel=preload; as=script")
+		w.WriteHeader(StatusEarlyHints)
+		w.WriteHeader(StatusOK)
+		w.Write([]byte("done"))
+	}))
+	defer cst.close()
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != StatusOK || string(body) != "done" {
+		t.Errorf("got %d %q; want 200 %q", res.StatusCode, body, "done")
+	}
+	if len(res.Informational) != 2 {
+		t.Fatalf("got %d informational responses; want 2", len(res.Informational))
+	}
+	hints := res.EarlyHints()
+	if len(hints) != 2 {
+		t.Fatalf("got %d early hints; want 2", len(hints))
+	}
+	wantLinks := [][]string{
+		{"</style.css>; rel=preload; as=style"},
+		{"</style.css>; rel=preload; as=style", "</script.js>; rel=preload; as=script"},
+	}
+	for i, want := range wantLinks {
+		if got := hints[i]["Link"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("early hints %d: Link = %q; want %q", i, got, want)
+		}
+	}
+	if got := res.Header["Link"]; !reflect.DeepEqual(got, wantLinks[1]) {
+		t.Errorf("final Link = %q; want %q", got, wantLinks[1])
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DownloadOptions configures Client.Download.
+type DownloadOptions struct {
+	// Parallelism is the maximum number of ranges of the resource
+	// that are downloaded concurrently. If zero, up to 4 ranges are
+	// downloaded at once. Ranges are only used if the server
+	// supports them and identifies the resource with a strong ETag.
+	Parallelism int
+
+	// MaxRetries is the maximum number of times that the download of
+	// a range is resumed after a transient failure, such as a reset
+	// connection. If zero, it is 5. If negative, failures are not
+	// retried.
+	MaxRetries int
+
+	// Progress optionally is called as the download progresses with
+	// the number of bytes written to the file so far, and the size
+	// of the resource, or -1 if the size is unknown. It is not
+	// called concurrently.
+	Progress func(written, size int64)
+}
+
+const (
+	defaultDownloadParallelism = 4
+	defaultDownloadRetries     = 5
+
+	// minDownloadPart is the smallest range of a resource that is
+	// downloaded in parallel with others.
+	minDownloadPart = 1 << 20
+)
+
+var errDownloadChanged = errors.New("net/http: resource changed during download")
+
+// Download downloads the resource at url with GET requests, writing it
+// at the start of dst with WriteAt, and returns its size. When it is
+// done, dst is truncated to the size of the resource.
+//
+// If the server supports range requests and identifies the resource
+// with a strong ETag, Download resumes the transfer with a range
+// request after a transient failure, such as a reset connection, and
+// downloads large resources in several ranges at once, into a file
+// that it first extends to the size of the resource. The ETag is sent
+// in an If-Range header, and Download fails if the resource changes
+// while it is downloaded. Otherwise, the resource is downloaded with a
+// single request that is not resumed.
+//
+// The context governs the whole download. A nil opts is equivalent
+// to a zero DownloadOptions.
+func (c *Client) Download(ctx context.Context, url string, dst *os.File, opts *DownloadOptions) (int64, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	d := &download{c: c, ctx: ctx, url: url, dst: dst, opts: opts, size: -1}
+	res, err := d.start()
+	if err != nil {
+		return 0, err
+	}
+	if d.size == 0 {
+		if res != nil {
+			res.Body.Close()
+		}
+		return 0, dst.Truncate(0)
+	}
+
+	parts := 1
+	if d.resumable && d.size > 0 {
+		parts = opts.Parallelism
+		if parts <= 0 {
+			parts = defaultDownloadParallelism
+		}
+		if max := d.size / minDownloadPart; int64(parts) > max {
+			parts = int(max)
+		}
+		if parts < 1 {
+			parts = 1
+		}
+	}
+	if parts == 1 {
+		n, err := d.part(res, 0, d.size)
+		if err != nil {
+			return 0, err
+		}
+		return n, dst.Truncate(n)
+	}
+
+	// Extend the file to its final size, so that the ranges
+	// can be written in any order.
+	if err := dst.Truncate(d.size); err != nil {
+		res.Body.Close()
+		return 0, err
+	}
+	partSize := d.size / int64(parts)
+	errc := make(chan error, parts)
+	for i := 0; i < parts; i++ {
+		off, end := int64(i)*partSize, int64(i+1)*partSize
+		if i == parts-1 {
+			end = d.size
+		}
+		var r *Response
+		if i == 0 {
+			// The first range is read from the first response.
+			r = res
+		}
+		go func() {
+			_, err := d.part(r, off, end)
+			errc <- err
+		}()
+	}
+	var firstErr error
+	for i := 0; i < parts; i++ {
+		if err := <-errc; err != nil && firstErr == nil {
+			// Stop downloading the other ranges.
+			firstErr = err
+			cancel()
+		}
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return d.size, nil
+}
+
+// A download is the state of a call to Client.Download.
+type download struct {
+	c    *Client
+	ctx  context.Context
+	url  string
+	dst  *os.File
+	opts *DownloadOptions
+
+	// Set by start.
+	size      int64 // or -1 if unknown
+	etag      string
+	resumable bool
+
+	mu      sync.Mutex // guards written and calls to Progress
+	written int64
+}
+
+// start sends the first request of the download. It returns its
+// response, positioned at the start of the resource, or nil if the
+// resource is empty.
+func (d *download) start() (*Response, error) {
+	var res *Response
+	for retry := 0; ; retry++ {
+		var err error
+		res, err = d.get(0, -1, "")
+		if err == nil {
+			break
+		}
+		if !d.retry(retry, err) {
+			return nil, err
+		}
+	}
+	switch res.StatusCode {
+	case StatusOK:
+		d.size = res.ContentLength
+		d.resumable = res.Header.get("Accept-Ranges") == "bytes"
+	case StatusPartialContent:
+		start, _, size, ok := parseContentRange(res.Header.get("Content-Range"))
+		if !ok || start != 0 {
+			res.Body.Close()
+			return nil, errors.New("net/http: invalid Content-Range in response to range request")
+		}
+		d.size = size
+		d.resumable = true
+	case StatusRequestedRangeNotSatisfiable:
+		// Only an empty resource has no range starting at 0.
+		res.Body.Close()
+		if _, _, size, ok := parseContentRange(res.Header.get("Content-Range")); !ok || size != 0 {
+			return nil, fmt.Errorf("net/http: unexpected response status %q", res.Status)
+		}
+		d.size = 0
+		return nil, nil
+	default:
+		res.Body.Close()
+		return nil, fmt.Errorf("net/http: unexpected response status %q", res.Status)
+	}
+	d.etag = res.Header.get("Etag")
+	if !isStrongETag(d.etag) {
+		d.etag = ""
+		d.resumable = false
+	}
+	return res, nil
+}
+
+// get sends a GET request for the resource from byte off to end, or to
+// its end if end is negative. A non-empty etag is sent in an If-Range
+// header.
+func (d *download) get(off, end int64, etag string) (*Response, error) {
+	req, err := NewRequestWithContext(d.ctx, "GET", d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r := "bytes=" + strconv.FormatInt(off, 10) + "-"
+	if end >= 0 {
+		r += strconv.FormatInt(end-1, 10)
+	}
+	req.Header.Set("Range", r)
+	if etag != "" {
+		req.Header.Set("If-Range", etag)
+	}
+	return d.c.Do(req)
+}
+
+// part downloads the resource from byte off to end, or to its end if
+// end is negative, reading the first bytes from res if it is not nil.
+// It returns the number of bytes written.
+func (d *download) part(res *Response, off, end int64) (int64, error) {
+	start := off
+	for retry := 0; ; retry++ {
+		var err error
+		if res == nil {
+			res, err = d.resume(off, end)
+		}
+		if err == nil {
+			var n int64
+			n, err = d.copy(res.Body, off, end)
+			res.Body.Close()
+			res = nil
+			off += n
+			if err == nil {
+				return off - start, nil
+			}
+		}
+		if !d.resumable || !d.retry(retry, err) {
+			return off - start, err
+		}
+	}
+}
+
+// resume requests the resource from byte off to end, if it is
+// unchanged.
+func (d *download) resume(off, end int64) (*Response, error) {
+	res, err := d.get(off, end, d.etag)
+	if err != nil {
+		return nil, err
+	}
+	start, _, size, ok := parseContentRange(res.Header.get("Content-Range"))
+	switch {
+	case res.StatusCode == StatusOK || res.StatusCode == StatusPartialContent && res.Header.get("Etag") != d.etag:
+		err = errDownloadChanged
+	case res.StatusCode != StatusPartialContent:
+		err = fmt.Errorf("net/http: unexpected response status %q", res.Status)
+	case !ok || start != off || size != d.size:
+		err = errors.New("net/http: invalid Content-Range in response to range request")
+	}
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// copy writes the body r of a response to dst at byte off, up to
+// byte end if it is not negative.
+func (d *download) copy(r io.Reader, off, end int64) (int64, error) {
+	n := end - off
+	if end < 0 {
+		n = maxInt64 - off
+	}
+	w := &downloadWriter{d: d, w: io.NewSectionWriter(d.dst, off, n)}
+	written, err := io.Copy(w, io.LimitReader(r, n))
+	if err == nil && end >= 0 && written < n {
+		err = io.ErrUnexpectedEOF
+	}
+	return written, err
+}
+
+// retry reports whether to retry after the failure err, and waits
+// before the retry if so. retry is the number of earlier retries.
+func (d *download) retry(retry int, err error) bool {
+	max := d.opts.MaxRetries
+	if max == 0 {
+		max = defaultDownloadRetries
+	}
+	if retry >= max || d.ctx.Err() != nil || !isTransientError(err) {
+		return false
+	}
+	t := time.NewTimer((&RetryPolicy{}).backoff(retry + 1))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-d.ctx.Done():
+		return false
+	}
+}
+
+// downloadWriter writes to a section of the destination of a
+// download, reporting progress.
+type downloadWriter struct {
+	d *download
+	w *io.SectionWriter
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	d := w.d
+	d.mu.Lock()
+	d.written += int64(n)
+	if d.opts.Progress != nil {
+		d.opts.Progress(d.written, d.size)
+	}
+	d.mu.Unlock()
+	return n, err
+}
+
+// isStrongETag reports whether etag is a valid strong entity tag.
+func isStrongETag(etag string) bool {
+	_, remain := scanETag(etag)
+	return remain == "" && etag != "" && !strings.HasPrefix(etag, "W/")
+}
+
+// parseContentRange parses the value of the Content-Range header of a
+// response to a range request, "bytes first-last/size", returning the
+// range from start to end, and the size, or -1 if it is unknown. For
+// the value "bytes */size" of an unsatisfied range request, start
+// and end are zero.
+func parseContentRange(v string) (start, end, size int64, ok bool) {
+	const prefix = "bytes "
+	if !strings.HasPrefix(v, prefix) {
+		return 0, 0, 0, false
+	}
+	v = v[len(prefix):]
+	slash := strings.IndexByte(v, '/')
+	if slash < 0 {
+		return 0, 0, 0, false
+	}
+	r, s := v[:slash], v[slash+1:]
+	size = -1
+	if s != "*" {
+		var err error
+		if size, err = strconv.ParseInt(s, 10, 64); err != nil || size < 0 {
+			return 0, 0, 0, false
+		}
+	}
+	if r == "*" {
+		return 0, 0, size, size >= 0
+	}
+	dash := strings.IndexByte(r, '-')
+	if dash < 0 {
+		return 0, 0, 0, false
+	}
+	start, err1 := strconv.ParseInt(r[:dash], 10, 64)
+	last, err2 := strconv.ParseInt(r[dash+1:], 10, 64)
+	if err1 != nil || err2 != nil || start < 0 || last < start || size >= 0 && last >= size {
+		return 0, 0, 0, false
+	}
+	return start, last + 1, size, true
+}
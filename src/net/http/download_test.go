@@ -0,0 +1,217 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	. "net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// downloadContent returns n bytes of test content.
+func downloadContent(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i * 7 / 5)
+	}
+	return b
+}
+
+func checkDownloadedFile(t *testing.T, f *os.File, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("downloaded %d bytes, want %d bytes, unchanged", len(got), len(want))
+	}
+}
+
+func TestClientDownload_h1(t *testing.T) { testClientDownload(t, h1Mode) }
+func TestClientDownload_h2(t *testing.T) { testClientDownload(t, h2Mode) }
+func testClientDownload(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	content := downloadContent(3<<20 + 5)
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer cst.close()
+
+	f, err := os.CreateTemp(t.TempDir(), "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Longer than the resource, to check that Download truncates it.
+	f.Write(make([]byte, len(content)+100))
+
+	var lastWritten, lastSize int64
+	n, err := cst.c.Download(context.Background(), cst.ts.URL, f, &DownloadOptions{
+		Parallelism: 3,
+		Progress: func(written, size int64) {
+			lastWritten, lastSize = written, size
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("Download = %d; want %d", n, len(content))
+	}
+	checkDownloadedFile(t, f, content)
+	if lastWritten != n || lastSize != n {
+		t.Errorf("last progress = %d of %d; want %d of %d", lastWritten, lastSize, n, n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ranges) != 3 || ranges[0] != "bytes=0-" {
+		t.Errorf("requested ranges %q; want 3 ranges, the first from 0", ranges)
+	}
+}
+
+func TestClientDownloadResume(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	content := downloadContent(100 << 10)
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+		if r.Header.Get("If-Range") != "" && r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("If-Range = %q; want %q", r.Header.Get("If-Range"), `"v1"`)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if first {
+			// Send half of the resource and fail.
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			w.(Flusher).Flush()
+			panic(ErrAbortHandler)
+		}
+		ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer cst.close()
+
+	f, err := os.CreateTemp(t.TempDir(), "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := cst.c.Download(context.Background(), cst.ts.URL, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkDownloadedFile(t, f, content)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ranges) != 2 || ranges[0] != "bytes=0-" || !strings.HasPrefix(ranges[1], "bytes=") || ranges[1] == "bytes=0-" {
+		t.Errorf("requested ranges %q; want the first from 0 and a resumed one", ranges)
+	}
+}
+
+func TestClientDownloadChanged(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	content := downloadContent(2 << 20)
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		// The resource changes after the first request.
+		w.Header().Set("ETag", `"v`+strconv.Itoa(n)+`"`)
+		ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer cst.close()
+
+	f, err := os.CreateTemp(t.TempDir(), "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = cst.c.Download(context.Background(), cst.ts.URL, f, &DownloadOptions{Parallelism: 2})
+	if err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("Download = %v; want error about a changed resource", err)
+	}
+}
+
+func TestClientDownloadNotResumable(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	for _, content := range [][]byte{downloadContent(2 << 20), {}} {
+		var (
+			mu       sync.Mutex
+			requests int
+		)
+		cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			// No ETag, and no support for ranges.
+			w.Write(content)
+		}))
+		f, err := os.CreateTemp(t.TempDir(), "download")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := cst.c.Download(context.Background(), cst.ts.URL, f, nil)
+		if err != nil || n != int64(len(content)) {
+			t.Errorf("Download = %d, %v; want %d, nil", n, err, len(content))
+		}
+		checkDownloadedFile(t, f, content)
+		f.Close()
+		cst.close()
+		if requests != 1 {
+			t.Errorf("%d requests; want 1", requests)
+		}
+	}
+}
+
+func TestClientDownloadEmptyRange(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("ETag", `"empty"`)
+		ServeContent(w, r, "", time.Time{}, strings.NewReader(""))
+	}))
+	defer cst.close()
+	f, err := os.CreateTemp(t.TempDir(), "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	io.WriteString(f, "old contents")
+	n, err := cst.c.Download(context.Background(), cst.ts.URL, f, nil)
+	if err != nil || n != 0 {
+		t.Fatalf("Download = %d, %v; want 0, nil", n, err)
+	}
+	checkDownloadedFile(t, f, nil)
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+)
+
+// DrainOptions configures Server.Drain.
+type DrainOptions struct {
+	// Handoff optionally is called with duplicates of the files of
+	// the server's listeners before they are closed, so that another
+	// process can keep accepting connections on them, such as a new
+	// version of the program started for a deploy. The files can be
+	// sent on a Unix domain socket with net.UnixConn.SendFDs, or
+	// passed to a child process with os/exec.Cmd.ExtraFiles; the
+	// receiving process can serve them with net.FileListener.
+	//
+	// The files are in no particular order, and are closed when
+	// Handoff returns. Only listeners that have a File method, such
+	// as *net.TCPListener and *net.UnixListener, including those
+	// given to ServeTLS, can be handed off. If a listener cannot be
+	// handed off or Handoff returns an error, Drain returns the error
+	// and the server keeps serving.
+	Handoff func(files []*os.File) error
+
+	// Progress optionally is called periodically while the server
+	// drains, with the connections that are still open. It is first
+	// called once the idle connections are closed, if any
+	// connections are left.
+	Progress func(DrainStatus)
+
+	// ProgressInterval is the interval between calls to Progress.
+	// If zero, Progress is called every second.
+	ProgressInterval time.Duration
+}
+
+func (o *DrainOptions) progressInterval() time.Duration {
+	if o.ProgressInterval > 0 {
+		return o.ProgressInterval
+	}
+	return time.Second
+}
+
+// DrainStatus describes the connections of a server that is draining.
+// See DrainOptions.Progress.
+type DrainStatus struct {
+	// Conns are the open connections, oldest first. Hijacked
+	// connections are not included.
+	Conns []DrainConn
+}
+
+// Count returns the number of connections of s in state.
+func (s DrainStatus) Count(state ConnState) int {
+	n := 0
+	for _, c := range s.Conns {
+		if c.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// A DrainConn describes a connection of a server that is draining.
+type DrainConn struct {
+	RemoteAddr net.Addr
+	State      ConnState
+
+	// Age is how long ago the connection was accepted.
+	Age time.Duration
+
+	// StateAge is how long ago the connection entered State,
+	// with a precision of a second.
+	StateAge time.Duration
+}
+
+// Drain is like Shutdown, and also hands off the listeners of the
+// server and reports the progress of the shutdown, as configured by
+// opts. A nil opts is equivalent to a zero DrainOptions, which makes
+// Drain equivalent to Shutdown.
+//
+// As with Shutdown, the server stops using keep-alives: idle
+// connections are closed, connections serving HTTP/1.x requests are
+// closed once their responses are written, and HTTP/2 clients are
+// told to stop sending requests on their connection.
+func (srv *Server) Drain(ctx context.Context, opts *DrainOptions) error {
+	if opts == nil {
+		opts = &DrainOptions{}
+	}
+	return srv.shutdown(ctx, opts)
+}
+
+// listenerFiler is implemented by listeners whose files can be
+// handed off by Drain.
+type listenerFiler interface {
+	File() (*os.File, error)
+}
+
+// handoffTLSListener is the listener of ServeTLS. Its File method
+// returns the file of the underlying listener.
+type handoffTLSListener struct {
+	net.Listener
+	raw net.Listener
+}
+
+func (l handoffTLSListener) File() (*os.File, error) {
+	fl, ok := l.raw.(listenerFiler)
+	if !ok {
+		return nil, fmt.Errorf("http: cannot hand off listener of type %T", l.raw)
+	}
+	return fl.File()
+}
+
+// handoffListeners calls handoff with the files of the server's
+// listeners.
+func (s *Server) handoffListeners(handoff func([]*os.File) error) error {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	s.mu.Lock()
+	for ln := range s.listeners {
+		l := *ln
+		if oc, ok := l.(*onceCloseListener); ok {
+			l = oc.Listener
+		}
+		fl, ok := l.(listenerFiler)
+		if !ok {
+			s.mu.Unlock()
+			return fmt.Errorf("http: cannot hand off listener of type %T", l)
+		}
+		f, err := fl.File()
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		files = append(files, f)
+	}
+	s.mu.Unlock()
+	return handoff(files)
+}
+
+// drainStatus returns the status of the server's connections.
+func (s *Server) drainStatus() DrainStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	st := DrainStatus{Conns: make([]DrainConn, 0, len(s.activeConn))}
+	for c := range s.activeConn {
+		state, unixSec := c.getState()
+		dc := DrainConn{
+			RemoteAddr: c.rwc.RemoteAddr(),
+			State:      state,
+			Age:        now.Sub(c.start),
+		}
+		dc.StateAge = dc.Age
+		if unixSec != 0 {
+			// Zero means that the state is not set yet.
+			dc.StateAge = now.Sub(time.Unix(unixSec, 0))
+		}
+		st.Conns = append(st.Conns, dc)
+	}
+	sort.Slice(st.Conns, func(i, j int) bool {
+		return st.Conns[i].Age > st.Conns[j].Age
+	})
+	return st
+}
@@ -7,12 +7,14 @@
 package http
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"mime"
 	"mime/multipart"
+	"net/http/internal/ascii"
 	"net/textproto"
 	"net/url"
 	"os"
@@ -21,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -125,7 +128,12 @@ func (d dirEntryDirs) len() int          { return len(d) }
 func (d dirEntryDirs) isDir(i int) bool  { return d[i].IsDir() }
 func (d dirEntryDirs) name(i int) string { return d[i].Name() }
 
-func dirList(w ResponseWriter, r *Request, f File) {
+func dirList(w ResponseWriter, r *Request, f File, opts *FileServerOptions) {
+	if opts.DirList != nil {
+		customDirList(w, r, f, opts)
+		return
+	}
+
 	// Prefer to use ReadDir instead of Readdir,
 	// because the former doesn't require calling
 	// Stat on every entry of a directory on Unix.
@@ -152,6 +160,9 @@ func dirList(w ResponseWriter, r *Request, f File) {
 	fmt.Fprintf(w, "<pre>\n")
 	for i, n := 0, dirs.len(); i < n; i++ {
 		name := dirs.name(i)
+		if opts.hidden(name) {
+			continue
+		}
 		if dirs.isDir(i) {
 			name += "/"
 		}
@@ -164,6 +175,24 @@ func dirList(w ResponseWriter, r *Request, f File) {
 	fmt.Fprintf(w, "</pre>\n")
 }
 
+// customDirList lists the directory f with opts.DirList.
+func customDirList(w ResponseWriter, r *Request, f File, opts *FileServerOptions) {
+	list, err := f.Readdir(-1)
+	if err != nil {
+		logf(r, "http: error reading directory: %v", err)
+		Error(w, "Error reading directory", StatusInternalServerError)
+		return
+	}
+	entries := list[:0]
+	for _, fi := range list {
+		if !opts.hidden(fi.Name()) {
+			entries = append(entries, fi)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	opts.DirList(w, r, entries)
+}
+
 // ServeContent replies to the request using the content in the
 // provided ReadSeeker. The main benefit of ServeContent over io.Copy
 // is that it handles Range requests properly, sets the MIME type, and
@@ -189,6 +218,12 @@ func dirList(w ResponseWriter, r *Request, f File) {
 // ServeContent uses it to handle requests using If-Match, If-None-Match, or If-Range.
 //
 // Note that *os.File implements the io.ReadSeeker interface.
+// When content is an *os.File and w is the ResponseWriter of an
+// HTTP/1.x connection, the file is sent with sendfile where the
+// operating system supports it. Content is always copied for HTTP/2
+// connections, for multi-range requests, and when the response
+// has a Content-Encoding. If w wraps the original ResponseWriter,
+// see ResponseController.EnableZeroCopy.
 func ServeContent(w ResponseWriter, req *Request, name string, modtime time.Time, content io.ReadSeeker) {
 	sizeFunc := func() (int64, error) {
 		size, err := content.Seek(0, io.SeekEnd)
@@ -201,7 +236,7 @@ func ServeContent(w ResponseWriter, req *Request, name string, modtime time.Time
 		}
 		return size, nil
 	}
-	serveContent(w, req, name, modtime, sizeFunc, content)
+	serveContent(w, req, name, modtime, sizeFunc, content, contentOptions{})
 }
 
 // errSeeker is returned by ServeContent's sizeFunc when the content
@@ -218,7 +253,7 @@ var errNoOverlap = errors.New("invalid range: failed to overlap")
 // if modtime.IsZero(), modtime is unknown.
 // content must be seeked to the beginning of the file.
 // The sizeFunc is called at most once. Its error, if any, is sent in the HTTP response.
-func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time, sizeFunc func() (int64, error), content io.ReadSeeker) {
+func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time, sizeFunc func() (int64, error), content io.ReadSeeker, opts contentOptions) {
 	setLastModified(w, modtime)
 	done, rangeReq := checkPreconditions(w, r, modtime)
 	if done {
@@ -267,6 +302,9 @@ func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time,
 			Error(w, err.Error(), StatusRequestedRangeNotSatisfiable)
 			return
 		}
+		if opts.coalesceRanges {
+			ranges = coalesceRanges(ranges)
+		}
 		if sumRangesSize(ranges) > size {
 			// The total number of bytes in all the ranges
 			// is larger than the size of the file by
@@ -326,7 +364,7 @@ func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time,
 		}
 
 		w.Header().Set("Accept-Ranges", "bytes")
-		if w.Header().Get("Content-Encoding") == "" {
+		if w.Header().Get("Content-Encoding") == "" || opts.encoded {
 			w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
 		}
 	}
@@ -334,7 +372,54 @@ func serveContent(w ResponseWriter, r *Request, name string, modtime time.Time,
 	w.WriteHeader(code)
 
 	if r.Method != "HEAD" {
-		io.CopyN(w, sendContent, sendSize)
+		copyContent(w, sendContent, sendSize)
+	}
+}
+
+// contentOptions are the options of serveContent.
+type contentOptions struct {
+	// encoded is set if the content is a precompressed variant
+	// whose size is known, so that the response's Content-Length
+	// is set even though it has a Content-Encoding.
+	encoded bool
+
+	// coalesceRanges is set to merge overlapping and adjacent
+	// ranges of a multi-range request.
+	coalesceRanges bool
+}
+
+// copyContent copies n bytes of content to w.
+//
+// Files opened from an fs.FS that are backed by an *os.File are read
+// directly, so that the response can send them with sendfile. If
+// zero-copy transfers were enabled with ResponseController.EnableZeroCopy,
+// the content is written to the original ResponseWriter, past any
+// wrappers that would otherwise hide its ReadFrom method.
+func copyContent(w ResponseWriter, content io.Reader, n int64) {
+	if f, ok := content.(ioFile); ok {
+		if osf, ok := f.file.(*os.File); ok {
+			content = osf
+		}
+	}
+	io.CopyN(zeroCopyWriter(w), content, n)
+}
+
+// zeroCopyWriter returns the original ResponseWriter wrapped by w if
+// zero-copy transfers were enabled on it, and w otherwise.
+func zeroCopyWriter(w ResponseWriter) ResponseWriter {
+	rw := w
+	for {
+		switch t := rw.(type) {
+		case *response:
+			if t.zeroCopy {
+				return t
+			}
+			return w
+		case rwUnwrapper:
+			rw = t.Unwrap()
+		default:
+			return w
+		}
 	}
 }
 
@@ -583,9 +668,15 @@ func checkPreconditions(w ResponseWriter, r *Request, modtime time.Time) (done b
 }
 
 // name is '/'-separated, not filepath.Separator.
-func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirect bool) {
+// If h is not nil, the file is served with the options of h.
+func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirect bool, h *fileHandler) {
 	const indexPage = "/index.html"
 
+	opts := new(FileServerOptions)
+	if h != nil {
+		opts = &h.opts
+	}
+
 	// redirect .../index.html to .../
 	// can't use Redirect() because that would make the path absolute,
 	// which would be a problem running under StripPrefix
@@ -594,6 +685,22 @@ func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirec
 		return
 	}
 
+	if opts.Dotfiles != DotfilesAllow && containsDotfile(name) {
+		if opts.Dotfiles == DotfilesDeny {
+			Error(w, "403 Forbidden", StatusForbidden)
+		} else {
+			NotFound(w, r)
+		}
+		return
+	}
+
+	if hfs, ok := fs.(*HashFS); ok {
+		var done bool
+		if name, done = hfs.serveConditional(w, r, name, opts); done {
+			return
+		}
+	}
+
 	f, err := fs.Open(name)
 	if err != nil {
 		msg, code := toHTTPError(err)
@@ -655,13 +762,121 @@ func serveFile(w ResponseWriter, r *Request, fs FileSystem, name string, redirec
 			return
 		}
 		setLastModified(w, d.ModTime())
-		dirList(w, r, f)
+		dirList(w, r, f, opts)
+		return
+	}
+
+	if opts.Precompressed && servePrecompressed(w, r, fs, name, d, f, h) {
+		return
+	}
+
+	if opts.HashETag && !h.setHashETag(w, name, d, f) {
 		return
 	}
 
 	// serveContent will check modification time
 	sizeFunc := func() (int64, error) { return d.Size(), nil }
-	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, f)
+	serveContent(w, r, d.Name(), d.ModTime(), sizeFunc, f, contentOptions{coalesceRanges: opts.CoalesceRanges})
+}
+
+// precompressedEncodings lists the content codings of the precompressed
+// variants that servePrecompressed looks for, in order of preference,
+// with the suffix that the name of each variant adds to the file name.
+var precompressedEncodings = []struct {
+	encoding, suffix string
+}{
+	{"zstd", ".zst"},
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed serves a precompressed variant of the file name,
+// with info d and contents f, if the client accepts its content coding.
+// It reports whether it served a response.
+// The variant is served with the options of h.
+func servePrecompressed(w ResponseWriter, r *Request, fsys FileSystem, name string, d fs.FileInfo, f File, h *fileHandler) bool {
+	var (
+		vf       File
+		vd       fs.FileInfo
+		encoding string
+		exists   bool
+	)
+	for _, pe := range precompressedEncodings {
+		ff, err := fsys.Open(name + pe.suffix)
+		if err != nil {
+			continue
+		}
+		dd, err := ff.Stat()
+		if err != nil || dd.IsDir() {
+			ff.Close()
+			continue
+		}
+		exists = true
+		if vf != nil || !acceptsEncoding(r.Header["Accept-Encoding"], pe.encoding) {
+			ff.Close()
+			continue
+		}
+		vf, vd, encoding = ff, dd, pe.encoding
+	}
+	if exists {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if vf == nil {
+		return false
+	}
+	defer vf.Close()
+
+	// The Content-Type is that of the original file.
+	if _, haveType := w.Header()["Content-Type"]; !haveType {
+		ctype := mime.TypeByExtension(filepath.Ext(d.Name()))
+		if ctype == "" {
+			var buf [sniffLen]byte
+			n, _ := io.ReadFull(f, buf[:])
+			ctype = DetectContentType(buf[:n])
+		}
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Header().Set("Content-Encoding", encoding)
+	if h.opts.HashETag && !h.setHashETag(w, name+"\x00"+encoding, vd, vf) {
+		return true
+	}
+	sizeFunc := func() (int64, error) { return vd.Size(), nil }
+	serveContent(w, r, d.Name(), vd.ModTime(), sizeFunc, vf, contentOptions{
+		encoded:        true,
+		coalesceRanges: h.opts.CoalesceRanges,
+	})
+	return true
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header values
+// accept the content coding, as described in RFC 7231, section 5.3.4.
+func acceptsEncoding(header []string, coding string) bool {
+	wildcard := false
+	for _, v := range header {
+		for _, elem := range strings.Split(v, ",") {
+			name, params := elem, ""
+			if i := strings.IndexByte(elem, ';'); i >= 0 {
+				name, params = elem[:i], elem[i+1:]
+			}
+			name = textproto.TrimString(name)
+			q := 1.0
+			for _, p := range strings.Split(params, ";") {
+				p = textproto.TrimString(p)
+				if len(p) > 2 && (p[0] == 'q' || p[0] == 'Q') && p[1] == '=' {
+					if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+						q = f
+					}
+				}
+			}
+			switch {
+			case ascii.EqualFold(name, coding):
+				return q > 0
+			case name == "*":
+				wildcard = q > 0
+			}
+		}
+	}
+	return wildcard
 }
 
 // toHTTPError returns a non-specific HTTP error message and status code
@@ -722,7 +937,31 @@ func ServeFile(w ResponseWriter, r *Request, name string) {
 		return
 	}
 	dir, file := filepath.Split(name)
-	serveFile(w, r, Dir(dir), file, false)
+	serveFile(w, r, Dir(dir), file, false, nil)
+}
+
+// ServeFileFS replies to the request with the contents
+// of the named file or directory from the file system fsys.
+//
+// If the client accepts a content coding for which a precompressed
+// variant of the file exists next to it, ServeFileFS serves the
+// variant with a Content-Encoding header instead. The variants are
+// named after the file with a suffix of ".zst" for zstd, ".br" for br,
+// or ".gz" for gzip, such as "foo.txt.zst" for "foo.txt", and are
+// preferred in that order. The response has the Content-Type of the
+// original file. When any variant exists, the response includes a
+// "Vary: Accept-Encoding" header.
+//
+// If the provided name is constructed from user input, it should be
+// sanitized before calling ServeFileFS. As with ServeFile, ServeFileFS
+// rejects requests where r.URL.Path contains a ".." path element, and
+// redirects requests where r.URL.Path ends in "/index.html".
+func ServeFileFS(w ResponseWriter, r *Request, fsys fs.FS, name string) {
+	if containsDotDot(r.URL.Path) {
+		Error(w, "invalid URL path", StatusBadRequest)
+		return
+	}
+	serveFile(w, r, FS(fsys), name, false, &fileHandler{opts: FileServerOptions{Precompressed: true}})
 }
 
 func containsDotDot(v string) bool {
@@ -740,7 +979,9 @@ func containsDotDot(v string) bool {
 func isSlashRune(r rune) bool { return r == '/' || r == '\\' }
 
 type fileHandler struct {
-	root FileSystem
+	root  FileSystem
+	opts  FileServerOptions
+	etags *etagCache // used if opts.HashETag is set
 }
 
 type ioFS struct {
@@ -836,7 +1077,136 @@ func FS(fsys fs.FS) FileSystem {
 //	http.Handle("/", http.FileServer(http.FS(fsys)))
 //
 func FileServer(root FileSystem) Handler {
-	return &fileHandler{root}
+	return &fileHandler{root: root}
+}
+
+// FileServerOptions are options for a file server created
+// with FileServerWithOptions.
+type FileServerOptions struct {
+	// DirList optionally renders the listing of a directory
+	// without an index.html file, in place of the default HTML
+	// listing. The entries are sorted by name and exclude the
+	// files hidden by Dotfiles.
+	DirList func(w ResponseWriter, r *Request, entries []fs.FileInfo)
+
+	// Dotfiles specifies how files and directories whose names
+	// start with a period, such as ".git", are served.
+	Dotfiles DotfilePolicy
+
+	// HashETag specifies whether files without a modification
+	// time, such as the files of an embed.FS, are served with an
+	// ETag computed from a hash of their contents, unless the
+	// response already has an ETag. The hash is computed once per
+	// file and size; the contents of such files are assumed not
+	// to change otherwise.
+	HashETag bool
+
+	// CoalesceRanges specifies whether overlapping and adjacent
+	// ranges of a multi-range request are merged before they are
+	// sent, in ascending order. A request whose ranges merge into
+	// one is answered with a single part, as for a single range.
+	CoalesceRanges bool
+
+	// Precompressed specifies whether precompressed variants of
+	// files are served to clients that accept their content
+	// coding, as described for ServeFileFS.
+	Precompressed bool
+}
+
+// A DotfilePolicy specifies how a file server created with
+// FileServerWithOptions serves files and directories whose names
+// start with a period.
+type DotfilePolicy int
+
+const (
+	// DotfilesAllow serves dotfiles like other files.
+	DotfilesAllow DotfilePolicy = iota
+
+	// DotfilesDeny responds to requests for dotfiles, and for
+	// files within dot-directories, with 403 Forbidden, and omits
+	// them from directory listings.
+	DotfilesDeny
+
+	// DotfilesIgnore responds to requests for dotfiles, and for
+	// files within dot-directories, with 404 Not Found, as if
+	// they did not exist, and omits them from directory listings.
+	DotfilesIgnore
+)
+
+// FileServerWithOptions returns a handler that serves HTTP requests
+// with the contents of the file system rooted at root, like
+// FileServer, configured by opts.
+func FileServerWithOptions(root FileSystem, opts FileServerOptions) Handler {
+	h := &fileHandler{root: root, opts: opts}
+	if opts.HashETag {
+		h.etags = new(etagCache)
+	}
+	return h
+}
+
+// hidden reports whether the directory entry name is omitted
+// from listings.
+func (opts *FileServerOptions) hidden(name string) bool {
+	return opts.Dotfiles != DotfilesAllow && strings.HasPrefix(name, ".")
+}
+
+// containsDotfile reports whether the '/'-separated name has
+// an element that starts with a period.
+func containsDotfile(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// etagCache caches the ETags computed for HashETag.
+type etagCache struct {
+	mu sync.Mutex
+	m  map[string]hashETag
+}
+
+type hashETag struct {
+	size int64
+	etag string
+}
+
+// setHashETag sets the ETag of the response to a hash of the contents
+// f, with info d, of the file name, if the file has no modification
+// time and the response has no ETag. It rewinds f. If f cannot be read,
+// setHashETag replies with an error and returns false.
+func (h *fileHandler) setHashETag(w ResponseWriter, name string, d fs.FileInfo, f File) bool {
+	if !isZeroTime(d.ModTime()) {
+		return true
+	}
+	if _, haveETag := w.Header()["Etag"]; haveETag {
+		return true
+	}
+	h.etags.mu.Lock()
+	e, ok := h.etags.m[name]
+	h.etags.mu.Unlock()
+	if !ok || e.size != d.Size() {
+		hash := sha256.New()
+		n, err := io.Copy(hash, f)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			msg, code := toHTTPError(err)
+			Error(w, msg, code)
+			return false
+		}
+		e = hashETag{size: n, etag: fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16])}
+		h.etags.mu.Lock()
+		if h.etags.m == nil {
+			h.etags.m = make(map[string]hashETag)
+		}
+		h.etags.m[name] = e
+		h.etags.mu.Unlock()
+	}
+	w.Header().Set("Etag", e.etag)
+	return true
 }
 
 func (f *fileHandler) ServeHTTP(w ResponseWriter, r *Request) {
@@ -845,7 +1215,7 @@ func (f *fileHandler) ServeHTTP(w ResponseWriter, r *Request) {
 		upath = "/" + upath
 		r.URL.Path = upath
 	}
-	serveFile(w, r, f.root, path.Clean(upath), true)
+	serveFile(w, r, f.root, path.Clean(upath), true, f)
 }
 
 // httpRange specifies the byte range to be sent to the client.
@@ -962,6 +1332,28 @@ func rangesMIMESize(ranges []httpRange, contentType string, contentSize int64) (
 	return
 }
 
+// coalesceRanges sorts ranges by their start and merges
+// the ranges that overlap or are adjacent.
+func coalesceRanges(ranges []httpRange) []httpRange {
+	if len(ranges) < 2 {
+		return ranges
+	}
+	sorted := append([]httpRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	merged := sorted[:1]
+	for _, ra := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if end := last.start + last.length; ra.start <= end {
+			if raEnd := ra.start + ra.length; raEnd > end {
+				last.length = raEnd - last.start
+			}
+			continue
+		}
+		merged = append(merged, ra)
+	}
+	return merged
+}
+
 func sumRangesSize(ranges []httpRange) (size int64) {
 	for _, ra := range ranges {
 		size += ra.length
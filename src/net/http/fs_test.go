@@ -26,6 +26,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -564,6 +565,331 @@ func testServeFileWithContentEncoding(t *testing.T, h2 bool) {
 	}
 }
 
+func TestServeFileFSPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.txt":     {Data: []byte("plain text")},
+		"foo.txt.zst": {Data: []byte("zstd data")},
+		"foo.txt.gz":  {Data: []byte("gzip data!")},
+		"bar.txt":     {Data: []byte("only plain")},
+	}
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+		wantVary       bool
+	}{
+		{"foo.txt", "", "", "plain text", true},
+		{"foo.txt", "gzip", "gzip", "gzip data!", true},
+		{"foo.txt", "gzip, zstd", "zstd", "zstd data", true},
+		{"foo.txt", "zstd;q=0, gzip", "gzip", "gzip data!", true},
+		{"foo.txt", "br", "", "plain text", true},
+		{"foo.txt", "*", "zstd", "zstd data", true},
+		{"foo.txt", "*, zstd;q=0", "gzip", "gzip data!", true},
+		{"bar.txt", "gzip, zstd", "", "only plain", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/"+tt.name, nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		ServeFileFS(rec, req, fsys, tt.name)
+		res := rec.Result()
+		if res.StatusCode != StatusOK {
+			t.Errorf("%s with Accept-Encoding %q: status = %d; want 200", tt.name, tt.acceptEncoding, res.StatusCode)
+			continue
+		}
+		if got := res.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("%s with Accept-Encoding %q: Content-Encoding = %q; want %q", tt.name, tt.acceptEncoding, got, tt.wantEncoding)
+		}
+		if got := rec.Body.String(); got != tt.wantBody {
+			t.Errorf("%s with Accept-Encoding %q: body = %q; want %q", tt.name, tt.acceptEncoding, got, tt.wantBody)
+		}
+		if got, want := res.Header.Get("Content-Length"), fmt.Sprint(len(tt.wantBody)); got != want {
+			t.Errorf("%s with Accept-Encoding %q: Content-Length = %q; want %q", tt.name, tt.acceptEncoding, got, want)
+		}
+		if got := res.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+			t.Errorf("%s with Accept-Encoding %q: Content-Type = %q; want text/plain", tt.name, tt.acceptEncoding, got)
+		}
+		if got := res.Header.Get("Vary") == "Accept-Encoding"; got != tt.wantVary {
+			t.Errorf("%s with Accept-Encoding %q: Vary = %q; want Accept-Encoding: %v", tt.name, tt.acceptEncoding, res.Header.Get("Vary"), tt.wantVary)
+		}
+	}
+}
+
+func TestFileServerDotfiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index/a.txt":       {Data: []byte("a")},
+		"index/.env":        {Data: []byte("secret")},
+		"index/.git/HEAD":   {Data: []byte("ref")},
+		"index/dir/b.txt":   {Data: []byte("b")},
+		"index/.well/c.txt": {Data: []byte("c")},
+	}
+	tests := []struct {
+		policy      DotfilePolicy
+		hiddenCode  int
+		listsHidden bool
+	}{
+		{DotfilesAllow, StatusOK, true},
+		{DotfilesDeny, StatusForbidden, false},
+		{DotfilesIgnore, StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		h := FileServerWithOptions(FS(fsys), FileServerOptions{Dotfiles: tt.policy})
+		for _, path := range []string{"/index/.env", "/index/.git/HEAD", "/index/.well/c.txt"} {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			if rec.Code != tt.hiddenCode {
+				t.Errorf("policy %d: GET %s = %d; want %d", tt.policy, path, rec.Code, tt.hiddenCode)
+			}
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/index/a.txt", nil))
+		if rec.Code != StatusOK {
+			t.Errorf("policy %d: GET /index/a.txt = %d; want 200", tt.policy, rec.Code)
+		}
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/index/", nil))
+		if got := strings.Contains(rec.Body.String(), ".env"); got != tt.listsHidden {
+			t.Errorf("policy %d: listing contains .env = %v; want %v\n%s", tt.policy, got, tt.listsHidden, rec.Body)
+		}
+	}
+}
+
+func TestFileServerDirList(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.txt":   {Data: []byte("bb")},
+		"a.txt":   {Data: []byte("a")},
+		".hidden": {Data: []byte("h")},
+		"sub/c":   {Data: []byte("c")},
+	}
+	h := FileServerWithOptions(FS(fsys), FileServerOptions{
+		Dotfiles: DotfilesIgnore,
+		DirList: func(w ResponseWriter, r *Request, entries []fs.FileInfo) {
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s %d %v\n", e.Name(), e.Size(), e.IsDir())
+			}
+		},
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got, want := rec.Body.String(), "a.txt 1 false\nb.txt 2 false\nsub 0 true\n"; got != want {
+		t.Errorf("listing = %q; want %q", got, want)
+	}
+}
+
+func TestFileServerHashETag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":    {Data: []byte("contents of a")},
+		"b.txt":    {Data: []byte("contents of b")},
+		"b.txt.gz": {Data: []byte("gzipped b")},
+		"m.txt":    {Data: []byte("modified"), ModTime: time.Unix(1e9, 0)},
+	}
+	h := FileServerWithOptions(FS(fsys), FileServerOptions{HashETag: true, Precompressed: true})
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	etagA := get("/a.txt").Header().Get("Etag")
+	if etagA == "" {
+		t.Fatal("no ETag for a.txt")
+	}
+	if got := get("/a.txt").Header().Get("Etag"); got != etagA {
+		t.Errorf("second ETag for a.txt = %q; want %q", got, etagA)
+	}
+	rec := get("/a.txt", "If-None-Match", etagA)
+	if rec.Code != StatusNotModified {
+		t.Errorf("If-None-Match: code = %d; want 304", rec.Code)
+	}
+	rec = get("/a.txt")
+	if rec.Body.String() != "contents of a" {
+		t.Errorf("body = %q; want contents of a", rec.Body)
+	}
+
+	etagB := get("/b.txt").Header().Get("Etag")
+	etagBGzip := get("/b.txt", "Accept-Encoding", "gzip").Header().Get("Etag")
+	if etagB == "" || etagB == etagA || etagBGzip == "" || etagBGzip == etagB {
+		t.Errorf("ETags of a.txt, b.txt and gzipped b.txt = %q, %q, %q; want distinct", etagA, etagB, etagBGzip)
+	}
+
+	if got := get("/m.txt").Header().Get("Etag"); got != "" {
+		t.Errorf("ETag of file with modification time = %q; want none", got)
+	}
+}
+
+// openCountingFS counts the files opened from an fs.FS.
+type openCountingFS struct {
+	fs.FS
+	opens int
+}
+
+func (fsys *openCountingFS) Open(name string) (fs.File, error) {
+	fsys.opens++
+	return fsys.FS.Open(name)
+}
+
+func TestHashFS(t *testing.T) {
+	fsys := &openCountingFS{FS: fstest.MapFS{
+		"app.css":        {Data: []byte("body { color: red }")},
+		"app.css.gz":     {Data: []byte("gzipped css")},
+		"js/app.min.js":  {Data: []byte("alert(1)")},
+		"js/index.html":  {Data: []byte("<p>index</p>")},
+		"noext":          {Data: []byte("no extension")},
+		"dir/.gitignore": {Data: []byte("*")},
+	}}
+	hfs, err := NewHashFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := FileServerWithOptions(hfs, FileServerOptions{Precompressed: true})
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	hashedPattern := regexp.MustCompile(`^/app\.[0-9a-f]{16}\.css$`)
+	hashed := hfs.HashName("/app.css")
+	if !hashedPattern.MatchString(hashed) {
+		t.Errorf("HashName(/app.css) = %q; want /app.<hash>.css", hashed)
+	}
+	if got := hfs.HashName("app.css"); got != hashed[1:] {
+		t.Errorf("HashName(app.css) = %q; want %q", got, hashed[1:])
+	}
+	if got := hfs.HashName("/js/app.min.js"); !regexp.MustCompile(`^/js/app\.min\.[0-9a-f]{16}\.js$`).MatchString(got) {
+		t.Errorf("HashName(/js/app.min.js) = %q; want /js/app.min.<hash>.js", got)
+	}
+	if got := hfs.HashName("/noext"); !regexp.MustCompile(`^/noext\.[0-9a-f]{16}$`).MatchString(got) {
+		t.Errorf("HashName(/noext) = %q; want /noext.<hash>", got)
+	}
+	if got := hfs.HashName("/missing.css"); got != "/missing.css" {
+		t.Errorf("HashName(/missing.css) = %q; want it unchanged", got)
+	}
+
+	plain := get("/app.css")
+	etag := plain.Header().Get("Etag")
+	if plain.Code != StatusOK || plain.Body.String() != "body { color: red }" || etag == "" {
+		t.Fatalf("GET /app.css = %d %q with ETag %q; want 200 with contents and ETag", plain.Code, plain.Body, etag)
+	}
+	if cc := plain.Header().Get("Cache-Control"); cc != "" {
+		t.Errorf("Cache-Control of unhashed name = %q; want none", cc)
+	}
+	if ct := plain.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/css") {
+		t.Errorf("Content-Type = %q; want text/css", ct)
+	}
+
+	rec := get(hashed)
+	if rec.Code != StatusOK || rec.Body.String() != "body { color: red }" {
+		t.Errorf("GET %s = %d %q; want 200 with contents of app.css", hashed, rec.Code, rec.Body)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Errorf("Cache-Control of hashed name = %q; want immutable", cc)
+	}
+	if got := rec.Header().Get("Etag"); got != etag {
+		t.Errorf("ETag of hashed name = %q; want %q", got, etag)
+	}
+
+	gz := get(hashed, "Accept-Encoding", "gzip")
+	gzETag := gz.Header().Get("Etag")
+	if gz.Body.String() != "gzipped css" || gz.Header().Get("Content-Encoding") != "gzip" || gzETag == etag {
+		t.Errorf("gzip request = %q, Content-Encoding %q, ETag %q; want precompressed variant with its own ETag", gz.Body, gz.Header().Get("Content-Encoding"), gzETag)
+	}
+
+	// Conditional requests are answered without opening files.
+	fsys.opens = 0
+	for _, tt := range []struct {
+		path   string
+		header []string
+		code   int
+	}{
+		{"/app.css", []string{"If-None-Match", etag}, StatusNotModified},
+		{hashed, []string{"If-None-Match", etag}, StatusNotModified},
+		{hashed, []string{"If-None-Match", `"other", ` + etag}, StatusNotModified},
+		{hashed, []string{"If-Match", `"other"`}, StatusPreconditionFailed},
+	} {
+		rec := get(tt.path, tt.header...)
+		if rec.Code != tt.code {
+			t.Errorf("%s with %q: code = %d; want %d", tt.path, tt.header, rec.Code, tt.code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", hashed, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", gzETag)
+	h.ServeHTTP(rec, req)
+	if rec.Code != StatusNotModified || rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("gzip If-None-Match: code = %d, Vary %q; want 304 with Vary: Accept-Encoding", rec.Code, rec.Header().Get("Vary"))
+	}
+	if fsys.opens != 0 {
+		t.Errorf("%d files opened for conditional requests; want 0", fsys.opens)
+	}
+
+	// A stale ETag gets the file.
+	if rec := get(hashed, "If-None-Match", `"stale"`); rec.Code != StatusOK {
+		t.Errorf("stale If-None-Match: code = %d; want 200", rec.Code)
+	}
+}
+
+func TestFileServerCoalesceRanges(t *testing.T) {
+	fsys := fstest.MapFS{"file": {Data: []byte("0123456789abcdefghij")}}
+	h := FileServerWithOptions(FS(fsys), FileServerOptions{CoalesceRanges: true})
+	tests := []struct {
+		r     string
+		code  int
+		parts []string // Content-Range values
+	}{
+		{"bytes=0-4,2-6", StatusPartialContent, []string{"bytes 0-6/20"}},
+		{"bytes=0-1,2-3", StatusPartialContent, []string{"bytes 0-3/20"}},
+		{"bytes=10-12,0-1,2-3", StatusPartialContent, []string{"bytes 0-3/20", "bytes 10-12/20"}},
+		{"bytes=0-9,0-9,0-9", StatusPartialContent, []string{"bytes 0-9/20"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/file", nil)
+		req.Header.Set("Range", tt.r)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("Range %q: code = %d; want %d", tt.r, rec.Code, tt.code)
+			continue
+		}
+		var got []string
+		ct := rec.Header().Get("Content-Type")
+		if strings.HasPrefix(ct, "multipart/byteranges") {
+			_, params, err := mime.ParseMediaType(ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mr := multipart.NewReader(rec.Body, params["boundary"])
+			for {
+				part, err := mr.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, part.Header.Get("Content-Range"))
+			}
+		} else {
+			got = []string{rec.Header().Get("Content-Range")}
+		}
+		if !reflect.DeepEqual(got, tt.parts) {
+			t.Errorf("Range %q: parts = %q; want %q", tt.r, got, tt.parts)
+		}
+	}
+}
+
 func TestServeIndexHtml(t *testing.T) {
 	defer afterTest(t)
 
@@ -1102,7 +1428,7 @@ func TestServerFileStatError(t *testing.T) {
 	redirect := false
 	name := "file.txt"
 	fs := issue12991FS{}
-	ExportServeFile(rec, r, fs, name, redirect)
+	ExportServeFile(rec, r, fs, name, redirect, nil)
 	if body := rec.Body.String(); !strings.Contains(body, "403") || !strings.Contains(body, "Forbidden") {
 		t.Errorf("wanted 403 forbidden message; got: %s", body)
 	}
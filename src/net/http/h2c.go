@@ -0,0 +1,214 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2/hpack"
+)
+
+// h2cClientPreface is the HTTP/2 client connection preface.
+const h2cClientPreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// serveH2C serves the connection with HTTP/2 if the request of w
+// starts an h2c connection, and reports whether it did. The request
+// either is the start of the HTTP/2 connection preface, sent by a
+// client with prior knowledge, or asks to upgrade the connection.
+//
+// The HTTP/2 server reads the connection from its start, so the bytes
+// it would have seen are given back to it: the preface, for a client
+// with prior knowledge, and for an upgrade, a preface, a SETTINGS frame
+// and a HEADERS frame carrying the upgrade request on stream 1, in
+// place of the preface that the client sends after the upgrade.
+func (c *conn) serveH2C(ctx context.Context, w *response) bool {
+	req := w.req
+	var head []byte
+	skipPreface := false
+	if req.isH2Upgrade() {
+		// The request line and the empty header were the start of
+		// the preface. Check that the rest of it follows.
+		const rest = "SM\r\n\r\n"
+		if b, err := c.bufr.Peek(len(rest)); err != nil || string(b) != rest {
+			return false
+		}
+		head = []byte(h2cClientPreface[:len(h2cClientPreface)-len(rest)])
+	} else {
+		settings, ok := h2cUpgradeSettings(req)
+		if !ok {
+			return false
+		}
+		head, ok = h2cUpgradeFrames(req, settings)
+		if !ok {
+			return false
+		}
+		if _, err := io.WriteString(c.rwc, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"); err != nil {
+			return true
+		}
+		skipPreface = true
+	}
+	defer w.cancelCtx()
+
+	// The HTTP/2 server has its own timeouts, and must
+	// not be bound by the deadline of the first request.
+	c.rwc.SetReadDeadline(time.Time{})
+
+	// Hand over the bytes that were read ahead along with the
+	// request. They are copied, as c.bufr is recycled once the
+	// connection is closed.
+	buffered, _ := c.bufr.Peek(c.bufr.Buffered())
+	var r io.Reader = io.MultiReader(bytes.NewReader(append([]byte(nil), buffered...)), c.rwc)
+	if skipPreface {
+		r = &h2cPrefaceSkipper{r: r}
+	}
+	nc := &h2cConn{
+		Conn: c.rwc,
+		r:    io.MultiReader(bytes.NewReader(head), r),
+	}
+	c.server.h2.ServeConn(nc, &http2ServeConnOpts{
+		Context:    ctx,
+		BaseConfig: c.server,
+		Handler:    serverHandler{c.server},
+	})
+	return true
+}
+
+// h2cHopHeaders are the HTTP/1.1 connection-specific header fields,
+// which are not allowed in HTTP/2.
+var h2cHopHeaders = map[string]bool{
+	"connection":        true,
+	"host":              true,
+	"http2-settings":    true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+}
+
+// h2cUpgradeFrames returns the client preface, a SETTINGS frame with
+// settings, and a HEADERS frame that carries req on stream 1 with no
+// body, for the HTTP/2 server to read in place of the client's. It
+// reports false if the header block does not fit in one frame of the
+// default maximum size.
+func h2cUpgradeFrames(req *Request, settings []byte) ([]byte, bool) {
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	enc.WriteField(hpack.HeaderField{Name: ":method", Value: req.Method})
+	enc.WriteField(hpack.HeaderField{Name: ":scheme", Value: "http"})
+	enc.WriteField(hpack.HeaderField{Name: ":authority", Value: req.Host})
+	enc.WriteField(hpack.HeaderField{Name: ":path", Value: req.RequestURI})
+	hop := make(map[string]bool)
+	for _, v := range req.Header["Connection"] {
+		for _, f := range strings.Split(v, ",") {
+			if f, ok := ascii.ToLower(textproto.TrimString(f)); ok && f != "" {
+				hop[f] = true
+			}
+		}
+	}
+	for k, vv := range req.Header {
+		k, ok := ascii.ToLower(k)
+		if !ok || h2cHopHeaders[k] || hop[k] {
+			continue
+		}
+		for _, v := range vv {
+			enc.WriteField(hpack.HeaderField{Name: k, Value: v})
+		}
+	}
+	const maxFrameSize = 16384 // the initial SETTINGS_MAX_FRAME_SIZE
+	if block.Len() > maxFrameSize || len(settings) > maxFrameSize {
+		return nil, false
+	}
+
+	const (
+		frameHeaders   = 0x1
+		frameSettings  = 0x4
+		flagEndStream  = 0x1
+		flagEndHeaders = 0x4
+	)
+	var b bytes.Buffer
+	b.WriteString(h2cClientPreface)
+	writeH2CFrame(&b, frameSettings, 0, 0, settings)
+	writeH2CFrame(&b, frameHeaders, flagEndStream|flagEndHeaders, 1, block.Bytes())
+	return b.Bytes(), true
+}
+
+// writeH2CFrame appends an HTTP/2 frame to b.
+func writeH2CFrame(b *bytes.Buffer, typ, flags byte, streamID uint32, payload []byte) {
+	n := len(payload)
+	b.Write([]byte{
+		byte(n >> 16), byte(n >> 8), byte(n),
+		typ, flags,
+		byte(streamID >> 24), byte(streamID >> 16), byte(streamID >> 8), byte(streamID),
+	})
+	b.Write(payload)
+}
+
+// h2cPrefaceSkipper drops the connection preface that a client sends
+// after the 101 response of an h2c upgrade, which the preface made up
+// by serveH2C has taken the place of.
+type h2cPrefaceSkipper struct {
+	r       io.Reader
+	skipped bool
+}
+
+func (s *h2cPrefaceSkipper) Read(p []byte) (int, error) {
+	if !s.skipped {
+		var b [len(h2cClientPreface)]byte
+		if _, err := io.ReadFull(s.r, b[:]); err != nil {
+			return 0, err
+		}
+		if string(b[:]) != h2cClientPreface {
+			return 0, errors.New("http: invalid HTTP/2 client preface after h2c upgrade")
+		}
+		s.skipped = true
+	}
+	return s.r.Read(p)
+}
+
+// h2cUpgradeSettings reports whether req asks to upgrade its HTTP/1.1
+// connection to h2c, as described in RFC 7540, section 3.2, and returns
+// the decoded contents of its HTTP2-Settings header. Requests with a
+// body are not upgraded, so that the body need not be buffered.
+func h2cUpgradeSettings(req *Request) ([]byte, bool) {
+	if !req.ProtoAtLeast(1, 1) || req.Method == "CONNECT" || req.ContentLength != 0 {
+		return nil, false
+	}
+	conn := req.Header["Connection"]
+	if !httpguts.HeaderValuesContainsToken(req.Header["Upgrade"], "h2c") ||
+		!httpguts.HeaderValuesContainsToken(conn, "Upgrade") ||
+		!httpguts.HeaderValuesContainsToken(conn, "HTTP2-Settings") {
+		return nil, false
+	}
+	values := req.Header["Http2-Settings"]
+	if len(values) != 1 {
+		return nil, false
+	}
+	settings, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(values[0], "="))
+	if err != nil || len(settings)%6 != 0 {
+		return nil, false
+	}
+	return settings, true
+}
+
+// h2cConn is a net.Conn whose reads first return the bytes
+// that the HTTP/1 server read ahead of an h2c connection.
+type h2cConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *h2cConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !nethttpomithttp2
+// +build !nethttpomithttp2
+
+package http
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// newH2CServer starts a Server with h2c enabled whose handler
+// replies with the protocol and path of the request.
+func newH2CServer(t *testing.T) (addr string, closeFunc func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{
+		Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+			io.WriteString(w, r.Proto+" "+r.URL.Path)
+		}),
+		UnencryptedHTTP2: true,
+	}
+	go srv.Serve(ln)
+	return ln.Addr().String(), func() { srv.Close() }
+}
+
+func TestServerH2CPriorKnowledge(t *testing.T) {
+	addr, closeServer := newH2CServer(t)
+	defer closeServer()
+
+	tr := &http2Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+	defer tr.CloseIdleConnections()
+	for i := 0; i < 2; i++ {
+		req, _ := NewRequest("GET", "http://"+addr+"/foo", nil)
+		res, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(body), "HTTP/2.0 /foo"; got != want {
+			t.Errorf("body = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestServerH2CUpgrade(t *testing.T) {
+	addr, closeServer := newH2CServer(t)
+	defer closeServer()
+
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(10 * time.Second))
+
+	// HTTP2-Settings carries SETTINGS_MAX_CONCURRENT_STREAMS = 100.
+	io.WriteString(c, "GET /up HTTP/1.1\r\nHost: example.com\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\n"+
+		"HTTP2-Settings: AAMAAABk\r\n\r\n")
+	br := bufio.NewReader(c)
+	res, err := ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != StatusSwitchingProtocols || res.Header.Get("Upgrade") != "h2c" {
+		t.Fatalf("got %s with Upgrade %q; want 101 with h2c", res.Status, res.Header.Get("Upgrade"))
+	}
+
+	io.WriteString(c, http2ClientPreface)
+	fr := http2NewFramer(c, br)
+	fr.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	if err := fr.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		status string
+		body   strings.Builder
+	)
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch f := f.(type) {
+		case *http2MetaHeadersFrame:
+			if f.StreamID != 1 {
+				t.Fatalf("HEADERS on stream %d; want 1", f.StreamID)
+			}
+			status = f.PseudoValue("status")
+		case *http2DataFrame:
+			if f.StreamID != 1 {
+				t.Fatalf("DATA on stream %d; want 1", f.StreamID)
+			}
+			body.Write(f.Data())
+		}
+		if f.Header().Flags.Has(http2FlagDataEndStream) && f.Header().StreamID == 1 {
+			break
+		}
+	}
+	if status != "200" {
+		t.Errorf(":status = %q; want 200", status)
+	}
+	if got, want := body.String(), "HTTP/2.0 /up"; got != want {
+		t.Errorf("body = %q; want %q", got, want)
+	}
+}
+
+func TestServerH2CFallback(t *testing.T) {
+	addr, closeServer := newH2CServer(t)
+	defer closeServer()
+
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	tests := []struct {
+		name string
+		req  func() *Request
+	}{
+		{"plain", func() *Request {
+			req, _ := NewRequest("GET", "http://"+addr+"/plain", nil)
+			return req
+		}},
+		{"upgrade with body", func() *Request {
+			req, _ := NewRequest("POST", "http://"+addr+"/plain", strings.NewReader("body"))
+			req.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+			req.Header.Set("Upgrade", "h2c")
+			req.Header.Set("HTTP2-Settings", "")
+			return req
+		}},
+	}
+	for _, tt := range tests {
+		res, err := tr.RoundTrip(tt.req())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if got, want := string(body), "HTTP/1.1 /plain"; got != want {
+			t.Errorf("%s: body = %q; want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestH2CUpgradeSettings(t *testing.T) {
+	tests := []struct {
+		header   string
+		settings string
+		ok       bool
+	}{
+		{"", "", true},
+		{"AAMAAABk", "\x00\x03\x00\x00\x00\x64", true},
+		{"AAMAAABk==", "\x00\x03\x00\x00\x00\x64", true},
+		{"AAMA", "", false}, // not a whole setting
+		{"A*MA", "", false}, // not base64url
+	}
+	for _, tt := range tests {
+		req := &Request{
+			Method:     "GET",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header: Header{
+				"Connection":     {"Upgrade, HTTP2-Settings"},
+				"Upgrade":        {"h2c"},
+				"Http2-Settings": {tt.header},
+			},
+		}
+		settings, ok := h2cUpgradeSettings(req)
+		if ok != tt.ok || string(settings) != tt.settings {
+			t.Errorf("h2cUpgradeSettings(%q) = %q, %v; want %q, %v", tt.header, settings, ok, tt.settings, tt.ok)
+		}
+	}
+}
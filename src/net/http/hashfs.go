@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// immutableCacheControl is the Cache-Control header of responses
+// for the hashed names of a HashFS.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// A HashFS is a FileSystem serving the files of an fs.FS whose
+// contents do not change, such as an embed.FS, with validators that
+// are computed once, when the HashFS is created. It is meant to be
+// used with FileServer or FileServerWithOptions:
+//
+//	hfs, err := http.NewHashFS(assets)
+//	...
+//	http.Handle("/static/", http.StripPrefix("/static", http.FileServer(hfs)))
+//
+// A file server serves each regular file of a HashFS with a strong
+// ETag computed from a SHA-256 hash of its contents, and answers
+// conditional requests with these ETags without opening the file:
+// a request whose If-None-Match header matches gets a 304 Not
+// Modified response right away.
+//
+// Each file may also be requested by its hashed name, returned by
+// HashName, which includes a prefix of the hash of its contents, such
+// as "app.3f2a1b9c5d7e8f60.css" for "app.css". As the contents at a
+// hashed name never change, responses for hashed names have the header
+// "Cache-Control: public, max-age=31536000, immutable", which lets
+// clients and caches keep them for a year without revalidating them.
+// Pages should link to files by their hashed names, so that clients
+// fetch the new version of a file when it changes.
+type HashFS struct {
+	fsys   fs.FS
+	files  map[string]hashedFile // by name, without a leading slash
+	hashed map[string]string     // file name by hashed name
+}
+
+type hashedFile struct {
+	etag       string
+	hashedName string
+}
+
+// NewHashFS returns a HashFS serving the files of fsys. It reads all
+// the regular files of fsys to hash them, and returns an error if any
+// of them cannot be read.
+func NewHashFS(fsys fs.FS) (*HashFS, error) {
+	h := &HashFS{
+		fsys:   fsys,
+		files:  make(map[string]hashedFile),
+		hashed: make(map[string]string),
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		sum := hash.Sum(nil)
+		hf := hashedFile{
+			// The same ETag as FileServerOptions.HashETag.
+			etag:       fmt.Sprintf(`"%x"`, sum[:16]),
+			hashedName: hashName(name, sum),
+		}
+		h.files[name] = hf
+		h.hashed[hf.hashedName] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// hashName returns name with the first 8 bytes of sum, in hexadecimal,
+// inserted before its extension.
+func hashName(name string, sum []byte) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:8]) + ext
+}
+
+// HashName returns the hashed name of the file with the slash-separated
+// name, which may start with a slash. If name is not the name of a
+// regular file of h, HashName returns name unchanged.
+func (h *HashFS) HashName(name string) string {
+	f, ok := h.files[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return name
+	}
+	if strings.HasPrefix(name, "/") {
+		return "/" + f.hashedName
+	}
+	return f.hashedName
+}
+
+// Open implements FileSystem. A hashed name opens the file that it
+// names.
+func (h *HashFS) Open(name string) (File, error) {
+	if n, hashed := h.resolve(name); hashed {
+		name = n
+	}
+	return ioFS{h.fsys}.Open(name)
+}
+
+// resolve returns the name of the file that the slash-separated name,
+// which may start with a slash, refers to, and whether name is a
+// hashed name.
+func (h *HashFS) resolve(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "/")
+	if _, ok := h.files[name]; ok {
+		return name, false
+	}
+	if n, ok := h.hashed[name]; ok {
+		return n, true
+	}
+	return name, false
+}
+
+// serveConditional sets the validators of the file name of h, and its
+// Cache-Control header if name is a hashed name, and answers the
+// request if its preconditions can be evaluated from them alone. It
+// returns the name of the file to serve otherwise, which starts with a
+// slash, and whether it answered the request.
+func (h *HashFS) serveConditional(w ResponseWriter, r *Request, name string, opts *FileServerOptions) (string, bool) {
+	n, hashed := h.resolve(name)
+	f, ok := h.files[n]
+	if !ok {
+		return name, false
+	}
+	name = "/" + n
+
+	// Use the ETag of the precompressed variant that is served
+	// instead, if any, as servePrecompressed would choose it.
+	etag, variants := f.etag, false
+	if opts.Precompressed {
+		chosen := false
+		for _, pe := range precompressedEncodings {
+			v, ok := h.files[n+pe.suffix]
+			if !ok {
+				continue
+			}
+			variants = true
+			if !chosen && acceptsEncoding(r.Header["Accept-Encoding"], pe.encoding) {
+				etag, chosen = v.etag, true
+			}
+		}
+	}
+
+	if hashed {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	}
+	if _, haveETag := w.Header()["Etag"]; !haveETag {
+		w.Header().Set("Etag", etag)
+	}
+	if checkIfMatch(w, r) != condFalse && checkIfNoneMatch(w, r) != condFalse {
+		// The file is needed to evaluate the other
+		// preconditions, if any, or to serve it.
+		return name, false
+	}
+	if variants {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	checkPreconditions(w, r, time.Time{})
+	return name, true
+}
@@ -161,6 +161,19 @@ type ClientTrace struct {
 	// request and any body. It may be called multiple times
 	// in the case of retried requests.
 	WroteRequest func(WroteRequestInfo)
+
+	// Retry is called when the Transport's RetryPolicy decides to
+	// retry the request, before waiting for the backoff delay.
+	Retry func(RetryInfo)
+
+	// DirectResponseBody is called when an HTTP/1 response body
+	// is copied with io.Copy or its WriteTo method into a Writer
+	// with a ReadFrom method, such as a Server's ResponseWriter,
+	// that is given direct access to the connection the body is
+	// read from. The argument is the number of bytes copied that
+	// way. Between TCP connections on Linux, these bytes are moved
+	// with splice(2) and do not pass through user space.
+	DirectResponseBody func(n int64)
 }
 
 // WroteRequestInfo contains information provided to the WroteRequest
@@ -170,6 +183,23 @@ type WroteRequestInfo struct {
 	Err error
 }
 
+// RetryInfo contains information provided to the Retry hook
+// about the attempt that is retried.
+type RetryInfo struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+
+	// Err is the error of the attempt, if any.
+	Err error
+
+	// StatusCode is the status code of the response to the
+	// attempt, or zero if it failed with Err.
+	StatusCode int
+
+	// Delay is how long the Transport waits before the next attempt.
+	Delay time.Duration
+}
+
 // compose modifies t such that it respects the previously-registered hooks in old,
 // subject to the composition policy requested in t.Compose.
 func (t *ClientTrace) compose(old *ClientTrace) {
@@ -8,6 +8,7 @@ package httputil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -444,6 +445,22 @@ func (p *ReverseProxy) copyResponse(dst io.Writer, src io.Reader, flushInterval
 // copyBuffer returns any write errors or non-EOF read errors, and the amount
 // of bytes written.
 func (p *ReverseProxy) copyBuffer(dst io.Writer, src io.Reader, buf []byte) (int64, error) {
+	// If the body can write itself to dst, let it: the Transport's
+	// HTTP/1 response bodies then hand their connection to the
+	// ResponseWriter's ReadFrom method, so that a body can be moved
+	// from one TCP connection to the other without being copied
+	// through buf.
+	if rf, ok := dst.(io.ReaderFrom); ok {
+		if wt, ok := src.(io.WriterTo); ok {
+			rec := &readErrorRecorder{w: dst, rf: rf}
+			written, err := wt.WriteTo(rec)
+			if rerr := rec.err; rerr != nil && rerr != io.EOF && rerr != context.Canceled {
+				p.logf("httputil: ReverseProxy read error during body copy: %v", rerr)
+			}
+			return written, err
+		}
+	}
+
 	if len(buf) == 0 {
 		buf = make([]byte, 32*1024)
 	}
@@ -474,6 +491,68 @@ func (p *ReverseProxy) copyBuffer(dst io.Writer, src io.Reader, buf []byte) (int
 	}
 }
 
+// readErrorRecorder is an io.ReaderFrom that records the first error,
+// other than io.EOF, returned by the Read method of the sources it
+// reads from.
+type readErrorRecorder struct {
+	w   io.Writer
+	rf  io.ReaderFrom
+	err error
+}
+
+func (r *readErrorRecorder) Write(p []byte) (int, error) {
+	return r.w.Write(p)
+}
+
+func (r *readErrorRecorder) ReadFrom(src io.Reader) (n int64, err error) {
+	if lr, ok := src.(*io.LimitedReader); ok {
+		// Keep the limit visible to rf, which needs it to splice
+		// the connection src reads from.
+		inner := &io.LimitedReader{R: r.wrap(lr.R), N: lr.N}
+		n, err = r.rf.ReadFrom(inner)
+		lr.N = inner.N
+	} else {
+		n, err = r.rf.ReadFrom(r.wrap(src))
+	}
+	var ce *io.CopyError
+	if errors.As(err, &ce) && ce.Op == "read" && r.err == nil {
+		r.err = ce.Err
+	}
+	return n, err
+}
+
+func (r *readErrorRecorder) wrap(src io.Reader) io.Reader {
+	er := &errorRecordingReader{r: src, rec: r}
+	if _, ok := src.(io.ReaderUnwrapper); ok {
+		// Let src be unwrapped to the connection it reads from.
+		// A failed read of the connection itself is reported by
+		// rf with a *io.CopyError.
+		return unwrappingErrorRecordingReader{er}
+	}
+	return er
+}
+
+type errorRecordingReader struct {
+	r   io.Reader
+	rec *readErrorRecorder
+}
+
+func (er *errorRecordingReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF && er.rec.err == nil {
+		er.rec.err = err
+	}
+	return n, err
+}
+
+type unwrappingErrorRecordingReader struct {
+	*errorRecordingReader
+}
+
+func (er unwrappingErrorRecordingReader) UnwrapReader() io.Reader {
+	return er.r
+}
+
 func (p *ReverseProxy) logf(format string, args ...interface{}) {
 	if p.ErrorLog != nil {
 		p.ErrorLog.Printf(format, args...)
@@ -4,8 +4,8 @@
 
 // The wire protocol for HTTP's "chunked" Transfer-Encoding.
 
-// Package internal contains HTTP internals shared by net/http and
-// net/http/httputil.
+// Package internal contains HTTP internals shared by net/http,
+// net/http/httputil and net/http/websocket.
 package internal
 
 import (
@@ -0,0 +1,12 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+// RegisterOnShutdownOnce is like srv.RegisterOnShutdown, where srv is
+// an *http.Server, but does nothing if a function was already registered
+// for key. It is set by package net/http, and used by
+// net/http/websocket, which registers a function for each Server that
+// it accepts connections from without keeping track of the Servers.
+var RegisterOnShutdownOnce func(srv interface{}, key interface{}, f func())
@@ -8,7 +8,9 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"net"
 	"sync"
 	"time"
 )
@@ -63,6 +65,14 @@ func http2NewPriorityWriteScheduler(interface{}) http2WriteScheduler { panic(noH
 
 func http2ConfigureServer(s *Server, conf *http2Server) error { panic(noHTTP2) }
 
+type http2ServeConnOpts struct {
+	Context    context.Context
+	BaseConfig *Server
+	Handler    Handler
+}
+
+func (*http2Server) ServeConn(net.Conn, *http2ServeConnOpts) { panic(noHTTP2) }
+
 var http2ErrNoCachedConn = http2noCachedConnError{}
 
 type http2noCachedConnError struct{}
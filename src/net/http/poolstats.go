@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import "time"
+
+// PoolStats is a snapshot of the connections of a Transport to one
+// host. See Transport.PoolStats.
+type PoolStats struct {
+	// Idle is the number of idle connections. HTTP/2 connections
+	// stay in the idle pool while they carry requests and are
+	// counted as idle.
+	Idle int
+
+	// Active is the number of HTTP/1 connections in use by a request.
+	Active int
+
+	// Dialing is the number of connections being dialed.
+	Dialing int
+
+	// Waiting is the number of requests waiting for a connection.
+	Waiting int
+
+	// Dials and DialErrors are the number of connections dialed,
+	// and the number of those dials that failed.
+	Dials      int64
+	DialErrors int64
+
+	// DialTime is the total time spent in successful dials,
+	// including the TLS handshake and any proxy CONNECT request.
+	DialTime time.Duration
+
+	// Requests is the number of requests given a connection,
+	// and Reused the number of those given a connection that
+	// had already been used.
+	Requests int64
+	Reused   int64
+}
+
+// MeanDialLatency returns the mean duration of successful dials.
+func (s PoolStats) MeanDialLatency() time.Duration {
+	if n := s.Dials - s.DialErrors; n > 0 {
+		return s.DialTime / time.Duration(n)
+	}
+	return 0
+}
+
+// ReuseRatio returns the fraction of requests that were given a
+// connection that had already been used.
+func (s PoolStats) ReuseRatio() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Reused) / float64(s.Requests)
+}
+
+// poolCounters holds the connection pool statistics of one
+// connectMethodKey. The number of idle connections is taken from the
+// idle pool when taking a snapshot.
+type poolCounters struct {
+	conns   int // dialing or open, as counted by connsPerHost
+	dialing int
+	waiting int
+
+	dials      int64
+	dialErrors int64
+	dialTime   time.Duration
+	requests   int64
+	reused     int64
+}
+
+// updatePoolStats calls f with the pool statistics of key. The
+// statistics are discarded once key has no connections, dials or
+// waiting requests left, so that a Transport talking to many hosts
+// does not accumulate them.
+func (t *Transport) updatePoolStats(key connectMethodKey, f func(*poolCounters)) {
+	t.poolStatsMu.Lock()
+	defer t.poolStatsMu.Unlock()
+	c := t.poolStats[key]
+	if c == nil {
+		if t.poolStats == nil {
+			t.poolStats = make(map[connectMethodKey]*poolCounters)
+		}
+		c = new(poolCounters)
+		t.poolStats[key] = c
+	}
+	f(c)
+	if c.conns <= 0 && c.dialing == 0 && c.waiting == 0 {
+		delete(t.poolStats, key)
+	}
+}
+
+// PoolStats returns a snapshot of the connection pool of t, keyed by
+// the host connections are made to, such as "https://example.com:443".
+// Connections through a proxy are keyed by the proxy URL followed by a
+// space and the host.
+//
+// A host is listed only while t has connections to it, is dialing it,
+// or has requests waiting for a connection to it. Its counters, such as
+// Dials and Requests, start again from zero when it is listed again.
+//
+// The result can be published with expvar:
+//
+//	expvar.Publish("http-pool", expvar.Func(func() interface{} { return tr.PoolStats() }))
+func (t *Transport) PoolStats() map[string]PoolStats {
+	t.poolStatsMu.Lock()
+	stats := make(map[string]PoolStats, len(t.poolStats))
+	conns := make(map[string]int)
+	for key, c := range t.poolStats {
+		name := key.poolStatsName()
+		s := stats[name]
+		s.Dialing += c.dialing
+		s.Waiting += c.waiting
+		s.Dials += c.dials
+		s.DialErrors += c.dialErrors
+		s.DialTime += c.dialTime
+		s.Requests += c.requests
+		s.Reused += c.reused
+		stats[name] = s
+		conns[name] += c.conns
+	}
+	t.poolStatsMu.Unlock()
+
+	t.idleMu.Lock()
+	for key, list := range t.idleConn {
+		name := key.poolStatsName()
+		s := stats[name]
+		s.Idle += len(list)
+		stats[name] = s
+	}
+	t.idleMu.Unlock()
+
+	for name, s := range stats {
+		if s.Active = conns[name] - s.Idle - s.Dialing; s.Active < 0 {
+			s.Active = 0
+		}
+		stats[name] = s
+	}
+	return stats
+}
+
+// poolStatsName returns the key of k in the result of PoolStats.
+func (k connectMethodKey) poolStatsName() string {
+	name := k.scheme + "://" + k.addr
+	if k.proxy != "" {
+		name = k.proxy + " " + name
+	}
+	return name
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// DefaultPriorityUrgency is the urgency of a request or response
+// that does not signal one.
+const DefaultPriorityUrgency = 3
+
+// Priority is a priority signal of the Extensible Prioritization
+// Scheme for HTTP, as defined in RFC 9218. A client may send it in the
+// Priority header of a request, and a server in the Priority header of
+// a response:
+//
+//	req.Header.Set("Priority", Priority{Urgency: 1}.String())
+//
+// The zero Priority has urgency 0, the highest; use ParsePriority("")
+// for the default priority.
+type Priority struct {
+	// Urgency is the urgency of the response, from 0, the most
+	// urgent, to 7, the least urgent.
+	Urgency int
+
+	// Incremental reports whether the response can be processed
+	// incrementally, as parts of it are received.
+	Incremental bool
+}
+
+// ParsePriority parses the value of a Priority header. Parameters
+// that are unknown or invalid are ignored, leaving their default
+// values: an urgency of DefaultPriorityUrgency and a non-incremental
+// response.
+func ParsePriority(v string) Priority {
+	p := Priority{Urgency: DefaultPriorityUrgency}
+	for _, member := range strings.Split(v, ",") {
+		// Parameters of the dictionary member are ignored.
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		key, value := textproto.TrimString(member), "?1"
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key, value = textproto.TrimString(key[:i]), textproto.TrimString(key[i+1:])
+		}
+		switch key {
+		case "u":
+			if len(value) == 1 && value[0] >= '0' && value[0] <= '7' {
+				p.Urgency = int(value[0] - '0')
+			}
+		case "i":
+			switch value {
+			case "?1":
+				p.Incremental = true
+			case "?0":
+				p.Incremental = false
+			}
+		}
+	}
+	return p
+}
+
+// String returns the value of a Priority header for p. Parameters
+// with their default value are omitted, so the default priority is
+// the empty string.
+func (p Priority) String() string {
+	var params []string
+	if p.Urgency != DefaultPriorityUrgency && p.Urgency >= 0 && p.Urgency <= 7 {
+		params = append(params, "u="+strconv.Itoa(p.Urgency))
+	}
+	if p.Incremental {
+		params = append(params, "i")
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	. "net/http"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Priority
+	}{
+		{"", Priority{Urgency: 3}},
+		{"u=0", Priority{Urgency: 0}},
+		{"u=5, i", Priority{Urgency: 5, Incremental: true}},
+		{"i=?1", Priority{Urgency: 3, Incremental: true}},
+		{"i, i=?0", Priority{Urgency: 3}},
+		{"u=8", Priority{Urgency: 3}},
+		{"u=-1", Priority{Urgency: 3}},
+		{"u=1;a=b, x=y", Priority{Urgency: 1}},
+		{"u=2, u=4", Priority{Urgency: 4}},
+		{"  u = 6 ", Priority{Urgency: 6}},
+	}
+	for _, tt := range tests {
+		if got := ParsePriority(tt.in); got != tt.want {
+			t.Errorf("ParsePriority(%q) = %+v; want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityString(t *testing.T) {
+	tests := []struct {
+		p    Priority
+		want string
+	}{
+		{Priority{Urgency: DefaultPriorityUrgency}, ""},
+		{Priority{Urgency: 0}, "u=0"},
+		{Priority{Urgency: 3, Incremental: true}, "i"},
+		{Priority{Urgency: 7, Incremental: true}, "u=7, i"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q; want %q", tt.p, got, tt.want)
+		}
+		if got := ParsePriority(tt.want); got != tt.p {
+			t.Errorf("ParsePriority(%q) = %+v; want %+v", tt.want, got, tt.p)
+		}
+	}
+}
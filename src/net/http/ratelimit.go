@@ -0,0 +1,133 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// A pacer limits the rate of a stream of bytes by waiting before
+// the bytes are transferred. The zero value does not limit the rate.
+// A pacer is not safe for concurrent use.
+type pacer struct {
+	rate  int64     // bytes per second; <= 0 means unlimited
+	start time.Time // start of the current measurement
+	n     int64     // bytes transferred since start
+
+	// deadline, if not zero, is the deadline of the transfers, at
+	// which waits end, so that the transfer then fails rather than
+	// wait past it.
+	deadline time.Time
+
+	// done and stop, if not nil, end waits when they are closed:
+	// done when the connection goes away, stop when the rate limit
+	// no longer applies, such as when the server shuts down.
+	done <-chan struct{}
+	stop <-chan struct{}
+}
+
+// setRate sets the rate of p, in bytes per second.
+// A rate of zero or less removes the limit.
+func (p *pacer) setRate(rate int64) {
+	p.rate, p.start, p.n = rate, time.Time{}, 0
+}
+
+// limited reports whether p limits the rate.
+func (p *pacer) limited() bool {
+	return p.rate > 0
+}
+
+// chunk returns the number of bytes to transfer at once, about an
+// eighth of a second's worth.
+func (p *pacer) chunk() int64 {
+	const minChunk, maxChunk = 512, 256 << 10
+	c := p.rate / 8
+	if c < minChunk {
+		c = minChunk
+	}
+	if c > maxChunk {
+		c = maxChunk
+	}
+	return c
+}
+
+// wait waits until the bytes transferred so far are due, and records
+// the transfer of n more bytes.
+func (p *pacer) wait(n int64) {
+	if p.rate <= 0 {
+		return
+	}
+	now := time.Now()
+	due := p.start.Add(time.Duration(float64(p.n) / float64(p.rate) * float64(time.Second)))
+	if p.start.IsZero() || now.After(due) {
+		// The stream was idle or slower than the rate. Start a new
+		// measurement, so that the unused time is not spent on a burst.
+		p.start, p.n = now, 0
+		due = now
+	}
+	p.n += n
+	d := due.Sub(now)
+	if !p.deadline.IsZero() {
+		if dl := p.deadline.Sub(now); dl < d {
+			d = dl
+		}
+	}
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-p.done:
+	case <-p.stop:
+		p.rate = 0
+	}
+}
+
+// write writes b to w in paced chunks.
+func (p *pacer) write(w io.Writer, b []byte) (n int, err error) {
+	for len(b) > 0 {
+		c := len(b)
+		if m := p.chunk(); int64(c) > m {
+			c = int(m)
+		}
+		p.wait(int64(c))
+		m, err := w.Write(b[:c])
+		n += m
+		if err != nil {
+			return n, err
+		}
+		b = b[c:]
+	}
+	return n, nil
+}
+
+// readFrom copies src to dst with dst.ReadFrom in paced chunks.
+// The chunks are *io.LimitedReaders of src, or of the reader wrapped
+// by src if src is itself an *io.LimitedReader, so that dst can still
+// recognize an *os.File and send it with sendfile.
+func (p *pacer) readFrom(dst io.ReaderFrom, src io.Reader) (n int64, err error) {
+	lr, ok := src.(*io.LimitedReader)
+	if !ok {
+		lr = &io.LimitedReader{R: src, N: math.MaxInt64}
+	}
+	for lr.N > 0 {
+		c := p.chunk()
+		if lr.N < c {
+			c = lr.N
+		}
+		p.wait(c)
+		m, err := dst.ReadFrom(&io.LimitedReader{R: lr.R, N: c})
+		n += m
+		lr.N -= m
+		if err != nil || m < c {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPacerWrite(t *testing.T) {
+	var p pacer
+	p.setRate(1 << 20)
+	var buf bytes.Buffer
+	start := time.Now()
+	n, err := p.write(&buf, make([]byte, 384<<10))
+	if n != 384<<10 || err != nil {
+		t.Fatalf("write = %d, %v; want %d, nil", n, err, 384<<10)
+	}
+	// Three 128 KiB chunks at 1 MiB/s: the last two wait 125ms each.
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("write took %v; want at least 200ms", d)
+	}
+}
+
+// recordingReaderFrom records the readers passed to ReadFrom.
+type recordingReaderFrom struct {
+	bytes.Buffer
+	srcs []io.Reader
+}
+
+func (w *recordingReaderFrom) ReadFrom(r io.Reader) (int64, error) {
+	w.srcs = append(w.srcs, r)
+	return w.Buffer.ReadFrom(r)
+}
+
+func TestPacerReadFrom(t *testing.T) {
+	var p pacer
+	p.setRate(8 << 10) // 1 KiB chunks
+	src := strings.NewReader(strings.Repeat("x", 4<<10))
+	var dst recordingReaderFrom
+	n, err := p.readFrom(&dst, &io.LimitedReader{R: src, N: 2500})
+	if n != 2500 || err != nil {
+		t.Fatalf("readFrom = %d, %v; want 2500, nil", n, err)
+	}
+	if len(dst.srcs) != 3 {
+		t.Fatalf("got %d chunks; want 3", len(dst.srcs))
+	}
+	for _, r := range dst.srcs {
+		if lr, ok := r.(*io.LimitedReader); !ok || lr.R != src {
+			t.Fatalf("chunk reader is %#v; want an *io.LimitedReader of the source", r)
+		}
+	}
+
+	// An unlimited source is copied until EOF.
+	dst = recordingReaderFrom{}
+	n, err = p.readFrom(&dst, strings.NewReader("hello"))
+	if n != 5 || err != nil || dst.String() != "hello" {
+		t.Fatalf("readFrom = %d, %v, %q; want 5, nil, %q", n, err, dst.String(), "hello")
+	}
+}
+
+func TestPacerWaitEnds(t *testing.T) {
+	// At 1 KiB/s, the second 512-byte chunk would wait half a second.
+	const rate = 1 << 10
+	b := make([]byte, 1<<10)
+
+	stop := make(chan struct{})
+	p := pacer{stop: stop}
+	p.setRate(rate)
+	close(stop)
+	start := time.Now()
+	if _, err := p.write(io.Discard, b); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 250*time.Millisecond {
+		t.Errorf("write after stop took %v", d)
+	}
+	if p.limited() {
+		t.Errorf("pacer still limited after stop")
+	}
+
+	p = pacer{deadline: time.Now().Add(10 * time.Millisecond)}
+	p.setRate(rate)
+	start = time.Now()
+	if _, err := p.write(io.Discard, b); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 250*time.Millisecond {
+		t.Errorf("write with a deadline 10ms away took %v", d)
+	}
+}
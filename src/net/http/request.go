@@ -1123,19 +1123,55 @@ func readRequest(b *bufio.Reader) (req *Request, err error) {
 // underlying reader when its Close method is called.
 //
 // MaxBytesReader prevents clients from accidentally or maliciously
-// sending a large request and wasting server resources.
+// sending a large request and wasting server resources. If possible,
+// it tells the ResponseWriter to close the connection after the limit
+// has been hit.
+//
+// Reads beyond the limit return a *MaxBytesError.
+//
+// If r is a request body that is limited by Server.MaxRequestBodyBytes,
+// a smaller limit n replaces the Server's limit and includes the bytes
+// of the body that were already read. A larger limit has no effect:
+// the Server's limit cannot be raised.
 func MaxBytesReader(w ResponseWriter, r io.ReadCloser, n int64) io.ReadCloser {
 	if n < 0 { // Treat negative limits as equivalent to 0.
 		n = 0
 	}
-	return &maxBytesReader{w: w, r: r, n: n}
+	if l, ok := r.(*maxBytesReader); ok && l.serverDefault && l.err == nil {
+		if n >= l.limit {
+			return r
+		}
+		remain := n - (l.limit - l.n)
+		if remain < 0 {
+			// More than n bytes were already read.
+			remain = 0
+		}
+		return &maxBytesReader{w: w, r: l.r, n: remain, limit: n}
+	}
+	return &maxBytesReader{w: w, r: r, n: n, limit: n}
+}
+
+// A MaxBytesError is returned by MaxBytesReader when its read limit
+// is exceeded.
+type MaxBytesError struct {
+	Limit int64
+}
+
+func (e *MaxBytesError) Error() string {
+	// Due to Hyrum's law, this text cannot be changed.
+	return "http: request body too large"
 }
 
 type maxBytesReader struct {
-	w   ResponseWriter
-	r   io.ReadCloser // underlying reader
-	n   int64         // max bytes remaining
-	err error         // sticky error
+	w     ResponseWriter
+	r     io.ReadCloser // underlying reader
+	n     int64         // max bytes remaining
+	limit int64         // the limit reported by MaxBytesError
+	err   error         // sticky error
+
+	// serverDefault is whether the reader applies the limit of
+	// Server.MaxRequestBodyBytes, which MaxBytesReader replaces.
+	serverDefault bool
 }
 
 func (l *maxBytesReader) Read(p []byte) (n int, err error) {
@@ -1172,10 +1208,18 @@ func (l *maxBytesReader) Read(p []byte) (n int, err error) {
 	type requestTooLarger interface {
 		requestTooLarge()
 	}
-	if res, ok := l.w.(requestTooLarger); ok {
-		res.requestTooLarge()
+	for w := l.w; w != nil; {
+		if res, ok := w.(requestTooLarger); ok {
+			res.requestTooLarge()
+			break
+		}
+		u, ok := w.(rwUnwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
 	}
-	l.err = errors.New("http: request body too large")
+	l.err = &MaxBytesError{l.limit}
 	return n, l.err
 }
 
@@ -1183,6 +1227,27 @@ func (l *maxBytesReader) Close() error {
 	return l.r.Close()
 }
 
+// unwrapServerBodyLimit returns the request body limited by
+// Server.MaxRequestBodyBytes, if body applies that limit, or else body.
+func unwrapServerBodyLimit(body io.ReadCloser) io.ReadCloser {
+	if l, ok := body.(*maxBytesReader); ok && l.serverDefault {
+		return l.r
+	}
+	return body
+}
+
+// MaxBytesHandler returns a Handler that runs h with its Request.Body
+// limited to n bytes by MaxBytesReader. Registered on a ServeMux, it
+// sets the body limit of the pattern, which can lower but not raise
+// the limit set by Server.MaxRequestBodyBytes.
+func MaxBytesHandler(h Handler, n int64) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		r2 := *r
+		r2.Body = MaxBytesReader(w, r.Body, n)
+		h.ServeHTTP(w, &r2)
+	})
+}
+
 func copyValues(dst, src url.Values) {
 	for k, vs := range src {
 		dst[k] = append(dst[k], vs...)
@@ -1405,6 +1470,31 @@ func (r *Request) wantsClose() bool {
 	return hasToken(r.Header.get("Connection"), "close")
 }
 
+// errNoGetBody is returned by Request.Rewind for a request
+// whose body cannot be rewound.
+var errNoGetBody = errors.New("net/http: cannot rewind body: Request.GetBody is nil")
+
+// Rewind resets the body of r so that it can be sent again, such as
+// after a failed attempt or to follow a redirect by hand. It closes
+// the current body and replaces it with a new one from r.GetBody.
+// Rewind does nothing for a request without a body, and returns an
+// error if r has a body but GetBody is nil.
+func (r *Request) Rewind() error {
+	if r.Body == nil || r.Body == NoBody {
+		return nil
+	}
+	if r.GetBody == nil {
+		return errNoGetBody
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = body
+	return nil
+}
+
 func (r *Request) closeBody() error {
 	if r.Body == nil {
 		return nil
@@ -1104,6 +1104,80 @@ func TestNewRequestGetBody(t *testing.T) {
 	}
 }
 
+// seekReader is an io.ReadSeeker that is not one of the types for
+// which NewRequest knows the length.
+type seekReader struct{ io.ReadSeeker }
+
+// NewRequest populates GetBody only for the readers it can snapshot;
+// a caller that can reproduce another body sets GetBody itself.
+func TestNewRequestGetBodyUnknownReader(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("hello"); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	bodies := []io.Reader{
+		f,
+		r,
+		seekReader{strings.NewReader("hello")},
+		struct{ io.Reader }{strings.NewReader("x")},
+	}
+	for _, body := range bodies {
+		req, err := NewRequest("POST", "http://foo.tld/", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.GetBody != nil {
+			t.Errorf("%T: GetBody != nil", body)
+		}
+		if err := req.Rewind(); err == nil {
+			t.Errorf("%T: Rewind succeeded; want error", body)
+		}
+	}
+}
+
+func TestRequestRewind(t *testing.T) {
+	req, err := NewRequest("POST", "http://foo.tld/", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		slurp, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("ReadAll(Body) = %v", err)
+		}
+		if string(slurp) != "hello" {
+			t.Errorf("read %d = %q; want %q", i, slurp, "hello")
+		}
+		if err := req.Rewind(); err != nil {
+			t.Fatalf("Rewind = %v", err)
+		}
+	}
+	req.Body.Close()
+}
+
+func TestRequestRewindNoBody(t *testing.T) {
+	for _, body := range []io.Reader{nil, NoBody} {
+		req, err := NewRequest("GET", "http://foo.tld/", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := req.Rewind(); err != nil {
+			t.Errorf("Rewind with body %v = %v; want nil", body, err)
+		}
+	}
+}
+
 func testMissingFile(t *testing.T, req *Request) {
 	f, fh, err := req.FormFile("missing")
 	if f != nil {
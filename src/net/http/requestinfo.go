@@ -0,0 +1,200 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// RequestInfo describes a request served by a Server.
+// See Server.RequestDone.
+type RequestInfo struct {
+	// Request is the request as read by the Server. Copies of the
+	// request made by handlers, such as with WithContext, are not
+	// reflected.
+	Request *Request
+
+	// Pattern is the pattern of the ServeMux entry that served the
+	// request, or "" if no ServeMux served it. If ServeMuxes are
+	// nested, it is the pattern of the innermost one.
+	Pattern string
+
+	// StatusCode is the status code of the response. It is zero
+	// if the handler hijacked the connection without writing a
+	// response header.
+	StatusCode int
+
+	// BytesWritten is the number of bytes of response body
+	// written by the handler.
+	BytesWritten int64
+
+	// Hijacked reports whether the handler hijacked the connection.
+	Hijacked bool
+
+	// BodyTooLarge reports whether the handler read past the limit
+	// of a MaxBytesReader on the request body, or the limit set by
+	// Server.MaxRequestBodyBytes.
+	BodyTooLarge bool
+
+	// Start is the time the Server started reading the request.
+	Start time.Time
+
+	// ReadHeader is the time taken to read the request header.
+	// It is zero for HTTP/2 requests, whose header is read as a
+	// whole before the Server starts tracking the request.
+	ReadHeader time.Duration
+
+	// FirstByte is the time from Start until the response header
+	// was written to the connection, or zero if it was not written.
+	// For HTTP/2 requests, it is the time until the handler first
+	// wrote or flushed the response.
+	FirstByte time.Duration
+
+	// Total is the time from Start until the response was finished.
+	Total time.Duration
+}
+
+// requestStats collects the information about a request that is
+// reported to Server.RequestDone. It is stored in the request's
+// context, so that ServeMux can record the pattern of the request
+// even if handlers copy it.
+type requestStats struct {
+	pattern    string
+	start      time.Time
+	headerRead time.Time
+	firstByte  time.Time
+
+	bodyTooLarge bool
+}
+
+// requestStatsContextKey is the context key of the *requestStats
+// of a request.
+var requestStatsContextKey = &contextKey{"http-request-stats"}
+
+// setRoutePattern records the ServeMux pattern that serves r,
+// if the Server reports r to Server.RequestDone.
+func setRoutePattern(r *Request, pattern string) {
+	if s, ok := r.Context().Value(requestStatsContextKey).(*requestStats); ok {
+		s.pattern = pattern
+	}
+}
+
+// wroteHeader records that the response header was written.
+func (s *requestStats) wroteHeader() {
+	if s != nil && s.firstByte.IsZero() {
+		s.firstByte = time.Now()
+	}
+}
+
+// report calls srv.RequestDone with the information about req.
+func (s *requestStats) report(srv *Server, req *Request, status int, written int64, hijacked bool) {
+	info := RequestInfo{
+		Request:      req,
+		Pattern:      s.pattern,
+		StatusCode:   status,
+		BytesWritten: written,
+		Hijacked:     hijacked,
+		BodyTooLarge: s.bodyTooLarge,
+		Start:        s.start,
+		ReadHeader:   s.headerRead.Sub(s.start),
+		Total:        time.Since(s.start),
+	}
+	if !s.firstByte.IsZero() {
+		info.FirstByte = s.firstByte.Sub(s.start)
+	}
+	srv.RequestDone(info)
+}
+
+// newStatsResponseWriter returns a copy of req and a ResponseWriter
+// wrapping rw that record an HTTP/2 request for Server.RequestDone.
+// The HTTP/2 server has no hooks for this, so the response is observed
+// through the ResponseWriter given to the Handler.
+func newStatsResponseWriter(rw ResponseWriter, req *Request) (*statsResponseWriter, *Request) {
+	now := time.Now()
+	stats := &requestStats{start: now, headerRead: now}
+	req = req.WithContext(context.WithValue(req.Context(), requestStatsContextKey, stats))
+	return &statsResponseWriter{rw: rw, stats: stats}, req
+}
+
+// report calls srv.RequestDone for req, once the Handler has returned.
+func (w *statsResponseWriter) report(srv *Server, req *Request) {
+	// The HTTP/2 server sends a 200 response
+	// for a handler that wrote nothing.
+	w.implicitHeader()
+	w.stats.report(srv, req, w.status, w.written, false)
+}
+
+// statsResponseWriter is a ResponseWriter that records the status,
+// size and timing of the response for Server.RequestDone. It has the
+// optional interfaces of the HTTP/2 server's ResponseWriter.
+type statsResponseWriter struct {
+	rw      ResponseWriter
+	stats   *requestStats
+	status  int
+	written int64
+}
+
+func (w *statsResponseWriter) Header() Header { return w.rw.Header() }
+
+func (w *statsResponseWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+		w.stats.wroteHeader()
+	}
+	w.rw.WriteHeader(code)
+}
+
+func (w *statsResponseWriter) Write(p []byte) (int, error) {
+	w.implicitHeader()
+	n, err := w.rw.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *statsResponseWriter) WriteString(s string) (int, error) {
+	w.implicitHeader()
+	n, err := io.WriteString(w.rw, s)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *statsResponseWriter) implicitHeader() {
+	if w.status == 0 {
+		w.status = StatusOK
+		w.stats.wroteHeader()
+	}
+}
+
+func (w *statsResponseWriter) Flush() {
+	w.implicitHeader()
+	if f, ok := w.rw.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statsResponseWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.rw.(CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+func (w *statsResponseWriter) Push(target string, opts *PushOptions) error {
+	if p, ok := w.rw.(Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return ErrNotSupported
+}
+
+// Unwrap lets ResponseController reach the HTTP/2 server's
+// ResponseWriter.
+func (w *statsResponseWriter) Unwrap() ResponseWriter { return w.rw }
+
+// requestTooLarge is called by MaxBytesReader.
+func (w *statsResponseWriter) requestTooLarge() {
+	w.stats.bodyTooLarge = true
+}
@@ -68,7 +68,9 @@ type Response struct {
 	//
 	// As of Go 1.12, the Body will also implement io.Writer
 	// on a successful "101 Switching Protocols" response,
-	// as used by WebSockets and HTTP/2's "h2c" mode.
+	// as used by WebSockets and HTTP/2's "h2c" mode. Such a Body
+	// also has SetReadDeadline and SetWriteDeadline methods, which
+	// set the deadlines of the underlying connection.
 	Body io.ReadCloser
 
 	// ContentLength records the length of the associated content. The
@@ -109,6 +111,13 @@ type Response struct {
 	// any trailer values sent by the server.
 	Trailer Header
 
+	// Informational holds the informational (1xx) responses, such
+	// as 103 Early Hints, that the server sent before this response,
+	// in the order they were received. It is only populated for
+	// Client requests made over HTTP/1.x. To act on informational
+	// responses as they arrive, use httptrace.ClientTrace.Got1xxResponse.
+	Informational []InformationalResponse
+
 	// Request is the request that was sent to obtain this Response.
 	// Request's Body is nil (having already been consumed).
 	// This is only populated for Client requests.
@@ -121,6 +130,26 @@ type Response struct {
 	TLS *tls.ConnectionState
 }
 
+// An InformationalResponse is an informational (1xx) response
+// received before the final response to a request.
+type InformationalResponse struct {
+	StatusCode int
+	Header     Header
+}
+
+// EarlyHints returns the headers of the 103 Early Hints responses
+// that the server sent before r, such as Link headers for resources
+// that the client may preload.
+func (r *Response) EarlyHints() []Header {
+	var hints []Header
+	for _, ir := range r.Informational {
+		if ir.StatusCode == StatusEarlyHints {
+			hints = append(hints, ir.Header)
+		}
+	}
+	return hints
+}
+
 // Cookies parses and returns the cookies set in the Set-Cookie headers.
 func (r *Response) Cookies() []*Cookie {
 	return readSetCookies(r.Header)
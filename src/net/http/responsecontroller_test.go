@@ -0,0 +1,134 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"errors"
+	"io"
+	. "net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+type unwrapResponseWriter struct {
+	ResponseWriter
+	written int
+}
+
+func (w *unwrapResponseWriter) Write(p []byte) (int, error) {
+	w.written += len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *unwrapResponseWriter) Unwrap() ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestResponseControllerFlush(t *testing.T) {
+	defer afterTest(t)
+	continuec := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(&unwrapResponseWriter{ResponseWriter: w})
+		w.Write([]byte("one"))
+		if err := ctl.Flush(); err != nil {
+			t.Errorf("ctl.Flush() = %v, want nil", err)
+			return
+		}
+		<-continuec
+		w.Write([]byte("two"))
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected connection error: %v", err)
+	}
+	defer res.Body.Close()
+
+	buf := make([]byte, 16)
+	n, err := res.Body.Read(buf)
+	close(continuec)
+	if err != nil || string(buf[:n]) != "one" {
+		t.Fatalf("Body.Read = %q, %v, want %q, nil", string(buf[:n]), err, "one")
+	}
+
+	got, err := io.ReadAll(res.Body)
+	if err != nil || string(got) != "two" {
+		t.Fatalf("Body.Read = %q, %v, want %q, nil", string(got), err, "two")
+	}
+}
+
+func TestResponseControllerSetDeadline(t *testing.T) {
+	defer afterTest(t)
+	errc := make(chan error, 2)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(&unwrapResponseWriter{ResponseWriter: w})
+		errc <- ctl.SetReadDeadline(time.Now().Add(time.Minute))
+		errc <- ctl.SetWriteDeadline(time.Now().Add(time.Minute))
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	for i := 0; i < 2; i++ {
+		if err := <-errc; err != nil {
+			t.Errorf("setting deadline: %v", err)
+		}
+	}
+}
+
+func TestResponseControllerEnableZeroCopy(t *testing.T) {
+	defer afterTest(t)
+	const file = "testdata/file"
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := make(chan int, 1)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		rw := &unwrapResponseWriter{ResponseWriter: w}
+		if err := NewResponseController(rw).EnableZeroCopy(); err != nil {
+			t.Errorf("EnableZeroCopy() = %v, want nil", err)
+		}
+		ServeFile(rw, r, file)
+		written <- rw.written
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || string(got) != string(want) {
+		t.Fatalf("body = %q, %v, want %q, nil", got, err, want)
+	}
+	if n := <-written; n != 0 {
+		t.Errorf("wrapper wrote %d bytes; want the file to bypass it", n)
+	}
+}
+
+func TestResponseControllerEnableZeroCopyHTTP2(t *testing.T) {
+	defer afterTest(t)
+	errc := make(chan error, 1)
+	cst := newClientServerTest(t, h2Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		errc <- NewResponseController(w).EnableZeroCopy()
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if err := <-errc; !errors.Is(err, ErrNotSupported) {
+		t.Errorf("EnableZeroCopy() = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http/httptrace"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// A RetryPolicy specifies how a Transport retries requests that
+// fail transiently. See Transport.RetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts to send
+	// a request, including the first one. If zero, a request
+	// is attempted at most 3 times.
+	MaxAttempts int
+
+	// Backoff optionally returns the delay before retry n of a
+	// request, starting at 1. If nil, the delay grows
+	// exponentially from 100ms, up to 10s, and is randomized to
+	// between half and all of that.
+	Backoff func(n int) time.Duration
+
+	// MaxRetryAfter is the longest delay that a response may
+	// request with a Retry-After header. Responses that request a
+	// longer delay are returned to the caller without a retry.
+	// If zero, the limit is one minute.
+	MaxRetryAfter time.Duration
+
+	// ShouldRetry optionally reports whether to retry a request
+	// whose attempt returned the response res, or failed with err,
+	// in place of the default decision. It must not read the body
+	// of res. ShouldRetry is only called for requests that can be
+	// retried safely, and not after the request's context is done.
+	ShouldRetry func(req *Request, res *Response, err error) bool
+}
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 100 * time.Millisecond
+	maxDefaultRetryBackoff = 10 * time.Second
+	defaultMaxRetryAfter   = time.Minute
+
+	// maxRetryDrainBytes is how much of the body of a response
+	// that is retried is read, so that its connection can be reused.
+	maxRetryDrainBytes = 4 << 10
+)
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	return defaultRetryAttempts
+}
+
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff(n)
+	}
+	d := maxDefaultRetryBackoff
+	if n < 8 {
+		if d1 := defaultRetryBackoff << (n - 1); d1 < d {
+			d = d1
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (p *RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxRetryAfter > 0 {
+		return p.MaxRetryAfter
+	}
+	return defaultMaxRetryAfter
+}
+
+// shouldRetry reports whether to retry req, whose attempt returned
+// res or failed with err. By default, network errors and responses
+// with status 429 Too Many Requests or 503 Service Unavailable are
+// retried.
+func (p *RetryPolicy) shouldRetry(req *Request, res *Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(req, res, err)
+	}
+	if err != nil {
+		return isTransientError(err)
+	}
+	return res.StatusCode == StatusTooManyRequests || res.StatusCode == StatusServiceUnavailable
+}
+
+// isTransientError reports whether the error of a round trip is a
+// network error, which may not occur again, such as a connection
+// reset or a timeout. Failures to find a host are not transient.
+func isTransientError(err error) bool {
+	var nwe nothingWrittenError
+	if err == errServerClosedIdle || errors.As(err, &nwe) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// retryRoundTrip sends req with roundTrip, retrying it according
+// to t.RetryPolicy.
+func (t *Transport) retryRoundTrip(req *Request, roundTrip func(*Request) (*Response, error)) (*Response, error) {
+	p := t.RetryPolicy
+	if !req.isReplayable() {
+		return roundTrip(req)
+	}
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	r := req
+	for attempt := 1; ; attempt++ {
+		res, err := roundTrip(r)
+		if attempt >= p.maxAttempts() || !p.shouldRetry(req, res, err) {
+			return res, err
+		}
+		delay := p.backoff(attempt)
+		if res != nil {
+			if d, ok := parseRetryAfter(res.Header.get("Retry-After"), time.Now()); ok {
+				if d > p.maxRetryAfter() {
+					return res, err
+				}
+				delay = d
+			}
+		}
+		next, rerr := replayRequest(req)
+		if rerr != nil {
+			return res, err
+		}
+
+		info := httptrace.RetryInfo{Attempt: attempt, Err: err, Delay: delay}
+		if res != nil {
+			info.StatusCode = res.StatusCode
+			io.CopyN(io.Discard, res.Body, maxRetryDrainBytes)
+			res.Body.Close()
+		}
+		if trace != nil && trace.Retry != nil {
+			trace.Retry(info)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			next.closeBody()
+			return nil, ctx.Err()
+		}
+		r = next
+	}
+}
+
+// replayRequest returns a request to send req again. If req has
+// a body, it is a shallow copy of req with a body from GetBody.
+func replayRequest(req *Request) (*Request, error) {
+	if req.Body == nil || req.Body == NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	r.Body = body
+	return &r, nil
+}
+
+// parseRetryAfter parses the value v of a Retry-After header, which
+// is either a delay in seconds or an HTTP date, as described in
+// RFC 7231, section 7.1.3.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = textproto.TrimString(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseUint(v, 10, 31); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -15,5 +15,8 @@ package http
 // Like the RoundTripper interface, the error types returned
 // by RoundTrip are unspecified.
 func (t *Transport) RoundTrip(req *Request) (*Response, error) {
+	if t.RetryPolicy != nil {
+		return t.retryRoundTrip(req, t.roundTrip)
+	}
 	return t.roundTrip(req)
 }
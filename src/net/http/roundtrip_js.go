@@ -45,6 +45,13 @@ var useFakeNetwork = js.Global().Get("fetch").IsUndefined()
 
 // RoundTrip implements the RoundTripper interface using the WHATWG Fetch API.
 func (t *Transport) RoundTrip(req *Request) (*Response, error) {
+	if t.RetryPolicy != nil {
+		return t.retryRoundTrip(req, t.roundTripFetch)
+	}
+	return t.roundTripFetch(req)
+}
+
+func (t *Transport) roundTripFetch(req *Request) (*Response, error) {
 	if useFakeNetwork {
 		return t.roundTrip(req)
 	}
@@ -3816,7 +3816,7 @@ func testServerReaderFromOrder(t *testing.T, h2 bool) {
 
 // Issue 6157, Issue 6685
 func TestCodesPreventingContentTypeAndBody(t *testing.T) {
-	for _, code := range []int{StatusNotModified, StatusNoContent, StatusContinue} {
+	for _, code := range []int{StatusNotModified, StatusNoContent} {
 		ht := newHandlerTest(HandlerFunc(func(w ResponseWriter, r *Request) {
 			if r.URL.Path == "/header" {
 				w.Header().Set("Content-Length", "123")
@@ -5671,6 +5671,108 @@ func TestServerShutdownStateNew(t *testing.T) {
 	}
 }
 
+func TestServerDrain(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9", "windows":
+		t.Skipf("listener files not supported on %s", runtime.GOOS)
+	}
+	setParallel(t)
+	defer afterTest(t)
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		inHandler <- struct{}{}
+		<-release
+		io.WriteString(w, "old")
+	}))
+	defer cst.close()
+
+	oldRes := make(chan *Response, 1)
+	go func() {
+		res, err := cst.c.Get(cst.ts.URL)
+		if err != nil {
+			t.Error(err)
+		}
+		oldRes <- res
+	}()
+	<-inHandler
+
+	// The new server serves the handed-off listener.
+	newSrv := &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "new")
+	})}
+	defer newSrv.Close()
+	statusc := make(chan DrainStatus, 1)
+	drainRes := make(chan error, 1)
+	go func() {
+		drainRes <- cst.ts.Config.Drain(context.Background(), &DrainOptions{
+			Handoff: func(files []*os.File) error {
+				if len(files) != 1 {
+					return fmt.Errorf("handed off %d files; want 1", len(files))
+				}
+				ln, err := net.FileListener(files[0])
+				if err != nil {
+					return err
+				}
+				go newSrv.Serve(ln)
+				return nil
+			},
+			Progress: func(st DrainStatus) {
+				select {
+				case statusc <- st:
+				default:
+				}
+			},
+			ProgressInterval: time.Millisecond,
+		})
+	}()
+
+	st := <-statusc
+	if len(st.Conns) != 1 || st.Count(StateActive) != 1 {
+		t.Errorf("drain status = %+v; want 1 active connection", st)
+	} else if c := st.Conns[0]; c.Age <= 0 || c.RemoteAddr == nil {
+		t.Errorf("drain status connection = %+v; want an age and address", c)
+	}
+
+	// New connections are accepted by the new server.
+	tr := &Transport{DisableKeepAlives: true}
+	defer tr.CloseIdleConnections()
+	if got := get(t, &Client{Transport: tr}, cst.ts.URL); got != "new" {
+		t.Errorf("after handoff, got %q; want %q", got, "new")
+	}
+
+	close(release)
+	if res := <-oldRes; res != nil {
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil || string(got) != "old" || !res.Close {
+			t.Errorf("in-flight response = %q, %v, Close = %v; want %q with connection closed", got, err, res.Close, "old")
+		}
+	}
+	if err := <-drainRes; err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+}
+
+func TestServerDrainHandoffError(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer cst.close()
+	err := cst.ts.Config.Drain(context.Background(), &DrainOptions{
+		Handoff: func([]*os.File) error { return errors.New("no new process") },
+	})
+	if err == nil {
+		t.Fatal("Drain succeeded; want an error")
+	}
+	// The server keeps serving.
+	if got := get(t, cst.c, cst.ts.URL); got != "ok" {
+		t.Errorf("after failed handoff, got %q; want %q", got, "ok")
+	}
+}
+
 // Issue 17878: tests that we can call Close twice.
 func TestServerCloseDeadlock(t *testing.T) {
 	var s Server
@@ -6609,3 +6711,200 @@ func testQuerySemicolon(t *testing.T, query string, wantX string, allowSemicolon
 		}
 	}
 }
+
+type fakeHTTP3Server struct {
+	pcc chan net.PacketConn
+}
+
+func (s *fakeHTTP3Server) ServeHTTP3(pc net.PacketConn, config *tls.Config, h Handler) error {
+	if len(config.NextProtos) != 1 || config.NextProtos[0] != "h3" {
+		return fmt.Errorf("NextProtos = %q; want [h3]", config.NextProtos)
+	}
+	s.pcc <- pc
+	var b [1]byte
+	_, _, err := pc.ReadFrom(b[:])
+	return err
+}
+
+func TestServerHTTP3(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	cert, err := tls.X509KeyPair(testcert.LocalhostCert, testcert.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newLocalListener(t)
+	h3 := &fakeHTTP3Server{pcc: make(chan net.PacketConn, 1)}
+	srv := &Server{
+		Handler:   HandlerFunc(func(w ResponseWriter, r *Request) {}),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		HTTP3:     h3,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ServeTLS(ln, "", "") }()
+
+	pc := <-h3.pcc
+	port := ln.Addr().(*net.TCPAddr).Port
+	if got := pc.LocalAddr().(*net.UDPAddr).Port; got != port {
+		t.Errorf("HTTP/3 socket port = %d; want %d", got, port)
+	}
+
+	c := &Client{Transport: &Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := c.Get("https://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := res.Header.Get("Alt-Svc"), fmt.Sprintf(`h3=":%d"; ma=86400`, port); got != want {
+		t.Errorf("Alt-Svc = %q; want %q", got, want)
+	}
+
+	srv.Close()
+	if err := <-errc; err != ErrServerClosed {
+		t.Errorf("ServeTLS = %v; want ErrServerClosed", err)
+	}
+	if _, err := pc.WriteTo([]byte("x"), pc.LocalAddr()); err == nil {
+		t.Error("HTTP/3 socket not closed by Close")
+	}
+}
+
+func TestServerWriteRate(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	const size = 64 << 10
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		if err := NewResponseController(w).SetWriteRate(size * 2); err != nil {
+			t.Errorf("SetWriteRate: %v", err)
+		}
+		w.Write(make([]byte, size))
+	}), func(ts *httptest.Server) {
+		ts.Config.WriteRate = 1 // overridden by the handler
+	})
+	defer cst.close()
+
+	start := time.Now()
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if n != size || err != nil {
+		t.Fatalf("read %d, %v; want %d, nil", n, err, size)
+	}
+	// The body is sent in 16 KiB chunks, an eighth of a second apart.
+	if d := time.Since(start); d < 300*time.Millisecond {
+		t.Errorf("response took %v; want at least 300ms", d)
+	}
+}
+
+func TestServerRequestDoneHijacked(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	infoc := make(chan RequestInfo, 1)
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		conn, bufrw, err := w.(Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		bufrw.WriteString("HTTP/1.0 200 OK\r\n\r\nhijacked")
+		bufrw.Flush()
+	}))
+	ts.Config.RequestDone = func(info RequestInfo) { infoc <- info }
+	ts.Start()
+	defer ts.Close()
+
+	res, err := Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	info := <-infoc
+	if !info.Hijacked || info.StatusCode != 0 || info.BytesWritten != 0 {
+		t.Errorf("Hijacked = %v, StatusCode = %d, BytesWritten = %d; want true, 0, 0", info.Hijacked, info.StatusCode, info.BytesWritten)
+	}
+}
+
+// readFromRecorderConn records the readers passed to the ReadFrom
+// method of the connection.
+type readFromRecorderConn struct {
+	*net.TCPConn
+	srcs chan io.Reader
+}
+
+func (c readFromRecorderConn) ReadFrom(r io.Reader) (int64, error) {
+	c.srcs <- r
+	return c.TCPConn.ReadFrom(r)
+}
+
+type readFromRecorderListener struct {
+	net.Listener
+	srcs chan io.Reader
+}
+
+func (ln readFromRecorderListener) Accept() (net.Conn, error) {
+	c, err := ln.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return readFromRecorderConn{c.(*net.TCPConn), ln.srcs}, nil
+}
+
+// Copying a TCP connection to a ResponseWriter must hand the
+// connection itself over to the ReadFrom method of the client
+// connection, so that the data can be spliced between the sockets.
+func TestResponseWriterReadFromTCPConn(t *testing.T) {
+	defer afterTest(t)
+	body := strings.Repeat("x", 4<<10) // more than the sniffed prefix
+
+	srcLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srcLn.Close()
+	go func() {
+		c, err := srcLn.Accept()
+		if err != nil {
+			return
+		}
+		io.WriteString(c, body)
+		c.Close()
+	}()
+
+	srcs := make(chan io.Reader, 1)
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		src, err := net.Dial("tcp", srcLn.Addr().String())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer src.Close()
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if _, err := io.Copy(w, src); err != nil {
+			t.Error(err)
+		}
+	}))
+	ts.Listener = readFromRecorderListener{ts.Listener, srcs}
+	ts.Start()
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || string(got) != body {
+		t.Fatalf("body = %q, %v; want %d bytes", got, err, len(body))
+	}
+	select {
+	case src := <-srcs:
+		if _, ok := src.(syscall.Conn); !ok {
+			t.Errorf("ReadFrom of the connection got %T, which hides the source connection", src)
+		}
+	default:
+		t.Error("ReadFrom of the connection not called")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"log"
 	"math/rand"
 	"net"
+	"net/http/internal"
 	"net/textproto"
 	"net/url"
 	urlpkg "net/url"
@@ -147,10 +148,21 @@ type ResponseWriter interface {
 	// send error codes.
 	//
 	// The provided code must be a valid HTTP 1xx-5xx status code.
-	// Only one header may be written. Go does not currently
-	// support sending user-defined 1xx informational headers,
-	// with the exception of 100-continue response header that the
-	// Server sends automatically when the Request.Body is read.
+	// Any number of 1xx headers may be written, followed by at most
+	// one 2xx-5xx header. 1xx headers are sent immediately, but 2xx-5xx
+	// headers may be buffered. Use the Flusher interface to send
+	// buffered data. The header map is cleared when 2xx-5xx headers are
+	// sent, but not with 1xx headers, so that headers such as the Link
+	// headers of a 103 Early Hints response can be sent again with the
+	// final response.
+	//
+	// Only HTTP/1.x connections support sending 1xx headers. The HTTP/2
+	// server takes the first header written as the final one, so
+	// handlers should only write 1xx headers if Request.ProtoMajor is 1.
+	//
+	// The server will automatically send a 100 (Continue) header
+	// on the first read from the request body if the request has
+	// an "Expect: 100-continue" header.
 	WriteHeader(statusCode int)
 }
 
@@ -259,6 +271,14 @@ type conn struct {
 	// *tls.Conn.
 	rwc net.Conn
 
+	// start is when the connection was accepted.
+	start time.Time
+
+	// writeRate paces writes to rwc. It is reset to the Server's
+	// WriteRate for each request, and stops pacing when the
+	// connection is done or the Server shuts down.
+	writeRate pacer
+
 	// remoteAddr is rwc.RemoteAddr().String(). It is not populated synchronously
 	// inside the Listener's Accept goroutine, as some implementations block.
 	// It is populated immediately inside the (*conn).serve goroutine.
@@ -472,6 +492,12 @@ type response struct {
 
 	handlerDone atomicBool // set true when the handler exits
 
+	// zeroCopy is set by EnableZeroCopy. It lets ServeContent write
+	// file contents to the response past ResponseWriter wrappers.
+	zeroCopy bool
+
+	stats *requestStats // nil unless the Server has a RequestDone hook
+
 	// Buffers for Date, Content-Length, and status code
 	dateBuf   [len(TimeFormat)]byte
 	clenBuf   [10]byte
@@ -544,6 +570,9 @@ func (w *response) declareTrailer(k string) {
 func (w *response) requestTooLarge() {
 	w.closeAfterReply = true
 	w.requestBodyLimitHit = true
+	if w.stats != nil {
+		w.stats.bodyTooLarge = true
+	}
 	if !w.wroteHeader {
 		w.Header().Set("Connection", "close")
 	}
@@ -561,6 +590,12 @@ type writerOnly struct {
 	io.Writer
 }
 
+// readerOnly hides an io.Reader value's optional WriteTo method
+// from io.Copy.
+type readerOnly struct {
+	io.Reader
+}
+
 // ReadFrom is here to optimize copying from an *os.File regular file
 // to a *net.TCPConn with sendfile, or from a supported src type such
 // as a *net.TCPConn on Linux with splice.
@@ -594,7 +629,12 @@ func (w *response) ReadFrom(src io.Reader) (n int64, err error) {
 
 	// Now that cw has been flushed, its chunking field is guaranteed initialized.
 	if !w.cw.chunking && w.bodyAllowed() {
-		n0, err := rf.ReadFrom(src)
+		var n0 int64
+		if w.conn.writeRate.limited() {
+			n0, err = w.conn.writeRate.readFrom(rf, src)
+		} else {
+			n0, err = rf.ReadFrom(src)
+		}
 		n += n0
 		w.written += n0
 		return n, err
@@ -614,6 +654,7 @@ func (srv *Server) newConn(rwc net.Conn) *conn {
 	c := &conn{
 		server: srv,
 		rwc:    rwc,
+		start:  time.Now(),
 	}
 	if debugServerConnections {
 		c.rwc = newLoggingConn("server", c.rwc)
@@ -999,6 +1040,11 @@ func (c *conn) readRequest(ctx context.Context) (w *response, err error) {
 	delete(req.Header, "Host")
 
 	ctx, cancelCtx := context.WithCancel(ctx)
+	var stats *requestStats
+	if c.server.RequestDone != nil {
+		stats = &requestStats{start: t0, headerRead: time.Now()}
+		ctx = context.WithValue(ctx, requestStatsContextKey, stats)
+	}
 	req.ctx = ctx
 	req.RemoteAddr = c.remoteAddr
 	req.TLS = c.tlsState
@@ -1011,6 +1057,11 @@ func (c *conn) readRequest(ctx context.Context) (w *response, err error) {
 		c.rwc.SetReadDeadline(wholeReqDeadline)
 	}
 
+	c.writeRate.setRate(c.server.WriteRate)
+	c.writeRate.deadline = time.Time{}
+	if d := c.server.WriteTimeout; d > 0 {
+		c.writeRate.deadline = time.Now().Add(d)
+	}
 	w = &response{
 		conn:          c,
 		cancelCtx:     cancelCtx,
@@ -1019,6 +1070,7 @@ func (c *conn) readRequest(ctx context.Context) (w *response, err error) {
 		handlerHeader: make(Header),
 		contentLength: -1,
 		closeNotifyCh: make(chan bool, 1),
+		stats:         stats,
 
 		// We populate these ahead of time so we're not
 		// reading from req.Header after their Handler starts
@@ -1121,6 +1173,24 @@ func (w *response) WriteHeader(code int) {
 		return
 	}
 	checkWriteHeaderCode(code)
+
+	// Informational headers are sent right away. 101 Switching
+	// Protocols is final, as no other header may follow it.
+	if code >= 100 && code <= 199 && code != StatusSwitchingProtocols {
+		// Prevent a race with an automatic 100 Continue
+		// triggered by reading the request body.
+		if code == StatusContinue && w.canWriteContinue.isSet() {
+			w.writeContinueMu.Lock()
+			w.canWriteContinue.setFalse()
+			w.writeContinueMu.Unlock()
+		}
+		writeStatusLine(w.conn.bufw, w.req.ProtoAtLeast(1, 1), code, w.statusBuf[:])
+		w.handlerHeader.WriteSubset(w.conn.bufw, excludedHeadersNoBody)
+		w.conn.bufw.Write(crlf)
+		w.conn.bufw.Flush()
+		return
+	}
+
 	w.wroteHeader = true
 	w.status = code
 
@@ -1139,6 +1209,10 @@ func (w *response) WriteHeader(code int) {
 	}
 }
 
+// excludedHeadersNoBody are the headers that are not sent with
+// responses that have no body, such as informational responses.
+var excludedHeadersNoBody = map[string]bool{"Content-Length": true, "Transfer-Encoding": true}
+
 // extraHeader is the set of headers sometimes added by chunkWriter.writeHeader.
 // This type is used to avoid extra allocations from cloning and/or populating
 // the response Header map and all its 1-element slices.
@@ -1203,6 +1277,7 @@ func (cw *chunkWriter) writeHeader(p []byte) {
 	cw.wroteHeader = true
 
 	w := cw.res
+	w.stats.wroteHeader()
 	keepAlivesEnabled := w.conn.server.doKeepAlives()
 	isHEAD := w.req.Method == "HEAD"
 
@@ -1307,7 +1382,7 @@ func (cw *chunkWriter) writeHeader(p []byte) {
 	// because we don't know if the next bytes on the wire will be
 	// the body-following-the-timer or the subsequent request.
 	// See Issue 11549.
-	if ecr, ok := w.req.Body.(*expectContinueReader); ok && !ecr.sawEOF.isSet() {
+	if ecr, ok := unwrapServerBodyLimit(w.req.Body).(*expectContinueReader); ok && !ecr.sawEOF.isSet() {
 		w.closeAfterReply = true
 	}
 
@@ -1321,7 +1396,7 @@ func (cw *chunkWriter) writeHeader(p []byte) {
 	if w.req.ContentLength != 0 && !w.closeAfterReply {
 		var discard, tooBig bool
 
-		switch bdy := w.req.Body.(type) {
+		switch bdy := unwrapServerBodyLimit(w.req.Body).(type) {
 		case *expectContinueReader:
 			if bdy.resp.wroteContinue {
 				discard = true
@@ -1647,7 +1722,7 @@ func (w *response) shouldReuseConnection() bool {
 }
 
 func (w *response) closedRequestBodyEarly() bool {
-	body, ok := w.req.Body.(*body)
+	body, ok := unwrapServerBodyLimit(w.req.Body).(*body)
 	return ok && body.didEarlyClose()
 }
 
@@ -1659,6 +1734,34 @@ func (w *response) Flush() {
 	w.cw.flush()
 }
 
+func (w *response) SetReadDeadline(deadline time.Time) error {
+	return w.conn.rwc.SetReadDeadline(deadline)
+}
+
+func (w *response) SetWriteDeadline(deadline time.Time) error {
+	w.conn.writeRate.deadline = deadline
+	return w.conn.rwc.SetWriteDeadline(deadline)
+}
+
+func (w *response) SetWriteRate(bytesPerSec int64) error {
+	w.conn.writeRate.setRate(bytesPerSec)
+	return nil
+}
+
+func (w *response) EnableZeroCopy() error {
+	switch rwc := w.conn.rwc.(type) {
+	case *net.TCPConn:
+	case *tls.Conn:
+		if !rwc.ConnectionState().KernelTX {
+			return errNotSupported()
+		}
+	default:
+		return errNotSupported()
+	}
+	w.zeroCopy = true
+	return nil
+}
+
 func (c *conn) finalFlush() {
 	if c.bufr != nil {
 		// Steal the bufio.Reader (~4KB worth of memory) and its associated
@@ -1846,6 +1949,8 @@ func (c *conn) serve(ctx context.Context) {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	c.cancelCtx = cancelCtx
 	defer cancelCtx()
+	c.writeRate.done = ctx.Done()
+	c.writeRate.stop = c.server.getDoneChan()
 
 	c.r = &connReader{conn: c}
 	c.bufr = newBufioReader(c.r)
@@ -1898,6 +2003,10 @@ func (c *conn) serve(ctx context.Context) {
 			}
 		}
 
+		if c.tlsState == nil && c.server.UnencryptedHTTP2 && c.server.h2 != nil && c.serveH2C(ctx, w) {
+			return
+		}
+
 		// Expect 100 Continue support
 		req := w.req
 		if req.expectsContinue() {
@@ -1929,9 +2038,15 @@ func (c *conn) serve(ctx context.Context) {
 		serverHandler{c.server}.ServeHTTP(w, w.req)
 		w.cancelCtx()
 		if c.hijacked() {
+			if w.stats != nil {
+				w.stats.report(c.server, w.req, w.status, w.written, true)
+			}
 			return
 		}
 		w.finishRequest()
+		if w.stats != nil {
+			w.stats.report(c.server, w.req, w.status, w.written, false)
+		}
 		if !w.shouldReuseConnection() {
 			if w.requestBodyLimitHit || w.closedRequestBodyEarly() {
 				c.closeWriteAndWait()
@@ -2420,7 +2535,8 @@ func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *Request) {
 		w.WriteHeader(StatusBadRequest)
 		return
 	}
-	h, _ := mux.Handler(r)
+	h, pattern := mux.Handler(r)
+	setRoutePattern(r, pattern)
 	h.ServeHTTP(w, r)
 }
 
@@ -2580,6 +2696,21 @@ type Server struct {
 	// If zero, DefaultMaxHeaderBytes is used.
 	MaxHeaderBytes int
 
+	// MaxRequestBodyBytes, if positive, limits the size of request
+	// bodies, as if each Handler wrapped them with MaxBytesReader.
+	// Handlers and ServeMux patterns can lower the limit, but not
+	// raise it, with MaxBytesReader or MaxBytesHandler.
+	// Requests that exceed their limit are recorded in the
+	// RequestInfo passed to RequestDone.
+	MaxRequestBodyBytes int64
+
+	// WriteRate, if positive, limits the rate at which each HTTP/1.x
+	// connection writes responses, in bytes per second, including
+	// response bodies sent with sendfile. Handlers can change the
+	// limit of a response with ResponseController.SetWriteRate.
+	// HTTP/2 connections are not limited.
+	WriteRate int64
+
 	// TLSNextProto optionally specifies a function to take over
 	// ownership of the provided TLS connection when an ALPN
 	// protocol upgrade has occurred. The map key is the protocol
@@ -2591,11 +2722,36 @@ type Server struct {
 	// automatically.
 	TLSNextProto map[string]func(*Server, *tls.Conn, Handler)
 
+	// UnencryptedHTTP2 enables HTTP/2 over cleartext TCP, known as
+	// h2c, on connections that do not use TLS. Clients may speak
+	// HTTP/2 with prior knowledge, by starting the connection with
+	// the HTTP/2 connection preface, or upgrade an HTTP/1.1 request
+	// that has no body with an "Upgrade: h2c" header, as described
+	// in RFC 7540, section 3. Other connections are served with
+	// HTTP/1.x.
+	//
+	// UnencryptedHTTP2 has no effect if HTTP/2 support is not
+	// enabled automatically; see TLSNextProto.
+	UnencryptedHTTP2 bool
+
 	// ConnState specifies an optional callback function that is
 	// called when a client connection changes state. See the
 	// ConnState type and associated constants for details.
 	ConnState func(net.Conn, ConnState)
 
+	// RequestDone optionally specifies a function that is called
+	// when the Server has finished serving a request, such as to
+	// write an access log or record metrics. It receives the route
+	// pattern, status, size and timing of the request without the
+	// handler having to wrap the ResponseWriter.
+	//
+	// RequestDone is called for HTTP/1 and HTTP/2 requests that
+	// are passed to the Handler, after the response was finished
+	// or the connection hijacked. It is not called if the Handler
+	// panics. RequestDone may be called concurrently, and blocks
+	// the connection of the request while it runs.
+	RequestDone func(RequestInfo)
+
 	// ErrorLog specifies an optional logger for errors accepting
 	// connections, unexpected behavior from handlers, and
 	// underlying FileSystem errors.
@@ -2616,17 +2772,47 @@ type Server struct {
 	// value.
 	ConnContext func(ctx context.Context, c net.Conn) context.Context
 
+	// HTTP3 optionally specifies an HTTP/3 server to run alongside
+	// the HTTP/1.x and HTTP/2 server. The net/http package does not
+	// include a QUIC implementation; HTTP3 is provided by a separate
+	// package.
+	//
+	// If non-nil, ServeTLS and ListenAndServeTLS open a UDP socket on
+	// the address of the TCP listener, pass it to HTTP3.ServeHTTP3,
+	// and advertise the HTTP/3 endpoint with an Alt-Svc header on
+	// responses sent over TLS, unless the handler sets Alt-Svc itself.
+	// Shutdown and Close close the UDP socket; HTTP/3 servers wanting
+	// to drain their connections should use RegisterOnShutdown.
+	HTTP3 HTTP3Server
+
 	inShutdown atomicBool // true when server is in shutdown
 
-	disableKeepAlives int32     // accessed atomically.
-	nextProtoOnce     sync.Once // guards setupHTTP2_* init
-	nextProtoErr      error     // result of http2.ConfigureServer if used
+	disableKeepAlives int32        // accessed atomically.
+	nextProtoOnce     sync.Once    // guards setupHTTP2_* init
+	nextProtoErr      error        // result of http2.ConfigureServer if used
+	h2                *http2Server // the automatically configured HTTP/2 server, if any
 
 	mu         sync.Mutex
 	listeners  map[*net.Listener]struct{}
 	activeConn map[*conn]struct{}
 	doneChan   chan struct{}
 	onShutdown []func()
+
+	onShutdownKeys map[interface{}]bool // keys of registerOnShutdownOnce
+
+	packetConns []net.PacketConn // UDP sockets given to HTTP3
+	altSvc      atomic.Value     // of string; Alt-Svc value advertising HTTP3
+}
+
+// HTTP3Server is implemented by HTTP/3 servers that a Server runs
+// alongside its HTTP/1.x and HTTP/2 listener. See Server.HTTP3.
+type HTTP3Server interface {
+	// ServeHTTP3 serves HTTP/3 on pc until pc is closed, handling
+	// requests with h. The pc is a *net.UDPConn, so implementations
+	// can use its batch and segmentation offload methods. The config
+	// is a clone of the Server's TLS configuration, with "h3" as its
+	// only NextProtos entry.
+	ServeHTTP3(pc net.PacketConn, config *tls.Config, h Handler) error
 }
 
 func (s *Server) getDoneChan() <-chan struct{} {
@@ -2705,7 +2891,22 @@ const shutdownPollIntervalMax = 500 * time.Millisecond
 //
 // Once Shutdown has been called on a server, it may not be reused;
 // future calls to methods such as Serve will return ErrServerClosed.
+//
+// To hand off the server's listeners to another process, or to
+// monitor the connections left, use Drain.
 func (srv *Server) Shutdown(ctx context.Context) error {
+	return srv.shutdown(ctx, nil)
+}
+
+// shutdown implements Shutdown and Drain. The opts are nil for
+// Shutdown.
+func (srv *Server) shutdown(ctx context.Context, opts *DrainOptions) error {
+	if opts != nil && opts.Handoff != nil {
+		if err := srv.handoffListeners(opts.Handoff); err != nil {
+			return err
+		}
+	}
+
 	srv.inShutdown.setTrue()
 
 	srv.mu.Lock()
@@ -2730,15 +2931,30 @@ func (srv *Server) Shutdown(ctx context.Context) error {
 
 	timer := time.NewTimer(nextPollInterval())
 	defer timer.Stop()
+	var progress <-chan time.Time // nil unless opts.Progress is set
+	if opts != nil && opts.Progress != nil {
+		ticker := time.NewTicker(opts.progressInterval())
+		defer ticker.Stop()
+		progress = ticker.C
+	}
+	reported := false
 	for {
 		if srv.closeIdleConns() && srv.numListeners() == 0 {
 			return lnerr
 		}
+		if progress != nil && !reported {
+			// Report the connections left once the idle
+			// ones are closed, then periodically.
+			reported = true
+			opts.Progress(srv.drainStatus())
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-timer.C:
 			timer.Reset(nextPollInterval())
+		case <-progress:
+			opts.Progress(srv.drainStatus())
 		}
 	}
 }
@@ -2754,6 +2970,27 @@ func (srv *Server) RegisterOnShutdown(f func()) {
 	srv.mu.Unlock()
 }
 
+func init() {
+	internal.RegisterOnShutdownOnce = func(srv interface{}, key interface{}, f func()) {
+		srv.(*Server).registerOnShutdownOnce(key, f)
+	}
+}
+
+// registerOnShutdownOnce is like RegisterOnShutdown, but does nothing if
+// a function was already registered for key.
+func (srv *Server) registerOnShutdownOnce(key interface{}, f func()) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.onShutdownKeys[key] {
+		return
+	}
+	if srv.onShutdownKeys == nil {
+		srv.onShutdownKeys = make(map[interface{}]bool)
+	}
+	srv.onShutdownKeys[key] = true
+	srv.onShutdown = append(srv.onShutdown, f)
+}
+
 func (s *Server) numListeners() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -2793,6 +3030,12 @@ func (s *Server) closeListenersLocked() error {
 			err = cerr
 		}
 	}
+	for _, pc := range s.packetConns {
+		if cerr := pc.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	s.packetConns = nil
 	return err
 }
 
@@ -2859,9 +3102,23 @@ func (sh serverHandler) ServeHTTP(rw ResponseWriter, req *Request) {
 	if handler == nil {
 		handler = DefaultServeMux
 	}
+	if req.TLS != nil {
+		if v, _ := sh.srv.altSvc.Load().(string); v != "" {
+			rw.Header().Set("Alt-Svc", v)
+		}
+	}
 	if req.RequestURI == "*" && req.Method == "OPTIONS" {
 		handler = globalOptionsHandler{}
 	}
+	var sw *statsResponseWriter
+	if _, h1 := rw.(*response); !h1 && sh.srv.RequestDone != nil {
+		// An HTTP/2 request, which conn.serve does not report.
+		sw, req = newStatsResponseWriter(rw, req)
+		rw = sw
+	}
+	if n := sh.srv.MaxRequestBodyBytes; n > 0 && req.Body != nil && req.Body != NoBody {
+		req.Body = &maxBytesReader{w: rw, r: req.Body, n: n, limit: n, serverDefault: true}
+	}
 
 	if req.URL != nil && strings.Contains(req.URL.RawQuery, ";") {
 		var allowQuerySemicolonsInUse int32
@@ -2876,6 +3133,9 @@ func (sh serverHandler) ServeHTTP(rw ResponseWriter, req *Request) {
 	}
 
 	handler.ServeHTTP(rw, req)
+	if sw != nil {
+		sw.report(sh.srv, req)
+	}
 }
 
 var silenceSemWarnContextKey = &contextKey{"silence-semicolons"}
@@ -3069,8 +3329,46 @@ func (srv *Server) ServeTLS(l net.Listener, certFile, keyFile string) error {
 		}
 	}
 
+	if srv.HTTP3 != nil {
+		if err := srv.serveHTTP3(l, config); err != nil {
+			return err
+		}
+	}
+
 	tlsListener := tls.NewListener(l, config)
-	return srv.Serve(tlsListener)
+	return srv.Serve(handoffTLSListener{tlsListener, l})
+}
+
+// serveHTTP3 starts srv.HTTP3 on a UDP socket bound to the address
+// of the TCP listener l.
+func (srv *Server) serveHTTP3(l net.Listener, config *tls.Config) error {
+	addr, ok := l.Addr().(*net.TCPAddr)
+	if !ok {
+		return errors.New("http: HTTP/3 requires a TCP listener")
+	}
+	pc, err := net.ListenUDP("udp", &net.UDPAddr{IP: addr.IP, Port: addr.Port, Zone: addr.Zone})
+	if err != nil {
+		return err
+	}
+	srv.mu.Lock()
+	if srv.shuttingDown() {
+		srv.mu.Unlock()
+		pc.Close()
+		return ErrServerClosed
+	}
+	srv.packetConns = append(srv.packetConns, pc)
+	srv.mu.Unlock()
+
+	h3config := config.Clone()
+	h3config.NextProtos = []string{"h3"}
+	srv.altSvc.Store(fmt.Sprintf(`h3=":%d"; ma=86400`, addr.Port))
+	go func() {
+		err := srv.HTTP3.ServeHTTP3(pc, h3config, serverHandler{srv})
+		if err != nil && !srv.shuttingDown() {
+			srv.logf("http: HTTP/3 server error: %v", err)
+		}
+	}()
+	return nil
 }
 
 // trackListener adds or removes a net.Listener to the set of tracked
@@ -3250,7 +3548,7 @@ func (srv *Server) setupHTTP2_Serve() error {
 }
 
 func (srv *Server) onceSetNextProtoDefaults_Serve() {
-	if srv.shouldConfigureHTTP2ForServe() {
+	if srv.shouldConfigureHTTP2ForServe() || srv.UnencryptedHTTP2 {
 		srv.onceSetNextProtoDefaults()
 	}
 }
@@ -3269,6 +3567,9 @@ func (srv *Server) onceSetNextProtoDefaults() {
 			NewWriteScheduler: func() http2WriteScheduler { return http2NewPriorityWriteScheduler(nil) },
 		}
 		srv.nextProtoErr = http2ConfigureServer(srv, conf)
+		if srv.nextProtoErr == nil {
+			srv.h2 = conf
+		}
 	}
 }
 
@@ -3529,7 +3830,11 @@ type checkConnErrorWriter struct {
 }
 
 func (w checkConnErrorWriter) Write(p []byte) (n int, err error) {
-	n, err = w.c.rwc.Write(p)
+	if w.c.writeRate.limited() {
+		n, err = w.c.writeRate.write(w.c.rwc, p)
+	} else {
+		n, err = w.c.rwc.Write(p)
+	}
 	if err != nil && w.c.werr == nil {
 		w.c.werr = err
 		w.c.cancelCtx()
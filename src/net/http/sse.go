@@ -0,0 +1,301 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ServerSentEvent is an event in a stream of server-sent events,
+// as defined by the HTML Living Standard, section 9.2.
+type ServerSentEvent struct {
+	// ID is the event ID. When sending an event, an empty ID is
+	// not sent. When reading an event, ID is the last event ID
+	// received on the stream, which may have been sent with an
+	// earlier event.
+	ID string
+
+	// Event is the event type, or "" for the default type,
+	// "message".
+	Event string
+
+	// Data is the data of the event. It may contain newlines.
+	Data string
+}
+
+var (
+	errServerSentEventsClosed = errors.New("http: ServerSentEvents used after Close")
+	errBadServerSentEvent     = errors.New("http: server-sent event ID or type contains a newline or NUL")
+)
+
+// ServerSentEvents writes a stream of server-sent events to the
+// response of a handler. Each event is flushed to the client as soon
+// as it is written.
+//
+// The methods of a ServerSentEvents may be called concurrently.
+// Once it is created, the handler must not write to the response
+// directly, and must call Close before returning.
+type ServerSentEvents struct {
+	w  ResponseWriter
+	rc *ResponseController
+
+	mu        sync.Mutex
+	err       error // sticky
+	closed    bool
+	heartbeat time.Duration
+	timer     *time.Timer
+	buf       strings.Builder
+}
+
+// NewServerSentEvents starts a stream of server-sent events in the
+// response w, writing and flushing its header. It sets the
+// Content-Type header to "text/event-stream", and, unless they are
+// already set, headers that ask caches and proxies not to store or
+// buffer the stream.
+//
+// The ResponseWriter should be the original value passed to the
+// Handler.ServeHTTP method, or have an Unwrap method returning it;
+// see NewResponseController.
+func NewServerSentEvents(w ResponseWriter) *ServerSentEvents {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Del("Content-Length")
+	if _, ok := h["Cache-Control"]; !ok {
+		h.Set("Cache-Control", "no-cache")
+	}
+	if _, ok := h["X-Accel-Buffering"]; !ok {
+		// Disable response buffering in nginx and proxies that
+		// follow its lead.
+		h.Set("X-Accel-Buffering", "no")
+	}
+	w.WriteHeader(StatusOK)
+	s := &ServerSentEvents{w: w, rc: NewResponseController(w)}
+	s.err = s.rc.Flush()
+	return s
+}
+
+// Send sends an event of type event with the data. An empty event
+// sends an event of the default type, "message".
+func (s *ServerSentEvents) Send(event, data string) error {
+	return s.SendEvent(ServerSentEvent{Event: event, Data: data})
+}
+
+// SendEvent sends the event ev. It returns an error if ev.ID or
+// ev.Event contains a newline or NUL.
+func (s *ServerSentEvents) SendEvent(ev ServerSentEvent) error {
+	if strings.ContainsAny(ev.ID, "\r\n\x00") || strings.ContainsAny(ev.Event, "\r\n\x00") {
+		return errBadServerSentEvent
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := &s.buf
+	b.Reset()
+	if ev.ID != "" {
+		b.WriteString("id: ")
+		b.WriteString(ev.ID)
+		b.WriteByte('\n')
+	}
+	if ev.Event != "" {
+		b.WriteString("event: ")
+		b.WriteString(ev.Event)
+		b.WriteByte('\n')
+	}
+	data := strings.ReplaceAll(ev.Data, "\r\n", "\n")
+	for {
+		line := data
+		i := strings.IndexAny(data, "\r\n")
+		if i >= 0 {
+			line = data[:i]
+		}
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+		if i < 0 {
+			break
+		}
+		data = data[i+1:]
+	}
+	b.WriteByte('\n')
+	return s.writeLocked(b.String())
+}
+
+// SetRetry tells the client to wait d before reconnecting if the
+// connection is lost.
+func (s *ServerSentEvents) SetRetry(d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// SetHeartbeat makes the stream send a comment, which clients ignore,
+// whenever no event has been sent for the duration d, so that idle
+// connections are not closed by proxies. A duration of zero or less
+// stops the heartbeats.
+func (s *ServerSentEvents) SetHeartbeat(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeat = d
+	s.resetHeartbeatLocked()
+}
+
+func (s *ServerSentEvents) resetHeartbeatLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.heartbeat > 0 && !s.closed && s.err == nil {
+		s.timer = time.AfterFunc(s.heartbeat, s.sendHeartbeat)
+	}
+}
+
+func (s *ServerSentEvents) sendHeartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.writeLocked(":\n")
+	}
+}
+
+// writeLocked writes and flushes p. s.mu must be held.
+func (s *ServerSentEvents) writeLocked(p string) error {
+	if s.closed {
+		return errServerSentEventsClosed
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if _, err := io.WriteString(s.w, p); err != nil {
+		s.err = err
+	} else {
+		s.err = s.rc.Flush()
+	}
+	s.resetHeartbeatLocked()
+	return s.err
+}
+
+// Close stops the heartbeats of the stream. Once Close returns, s
+// no longer writes to the response, which ends when the handler
+// returns.
+func (s *ServerSentEvents) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.resetHeartbeatLocked()
+	return nil
+}
+
+// maxServerSentEventLine is the maximum length of a line of a stream
+// of server-sent events read by a ServerSentEventReader.
+const maxServerSentEventLine = 16 << 20
+
+var errServerSentEventLineTooLong = errors.New("http: server-sent event line too long")
+
+// A ServerSentEventReader reads server-sent events from a stream,
+// such as the body of a response with the Content-Type
+// "text/event-stream".
+type ServerSentEventReader struct {
+	br     *bufio.Reader
+	lines  []string // split from the last line read
+	lastID string
+	retry  time.Duration
+	bom    bool // checked for a byte order mark
+}
+
+// NewServerSentEventReader returns a reader of the server-sent
+// events in r.
+func NewServerSentEventReader(r io.Reader) *ServerSentEventReader {
+	return &ServerSentEventReader{br: bufio.NewReader(r)}
+}
+
+// Next returns the next event of the stream. At the end of the stream,
+// it returns io.EOF; an event that is not complete at the end of the
+// stream is discarded.
+func (r *ServerSentEventReader) Next() (ServerSentEvent, error) {
+	var (
+		event   string
+		data    strings.Builder
+		hasData bool
+	)
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return ServerSentEvent{}, err
+		}
+		if line == "" {
+			if !hasData {
+				event = ""
+				continue
+			}
+			return ServerSentEvent{ID: r.lastID, Event: event, Data: data.String()}, nil
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "":
+			// A comment.
+		case "event":
+			event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				r.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// Retry returns the reconnection time last set by the server with
+// a retry field, or zero if it has not set one.
+func (r *ServerSentEventReader) Retry() time.Duration {
+	return r.retry
+}
+
+// readLine returns the next line of the stream. Lines end with CRLF,
+// LF or CR, but are only read once a LF follows.
+func (r *ServerSentEventReader) readLine() (string, error) {
+	for len(r.lines) == 0 {
+		var line []byte
+		for {
+			frag, err := r.br.ReadSlice('\n')
+			if len(line)+len(frag) > maxServerSentEventLine {
+				return "", errServerSentEventLineTooLong
+			}
+			line = append(line, frag...)
+			if err == nil {
+				break
+			}
+			if err != bufio.ErrBufferFull {
+				return "", err
+			}
+		}
+		s := string(line[:len(line)-1])
+		if !r.bom {
+			r.bom = true
+			s = strings.TrimPrefix(s, "\uFEFF")
+		}
+		s = strings.TrimSuffix(s, "\r")
+		r.lines = strings.Split(s, "\r")
+	}
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	return line, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"io"
+	. "net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerSentEvents_h1(t *testing.T) { testServerSentEvents(t, h1Mode) }
+func TestServerSentEvents_h2(t *testing.T) { testServerSentEvents(t, h2Mode) }
+func testServerSentEvents(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		s := NewServerSentEvents(w)
+		defer s.Close()
+		if err := s.SetRetry(1500 * time.Millisecond); err != nil {
+			t.Error(err)
+		}
+		s.SendEvent(ServerSentEvent{ID: "1", Event: "greeting", Data: "hello\nworld"})
+		s.Send("", "second")
+		if err := s.Send("bad\nevent", "x"); err == nil {
+			t.Error("Send with newline in event type succeeded")
+		}
+	}))
+	defer cst.close()
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if got, want := res.Header.Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("Content-Type = %q; want %q", got, want)
+	}
+	if got, want := res.Header.Get("Cache-Control"), "no-cache"; got != want {
+		t.Errorf("Cache-Control = %q; want %q", got, want)
+	}
+
+	r := NewServerSentEventReader(res.Body)
+	var got []ServerSentEvent
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev)
+	}
+	want := []ServerSentEvent{
+		{ID: "1", Event: "greeting", Data: "hello\nworld"},
+		{ID: "1", Data: "second"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v; want %+v", got, want)
+	}
+	if got, want := r.Retry(), 1500*time.Millisecond; got != want {
+		t.Errorf("Retry = %v; want %v", got, want)
+	}
+}
+
+func TestServerSentEventsHeartbeat(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	release := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		s := NewServerSentEvents(w)
+		defer s.Close()
+		s.SetHeartbeat(time.Millisecond)
+		<-release
+	}))
+	defer cst.close()
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(res.Body, buf)
+	close(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != ":\n" {
+		t.Errorf("read %q; want heartbeat comment", buf)
+	}
+}
+
+func TestServerSentEventReader(t *testing.T) {
+	const stream = "\uFEFFdata: first\r\n\r\n" +
+		": a comment\n" +
+		"event: update\rdata:no space\rdata\r\r" +
+		"id: 7\nretry: soon\n\n" + // no data: not dispatched
+		"data:  two spaces\nid\n\n" +
+		"data: incomplete\n"
+	r := NewServerSentEventReader(strings.NewReader(stream))
+	want := []ServerSentEvent{
+		{Data: "first"},
+		{Event: "update", Data: "no space\n"},
+		{ID: "", Data: " two spaces"},
+	}
+	for i, w := range want {
+		ev, err := r.Next()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		if ev != w {
+			t.Errorf("event %d = %+v; want %+v", i, ev, w)
+		}
+	}
+	if ev, err := r.Next(); err != io.EOF {
+		t.Errorf("Next at end = %+v, %v; want io.EOF", ev, err)
+	}
+	if r.Retry() != 0 {
+		t.Errorf("Retry = %v; want 0 after invalid retry field", r.Retry())
+	}
+}
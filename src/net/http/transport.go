@@ -105,21 +105,45 @@ type Transport struct {
 	altMu    sync.Mutex   // guards changing altProto only
 	altProto atomic.Value // of nil or map[string]RoundTripper, key is URI scheme
 
+	altSvc altSvcCache // HTTP/3 endpoints advertised with Alt-Svc
+
+	decMu    sync.Mutex   // guards changing decoders only
+	decoders atomic.Value // of nil or []contentDecoder, in order of preference
+
 	connsPerHostMu   sync.Mutex
 	connsPerHost     map[connectMethodKey]int
 	connsPerHostWait map[connectMethodKey]wantConnQueue // waiting getConns
 
+	poolStatsMu sync.Mutex
+	poolStats   map[connectMethodKey]*poolCounters
+
 	// Proxy specifies a function to return a proxy for a given
 	// Request. If the function returns a non-nil error, the
 	// request is aborted with the provided error.
 	//
 	// The proxy type is determined by the URL scheme. "http",
-	// "https", and "socks5" are supported. If the scheme is empty,
-	// "http" is assumed.
+	// "https", "socks5" and "unix" are supported. If the scheme is
+	// empty, "http" is assumed. A "unix" proxy is an HTTP proxy
+	// listening on the Unix domain socket at the URL's path, as in
+	// "unix:///run/proxy.sock". For all schemes, the URL's user
+	// information, if any, is used to authenticate to the proxy.
 	//
 	// If Proxy is nil or returns a nil *URL, no proxy is used.
 	Proxy func(*Request) (*url.URL, error)
 
+	// Upstreams optionally maps the "host:port" address of request
+	// URLs, with the port always present, to the address to connect
+	// to in its place, written as a URL. The scheme "unix" connects to
+	// the Unix domain socket at the URL's path, as in
+	// "unix:///run/app.sock", and the scheme "tcp" connects to the
+	// URL's host and port, as in "tcp://10.0.0.1:8080". Requests are
+	// otherwise sent unchanged: HTTPS requests still verify the
+	// certificate of the host in the request URL.
+	//
+	// Upstreams is not used for requests that are sent via a proxy.
+	// It must not be modified after the Transport is first used.
+	Upstreams map[string]*url.URL
+
 	// DialContext specifies the dial function for creating unencrypted TCP connections.
 	// If DialContext is nil (and the deprecated Dial below is also nil),
 	// then the transport dials using package net.
@@ -186,7 +210,8 @@ type Transport struct {
 	// its own and gets a gzipped response, it's transparently
 	// decoded in the Response.Body. However, if the user
 	// explicitly requested gzip it is not automatically
-	// uncompressed.
+	// uncompressed. Content codings with a decoder registered
+	// by RegisterDecoder are requested and decoded the same way.
 	DisableCompression bool
 
 	// MaxIdleConns controls the maximum number of idle (keep-alive)
@@ -269,6 +294,24 @@ type Transport struct {
 	// If zero, a default (currently 4KB) is used.
 	ReadBufferSize int
 
+	// ReadRate, if positive, limits the rate at which each HTTP/1
+	// connection reads responses, in bytes per second. HTTP/2
+	// connections are not limited.
+	ReadRate int64
+
+	// RetryPolicy optionally specifies a policy for retrying
+	// requests that fail transiently: requests whose attempt fails
+	// with a network error, such as a connection reset or a
+	// timeout, or gets a 429 Too Many Requests or 503 Service
+	// Unavailable response, honoring its Retry-After header.
+	// Only requests that can be sent again safely are retried:
+	// requests with a body must have GetBody set, and the method
+	// must be GET, HEAD, OPTIONS or TRACE, or the request must have
+	// an Idempotency-Key or X-Idempotency-Key header.
+	// If nil, requests are only retried in the cases where the
+	// Transport knows that the server has not processed them.
+	RetryPolicy *RetryPolicy
+
 	// nextProtoOnce guards initialization of TLSNextProto and
 	// h2transport (via onceSetNextProtoDefaults)
 	nextProtoOnce      sync.Once
@@ -281,6 +324,26 @@ type Transport struct {
 	// To use a custom dialer or TLS config and still attempt HTTP/2
 	// upgrades, set this to true.
 	ForceAttemptHTTP2 bool
+
+	// HTTP3 optionally specifies a RoundTripper that speaks HTTP/3.
+	// The net/http package does not include a QUIC implementation;
+	// HTTP3 is provided by a separate package.
+	//
+	// If non-nil, the Transport records the HTTP/3 endpoints that
+	// servers advertise in the Alt-Svc header (RFC 7838) of https
+	// responses, and sends later requests to the same origin to HTTP3,
+	// with the URL's Host set to the advertised endpoint and
+	// Request.Host set to the origin. Only endpoints on the origin's
+	// host are used.
+	//
+	// If HTTP3 fails, the endpoint is avoided for a while. The request
+	// is then sent over TCP instead only if it could be retried on a
+	// new connection: its method must be idempotent, or it must have
+	// an Idempotency-Key header, and its body must be rewindable with
+	// GetBody. Errors caused by the request's context are returned
+	// as they are. If HTTP3 returns ErrSkipAltProtocol, the request
+	// is sent over TCP and the endpoint is not avoided.
+	HTTP3 RoundTripper
 }
 
 // A cancelKey is the key of the reqCanceler map.
@@ -309,6 +372,7 @@ func (t *Transport) Clone() *Transport {
 	t.nextProtoOnce.Do(t.onceSetNextProtoDefaults)
 	t2 := &Transport{
 		Proxy:                  t.Proxy,
+		Upstreams:              t.Upstreams,
 		DialContext:            t.DialContext,
 		Dial:                   t.Dial,
 		DialTLS:                t.DialTLS,
@@ -328,10 +392,16 @@ func (t *Transport) Clone() *Transport {
 		ForceAttemptHTTP2:      t.ForceAttemptHTTP2,
 		WriteBufferSize:        t.WriteBufferSize,
 		ReadBufferSize:         t.ReadBufferSize,
+		ReadRate:               t.ReadRate,
+		RetryPolicy:            t.RetryPolicy,
+		HTTP3:                  t.HTTP3,
 	}
 	if t.TLSClientConfig != nil {
 		t2.TLSClientConfig = t.TLSClientConfig.Clone()
 	}
+	if decs, ok := t.decoders.Load().([]contentDecoder); ok {
+		t2.decoders.Store(decs)
+	}
 	if !t.tlsNextProtoWasNil {
 		npm := map[string]func(authority string, c *tls.Conn) RoundTripper{}
 		for k, v := range t.TLSNextProto {
@@ -429,6 +499,8 @@ func (t *Transport) onceSetNextProtoDefaults() {
 // "host[:port]", in which case the "http" scheme is assumed.
 // The schemes "http", "https", and "socks5" are supported.
 // An error is returned if the value is a different form.
+// Unix domain socket proxies can be set with ProxyURL or
+// ProxyByScheme instead.
 //
 // A nil URL and nil error are returned if no proxy is defined in the
 // environment, or a proxy should not be used for the given request,
@@ -448,6 +520,16 @@ func ProxyURL(fixedURL *url.URL) func(*Request) (*url.URL, error) {
 	}
 }
 
+// ProxyByScheme returns a proxy function (for use in a Transport)
+// that selects the proxy of each request by the scheme of its URL,
+// such as "http" or "https". Requests whose scheme has no entry in
+// proxies are not sent via a proxy.
+func ProxyByScheme(proxies map[string]*url.URL) func(*Request) (*url.URL, error) {
+	return func(req *Request) (*url.URL, error) {
+		return proxies[req.URL.Scheme], nil
+	}
+}
+
 // transportRequest is a wrapper around a *Request that adds
 // optional extra headers to write and stores any error to return
 // from roundTrip.
@@ -545,6 +627,25 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 			return nil, err
 		}
 	}
+	if scheme == "https" && t.HTTP3 != nil {
+		if addr, ok := t.altSvc.lookup(req.URL); ok {
+			resp, err := t.HTTP3.RoundTrip(http3Request(req, addr))
+			if err == nil {
+				resp.Request = origReq
+				return resp, nil
+			}
+			if !http3ShouldFallBack(req, err) {
+				return nil, err
+			}
+			if err != ErrSkipAltProtocol {
+				t.altSvc.markBroken(req.URL)
+			}
+			req, err = rewindBody(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	if !isHTTP {
 		req.closeBody()
 		return nil, badStringError("unsupported protocol scheme", scheme)
@@ -595,6 +696,9 @@ func (t *Transport) roundTrip(req *Request) (*Response, error) {
 		}
 		if err == nil {
 			resp.Request = origReq
+			if scheme == "https" && t.HTTP3 != nil {
+				t.altSvc.update(req.URL, resp.Header["Alt-Svc"])
+			}
 			return resp, nil
 		}
 
@@ -675,6 +779,20 @@ func rewindBody(req *Request) (rewound *Request, err error) {
 	return &newReq, nil
 }
 
+// http3ShouldFallBack reports whether req, which Transport.HTTP3 failed
+// to send with err, may be sent again over TCP. The HTTP/3 round trip
+// may have reached the server, so only requests that can be replayed
+// are sent again, as by shouldRetryRequest for a reused connection.
+func http3ShouldFallBack(req *Request, err error) bool {
+	if err == ErrSkipAltProtocol {
+		return true
+	}
+	if req.Context().Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return req.isReplayable()
+}
+
 // shouldRetryRequest reports whether we should retry sending a failed
 // HTTP request on a new connection. The non-nil input error is the
 // error from roundTrip.
@@ -753,6 +871,70 @@ func (t *Transport) RegisterProtocol(scheme string, rt RoundTripper) {
 	t.altProto.Store(newMap)
 }
 
+// A contentDecoder is a decoder registered with RegisterDecoder.
+type contentDecoder struct {
+	encoding  string
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+// RegisterDecoder registers a decoder for response bodies with the
+// content coding encoding, such as "zstd" or "br". When the Transport
+// requests compression on its own (see DisableCompression), it lists
+// the registered codings in the Accept-Encoding request header, in
+// the order they were registered and ahead of gzip, and transparently
+// decodes responses that use one of them.
+//
+// The newReader function is called on the first read of the
+// response body. Closing the response body closes the reader it
+// returns.
+//
+// Registered decoders apply to HTTP/1 responses only. HTTP/2
+// requests still ask for and decode gzip alone, until the HTTP/2
+// implementation in golang.org/x/net/http2 supports them.
+//
+// RegisterDecoder panics if a decoder for encoding is already
+// registered, or if encoding is "gzip", which the Transport always
+// decodes itself.
+func (t *Transport) RegisterDecoder(encoding string, newReader func(io.Reader) (io.ReadCloser, error)) {
+	encoding, _ = ascii.ToLower(encoding)
+	t.decMu.Lock()
+	defer t.decMu.Unlock()
+	old, _ := t.decoders.Load().([]contentDecoder)
+	if encoding == "gzip" || t.decoder(encoding) != nil {
+		panic("content coding " + encoding + " already registered")
+	}
+	decs := make([]contentDecoder, len(old), len(old)+1)
+	copy(decs, old)
+	t.decoders.Store(append(decs, contentDecoder{encoding, newReader}))
+}
+
+// decoder returns the registered decoder for encoding, or nil.
+func (t *Transport) decoder(encoding string) func(io.Reader) (io.ReadCloser, error) {
+	decs, _ := t.decoders.Load().([]contentDecoder)
+	for _, d := range decs {
+		if ascii.EqualFold(d.encoding, encoding) {
+			return d.newReader
+		}
+	}
+	return nil
+}
+
+// acceptEncoding returns the Accept-Encoding header value that the
+// Transport sends when it requests compression on its own.
+func (t *Transport) acceptEncoding() string {
+	decs, _ := t.decoders.Load().([]contentDecoder)
+	if len(decs) == 0 {
+		return "gzip"
+	}
+	var b strings.Builder
+	for _, d := range decs {
+		b.WriteString(d.encoding)
+		b.WriteString(", ")
+	}
+	b.WriteString("gzip")
+	return b.String()
+}
+
 // CloseIdleConnections closes any connections which were previously
 // connected from previous requests but are now sitting idle in
 // a "keep-alive" state. It does not interrupt any connections currently
@@ -833,6 +1015,14 @@ func (t *Transport) connectMethodForRequest(treq *transportRequest) (cm connectM
 	if t.Proxy != nil {
 		cm.proxyURL, err = t.Proxy(treq.Request)
 	}
+	if cm.proxyURL == nil && err == nil {
+		if u, ok := t.Upstreams[cm.targetAddr]; ok {
+			if u.Scheme != "unix" && u.Scheme != "tcp" {
+				return cm, fmt.Errorf("net/http: unsupported upstream scheme %q for %s", u.Scheme, cm.targetAddr)
+			}
+			cm.upstream = u
+		}
+	}
 	cm.onlyH1 = treq.requiresHTTP1()
 	return cm, err
 }
@@ -1350,6 +1540,10 @@ func (t *Transport) getConn(treq *transportRequest, cm connectMethod) (pc *persi
 	// Queue for idle connection.
 	if delivered := t.queueForIdleConn(w); delivered {
 		pc := w.pc
+		t.updatePoolStats(w.key, func(c *poolCounters) {
+			c.requests++
+			c.reused++
+		})
 		// Trace only for HTTP/1.
 		// HTTP/2 calls trace.GotConn itself.
 		if pc.alt == nil && trace != nil && trace.GotConn != nil {
@@ -1366,6 +1560,16 @@ func (t *Transport) getConn(treq *transportRequest, cm connectMethod) (pc *persi
 	t.setReqCanceler(treq.cancelKey, func(err error) { cancelc <- err })
 
 	// Queue for permission to dial.
+	t.updatePoolStats(w.key, func(c *poolCounters) { c.waiting++ })
+	defer t.updatePoolStats(w.key, func(c *poolCounters) {
+		c.waiting--
+		if err == nil && pc != nil {
+			c.requests++
+			if pc.isReused() {
+				c.reused++
+			}
+		}
+	})
 	t.queueForDial(w)
 
 	// Wait for completion or cancellation.
@@ -1443,7 +1647,21 @@ func (t *Transport) queueForDial(w *wantConn) {
 func (t *Transport) dialConnFor(w *wantConn) {
 	defer w.afterDial()
 
+	t.updatePoolStats(w.key, func(c *poolCounters) {
+		c.conns++
+		c.dialing++
+	})
+	start := time.Now()
 	pc, err := t.dialConn(w.ctx, w.cm)
+	t.updatePoolStats(w.key, func(c *poolCounters) {
+		c.dialing--
+		c.dials++
+		if err != nil {
+			c.dialErrors++
+		} else {
+			c.dialTime += time.Since(start)
+		}
+	})
 	delivered := w.tryDeliver(pc, err)
 	if err == nil && (!delivered || pc.alt != nil) {
 		// pconn was not passed to w,
@@ -1459,6 +1677,7 @@ func (t *Transport) dialConnFor(w *wantConn) {
 // decConnsPerHost decrements the per-host connection count for key,
 // which may in turn give a different waiting goroutine permission to dial.
 func (t *Transport) decConnsPerHost(key connectMethodKey) {
+	t.updatePoolStats(key, func(c *poolCounters) { c.conns-- })
 	if t.MaxConnsPerHost <= 0 {
 		return
 	}
@@ -1567,17 +1786,20 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 		writeErrCh:    make(chan error, 1),
 		writeLoopDone: make(chan struct{}),
 	}
+	pconn.readRate.setRate(t.ReadRate)
+	pconn.readRate.done = pconn.closech
 	trace := httptrace.ContextClientTrace(ctx)
+	network, addr := cm.dialAddr()
 	wrapErr := func(err error) error {
 		if cm.proxyURL != nil {
 			// Return a typed error, per Issue 16997
-			return &net.OpError{Op: "proxyconnect", Net: "tcp", Err: err}
+			return &net.OpError{Op: "proxyconnect", Net: network, Err: err}
 		}
 		return err
 	}
 	if cm.scheme() == "https" && t.hasCustomTLSDialer() {
 		var err error
-		pconn.conn, err = t.customDialTLS(ctx, "tcp", cm.addr())
+		pconn.conn, err = t.customDialTLS(ctx, network, addr)
 		if err != nil {
 			return nil, wrapErr(err)
 		}
@@ -1601,7 +1823,7 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (pconn *pers
 			pconn.tlsState = &cs
 		}
 	} else {
-		conn, err := t.dial(ctx, "tcp", cm.addr())
+		conn, err := t.dial(ctx, network, addr)
 		if err != nil {
 			return nil, wrapErr(err)
 		}
@@ -1792,16 +2014,19 @@ var _ io.ReaderFrom = (*persistConnWriter)(nil)
 //	socks5://proxy.com|https|foo.com  socks5 to proxy, then https to foo.com
 //	https://proxy.com|https|foo.com   https to proxy, then CONNECT to foo.com
 //	https://proxy.com|http            https to proxy, http to anywhere after that
+//	unix:///proxy.sock|https|foo.com  http to proxy socket, then CONNECT to foo.com
+//	unix:///proxy.sock|http           http to proxy socket, http to anywhere after that
 //
 type connectMethod struct {
 	_            incomparable
 	proxyURL     *url.URL // nil for no proxy, else full proxy URL
 	targetScheme string   // "http" or "https"
-	// If proxyURL specifies an http or https proxy, and targetScheme is http (not https),
+	// If proxyURL specifies an http, https or unix proxy, and targetScheme is http (not https),
 	// then targetAddr is not included in the connect method key, because the socket can
 	// be reused for different targetAddr values.
 	targetAddr string
-	onlyH1     bool // whether to disable HTTP/2 and force HTTP/1
+	onlyH1     bool     // whether to disable HTTP/2 and force HTTP/1
+	upstream   *url.URL // address dialed in place of targetAddr, from Transport.Upstreams
 }
 
 func (cm *connectMethod) key() connectMethodKey {
@@ -1809,8 +2034,11 @@ func (cm *connectMethod) key() connectMethodKey {
 	targetAddr := cm.targetAddr
 	if cm.proxyURL != nil {
 		proxyStr = cm.proxyURL.String()
-		if (cm.proxyURL.Scheme == "http" || cm.proxyURL.Scheme == "https") && cm.targetScheme == "http" {
-			targetAddr = ""
+		switch cm.proxyURL.Scheme {
+		case "http", "https", "unix":
+			if cm.targetScheme == "http" {
+				targetAddr = ""
+			}
 		}
 	}
 	return connectMethodKey{
@@ -1821,7 +2049,7 @@ func (cm *connectMethod) key() connectMethodKey {
 	}
 }
 
-// scheme returns the first hop scheme: http, https, or socks5
+// scheme returns the first hop scheme: http, https, socks5, or unix
 func (cm *connectMethod) scheme() string {
 	if cm.proxyURL != nil {
 		return cm.proxyURL.Scheme
@@ -1829,14 +2057,36 @@ func (cm *connectMethod) scheme() string {
 	return cm.targetScheme
 }
 
-// addr returns the first hop "host:port" to which we need to TCP connect.
+// addr returns the first hop "host:port" to which we need to TCP connect,
+// or the path of the socket of a unix proxy.
 func (cm *connectMethod) addr() string {
 	if cm.proxyURL != nil {
+		if cm.proxyURL.Scheme == "unix" {
+			return cm.proxyURL.Path
+		}
 		return canonicalAddr(cm.proxyURL)
 	}
 	return cm.targetAddr
 }
 
+// dialAddr returns the network and address to dial for the first hop.
+// It differs from addr for unix proxies and for Transport.Upstreams.
+func (cm *connectMethod) dialAddr() (network, addr string) {
+	u := cm.upstream
+	if cm.proxyURL != nil {
+		u = cm.proxyURL
+	}
+	switch {
+	case u == nil:
+		return "tcp", cm.targetAddr
+	case u.Scheme == "unix":
+		return "unix", u.Path
+	case u == cm.upstream:
+		return "tcp", u.Host
+	}
+	return "tcp", cm.addr()
+}
+
 // tlsHost returns the host name to match against the peer's
 // TLS certificate.
 func (cm *connectMethod) tlsHost() string {
@@ -1885,6 +2135,7 @@ type persistConn struct {
 	isProxy   bool
 	sawEOF    bool  // whether we've seen EOF from conn; owned by readLoop
 	readLimit int64 // bytes allowed to be read; owned by readLoop
+	readRate  pacer // paces reads for Transport.ReadRate; owned by readLoop
 	// writeErrCh passes the request write error (usually nil)
 	// from the writeLoop goroutine to the readLoop which passes
 	// it off to the res.Body reader, which then uses it to decide
@@ -1923,11 +2174,17 @@ func (pc *persistConn) Read(p []byte) (n int, err error) {
 	if int64(len(p)) > pc.readLimit {
 		p = p[:pc.readLimit]
 	}
+	if pc.readRate.limited() {
+		if c := pc.readRate.chunk(); int64(len(p)) > c {
+			p = p[:c]
+		}
+	}
 	n, err = pc.conn.Read(p)
 	if err == io.EOF {
 		pc.sawEOF = true
 	}
 	pc.readLimit -= int64(n)
+	pc.readRate.wait(int64(n))
 	return
 }
 
@@ -2039,7 +2296,7 @@ func (pc *persistConn) mapRoundTripError(req *transportRequest, startBytesWritte
 		if pc.nwrite == startBytesWritten {
 			return nothingWrittenError{err}
 		}
-		return fmt.Errorf("net/http: HTTP/1.x transport connection broken: %v", err)
+		return fmt.Errorf("net/http: HTTP/1.x transport connection broken: %w", err)
 	}
 	return err
 }
@@ -2165,7 +2422,9 @@ func (pc *persistConn) readLoop() {
 
 		waitForBodyRead := make(chan bool, 2)
 		body := &bodyEOFSignal{
-			body: resp.Body,
+			body:  resp.Body,
+			pc:    pc,
+			trace: trace,
 			earlyCloseFn: func() error {
 				waitForBodyRead <- false
 				<-eofc // will be closed by deferred call at the end of the function
@@ -2187,12 +2446,18 @@ func (pc *persistConn) readLoop() {
 		}
 
 		resp.Body = body
-		if rc.addedGzip && ascii.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
-			resp.Body = &gzipReader{body: body}
-			resp.Header.Del("Content-Encoding")
-			resp.Header.Del("Content-Length")
-			resp.ContentLength = -1
-			resp.Uncompressed = true
+		if rc.addedGzip {
+			if ce := resp.Header.Get("Content-Encoding"); ascii.EqualFold(ce, "gzip") {
+				resp.Body = &gzipReader{body: body}
+			} else if dec := pc.t.decoder(ce); dec != nil {
+				resp.Body = &decodingReader{body: body, newReader: dec}
+			}
+			if resp.Body != io.ReadCloser(body) {
+				resp.Header.Del("Content-Encoding")
+				resp.Header.Del("Content-Length")
+				resp.ContentLength = -1
+				resp.Uncompressed = true
+			}
 		}
 
 		select {
@@ -2274,6 +2539,7 @@ func (pc *persistConn) readResponse(rc requestAndChan, trace *httptrace.ClientTr
 	}
 	num1xx := 0               // number of informational 1xx headers received
 	const max1xxResponses = 5 // arbitrary bound on number of informational responses
+	var informational []InformationalResponse
 
 	continueCh := rc.continueCh
 	for {
@@ -2308,10 +2574,12 @@ func (pc *persistConn) readResponse(rc requestAndChan, trace *httptrace.ClientTr
 					return nil, err
 				}
 			}
+			informational = append(informational, InformationalResponse{resCode, resp.Header})
 			continue
 		}
 		break
 	}
+	resp.Informational = informational
 	if resp.isProtocolSwitch() {
 		resp.Body = newReadWriteCloserBody(pc.br, pc.conn)
 	}
@@ -2375,6 +2643,22 @@ func (b *readWriteCloserBody) Read(p []byte) (n int, err error) {
 	return b.ReadWriteCloser.Read(p)
 }
 
+// SetReadDeadline sets the read deadline of the underlying connection.
+func (b *readWriteCloserBody) SetReadDeadline(t time.Time) error {
+	if c, ok := b.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return c.SetReadDeadline(t)
+	}
+	return errNotSupported()
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection.
+func (b *readWriteCloserBody) SetWriteDeadline(t time.Time) error {
+	if c, ok := b.ReadWriteCloser.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		return c.SetWriteDeadline(t)
+	}
+	return errNotSupported()
+}
+
 // nothingWrittenError wraps a write errors which ended up writing zero bytes.
 type nothingWrittenError struct {
 	error
@@ -2468,8 +2752,9 @@ type requestAndChan struct {
 	ch        chan responseAndError // unbuffered; always send in select on callerGone
 
 	// whether the Transport (as opposed to the user client code)
-	// added the Accept-Encoding gzip header. If the Transport
-	// set it, only then do we transparently decode the gzip.
+	// added the Accept-Encoding header. If the Transport set it,
+	// only then do we transparently decode gzip and the content
+	// codings registered with RegisterDecoder.
 	addedGzip bool
 
 	// Optional blocking chan for Expect: 100-continue (for send).
@@ -2562,7 +2847,7 @@ func (pc *persistConn) roundTrip(req *transportRequest) (resp *Response, err err
 		// auto-decoding a portion of a gzipped document will just fail
 		// anyway. See https://golang.org/issue/8923
 		requestedGzip = true
-		req.extraHeaders().Set("Accept-Encoding", "gzip")
+		req.extraHeaders().Set("Accept-Encoding", pc.t.acceptEncoding())
 	}
 
 	var continueCh chan struct{}
@@ -2750,6 +3035,9 @@ type bodyEOFSignal struct {
 	rerr         error             // sticky Read error
 	fn           func(error) error // err will be nil on Read io.EOF
 	earlyCloseFn func() error      // optional alt Close func used if io.EOF not seen
+
+	pc    *persistConn // if non-nil, the connection body is read from
+	trace *httptrace.ClientTrace
 }
 
 var errReadOnClosedResBody = errors.New("http: read on closed response body")
@@ -2777,6 +3065,91 @@ func (es *bodyEOFSignal) Read(p []byte) (n int, err error) {
 	return
 }
 
+// WriteTo implements io.WriterTo. If w has a ReadFrom method and the
+// body has a known length and is read from a plain TCP connection, w
+// reads the body from the connection directly rather than through the
+// connection's buffer, so that the data may be moved between sockets
+// by the operating system.
+func (es *bodyEOFSignal) WriteTo(w io.Writer) (n int64, err error) {
+	es.mu.Lock()
+	closed, rerr := es.closed, es.rerr
+	es.mu.Unlock()
+	if closed {
+		return 0, errReadOnClosedResBody
+	}
+	if rerr != nil {
+		return 0, rerr
+	}
+	if rf, ok := w.(io.ReaderFrom); ok {
+		n, err = es.writeDirect(rf)
+		if err != nil {
+			return n, err
+		}
+	}
+	// Copy the rest of the body, if any, and read its EOF.
+	n0, err := io.Copy(w, readerOnly{es})
+	return n + n0, err
+}
+
+// writeDirect copies as much of the body as it can to rf by giving
+// it direct access to the connection. It copies nothing if the body is
+// not suitable.
+func (es *bodyEOFSignal) writeDirect(rf io.ReaderFrom) (n int64, err error) {
+	pc := es.pc
+	b, ok := es.body.(*body)
+	if pc == nil || !ok || pc.readRate.limited() {
+		return 0, nil
+	}
+	if _, ok := pc.conn.(*net.TCPConn); !ok {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lr, ok := b.src.(*io.LimitedReader)
+	if !ok || b.closed || b.sawEOF || lr.R != io.Reader(pc.br) || lr.N <= 0 {
+		return 0, nil
+	}
+
+	// First copy what the connection's buffer holds.
+	if buffered := int64(pc.br.Buffered()); buffered > 0 {
+		if buffered > lr.N {
+			buffered = lr.N
+		}
+		n, err = rf.ReadFrom(io.LimitReader(lr, buffered))
+		if err != nil || lr.N == 0 {
+			return n, err
+		}
+	}
+
+	direct := &io.LimitedReader{R: persistConnReader{pc}, N: lr.N}
+	n0, err := rf.ReadFrom(direct)
+	n += n0
+	lr.N = direct.N
+	if err == nil && direct.N > 0 {
+		// The connection reached EOF before the end of the body.
+		// Reading the rest through the buffer reports it.
+		pc.sawEOF = true
+	}
+	if es.trace != nil && es.trace.DirectResponseBody != nil {
+		es.trace.DirectResponseBody(n0)
+	}
+	return n, err
+}
+
+// persistConnReader reads from a persistConn, and unwraps to its
+// *net.TCPConn, so that it can be the source of splice(2).
+type persistConnReader struct {
+	pc *persistConn
+}
+
+func (r persistConnReader) Read(p []byte) (int, error) {
+	return r.pc.Read(p)
+}
+
+func (r persistConnReader) UnwrapReader() io.Reader {
+	return r.pc.conn
+}
+
 func (es *bodyEOFSignal) Close() error {
 	es.mu.Lock()
 	defer es.mu.Unlock()
@@ -2836,6 +3209,48 @@ func (gz *gzipReader) Close() error {
 	return gz.body.Close()
 }
 
+// decodingReader wraps a response body so it can lazily call
+// the newReader function of a decoder registered with
+// Transport.RegisterDecoder on the first call to Read.
+type decodingReader struct {
+	_         incomparable
+	body      io.ReadCloser // underlying response body
+	newReader func(io.Reader) (io.ReadCloser, error)
+
+	mu     sync.Mutex    // guards following
+	r      io.ReadCloser // lazily-initialized decoder
+	err    error         // any error from newReader; sticky
+	closed bool
+}
+
+func (d *decodingReader) Read(p []byte) (n int, err error) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return 0, errReadOnClosedResBody
+	}
+	if d.r == nil && d.err == nil {
+		d.r, d.err = d.newReader(d.body)
+	}
+	r, err := d.r, d.err
+	d.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return r.Read(p)
+}
+
+func (d *decodingReader) Close() error {
+	err := d.body.Close()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.r != nil && !d.closed {
+		d.r.Close()
+	}
+	d.closed = true
+	return err
+}
+
 type tlsHandshakeTimeoutError struct{}
 
 func (tlsHandshakeTimeoutError) Timeout() bool   { return true }
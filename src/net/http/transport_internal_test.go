@@ -15,6 +15,7 @@ import (
 	"net/http/internal/testcert"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Issue 15446: incorrect wrapping of errors when server closes an idle connection.
@@ -260,3 +261,27 @@ func TestTransportBodyAltRewind(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{" 120 ", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"1.5", 0, false},
+		{"Sun, 02 Jan 2022 03:05:05 GMT", time.Minute, true},
+		{"Sun, 02 Jan 2022 03:00:00 GMT", 0, true},
+		{"tomorrow", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
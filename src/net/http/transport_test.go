@@ -17,6 +17,7 @@ import (
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -1615,6 +1616,92 @@ func TestTransportProxyDialDoesNotMutateProxyConnectHeader(t *testing.T) {
 	}
 }
 
+// newUnixServer serves h on a Unix domain socket and returns its path.
+func newUnixServer(t *testing.T, h Handler) string {
+	switch runtime.GOOS {
+	case "js", "plan9", "windows":
+		t.Skipf("skipping on %s; no Unix domain sockets", runtime.GOOS)
+	}
+	path := t.TempDir() + "/s"
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{Handler: h}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return path
+}
+
+func TestTransportUnixProxy(t *testing.T) {
+	defer afterTest(t)
+	sock := newUnixServer(t, HandlerFunc(func(w ResponseWriter, r *Request) {
+		fmt.Fprintf(w, "%s %s", r.RequestURI, r.Header.Get("Proxy-Authorization"))
+	}))
+	tr := &Transport{
+		Proxy: ProxyByScheme(map[string]*url.URL{
+			"http": {Scheme: "unix", User: url.UserPassword("aladdin", "opensesame"), Path: sock},
+		}),
+	}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+	res, err := c.Get("http://example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "http://example.com/foo Basic " + base64.StdEncoding.EncodeToString([]byte("aladdin:opensesame"))
+	if string(body) != want {
+		t.Errorf("got %q; want %q", body, want)
+	}
+
+	req, _ := NewRequest("GET", "https://example.com/", nil)
+	if u, err := tr.Proxy(req); u != nil || err != nil {
+		t.Errorf("proxy for https = %v, %v; want none", u, err)
+	}
+}
+
+func TestTransportUpstreams(t *testing.T) {
+	defer afterTest(t)
+	handler := HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Host+r.URL.Path)
+	})
+	sock := newUnixServer(t, handler)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	tr := &Transport{
+		Upstreams: map[string]*url.URL{
+			"app.example:80": {Scheme: "unix", Path: sock},
+			"api.example:80": {Scheme: "tcp", Host: ts.Listener.Addr().String()},
+			"bad.example:80": {Scheme: "udp", Host: "127.0.0.1:1"},
+		},
+	}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+	for _, url := range []string{"http://app.example/a", "http://api.example/b"} {
+		res, err := c.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.TrimPrefix(url, "http://"); string(body) != want {
+			t.Errorf("Get(%q) = %q; want %q", url, body, want)
+		}
+	}
+	if _, err := c.Get("http://bad.example/"); err == nil || !strings.Contains(err.Error(), "unsupported upstream scheme") {
+		t.Errorf("Get with udp upstream: err = %v; want unsupported scheme", err)
+	}
+}
+
 // TestTransportGzipRecursive sends a gzip quine and checks that the
 // client gets the same value back. This is more cute than anything,
 // but checks that we don't recurse forever, and checks that
@@ -5879,6 +5966,7 @@ func TestTransportRequestWriteRoundTrip(t *testing.T) {
 func TestTransportClone(t *testing.T) {
 	tr := &Transport{
 		Proxy:                  func(*Request) (*url.URL, error) { panic("") },
+		Upstreams:              map[string]*url.URL{},
 		DialContext:            func(ctx context.Context, network, addr string) (net.Conn, error) { panic("") },
 		Dial:                   func(network, addr string) (net.Conn, error) { panic("") },
 		DialTLS:                func(network, addr string) (net.Conn, error) { panic("") },
@@ -5902,6 +5990,9 @@ func TestTransportClone(t *testing.T) {
 		},
 		ReadBufferSize:  1,
 		WriteBufferSize: 1,
+		ReadRate:        1,
+		RetryPolicy:     &RetryPolicy{},
+		HTTP3:           roundTripperFunc(func(*Request) (*Response, error) { panic("") }),
 	}
 	tr2 := tr.Clone()
 	rv := reflect.ValueOf(tr2).Elem()
@@ -6487,3 +6578,375 @@ func TestCancelRequestWhenSharingConnection(t *testing.T) {
 	cancel()
 	wg.Wait()
 }
+
+type roundTripperFunc func(*Request) (*Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *Request) (*Response, error) {
+	return f(req)
+}
+
+func TestTransportHTTP3AltSvc(t *testing.T) {
+	defer afterTest(t)
+	ts := httptest.NewTLSServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Alt-Svc", `h3=":4433"; ma=3600`)
+		io.WriteString(w, "tcp")
+	}))
+	defer ts.Close()
+
+	var h3Reqs []*Request
+	h3Fail := false
+	c := ts.Client()
+	tr := c.Transport.(*Transport)
+	tr.HTTP3 = roundTripperFunc(func(req *Request) (*Response, error) {
+		h3Reqs = append(h3Reqs, req)
+		if h3Fail {
+			return nil, errors.New("h3 failed")
+		}
+		return &Response{
+			StatusCode: 200,
+			Header:     Header{},
+			Body:       io.NopCloser(strings.NewReader("h3")),
+		}, nil
+	})
+
+	get := func() string {
+		t.Helper()
+		res, err := c.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := get(); got != "tcp" {
+		t.Fatalf("first request got %q; want it sent over TCP", got)
+	}
+	if got := get(); got != "h3" {
+		t.Fatalf("second request got %q; want it sent over HTTP/3", got)
+	}
+	u, _ := url.Parse(ts.URL)
+	if len(h3Reqs) != 1 || h3Reqs[0].URL.Host != net.JoinHostPort(u.Hostname(), "4433") || h3Reqs[0].Host != u.Host {
+		t.Fatalf("HTTP/3 request URL host, Host = %v; want %s:4433, %s", h3Reqs, u.Hostname(), u.Host)
+	}
+
+	h3Fail = true
+	if got := get(); got != "tcp" {
+		t.Fatalf("request after HTTP/3 failure got %q; want it sent over TCP", got)
+	}
+	h3Fail = false
+	if got := get(); got != "tcp" {
+		t.Fatalf("request after HTTP/3 failure got %q; want the endpoint avoided", got)
+	}
+}
+
+// A failed HTTP/3 request is sent again over TCP only if it could be
+// retried on a new connection, and never after its context is done.
+func TestTransportHTTP3NoFallBack(t *testing.T) {
+	defer afterTest(t)
+	errH3 := errors.New("h3 failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tests := []struct {
+		name    string
+		newReq  func(url string) *Request
+		h3Err   func(req *Request) error
+		wantErr error
+	}{{
+		name: "POST",
+		newReq: func(url string) *Request {
+			req, _ := NewRequest("POST", url, strings.NewReader("body"))
+			return req
+		},
+		h3Err:   func(*Request) error { return errH3 },
+		wantErr: errH3,
+	}, {
+		name: "canceled GET",
+		newReq: func(url string) *Request {
+			req, _ := NewRequestWithContext(ctx, "GET", url, nil)
+			return req
+		},
+		h3Err: func(req *Request) error {
+			cancel()
+			return req.Context().Err()
+		},
+		wantErr: context.Canceled,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tcpReqs int32
+			ts := httptest.NewTLSServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+				atomic.AddInt32(&tcpReqs, 1)
+				w.Header().Set("Alt-Svc", `h3=":4433"; ma=3600`)
+			}))
+			defer ts.Close()
+			c := ts.Client()
+			c.Transport.(*Transport).HTTP3 = roundTripperFunc(func(req *Request) (*Response, error) {
+				return nil, tt.h3Err(req)
+			})
+
+			// Learn the HTTP/3 endpoint.
+			res, err := c.Get(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			_, err = c.Do(tt.newReq(ts.URL))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v; want %v", err, tt.wantErr)
+			}
+			if n := atomic.LoadInt32(&tcpReqs); n != 1 {
+				t.Errorf("sent over TCP %d times after HTTP/3 failed; want 0", n-1)
+			}
+		})
+	}
+}
+
+func TestTransportPoolStats(t *testing.T) {
+	defer afterTest(t)
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	defer ts.Close()
+
+	tr := &Transport{}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+	for i := 0; i < 2; i++ {
+		res, err := c.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	u, _ := url.Parse(ts.URL)
+	name := "http://" + u.Host
+	var s PoolStats
+	for {
+		var ok bool
+		s, ok = tr.PoolStats()[name]
+		if !ok {
+			t.Fatalf("PoolStats() = %v; want an entry for %q", tr.PoolStats(), name)
+		}
+		if s.Idle == 1 {
+			break
+		}
+		// The connection is returned to the idle pool
+		// after the response body has been closed.
+		time.Sleep(time.Millisecond)
+	}
+	want := PoolStats{Idle: 1, Dials: 1, DialTime: s.DialTime, Requests: 2, Reused: 1}
+	if s != want {
+		t.Errorf("PoolStats()[%q] = %+v; want %+v", name, s, want)
+	}
+	if s.DialTime <= 0 || s.MeanDialLatency() != s.DialTime {
+		t.Errorf("DialTime = %v, MeanDialLatency = %v; want equal positive durations", s.DialTime, s.MeanDialLatency())
+	}
+	if r := s.ReuseRatio(); r != 0.5 {
+		t.Errorf("ReuseRatio = %v; want 0.5", r)
+	}
+
+	tr.CloseIdleConnections()
+	if s, ok := tr.PoolStats()[name]; ok {
+		t.Errorf("after CloseIdleConnections: %+v; want no entry for %q", s, name)
+	}
+}
+
+func TestTransportReadRate(t *testing.T) {
+	defer afterTest(t)
+	const size = 8 << 10
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Write(make([]byte, size))
+	}))
+	defer ts.Close()
+
+	tr := &Transport{ReadRate: size * 2}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+	start := time.Now()
+	res, err := c.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if n != size || err != nil {
+		t.Fatalf("read %d, %v; want %d, nil", n, err, size)
+	}
+	// The response is read in 2 KiB chunks, an eighth of a second apart.
+	if d := time.Since(start); d < 300*time.Millisecond {
+		t.Errorf("response took %v; want at least 300ms", d)
+	}
+}
+
+func TestTransportRetryPolicy(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var attempts int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if body, _ := io.ReadAll(r.Body); r.Method == "POST" && string(body) != "payload" {
+			t.Errorf("attempt %d: body = %q; want payload", n, body)
+		}
+		switch r.URL.Path {
+		case "/unavailable":
+			if n < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(StatusServiceUnavailable)
+				return
+			}
+		case "/reset":
+			if n == 1 {
+				c, _, _ := w.(Hijacker).Hijack()
+				c.Close()
+				return
+			}
+		case "/later":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(StatusTooManyRequests)
+			return
+		case "/always":
+			w.WriteHeader(StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	tr := &Transport{RetryPolicy: &RetryPolicy{
+		Backoff: func(int) time.Duration { return time.Millisecond },
+	}}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+
+	tests := []struct {
+		method, path string
+		idempotent   bool
+		wantCode     int
+		wantAttempts int32
+		wantRetries  []int // status codes passed to the Retry trace hook
+	}{
+		{"GET", "/unavailable", false, 200, 3, []int{503, 503}},
+		{"GET", "/reset", false, 200, 2, []int{0}},
+		{"GET", "/later", false, 429, 1, nil},
+		{"GET", "/always", false, 503, 3, []int{503, 503}},
+		{"POST", "/unavailable", false, 503, 1, nil},
+		{"POST", "/unavailable", true, 200, 3, []int{503, 503}},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&attempts, 0)
+		var body io.Reader
+		if tt.method == "POST" {
+			body = strings.NewReader("payload")
+		}
+		req, _ := NewRequest(tt.method, ts.URL+tt.path, body)
+		if tt.idempotent {
+			req.Header.Set("Idempotency-Key", "k")
+		}
+		var retries []int
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			Retry: func(info httptrace.RetryInfo) {
+				if info.Attempt != len(retries)+1 {
+					t.Errorf("%s %s: Retry Attempt = %d; want %d", tt.method, tt.path, info.Attempt, len(retries)+1)
+				}
+				if (info.StatusCode == 0) != (info.Err != nil) {
+					t.Errorf("%s %s: Retry StatusCode = %d, Err = %v; want exactly one", tt.method, tt.path, info.StatusCode, info.Err)
+				}
+				retries = append(retries, info.StatusCode)
+			},
+		}))
+		res, err := c.Do(req)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.method, tt.path, err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantCode {
+			t.Errorf("%s %s: status = %d; want %d", tt.method, tt.path, res.StatusCode, tt.wantCode)
+		}
+		if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+			t.Errorf("%s %s: %d attempts; want %d", tt.method, tt.path, got, tt.wantAttempts)
+		}
+		if !reflect.DeepEqual(retries, tt.wantRetries) {
+			t.Errorf("%s %s: retries = %v; want %v", tt.method, tt.path, retries, tt.wantRetries)
+		}
+	}
+}
+
+func TestTransportRetryPolicyContextCanceled(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.WriteHeader(StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	tr := &Transport{RetryPolicy: &RetryPolicy{
+		Backoff: func(int) time.Duration { return time.Hour },
+	}}
+	defer tr.CloseIdleConnections()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		Retry: func(httptrace.RetryInfo) { cancel() },
+	}))
+	_, err := tr.RoundTrip(req)
+	if err != context.Canceled {
+		t.Errorf("RoundTrip error = %v; want %v", err, context.Canceled)
+	}
+}
+
+// Tests that a response body copied to a ResponseWriter is read
+// directly from the Transport's connection, which can then be reused.
+func TestTransportResponseBodyWriteToDirect(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	body := bytes.Repeat([]byte("0123456789abcdef"), 64<<10)
+	backend := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+	defer backend.close()
+
+	var direct int64
+	trace := &httptrace.ClientTrace{
+		DirectResponseBody: func(n int64) { atomic.AddInt64(&direct, n) },
+	}
+	frontend := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		req, _ := NewRequestWithContext(httptrace.WithClientTrace(r.Context(), trace), "GET", backend.ts.URL, nil)
+		res, err := backend.c.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer res.Body.Close()
+		w.Header().Set("Content-Length", strconv.FormatInt(res.ContentLength, 10))
+		if _, err := io.Copy(w, res.Body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer frontend.close()
+
+	for i := 0; i < 2; i++ {
+		atomic.StoreInt64(&direct, 0)
+		res, err := frontend.c.Get(frontend.ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Fatalf("request %d: got %d bytes of body; want %d bytes, unchanged", i, len(got), len(body))
+		}
+		if n := atomic.LoadInt64(&direct); n <= 0 || n > int64(len(body)) {
+			t.Errorf("request %d: %d bytes copied directly; want between 1 and %d", i, n, len(body))
+		}
+	}
+}
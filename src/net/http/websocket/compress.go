@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// The permessage-deflate extension, as defined in RFC 7692. Both
+// peers are always asked not to use context takeover, so that each
+// message is compressed on its own and no compression state is kept
+// between messages.
+
+const deflateExtension = "permessage-deflate"
+
+// deflateResponse is the value of the Sec-WebSocket-Extensions header
+// with which the server accepts an offer of permessage-deflate, and
+// which the client requires in reply to its own offer.
+const deflateResponse = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+// deflateTail is the end of a DEFLATE sync flush, which is
+// removed from compressed messages. See RFC 7692, section 7.2.1.
+const deflateTail = "\x00\x00\xff\xff"
+
+var (
+	flateWriterPool sync.Pool // *flate.Writer
+	flateReaderPool sync.Pool // io.ReadCloser from flate.NewReader
+)
+
+// compress returns the compressed payload of a message with contents p.
+func compress(p []byte) []byte {
+	var buf bytes.Buffer
+	fw, _ := flateWriterPool.Get().(*flate.Writer)
+	if fw == nil {
+		fw, _ = flate.NewWriter(&buf, flate.BestSpeed)
+	} else {
+		fw.Reset(&buf)
+	}
+	fw.Write(p)
+	fw.Flush()
+	flateWriterPool.Put(fw)
+	return bytes.TrimSuffix(buf.Bytes(), []byte(deflateTail))
+}
+
+// decompress returns the contents of a message with the compressed
+// payload p. It returns errTooBig if they are longer than limit.
+func decompress(p []byte, limit int64) ([]byte, error) {
+	// Restore the end of the sync flush, and add an empty final
+	// block, so that the reader stops at the end of the message.
+	r := io.MultiReader(bytes.NewReader(p), strings.NewReader(deflateTail+"\x01\x00\x00\xff\xff"))
+	fr, _ := flateReaderPool.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(r)
+	} else {
+		fr.(flate.Resetter).Reset(r, nil)
+	}
+	defer flateReaderPool.Put(fr)
+	out, err := io.ReadAll(io.LimitReader(fr, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, errTooBig
+	}
+	return out, nil
+}
+
+// acceptDeflate reports whether the server can accept one of the
+// offers of permessage-deflate in the Sec-WebSocket-Extensions header
+// values. Offers that limit the server's window size are declined, as
+// compress/flate always uses the largest window.
+func acceptDeflate(values []string) bool {
+	for _, ext := range parseExtensions(values) {
+		if ext[0] != deflateExtension {
+			continue
+		}
+		ok := true
+		for _, param := range ext[1:] {
+			name := param
+			if i := strings.IndexByte(param, '='); i >= 0 {
+				name = textproto.TrimString(param[:i])
+			}
+			name, _ = ascii.ToLower(name)
+			switch name {
+			case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// deflateAccepted reports whether the Sec-WebSocket-Extensions header
+// values of the server's handshake response accept the client's offer
+// of permessage-deflate. It returns an error if they name another
+// extension, or permessage-deflate with parameters that the client
+// did not ask for.
+func deflateAccepted(values []string) (bool, error) {
+	exts := parseExtensions(values)
+	if len(exts) == 0 {
+		return false, nil
+	}
+	if len(exts) > 1 || exts[0][0] != deflateExtension {
+		return false, errBadHandshake
+	}
+	var serverNoTakeover bool
+	for _, param := range exts[0][1:] {
+		param, _ = ascii.ToLower(param)
+		switch param {
+		case "server_no_context_takeover":
+			serverNoTakeover = true
+		case "client_no_context_takeover":
+		default:
+			return false, errBadHandshake
+		}
+	}
+	if !serverNoTakeover {
+		return false, errBadHandshake
+	}
+	return true, nil
+}
+
+// parseExtensions parses Sec-WebSocket-Extensions header values into
+// a list of extensions, each a name followed by its parameters.
+func parseExtensions(values []string) [][]string {
+	var exts [][]string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			var ext []string
+			for _, f := range strings.Split(e, ";") {
+				ext = append(ext, textproto.TrimString(f))
+			}
+			if ext[0] != "" {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// An opcode is the type of a frame, as defined in RFC 6455, section 5.2.
+type opcode byte
+
+const (
+	opContinuation opcode = 0x0
+	opText         opcode = 0x1
+	opBinary       opcode = 0x2
+	opClose        opcode = 0x8
+	opPing         opcode = 0x9
+	opPong         opcode = 0xa
+)
+
+func (op opcode) isControl() bool {
+	return op&0x8 != 0
+}
+
+func (op opcode) valid() bool {
+	switch op {
+	case opContinuation, opText, opBinary, opClose, opPing, opPong:
+		return true
+	}
+	return false
+}
+
+// A frameHeader is the header of a WebSocket frame.
+type frameHeader struct {
+	fin              bool
+	rsv1, rsv2, rsv3 bool
+	op               opcode
+	masked           bool
+	mask             [4]byte
+	length           int64
+}
+
+// readFrameHeader reads a frame header from r.
+func readFrameHeader(r *bufio.Reader) (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&0x80 != 0
+	h.rsv1 = b[0]&0x40 != 0
+	h.rsv2 = b[0]&0x20 != 0
+	h.rsv3 = b[0]&0x10 != 0
+	h.op = opcode(b[0] & 0xf)
+	h.masked = b[1]&0x80 != 0
+	switch n := b[1] & 0x7f; n {
+	case 126:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, b[:8]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+		u := binary.BigEndian.Uint64(b[:8])
+		if u>>63 != 0 {
+			return h, errBadFrame
+		}
+		h.length = int64(u)
+	default:
+		h.length = int64(n)
+	}
+	if h.masked {
+		if _, err := io.ReadFull(r, h.mask[:]); err != nil {
+			return h, unexpectedEOF(err)
+		}
+	}
+	return h, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// writeFrameHeader writes h to w. Errors are reported when w is flushed.
+func writeFrameHeader(w *bufio.Writer, h frameHeader) {
+	var b [14]byte
+	b[0] = byte(h.op)
+	if h.fin {
+		b[0] |= 0x80
+	}
+	if h.rsv1 {
+		b[0] |= 0x40
+	}
+	n := 2
+	switch {
+	case h.length < 126:
+		b[1] = byte(h.length)
+	case h.length <= 0xffff:
+		b[1] = 126
+		binary.BigEndian.PutUint16(b[2:], uint16(h.length))
+		n += 2
+	default:
+		b[1] = 127
+		binary.BigEndian.PutUint64(b[2:], uint64(h.length))
+		n += 8
+	}
+	if h.masked {
+		b[1] |= 0x80
+		n += copy(b[n:], h.mask[:])
+	}
+	w.Write(b[:n])
+}
+
+// maskBytes masks or unmasks p, the whole payload of a frame, with
+// the masking key.
+func maskBytes(key [4]byte, p []byte) {
+	for i := range p {
+		p[i] ^= key[i&3]
+	}
+}
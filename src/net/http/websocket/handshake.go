@@ -0,0 +1,366 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/internal"
+	"net/http/internal/ascii"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// keyGUID is appended to the Sec-WebSocket-Key of a handshake to
+// compute its Sec-WebSocket-Accept. See RFC 6455, section 1.3.
+const keyGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errBadHandshake = errors.New("websocket: bad handshake")
+
+// acceptKey returns the Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + keyGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// AcceptOptions configures Accept.
+type AcceptOptions struct {
+	// Subprotocols lists the subprotocols that the server supports,
+	// in order of preference. The first one that the client also
+	// lists in its Sec-WebSocket-Protocol header is selected.
+	Subprotocols []string
+
+	// CheckOrigin optionally reports whether to accept a request
+	// from its Origin header. If nil, a request with an Origin
+	// header is accepted only if the origin's host is the request's
+	// Host, so that pages from other sites cannot open connections
+	// with the credentials of their visitors.
+	CheckOrigin func(r *http.Request) bool
+
+	// Compression enables the permessage-deflate extension if the
+	// client offers it.
+	Compression bool
+}
+
+// Accept upgrades the HTTP/1.1 request r to a WebSocket connection,
+// writing the opening handshake response to w. Headers set on w, such
+// as cookies, are included in the response.
+//
+// If r is not a valid WebSocket handshake, Accept replies to it with
+// an HTTP error and returns an error. Otherwise, the connection is
+// hijacked: the handler may return once it has handed the Conn to
+// another goroutine, and the Conn is no longer subject to the
+// Server's timeouts; use Conn.SetReadDeadline and
+// Conn.SetWriteDeadline instead. When the Server is shut down with
+// Shutdown, its open connections are closed with StatusGoingAway.
+func Accept(w http.ResponseWriter, r *http.Request, opts *AcceptOptions) (*Conn, error) {
+	if opts == nil {
+		opts = &AcceptOptions{}
+	}
+	if err := checkRequest(r); err != nil {
+		w.Header().Set("Connection", "close")
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			http.Error(w, err.Error(), http.StatusUpgradeRequired)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return nil, err
+	}
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		err := errors.New("websocket: request origin not allowed")
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, err
+	}
+
+	h := w.Header().Clone()
+	h.Set("Upgrade", "websocket")
+	h.Set("Connection", "Upgrade")
+	h.Set("Sec-WebSocket-Accept", acceptKey(r.Header.Get("Sec-WebSocket-Key")))
+	subprotocol := selectSubprotocol(r, opts.Subprotocols)
+	if subprotocol != "" {
+		h.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+	compress := opts.Compression && acceptDeflate(r.Header["Sec-Websocket-Extensions"])
+	if compress {
+		h.Set("Sec-WebSocket-Extensions", deflateResponse)
+	}
+
+	nc, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket: connection cannot be upgraded", http.StatusNotImplemented)
+		return nil, err
+	}
+	fmt.Fprintf(brw, "HTTP/1.1 %d %s\r\n", http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols))
+	h.Write(brw)
+	brw.WriteString("\r\n")
+	if err := brw.Flush(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	c := newConn(nc, brw.Reader, brw.Writer, false)
+	c.subprotocol = subprotocol
+	c.compress = compress
+	if srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server); ok {
+		trackConn(srv, c)
+	}
+	return c, nil
+}
+
+// checkRequest reports whether r is a valid WebSocket opening
+// handshake, as described in RFC 6455, section 4.2.1.
+func checkRequest(r *http.Request) error {
+	switch {
+	case r.Method != "GET":
+		return errors.New("websocket: handshake method is not GET")
+	case r.ProtoMajor != 1 || !r.ProtoAtLeast(1, 1):
+		return errors.New("websocket: handshake is not HTTP/1.1")
+	case !headerContainsToken(r.Header["Connection"], "upgrade"),
+		!headerContainsToken(r.Header["Upgrade"], "websocket"):
+		return errors.New("websocket: request does not ask to upgrade to websocket")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return errors.New("websocket: unsupported version")
+	}
+	key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key"))
+	if err != nil || len(key) != 16 {
+		return errors.New("websocket: invalid Sec-WebSocket-Key")
+	}
+	return nil
+}
+
+// sameOrigin reports whether r has no Origin header, or one whose
+// host is the request's Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return ascii.EqualFold(u.Host, r.Host)
+}
+
+// selectSubprotocol returns the first of the server's subprotocols
+// that the client of r offers, or "" if there is none.
+func selectSubprotocol(r *http.Request, subprotocols []string) string {
+	offered := headerTokens(r.Header["Sec-Websocket-Protocol"])
+	for _, p := range subprotocols {
+		for _, o := range offered {
+			if o == p {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// headerTokens returns the comma-separated tokens of header values.
+func headerTokens(values []string) []string {
+	var tokens []string
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if t = textproto.TrimString(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether header values contain
+// the token, ignoring case.
+func headerContainsToken(values []string, token string) bool {
+	for _, t := range headerTokens(values) {
+		if ascii.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverConns tracks the open connections accepted by each Server,
+// so that they can be closed when the Server is shut down. A Server
+// is only listed while it has open connections.
+var serverConns struct {
+	mu sync.Mutex
+	m  map[*http.Server]*connSet
+}
+
+type connSet struct {
+	conns map[*Conn]bool
+}
+
+// serverConnsKey is the key under which trackConn registers
+// shutdownConns with each Server.
+type serverConnsKey struct{}
+
+func trackConn(srv *http.Server, c *Conn) {
+	serverConns.mu.Lock()
+	defer serverConns.mu.Unlock()
+	set, ok := serverConns.m[srv]
+	if !ok {
+		if serverConns.m == nil {
+			serverConns.m = make(map[*http.Server]*connSet)
+		}
+		set = &connSet{conns: make(map[*Conn]bool)}
+		serverConns.m[srv] = set
+		internal.RegisterOnShutdownOnce(srv, serverConnsKey{}, func() { shutdownConns(srv) })
+	}
+	set.conns[c] = true
+	c.untrack = func() {
+		serverConns.mu.Lock()
+		delete(set.conns, c)
+		if len(set.conns) == 0 && serverConns.m[srv] == set {
+			delete(serverConns.m, srv)
+		}
+		serverConns.mu.Unlock()
+	}
+}
+
+// shutdownConns closes the connections accepted by srv.
+func shutdownConns(srv *http.Server) {
+	serverConns.mu.Lock()
+	var conns []*Conn
+	if set, ok := serverConns.m[srv]; ok {
+		for c := range set.conns {
+			conns = append(conns, c)
+		}
+	}
+	delete(serverConns.m, srv)
+	serverConns.mu.Unlock()
+	for _, c := range conns {
+		go c.Close(StatusGoingAway, "server shutting down")
+	}
+}
+
+// DialOptions configures Dial.
+type DialOptions struct {
+	// Client sends the opening handshake request.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Header specifies additional headers to send with the
+	// opening handshake request, such as Origin or cookies.
+	Header http.Header
+
+	// Subprotocols lists the subprotocols that the client supports.
+	Subprotocols []string
+
+	// Compression offers the permessage-deflate extension to the
+	// server.
+	Compression bool
+}
+
+// Dial opens a WebSocket connection to the "ws", "wss", "http" or
+// "https" URL urlStr. The context governs the opening handshake;
+// cancelling it afterwards does not affect the connection.
+//
+// Dial returns the server's handshake response along with the
+// connection. If the handshake fails because of the server's response,
+// Dial returns that response, with its body closed, and an error.
+func Dial(ctx context.Context, urlStr string, opts *DialOptions) (*Conn, *http.Response, error) {
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported URL scheme %q", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range opts.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	var rawKey [16]byte
+	if _, err := io.ReadFull(rand.Reader, rawKey[:]); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(rawKey[:])
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(opts.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(opts.Subprotocols, ", "))
+	}
+	if opts.Compression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateResponse)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	compress, err := checkResponse(res, key, opts)
+	if err != nil {
+		res.Body.Close()
+		return nil, res, err
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+		return nil, res, errors.New("websocket: response body is not writable")
+	}
+	c := newConn(rwc, bufio.NewReader(rwc), bufio.NewWriter(rwc), true)
+	c.subprotocol = res.Header.Get("Sec-WebSocket-Protocol")
+	c.compress = compress
+	return c, res, nil
+}
+
+// checkResponse reports whether res is a valid response to an opening
+// handshake with the key, and whether it accepts permessage-deflate.
+func checkResponse(res *http.Response, key string, opts *DialOptions) (compress bool, err error) {
+	if res.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(res.Header["Connection"], "upgrade") ||
+		!headerContainsToken(res.Header["Upgrade"], "websocket") ||
+		res.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return false, errBadHandshake
+	}
+	if p := res.Header.Get("Sec-WebSocket-Protocol"); p != "" {
+		ok := false
+		for _, s := range opts.Subprotocols {
+			ok = ok || s == p
+		}
+		if !ok {
+			return false, errBadHandshake
+		}
+	}
+	compress, err = deflateAccepted(res.Header["Sec-Websocket-Extensions"])
+	if compress && !opts.Compression {
+		return false, errBadHandshake
+	}
+	return compress, err
+}
@@ -0,0 +1,477 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package websocket implements the WebSocket protocol defined in RFC 6455,
+// including the permessage-deflate extension defined in RFC 7692.
+//
+// Servers upgrade a request to a WebSocket connection with Accept,
+// and clients open one with Dial. Both return a Conn, which exchanges
+// whole messages:
+//
+//	func echo(w http.ResponseWriter, r *http.Request) {
+//		c, err := websocket.Accept(w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		defer c.Close(websocket.StatusNormalClosure, "")
+//		for {
+//			typ, msg, err := c.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			if err := c.WriteMessage(typ, msg); err != nil {
+//				return
+//			}
+//		}
+//	}
+//
+// Ping frames are answered automatically while a message is being
+// read, so a connection should always have a goroutine reading from it.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// A MessageType is the type of a WebSocket message.
+type MessageType int
+
+// The message types defined in RFC 6455, section 5.6.
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// A StatusCode is a close status code, as defined in RFC 6455,
+// section 7.4.
+type StatusCode int
+
+const (
+	StatusNormalClosure           StatusCode = 1000
+	StatusGoingAway               StatusCode = 1001
+	StatusProtocolError           StatusCode = 1002
+	StatusUnsupportedData         StatusCode = 1003
+	StatusNoStatusReceived        StatusCode = 1005 // never sent
+	StatusAbnormalClosure         StatusCode = 1006 // never sent
+	StatusInvalidFramePayloadData StatusCode = 1007
+	StatusPolicyViolation         StatusCode = 1008
+	StatusMessageTooBig           StatusCode = 1009
+	StatusMandatoryExtension      StatusCode = 1010
+	StatusInternalError           StatusCode = 1011
+)
+
+// sendable reports whether code may be sent in a close frame.
+func (code StatusCode) sendable() bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// A CloseError is returned by Conn.ReadMessage when the peer
+// closed the connection with a close frame.
+type CloseError struct {
+	Code   StatusCode
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Reason)
+}
+
+const (
+	// defaultReadLimit is the default maximum size of a message read.
+	defaultReadLimit = 1 << 20
+
+	// maxControlPayload is the maximum size of the payload of a
+	// control frame, including the status code of a close frame.
+	maxControlPayload = 125
+
+	// closeTimeout is how long Close waits for the peer to reply
+	// to a close frame.
+	closeTimeout = 5 * time.Second
+)
+
+var (
+	errBadFrame      = errors.New("websocket: malformed frame")
+	errUnmasked      = errors.New("websocket: unmasked frame from client")
+	errMasked        = errors.New("websocket: masked frame from server")
+	errBadContinue   = errors.New("websocket: continuation frame without a message")
+	errInterleaved   = errors.New("websocket: data frame before the end of a message")
+	errTooBig        = errors.New("websocket: message exceeds read limit")
+	errInvalidUTF8   = errors.New("websocket: text message is not valid UTF-8")
+	errBadCloseFrame = errors.New("websocket: malformed close frame")
+)
+
+// A Conn is a WebSocket connection.
+//
+// ReadMessage must not be called concurrently with itself. The other
+// methods may be called concurrently with each other and with
+// ReadMessage.
+type Conn struct {
+	rwc         io.ReadWriteCloser // net.Conn, or a 101 response body
+	client      bool
+	subprotocol string
+	compress    bool // permessage-deflate, without context takeover
+	untrack     func()
+
+	readMu    sync.Mutex
+	br        *bufio.Reader
+	readLimit int64
+	readErr   error // sticky
+
+	writeMu   sync.Mutex
+	bw        *bufio.Writer
+	writeBuf  []byte
+	closeSent bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, bw *bufio.Writer, client bool) *Conn {
+	return &Conn{
+		rwc:       rwc,
+		client:    client,
+		br:        br,
+		bw:        bw,
+		readLimit: defaultReadLimit,
+	}
+}
+
+// Subprotocol returns the subprotocol negotiated in the opening
+// handshake, or "" if none was.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetReadLimit sets the maximum size of a message read, after it is
+// decompressed. A larger message closes the connection with
+// StatusMessageTooBig. The default limit is 1 MiB.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readMu.Lock()
+	c.readLimit = n
+	c.readMu.Unlock()
+}
+
+// SetReadDeadline sets the deadline for reading from the underlying
+// connection. A zero value means no deadline. Once the deadline is
+// exceeded, the connection can no longer be read.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	if d, ok := c.rwc.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return d.SetReadDeadline(t)
+	}
+	return http.ErrNotSupported
+}
+
+// SetWriteDeadline sets the deadline for writing to the underlying
+// connection. A zero value means no deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	if d, ok := c.rwc.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return http.ErrNotSupported
+}
+
+// LocalAddr returns the local address of the underlying connection,
+// or nil if it is not known.
+func (c *Conn) LocalAddr() net.Addr {
+	if a, ok := c.rwc.(interface{ LocalAddr() net.Addr }); ok {
+		return a.LocalAddr()
+	}
+	return nil
+}
+
+// RemoteAddr returns the remote address of the underlying connection,
+// or nil if it is not known.
+func (c *Conn) RemoteAddr() net.Addr {
+	if a, ok := c.rwc.(interface{ RemoteAddr() net.Addr }); ok {
+		return a.RemoteAddr()
+	}
+	return nil
+}
+
+// ReadMessage reads the next data message from the connection,
+// replying to any ping and close frames received before it.
+//
+// If the peer closes the connection, ReadMessage returns a
+// *CloseError. Once ReadMessage returns an error, it returns the
+// same error on every later call.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	typ, p, err := c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return typ, p, err
+}
+
+// readMessage reads the frames of the next data message.
+// c.readMu must be held.
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ        MessageType
+		compressed bool
+		p          []byte
+	)
+	for {
+		h, err := readFrameHeader(c.br)
+		if err != nil {
+			c.closeConn()
+			return 0, nil, err
+		}
+		if err := c.checkFrame(h); err != nil {
+			return 0, nil, c.fail(StatusProtocolError, err)
+		}
+		if h.op.isControl() {
+			if err := c.readControl(h); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		switch {
+		case typ == 0 && h.op == opContinuation:
+			return 0, nil, c.fail(StatusProtocolError, errBadContinue)
+		case typ == 0:
+			typ = MessageType(h.op)
+			compressed = h.rsv1
+		case h.op != opContinuation:
+			return 0, nil, c.fail(StatusProtocolError, errInterleaved)
+		case h.rsv1:
+			return 0, nil, c.fail(StatusProtocolError, errBadFrame)
+		}
+		if h.length > c.readLimit-int64(len(p)) {
+			return 0, nil, c.fail(StatusMessageTooBig, errTooBig)
+		}
+		n := len(p)
+		p = append(p, make([]byte, h.length)...)
+		if _, err := io.ReadFull(c.br, p[n:]); err != nil {
+			c.closeConn()
+			return 0, nil, unexpectedEOF(err)
+		}
+		if h.masked {
+			maskBytes(h.mask, p[n:])
+		}
+		if h.fin {
+			break
+		}
+	}
+	if compressed {
+		var err error
+		if p, err = decompress(p, c.readLimit); err != nil {
+			if err == errTooBig {
+				return 0, nil, c.fail(StatusMessageTooBig, err)
+			}
+			return 0, nil, c.fail(StatusInvalidFramePayloadData, err)
+		}
+	}
+	if typ == TextMessage && !utf8.Valid(p) {
+		return 0, nil, c.fail(StatusInvalidFramePayloadData, errInvalidUTF8)
+	}
+	return typ, p, nil
+}
+
+// checkFrame reports whether the header of a frame received by c is
+// valid, apart from its place in the sequence of frames.
+func (c *Conn) checkFrame(h frameHeader) error {
+	switch {
+	case h.rsv2 || h.rsv3:
+		return errBadFrame
+	case h.rsv1 && (!c.compress || h.op.isControl()):
+		return errBadFrame
+	case !h.op.valid():
+		return fmt.Errorf("websocket: unknown opcode %d", h.op)
+	case h.op.isControl() && (!h.fin || h.length > maxControlPayload):
+		return errBadFrame
+	case !c.client && !h.masked:
+		return errUnmasked
+	case c.client && h.masked:
+		return errMasked
+	}
+	return nil
+}
+
+// readControl reads and handles the payload of a control frame.
+// It returns an error if the frame closes the connection.
+func (c *Conn) readControl(h frameHeader) error {
+	var buf [maxControlPayload]byte
+	p := buf[:h.length]
+	if _, err := io.ReadFull(c.br, p); err != nil {
+		c.closeConn()
+		return unexpectedEOF(err)
+	}
+	if h.masked {
+		maskBytes(h.mask, p)
+	}
+	switch h.op {
+	case opPing:
+		// Errors writing the pong surface in the next
+		// write, or read, of the connection.
+		c.writeControl(opPong, p)
+	case opClose:
+		e := &CloseError{Code: StatusNoStatusReceived}
+		if len(p) > 0 {
+			if len(p) < 2 {
+				return c.fail(StatusProtocolError, errBadCloseFrame)
+			}
+			e.Code = StatusCode(binary.BigEndian.Uint16(p))
+			e.Reason = string(p[2:])
+			if !e.Code.sendable() || !utf8.ValidString(e.Reason) {
+				return c.fail(StatusProtocolError, errBadCloseFrame)
+			}
+		}
+		c.writeClose(e.Code, "")
+		c.closeConn()
+		return e
+	}
+	return nil
+}
+
+// fail closes the connection because of a protocol error or a
+// message that cannot be handled, and returns err.
+func (c *Conn) fail(code StatusCode, err error) error {
+	c.writeClose(code, "")
+	c.closeConn()
+	return err
+}
+
+// WriteMessage writes a message of type typ with contents p.
+//
+// Once a close frame has been sent, WriteMessage returns an error
+// matching net.ErrClosed.
+func (c *Conn) WriteMessage(typ MessageType, p []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	compressed := c.compress && len(p) > 0
+	if compressed {
+		p = compress(p)
+	}
+	return c.writeFrame(opcode(typ), compressed, p)
+}
+
+// Ping sends a ping frame with the payload p, which must be at most
+// 125 bytes long. The peer's pong is discarded by ReadMessage.
+func (c *Conn) Ping(p []byte) error {
+	if len(p) > maxControlPayload {
+		return errors.New("websocket: ping payload too long")
+	}
+	return c.writeControl(opPing, p)
+}
+
+func (c *Conn) writeControl(op opcode, p []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeFrame(op, false, p)
+}
+
+// writeClose sends a close frame, if none has been sent yet.
+// The status StatusNoStatusReceived sends an empty close frame.
+func (c *Conn) writeClose(code StatusCode, reason string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errCloseSent
+	}
+	var p []byte
+	if code != StatusNoStatusReceived {
+		p = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(p, uint16(code))
+		p = append(p, reason...)
+	}
+	err := c.writeFrame(opClose, false, p)
+	c.closeSent = true
+	return err
+}
+
+var errCloseSent = fmt.Errorf("websocket: close frame sent: %w", net.ErrClosed)
+
+// writeFrame writes a single final frame. c.writeMu must be held.
+func (c *Conn) writeFrame(op opcode, rsv1 bool, p []byte) error {
+	if c.closeSent {
+		return errCloseSent
+	}
+	h := frameHeader{
+		fin:    true,
+		rsv1:   rsv1,
+		op:     op,
+		length: int64(len(p)),
+	}
+	if c.client {
+		// Clients mask their frames with an unpredictable key,
+		// so that they cannot be crafted to look like requests to
+		// intermediaries. See RFC 6455, section 10.3.
+		h.masked = true
+		if _, err := io.ReadFull(rand.Reader, h.mask[:]); err != nil {
+			return err
+		}
+		c.writeBuf = append(c.writeBuf[:0], p...)
+		p = c.writeBuf
+		maskBytes(h.mask, p)
+	}
+	writeFrameHeader(c.bw, h)
+	c.bw.Write(p)
+	return c.bw.Flush()
+}
+
+// Close performs the closing handshake with the status code and
+// reason, and closes the underlying connection. The reason must be
+// valid UTF-8 and at most 123 bytes long.
+//
+// Close waits a few seconds for the peer's close frame, reading and
+// discarding any messages before it; a concurrent ReadMessage returns
+// once the handshake completes. If a close frame was already sent,
+// Close just closes the underlying connection.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	if !code.sendable() || len(reason) > maxControlPayload-2 || !utf8.ValidString(reason) {
+		return errors.New("websocket: invalid close status or reason")
+	}
+	if err := c.writeClose(code, reason); err != nil {
+		c.closeConn()
+		if err == errCloseSent {
+			return nil
+		}
+		return err
+	}
+	c.SetReadDeadline(time.Now().Add(closeTimeout))
+	c.readMu.Lock()
+	for c.readErr == nil {
+		_, _, c.readErr = c.readMessage()
+	}
+	c.readMu.Unlock()
+	return c.closeConn()
+}
+
+// closeConn closes the underlying connection.
+func (c *Conn) closeConn() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.rwc.Close()
+		if c.untrack != nil {
+			c.untrack()
+		}
+	})
+	return c.closeErr
+}
@@ -0,0 +1,357 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServer starts a server that echoes the messages of
+// WebSocket connections accepted with opts.
+func newEchoServer(t *testing.T, opts *AcceptOptions) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, opts)
+		if err != nil {
+			return
+		}
+		for {
+			typ, p, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(typ, p); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func wsURL(ts *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example in RFC 6455, section 1.3.
+	if got, want := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("acceptKey = %q; want %q", got, want)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	for _, compression := range []bool{false, true} {
+		ts := newEchoServer(t, &AcceptOptions{
+			Subprotocols: []string{"chat", "echo"},
+			Compression:  compression,
+		})
+		c, res, err := Dial(context.Background(), wsURL(ts), &DialOptions{
+			Subprotocols: []string{"echo", "chat"},
+			Compression:  compression,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Subprotocol(); got != "chat" {
+			t.Errorf("Subprotocol = %q; want %q", got, "chat")
+		}
+		if c.compress != compression {
+			t.Errorf("compression = %v; want %v (extensions %q)", c.compress, compression, res.Header["Sec-Websocket-Extensions"])
+		}
+		msgs := []struct {
+			typ MessageType
+			p   string
+		}{
+			{TextMessage, "hello"},
+			{BinaryMessage, "\x00\xff"},
+			{TextMessage, ""},
+			{TextMessage, strings.Repeat("long message ", 10000)},
+		}
+		for _, m := range msgs {
+			if err := c.WriteMessage(m.typ, []byte(m.p)); err != nil {
+				t.Fatal(err)
+			}
+			typ, p, err := c.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ != m.typ || string(p) != m.p {
+				t.Errorf("echo of %v %.20q = %v %.20q", m.typ, m.p, typ, p)
+			}
+		}
+		if err := c.Close(StatusNormalClosure, "bye"); err != nil {
+			t.Errorf("Close = %v", err)
+		}
+		var ce *CloseError
+		if _, _, err := c.ReadMessage(); !errors.As(err, &ce) || ce.Code != StatusNormalClosure {
+			t.Errorf("ReadMessage after Close = %v; want close with status %d", err, StatusNormalClosure)
+		}
+		if err := c.WriteMessage(TextMessage, nil); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("WriteMessage after Close = %v; want net.ErrClosed", err)
+		}
+	}
+}
+
+func TestAcceptRejects(t *testing.T) {
+	ts := newEchoServer(t, nil)
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{"not websocket", http.Header{}, http.StatusUpgradeRequired},
+		{"bad key", http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"short"},
+		}, http.StatusBadRequest},
+		{"cross origin", http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+			"Origin":                {"https://evil.example"},
+		}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		req.Header = tt.header
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s: status = %d; want %d", tt.name, res.StatusCode, tt.status)
+		}
+	}
+
+	_, res, err := Dial(context.Background(), wsURL(ts), &DialOptions{
+		Header: http.Header{"Origin": {"https://evil.example"}},
+	})
+	if err == nil || res == nil || res.StatusCode != http.StatusForbidden {
+		t.Errorf("Dial from other origin = %v, %v; want handshake error with status 403", res, err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	errc := make(chan error, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, nil)
+		if err != nil {
+			errc <- err
+			return
+		}
+		c.SetReadLimit(10)
+		_, _, err = c.ReadMessage()
+		errc <- err
+	}))
+	defer ts.Close()
+
+	c, _, err := Dial(context.Background(), wsURL(ts), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	if err := c.WriteMessage(BinaryMessage, make([]byte, 11)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != errTooBig {
+		t.Errorf("server ReadMessage = %v; want %v", err, errTooBig)
+	}
+	var ce *CloseError
+	if _, _, err := c.ReadMessage(); !errors.As(err, &ce) || ce.Code != StatusMessageTooBig {
+		t.Errorf("client ReadMessage = %v; want close with status %d", err, StatusMessageTooBig)
+	}
+}
+
+func TestServerShutdownClosesConns(t *testing.T) {
+	ts := newEchoServer(t, nil)
+	c, _, err := Dial(context.Background(), wsURL(ts), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	// Make sure that the server has accepted the connection.
+	if err := c.WriteMessage(TextMessage, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ts.Config.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var ce *CloseError
+	if _, _, err := c.ReadMessage(); !errors.As(err, &ce) || ce.Code != StatusGoingAway {
+		t.Errorf("ReadMessage after Shutdown = %v; want close with status %d", err, StatusGoingAway)
+	}
+}
+
+func TestServerConnsUntracked(t *testing.T) {
+	ts := newEchoServer(t, nil)
+	for i := 0; i < 2; i++ {
+		c, _, err := Dial(context.Background(), wsURL(ts), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.WriteMessage(TextMessage, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := c.ReadMessage(); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Close(StatusNormalClosure, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The server closes its side of the connections asynchronously.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		serverConns.mu.Lock()
+		_, ok := serverConns.m[ts.Config]
+		serverConns.mu.Unlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Server still tracked after its connections closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// frame returns a masked client frame.
+func frame(fin bool, op opcode, payload string) []byte {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	h := frameHeader{fin: fin, op: op, masked: true, mask: [4]byte{1, 2, 3, 4}, length: int64(len(payload))}
+	writeFrameHeader(bw, h)
+	p := []byte(payload)
+	maskBytes(h.mask, p)
+	bw.Write(p)
+	bw.Flush()
+	return buf.Bytes()
+}
+
+func TestReadFrames(t *testing.T) {
+	tests := []struct {
+		name    string
+		frames  [][]byte
+		want    string
+		code    StatusCode // of the close frame sent, if any
+		wantErr bool
+	}{
+		{
+			name: "fragmented with ping",
+			frames: [][]byte{
+				frame(false, opText, "hel"),
+				frame(true, opPing, "p"),
+				frame(true, opContinuation, "lo"),
+			},
+			want: "hello",
+		},
+		{
+			name:    "unmasked",
+			frames:  [][]byte{{0x81, 0x00}},
+			code:    StatusProtocolError,
+			wantErr: true,
+		},
+		{
+			name:    "continuation without message",
+			frames:  [][]byte{frame(true, opContinuation, "x")},
+			code:    StatusProtocolError,
+			wantErr: true,
+		},
+		{
+			name:    "fragmented control frame",
+			frames:  [][]byte{frame(false, opPing, "x")},
+			code:    StatusProtocolError,
+			wantErr: true,
+		},
+		{
+			name:    "invalid UTF-8",
+			frames:  [][]byte{frame(true, opText, "\xff")},
+			code:    StatusInvalidFramePayloadData,
+			wantErr: true,
+		},
+		{
+			name:    "reserved opcode",
+			frames:  [][]byte{frame(true, 0x3, "")},
+			code:    StatusProtocolError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		client, server := net.Pipe()
+		c := newConn(server, bufio.NewReader(server), bufio.NewWriter(server), false)
+		go func() {
+			for _, f := range tt.frames {
+				client.Write(f)
+			}
+		}()
+		var replies bytes.Buffer
+		done := make(chan bool)
+		go func() {
+			replies.ReadFrom(client)
+			close(done)
+		}()
+		_, p, err := c.ReadMessage()
+		if (err != nil) != tt.wantErr || string(p) != tt.want {
+			t.Errorf("%s: ReadMessage = %q, %v; want %q, error %v", tt.name, p, err, tt.want, tt.wantErr)
+		}
+		c.closeConn()
+		<-done
+		if tt.code != 0 {
+			want := []byte{0x88, 0x02, byte(tt.code >> 8), byte(tt.code)}
+			if !bytes.Equal(replies.Bytes(), want) {
+				t.Errorf("%s: sent %x; want close frame %x", tt.name, replies.Bytes(), want)
+			}
+		}
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "a", strings.Repeat("abc", 1000)} {
+		got, err := decompress(compress([]byte(s)), 1<<20)
+		if err != nil || string(got) != s {
+			t.Errorf("decompress(compress(%.10q)) = %.10q, %v", s, got, err)
+		}
+	}
+	if _, err := decompress(compress(make([]byte, 100)), 99); err != errTooBig {
+		t.Errorf("decompress over limit = %v; want %v", err, errTooBig)
+	}
+}
+
+func TestAcceptDeflate(t *testing.T) {
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_max_window_bits", true},
+		{"permessage-deflate; server_max_window_bits=10", false},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", true},
+		{"x-webkit-deflate-frame", false},
+	}
+	for _, tt := range tests {
+		if got := acceptDeflate([]string{tt.header}); got != tt.ok {
+			t.Errorf("acceptDeflate(%q) = %v; want %v", tt.header, got, tt.ok)
+		}
+	}
+}
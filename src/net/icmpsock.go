@@ -0,0 +1,236 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"syscall"
+)
+
+// ICMP message types of ICMP for IPv4 (RFC 792) and of ICMPv6
+// (RFC 4443).
+const (
+	ICMPv4EchoReply              = 0
+	ICMPv4DestinationUnreachable = 3
+	ICMPv4EchoRequest            = 8
+	ICMPv4TimeExceeded           = 11
+
+	ICMPv6DestinationUnreachable = 1
+	ICMPv6PacketTooBig           = 2
+	ICMPv6TimeExceeded           = 3
+	ICMPv6EchoRequest            = 128
+	ICMPv6EchoReply              = 129
+)
+
+var (
+	errICMPMessageTooShort = errors.New("ICMP message too short")
+	errInvalidICMPMessage  = errors.New("invalid ICMP message")
+)
+
+// An ICMPMessage is an ICMP for IPv4 or an ICMPv6 message.
+type ICMPMessage struct {
+	IPv6 bool // whether the message is an ICMPv6 message
+	Type int
+	Code int
+
+	// ID and Seq are the identifier and the sequence number of
+	// echo requests and replies.
+	ID  int
+	Seq int
+
+	// Data is the data of echo requests and replies, and the
+	// body following the 4-byte header of other messages, such
+	// as the start of the packet that caused an error.
+	Data []byte
+}
+
+func (m *ICMPMessage) isEcho() bool {
+	if m.IPv6 {
+		return m.Type == ICMPv6EchoRequest || m.Type == ICMPv6EchoReply
+	}
+	return m.Type == ICMPv4EchoRequest || m.Type == ICMPv4EchoReply
+}
+
+// Marshal returns the binary encoding of m. The checksum of ICMPv6
+// messages, which covers the addresses of the IPv6 packet, is left
+// for the operating system to compute when the message is sent.
+func (m *ICMPMessage) Marshal() ([]byte, error) {
+	if m.Type < 0 || m.Type > 0xff || m.Code < 0 || m.Code > 0xff {
+		return nil, errInvalidICMPMessage
+	}
+	hdrlen := 4
+	if m.isEcho() {
+		if m.ID < 0 || m.ID > 0xffff || m.Seq < 0 || m.Seq > 0xffff {
+			return nil, errInvalidICMPMessage
+		}
+		hdrlen = 8
+	}
+	b := make([]byte, hdrlen+len(m.Data))
+	b[0], b[1] = byte(m.Type), byte(m.Code)
+	if hdrlen == 8 {
+		b[4], b[5] = byte(m.ID>>8), byte(m.ID)
+		b[6], b[7] = byte(m.Seq>>8), byte(m.Seq)
+	}
+	copy(b[hdrlen:], m.Data)
+	if !m.IPv6 {
+		s := icmpChecksum(b)
+		b[2], b[3] = byte(s>>8), byte(s)
+	}
+	return b, nil
+}
+
+// icmpChecksum returns the Internet checksum (RFC 1071) of b.
+func icmpChecksum(b []byte) uint16 {
+	var s uint32
+	for ; len(b) >= 2; b = b[2:] {
+		s += uint32(b[0])<<8 | uint32(b[1])
+	}
+	if len(b) == 1 {
+		s += uint32(b[0]) << 8
+	}
+	for s>>16 != 0 {
+		s = s&0xffff + s>>16
+	}
+	return ^uint16(s)
+}
+
+// ParseICMPMessage parses b, which holds an ICMP message without
+// its IP header, as read from an ICMPConn. It parses an ICMPv6
+// message if ipv6 is true, and an ICMP for IPv4 message otherwise.
+// The Data field of the returned message refers to b.
+func ParseICMPMessage(ipv6 bool, b []byte) (*ICMPMessage, error) {
+	if len(b) < 4 {
+		return nil, errICMPMessageTooShort
+	}
+	m := &ICMPMessage{IPv6: ipv6, Type: int(b[0]), Code: int(b[1])}
+	if !m.isEcho() {
+		m.Data = b[4:]
+		return m, nil
+	}
+	if len(b) < 8 {
+		return nil, errICMPMessageTooShort
+	}
+	m.ID = int(b[4])<<8 | int(b[5])
+	m.Seq = int(b[6])<<8 | int(b[7])
+	m.Data = b[8:]
+	return m, nil
+}
+
+// ICMPConn is the implementation of the Conn and PacketConn
+// interfaces for ICMP, such as for sending echo requests ("pings")
+// and receiving their replies. Its ReadFrom and WriteTo methods
+// receive and send ICMP messages without their IP headers.
+type ICMPConn struct {
+	conn
+}
+
+func newICMPConn(fd *netFD) *ICMPConn { return &ICMPConn{conn{fd}} }
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+func (c *ICMPConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd)
+}
+
+// ReadFromIP acts like ReadFrom but returns an IPAddr.
+func (c *ICMPConn) ReadFromIP(b []byte) (int, *IPAddr, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err := c.readFrom(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, addr, err
+}
+
+// ReadFrom implements the PacketConn ReadFrom method. It reads an
+// ICMP message, without its IP header, into b. The address it
+// returns is an *IPAddr.
+func (c *ICMPConn) ReadFrom(b []byte) (int, Addr, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err := c.readFrom(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// WriteToIP acts like WriteTo but takes an IPAddr.
+func (c *ICMPConn) WriteToIP(b []byte, addr *IPAddr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeTo(b, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// WriteTo implements the PacketConn WriteTo method. It sends the
+// ICMP message in b, as made by ICMPMessage.Marshal, to addr, which
+// must be an *IPAddr.
+func (c *ICMPConn) WriteTo(b []byte, addr Addr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	a, ok := addr.(*IPAddr)
+	if !ok {
+		return 0, &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr, Err: syscall.EINVAL}
+	}
+	n, err := c.writeTo(b, a)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: a.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// SetTTL sets the time to live of the IPv4 packets, or the hop
+// limit of the IPv6 packets, that carry the messages sent on c.
+func (c *ICMPConn) SetTTL(ttl int) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setIPTTL(c.fd, ttl); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// ListenICMP listens for ICMP messages addressed to the local address
+// laddr, and lets them be sent from it. If laddr is nil or its IP is
+// unspecified, it listens on all the addresses of the local system
+// of the address family of the network.
+//
+// The network "ip4:icmp" or "ip6:ipv6-icmp" opens a raw socket,
+// which receives all the ICMP messages of its address family and
+// needs privileges on most systems. The network "udp4" or "udp6"
+// instead opens a datagram ICMP socket, which only Linux and macOS
+// provide and which can be used without privileges: on Linux, for
+// the groups in the net.ipv4.ping_group_range sysctl. Datagram ICMP
+// sockets only send and receive echo messages, and their replies to
+// them; the system sets the identifier of the echo requests sent,
+// which on Linux is the port of the local address of the connection.
+func ListenICMP(network string, laddr *IPAddr) (*ICMPConn, error) {
+	if laddr == nil {
+		laddr = &IPAddr{}
+	}
+	sl := &sysListener{network: network, address: laddr.String()}
+	c, err := sl.listenICMP(context.Background(), laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
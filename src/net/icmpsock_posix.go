@@ -0,0 +1,71 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || (js && wasm) || linux || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly freebsd js,wasm linux netbsd openbsd solaris windows
+
+package net
+
+import (
+	"context"
+	"syscall"
+)
+
+const (
+	ipprotoICMP   = 1
+	ipprotoICMPv6 = 58
+)
+
+func (c *ICMPConn) readFrom(b []byte) (int, *IPAddr, error) {
+	var addr *IPAddr
+	n, sa, err := c.fd.readFrom(b)
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		addr = &IPAddr{IP: sa.Addr[0:]}
+		// Raw sockets, and datagram sockets on macOS, pass
+		// on the IPv4 header. No ICMP message type starts
+		// with the version nibble of 4 that it starts with.
+		n = stripIPv4Header(n, b[:n])
+	case *syscall.SockaddrInet6:
+		addr = &IPAddr{IP: sa.Addr[0:], Zone: zoneCache.name(int(sa.ZoneId))}
+	}
+	return n, addr, err
+}
+
+func (c *ICMPConn) writeTo(b []byte, addr *IPAddr) (int, error) {
+	if addr == nil {
+		return 0, errMissingAddress
+	}
+	sa, err := addr.sockaddr(c.fd.family)
+	if err != nil {
+		return 0, err
+	}
+	return c.fd.writeTo(b, sa)
+}
+
+func (sl *sysListener) listenICMP(ctx context.Context, laddr *IPAddr) (*ICMPConn, error) {
+	network, sotype := sl.network, syscall.SOCK_DGRAM
+	var proto int
+	switch network {
+	case "udp4":
+		proto = ipprotoICMP
+	case "udp6":
+		proto = ipprotoICMPv6
+	default:
+		var err error
+		network, proto, err = parseNetwork(ctx, sl.network, true)
+		if err != nil {
+			return nil, err
+		}
+		if !(network == "ip4" && proto == ipprotoICMP) && !(network == "ip6" && proto == ipprotoICMPv6) {
+			return nil, UnknownNetworkError(sl.network)
+		}
+		sotype = syscall.SOCK_RAW
+	}
+	fd, err := internetSocket(ctx, network, laddr, nil, sotype, proto, "listen", sl.ListenConfig.Control)
+	if err != nil {
+		return nil, err
+	}
+	return newICMPConn(fd), nil
+}
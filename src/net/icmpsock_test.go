@@ -0,0 +1,126 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !js
+// +build !js
+
+package net
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var icmpMessageTests = []struct {
+	m ICMPMessage
+	b []byte
+}{
+	{
+		ICMPMessage{Type: ICMPv4EchoRequest, ID: 0x1234, Seq: 1, Data: []byte("HELLO")},
+		[]byte{8, 0, 0x02, 0x39, 0x12, 0x34, 0, 1, 'H', 'E', 'L', 'L', 'O'},
+	},
+	{
+		ICMPMessage{IPv6: true, Type: ICMPv6EchoReply, ID: 0xfffe, Seq: 0x100, Data: []byte{}},
+		[]byte{129, 0, 0, 0, 0xff, 0xfe, 1, 0},
+	},
+	{
+		ICMPMessage{Type: ICMPv4DestinationUnreachable, Code: 3, Data: []byte{0, 0, 0, 0, 0x45}},
+		[]byte{3, 3, 0xb7, 0xfc, 0, 0, 0, 0, 0x45},
+	},
+}
+
+func TestICMPMessage(t *testing.T) {
+	for _, tt := range icmpMessageTests {
+		b, err := tt.m.Marshal()
+		if err != nil {
+			t.Errorf("%+v: %v", tt.m, err)
+			continue
+		}
+		if !bytes.Equal(b, tt.b) {
+			t.Errorf("%+v: got %#v; want %#v", tt.m, b, tt.b)
+		}
+		m, err := ParseICMPMessage(tt.m.IPv6, b)
+		if err != nil {
+			t.Errorf("%#v: %v", b, err)
+			continue
+		}
+		if !reflect.DeepEqual(*m, tt.m) {
+			t.Errorf("%#v: got %+v; want %+v", b, *m, tt.m)
+		}
+	}
+
+	if _, err := (&ICMPMessage{Type: ICMPv4EchoRequest, ID: 1 << 16}).Marshal(); err == nil {
+		t.Error("Marshal succeeded with an ID out of range")
+	}
+	if _, err := ParseICMPMessage(false, []byte{8, 0, 0, 0, 0}); err == nil {
+		t.Error("ParseICMPMessage succeeded with a truncated echo request")
+	}
+}
+
+func TestICMPConnEcho(t *testing.T) {
+	for _, tt := range []struct {
+		network string
+		addr    *IPAddr
+	}{
+		{"ip4:icmp", &IPAddr{IP: IPv4(127, 0, 0, 1)}},
+		{"udp4", &IPAddr{IP: IPv4(127, 0, 0, 1)}},
+		{"ip6:ipv6-icmp", &IPAddr{IP: IPv6loopback}},
+		{"udp6", &IPAddr{IP: IPv6loopback}},
+	} {
+		t.Run(tt.network, func(t *testing.T) {
+			if !testableNetwork(tt.network) {
+				t.Skipf("not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+			}
+			c, err := ListenICMP(tt.network, nil)
+			if err != nil {
+				// Datagram ICMP sockets are not available
+				// everywhere, nor to every user.
+				t.Skip(err)
+			}
+			defer c.Close()
+			if err := c.SetTTL(8); err != nil {
+				t.Fatal(err)
+			}
+
+			ipv6 := tt.addr.IP.To4() == nil
+			req := ICMPMessage{IPv6: ipv6, Type: ICMPv4EchoRequest, ID: 0x4d2, Seq: 7, Data: []byte("ping")}
+			want := ICMPv4EchoReply
+			if ipv6 {
+				req.Type, want = ICMPv6EchoRequest, ICMPv6EchoReply
+			}
+			b, err := req.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.WriteTo(b, tt.addr); err != nil {
+				t.Fatal(err)
+			}
+
+			c.SetReadDeadline(time.Now().Add(5 * time.Second))
+			rb := make([]byte, 1500)
+			for {
+				n, addr, err := c.ReadFromIP(rb)
+				if err != nil {
+					t.Fatal(err)
+				}
+				m, err := ParseICMPMessage(ipv6, rb[:n])
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Raw sockets also receive the request, and
+				// the messages of other programs.
+				if m.Type != want || m.Seq != req.Seq || string(m.Data) != "ping" {
+					continue
+				}
+				if !addr.IP.Equal(tt.addr.IP) {
+					t.Errorf("got reply from %v; want %v", addr, tt.addr)
+				}
+				return
+			}
+		})
+	}
+}
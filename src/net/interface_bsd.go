@@ -94,26 +94,33 @@ func interfaceAddrTable(ifi *Interface) ([]Addr, error) {
 			if index != 0 && index != m.Index {
 				continue
 			}
-			var mask IPMask
-			switch sa := m.Addrs[syscall.RTAX_NETMASK].(type) {
-			case *route.Inet4Addr:
-				mask = IPv4Mask(sa.IP[0], sa.IP[1], sa.IP[2], sa.IP[3])
-			case *route.Inet6Addr:
-				mask = make(IPMask, IPv6len)
-				copy(mask, sa.IP[:])
-			}
-			var ip IP
-			switch sa := m.Addrs[syscall.RTAX_IFA].(type) {
-			case *route.Inet4Addr:
-				ip = IPv4(sa.IP[0], sa.IP[1], sa.IP[2], sa.IP[3])
-			case *route.Inet6Addr:
-				ip = make(IP, IPv6len)
-				copy(ip, sa.IP[:])
-			}
-			if ip != nil && mask != nil { // NetBSD may contain route.LinkAddr
-				ifat = append(ifat, &IPNet{IP: ip, Mask: mask})
+			if ifa := newAddr(m); ifa != nil {
+				ifat = append(ifat, ifa)
 			}
 		}
 	}
 	return ifat, nil
 }
+
+func newAddr(m *route.InterfaceAddrMessage) Addr {
+	var mask IPMask
+	switch sa := m.Addrs[syscall.RTAX_NETMASK].(type) {
+	case *route.Inet4Addr:
+		mask = IPv4Mask(sa.IP[0], sa.IP[1], sa.IP[2], sa.IP[3])
+	case *route.Inet6Addr:
+		mask = make(IPMask, IPv6len)
+		copy(mask, sa.IP[:])
+	}
+	var ip IP
+	switch sa := m.Addrs[syscall.RTAX_IFA].(type) {
+	case *route.Inet4Addr:
+		ip = IPv4(sa.IP[0], sa.IP[1], sa.IP[2], sa.IP[3])
+	case *route.Inet6Addr:
+		ip = make(IP, IPv6len)
+		copy(ip, sa.IP[:])
+	}
+	if ip == nil || mask == nil { // NetBSD may contain route.LinkAddr
+		return nil
+	}
+	return &IPNet{IP: ip, Mask: mask}
+}
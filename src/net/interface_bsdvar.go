@@ -13,6 +13,10 @@ import (
 	"golang.org/x/net/route"
 )
 
+// sysRTM_IFANNOUNCE is the type of the route messages that announce the
+// arrival and departure of interfaces.
+const sysRTM_IFANNOUNCE = syscall.RTM_IFANNOUNCE
+
 func interfaceMessages(ifindex int) ([]route.Message, error) {
 	rib, err := route.FetchRIB(syscall.AF_UNSPEC, syscall.NET_RT_IFLIST, ifindex)
 	if err != nil {
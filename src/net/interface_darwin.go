@@ -10,6 +10,11 @@ import (
 	"golang.org/x/net/route"
 )
 
+// sysRTM_IFANNOUNCE would be the type of the route messages that
+// announce the arrival and departure of interfaces, which macOS does
+// not send; it is not the type of any message.
+const sysRTM_IFANNOUNCE = -1
+
 func interfaceMessages(ifindex int) ([]route.Message, error) {
 	rib, err := route.FetchRIB(syscall.AF_UNSPEC, syscall.NET_RT_IFLIST, ifindex)
 	if err != nil {
@@ -10,6 +10,10 @@ import (
 	"golang.org/x/net/route"
 )
 
+// sysRTM_IFANNOUNCE is the type of the route messages that announce the
+// arrival and departure of interfaces.
+const sysRTM_IFANNOUNCE = syscall.RTM_IFANNOUNCE
+
 func interfaceMessages(ifindex int) ([]route.Message, error) {
 	typ := route.RIBType(syscall.NET_RT_IFLISTL)
 	rib, err := route.FetchRIB(syscall.AF_UNSPEC, typ, ifindex)
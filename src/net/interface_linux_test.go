@@ -5,9 +5,12 @@
 package net
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"testing"
+	"time"
 )
 
 func (ti *testInterface) setBroadcast(suffix int) error {
@@ -131,3 +134,57 @@ func TestParseProcNet(t *testing.T) {
 		t.Fatalf("got %d; want %d", len(ifmat6), numOfTestIPv6MCAddrs)
 	}
 }
+
+func TestNotifyInterfaceChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("avoid changing the interfaces")
+	}
+	if os.Getuid() != 0 {
+		t.Skip("must be root")
+	}
+	lo := loopbackInterface()
+	if lo == nil {
+		t.Skip("no loopback interface")
+	}
+	xname, err := exec.LookPath("ip")
+	if err != nil {
+		t.Skipf("test requires external command: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := NotifyInterfaceChanges(ctx)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	local := "127.0.0.254/32"
+	if out, err := exec.Command(xname, "address", "add", local, "dev", lo.Name).CombinedOutput(); err != nil {
+		cancel()
+		t.Skipf("cannot add address: %v %s", err, out)
+	}
+	if out, err := exec.Command(xname, "address", "del", local, "dev", lo.Name).CombinedOutput(); err != nil {
+		t.Errorf("cannot remove address: %v %s", err, out)
+	}
+
+	want := []InterfaceEventType{AddrAdded, AddrRemoved}
+	timeout := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case ev := <-ch:
+			ifa, ok := ev.Addr.(*IPNet)
+			if !ok || ifa.String() != local {
+				continue
+			}
+			if ev.Type != want[0] || ev.Interface.Index != lo.Index || ev.Interface.Name != lo.Name {
+				t.Errorf("got %v on %+v; want %v on %s", ev.Type, ev.Interface, want[0], lo.Name)
+			}
+			want = want[1:]
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v", want)
+		}
+	}
+
+	cancel()
+	for range ch {
+	}
+}
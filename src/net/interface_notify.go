@@ -0,0 +1,169 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/itoa"
+)
+
+// errInterfaceNotifyNotSupported is returned by NotifyInterfaceChanges
+// on systems where package net does not implement it.
+var errInterfaceNotifyNotSupported = errors.New("interface change notifications not supported")
+
+// An InterfaceEventType is the type of an InterfaceEvent.
+type InterfaceEventType int
+
+const (
+	InterfaceAdded   InterfaceEventType = iota + 1 // an interface appeared
+	InterfaceChanged                               // the flags, name, MTU or hardware address of an interface changed
+	InterfaceRemoved                               // an interface went away
+	AddrAdded                                      // an address was assigned to an interface
+	AddrRemoved                                    // an address was removed from an interface
+)
+
+var interfaceEventTypes = []string{
+	InterfaceAdded:   "interface added",
+	InterfaceChanged: "interface changed",
+	InterfaceRemoved: "interface removed",
+	AddrAdded:        "address added",
+	AddrRemoved:      "address removed",
+}
+
+func (t InterfaceEventType) String() string {
+	if t > 0 && int(t) < len(interfaceEventTypes) {
+		return interfaceEventTypes[t]
+	}
+	return "InterfaceEventType(" + itoa.Itoa(int(t)) + ")"
+}
+
+// An InterfaceEvent reports a change of the network interfaces or of
+// their addresses.
+type InterfaceEvent struct {
+	Type InterfaceEventType
+
+	// Interface is the interface the event is about, as of the
+	// event. For an InterfaceRemoved event, and for address events
+	// about interfaces not seen before, only its Index, and maybe
+	// its Name, are set.
+	Interface Interface
+
+	// Addr is the address added or removed, an *IPNet, for
+	// AddrAdded and AddrRemoved events.
+	Addr Addr
+}
+
+// NotifyInterfaceChanges returns a channel on which the changes of the
+// network interfaces of the system and of their addresses are
+// reported, until ctx is done. The channel is closed then, or when
+// the system stops reporting changes.
+//
+// Changes are reported from netlink on Linux, route sockets on BSD
+// systems and macOS, and NotifyIpInterfaceChange and
+// NotifyUnicastIpAddressChange on Windows. Not all systems report
+// all types of events; macOS, for one, does not report that an
+// interface went away.
+//
+// The system drops events that are not read in time, so the channel
+// should be drained promptly. Programs that need an exact view of the
+// interfaces should call Interfaces and InterfaceAddrs after an event.
+func NotifyInterfaceChanges(ctx context.Context) (<-chan InterfaceEvent, error) {
+	w, err := newInterfaceWatcher()
+	if err != nil {
+		return nil, &OpError{Op: "route", Net: "ip+net", Source: nil, Addr: nil, Err: err}
+	}
+	known := make(map[int]Interface)
+	ift, err := interfaceTable(0)
+	if err != nil {
+		w.close()
+		return nil, &OpError{Op: "route", Net: "ip+net", Source: nil, Addr: nil, Err: err}
+	}
+	for _, ifi := range ift {
+		known[ifi.Index] = ifi
+	}
+
+	ch := make(chan InterfaceEvent, 16)
+	done, closed := make(chan struct{}), make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		w.close()
+		close(closed)
+	}()
+	go func() {
+		watchInterfaces(ctx, w, known, ch)
+		close(done)
+		<-closed
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// watchInterfaces sends the events that w reads on ch until w fails
+// or ctx is done.
+func watchInterfaces(ctx context.Context, w *interfaceWatcher, known map[int]Interface, ch chan<- InterfaceEvent) {
+	for {
+		evs, err := w.read()
+		if err != nil {
+			return
+		}
+		for _, ev := range evs {
+			if !classifyInterfaceEvent(known, &ev) {
+				continue
+			}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// classifyInterfaceEvent completes the event ev that a system
+// reported, given the interfaces known so far, and updates them. The
+// systems report both new and changed interfaces as InterfaceChanged
+// events. It reports false if ev brings no news.
+func classifyInterfaceEvent(known map[int]Interface, ev *InterfaceEvent) bool {
+	index := ev.Interface.Index
+	old, ok := known[index]
+	switch ev.Type {
+	case InterfaceChanged:
+		if !ok {
+			ev.Type = InterfaceAdded
+		} else if equalInterface(&old, &ev.Interface) {
+			return false
+		}
+		known[index] = ev.Interface
+	case InterfaceRemoved:
+		if !ok {
+			return false
+		}
+		if ev.Interface.Name == "" {
+			ev.Interface.Name = old.Name
+		}
+		delete(known, index)
+	case AddrAdded, AddrRemoved:
+		if ok {
+			ev.Interface = old
+		}
+	}
+	return true
+}
+
+func equalInterface(a, b *Interface) bool {
+	if a.Index != b.Index || a.MTU != b.MTU || a.Name != b.Name || a.Flags != b.Flags || len(a.HardwareAddr) != len(b.HardwareAddr) {
+		return false
+	}
+	for i := range a.HardwareAddr {
+		if a.HardwareAddr[i] != b.HardwareAddr[i] {
+			return false
+		}
+	}
+	return true
+}
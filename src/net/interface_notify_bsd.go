@@ -0,0 +1,124 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package net
+
+import (
+	"internal/poll"
+	"syscall"
+
+	"golang.org/x/net/route"
+)
+
+// An interfaceWatcher reads the interface and address messages of a
+// route socket.
+type interfaceWatcher struct {
+	fd  *netFD
+	buf []byte
+	ift map[int]Interface // interfaces as of the last interface message
+}
+
+func newInterfaceWatcher() (*interfaceWatcher, error) {
+	s, err := sysSocket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := newFD(s, syscall.AF_ROUTE, syscall.SOCK_RAW, "route")
+	if err != nil {
+		poll.CloseFunc(s)
+		return nil, err
+	}
+	if err := fd.init(); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	w := &interfaceWatcher{fd: fd, buf: make([]byte, syscall.Getpagesize()), ift: make(map[int]Interface)}
+	ift, err := interfaceTable(0)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+	for _, ifi := range ift {
+		w.ift[ifi.Index] = ifi
+	}
+	return w, nil
+}
+
+func (w *interfaceWatcher) read() ([]InterfaceEvent, error) {
+	for {
+		n, err := w.fd.Read(w.buf)
+		if err != nil {
+			return nil, err
+		}
+		// A read returns a single message, whose header starts
+		// with its length, version and type.
+		if n < 4 {
+			continue
+		}
+		switch int(w.buf[3]) {
+		case syscall.RTM_IFINFO, sysRTM_IFANNOUNCE:
+			// The interface messages of route sockets do not
+			// all carry the name and hardware address of the
+			// interface, so take the news from the table.
+			if evs := w.diffInterfaces(); len(evs) > 0 {
+				return evs, nil
+			}
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			msgs, err := route.ParseRIB(route.RIBTypeInterface, w.buf[:n])
+			if err != nil {
+				continue
+			}
+			var evs []InterfaceEvent
+			for _, m := range msgs {
+				m, ok := m.(*route.InterfaceAddrMessage)
+				if !ok {
+					continue
+				}
+				ifa := newAddr(m)
+				if ifa == nil {
+					continue
+				}
+				ev := InterfaceEvent{Type: AddrAdded, Interface: Interface{Index: m.Index}, Addr: ifa}
+				if m.Type == syscall.RTM_DELADDR {
+					ev.Type = AddrRemoved
+				}
+				evs = append(evs, ev)
+			}
+			if len(evs) > 0 {
+				return evs, nil
+			}
+		}
+	}
+}
+
+// diffInterfaces reports the interfaces that changed or went away
+// since it was last called.
+func (w *interfaceWatcher) diffInterfaces() []InterfaceEvent {
+	ift, err := interfaceTable(0)
+	if err != nil {
+		return nil
+	}
+	var evs []InterfaceEvent
+	cur := make(map[int]Interface, len(ift))
+	for _, ifi := range ift {
+		cur[ifi.Index] = ifi
+		if old, ok := w.ift[ifi.Index]; !ok || !equalInterface(&old, &ifi) {
+			evs = append(evs, InterfaceEvent{Type: InterfaceChanged, Interface: ifi})
+		}
+	}
+	for index, ifi := range w.ift {
+		if _, ok := cur[index]; !ok {
+			evs = append(evs, InterfaceEvent{Type: InterfaceRemoved, Interface: ifi})
+		}
+	}
+	w.ift = cur
+	return evs
+}
+
+func (w *interfaceWatcher) close() {
+	w.fd.Close()
+}
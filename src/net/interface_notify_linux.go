@@ -0,0 +1,108 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"internal/poll"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Multicast groups of netlink route sockets; see linux/rtnetlink.h.
+const (
+	sysRTMGRP_LINK        = 0x1
+	sysRTMGRP_IPV4_IFADDR = 0x10
+	sysRTMGRP_IPV6_IFADDR = 0x100
+)
+
+// An interfaceWatcher reads the link and address events of a
+// netlink route socket.
+type interfaceWatcher struct {
+	fd  *netFD
+	buf []byte
+}
+
+func newInterfaceWatcher() (*interfaceWatcher, error) {
+	s, err := sysSocket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+	sa := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: sysRTMGRP_LINK | sysRTMGRP_IPV4_IFADDR | sysRTMGRP_IPV6_IFADDR,
+	}
+	if err := syscall.Bind(s, sa); err != nil {
+		poll.CloseFunc(s)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	fd, err := newFD(s, syscall.AF_NETLINK, syscall.SOCK_RAW, "netlink")
+	if err != nil {
+		poll.CloseFunc(s)
+		return nil, err
+	}
+	if err := fd.init(); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return &interfaceWatcher{fd: fd, buf: make([]byte, syscall.Getpagesize()*2)}, nil
+}
+
+func (w *interfaceWatcher) read() ([]InterfaceEvent, error) {
+	for {
+		n, err := w.fd.Read(w.buf)
+		if errors.Is(err, syscall.ENOBUFS) {
+			// The socket buffer overflowed and events were
+			// lost; carry on with the next ones.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(w.buf[:n])
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkmessage", err)
+		}
+		var evs []InterfaceEvent
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+				ifim := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+				attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+				if err != nil {
+					continue
+				}
+				ev := InterfaceEvent{Type: InterfaceChanged, Interface: *newLink(ifim, attrs)}
+				if m.Header.Type == syscall.RTM_DELLINK {
+					ev.Type = InterfaceRemoved
+				}
+				evs = append(evs, ev)
+			case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+				ifam := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+				attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+				if err != nil {
+					continue
+				}
+				ifa := newAddr(ifam, attrs)
+				if ifa == nil {
+					continue
+				}
+				ev := InterfaceEvent{Type: AddrAdded, Interface: Interface{Index: int(ifam.Index)}, Addr: ifa}
+				if m.Header.Type == syscall.RTM_DELADDR {
+					ev.Type = AddrRemoved
+				}
+				evs = append(evs, ev)
+			}
+		}
+		if len(evs) > 0 {
+			return evs, nil
+		}
+	}
+}
+
+func (w *interfaceWatcher) close() {
+	w.fd.Close()
+}
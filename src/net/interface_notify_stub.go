@@ -0,0 +1,20 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package net
+
+type interfaceWatcher struct{}
+
+func newInterfaceWatcher() (*interfaceWatcher, error) {
+	return nil, errInterfaceNotifyNotSupported
+}
+
+func (w *interfaceWatcher) read() ([]InterfaceEvent, error) {
+	return nil, errInterfaceNotifyNotSupported
+}
+
+func (w *interfaceWatcher) close() {}
@@ -0,0 +1,38 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "testing"
+
+func TestClassifyInterfaceEvent(t *testing.T) {
+	eth0 := Interface{Index: 2, MTU: 1500, Name: "eth0", Flags: FlagUp}
+	eth0Down := eth0
+	eth0Down.Flags = 0
+	known := map[int]Interface{eth0.Index: eth0}
+	addr := &IPNet{IP: IPv4(192, 0, 2, 1), Mask: CIDRMask(24, 32)}
+
+	for i, tt := range []struct {
+		in   InterfaceEvent
+		want InterfaceEvent
+		ok   bool
+	}{
+		{InterfaceEvent{Type: InterfaceChanged, Interface: eth0}, InterfaceEvent{}, false},
+		{InterfaceEvent{Type: InterfaceChanged, Interface: eth0Down}, InterfaceEvent{Type: InterfaceChanged, Interface: eth0Down}, true},
+		{InterfaceEvent{Type: AddrAdded, Interface: Interface{Index: 2}, Addr: addr}, InterfaceEvent{Type: AddrAdded, Interface: eth0Down, Addr: addr}, true},
+		{InterfaceEvent{Type: InterfaceRemoved, Interface: Interface{Index: 2}}, InterfaceEvent{Type: InterfaceRemoved, Interface: Interface{Index: 2, Name: "eth0"}}, true},
+		{InterfaceEvent{Type: InterfaceRemoved, Interface: Interface{Index: 2}}, InterfaceEvent{}, false},
+		{InterfaceEvent{Type: InterfaceChanged, Interface: eth0}, InterfaceEvent{Type: InterfaceAdded, Interface: eth0}, true},
+	} {
+		ev := tt.in
+		ok := classifyInterfaceEvent(known, &ev)
+		if ok != tt.ok {
+			t.Errorf("#%d: got %v; want %v", i, ok, tt.ok)
+			continue
+		}
+		if ok && (ev.Type != tt.want.Type || !equalInterface(&ev.Interface, &tt.want.Interface) || ev.Addr != tt.want.Addr) {
+			t.Errorf("#%d: got %v %+v %v; want %v %+v %v", i, ev.Type, ev.Interface, ev.Addr, tt.want.Type, tt.want.Interface, tt.want.Addr)
+		}
+	}
+}
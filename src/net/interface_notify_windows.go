@@ -0,0 +1,178 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/windows"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// An interfaceWatcher receives the notifications of
+// NotifyIpInterfaceChange and NotifyUnicastIpAddressChange.
+type interfaceWatcher struct {
+	id      uintptr
+	handles []syscall.Handle
+	changes chan interfaceChange
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// An interfaceChange is a notification as received by the callbacks.
+type interfaceChange struct {
+	typ   uint32 // MIB_NOTIFICATION_TYPE
+	index int
+	addr  *IPNet // nil for interface notifications
+}
+
+var (
+	interfaceWatchersMu sync.Mutex
+	interfaceWatchers   = make(map[uintptr]*interfaceWatcher)
+	lastInterfaceWatch  uintptr
+
+	// The callbacks are made once and shared by the watchers, which
+	// they tell apart by their caller context, since the system has
+	// room for only a limited number of callbacks.
+	interfaceCallbacksOnce   sync.Once
+	ipInterfaceCallback      uintptr
+	unicastIPAddressCallback uintptr
+)
+
+func newInterfaceWatcher() (*interfaceWatcher, error) {
+	interfaceCallbacksOnce.Do(func() {
+		ipInterfaceCallback = syscall.NewCallback(ipInterfaceChanged)
+		unicastIPAddressCallback = syscall.NewCallback(unicastIPAddressChanged)
+	})
+	w := &interfaceWatcher{
+		changes: make(chan interfaceChange, 64),
+		closed:  make(chan struct{}),
+	}
+	interfaceWatchersMu.Lock()
+	lastInterfaceWatch++
+	w.id = lastInterfaceWatch
+	interfaceWatchers[w.id] = w
+	interfaceWatchersMu.Unlock()
+
+	var h syscall.Handle
+	if err := windows.NotifyIpInterfaceChange(syscall.AF_UNSPEC, ipInterfaceCallback, w.id, false, &h); err != nil {
+		w.close()
+		return nil, os.NewSyscallError("notifyipinterfacechange", err)
+	}
+	w.handles = append(w.handles, h)
+	if err := windows.NotifyUnicastIpAddressChange(syscall.AF_UNSPEC, unicastIPAddressCallback, w.id, false, &h); err != nil {
+		w.close()
+		return nil, os.NewSyscallError("notifyunicastipaddresschange", err)
+	}
+	w.handles = append(w.handles, h)
+	return w, nil
+}
+
+// notifyInterfaceWatcher passes a change on to the watcher with the
+// given caller context. It drops the change rather than block the
+// system's thread if the watcher is not keeping up.
+func notifyInterfaceWatcher(id uintptr, c interfaceChange) {
+	interfaceWatchersMu.Lock()
+	w := interfaceWatchers[id]
+	interfaceWatchersMu.Unlock()
+	if w == nil {
+		return
+	}
+	select {
+	case w.changes <- c:
+	default:
+	}
+}
+
+// ipInterfaceChanged is the callback of NotifyIpInterfaceChange. The
+// row is a MIB_IPINTERFACE_ROW, whose InterfaceIndex is at offset 16.
+func ipInterfaceChanged(id uintptr, row unsafe.Pointer, typ uintptr) uintptr {
+	if row != nil {
+		index := *(*uint32)(unsafe.Add(row, 16))
+		notifyInterfaceWatcher(id, interfaceChange{typ: uint32(typ), index: int(index)})
+	}
+	return 0
+}
+
+// unicastIPAddressChanged is the callback of
+// NotifyUnicastIpAddressChange. The row is a MIB_UNICASTIPADDRESS_ROW,
+// which starts with a SOCKADDR_INET and has its InterfaceIndex at
+// offset 40 and its OnLinkPrefixLength at offset 60.
+func unicastIPAddressChanged(id uintptr, row unsafe.Pointer, typ uintptr) uintptr {
+	if row == nil {
+		return 0
+	}
+	index := *(*uint32)(unsafe.Add(row, 40))
+	prefixLen := int(*(*uint8)(unsafe.Add(row, 60)))
+	var ifa *IPNet
+	switch *(*uint16)(row) {
+	case syscall.AF_INET:
+		b := (*[4]byte)(unsafe.Add(row, 4))
+		ifa = &IPNet{IP: IPv4(b[0], b[1], b[2], b[3]), Mask: CIDRMask(prefixLen, 8*IPv4len)}
+	case syscall.AF_INET6:
+		ifa = &IPNet{IP: make(IP, IPv6len), Mask: CIDRMask(prefixLen, 8*IPv6len)}
+		copy(ifa.IP, (*[16]byte)(unsafe.Add(row, 8))[:])
+	default:
+		return 0
+	}
+	notifyInterfaceWatcher(id, interfaceChange{typ: uint32(typ), index: int(index), addr: ifa})
+	return 0
+}
+
+func (w *interfaceWatcher) read() ([]InterfaceEvent, error) {
+	for {
+		var c interfaceChange
+		select {
+		case c = <-w.changes:
+		case <-w.closed:
+			return nil, ErrClosed
+		}
+		ev := InterfaceEvent{Interface: Interface{Index: c.index}}
+		if c.addr != nil {
+			switch c.typ {
+			case windows.MibAddInstance:
+				ev.Type = AddrAdded
+			case windows.MibDeleteInstance:
+				ev.Type = AddrRemoved
+			default:
+				continue
+			}
+			ev.Addr = c.addr
+			return []InterfaceEvent{ev}, nil
+		}
+		if c.typ == windows.MibDeleteInstance {
+			// The interface may still have a row for the
+			// other address family.
+			if ift, err := interfaceTable(c.index); err == nil && len(ift) > 0 {
+				ev.Type, ev.Interface = InterfaceChanged, ift[0]
+			} else {
+				ev.Type = InterfaceRemoved
+			}
+			return []InterfaceEvent{ev}, nil
+		}
+		ift, err := interfaceTable(c.index)
+		if err != nil || len(ift) == 0 {
+			continue
+		}
+		ev.Type, ev.Interface = InterfaceChanged, ift[0]
+		return []InterfaceEvent{ev}, nil
+	}
+}
+
+func (w *interfaceWatcher) close() {
+	w.closeOnce.Do(func() {
+		// CancelMibChangeNotify2 waits for the callbacks in
+		// progress to return.
+		for _, h := range w.handles {
+			windows.CancelMibChangeNotify2(h)
+		}
+		interfaceWatchersMu.Lock()
+		delete(interfaceWatchers, w.id)
+		interfaceWatchersMu.Unlock()
+		close(w.closed)
+	})
+}
@@ -199,6 +199,45 @@ func (c *IPConn) WriteMsgIP(b, oob []byte, addr *IPAddr) (n, oobn int, err error
 	return
 }
 
+// ReadBatch reads up to len(ms) datagrams from c into ms and returns
+// the number of messages read. It blocks until at least one datagram
+// is available and then reads those that are ready, without waiting
+// for more. flags are the MSG_* flags of the recvmsg system call.
+//
+// On Linux, ReadBatch reads all the messages with a single recvmmsg
+// system call. Elsewhere it reads one message per call and flags
+// must be zero.
+func (c *IPConn) ReadBatch(ms []Message, flags int) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.readBatch(ms, flags)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
+// WriteBatch writes the datagrams in ms to c and returns the number
+// of messages written, which is less than len(ms) only if err is not
+// nil. Each message is sent to its Addr if c isn't connected, or to
+// c's remote address if c is connected (in which case Addr must be
+// nil). flags are the MSG_* flags of the sendmsg system call.
+//
+// On Linux, WriteBatch writes as many messages as the system allows
+// with each sendmmsg system call. Elsewhere it writes one message at a
+// time and flags must be zero.
+func (c *IPConn) WriteBatch(ms []Message, flags int) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeBatch(ms, flags)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
 func newIPConn(fd *netFD) *IPConn { return &IPConn{conn{fd}} }
 
 // DialIP acts like Dial for IP networks.
@@ -66,6 +66,8 @@ func isIPv4(addr Addr) bool {
 		return addr.IP.To4() != nil
 	case *UDPAddr:
 		return addr.IP.To4() != nil
+	case *SCTPAddr:
+		return addr.IP.To4() != nil
 	case *IPAddr:
 		return addr.IP.To4() != nil
 	}
@@ -76,7 +78,8 @@ func isIPv4(addr Addr) bool {
 func isNotIPv4(addr Addr) bool { return !isIPv4(addr) }
 
 // forResolve returns the most appropriate address in address for
-// a call to ResolveTCPAddr, ResolveUDPAddr, or ResolveIPAddr.
+// a call to ResolveTCPAddr, ResolveUDPAddr, ResolveSCTPAddr, or
+// ResolveIPAddr.
 // IPv4 is preferred, unless addr contains an IPv6 literal.
 func (addrs addrList) forResolve(network, addr string) Addr {
 	var want6 bool
@@ -84,7 +87,7 @@ func (addrs addrList) forResolve(network, addr string) Addr {
 	case "ip":
 		// IPv6 literal (addr does NOT contain a port)
 		want6 = count(addr, ':') > 0
-	case "tcp", "udp":
+	case "tcp", "udp", "sctp":
 		// IPv6 literal. (addr contains a port, so look for '[')
 		want6 = count(addr, '[') > 0
 	}
@@ -125,6 +128,24 @@ func (addrs addrList) partition(strategy func(Addr) bool) (primaries, fallbacks
 	return
 }
 
+// preferFamily returns addrs with its IPv4 addresses first if ipv4
+// is true, and with its IPv6 addresses first otherwise, keeping the
+// order of the addresses of each family.
+func (addrs addrList) preferFamily(ipv4 bool) addrList {
+	sorted := make(addrList, 0, len(addrs))
+	for _, addr := range addrs {
+		if isIPv4(addr) == ipv4 {
+			sorted = append(sorted, addr)
+		}
+	}
+	for _, addr := range addrs {
+		if isIPv4(addr) != ipv4 {
+			sorted = append(sorted, addr)
+		}
+	}
+	return sorted
+}
+
 // filterAddrList applies a filter to a list of IP addresses,
 // yielding a list of Addr objects. Known filters are nil, ipv4only,
 // and ipv6only. It returns every address when the filter is nil.
@@ -252,7 +273,7 @@ func (r *Resolver) internetAddrList(ctx context.Context, net, addr string) (addr
 		portnum    int
 	)
 	switch net {
-	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "sctp", "sctp4", "sctp6":
 		if addr != "" {
 			if host, port, err = SplitHostPort(addr); err != nil {
 				return nil, err
@@ -274,6 +295,8 @@ func (r *Resolver) internetAddrList(ctx context.Context, net, addr string) (addr
 			return &TCPAddr{IP: ip.IP, Port: portnum, Zone: ip.Zone}
 		case "udp", "udp4", "udp6":
 			return &UDPAddr{IP: ip.IP, Port: portnum, Zone: ip.Zone}
+		case "sctp", "sctp4", "sctp6":
+			return &SCTPAddr{IP: ip.IP, Port: portnum, Zone: ip.Zone}
 		case "ip", "ip4", "ip6":
 			return &IPAddr{IP: ip.IP, Zone: ip.Zone}
 		default:
@@ -181,3 +181,119 @@ func ipToSockaddr(family int, ip IP, port int, zone string) (syscall.Sockaddr, e
 	}
 	return nil, &AddrError{Err: "invalid address family", Addr: ip.String()}
 }
+
+// control returns the function to call on sockets created by sl
+// before they are bound. It sets the socket options requested by
+// sl.ListenConfig and then calls sl.Control, if any.
+func (sl *sysListener) control() func(string, string, syscall.RawConn) error {
+	ro := routeSockopts{sl.Mark, sl.BindToDevice, sl.FreeBind, sl.Transparent}
+	if !sl.ReusePort && !sl.FastOpen && !ro.any() {
+		return sl.Control
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(s uintptr) {
+			serr = ro.set(s, network)
+			if serr == nil && sl.ReusePort {
+				serr = setReusePort(s)
+			}
+			switch network {
+			case "tcp", "tcp4", "tcp6":
+				if serr == nil && sl.FastOpen {
+					qlen := sl.FastOpenQueueLen
+					if qlen == 0 {
+						qlen = listenerBacklog()
+					}
+					serr = setFastOpen(s, qlen)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if serr != nil {
+			return serr
+		}
+		if sl.Control != nil {
+			return sl.Control(network, address, c)
+		}
+		return nil
+	}
+}
+
+// control returns the function to call on sockets created by sd
+// before they are connected. It sets the socket options requested by
+// sd.Dialer and, for DialFastOpen, asks for the connection to be
+// deferred to the first write, so that the data can be carried in the
+// SYN. Then it calls sd.Control, if any.
+func (sd *sysDialer) control() func(string, string, syscall.RawConn) error {
+	ro := routeSockopts{sd.Mark, sd.BindToDevice, sd.FreeBind, sd.Transparent}
+	if sd.fastOpen == nil && !ro.any() {
+		return sd.Control
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(s uintptr) {
+			serr = ro.set(s, network)
+			if serr == nil && sd.fastOpen != nil {
+				// If deferred connections are not supported,
+				// the data is written after the handshake.
+				setFastOpenConnect(s)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if serr != nil {
+			return serr
+		}
+		if sd.Control != nil {
+			return sd.Control(network, address, c)
+		}
+		return nil
+	}
+}
+
+// routeSockopts holds the options of Dialer and ListenConfig that
+// steer the traffic of sockets: Mark, BindToDevice, FreeBind and
+// Transparent.
+type routeSockopts struct {
+	mark        uint32
+	device      string
+	freeBind    bool
+	transparent bool
+}
+
+func (ro routeSockopts) any() bool {
+	return ro.mark != 0 || ro.device != "" || ro.freeBind || ro.transparent
+}
+
+// set sets the options on the socket s of the given network, which
+// ends in "4" or "6" as networks passed to Control functions do.
+func (ro routeSockopts) set(s uintptr, network string) error {
+	family := syscall.AF_INET
+	if network[len(network)-1] == '6' {
+		family = syscall.AF_INET6
+	}
+	if ro.mark != 0 {
+		if err := setMark(s, ro.mark); err != nil {
+			return err
+		}
+	}
+	if ro.device != "" {
+		if err := setBindToDevice(s, family, ro.device); err != nil {
+			return err
+		}
+	}
+	if ro.freeBind {
+		if err := setFreeBind(s, family); err != nil {
+			return err
+		}
+	}
+	if ro.transparent {
+		if err := setTransparent(s, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errLinkNotSupported is returned for link-layer sockets on systems
+// where package net does not implement them.
+var errLinkNotSupported = errors.New("link-layer sockets not supported")
+
+// LinkAddr represents the link-layer address of a frame.
+type LinkAddr struct {
+	Index    int          // interface index
+	Protocol uint16       // EtherType of the frame
+	Addr     HardwareAddr // hardware address of the end point
+}
+
+// Network returns the address's network name, "link".
+func (a *LinkAddr) Network() string { return "link" }
+
+func (a *LinkAddr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	s := a.Addr.String()
+	if a.Index != 0 {
+		s += "%" + zoneCache.name(a.Index)
+	}
+	return s
+}
+
+func (a *LinkAddr) opAddr() Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+// LinkConfig contains options for listening on a network interface
+// at the link layer.
+type LinkConfig struct {
+	// Protocol is the EtherType of the frames to receive.
+	// If zero, frames of all protocols are received.
+	Protocol uint16
+
+	// Promiscuous puts the interface in promiscuous mode for as
+	// long as the connection is open, so that frames not addressed
+	// to the interface are received too.
+	Promiscuous bool
+
+	// RingSize, if positive, is the number of bytes the system
+	// buffers for received frames.
+	//
+	// On Linux, it asks for a memory-mapped receive ring
+	// (TPACKET_V3), which lets frames be read without a system
+	// call each. The ring is made of blocks that the kernel hands
+	// over when they are full or after a few milliseconds, so
+	// frames may be delivered in bursts. On BSD systems, it sets
+	// the size of the BPF buffer.
+	RingSize int
+}
+
+// LinkConn is the implementation of the PacketConn interface for
+// link-layer sockets: AF_PACKET sockets on Linux and BPF devices on
+// BSD systems and macOS.
+//
+// Frames are read and written whole, including their link-layer
+// header.
+type LinkConn struct {
+	conn
+	link linkState
+}
+
+// Listen opens a link-layer connection on the interface ifi.
+func (lc *LinkConfig) Listen(ifi *Interface) (*LinkConn, error) {
+	if ifi == nil {
+		return nil, &OpError{Op: "listen", Net: "link", Source: nil, Addr: nil, Err: errMissingAddress}
+	}
+	c, err := listenLink(ifi, lc)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: "link", Source: nil, Addr: &LinkAddr{Index: ifi.Index, Protocol: lc.Protocol, Addr: ifi.HardwareAddr}, Err: err}
+	}
+	return c, nil
+}
+
+// ListenLink opens a link-layer connection on the interface ifi that
+// receives the frames of the given EtherType, or of all protocols
+// if protocol is zero.
+//
+// ListenLink usually needs elevated privileges, such as
+// CAP_NET_RAW on Linux or access to /dev/bpf* on BSD systems.
+func ListenLink(ifi *Interface, protocol uint16) (*LinkConn, error) {
+	lc := &LinkConfig{Protocol: protocol}
+	return lc.Listen(ifi)
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+func (c *LinkConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd)
+}
+
+// Read reads the next frame from c into b.
+func (c *LinkConn) Read(b []byte) (int, error) {
+	n, _, err := c.ReadFromLink(b)
+	return n, err
+}
+
+// ReadFromLink acts like ReadFrom but returns a LinkAddr. The
+// address is that of the sender, on the interface the frame was
+// received on.
+//
+// If b is too short for the frame, the rest of it is discarded.
+func (c *LinkConn) ReadFromLink(b []byte) (int, *LinkAddr, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err := c.readFrom(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, addr, err
+}
+
+// ReadFrom implements the PacketConn ReadFrom method.
+func (c *LinkConn) ReadFrom(b []byte) (int, Addr, error) {
+	n, addr, err := c.ReadFromLink(b)
+	if addr == nil {
+		return n, nil, err
+	}
+	return n, addr, err
+}
+
+// Write writes the frame b on the interface of c.
+func (c *LinkConn) Write(b []byte) (int, error) {
+	return c.WriteToLink(b, nil)
+}
+
+// WriteToLink acts like WriteTo but takes a LinkAddr. The frame is
+// sent on the interface with index addr.Index, or on the interface
+// of c if addr is nil or addr.Index is zero; the destination is that
+// of the link-layer header of b.
+func (c *LinkConn) WriteToLink(b []byte, addr *LinkAddr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeTo(b, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// WriteTo implements the PacketConn WriteTo method.
+func (c *LinkConn) WriteTo(b []byte, addr Addr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	a, ok := addr.(*LinkAddr)
+	if !ok {
+		return 0, &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr, Err: syscall.EINVAL}
+	}
+	return c.WriteToLink(b, a)
+}
+
+// Close closes the connection.
+func (c *LinkConn) Close() error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	err := c.fd.Close()
+	c.closeLink()
+	if err != nil {
+		err = &OpError{Op: "close", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return err
+}
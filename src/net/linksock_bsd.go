@@ -0,0 +1,170 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package net
+
+import (
+	"internal/itoa"
+	"internal/poll"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// linkState is the state of a LinkConn that is specific to BPF
+// devices.
+type linkState struct {
+	index int // index of the interface the device is attached to
+
+	mu   sync.Mutex // serializes reads
+	buf  []byte     // buffer of the size BPF reads need
+	data []byte     // frames in buf that are not read yet
+}
+
+// openBPF opens a free BPF device.
+func openBPF() (int, error) {
+	// Systems with a cloning /dev/bpf hand out a new device for
+	// each open; the others have a fixed set of /dev/bpfN.
+	s, err := syscall.Open("/dev/bpf", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err == nil {
+		return s, nil
+	}
+	for i := 0; ; i++ {
+		s, err = syscall.Open("/dev/bpf"+itoa.Itoa(i), syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+		if err != syscall.EBUSY {
+			break
+		}
+	}
+	if err != nil {
+		return -1, os.NewSyscallError("open", err)
+	}
+	return s, nil
+}
+
+func listenLink(ifi *Interface, lc *LinkConfig) (*LinkConn, error) {
+	s, err := openBPF()
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(s, true); err != nil {
+		poll.CloseFunc(s)
+		return nil, os.NewSyscallError("setnonblock", err)
+	}
+	fd, err := newFD(s, syscall.AF_UNSPEC, syscall.SOCK_RAW, "link")
+	if err != nil {
+		poll.CloseFunc(s)
+		return nil, err
+	}
+	c := &LinkConn{conn: conn{fd}}
+	c.link.index = ifi.Index
+	if err := c.link.listen(s, ifi, lc); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	if err := fd.init(); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	fd.setAddr(&LinkAddr{Index: ifi.Index, Protocol: lc.Protocol, Addr: ifi.HardwareAddr}, nil)
+	return c, nil
+}
+
+// listen attaches the BPF device s to ifi and sets it up to pass
+// frames on as soon as they arrive.
+func (l *linkState) listen(s int, ifi *Interface, lc *LinkConfig) error {
+	// The buffer size must be set before the device is
+	// attached.
+	if lc.RingSize > 0 {
+		if _, err := syscall.SetBpfBuflen(s, lc.RingSize); err != nil {
+			return os.NewSyscallError("ioctl", err)
+		}
+	}
+	if err := syscall.SetBpfInterface(s, ifi.Name); err != nil {
+		return os.NewSyscallError("ioctl", err)
+	}
+	if err := syscall.SetBpfImmediate(s, 1); err != nil {
+		return os.NewSyscallError("ioctl", err)
+	}
+	// Send frames with the source address they have, as AF_PACKET
+	// sockets do, instead of having the system fill it in.
+	one := 1
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(s), syscall.BIOCSHDRCMPLT, uintptr(unsafe.Pointer(&one))); e != 0 {
+		return os.NewSyscallError("ioctl", e)
+	}
+	if lc.Promiscuous {
+		if err := syscall.SetBpfPromisc(s, 1); err != nil {
+			return os.NewSyscallError("ioctl", err)
+		}
+	}
+	if lc.Protocol != 0 {
+		// Accept the frames whose EtherType is lc.Protocol
+		// and drop the others.
+		prog := []syscall.BpfInsn{
+			{Code: syscall.BPF_LD | syscall.BPF_H | syscall.BPF_ABS, K: 12},
+			{Code: syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K, Jt: 0, Jf: 1, K: uint32(lc.Protocol)},
+			{Code: syscall.BPF_RET | syscall.BPF_K, K: 1<<32 - 1},
+			{Code: syscall.BPF_RET | syscall.BPF_K, K: 0},
+		}
+		if err := syscall.SetBpf(s, prog); err != nil {
+			return os.NewSyscallError("ioctl", err)
+		}
+	}
+	n, err := syscall.BpfBuflen(s)
+	if err != nil {
+		return os.NewSyscallError("ioctl", err)
+	}
+	l.buf = make([]byte, n)
+	return nil
+}
+
+func (c *LinkConn) readFrom(b []byte) (int, *LinkAddr, error) {
+	l := &c.link
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// A read from a BPF device returns all the frames in its
+	// buffer, each behind a struct bpf_hdr.
+	for len(l.data) == 0 {
+		n, err := c.fd.Read(l.buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		l.data = l.buf[:n]
+	}
+	hdr := (*syscall.BpfHdr)(unsafe.Pointer(&l.data[0]))
+	start := int(hdr.Hdrlen)
+	end := start + int(hdr.Caplen)
+	if end > len(l.data) {
+		end = len(l.data)
+	}
+	frame := l.data[start:end]
+	if next := (end + syscall.BPF_ALIGNMENT - 1) &^ (syscall.BPF_ALIGNMENT - 1); next < len(l.data) {
+		l.data = l.data[next:]
+	} else {
+		l.data = nil
+	}
+
+	n := copy(b, frame)
+	addr := &LinkAddr{Index: l.index}
+	if len(frame) >= 14 {
+		// Ethernet header.
+		addr.Addr = append(HardwareAddr(nil), frame[6:12]...)
+		addr.Protocol = uint16(frame[12])<<8 | uint16(frame[13])
+	}
+	return n, addr, nil
+}
+
+func (c *LinkConn) writeTo(b []byte, addr *LinkAddr) (int, error) {
+	// A BPF device can only send on the interface it is attached
+	// to.
+	if addr != nil && addr.Index != 0 && addr.Index != c.link.index {
+		return 0, syscall.EINVAL
+	}
+	return c.fd.Write(b)
+}
+
+func (c *LinkConn) closeLink() {}
@@ -0,0 +1,277 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/poll"
+	"internal/syscall/unix"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysPACKET_VERSION = 0xa
+	sysTPACKET_V3     = 0x2
+
+	sysTP_STATUS_KERNEL = 0x0
+	sysTP_STATUS_USER   = 0x1
+
+	// sysTPACKET3_HDRLEN is the aligned size of struct
+	// tpacket3_hdr, which the struct sockaddr_ll of a frame in
+	// the receive ring follows.
+	sysTPACKET3_HDRLEN = 0x30
+
+	// linkRingBlockSize is the size of the blocks of the receive
+	// ring when there is room for at least two of them.
+	linkRingBlockSize = 1 << 20
+
+	// linkRingFrameSize is the frame size given to the kernel,
+	// which TPACKET_V3 only uses to check the ring's geometry.
+	linkRingFrameSize = 1 << 11
+)
+
+// sysTPacketReq3 is struct tpacket_req3.
+type sysTPacketReq3 struct {
+	BlockSize      uint32
+	BlockNr        uint32
+	FrameSize      uint32
+	FrameNr        uint32
+	RetireBlkTOV   uint32
+	SizeofPriv     uint32
+	FeatureReqWord uint32
+}
+
+// sysPacketMreq is struct packet_mreq.
+type sysPacketMreq struct {
+	Ifindex int32
+	Type    uint16
+	Alen    uint16
+	Address [8]byte
+}
+
+// linkState is the state of a LinkConn that is specific to AF_PACKET
+// sockets.
+type linkState struct {
+	proto uint16 // protocol the socket is bound to
+
+	mu        sync.Mutex // serializes reads from ring
+	ring      []byte     // mapped TPACKET_V3 receive ring, or nil
+	blockSize int
+	block     int // index of the block being read
+	pkts      int // number of frames left in the block
+	off       int // offset in the block of the next frame
+}
+
+// htons converts v between host and network byte order.
+func htons(v uint16) uint16 {
+	b := [2]byte{byte(v >> 8), byte(v)}
+	return *(*uint16)(unsafe.Pointer(&b[0]))
+}
+
+func listenLink(ifi *Interface, lc *LinkConfig) (*LinkConn, error) {
+	proto := lc.Protocol
+	if proto == 0 {
+		proto = syscall.ETH_P_ALL
+	}
+	s, err := sysSocket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(proto)))
+	if err != nil {
+		return nil, err
+	}
+	fd, err := newFD(s, syscall.AF_PACKET, syscall.SOCK_RAW, "link")
+	if err != nil {
+		poll.CloseFunc(s)
+		return nil, err
+	}
+	c := &LinkConn{conn: conn{fd}}
+	c.link.proto = proto
+	if err := c.link.listen(s, ifi, lc); err != nil {
+		fd.Close()
+		c.closeLink()
+		return nil, err
+	}
+	if err := fd.init(); err != nil {
+		fd.Close()
+		c.closeLink()
+		return nil, err
+	}
+	fd.setAddr(&LinkAddr{Index: ifi.Index, Protocol: lc.Protocol, Addr: ifi.HardwareAddr}, nil)
+	return c, nil
+}
+
+// listen sets up the receive ring, if any, of the socket s and binds
+// it to ifi.
+func (l *linkState) listen(s int, ifi *Interface, lc *LinkConfig) error {
+	if lc.RingSize > 0 {
+		if err := l.mapRing(s, lc.RingSize); err != nil {
+			return err
+		}
+	}
+	sa := &syscall.SockaddrLinklayer{Protocol: htons(l.proto), Ifindex: ifi.Index}
+	if err := syscall.Bind(s, sa); err != nil {
+		return os.NewSyscallError("bind", err)
+	}
+	if lc.Promiscuous {
+		mreq := sysPacketMreq{Ifindex: int32(ifi.Index), Type: syscall.PACKET_MR_PROMISC}
+		if err := unix.Setsockopt(s, syscall.SOL_PACKET, syscall.PACKET_ADD_MEMBERSHIP, unsafe.Pointer(&mreq), unsafe.Sizeof(mreq)); err != nil {
+			return os.NewSyscallError("setsockopt", err)
+		}
+	}
+	return nil
+}
+
+// mapRing sets up a TPACKET_V3 receive ring of about size bytes on
+// the socket s and maps it.
+func (l *linkState) mapRing(s, size int) error {
+	pageSize := syscall.Getpagesize()
+	blockSize, blocks := linkRingBlockSize, size/linkRingBlockSize
+	if blocks < 2 {
+		blockSize = (size/2 + pageSize - 1) &^ (pageSize - 1)
+		if blockSize < linkRingFrameSize {
+			blockSize = (linkRingFrameSize + pageSize - 1) &^ (pageSize - 1)
+		}
+		blocks = 2
+	}
+	if err := syscall.SetsockoptInt(s, syscall.SOL_PACKET, sysPACKET_VERSION, sysTPACKET_V3); err != nil {
+		return os.NewSyscallError("setsockopt", err)
+	}
+	req := sysTPacketReq3{
+		BlockSize: uint32(blockSize),
+		BlockNr:   uint32(blocks),
+		FrameSize: linkRingFrameSize,
+		FrameNr:   uint32(blockSize / linkRingFrameSize * blocks),
+	}
+	if err := unix.Setsockopt(s, syscall.SOL_PACKET, syscall.PACKET_RX_RING, unsafe.Pointer(&req), unsafe.Sizeof(req)); err != nil {
+		return os.NewSyscallError("setsockopt", err)
+	}
+	ring, err := syscall.Mmap(s, 0, blockSize*blocks, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	l.ring = ring
+	l.blockSize = blockSize
+	return nil
+}
+
+func (c *LinkConn) readFrom(b []byte) (int, *LinkAddr, error) {
+	l := &c.link
+	l.mu.Lock()
+	if l.ring == nil {
+		l.mu.Unlock()
+		n, sa, err := c.fd.readFrom(b)
+		if err != nil {
+			return n, nil, err
+		}
+		sll, ok := sa.(*syscall.SockaddrLinklayer)
+		if !ok {
+			return n, nil, nil
+		}
+		halen := int(sll.Halen)
+		if halen > len(sll.Addr) {
+			halen = len(sll.Addr)
+		}
+		addr := &LinkAddr{Index: sll.Ifindex, Protocol: htons(sll.Protocol), Addr: append(HardwareAddr(nil), sll.Addr[:halen]...)}
+		return n, addr, nil
+	}
+	defer l.mu.Unlock()
+	var (
+		n    int
+		addr *LinkAddr
+	)
+	err := c.fd.pfd.RawRead(func(uintptr) bool {
+		var ok bool
+		n, addr, ok = l.readRing(b)
+		return ok
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, addr, nil
+}
+
+// readRing copies the next frame of the receive ring into b. It
+// reports false if the kernel has not handed over a block yet.
+func (l *linkState) readRing(b []byte) (int, *LinkAddr, bool) {
+	for {
+		blk := l.ring[l.block*l.blockSize : (l.block+1)*l.blockSize]
+		// struct tpacket_block_desc, whose struct tpacket_hdr_v1
+		// starts with block_status, num_pkts and
+		// offset_to_first_pkt.
+		status := (*uint32)(unsafe.Pointer(&blk[8]))
+		if l.pkts == 0 {
+			if atomic.LoadUint32(status)&sysTP_STATUS_USER == 0 {
+				return 0, nil, false
+			}
+			l.pkts = int(*(*uint32)(unsafe.Pointer(&blk[12])))
+			l.off = int(*(*uint32)(unsafe.Pointer(&blk[16])))
+			if l.pkts == 0 {
+				l.releaseBlock(status)
+				continue
+			}
+		}
+
+		// struct tpacket3_hdr, followed by struct sockaddr_ll.
+		hdr := blk[l.off:]
+		next := *(*uint32)(unsafe.Pointer(&hdr[0]))
+		snaplen := int(*(*uint32)(unsafe.Pointer(&hdr[12])))
+		mac := int(*(*uint16)(unsafe.Pointer(&hdr[24])))
+		n := copy(b, hdr[mac:mac+snaplen])
+		sll := hdr[sysTPACKET3_HDRLEN:]
+		halen := int(sll[11])
+		if halen > 8 {
+			halen = 8
+		}
+		addr := &LinkAddr{
+			Index:    int(*(*int32)(unsafe.Pointer(&sll[4]))),
+			Protocol: uint16(sll[2])<<8 | uint16(sll[3]),
+			Addr:     append(HardwareAddr(nil), sll[12:12+halen]...),
+		}
+
+		l.pkts--
+		if l.pkts == 0 {
+			l.releaseBlock(status)
+		} else {
+			l.off += int(next)
+		}
+		return n, addr, true
+	}
+}
+
+// releaseBlock gives the current block back to the kernel and moves
+// on to the next one.
+func (l *linkState) releaseBlock(status *uint32) {
+	atomic.StoreUint32(status, sysTP_STATUS_KERNEL)
+	l.pkts = 0
+	l.block = (l.block + 1) % (len(l.ring) / l.blockSize)
+}
+
+func (c *LinkConn) writeTo(b []byte, addr *LinkAddr) (int, error) {
+	if addr == nil || addr.Index == 0 {
+		return c.fd.Write(b)
+	}
+	proto := addr.Protocol
+	if proto == 0 {
+		proto = c.link.proto
+	}
+	sa := &syscall.SockaddrLinklayer{Protocol: htons(proto), Ifindex: addr.Index}
+	if len(addr.Addr) > len(sa.Addr) {
+		return 0, syscall.EINVAL
+	}
+	sa.Halen = uint8(copy(sa.Addr[:], addr.Addr))
+	return c.fd.writeTo(b, sa)
+}
+
+// closeLink releases the receive ring after the socket is closed.
+func (c *LinkConn) closeLink() {
+	l := &c.link
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ring != nil {
+		syscall.Munmap(l.ring)
+		l.ring = nil
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// linkTestProtocol is the EtherType reserved for local
+// experiments by IEEE 802.
+const linkTestProtocol = 0x88b5
+
+func TestLinkConn(t *testing.T) {
+	lo := loopbackInterface()
+	if lo == nil {
+		t.Skip("no loopback interface")
+	}
+
+	for _, lc := range []LinkConfig{
+		{Protocol: linkTestProtocol},
+		{Protocol: linkTestProtocol, RingSize: 1 << 16},
+	} {
+		rx, err := lc.Listen(lo)
+		if errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EAFNOSUPPORT) {
+			t.Skipf("link-layer sockets not available: %v", err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rx.Close()
+		tx, err := ListenLink(lo, linkTestProtocol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Close()
+
+		frame := make([]byte, 14, 64)
+		frame[12], frame[13] = linkTestProtocol>>8, linkTestProtocol&0xff
+		frame = append(frame, "HELLO, WORLD"...)
+		if _, err := tx.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+
+		rx.SetReadDeadline(time.Now().Add(5 * time.Second))
+		b := make([]byte, 128)
+		n, addr, err := rx.ReadFromLink(b)
+		if err != nil {
+			t.Fatalf("RingSize %d: %v", lc.RingSize, err)
+		}
+		if !bytes.Equal(b[:n], frame) {
+			t.Errorf("RingSize %d: got frame %x; want %x", lc.RingSize, b[:n], frame)
+		}
+		if addr.Index != lo.Index || addr.Protocol != linkTestProtocol {
+			t.Errorf("RingSize %d: got address %+v; want index %d and protocol %#x", lc.RingSize, addr, lo.Index, linkTestProtocol)
+		}
+
+		rx.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		if _, _, err := rx.ReadFromLink(b); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("RingSize %d: got %v; want deadline exceeded", lc.RingSize, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package net
+
+type linkState struct{}
+
+func listenLink(ifi *Interface, lc *LinkConfig) (*LinkConn, error) {
+	return nil, errLinkNotSupported
+}
+
+func (c *LinkConn) readFrom(b []byte) (int, *LinkAddr, error) {
+	return 0, nil, errLinkNotSupported
+}
+
+func (c *LinkConn) writeTo(b []byte, addr *LinkAddr) (int, error) {
+	return 0, errLinkNotSupported
+}
+
+func (c *LinkConn) closeLink() {}
@@ -9,6 +9,7 @@ package net
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"internal/testenv"
 	"os"
@@ -787,3 +788,148 @@ func TestListenConfigControl(t *testing.T) {
 		}
 	})
 }
+
+func TestListenConfigConnControl(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	for _, network := range []string{"tcp", "tcp4", "tcp6", "unix", "unixpacket"} {
+		if !testableNetwork(network) {
+			continue
+		}
+		ln, err := newLocalListener(network)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		address := ln.Addr().String()
+		ln.Close()
+		var calls int
+		var raddr string
+		lc := ListenConfig{ConnControl: func(network, address string, c syscall.RawConn) error {
+			calls++
+			raddr = address
+			return controlOnConnSetup(network, address, c)
+		}}
+		ln, err = lc.Listen(context.Background(), network, address)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		c, err := Dial(network, ln.Addr().String())
+		if err != nil {
+			ln.Close()
+			t.Error(err)
+			continue
+		}
+		ac, err := ln.Accept()
+		if err != nil {
+			t.Errorf("%s: %v", network, err)
+		} else {
+			if calls != 1 {
+				t.Errorf("%s: ConnControl called %d times; want 1", network, calls)
+			}
+			var want string
+			if a := ac.RemoteAddr(); a != nil {
+				want = a.String()
+			}
+			if raddr != want {
+				t.Errorf("%s: ConnControl got address %q; want %q", network, raddr, want)
+			}
+			ac.Close()
+		}
+		c.Close()
+		ln.Close()
+	}
+
+	// A failing ConnControl makes Accept fail and closes the
+	// connection.
+	errConnControl := errors.New("rejected")
+	lc := ListenConfig{ConnControl: func(network, address string, c syscall.RawConn) error {
+		return errConnControl
+	}}
+	ln, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := ln.Accept(); !errors.Is(err, errConnControl) {
+		t.Errorf("Accept: %v; want %v", err, errConnControl)
+	}
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.Read(make([]byte, 1)); err == nil {
+		t.Error("read from connection closed by ConnControl succeeded")
+	}
+}
+
+func TestListenConfigReusePort(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9", "solaris", "illumos", "windows":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	lc := ListenConfig{ReusePort: true}
+	t.Run("StreamListen", func(t *testing.T) {
+		for _, tt := range []struct{ network, address string }{{"tcp4", "127.0.0.1:0"}, {"tcp6", "[::1]:0"}} {
+			if !testableNetwork(tt.network) {
+				continue
+			}
+			ln1, err := lc.Listen(context.Background(), tt.network, tt.address)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			defer ln1.Close()
+			ln2, err := lc.Listen(context.Background(), tt.network, ln1.Addr().String())
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			ln2.Close()
+		}
+	})
+	t.Run("PacketListen", func(t *testing.T) {
+		for _, tt := range []struct{ network, address string }{{"udp4", "127.0.0.1:0"}, {"udp6", "[::1]:0"}} {
+			if !testableNetwork(tt.network) {
+				continue
+			}
+			c1, err := lc.ListenPacket(context.Background(), tt.network, tt.address)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			defer c1.Close()
+			c2, err := lc.ListenPacket(context.Background(), tt.network, c1.LocalAddr().String())
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			c2.Close()
+		}
+	})
+	t.Run("Control", func(t *testing.T) {
+		called := false
+		lc := ListenConfig{
+			ReusePort: true,
+			Control: func(network, address string, c syscall.RawConn) error {
+				called = true
+				return nil
+			},
+		}
+		ln, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.Close()
+		if !called {
+			t.Error("Control was not called")
+		}
+	})
+}
@@ -83,6 +83,8 @@ func lookupPortMap(network, service string) (port int, error error) {
 		network = "tcp"
 	case "udp4", "udp6":
 		network = "udp"
+	case "sctp4", "sctp6":
+		network = "sctp"
 	}
 
 	if m, ok := services[network]; ok {
@@ -145,6 +147,20 @@ type Resolver struct {
 	// If nil, the default dialer is used.
 	Dial func(ctx context.Context, network, address string) (Conn, error)
 
+	// Encrypted optionally specifies an upstream server to which
+	// Go's built-in DNS resolver sends all queries over DNS over
+	// TLS or DNS over HTTPS, in place of the name servers of the
+	// system configuration. Setting it implies PreferGo. The
+	// hosts file is still consulted first, as configured by the
+	// system. If Dial is set, it makes the TCP connections to
+	// the server.
+	Encrypted *EncryptedDNS
+
+	// Cache optionally specifies a cache for the answers of Go's
+	// built-in DNS resolver. Lookups answered by it send no
+	// queries to name servers. Setting it does not imply PreferGo.
+	Cache *DNSCache
+
 	// lookupGroup merges LookupIPAddr calls together for lookups for the same
 	// host. The lookupGroup key is the LookupIPAddr.host argument.
 	// The return values are ([]IPAddr, error).
@@ -154,7 +170,7 @@ type Resolver struct {
 	// TODO(bradfitz): Timeout time.Duration?
 }
 
-func (r *Resolver) preferGo() bool     { return r != nil && r.PreferGo }
+func (r *Resolver) preferGo() bool     { return r != nil && (r.PreferGo || r.Encrypted != nil) }
 func (r *Resolver) strictErrors() bool { return r != nil && r.StrictErrors }
 
 func (r *Resolver) getLookupGroup() *singleflight.Group {
@@ -368,7 +384,7 @@ func (r *Resolver) LookupPort(ctx context.Context, network, service string) (por
 	port, needsLookup := parsePort(service)
 	if needsLookup {
 		switch network {
-		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "sctp", "sctp4", "sctp6":
 		case "": // a hint wildcard for Go 1.0 undocumented behavior
 			network = "ip"
 		default:
@@ -580,6 +596,88 @@ func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error)
 	return r.lookupTXT(ctx, name)
 }
 
+// LookupSVCB returns the DNS SVCB records for the given domain name,
+// sorted by priority.
+//
+// Alias records are returned as is; LookupSVCB does not follow them.
+// The returned target names are validated to be properly formatted
+// presentation-format domain names. If the response contains invalid
+// names, those records are filtered out and an error will be returned
+// alongside the remaining results, if any.
+//
+// LookupSVCB uses context.Background internally; to specify the context, use
+// Resolver.LookupSVCB.
+func LookupSVCB(name string) ([]*SVCB, error) {
+	return DefaultResolver.LookupSVCB(context.Background(), name)
+}
+
+// LookupSVCB returns the DNS SVCB records for the given domain name,
+// sorted by priority.
+//
+// Alias records are returned as is; LookupSVCB does not follow them.
+// The returned target names are validated to be properly formatted
+// presentation-format domain names. If the response contains invalid
+// names, those records are filtered out and an error will be returned
+// alongside the remaining results, if any.
+func (r *Resolver) LookupSVCB(ctx context.Context, name string) ([]*SVCB, error) {
+	recs, err := r.lookupSVCB(ctx, name, dnsTypeSVCB)
+	return filterSVCB(name, recs, err)
+}
+
+// LookupHTTPS returns the DNS HTTPS records for the given domain name,
+// sorted by priority. HTTPS records advertise the ALPN protocols,
+// alternative endpoints, address hints and Encrypted Client Hello
+// configuration of an HTTPS origin.
+//
+// Alias records are returned as is; LookupHTTPS does not follow them.
+// The returned target names are validated to be properly formatted
+// presentation-format domain names. If the response contains invalid
+// names, those records are filtered out and an error will be returned
+// alongside the remaining results, if any.
+//
+// LookupHTTPS uses context.Background internally; to specify the context, use
+// Resolver.LookupHTTPS.
+func LookupHTTPS(name string) ([]*SVCB, error) {
+	return DefaultResolver.LookupHTTPS(context.Background(), name)
+}
+
+// LookupHTTPS returns the DNS HTTPS records for the given domain name,
+// sorted by priority. HTTPS records advertise the ALPN protocols,
+// alternative endpoints, address hints and Encrypted Client Hello
+// configuration of an HTTPS origin.
+//
+// Alias records are returned as is; LookupHTTPS does not follow them.
+// The returned target names are validated to be properly formatted
+// presentation-format domain names. If the response contains invalid
+// names, those records are filtered out and an error will be returned
+// alongside the remaining results, if any.
+func (r *Resolver) LookupHTTPS(ctx context.Context, name string) ([]*SVCB, error) {
+	recs, err := r.lookupSVCB(ctx, name, dnsTypeHTTPS)
+	return filterSVCB(name, recs, err)
+}
+
+// filterSVCB removes the records with invalid target names from the
+// result of lookupSVCB.
+func filterSVCB(name string, recs []*SVCB, err error) ([]*SVCB, error) {
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]*SVCB, 0, len(recs))
+	for _, rec := range recs {
+		if rec == nil {
+			continue
+		}
+		if rec.Target != "." && !isDomainName(rec.Target) {
+			continue
+		}
+		filtered = append(filtered, rec)
+	}
+	if len(recs) != len(filtered) {
+		return filtered, &DNSError{Err: errMalformedDNSRecordsDetail, Name: name}
+	}
+	return filtered, nil
+}
+
 // LookupAddr performs a reverse lookup for the given address, returning a list
 // of names mapping to that address.
 //
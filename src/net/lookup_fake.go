@@ -10,6 +10,8 @@ package net
 import (
 	"context"
 	"syscall"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func lookupProtocol(ctx context.Context, name string) (proto int, err error) {
@@ -48,6 +50,10 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) (txts []string, err
 	return nil, syscall.ENOPROTOOPT
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, qtype dnsmessage.Type) ([]*SVCB, error) {
+	return nil, syscall.ENOPROTOOPT
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) (ptrs []string, err error) {
 	return nil, syscall.ENOPROTOOPT
 }
@@ -11,6 +11,9 @@ import (
 	"internal/itoa"
 	"io"
 	"os"
+	"syscall"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func query(ctx context.Context, filename, query string, bufSize int) (addrs []string, err error) {
@@ -315,6 +318,10 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) (txt []string, err
 	return
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, qtype dnsmessage.Type) ([]*SVCB, error) {
+	return nil, &DNSError{Err: syscall.EPLAN9.Error(), Name: name}
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) (name []string, err error) {
 	arpa, err := reverseaddr(addr)
 	if err != nil {
@@ -265,6 +265,56 @@ func (r *Resolver) lookupNS(ctx context.Context, name string) ([]*NS, error) {
 	return nss, nil
 }
 
+func (r *Resolver) lookupSVCB(ctx context.Context, name string, qtype dnsmessage.Type) ([]*SVCB, error) {
+	p, server, err := r.lookup(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	var recs []*SVCB
+	for {
+		h, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, &DNSError{
+				Err:    "cannot unmarshal DNS message",
+				Name:   name,
+				Server: server,
+			}
+		}
+		if h.Type != qtype {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, &DNSError{
+					Err:    "cannot unmarshal DNS message",
+					Name:   name,
+					Server: server,
+				}
+			}
+			continue
+		}
+		res, err := p.UnknownResource()
+		if err != nil {
+			return nil, &DNSError{
+				Err:    "cannot unmarshal DNS message",
+				Name:   name,
+				Server: server,
+			}
+		}
+		rec, err := parseSVCB(res.Data)
+		if err != nil {
+			return nil, &DNSError{
+				Err:    err.Error(),
+				Name:   name,
+				Server: server,
+			}
+		}
+		recs = append(recs, rec)
+	}
+	byPriority(recs).sort()
+	return recs, nil
+}
+
 func (r *Resolver) lookupTXT(ctx context.Context, name string) ([]string, error) {
 	p, server, err := r.lookup(ctx, name, dnsmessage.TypeTXT)
 	if err != nil {
@@ -11,6 +11,8 @@ import (
 	"runtime"
 	"syscall"
 	"unsafe"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 const _WSAHOST_NOT_FOUND = syscall.Errno(11001)
@@ -326,6 +328,10 @@ func (*Resolver) lookupTXT(ctx context.Context, name string) ([]string, error) {
 	return txts, nil
 }
 
+func (*Resolver) lookupSVCB(ctx context.Context, name string, qtype dnsmessage.Type) ([]*SVCB, error) {
+	return nil, &DNSError{Err: syscall.EWINDOWS.Error(), Name: name}
+}
+
 func (*Resolver) lookupAddr(ctx context.Context, addr string) ([]string, error) {
 	// TODO(bradfitz): finish ctx plumbing. Nothing currently depends on this.
 	acquireThread()
@@ -0,0 +1,135 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "syscall"
+
+// MultipathTCPInfo holds the state that the operating system keeps
+// about a Multipath TCP connection. Fields that the operating system
+// does not report are zero.
+type MultipathTCPInfo struct {
+	// Token identifies the connection to the path manager of the
+	// operating system.
+	Token uint32
+
+	// Subflows is the number of subflows besides the initial one,
+	// and MaxSubflows the most the path manager opens.
+	Subflows    int
+	MaxSubflows int
+
+	// AddrsSignaled is the number of local addresses announced to
+	// the peer, and AddrsAccepted the number of addresses
+	// announced by the peer that were accepted.
+	AddrsSignaled int
+	AddrsAccepted int
+
+	// Retransmits is the number of data-level retransmissions,
+	// and BytesRetransmitted the number of bytes in them.
+	Retransmits        uint64
+	BytesRetransmitted uint64
+
+	// BytesSent, BytesReceived and BytesAcked count the payload
+	// bytes sent, received and acknowledged by the peer at the
+	// connection level, over all of its subflows.
+	BytesSent     uint64
+	BytesReceived uint64
+	BytesAcked    uint64
+}
+
+// A MultipathSubflow describes one of the TCP subflows that make up a
+// Multipath TCP connection.
+type MultipathSubflow struct {
+	LocalAddr  *TCPAddr
+	RemoteAddr *TCPAddr
+	Info       TCPInfo
+}
+
+// MultipathTCP reports whether the connection uses Multipath TCP. It
+// reports false if the connection was not made with Multipath TCP
+// enabled, or if it fell back to regular TCP because the peer does not
+// support Multipath TCP.
+//
+// On Linux before 5.16, it reports whether the connection was made with
+// Multipath TCP enabled, whether or not it fell back to regular TCP.
+func (c *TCPConn) MultipathTCP() (bool, error) {
+	if !c.ok() {
+		return false, syscall.EINVAL
+	}
+	ok, err := mptcpEnabled(c.fd)
+	if err != nil {
+		return false, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return ok, nil
+}
+
+// MultipathInfo returns the statistics of the operating system about
+// the Multipath TCP connection. It needs Linux 5.16 or later and
+// returns an error on other systems and for connections that do not
+// use Multipath TCP.
+func (c *TCPConn) MultipathInfo() (MultipathTCPInfo, error) {
+	if !c.ok() {
+		return MultipathTCPInfo{}, syscall.EINVAL
+	}
+	info, err := mptcpInfo(c.fd)
+	if err != nil {
+		return MultipathTCPInfo{}, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return info, nil
+}
+
+// MultipathSubflows returns the addresses and statistics of the
+// subflows of the Multipath TCP connection, the initial one first.
+// It needs Linux 5.16 or later and returns an error on other systems
+// and for connections that do not use Multipath TCP.
+func (c *TCPConn) MultipathSubflows() ([]MultipathSubflow, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	sfs, err := mptcpSubflows(c.fd)
+	if err != nil {
+		return nil, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return sfs, nil
+}
+
+// AddMultipathSubflow opens a new subflow of the Multipath TCP
+// connection from the local address laddr to the remote address raddr.
+// If the port of laddr is zero, a port is chosen automatically.
+//
+// On Linux, subflows are normally opened by the path manager of the
+// kernel, following the endpoints configured with "ip mptcp". Adding
+// and removing subflows of a single connection needs Linux 5.19 or
+// later, with the userspace path manager selected by the
+// net.mptcp.pm_type sysctl, and the CAP_NET_ADMIN capability. Other
+// systems return an error.
+func (c *TCPConn) AddMultipathSubflow(laddr, raddr *TCPAddr) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if laddr == nil || raddr == nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: errMissingAddress}
+	}
+	if err := mptcpAddSubflow(c.fd, laddr, raddr); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// RemoveMultipathSubflow closes the subflow of the Multipath TCP
+// connection from the local address laddr to the remote address raddr,
+// as reported by MultipathSubflows. It has the requirements of
+// AddMultipathSubflow.
+func (c *TCPConn) RemoveMultipathSubflow(laddr, raddr *TCPAddr) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if laddr == nil || raddr == nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: errMissingAddress}
+	}
+	if err := mptcpRemoveSubflow(c.fd, laddr, raddr); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
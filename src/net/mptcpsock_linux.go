@@ -0,0 +1,405 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/syscall/unix"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Multipath TCP constants from linux/in.h, linux/socket.h and
+// linux/mptcp.h, which package syscall does not define.
+const (
+	sysIPPROTO_MPTCP = 0x106
+	sysSOL_MPTCP     = 0x11c
+
+	sysMPTCP_INFO          = 0x1
+	sysMPTCP_TCPINFO       = 0x2
+	sysMPTCP_SUBFLOW_ADDRS = 0x3
+)
+
+// Generic netlink constants from linux/genetlink.h, and those of the
+// "mptcp_pm" generic netlink family from linux/mptcp.h.
+const (
+	sysGENL_ID_CTRL          = 0x10
+	sysCTRL_CMD_GETFAMILY    = 0x3
+	sysCTRL_ATTR_FAMILY_ID   = 0x1
+	sysCTRL_ATTR_FAMILY_NAME = 0x2
+	sysNLA_F_NESTED          = 0x8000
+
+	sysMPTCP_PM_VER                 = 0x1
+	sysMPTCP_PM_CMD_SUBFLOW_CREATE  = 0xa
+	sysMPTCP_PM_CMD_SUBFLOW_DESTROY = 0xb
+	sysMPTCP_PM_ATTR_ADDR           = 0x1
+	sysMPTCP_PM_ATTR_TOKEN          = 0x4
+	sysMPTCP_PM_ATTR_ADDR_REMOTE    = 0x6
+	sysMPTCP_PM_ADDR_ATTR_FAMILY    = 0x1
+	sysMPTCP_PM_ADDR_ATTR_ADDR4     = 0x3
+	sysMPTCP_PM_ADDR_ATTR_ADDR6     = 0x4
+	sysMPTCP_PM_ADDR_ATTR_PORT      = 0x5
+	sysMPTCP_PM_ADDR_ATTR_IF_IDX    = 0x7
+)
+
+// sysMPTCPInfo is struct mptcp_info from linux/mptcp.h, up to
+// mptcpi_bytes_acked. Older kernels fill in only a prefix of it.
+type sysMPTCPInfo struct {
+	subflows, addAddrSignal, addAddrAccepted, subflowsMax uint8
+	addAddrSignalMax, addAddrAcceptedMax                  uint8
+
+	flags, token uint32
+
+	writeSeq, sndUna, rcvNxt uint64
+
+	localAddrUsed, localAddrMax, csumEnabled uint8
+
+	retransmits uint32
+
+	bytesRetrans, bytesSent, bytesReceived, bytesAcked uint64
+}
+
+// sysMPTCPSubflowData is struct mptcp_subflow_data from linux/mptcp.h,
+// which precedes the per-subflow elements of MPTCP_TCPINFO and
+// MPTCP_SUBFLOW_ADDRS.
+type sysMPTCPSubflowData struct {
+	sizeSubflowData, numSubflows, sizeKernel, sizeUser uint32
+}
+
+const (
+	sizeofMPTCPSubflowData  = 0x10
+	sizeofMPTCPSubflowAddrs = 0x100 // two sockaddr_storage-sized unions
+)
+
+func (sd *sysDialer) dialMPTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCPConn, error) {
+	c, err := sd.doDialTCPProto(ctx, laddr, raddr, sysIPPROTO_MPTCP)
+	if err == nil || !mptcpUnsupported(err) {
+		return c, err
+	}
+	return sd.doDialTCPProto(ctx, laddr, raddr, 0)
+}
+
+func (sl *sysListener) listenMPTCP(ctx context.Context, laddr *TCPAddr) (*TCPListener, error) {
+	ln, err := sl.listenTCPProto(ctx, laddr, sysIPPROTO_MPTCP)
+	if err == nil || !mptcpUnsupported(err) {
+		return ln, err
+	}
+	return sl.listenTCPProto(ctx, laddr, 0)
+}
+
+// mptcpUnsupported reports whether err tells that no Multipath TCP
+// socket could be made because the kernel does not support Multipath
+// TCP, or has it disabled by the net.mptcp.enabled sysctl.
+func mptcpUnsupported(err error) bool {
+	var se *os.SyscallError
+	if !errors.As(err, &se) || se.Syscall != "socket" {
+		return false
+	}
+	switch se.Err {
+	case syscall.EPROTONOSUPPORT, syscall.EINVAL, syscall.ENOPROTOOPT:
+		return true
+	}
+	return false
+}
+
+func mptcpEnabled(fd *netFD) (bool, error) {
+	var proto int
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		proto, err = syscall.GetsockoptInt(int(s), syscall.SOL_SOCKET, syscall.SO_PROTOCOL)
+	}); cerr != nil {
+		return false, cerr
+	}
+	if err != nil {
+		return false, wrapSyscallError("getsockopt", err)
+	}
+	if proto != sysIPPROTO_MPTCP {
+		return false, nil
+	}
+	if major, minor := kernelVersion(); major < 5 || major == 5 && minor < 16 {
+		// There is no SOL_MPTCP to tell whether the
+		// connection fell back to regular TCP.
+		return true, nil
+	}
+	// A connection that fell back to regular TCP has no MPTCP_INFO.
+	switch _, err := getMPTCPInfo(fd); err {
+	case nil:
+		return true, nil
+	case syscall.EOPNOTSUPP, syscall.ENOPROTOOPT:
+		return false, nil
+	default:
+		return false, wrapSyscallError("getsockopt", err)
+	}
+}
+
+// getMPTCPInfo reads MPTCP_INFO. The error it returns is not wrapped.
+func getMPTCPInfo(fd *netFD) (sysMPTCPInfo, error) {
+	var mi sysMPTCPInfo
+	var err error
+	size := uint32(unsafe.Sizeof(mi))
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		err = unix.Getsockopt(int(s), sysSOL_MPTCP, sysMPTCP_INFO, unsafe.Pointer(&mi), &size)
+	}); cerr != nil {
+		return sysMPTCPInfo{}, cerr
+	}
+	return mi, err
+}
+
+func mptcpInfo(fd *netFD) (MultipathTCPInfo, error) {
+	mi, err := getMPTCPInfo(fd)
+	if err != nil {
+		return MultipathTCPInfo{}, wrapSyscallError("getsockopt", err)
+	}
+	return MultipathTCPInfo{
+		Token:              mi.token,
+		Subflows:           int(mi.subflows),
+		MaxSubflows:        int(mi.subflowsMax),
+		AddrsSignaled:      int(mi.addAddrSignal),
+		AddrsAccepted:      int(mi.addAddrAccepted),
+		Retransmits:        uint64(mi.retransmits),
+		BytesRetransmitted: mi.bytesRetrans,
+		BytesSent:          mi.bytesSent,
+		BytesReceived:      mi.bytesReceived,
+		BytesAcked:         mi.bytesAcked,
+	}, nil
+}
+
+func mptcpSubflows(fd *netFD) ([]MultipathSubflow, error) {
+	var ti sysTCPInfo
+	infos, ninfos, infoSize, err := mptcpSubflowData(fd, sysMPTCP_TCPINFO, uint32(unsafe.Sizeof(ti)))
+	if err != nil {
+		return nil, err
+	}
+	addrs, naddrs, addrSize, err := mptcpSubflowData(fd, sysMPTCP_SUBFLOW_ADDRS, sizeofMPTCPSubflowAddrs)
+	if err != nil {
+		return nil, err
+	}
+	// Subflows may come and go between the two reads; report those
+	// that both have seen.
+	n := ninfos
+	if naddrs < n {
+		n = naddrs
+	}
+	sfs := make([]MultipathSubflow, 0, n)
+	for i := 0; i < n; i++ {
+		ti = sysTCPInfo{}
+		copy((*[unsafe.Sizeof(ti)]byte)(unsafe.Pointer(&ti))[:], infos[i*infoSize:(i+1)*infoSize])
+		a := addrs[i*addrSize : (i+1)*addrSize]
+		if len(a) < sizeofMPTCPSubflowAddrs {
+			return nil, wrapSyscallError("getsockopt", syscall.EINVAL)
+		}
+		sfs = append(sfs, MultipathSubflow{
+			LocalAddr:  rawSockaddrToTCP(a[:sizeofMPTCPSubflowAddrs/2]),
+			RemoteAddr: rawSockaddrToTCP(a[sizeofMPTCPSubflowAddrs/2:]),
+			Info:       ti.info(),
+		})
+	}
+	return sfs, nil
+}
+
+// mptcpSubflowData reads the socket option name of level SOL_MPTCP,
+// which is a struct mptcp_subflow_data followed by an element of
+// elemSize bytes for each subflow. It returns the elements, their
+// number and the size the kernel filled in of each.
+func mptcpSubflowData(fd *netFD, name int, elemSize uint32) ([]byte, int, int, error) {
+	for room := 4; ; room *= 2 {
+		b := make([]byte, sizeofMPTCPSubflowData+room*int(elemSize))
+		h := (*sysMPTCPSubflowData)(unsafe.Pointer(&b[0]))
+		h.sizeSubflowData = sizeofMPTCPSubflowData
+		h.sizeUser = elemSize
+		size := uint32(len(b))
+		var err error
+		if cerr := fd.pfd.RawControl(func(s uintptr) {
+			err = unix.Getsockopt(int(s), sysSOL_MPTCP, name, unsafe.Pointer(&b[0]), &size)
+		}); cerr != nil {
+			return nil, 0, 0, cerr
+		}
+		if err != nil {
+			return nil, 0, 0, wrapSyscallError("getsockopt", err)
+		}
+		if int(h.numSubflows) <= room {
+			if h.sizeUser == 0 || h.sizeUser > elemSize {
+				return nil, 0, 0, wrapSyscallError("getsockopt", syscall.EINVAL)
+			}
+			return b[sizeofMPTCPSubflowData:], int(h.numSubflows), int(h.sizeUser), nil
+		}
+	}
+}
+
+// rawSockaddrToTCP returns the address of the struct sockaddr_in or
+// sockaddr_in6 in b, or nil.
+func rawSockaddrToTCP(b []byte) *TCPAddr {
+	port := int(b[2])<<8 | int(b[3])
+	switch *(*uint16)(unsafe.Pointer(&b[0])) {
+	case syscall.AF_INET:
+		return &TCPAddr{IP: IPv4(b[4], b[5], b[6], b[7]), Port: port}
+	case syscall.AF_INET6:
+		ip := make(IP, IPv6len)
+		copy(ip, b[8:24])
+		scope := *(*uint32)(unsafe.Pointer(&b[24]))
+		return &TCPAddr{IP: ip, Port: port, Zone: zoneCache.name(int(scope))}
+	}
+	return nil
+}
+
+func mptcpAddSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return mptcpChangeSubflow(fd, sysMPTCP_PM_CMD_SUBFLOW_CREATE, laddr, raddr)
+}
+
+func mptcpRemoveSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return mptcpChangeSubflow(fd, sysMPTCP_PM_CMD_SUBFLOW_DESTROY, laddr, raddr)
+}
+
+// mptcpChangeSubflow asks the userspace path manager of the kernel to
+// create or destroy, as told by cmd, the subflow from laddr to raddr of
+// the connection of fd.
+func mptcpChangeSubflow(fd *netFD, cmd uint8, laddr, raddr *TCPAddr) error {
+	mi, err := getMPTCPInfo(fd)
+	if err != nil {
+		return wrapSyscallError("getsockopt", err)
+	}
+	attrs := appendNetlinkAttr(nil, sysMPTCP_PM_ATTR_TOKEN, nativeUint32(mi.token))
+	if attrs, err = appendMPTCPAddr(attrs, sysMPTCP_PM_ATTR_ADDR, laddr); err != nil {
+		return err
+	}
+	if attrs, err = appendMPTCPAddr(attrs, sysMPTCP_PM_ATTR_ADDR_REMOTE, raddr); err != nil {
+		return err
+	}
+	family, err := mptcpPMFamily()
+	if err != nil {
+		return err
+	}
+	_, err = genlRequest(family, cmd, sysMPTCP_PM_VER, attrs)
+	return err
+}
+
+// appendMPTCPAddr appends a, as a nested attribute of type typ of the
+// "mptcp_pm" family, to the netlink attributes b.
+func appendMPTCPAddr(b []byte, typ uint16, a *TCPAddr) ([]byte, error) {
+	var nested []byte
+	if ip4 := a.IP.To4(); ip4 != nil {
+		nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_FAMILY, nativeUint16(syscall.AF_INET))
+		nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_ADDR4, ip4)
+	} else if ip6 := a.IP.To16(); ip6 != nil {
+		nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_FAMILY, nativeUint16(syscall.AF_INET6))
+		nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_ADDR6, ip6)
+	} else {
+		return nil, &AddrError{Err: "non-IP address", Addr: a.String()}
+	}
+	nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_PORT, nativeUint16(uint16(a.Port)))
+	if a.Zone != "" {
+		nested = appendNetlinkAttr(nested, sysMPTCP_PM_ADDR_ATTR_IF_IDX, nativeUint32(uint32(zoneCache.index(a.Zone))))
+	}
+	return appendNetlinkAttr(b, typ|sysNLA_F_NESTED, nested), nil
+}
+
+// mptcpPMFamily returns the ID of the "mptcp_pm" generic netlink
+// family.
+func mptcpPMFamily() (uint16, error) {
+	name := append([]byte("mptcp_pm"), 0)
+	msgs, err := genlRequest(sysGENL_ID_CTRL, sysCTRL_CMD_GETFAMILY, 1, appendNetlinkAttr(nil, sysCTRL_ATTR_FAMILY_NAME, name))
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range msgs {
+		if len(m.Data) < 4 {
+			continue
+		}
+		// Skip the struct genlmsghdr.
+		for b := m.Data[4:]; len(b) >= syscall.SizeofRtAttr; {
+			l := int(*(*uint16)(unsafe.Pointer(&b[0])))
+			typ := *(*uint16)(unsafe.Pointer(&b[2]))
+			if l < syscall.SizeofRtAttr || l > len(b) {
+				break
+			}
+			if typ == sysCTRL_ATTR_FAMILY_ID && l >= syscall.SizeofRtAttr+2 {
+				return *(*uint16)(unsafe.Pointer(&b[syscall.SizeofRtAttr])), nil
+			}
+			if l = (l + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1); l >= len(b) {
+				break
+			}
+			b = b[l:]
+		}
+	}
+	return 0, os.NewSyscallError("netlink", syscall.ENOENT)
+}
+
+// genlRequest sends the command cmd of the given version, with the
+// netlink attributes attrs, to the generic netlink family and returns
+// the messages of the reply, once the kernel acknowledges the request.
+func genlRequest(family uint16, cmd, version uint8, attrs []byte) ([]syscall.NetlinkMessage, error) {
+	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_GENERIC)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	defer syscall.Close(s)
+
+	const sizeofGenlMsghdr = 4
+	b := make([]byte, syscall.NLMSG_HDRLEN+sizeofGenlMsghdr, syscall.NLMSG_HDRLEN+sizeofGenlMsghdr+len(attrs))
+	b = append(b, attrs...)
+	h := (*syscall.NlMsghdr)(unsafe.Pointer(&b[0]))
+	h.Len = uint32(len(b))
+	h.Type = family
+	h.Flags = syscall.NLM_F_REQUEST | syscall.NLM_F_ACK
+	h.Seq = 1
+	b[syscall.NLMSG_HDRLEN] = cmd
+	b[syscall.NLMSG_HDRLEN+1] = version
+	sa := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err := syscall.Sendto(s, b, 0, sa); err != nil {
+		return nil, os.NewSyscallError("sendto", err)
+	}
+
+	var msgs []syscall.NetlinkMessage
+	for {
+		// The messages returned alias rb, so it is not reused.
+		rb := make([]byte, syscall.Getpagesize())
+		n, _, err := syscall.Recvfrom(s, rb, 0)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, os.NewSyscallError("recvfrom", err)
+		}
+		ms, err := syscall.ParseNetlinkMessage(rb[:n])
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkmessage", err)
+		}
+		for _, m := range ms {
+			if m.Header.Type != syscall.NLMSG_ERROR {
+				msgs = append(msgs, m)
+				continue
+			}
+			if len(m.Data) < 4 {
+				return nil, os.NewSyscallError("parsenetlinkmessage", syscall.EINVAL)
+			}
+			if errno := *(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+				return nil, os.NewSyscallError("netlink", syscall.Errno(-errno))
+			}
+			return msgs, nil
+		}
+	}
+}
+
+// appendNetlinkAttr appends a netlink attribute of type typ holding
+// data to b, padded to the alignment of attributes.
+func appendNetlinkAttr(b []byte, typ uint16, data []byte) []byte {
+	b = append(b, nativeUint16(uint16(syscall.SizeofRtAttr+len(data)))...)
+	b = append(b, nativeUint16(typ)...)
+	b = append(b, data...)
+	for len(b)%syscall.RTA_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func nativeUint16(v uint16) []byte {
+	return (*[2]byte)(unsafe.Pointer(&v))[:]
+}
+
+func nativeUint32(v uint32) []byte {
+	return (*[4]byte)(unsafe.Pointer(&v))[:]
+}
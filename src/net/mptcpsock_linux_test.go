@@ -0,0 +1,91 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMultipathTCP(t *testing.T) {
+	if !supportsIPv4() {
+		t.Skip("IPv4 is not supported")
+	}
+	lc := &ListenConfig{MultipathTCP: true}
+	ln, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	done := make(chan *TCPConn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			done <- nil
+			return
+		}
+		done <- c.(*TCPConn)
+	}()
+
+	d := &Dialer{MultipathTCP: true}
+	c, err := d.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	sc := <-done
+	if sc == nil {
+		return
+	}
+	defer sc.Close()
+
+	tc := c.(*TCPConn)
+	ok, err := tc.MultipathTCP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Skip("Multipath TCP is not available")
+	}
+	if ok, err := sc.MultipathTCP(); !ok || err != nil {
+		t.Errorf("accepted connection: MultipathTCP() = %v, %v; want true, nil", ok, err)
+	}
+
+	info, err := tc.MultipathInfo()
+	if err != nil {
+		t.Skipf("MultipathInfo: %v", err)
+	}
+	t.Logf("%+v", info)
+	if info.Token == 0 {
+		t.Error("Token = 0; want non-zero")
+	}
+	sfs, err := tc.MultipathSubflows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sfs) != 1 {
+		t.Fatalf("got %d subflows; want 1", len(sfs))
+	}
+	if la := c.LocalAddr().(*TCPAddr); !sfs[0].LocalAddr.IP.Equal(la.IP) || sfs[0].LocalAddr.Port != la.Port {
+		t.Errorf("subflow LocalAddr = %v; want %v", sfs[0].LocalAddr, la)
+	}
+	if ra := c.RemoteAddr().(*TCPAddr); !sfs[0].RemoteAddr.IP.Equal(ra.IP) || sfs[0].RemoteAddr.Port != ra.Port {
+		t.Errorf("subflow RemoteAddr = %v; want %v", sfs[0].RemoteAddr, ra)
+	}
+	if sfs[0].Info.SendMSS <= 0 {
+		t.Errorf("subflow SendMSS = %d; want > 0", sfs[0].Info.SendMSS)
+	}
+
+	// A regular TCP connection does not use Multipath TCP.
+	c2, err := Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	if ok, err := c2.(*TCPConn).MultipathTCP(); ok || err != nil {
+		t.Errorf("regular TCP: MultipathTCP() = %v, %v; want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || (js && wasm) || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly freebsd js,wasm netbsd openbsd solaris windows
+
+package net
+
+import (
+	"context"
+	"syscall"
+)
+
+// Multipath TCP is only supported on Linux; elsewhere Dialer and
+// ListenConfig fall back to regular TCP.
+
+func (sd *sysDialer) dialMPTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCPConn, error) {
+	return sd.doDialTCPProto(ctx, laddr, raddr, 0)
+}
+
+func (sl *sysListener) listenMPTCP(ctx context.Context, laddr *TCPAddr) (*TCPListener, error) {
+	return sl.listenTCPProto(ctx, laddr, 0)
+}
+
+func mptcpEnabled(fd *netFD) (bool, error) {
+	return false, nil
+}
+
+func mptcpInfo(fd *netFD) (MultipathTCPInfo, error) {
+	return MultipathTCPInfo{}, syscall.ENOPROTOOPT
+}
+
+func mptcpSubflows(fd *netFD) ([]MultipathSubflow, error) {
+	return nil, syscall.ENOPROTOOPT
+}
+
+func mptcpAddSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return syscall.ENOPROTOOPT
+}
+
+func mptcpRemoveSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return syscall.ENOPROTOOPT
+}
@@ -199,6 +199,25 @@ func (c *conn) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// WriteVec implements the io.VecWriter interface.
+// Where the platform supports it, all of bufs are written with a single
+// writev-style system call, as with Buffers.
+func (c *conn) WriteVec(bufs [][]byte) (int64, error) {
+	v := append(Buffers(nil), bufs...)
+	if wv, ok := interface{}(c).(buffersWriter); ok {
+		return wv.writeBuffers(&v)
+	}
+	var n int64
+	for _, b := range v {
+		nb, err := c.Write(b)
+		n += int64(nb)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
 // Close closes the connection.
 func (c *conn) Close() error {
 	if !c.ok() {
@@ -655,7 +674,35 @@ type writerOnly struct {
 // applicable.
 func genericReadFrom(w io.Writer, r io.Reader) (n int64, err error) {
 	// Use wrapper to hide existing r.ReadFrom from io.Copy.
-	return io.Copy(writerOnly{w}, r)
+	// Callers wrap the error in an *OpError, so it can report
+	// which side failed without breaking comparisons.
+	return io.CopyAttributed(writerOnly{w}, r)
+}
+
+// noWriteTo can be embedded alongside another type to
+// hide the WriteTo method of that other type.
+type noWriteTo struct{}
+
+// WriteTo hides another WriteTo method.
+// It should never be called.
+func (noWriteTo) WriteTo(io.Writer) (int64, error) {
+	panic("can't happen")
+}
+
+// tcpConnWithoutWriteTo implements all the methods of *TCPConn other
+// than WriteTo. The ReadFrom method of the destination of a copy,
+// such as that of a *TCPConn, an *os.File or a bufio.Writer wrapping
+// one, still sees the connection, and can splice from it.
+type tcpConnWithoutWriteTo struct {
+	noWriteTo
+	*TCPConn
+}
+
+// Fallback implementation of io.WriterTo's WriteTo, when splice isn't
+// applicable.
+func genericWriteTo(c *TCPConn, w io.Writer) (n int64, err error) {
+	// Use wrapper to hide existing c.WriteTo from io.Copy.
+	return io.Copy(w, tcpConnWithoutWriteTo{TCPConn: c})
 }
 
 // Limit the number of concurrent cgo-using goroutines, because
@@ -703,6 +750,11 @@ func (v *Buffers) WriteTo(w io.Writer) (n int64, err error) {
 	if wv, ok := w.(buffersWriter); ok {
 		return wv.writeBuffers(v)
 	}
+	if wv, ok := w.(io.VecWriter); ok {
+		n, err = wv.WriteVec(*v)
+		v.consume(n)
+		return n, err
+	}
 	for _, b := range *v {
 		nb, err := w.Write(b)
 		n += int64(nb)
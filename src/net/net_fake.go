@@ -148,6 +148,10 @@ func (fd *netFD) accept() (*netFD, error) {
 	return c, nil
 }
 
+func (lc *ListenConfig) connControl(fd *netFD) error {
+	return nil
+}
+
 func (fd *netFD) SetDeadline(t time.Time) error {
 	fd.r.SetReadDeadline(t)
 	fd.w.SetWriteDeadline(t)
@@ -94,6 +94,9 @@ func TestTCPConnSpecificMethods(t *testing.T) {
 	defer c.Close()
 	c.SetKeepAlive(false)
 	c.SetKeepAlivePeriod(3 * time.Second)
+	c.SetKeepAliveInterval(time.Second)
+	c.SetKeepAliveCount(3)
+	c.SetUserTimeout(10 * time.Second)
 	c.SetLinger(0)
 	c.SetNoDelay(false)
 	c.LocalAddr()
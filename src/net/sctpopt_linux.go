@@ -0,0 +1,141 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"io"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Socket options, ancillary data types and flags from linux/sctp.h.
+const (
+	sctpStatus      = 14 // SCTP_STATUS
+	sctpRecvRcvInfo = 32 // SCTP_RECVRCVINFO
+	sctpSndInfo     = 2  // SCTP_SNDINFO
+	sctpRcvInfo     = 3  // SCTP_RCVINFO
+	sctpUnordered   = 1  // SCTP_UNORDERED
+)
+
+// sysSCTPSndInfo is struct sctp_sndinfo.
+type sysSCTPSndInfo struct {
+	sid     uint16
+	flags   uint16
+	ppid    [4]byte // network byte order
+	context uint32
+	assocID int32
+}
+
+// sysSCTPRcvInfo is struct sctp_rcvinfo.
+type sysSCTPRcvInfo struct {
+	sid     uint16
+	ssn     uint16
+	flags   uint16
+	_       uint16
+	ppid    [4]byte // network byte order
+	tsn     uint32
+	cumtsn  uint32
+	context uint32
+	assocID int32
+}
+
+// sysSCTPStatus is struct sctp_status, including its struct
+// sctp_paddrinfo for the primary address.
+type sysSCTPStatus struct {
+	assocID   int32
+	state     int32
+	rwnd      uint32
+	unackdata uint16
+	penddata  uint16
+	instrms   uint16
+	outstrms  uint16
+	fragPoint uint32
+
+	primaryAssocID int32
+	primaryAddr    [128]byte
+	primaryState   int32
+	primaryCwnd    uint32
+	primarySrtt    uint32 // milliseconds
+	primaryRTO     uint32 // milliseconds
+	primaryMTU     uint32
+}
+
+// setSCTPRecvInfo asks for the stream and payload protocol identifier
+// of received messages to be reported to ReadMsgSCTP.
+func setSCTPRecvInfo(fd *netFD) {
+	fd.pfd.SetsockoptInt(ipprotoSCTP, sctpRecvRcvInfo, 1)
+	runtime.KeepAlive(fd)
+}
+
+func (c *SCTPConn) readMsg(b []byte) (int, SCTPMessageInfo, error) {
+	var info SCTPMessageInfo
+	oob := make([]byte, syscall.CmsgSpace(int(unsafe.Sizeof(sysSCTPRcvInfo{}))))
+	n, oobn, _, _, err := c.fd.readMsg(b, oob, 0)
+	if err != nil {
+		return n, info, err
+	}
+	if n == 0 && len(b) > 0 {
+		return 0, info, io.EOF
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return n, info, wrapSyscallError("parsesocketcontrolmessage", err)
+	}
+	for _, m := range msgs {
+		if m.Header.Level != ipprotoSCTP || m.Header.Type != sctpRcvInfo || len(m.Data) < int(unsafe.Sizeof(sysSCTPRcvInfo{})) {
+			continue
+		}
+		ri := (*sysSCTPRcvInfo)(unsafe.Pointer(&m.Data[0]))
+		info.Stream = ri.sid
+		info.PPID = uint32(ri.ppid[0])<<24 | uint32(ri.ppid[1])<<16 | uint32(ri.ppid[2])<<8 | uint32(ri.ppid[3])
+		info.Unordered = ri.flags&sctpUnordered != 0
+	}
+	return n, info, nil
+}
+
+func (c *SCTPConn) writeMsg(b []byte, info SCTPMessageInfo) (int, error) {
+	size := int(unsafe.Sizeof(sysSCTPSndInfo{}))
+	oob := make([]byte, syscall.CmsgSpace(size))
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	h.Level = ipprotoSCTP
+	h.Type = sctpSndInfo
+	h.SetLen(syscall.CmsgLen(size))
+	si := (*sysSCTPSndInfo)(unsafe.Pointer(&oob[syscall.CmsgLen(0)]))
+	si.sid = info.Stream
+	if info.Unordered {
+		si.flags = sctpUnordered
+	}
+	si.ppid = [4]byte{byte(info.PPID >> 24), byte(info.PPID >> 16), byte(info.PPID >> 8), byte(info.PPID)}
+	n, _, err := c.fd.writeMsg(b, oob, nil)
+	return n, err
+}
+
+func (c *SCTPConn) status() (SCTPStatus, error) {
+	var st sysSCTPStatus
+	var err error
+	size := uint32(unsafe.Sizeof(st))
+	if cerr := c.fd.pfd.RawControl(func(s uintptr) {
+		err = unix.Getsockopt(int(s), ipprotoSCTP, sctpStatus, unsafe.Pointer(&st), &size)
+	}); cerr != nil {
+		return SCTPStatus{}, cerr
+	}
+	if err != nil {
+		return SCTPStatus{}, wrapSyscallError("getsockopt", err)
+	}
+	return SCTPStatus{
+		InboundStreams:     int(st.instrms),
+		OutboundStreams:    int(st.outstrms),
+		ReceiveWindow:      int(st.rwnd),
+		UnackedData:        int(st.unackdata),
+		PendingData:        int(st.penddata),
+		FragmentationPoint: int(st.fragPoint),
+		MTU:                int(st.primaryMTU),
+		RTT:                time.Duration(st.primarySrtt) * time.Millisecond,
+		RTO:                time.Duration(st.primaryRTO) * time.Millisecond,
+	}, nil
+}
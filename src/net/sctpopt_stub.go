@@ -0,0 +1,22 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package net
+
+func setSCTPRecvInfo(fd *netFD) {}
+
+func (c *SCTPConn) readMsg(b []byte) (int, SCTPMessageInfo, error) {
+	return 0, SCTPMessageInfo{}, errSCTPNotSupported
+}
+
+func (c *SCTPConn) writeMsg(b []byte, info SCTPMessageInfo) (int, error) {
+	return 0, errSCTPNotSupported
+}
+
+func (c *SCTPConn) status() (SCTPStatus, error) {
+	return SCTPStatus{}, errSCTPNotSupported
+}
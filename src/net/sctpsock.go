@@ -0,0 +1,334 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/itoa"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errSCTPNotSupported is returned for SCTP operations on systems
+// where package net does not implement them.
+var errSCTPNotSupported = errors.New("SCTP not supported")
+
+// SCTPAddr represents the address of an SCTP end point.
+type SCTPAddr struct {
+	IP   IP
+	Port int
+	Zone string // IPv6 scoped addressing zone
+}
+
+// Network returns the address's network name, "sctp".
+func (a *SCTPAddr) Network() string { return "sctp" }
+
+func (a *SCTPAddr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	ip := ipEmptyString(a.IP)
+	if a.Zone != "" {
+		return JoinHostPort(ip+"%"+a.Zone, itoa.Itoa(a.Port))
+	}
+	return JoinHostPort(ip, itoa.Itoa(a.Port))
+}
+
+func (a *SCTPAddr) isWildcard() bool {
+	if a == nil || a.IP == nil {
+		return true
+	}
+	return a.IP.IsUnspecified()
+}
+
+func (a *SCTPAddr) opAddr() Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+// ResolveSCTPAddr returns an address of SCTP end point.
+//
+// The network must be an SCTP network name: "sctp", "sctp4"
+// (IPv4-only) or "sctp6" (IPv6-only).
+//
+// See func ResolveTCPAddr for a description of how the address
+// parameter is resolved.
+func ResolveSCTPAddr(network, address string) (*SCTPAddr, error) {
+	switch network {
+	case "sctp", "sctp4", "sctp6":
+	default:
+		return nil, UnknownNetworkError(network)
+	}
+	addrs, err := DefaultResolver.internetAddrList(context.Background(), network, address)
+	if err != nil {
+		return nil, err
+	}
+	return addrs.forResolve(network, address).(*SCTPAddr), nil
+}
+
+// SCTPConn is an implementation of the Conn interface for SCTP
+// network connections. It uses one-to-one style sockets, each of
+// which carries a single SCTP association.
+//
+// Read and Write transfer message data without regard to streams,
+// using stream 0 for writes. ReadMsgSCTP and WriteMsgSCTP give
+// access to the stream and payload protocol identifier of each
+// message.
+//
+// The number of streams of an association is negotiated when it is
+// set up. To ask for other than the system default, set the
+// SCTP_INITMSG socket option in the Control function of a Dialer or
+// ListenConfig.
+type SCTPConn struct {
+	conn
+}
+
+// SCTPMessageInfo describes an SCTP message.
+type SCTPMessageInfo struct {
+	// Stream is the stream the message is sent on.
+	Stream uint16
+
+	// PPID is the payload protocol identifier of the message,
+	// which SCTP passes to the application unchanged.
+	PPID uint32
+
+	// Unordered reports whether the message is delivered without
+	// regard to the order of the other messages on its stream.
+	Unordered bool
+}
+
+// SCTPStatus describes the state of an SCTP association.
+type SCTPStatus struct {
+	// InboundStreams and OutboundStreams are the numbers of
+	// streams negotiated in each direction.
+	InboundStreams  int
+	OutboundStreams int
+
+	// ReceiveWindow is the current receiver window of the peer,
+	// in bytes.
+	ReceiveWindow int
+
+	// UnackedData and PendingData are the numbers of data chunks
+	// that are not yet acknowledged and that are waiting to be
+	// sent.
+	UnackedData int
+	PendingData int
+
+	// FragmentationPoint is the size above which messages are
+	// fragmented, and MTU the path MTU of the primary address.
+	FragmentationPoint int
+	MTU                int
+
+	// RTT is the smoothed round-trip time and RTO the
+	// retransmission timeout of the primary address.
+	RTT time.Duration
+	RTO time.Duration
+}
+
+func newSCTPConn(fd *netFD) *SCTPConn {
+	c := &SCTPConn{conn{fd}}
+	setSCTPRecvInfo(c.fd)
+	return c
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+func (c *SCTPConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd)
+}
+
+// ReadMsgSCTP reads a message from c, copying the payload into b.
+// It returns the number of bytes copied into b and the stream and
+// payload protocol identifier of the message.
+//
+// If b is too short for the message, ReadMsgSCTP returns the start of
+// it, and the next call returns more.
+func (c *SCTPConn) ReadMsgSCTP(b []byte) (n int, info SCTPMessageInfo, err error) {
+	if !c.ok() {
+		return 0, SCTPMessageInfo{}, syscall.EINVAL
+	}
+	n, info, err = c.readMsg(b)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return
+}
+
+// WriteMsgSCTP writes b to c as one message, on the stream and with
+// the payload protocol identifier given by info.
+func (c *SCTPConn) WriteMsgSCTP(b []byte, info SCTPMessageInfo) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeMsg(b, info)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
+// Status returns the state of the SCTP association of c, as
+// reported by the SCTP_STATUS socket option.
+func (c *SCTPConn) Status() (SCTPStatus, error) {
+	if !c.ok() {
+		return SCTPStatus{}, syscall.EINVAL
+	}
+	st, err := c.status()
+	if err != nil {
+		return SCTPStatus{}, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return st, nil
+}
+
+// DialSCTP acts like Dial for SCTP networks.
+//
+// The network must be an SCTP network name: "sctp", "sctp4" or
+// "sctp6".
+//
+// If laddr is nil, a local address is automatically chosen.
+// If the IP field of raddr is nil or an unspecified IP address, the
+// local system is assumed.
+func DialSCTP(network string, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
+	switch network {
+	case "sctp", "sctp4", "sctp6":
+	default:
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: UnknownNetworkError(network)}
+	}
+	if raddr == nil {
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: nil, Err: errMissingAddress}
+	}
+	sd := &sysDialer{network: network, address: raddr.String()}
+	c, err := sd.dialSCTP(context.Background(), laddr, raddr)
+	if err != nil {
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
+
+// SCTPListener is an SCTP network listener. Clients should typically
+// use variables of type Listener instead of assuming SCTP.
+type SCTPListener struct {
+	fd *netFD
+	lc ListenConfig
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+//
+// The returned RawConn only supports calling Control. Read and
+// Write return an error.
+func (l *SCTPListener) SyscallConn() (syscall.RawConn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawListener(l.fd)
+}
+
+// AcceptSCTP accepts the next incoming association and returns the
+// new connection.
+func (l *SCTPListener) AcceptSCTP() (*SCTPConn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	c, err := l.accept()
+	if err != nil {
+		return nil, &OpError{Op: "accept", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return c, nil
+}
+
+// Accept implements the Accept method in the Listener interface; it
+// waits for the next association and returns a generic Conn.
+func (l *SCTPListener) Accept() (Conn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	c, err := l.accept()
+	if err != nil {
+		return nil, &OpError{Op: "accept", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return c, nil
+}
+
+// Close stops listening on the SCTP address.
+// Already Accepted connections are not closed.
+func (l *SCTPListener) Close() error {
+	if !l.ok() {
+		return syscall.EINVAL
+	}
+	if err := l.close(); err != nil {
+		return &OpError{Op: "close", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// Addr returns the listener's network address, an *SCTPAddr.
+// The Addr returned is shared by all invocations of Addr, so
+// do not modify it.
+func (l *SCTPListener) Addr() Addr { return l.fd.laddr }
+
+// SetDeadline sets the deadline associated with the listener.
+// A zero time value disables the deadline.
+func (l *SCTPListener) SetDeadline(t time.Time) error {
+	if !l.ok() {
+		return syscall.EINVAL
+	}
+	if err := l.fd.pfd.SetDeadline(t); err != nil {
+		return &OpError{Op: "set", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// File returns a copy of the underlying os.File.
+// It is the caller's responsibility to close f when finished.
+// Closing l does not affect f, and closing f does not affect l.
+//
+// The returned os.File's file descriptor is different from the
+// connection's. Attempting to change properties of the original
+// using this duplicate may or may not have the desired effect.
+func (l *SCTPListener) File() (f *os.File, err error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	f, err = l.file()
+	if err != nil {
+		return nil, &OpError{Op: "file", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return
+}
+
+// ListenSCTP acts like Listen for SCTP networks.
+//
+// The network must be an SCTP network name: "sctp", "sctp4" or
+// "sctp6".
+//
+// If the IP field of laddr is nil or an unspecified IP address,
+// ListenSCTP listens on all available unicast and anycast IP
+// addresses of the local system.
+// If the Port field of laddr is 0, a port number is automatically
+// chosen.
+func ListenSCTP(network string, laddr *SCTPAddr) (*SCTPListener, error) {
+	switch network {
+	case "sctp", "sctp4", "sctp6":
+	default:
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: UnknownNetworkError(network)}
+	}
+	if laddr == nil {
+		laddr = &SCTPAddr{}
+	}
+	sl := &sysListener{network: network, address: laddr.String()}
+	ln, err := sl.listenSCTP(context.Background(), laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return ln, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func newLocalSCTPListener(t *testing.T) *SCTPListener {
+	ln, err := ListenSCTP("sctp4", &SCTPAddr{IP: IPv4(127, 0, 0, 1)})
+	if errors.Is(err, syscall.EPROTONOSUPPORT) || errors.Is(err, syscall.ESOCKTNOSUPPORT) {
+		t.Skipf("SCTP not supported: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+func TestSCTPMessages(t *testing.T) {
+	ln := newLocalSCTPListener(t)
+	defer ln.Close()
+
+	type result struct {
+		c   *SCTPConn
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		c, err := ln.AcceptSCTP()
+		ch <- result{c, err}
+	}()
+
+	c, err := Dial("sctp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	cc, ok := c.(*SCTPConn)
+	if !ok {
+		t.Fatalf("Dial returned %T, want *SCTPConn", c)
+	}
+	if _, ok := cc.LocalAddr().(*SCTPAddr); !ok {
+		t.Errorf("LocalAddr returned %T, want *SCTPAddr", cc.LocalAddr())
+	}
+	r := <-ch
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	sc := r.c
+	defer sc.Close()
+
+	want := SCTPMessageInfo{Stream: 1, PPID: 0x01020304}
+	if _, err := cc.WriteMsgSCTP([]byte("HELLO"), want); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 16)
+	n, info, err := sc.ReadMsgSCTP(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "HELLO" || info != want {
+		t.Errorf("ReadMsgSCTP = %q, %+v; want %q, %+v", b[:n], info, "HELLO", want)
+	}
+
+	if _, err := cc.Write([]byte("WORLD")); err != nil {
+		t.Fatal(err)
+	}
+	n, err = sc.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "WORLD" {
+		t.Errorf("Read = %q; want %q", b[:n], "WORLD")
+	}
+
+	st, err := cc.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.InboundStreams < 1 || st.OutboundStreams < 2 || st.MTU <= 0 {
+		t.Errorf("unexpected status %+v", st)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package net
+
+import (
+	"context"
+	"os"
+	"syscall"
+)
+
+// ipprotoSCTP is IPPROTO_SCTP, which package syscall does not
+// define on all systems.
+const ipprotoSCTP = 132
+
+// isSCTPNetwork reports whether net is an SCTP network name.
+func isSCTPNetwork(net string) bool {
+	switch net {
+	case "sctp", "sctp4", "sctp6":
+		return true
+	}
+	return false
+}
+
+func sockaddrToSCTP(sa syscall.Sockaddr) Addr {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		return &SCTPAddr{IP: sa.Addr[0:], Port: sa.Port}
+	case *syscall.SockaddrInet6:
+		return &SCTPAddr{IP: sa.Addr[0:], Port: sa.Port, Zone: zoneCache.name(int(sa.ZoneId))}
+	}
+	return nil
+}
+
+func (a *SCTPAddr) family() int {
+	if a == nil || len(a.IP) <= IPv4len {
+		return syscall.AF_INET
+	}
+	if a.IP.To4() != nil {
+		return syscall.AF_INET
+	}
+	return syscall.AF_INET6
+}
+
+func (a *SCTPAddr) sockaddr(family int) (syscall.Sockaddr, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return ipToSockaddr(family, a.IP, a.Port, a.Zone)
+}
+
+func (a *SCTPAddr) toLocal(net string) sockaddr {
+	return &SCTPAddr{loopbackIP(net), a.Port, a.Zone}
+}
+
+func (sd *sysDialer) dialSCTP(ctx context.Context, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
+	fd, err := internetSocket(ctx, sd.network, laddr, raddr, syscall.SOCK_STREAM, ipprotoSCTP, "dial", sd.Dialer.Control)
+	if err != nil {
+		return nil, err
+	}
+	return newSCTPConn(fd), nil
+}
+
+func (ln *SCTPListener) ok() bool { return ln != nil && ln.fd != nil }
+
+func (ln *SCTPListener) accept() (*SCTPConn, error) {
+	fd, err := ln.fd.accept()
+	if err != nil {
+		return nil, err
+	}
+	if err := ln.lc.connControl(fd); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return newSCTPConn(fd), nil
+}
+
+func (ln *SCTPListener) close() error {
+	return ln.fd.Close()
+}
+
+func (ln *SCTPListener) file() (*os.File, error) {
+	f, err := ln.fd.dup()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (sl *sysListener) listenSCTP(ctx context.Context, laddr *SCTPAddr) (*SCTPListener, error) {
+	fd, err := internetSocket(ctx, sl.network, laddr, nil, syscall.SOCK_STREAM, ipprotoSCTP, "listen", sl.control())
+	if err != nil {
+		return nil, err
+	}
+	return &SCTPListener{fd: fd, lc: sl.ListenConfig}, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (js && wasm) || plan9
+// +build js,wasm plan9
+
+package net
+
+import (
+	"context"
+	"os"
+)
+
+func (sd *sysDialer) dialSCTP(ctx context.Context, laddr, raddr *SCTPAddr) (*SCTPConn, error) {
+	return nil, errSCTPNotSupported
+}
+
+func (ln *SCTPListener) ok() bool { return ln != nil && ln.fd != nil }
+
+func (ln *SCTPListener) accept() (*SCTPConn, error) {
+	return nil, errSCTPNotSupported
+}
+
+func (ln *SCTPListener) close() error {
+	return errSCTPNotSupported
+}
+
+func (ln *SCTPListener) file() (*os.File, error) {
+	return nil, errSCTPNotSupported
+}
+
+func (sl *sysListener) listenSCTP(ctx context.Context, laddr *SCTPAddr) (*SCTPListener, error) {
+	return nil, errSCTPNotSupported
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !js
+// +build !js
+
+package net
+
+import (
+	"reflect"
+	"testing"
+)
+
+var resolveSCTPAddrTests = []struct {
+	network       string
+	litAddrOrName string
+	addr          *SCTPAddr
+	err           error
+}{
+	{"sctp", "127.0.0.1:0", &SCTPAddr{IP: IPv4(127, 0, 0, 1), Port: 0}, nil},
+	{"sctp4", "127.0.0.1:65535", &SCTPAddr{IP: IPv4(127, 0, 0, 1), Port: 65535}, nil},
+
+	{"sctp", "[::1]:0", &SCTPAddr{IP: ParseIP("::1"), Port: 0}, nil},
+	{"sctp6", "[::1]:65535", &SCTPAddr{IP: ParseIP("::1"), Port: 65535}, nil},
+
+	{"sctp", "[::1%en0]:1", &SCTPAddr{IP: ParseIP("::1"), Port: 1, Zone: "en0"}, nil},
+	{"sctp6", "[::1%911]:2", &SCTPAddr{IP: ParseIP("::1"), Port: 2, Zone: "911"}, nil},
+
+	{"sctp", ":12345", &SCTPAddr{Port: 12345}, nil},
+
+	{"tcp", "127.0.0.1:0", nil, UnknownNetworkError("tcp")},
+
+	{"sctp4", "[2001:db8::1]:0", nil, &AddrError{Err: errNoSuitableAddress.Error(), Addr: "2001:db8::1"}},
+	{"sctp6", "127.0.0.1:0", nil, &AddrError{Err: errNoSuitableAddress.Error(), Addr: "127.0.0.1"}},
+}
+
+func TestResolveSCTPAddr(t *testing.T) {
+	origTestHookLookupIP := testHookLookupIP
+	defer func() { testHookLookupIP = origTestHookLookupIP }()
+	testHookLookupIP = lookupLocalhost
+
+	for _, tt := range resolveSCTPAddrTests {
+		addr, err := ResolveSCTPAddr(tt.network, tt.litAddrOrName)
+		if !reflect.DeepEqual(addr, tt.addr) || !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("ResolveSCTPAddr(%q, %q) = %#v, %v, want %#v, %v", tt.network, tt.litAddrOrName, addr, err, tt.addr, tt.err)
+			continue
+		}
+		if err == nil {
+			addr2, err := ResolveSCTPAddr(addr.Network(), addr.String())
+			if !reflect.DeepEqual(addr2, tt.addr) || err != tt.err {
+				t.Errorf("(%q, %q): ResolveSCTPAddr(%q, %q) = %#v, %v, want %#v, %v", tt.network, tt.litAddrOrName, addr.Network(), addr.String(), addr2, err, tt.addr, tt.err)
+			}
+		}
+	}
+}
@@ -35,6 +35,7 @@ func sendFile(c *netFD, r io.Reader) (written int64, err error, handled bool) {
 			return 0, nil, true
 		}
 	}
+	r = io.UnwrapReader(r)
 	f, ok := r.(*os.File)
 	if !ok {
 		return 0, nil, false
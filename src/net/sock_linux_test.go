@@ -5,7 +5,13 @@
 package net
 
 import (
+	"context"
+	"internal/syscall/unix"
+	"os"
+	"syscall"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 func TestMaxAckBacklog(t *testing.T) {
@@ -20,3 +26,116 @@ func TestMaxAckBacklog(t *testing.T) {
 		t.Fatalf(`Kernel version: "%d.%d", sk_max_ack_backlog mismatch, got %d, want %d`, major, minor, backlog, expected)
 	}
 }
+
+func TestRouteSockopts(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("setting marks and transparent sockets needs CAP_NET_ADMIN")
+	}
+	if !supportsIPv4() {
+		t.Skip("IPv4 is not supported")
+	}
+
+	// 192.0.2.1 is not assigned to any interface, so listening
+	// on it needs FreeBind.
+	lc := ListenConfig{Mark: 7, BindToDevice: "lo", FreeBind: true}
+	ln, err := lc.Listen(context.Background(), "tcp4", "192.0.2.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRouteSockopts(t, ln.(*TCPListener).fd, 7, "lo", syscall.IP_FREEBIND)
+	ln.Close()
+
+	ln, err = newLocalListener("tcp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	d := Dialer{Mark: 42, BindToDevice: "lo", Transparent: true}
+	c, err := d.Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	checkRouteSockopts(t, c.(*TCPConn).fd, 42, "lo", syscall.IP_TRANSPARENT)
+
+	uc, err := d.Dial("udp4", "127.0.0.1:9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uc.Close()
+	checkRouteSockopts(t, uc.(*UDPConn).fd, 42, "lo", syscall.IP_TRANSPARENT)
+}
+
+func checkRouteSockopts(t *testing.T, fd *netFD, mark int, device string, ipopt int) {
+	t.Helper()
+	err := fd.pfd.RawControl(func(s uintptr) {
+		if v, err := syscall.GetsockoptInt(int(s), syscall.SOL_SOCKET, syscall.SO_MARK); err != nil || v != mark {
+			t.Errorf("SO_MARK = %d, %v; want %d", v, err, mark)
+		}
+		var b [syscall.IFNAMSIZ]byte
+		n := uint32(len(b))
+		if err := unix.Getsockopt(int(s), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, unsafe.Pointer(&b[0]), &n); err != nil {
+			t.Errorf("SO_BINDTODEVICE: %v", err)
+		} else if dev := string(b[:n-1]); dev != device {
+			t.Errorf("SO_BINDTODEVICE = %q; want %q", dev, device)
+		}
+		if v, err := syscall.GetsockoptInt(int(s), syscall.SOL_IP, ipopt); err != nil || v != 1 {
+			t.Errorf("IP option %d = %d, %v; want 1", ipopt, v, err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeferAccept(t *testing.T) {
+	if !supportsIPv4() {
+		t.Skip("IPv4 is not supported")
+	}
+
+	lc := ListenConfig{DeferAccept: 1500 * time.Millisecond}
+	ln, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	tln := ln.(*TCPListener)
+	err = tln.fd.pfd.RawControl(func(s uintptr) {
+		// The kernel rounds the timeout up to a number of
+		// SYN-ACK retransmissions.
+		if v, err := syscall.GetsockoptInt(int(s), syscall.IPPROTO_TCP, syscall.TCP_DEFER_ACCEPT); err != nil || v < 2 {
+			t.Errorf("TCP_DEFER_ACCEPT = %d, %v; want at least 2", v, err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Dial("tcp4", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tln.SetDeadline(time.Now().Add(100 * time.Millisecond))
+	if ac, err := tln.Accept(); err == nil {
+		ac.Close()
+		t.Fatal("accepted a connection without data")
+	} else if !isDeadlineExceeded(err) {
+		t.Fatal(err)
+	}
+	if _, err := c.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	tln.SetDeadline(time.Now().Add(5 * time.Second))
+	ac, err := tln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac.Close()
+
+	lc = ListenConfig{AcceptFilter: "dataready"}
+	if ln, err := lc.Listen(context.Background(), "tcp4", "127.0.0.1:0"); err == nil {
+		ln.Close()
+		t.Error("listening with an accept filter succeeded")
+	}
+}
@@ -90,11 +90,31 @@ func (fd *netFD) ctrlNetwork() string {
 	return fd.net + "6"
 }
 
+// connControl calls lc.ConnControl, if set, on the connection fd
+// accepted by a listener made with lc.
+func (lc *ListenConfig) connControl(fd *netFD) error {
+	if lc.ConnControl == nil {
+		return nil
+	}
+	c, err := newRawConn(fd)
+	if err != nil {
+		return err
+	}
+	var raddr string
+	if fd.raddr != nil {
+		raddr = fd.raddr.String()
+	}
+	return lc.ConnControl(fd.ctrlNetwork(), raddr, c)
+}
+
 func (fd *netFD) addrFunc() func(syscall.Sockaddr) Addr {
 	switch fd.family {
 	case syscall.AF_INET, syscall.AF_INET6:
 		switch fd.sotype {
 		case syscall.SOCK_STREAM:
+			if isSCTPNetwork(fd.net) {
+				return sockaddrToSCTP
+			}
 			return sockaddrToTCP
 		case syscall.SOCK_DGRAM:
 			return sockaddrToUDP
@@ -110,6 +130,10 @@ func (fd *netFD) addrFunc() func(syscall.Sockaddr) Addr {
 		case syscall.SOCK_SEQPACKET:
 			return sockaddrToUnixpacket
 		}
+	case sysAF_VSOCK:
+		if fd.sotype == syscall.SOCK_STREAM {
+			return sockaddrToVsock
+		}
 	}
 	return func(syscall.Sockaddr) Addr { return nil }
 }
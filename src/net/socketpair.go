@@ -0,0 +1,21 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+// SocketPair returns a pair of connected Unix domain stream sockets.
+// Unlike the ends of a Pipe, the connections are backed by file
+// descriptors, so they can be passed to child processes with their
+// File methods, used with SyscallConn, and take part in the zero-copy
+// paths of the other connections. Both connections are *UnixConn.
+//
+// SocketPair is not implemented on JS, Plan 9 and Windows, where it
+// returns an error.
+func SocketPair() (Conn, Conn, error) {
+	c1, c2, err := socketPair()
+	if err != nil {
+		return nil, nil, &OpError{Op: "socketpair", Net: "unix", Err: err}
+	}
+	return c1, c2, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSocketPair(t *testing.T) {
+	if !testableNetwork("unix") {
+		t.Skip("unix domain sockets not supported")
+	}
+	c1, c2, err := SocketPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	defer c2.Close()
+
+	for _, c := range []Conn{c1, c2} {
+		if _, ok := c.(*UnixConn); !ok {
+			t.Fatalf("got %T, want *UnixConn", c)
+		}
+		if a := c.LocalAddr(); a == nil || a.Network() != "unix" {
+			t.Errorf("LocalAddr = %v, want an unnamed unix address", a)
+		}
+	}
+
+	// Data flows both ways.
+	for _, p := range [][2]Conn{{c1, c2}, {c2, c1}} {
+		if _, err := p[0].Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 5)
+		if _, err := io.ReadFull(p[1], b); err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "hello" {
+			t.Errorf("read %q, want %q", b, "hello")
+		}
+	}
+
+	// The connections are backed by file descriptors.
+	rc, err := c1.(*UnixConn).SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fd uintptr
+	if err := rc.Control(func(s uintptr) { fd = s }); err != nil {
+		t.Fatal(err)
+	}
+	if int(fd) < 0 {
+		t.Errorf("fd = %d, want a valid file descriptor", int(fd))
+	}
+	f, err := c2.(*UnixConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c1.Close()
+	if _, err := c2.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read after the other end closed: %v, want io.EOF", err)
+	}
+}
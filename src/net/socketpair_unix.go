@@ -0,0 +1,53 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package net
+
+import (
+	"internal/poll"
+	"os"
+	"syscall"
+)
+
+func socketPair() (*UnixConn, *UnixConn, error) {
+	fds, err := sysSocketPair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cs [2]*UnixConn
+	for i, s := range fds {
+		fd, err := newFD(s, syscall.AF_UNIX, syscall.SOCK_STREAM, "unix")
+		if err == nil {
+			err = fd.init()
+		}
+		if err != nil {
+			for _, c := range cs[:i] {
+				c.Close()
+			}
+			for _, s := range fds[i:] {
+				poll.CloseFunc(s)
+			}
+			return nil, nil, err
+		}
+		fd.setAddr(&UnixAddr{Net: "unix"}, &UnixAddr{Net: "unix"})
+		cs[i] = newUnixConn(fd)
+	}
+	return cs[0], cs[1], nil
+}
+
+// setNonblockPair puts both of fds in nonblocking mode,
+// closing them if it cannot.
+func setNonblockPair(fds [2]int) ([2]int, error) {
+	for _, s := range fds {
+		if err := syscall.SetNonblock(s, true); err != nil {
+			poll.CloseFunc(fds[0])
+			poll.CloseFunc(fds[1])
+			return [2]int{-1, -1}, os.NewSyscallError("setnonblock", err)
+		}
+	}
+	return fds, nil
+}
@@ -56,3 +56,117 @@ func setDefaultMulticastSockopts(s int) error {
 	// quick draw possible.
 	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_REUSEPORT, 1))
 }
+
+// soReusePortLB is FreeBSD's SO_REUSEPORT_LB, which unlike
+// SO_REUSEPORT balances connections across the sockets.
+const soReusePortLB = 0x10000
+
+func setReusePort(s uintptr) error {
+	if runtime.GOOS == "freebsd" {
+		// SO_REUSEPORT_LB was added in FreeBSD 12.
+		// Fall back to SO_REUSEPORT on older systems.
+		err := syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, soReusePortLB, 1)
+		if err != syscall.ENOPROTOOPT {
+			return os.NewSyscallError("setsockopt", err)
+		}
+	}
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, syscall.SO_REUSEPORT, 1))
+}
+
+func setFastOpen(s uintptr, qlen int) error {
+	// The queue length is set by sysctl on these systems.
+	var opt int
+	switch runtime.GOOS {
+	case "darwin", "ios":
+		opt = 0x105 // TCP_FASTOPEN
+	case "freebsd":
+		opt = 0x401 // TCP_FASTOPEN
+	default:
+		return nil
+	}
+	err := syscall.SetsockoptInt(int(s), syscall.IPPROTO_TCP, opt, 1)
+	if runtime.GOOS == "freebsd" && (err == syscall.ENOPROTOOPT || err == syscall.EINVAL) {
+		// The kernel lacks TCP Fast Open, or server Fast Open is
+		// disabled by sysctl; listen without it.
+		return nil
+	}
+	return os.NewSyscallError("setsockopt", err)
+}
+
+func setMark(s uintptr, mark uint32) error {
+	if runtime.GOOS != "freebsd" {
+		return syscall.ENOPROTOOPT
+	}
+	const soUserCookie = 0x1015 // SO_USER_COOKIE
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, soUserCookie, int(mark)))
+}
+
+func setBindToDevice(s uintptr, family int, device string) error {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		return syscall.ENOPROTOOPT
+	}
+	ifi, err := InterfaceByName(device)
+	if err != nil {
+		return err
+	}
+	if family == syscall.AF_INET6 {
+		const ipv6BoundIf = 0x7d // IPV6_BOUND_IF
+		return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_IPV6, ipv6BoundIf, ifi.Index))
+	}
+	const ipBoundIf = 0x19 // IP_BOUND_IF
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_IP, ipBoundIf, ifi.Index))
+}
+
+func setFreeBind(s uintptr, family int) error {
+	return setBindAny(s, family)
+}
+
+func setTransparent(s uintptr, family int) error {
+	return setBindAny(s, family)
+}
+
+// setBindAny lets s bind to any address, which covers both FreeBind
+// and Transparent.
+func setBindAny(s uintptr, family int) error {
+	switch runtime.GOOS {
+	case "freebsd":
+		if family == syscall.AF_INET6 {
+			const ipv6BindAny = 0x40 // IPV6_BINDANY
+			return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_IPV6, ipv6BindAny, 1))
+		}
+		const ipBindAny = 0x18 // IP_BINDANY
+		return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_IP, ipBindAny, 1))
+	case "openbsd":
+		const soBindAny = 0x1000 // SO_BINDANY
+		return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, soBindAny, 1))
+	}
+	return syscall.ENOPROTOOPT
+}
+
+// setDeferAccept installs the dataready accept filter, which holds
+// connections back until data arrives, on the systems that have
+// accept filters. The others ignore DeferAccept.
+func setDeferAccept(s uintptr, secs int) error {
+	switch runtime.GOOS {
+	case "dragonfly", "freebsd", "netbsd":
+		return setAcceptFilter(s, "dataready")
+	}
+	return nil
+}
+
+func setAcceptFilter(s uintptr, name string) error {
+	switch runtime.GOOS {
+	case "dragonfly", "freebsd", "netbsd":
+	default:
+		return syscall.ENOPROTOOPT
+	}
+	// The option value is a struct accept_filter_arg, which holds
+	// the name of the filter and an argument that no filter uses.
+	var arg [256]byte
+	if len(name) >= 16 {
+		return syscall.EINVAL
+	}
+	copy(arg[:], name)
+	const soAcceptFilter = 0x1000 // SO_ACCEPTFILTER
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(int(s), syscall.SOL_SOCKET, soAcceptFilter, string(arg[:])))
+}
@@ -6,6 +6,7 @@ package net
 
 import (
 	"os"
+	"runtime"
 	"syscall"
 )
 
@@ -33,3 +34,48 @@ func setDefaultMulticastSockopts(s int) error {
 	// concurrently across multiple listeners.
 	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1))
 }
+
+func setReusePort(s uintptr) error {
+	// Package syscall does not define SO_REUSEPORT for all
+	// architectures. Its value is 0x200 on MIPS and 0xf elsewhere.
+	opt := 0xf
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le":
+		opt = 0x200
+	}
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, opt, 1))
+}
+
+func setFastOpen(s uintptr, qlen int) error {
+	// Package syscall does not define TCP_FASTOPEN
+	// for all architectures.
+	const tcpFastOpen = 0x17
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_TCP, tcpFastOpen, qlen))
+}
+
+func setMark(s uintptr, mark uint32) error {
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_SOCKET, syscall.SO_MARK, int(mark)))
+}
+
+func setBindToDevice(s uintptr, family int, device string) error {
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptString(int(s), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, device))
+}
+
+// Linux honors the IPv4 options below on IPv6 sockets too, while
+// their IPv6 counterparts were only added in Linux 4.15.
+
+func setFreeBind(s uintptr, family int) error {
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_IP, syscall.IP_FREEBIND, 1))
+}
+
+func setTransparent(s uintptr, family int) error {
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.SOL_IP, syscall.IP_TRANSPARENT, 1))
+}
+
+func setDeferAccept(s uintptr, secs int) error {
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(int(s), syscall.IPPROTO_TCP, syscall.TCP_DEFER_ACCEPT, secs))
+}
+
+func setAcceptFilter(s uintptr, name string) error {
+	return syscall.ENOPROTOOPT
+}
@@ -17,3 +17,7 @@ func setKeepAlive(fd *netFD, keepalive bool) error {
 func setLinger(fd *netFD, sec int) error {
 	return syscall.EPLAN9
 }
+
+func setIPTTL(fd *netFD, ttl int) error {
+	return syscall.EPLAN9
+}
@@ -33,3 +33,36 @@ func setDefaultMulticastSockopts(s int) error {
 	// concurrently across multiple listeners.
 	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1))
 }
+
+func setReusePort(s uintptr) error {
+	// Solaris and illumos have no SO_REUSEPORT.
+	return os.NewSyscallError("setsockopt", syscall.ENOPROTOOPT)
+}
+
+func setFastOpen(s uintptr, qlen int) error {
+	return nil
+}
+
+func setMark(s uintptr, mark uint32) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setBindToDevice(s uintptr, family int, device string) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setFreeBind(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setTransparent(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setDeferAccept(s uintptr, secs int) error {
+	return nil
+}
+
+func setAcceptFilter(s uintptr, name string) error {
+	return syscall.ENOPROTOOPT
+}
@@ -21,6 +21,38 @@ func setDefaultMulticastSockopts(s int) error {
 	return nil
 }
 
+func setReusePort(s uintptr) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setFastOpen(s uintptr, qlen int) error {
+	return nil
+}
+
+func setMark(s uintptr, mark uint32) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setBindToDevice(s uintptr, family int, device string) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setFreeBind(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setTransparent(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setDeferAccept(s uintptr, secs int) error {
+	return nil
+}
+
+func setAcceptFilter(s uintptr, name string) error {
+	return syscall.ENOPROTOOPT
+}
+
 func setReadBuffer(fd *netFD, bytes int) error {
 	return syscall.ENOPROTOOPT
 }
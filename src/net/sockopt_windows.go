@@ -38,3 +38,39 @@ func setDefaultMulticastSockopts(s syscall.Handle) error {
 	// concurrently across multiple listeners.
 	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1))
 }
+
+func setReusePort(s uintptr) error {
+	// Windows has no SO_REUSEPORT. SO_REUSEADDR would let any
+	// other process bind to the address and take over the port,
+	// and SO_REUSE_UNICASTPORT only affects implicit binds.
+	return os.NewSyscallError("setsockopt", syscall.ENOPROTOOPT)
+}
+
+func setFastOpen(s uintptr, qlen int) error {
+	const tcpFastOpen = 15 // TCP_FASTOPEN
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(syscall.Handle(s), syscall.IPPROTO_TCP, tcpFastOpen, 1))
+}
+
+func setMark(s uintptr, mark uint32) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setBindToDevice(s uintptr, family int, device string) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setFreeBind(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setTransparent(s uintptr, family int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setDeferAccept(s uintptr, secs int) error {
+	return nil
+}
+
+func setAcceptFilter(s uintptr, name string) error {
+	return syscall.ENOPROTOOPT
+}
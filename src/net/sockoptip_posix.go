@@ -48,3 +48,14 @@ func joinIPv6Group(fd *netFD, ifi *Interface, ip IP) error {
 	runtime.KeepAlive(fd)
 	return wrapSyscallError("setsockopt", err)
 }
+
+func setIPTTL(fd *netFD, ttl int) error {
+	var err error
+	if fd.family == syscall.AF_INET6 {
+		err = fd.pfd.SetsockoptInt(syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	} else {
+		err = fd.pfd.SetsockoptInt(syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+	}
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
@@ -38,3 +38,7 @@ func joinIPv6Group(fd *netFD, ifi *Interface, ip IP) error {
 	// See golang.org/issue/7399.
 	return syscall.ENOPROTOOPT
 }
+
+func setIPTTL(fd *netFD, ttl int) error {
+	return syscall.ENOPROTOOPT
+}
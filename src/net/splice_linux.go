@@ -7,11 +7,15 @@ package net
 import (
 	"internal/poll"
 	"io"
+	"os"
+	"runtime"
+	"syscall"
 )
 
 // splice transfers data from r to c using the splice system call to minimize
 // copies from and to userspace. c must be a TCP connection. Currently, splice
-// is only enabled if r is a TCP or a stream-oriented Unix connection.
+// is only enabled if r is a TCP or a stream-oriented Unix connection, or an
+// *os.File that is a pipe or a socket.
 //
 // If splice returns handled == false, it has performed no work.
 func splice(c *netFD, r io.Reader) (written int64, err error, handled bool) {
@@ -23,22 +27,128 @@ func splice(c *netFD, r io.Reader) (written int64, err error, handled bool) {
 			return 0, nil, true
 		}
 	}
+	r = io.UnwrapReader(r)
 
-	var s *netFD
+	var s *poll.FD
 	if tc, ok := r.(*TCPConn); ok {
-		s = tc.fd
+		s = &tc.fd.pfd
+	} else if tc, ok := r.(tcpConnWithoutWriteTo); ok {
+		s = &tc.fd.pfd
 	} else if uc, ok := r.(*UnixConn); ok {
 		if uc.fd.net != "unix" {
 			return 0, nil, false
 		}
-		s = uc.fd
+		s = &uc.fd.pfd
+	} else if f, ok := r.(*os.File); ok {
+		if s = filePollFD(f); s == nil {
+			return 0, nil, false
+		}
+		defer runtime.KeepAlive(f)
 	} else {
 		return 0, nil, false
 	}
 
-	written, handled, sc, err := poll.Splice(&c.pfd, &s.pfd, remain)
+	written, err, handled = spliceFD(&c.pfd, s, remain)
 	if lr != nil {
 		lr.N -= written
 	}
-	return written, wrapSyscallError(sc, err), handled
+	return written, err, handled
+}
+
+// spliceTo transfers data from c to w using the splice system call to
+// minimize copies from and to userspace. c must be a TCP connection.
+// Currently, spliceTo is only enabled if w is a TCP or a stream-oriented
+// Unix connection, a pipe or a socket, or a regular file not opened with
+// O_APPEND.
+//
+// If spliceTo returns handled == false, it has performed no work.
+func spliceTo(w io.Writer, c *netFD) (written int64, err error, handled bool) {
+	w = io.UnwrapWriter(w)
+
+	var d *poll.FD
+	if tc, ok := w.(*TCPConn); ok {
+		d = &tc.fd.pfd
+	} else if uc, ok := w.(*UnixConn); ok {
+		if uc.fd.net != "unix" {
+			return 0, nil, false
+		}
+		d = &uc.fd.pfd
+	} else if f, ok := w.(*os.File); ok {
+		if d = filePollFD(f); d == nil {
+			return spliceToFile(f, c, 1<<62)
+		}
+		defer runtime.KeepAlive(f)
+	} else {
+		return 0, nil, false
+	}
+
+	return spliceFD(d, &c.pfd, 1<<62)
+}
+
+// spliceToFile transfers at most remain bytes from src to the file f
+// with poll.SpliceToFile, wrapping any error like spliceFD.
+func spliceToFile(f *os.File, src *netFD, remain int64) (written int64, err error, handled bool) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return 0, nil, false
+	}
+	var sc string
+	// Write holds the write lock of f while the data is spliced.
+	cerr := rc.Write(func(fd uintptr) bool {
+		written, handled, sc, err = poll.SpliceToFile(int(fd), &src.pfd, remain)
+		return true
+	})
+	if cerr != nil {
+		return 0, nil, false
+	}
+	if ce, ok := err.(*io.CopyError); ok {
+		err = &io.CopyError{Op: ce.Op, Err: wrapSyscallError(sc, ce.Err)}
+	} else {
+		err = wrapSyscallError(sc, err)
+	}
+	return written, err, handled
+}
+
+// pollSplice is poll.Splice, replaced in tests.
+var pollSplice = poll.Splice
+
+// spliceFD transfers at most remain bytes from src to dst with
+// poll.Splice, wrapping any error in a *io.CopyError that tells
+// which side failed.
+func spliceFD(dst, src *poll.FD, remain int64) (written int64, err error, handled bool) {
+	written, handled, sc, err := pollSplice(dst, src, remain)
+	if ce, ok := err.(*io.CopyError); ok {
+		err = &io.CopyError{Op: ce.Op, Err: wrapSyscallError(sc, ce.Err)}
+	} else {
+		err = wrapSyscallError(sc, err)
+	}
+	return written, err, handled
+}
+
+// filePollFD returns the poll.FD of f, if f is a pipe or a socket
+// registered with the runtime poller, which splice reads and writes
+// like a stream-oriented connection, or nil otherwise.
+func filePollFD(f *os.File) *poll.FD {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&(os.ModeNamedPipe|os.ModeSocket) == 0 {
+		return nil
+	}
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	p, ok := rc.(interface{ PollFD() *poll.FD })
+	if !ok {
+		return nil
+	}
+	return p.PollFD()
+}
+
+// PollFD returns the poll.FD of a stream-oriented connection, for the
+// splice path of os.File.ReadFrom, or nil.
+func (c *rawConn) PollFD() *poll.FD {
+	if !c.ok() || c.fd.sotype != syscall.SOCK_STREAM {
+		return nil
+	}
+	return &c.fd.pfd
 }
@@ -12,3 +12,7 @@ import "io"
 func splice(c *netFD, r io.Reader) (int64, error, bool) {
 	return 0, nil, false
 }
+
+func spliceTo(w io.Writer, c *netFD) (int64, error, bool) {
+	return 0, nil, false
+}
@@ -8,12 +8,16 @@
 package net
 
 import (
+	"bufio"
+	"errors"
+	"internal/poll"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"strconv"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -24,6 +28,12 @@ func TestSplice(t *testing.T) {
 		t.Skip("skipping unix-to-tcp tests")
 	}
 	t.Run("unix-to-tcp", func(t *testing.T) { testSplice(t, "unix", "tcp") })
+	t.Run("tcp-to-unix", func(t *testing.T) { testSplice(t, "tcp", "unix") })
+	t.Run("writeTo", testSpliceWriteTo)
+	t.Run("writeTo-file", testSpliceWriteToFile)
+	t.Run("writeTo-readerFrom", testSpliceWriteToReaderFrom)
+	t.Run("pipe", testSplicePipe)
+	t.Run("blocking-pipe", testSpliceBlockingPipe)
 	t.Run("no-unixpacket", testSpliceNoUnixpacket)
 	t.Run("no-unixgram", testSpliceNoUnixgram)
 }
@@ -135,7 +145,11 @@ func testSpliceReaderAtEOF(t *testing.T, upNet, downNet string) {
 	// get a goodbye signal. Test for the goodbye signal.
 	msg := "bye"
 	go func() {
-		serverDown.(io.ReaderFrom).ReadFrom(serverUp)
+		if rf, ok := serverDown.(io.ReaderFrom); ok {
+			rf.ReadFrom(serverUp)
+		} else {
+			serverUp.(io.WriterTo).WriteTo(serverDown)
+		}
 		io.WriteString(serverDown, msg)
 		serverDown.Close()
 	}()
@@ -210,6 +224,316 @@ func testSpliceIssue25985(t *testing.T, upNet, downNet string) {
 	wg.Wait()
 }
 
+func testSpliceWriteTo(t *testing.T) {
+	clientUp, serverUp, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientUp.Close()
+	defer serverUp.Close()
+	clientDown, serverDown, err := spliceTestSocketPair("unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientDown.Close()
+	defer serverDown.Close()
+
+	msg := "hello, world"
+	go func() {
+		io.WriteString(clientUp, msg)
+		clientUp.Close()
+	}()
+	n, err, handled := spliceTo(serverDown, serverUp.(*TCPConn).fd)
+	if !handled {
+		t.Skip("splice not supported for unix sockets")
+	}
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("spliceTo = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	serverDown.Close()
+	got, err := io.ReadAll(clientDown)
+	if err != nil || string(got) != msg {
+		t.Errorf("read %q, %v, want %q, nil", got, err, msg)
+	}
+
+	// A connection that is not a stream socket is left alone.
+	up, err := ListenUnixgram("unixgram", &UnixAddr{Name: testUnixAddr(), Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(up.LocalAddr().String())
+	defer up.Close()
+	_, err, handled = spliceTo(up, serverUp.(*TCPConn).fd)
+	if err != nil || handled {
+		t.Fatalf("spliceTo(unixgram) = %v, handled %t, want nil error, handled == false", err, handled)
+	}
+}
+
+// unwrappingWriter is a Writer that declares it writes to w.
+type unwrappingWriter struct {
+	w io.Writer
+}
+
+func (u unwrappingWriter) Write(b []byte) (int, error) { return u.w.Write(b) }
+func (u unwrappingWriter) UnwrapWriter() io.Writer     { return u.w }
+
+func testSpliceWriteToFile(t *testing.T) {
+	clientUp, serverUp, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientUp.Close()
+	defer serverUp.Close()
+
+	f, err := os.CreateTemp(t.TempDir(), "splice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("> "); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := "hello, world"
+	go func() {
+		io.WriteString(clientUp, msg)
+		clientUp.Close()
+	}()
+	n, err, handled := spliceTo(unwrappingWriter{f}, serverUp.(*TCPConn).fd)
+	if !handled {
+		t.Fatal("spliceTo to a file not handled")
+	}
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("spliceTo = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	got, err := os.ReadFile(f.Name())
+	if err != nil || string(got) != "> "+msg {
+		t.Errorf("file holds %q, %v, want %q, nil", got, err, "> "+msg)
+	}
+
+	// Files opened with O_APPEND are left alone.
+	af, err := os.OpenFile(f.Name(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+	_, err, handled = spliceTo(af, serverUp.(*TCPConn).fd)
+	if err != nil || handled {
+		t.Fatalf("spliceTo(O_APPEND file) = %v, handled %t, want nil error, handled == false", err, handled)
+	}
+}
+
+// readerFromWriter is a Writer whose ReadFrom hands the copy over to
+// that of w, like the ResponseWriter of package net/http.
+type readerFromWriter struct {
+	w io.Writer
+}
+
+func (rw readerFromWriter) Write(b []byte) (int, error) { return rw.w.Write(b) }
+
+func (rw readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	return rw.w.(io.ReaderFrom).ReadFrom(r)
+}
+
+func testSpliceWriteToReaderFrom(t *testing.T) {
+	var spliced int64
+	defer func(old func(dst, src *poll.FD, remain int64) (int64, bool, string, error)) { pollSplice = old }(pollSplice)
+	pollSplice = func(dst, src *poll.FD, remain int64) (int64, bool, string, error) {
+		n, handled, sc, err := poll.Splice(dst, src, remain)
+		spliced += n
+		return n, handled, sc, err
+	}
+
+	noWrap := func(r io.Reader) io.Reader { return r }
+	for _, tt := range []struct {
+		name string
+		wrap func(io.Writer) io.Writer
+		src  func(io.Reader) io.Reader
+	}{
+		{"bufio", func(w io.Writer) io.Writer { return bufio.NewWriter(w) }, noWrap},
+		{"ReaderFrom", func(w io.Writer) io.Writer { return readerFromWriter{w} }, noWrap},
+		{"CountingReader", func(w io.Writer) io.Writer { return bufio.NewWriter(w) }, func(r io.Reader) io.Reader { return io.NewCountingReader(r) }},
+		{"CountingWriter", func(w io.Writer) io.Writer { return io.NewCountingWriter(w) }, func(r io.Reader) io.Reader { return io.NewCountingReader(r) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clientUp, serverUp, err := spliceTestSocketPair("tcp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer clientUp.Close()
+			defer serverUp.Close()
+			clientDown, serverDown, err := spliceTestSocketPair("tcp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer clientDown.Close()
+			defer serverDown.Close()
+
+			msg := "hello, world"
+			go func() {
+				io.WriteString(clientUp, msg)
+				clientUp.Close()
+			}()
+			spliced = 0
+			src := tt.src(serverUp)
+			n, err := io.Copy(tt.wrap(serverDown), src)
+			if err != nil || n != int64(len(msg)) {
+				t.Fatalf("io.Copy = %d, %v, want %d, nil", n, err, len(msg))
+			}
+			if c, ok := src.(*io.CountingReader); ok && c.Count() != n {
+				t.Errorf("CountingReader counted %d bytes, want %d", c.Count(), n)
+			}
+			if spliced != n {
+				t.Errorf("spliced %d bytes, want %d", spliced, n)
+			}
+			serverDown.Close()
+			got, err := io.ReadAll(clientDown)
+			if err != nil || string(got) != msg {
+				t.Errorf("read %q, %v, want %q, nil", got, err, msg)
+			}
+		})
+	}
+
+	// Errors from the destination are returned as they are.
+	clientUp, serverUp, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientUp.Close()
+	defer serverUp.Close()
+	go io.WriteString(clientUp, "hello")
+	errWrite := errors.New("write failed")
+	if _, err := io.Copy(errorWriter{errWrite}, serverUp); err != errWrite {
+		t.Errorf("io.Copy to failing writer = %v, want %v", err, errWrite)
+	}
+}
+
+type errorWriter struct{ err error }
+
+func (w errorWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func testSplicePipe(t *testing.T) {
+	clientUp, serverUp, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientUp.Close()
+	defer serverUp.Close()
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	// From a pipe to a TCP connection.
+	msg := "hello, world"
+	go func() {
+		io.WriteString(pw, msg)
+		pw.Close()
+	}()
+	n, err, handled := splice(serverUp.(*TCPConn).fd, pr)
+	if !handled {
+		t.Fatal("splice from a pipe not handled")
+	}
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("splice = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(clientUp, buf); err != nil || string(buf) != msg {
+		t.Fatalf("read %q, %v, want %q, nil", buf, err, msg)
+	}
+
+	// From a TCP connection to a pipe.
+	pr2, pw2, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr2.Close()
+	defer pw2.Close()
+	go func() {
+		io.WriteString(clientUp, msg)
+		clientUp.Close()
+	}()
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(pr2)
+		done <- b
+	}()
+	n, err, handled = spliceTo(pw2, serverUp.(*TCPConn).fd)
+	pw2.Close()
+	if !handled {
+		t.Fatal("spliceTo to a pipe not handled")
+	}
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("spliceTo = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	if got := <-done; string(got) != msg {
+		t.Errorf("pipe holds %q, want %q", got, msg)
+	}
+}
+
+// blockingPipe returns a pipe whose ends are not registered with the
+// runtime poller, like an inherited standard input or output.
+func blockingPipe(t *testing.T) (r, w *os.File) {
+	var p [2]int
+	if err := syscall.Pipe2(p[:], syscall.O_CLOEXEC); err != nil {
+		t.Fatal(err)
+	}
+	return os.NewFile(uintptr(p[0]), "|0"), os.NewFile(uintptr(p[1]), "|1")
+}
+
+func testSpliceBlockingPipe(t *testing.T) {
+	clientUp, serverUp, err := spliceTestSocketPair("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientUp.Close()
+	defer serverUp.Close()
+
+	// From a blocking pipe to a TCP connection, as io.Copy(conn, os.Stdin).
+	pr, pw := blockingPipe(t)
+	defer pr.Close()
+	defer pw.Close()
+	if _, err, handled := splice(serverUp.(*TCPConn).fd, pr); handled {
+		t.Fatalf("splice from a blocking pipe handled, err %v", err)
+	}
+	msg := "hello, world"
+	go func() {
+		io.WriteString(pw, msg)
+		pw.Close()
+	}()
+	if n, err := io.Copy(serverUp, pr); err != nil || n != int64(len(msg)) {
+		t.Fatalf("io.Copy(conn, pipe) = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(clientUp, buf); err != nil || string(buf) != msg {
+		t.Fatalf("read %q, %v, want %q, nil", buf, err, msg)
+	}
+
+	// From a TCP connection to a blocking pipe, as io.Copy(os.Stdout, conn).
+	pr2, pw2 := blockingPipe(t)
+	defer pr2.Close()
+	defer pw2.Close()
+	go func() {
+		io.WriteString(clientUp, msg)
+		clientUp.Close()
+	}()
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(pr2)
+		done <- b
+	}()
+	n, err := io.Copy(pw2, serverUp)
+	pw2.Close()
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("io.Copy(pipe, conn) = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	if got := <-done; string(got) != msg {
+		t.Errorf("pipe holds %q, want %q", got, msg)
+	}
+}
+
 func testSpliceNoUnixpacket(t *testing.T) {
 	clientUp, serverUp, err := spliceTestSocketPair("unixpacket")
 	if err != nil {
@@ -0,0 +1,214 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"sort"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNS resource record types for service binding records, RFC 9460.
+const (
+	dnsTypeSVCB  dnsmessage.Type = 64
+	dnsTypeHTTPS dnsmessage.Type = 65
+)
+
+// Service parameter keys, RFC 9460 Section 14.3.2.
+const (
+	svcParamMandatory     = 0
+	svcParamALPN          = 1
+	svcParamNoDefaultALPN = 2
+	svcParamPort          = 3
+	svcParamIPv4Hint      = 4
+	svcParamECH           = 5
+	svcParamIPv6Hint      = 6
+)
+
+// An SVCB represents a single DNS SVCB or HTTPS record, as defined
+// by RFC 9460.
+//
+// A record with Priority 0 is in AliasMode: it only names another
+// domain, Target, at which the service binding records are to be
+// looked up, and carries no parameters. All other records are in
+// ServiceMode.
+type SVCB struct {
+	// Priority is the record's SvcPriority. Lower values are
+	// preferred; 0 marks an alias record.
+	Priority uint16
+
+	// Target is the TargetName of the record. A Target of "."
+	// means the owner name of the record for ServiceMode records.
+	Target string
+
+	// Mandatory lists the keys that a client must understand
+	// to use the record.
+	Mandatory []uint16
+
+	// ALPN lists the application protocol identifiers
+	// supported by the endpoint, such as "h2" or "h3".
+	ALPN []string
+
+	// NoDefaultALPN reports whether the protocol's default
+	// ALPN identifier is omitted from the supported set.
+	NoDefaultALPN bool
+
+	// Port is the alternative port of the endpoint, or 0 if the
+	// record does not specify one.
+	Port uint16
+
+	// IPv4Hint and IPv6Hint hold address hints for Target.
+	IPv4Hint []IP
+	IPv6Hint []IP
+
+	// ECHConfig holds the encoded ECHConfigList to use for
+	// Encrypted Client Hello with the endpoint, if any.
+	ECHConfig []byte
+
+	// Params holds the service parameters that are not decoded
+	// into one of the fields above, keyed by SvcParamKey.
+	Params map[uint16][]byte
+}
+
+// IsAlias reports whether s is an AliasMode record.
+func (s *SVCB) IsAlias() bool {
+	return s.Priority == 0
+}
+
+// byPriority sorts SVCB records by ascending priority.
+type byPriority []*SVCB
+
+func (s byPriority) Len() int           { return len(s) }
+func (s byPriority) Less(i, j int) bool { return s[i].Priority < s[j].Priority }
+func (s byPriority) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// sort sorts SVCB records by priority, keeping the order of the
+// records in the response within a priority.
+func (s byPriority) sort() {
+	sort.Stable(s)
+}
+
+var errMalformedSVCB = errors.New("malformed SVCB record")
+
+// parseSVCB parses the RDATA of an SVCB or HTTPS record.
+func parseSVCB(b []byte) (*SVCB, error) {
+	if len(b) < 2 {
+		return nil, errMalformedSVCB
+	}
+	s := &SVCB{Priority: uint16(b[0])<<8 | uint16(b[1])}
+	target, n, ok := parseSVCBTarget(b[2:])
+	if !ok {
+		return nil, errMalformedSVCB
+	}
+	s.Target = target
+	b = b[2+n:]
+	lastKey := -1
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, errMalformedSVCB
+		}
+		key := uint16(b[0])<<8 | uint16(b[1])
+		l := int(b[2])<<8 | int(b[3])
+		if int(key) <= lastKey || len(b) < 4+l {
+			return nil, errMalformedSVCB
+		}
+		lastKey = int(key)
+		v := b[4 : 4+l]
+		b = b[4+l:]
+		if err := s.setParam(key, v); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// setParam decodes the value v of the service parameter key into s.
+func (s *SVCB) setParam(key uint16, v []byte) error {
+	switch key {
+	case svcParamMandatory:
+		if len(v) == 0 || len(v)%2 != 0 {
+			return errMalformedSVCB
+		}
+		for ; len(v) > 0; v = v[2:] {
+			s.Mandatory = append(s.Mandatory, uint16(v[0])<<8|uint16(v[1]))
+		}
+	case svcParamALPN:
+		if len(v) == 0 {
+			return errMalformedSVCB
+		}
+		for len(v) > 0 {
+			l := int(v[0])
+			if l == 0 || len(v) < 1+l {
+				return errMalformedSVCB
+			}
+			s.ALPN = append(s.ALPN, string(v[1:1+l]))
+			v = v[1+l:]
+		}
+	case svcParamNoDefaultALPN:
+		if len(v) != 0 {
+			return errMalformedSVCB
+		}
+		s.NoDefaultALPN = true
+	case svcParamPort:
+		if len(v) != 2 {
+			return errMalformedSVCB
+		}
+		s.Port = uint16(v[0])<<8 | uint16(v[1])
+	case svcParamIPv4Hint:
+		if len(v) == 0 || len(v)%IPv4len != 0 {
+			return errMalformedSVCB
+		}
+		for ; len(v) > 0; v = v[IPv4len:] {
+			s.IPv4Hint = append(s.IPv4Hint, IPv4(v[0], v[1], v[2], v[3]))
+		}
+	case svcParamECH:
+		s.ECHConfig = append([]byte(nil), v...)
+	case svcParamIPv6Hint:
+		if len(v) == 0 || len(v)%IPv6len != 0 {
+			return errMalformedSVCB
+		}
+		for ; len(v) > 0; v = v[IPv6len:] {
+			s.IPv6Hint = append(s.IPv6Hint, append(IP(nil), v[:IPv6len]...))
+		}
+	default:
+		if s.Params == nil {
+			s.Params = make(map[uint16][]byte)
+		}
+		s.Params[key] = append([]byte(nil), v...)
+	}
+	return nil
+}
+
+// parseSVCBTarget parses the uncompressed domain name at the start
+// of b, returning it in presentation format and its encoded length.
+func parseSVCBTarget(b []byte) (string, int, bool) {
+	var name []byte
+	off := 0
+	for {
+		if off >= len(b) {
+			return "", 0, false
+		}
+		l := int(b[off])
+		off++
+		if l == 0 {
+			break
+		}
+		// Compression pointers are not permitted in TargetName.
+		if l > 63 || off+l > len(b) {
+			return "", 0, false
+		}
+		name = append(name, b[off:off+l]...)
+		name = append(name, '.')
+		off += l
+		if len(name) > 254 {
+			return "", 0, false
+		}
+	}
+	if len(name) == 0 {
+		return ".", off, true
+	}
+	return string(name), off, true
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var parseSVCBTests = []struct {
+	name string
+	data []byte
+	want *SVCB
+}{
+	{
+		name: "alias",
+		data: []byte{0, 0, 3, 'f', 'o', 'o', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0},
+		want: &SVCB{Priority: 0, Target: "foo.example.com."},
+	},
+	{
+		name: "service",
+		data: []byte{
+			0, 1, 0,
+			0, 0, 0, 2, 0, 4, // mandatory=ipv4hint
+			0, 1, 0, 6, 2, 'h', '3', 2, 'h', '2', // alpn=h3,h2
+			0, 2, 0, 0, // no-default-alpn
+			0, 3, 0, 2, 0x01, 0xbb, // port=443
+			0, 4, 0, 4, 192, 0, 2, 1, // ipv4hint=192.0.2.1
+			0, 5, 0, 3, 1, 2, 3, // ech
+			0, 6, 0, 16, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, // ipv6hint=2001:db8::1
+			0x01, 0x00, 0, 1, 'x', // key256
+		},
+		want: &SVCB{
+			Priority:      1,
+			Target:        ".",
+			Mandatory:     []uint16{4},
+			ALPN:          []string{"h3", "h2"},
+			NoDefaultALPN: true,
+			Port:          443,
+			IPv4Hint:      []IP{IPv4(192, 0, 2, 1)},
+			IPv6Hint:      []IP{ParseIP("2001:db8::1")},
+			ECHConfig:     []byte{1, 2, 3},
+			Params:        map[uint16][]byte{256: []byte("x")},
+		},
+	},
+	{name: "short", data: []byte{0}},
+	{name: "truncated target", data: []byte{0, 1, 3, 'f', 'o'}},
+	{name: "compressed target", data: []byte{0, 1, 0xc0, 12}},
+	{name: "truncated param", data: []byte{0, 1, 0, 0, 3, 0, 2, 1}},
+	{name: "unordered keys", data: []byte{0, 1, 0, 0, 3, 0, 2, 1, 1, 0, 1, 0, 1, 1, 'x'}},
+	{name: "bad port", data: []byte{0, 1, 0, 0, 3, 0, 1, 1}},
+	{name: "empty alpn id", data: []byte{0, 1, 0, 0, 1, 0, 1, 0}},
+	{name: "bad ipv4hint", data: []byte{0, 1, 0, 0, 4, 0, 3, 1, 2, 3}},
+}
+
+func TestParseSVCB(t *testing.T) {
+	for _, tt := range parseSVCBTests {
+		got, err := parseSVCB(tt.data)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: parseSVCB succeeded with %+v; want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: parseSVCB: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseSVCBCopiesData(t *testing.T) {
+	data := []byte{0, 1, 0, 0, 5, 0, 1, 7}
+	s, err := parseSVCB(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] = 0
+	if !bytes.Equal(s.ECHConfig, []byte{7}) {
+		t.Errorf("ECHConfig = %v; want [7]", s.ECHConfig)
+	}
+}
@@ -35,3 +35,20 @@ func sysSocket(family, sotype, proto int) (int, error) {
 	}
 	return s, nil
 }
+
+// Wrapper around the socketpair system call that marks the returned
+// file descriptors as nonblocking and close-on-exec.
+func sysSocketPair(family, sotype, proto int) ([2]int, error) {
+	// See ../syscall/exec_unix.go for description of ForkLock.
+	syscall.ForkLock.RLock()
+	fds, err := syscall.Socketpair(family, sotype, proto)
+	if err == nil {
+		syscall.CloseOnExec(fds[0])
+		syscall.CloseOnExec(fds[1])
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return [2]int{-1, -1}, os.NewSyscallError("socketpair", err)
+	}
+	return setNonblockPair(fds)
+}
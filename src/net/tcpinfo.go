@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"syscall"
+	"time"
+)
+
+// TCPInfo holds statistics that the operating system keeps about
+// a TCP connection. Fields that the operating system does not
+// report are zero.
+type TCPInfo struct {
+	// RTT is the smoothed round-trip time, and RTTVar its
+	// variation. MinRTT is the smallest round-trip time seen.
+	RTT    time.Duration
+	RTTVar time.Duration
+	MinRTT time.Duration
+
+	// RTO is the current retransmission timeout.
+	RTO time.Duration
+
+	// SendMSS is the maximum segment size for sending, and
+	// SendCongestionWindow the congestion window, in bytes.
+	SendMSS              int
+	SendCongestionWindow int
+
+	// Retransmits is the number of segments retransmitted, and
+	// BytesRetransmitted the number of bytes in them.
+	Retransmits        uint64
+	BytesRetransmitted uint64
+
+	// BytesSent and BytesReceived count the payload bytes sent,
+	// including retransmissions, and received.
+	BytesSent     uint64
+	BytesReceived uint64
+
+	// DeliveryRate is the most recent estimate of the rate at
+	// which data is delivered to the peer, and PacingRate the
+	// rate at which the kernel paces sending, in bytes per second.
+	DeliveryRate uint64
+	PacingRate   uint64
+}
+
+// Info returns the statistics of the operating system about the
+// connection. It reads TCP_INFO on Linux and FreeBSD,
+// TCP_CONNECTION_INFO on macOS and SIO_TCP_INFO on Windows, and
+// returns an error on other systems.
+//
+// The fields reported differ between systems. Linux reports all of
+// them, though BytesSent and BytesRetransmitted need Linux 4.19 and
+// DeliveryRate Linux 4.9.
+func (c *TCPConn) Info() (TCPInfo, error) {
+	if !c.ok() {
+		return TCPInfo{}, syscall.EINVAL
+	}
+	info, err := tcpInfo(c.fd)
+	if err != nil {
+		return TCPInfo{}, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return info, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const sysTCP_CONNECTION_INFO = 0x106
+
+// sysTCPConnectionInfo is struct tcp_connection_info from netinet/tcp.h.
+type sysTCPConnectionInfo struct {
+	state, sndWscale, rcvWscale, _ uint8
+
+	options, flags, rto, maxseg              uint32
+	sndSsthresh, sndCwnd, sndWnd, sndSbbytes uint32
+	rcvWnd, rttcur, srtt, rttvar, tfoFlags   uint32
+	txPackets, txBytes, txRetransmitBytes    uint64
+	rxPackets, rxBytes, rxOutOfOrderBytes    uint64
+	txRetransmitPackets                      uint64
+}
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	var ti sysTCPConnectionInfo
+	var err error
+	size := uint32(unsafe.Sizeof(ti))
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		err = unix.Getsockopt(int(s), syscall.IPPROTO_TCP, sysTCP_CONNECTION_INFO, unsafe.Pointer(&ti), &size)
+	}); cerr != nil {
+		return TCPInfo{}, cerr
+	}
+	if err != nil {
+		return TCPInfo{}, wrapSyscallError("getsockopt", err)
+	}
+	return TCPInfo{
+		RTT:                  time.Duration(ti.srtt) * time.Millisecond,
+		RTTVar:               time.Duration(ti.rttvar) * time.Millisecond,
+		RTO:                  time.Duration(ti.rto) * time.Millisecond,
+		SendMSS:              int(ti.maxseg),
+		SendCongestionWindow: int(ti.sndCwnd),
+		Retransmits:          ti.txRetransmitPackets,
+		BytesRetransmitted:   ti.txRetransmitBytes,
+		BytesSent:            ti.txBytes,
+		BytesReceived:        ti.rxBytes,
+	}, nil
+}
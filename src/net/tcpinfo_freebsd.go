@@ -0,0 +1,51 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const sysTCP_INFO = 0x20
+
+// sysTCPInfo is struct tcp_info from netinet/tcp.h.
+type sysTCPInfo struct {
+	state, caState, retransmits, probes, backoff, options, wscale, _ uint8
+
+	rto, ato, sndMSS, rcvMSS                             uint32
+	unacked, sacked, lost, retrans, fackets              uint32
+	lastDataSent, lastAckSent, lastDataRecv, lastAckRecv uint32
+	pmtu, rcvSsthresh, rtt, rttvar                       uint32
+	sndSsthresh, sndCwnd, advMSS, reordering             uint32
+	rcvRTT, rcvSpace, sndWnd, sndBwnd                    uint32
+	sndNxt, rcvNxt, toeTid, sndRexmitPack                uint32
+	rcvOOOPack, sndZerowin                               uint32
+	_                                                    [26]uint32
+}
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	var ti sysTCPInfo
+	var err error
+	size := uint32(unsafe.Sizeof(ti))
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		err = unix.Getsockopt(int(s), syscall.IPPROTO_TCP, sysTCP_INFO, unsafe.Pointer(&ti), &size)
+	}); cerr != nil {
+		return TCPInfo{}, cerr
+	}
+	if err != nil {
+		return TCPInfo{}, wrapSyscallError("getsockopt", err)
+	}
+	return TCPInfo{
+		RTT:                  time.Duration(ti.rtt) * time.Microsecond,
+		RTTVar:               time.Duration(ti.rttvar) * time.Microsecond,
+		RTO:                  time.Duration(ti.rto) * time.Microsecond,
+		SendMSS:              int(ti.sndMSS),
+		SendCongestionWindow: int(ti.sndCwnd),
+		Retransmits:          uint64(ti.sndRexmitPack),
+	}, nil
+}
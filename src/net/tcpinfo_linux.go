@@ -0,0 +1,73 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// sysTCP_INFO is missing from package syscall on some architectures.
+const sysTCP_INFO = 0xb
+
+// sysTCPInfo is struct tcp_info from linux/tcp.h, up to tcpi_snd_wnd.
+// Older kernels fill in only a prefix of it.
+type sysTCPInfo struct {
+	state, caState, retransmits, probes, backoff, options, wscale, flags uint8
+
+	rto, ato, sndMSS, rcvMSS                             uint32
+	unacked, sacked, lost, retrans, fackets              uint32
+	lastDataSent, lastAckSent, lastDataRecv, lastAckRecv uint32
+	pmtu, rcvSsthresh, rtt, rttvar                       uint32
+	sndSsthresh, sndCwnd, advMSS, reordering             uint32
+	rcvRTT, rcvSpace, totalRetrans                       uint32
+
+	pacingRate, maxPacingRate, bytesAcked, bytesReceived uint64
+
+	segsOut, segsIn, notsentBytes, minRTT, dataSegsIn, dataSegsOut uint32
+
+	deliveryRate, busyTime, rwndLimited, sndbufLimited uint64
+
+	delivered, deliveredCE uint32
+
+	bytesSent, bytesRetrans uint64
+
+	dsackDups, reordSeen, rcvOOOPack, sndWnd uint32
+}
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	var ti sysTCPInfo
+	var err error
+	size := uint32(unsafe.Sizeof(ti))
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		err = unix.Getsockopt(int(s), syscall.IPPROTO_TCP, sysTCP_INFO, unsafe.Pointer(&ti), &size)
+	}); cerr != nil {
+		return TCPInfo{}, cerr
+	}
+	if err != nil {
+		return TCPInfo{}, wrapSyscallError("getsockopt", err)
+	}
+	return ti.info(), nil
+}
+
+// info converts ti to a TCPInfo.
+func (ti *sysTCPInfo) info() TCPInfo {
+	return TCPInfo{
+		RTT:                  time.Duration(ti.rtt) * time.Microsecond,
+		RTTVar:               time.Duration(ti.rttvar) * time.Microsecond,
+		MinRTT:               time.Duration(ti.minRTT) * time.Microsecond,
+		RTO:                  time.Duration(ti.rto) * time.Microsecond,
+		SendMSS:              int(ti.sndMSS),
+		SendCongestionWindow: int(ti.sndCwnd) * int(ti.sndMSS),
+		Retransmits:          uint64(ti.totalRetrans),
+		BytesRetransmitted:   ti.bytesRetrans,
+		BytesSent:            ti.bytesSent,
+		BytesReceived:        ti.bytesReceived,
+		DeliveryRate:         ti.deliveryRate,
+		PacingRate:           ti.pacingRate,
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !freebsd && !linux && !plan9 && !windows
+// +build !darwin,!freebsd,!linux,!plan9,!windows
+
+package net
+
+import "syscall"
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	return TCPInfo{}, syscall.ENOPROTOOPT
+}
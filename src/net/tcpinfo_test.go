@@ -0,0 +1,61 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || freebsd || linux || windows
+// +build darwin freebsd linux windows
+
+package net
+
+import (
+	"io"
+	"testing"
+)
+
+func TestTCPConnInfo(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	const n = 1 << 20
+	done := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer c.Close()
+		_, err = io.CopyN(io.Discard, c, n)
+		done <- err
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Write(make([]byte, n)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := c.(*TCPConn).Info()
+	if err != nil {
+		t.Skipf("Info: %v", err)
+	}
+	t.Logf("%+v", info)
+	if info.SendMSS <= 0 {
+		t.Errorf("SendMSS = %d; want > 0", info.SendMSS)
+	}
+	if info.SendCongestionWindow <= 0 {
+		t.Errorf("SendCongestionWindow = %d; want > 0", info.SendCongestionWindow)
+	}
+	if info.BytesSent != 0 && info.BytesSent < n {
+		t.Errorf("BytesSent = %d; want at least %d", info.BytesSent, n)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"os"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// sysSIO_TCP_INFO is _WSAIORW(IOC_VENDOR, 39), which needs
+// Windows 10 1703.
+const sysSIO_TCP_INFO = 0xd8000027
+
+// sysTCPInfoV0 is TCP_INFO_v0 from mstcpip.h.
+type sysTCPInfoV0 struct {
+	state             int32
+	mss               uint32
+	connectionTimeMs  uint64
+	timestampsEnabled bool
+	rttUs, minRttUs   uint32
+	bytesInFlight     uint32
+	cwnd, sndWnd      uint32
+	rcvWnd, rcvBuf    uint32
+	bytesOut, bytesIn uint64
+	bytesReordered    uint32
+	bytesRetrans      uint32
+	fastRetrans       uint32
+	dupAcksIn         uint32
+	timeoutEpisodes   uint32
+	synRetrans        uint8
+}
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	var ti sysTCPInfoV0
+	version := uint32(0)
+	ret := uint32(0)
+	err := fd.pfd.WSAIoctl(sysSIO_TCP_INFO, (*byte)(unsafe.Pointer(&version)), uint32(unsafe.Sizeof(version)), (*byte)(unsafe.Pointer(&ti)), uint32(unsafe.Sizeof(ti)), &ret, nil, 0)
+	runtime.KeepAlive(fd)
+	if err != nil {
+		return TCPInfo{}, os.NewSyscallError("wsaioctl", err)
+	}
+	return TCPInfo{
+		RTT:                  time.Duration(ti.rttUs) * time.Microsecond,
+		MinRTT:               time.Duration(ti.minRttUs) * time.Microsecond,
+		SendMSS:              int(ti.mss),
+		SendCongestionWindow: int(ti.cwnd),
+		BytesRetransmitted:   uint64(ti.bytesRetrans),
+		BytesSent:            ti.bytesOut,
+		BytesReceived:        ti.bytesIn,
+	}, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ErrUnsupportedOption is returned, wrapped in an *OpError, when a
+// socket option is not supported by the operating system.
+var ErrUnsupportedOption = errors.New("socket option not supported")
+
+// TCPOptions holds options of a TCP connection, as reported by
+// TCPConn.Options and set by TCPConn.SetOptions. Options that the
+// operating system does not support are reported as zero.
+type TCPOptions struct {
+	// NoDelay disables Nagle's algorithm, as with SetNoDelay.
+	NoDelay bool
+
+	// QuickAck makes the connection acknowledge segments as soon
+	// as they arrive rather than delay the acknowledgements. The
+	// system turns it off again as it sees fit, so it may need to
+	// be set repeatedly. Only Linux supports QuickAck.
+	QuickAck bool
+
+	// MaxSegment is the maximum segment size of the connection,
+	// the most bytes of data sent in a segment. Setting it can
+	// only lower the size that the system chose. MaxSegment is
+	// supported on all systems but js/wasm and Plan 9, and on
+	// Windows 10 version 1607 and later.
+	MaxSegment int
+
+	// NotSentLowat limits the number of bytes written to the
+	// connection that have not been sent yet, beyond which the
+	// connection is not writable, so that writes of fresh data
+	// wait rather than queue behind stale data. If zero, the
+	// system default applies. NotSentLowat is supported on Linux
+	// and macOS.
+	NotSentLowat int
+
+	// CongestionControl is the name of the congestion control
+	// algorithm of the connection, such as "cubic" or "bbr".
+	// CongestionControl is supported on Linux and FreeBSD.
+	CongestionControl string
+}
+
+// Options returns the options of the connection.
+func (c *TCPConn) Options() (TCPOptions, error) {
+	if !c.ok() {
+		return TCPOptions{}, syscall.EINVAL
+	}
+	o, err := tcpOptions(c.fd)
+	if err != nil {
+		return TCPOptions{}, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return o, nil
+}
+
+// SetOptions sets the options of the connection to o. Only the
+// options whose values differ from those reported by Options are
+// set, so that
+//
+//	o, err := c.Options()
+//	...
+//	o.MaxSegment = 1200
+//	err = c.SetOptions(o)
+//
+// changes only the maximum segment size. If the operating system
+// does not support an option that is changed, SetOptions returns an
+// error wrapping ErrUnsupportedOption. The options are set in the
+// order of the fields of TCPOptions, and those preceding an option
+// that fails to be set remain set.
+func (c *TCPConn) SetOptions(o TCPOptions) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setTCPOptions(c.fd, o); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
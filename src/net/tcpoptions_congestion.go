@@ -0,0 +1,49 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build freebsd || linux
+// +build freebsd linux
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+// tcpCongestionNameMax is the size of the buffer for the names of
+// congestion control algorithms, TCP_CA_NAME_MAX.
+const tcpCongestionNameMax = 16
+
+func tcpCongestionControl(fd *netFD) (string, error) {
+	var b [tcpCongestionNameMax]byte
+	l := uint32(len(b))
+	var serr error
+	err := fd.pfd.RawControl(func(s uintptr) {
+		serr = unix.Getsockopt(int(s), syscall.IPPROTO_TCP, sysTCP_CONGESTION, unsafe.Pointer(&b[0]), &l)
+	})
+	if err != nil {
+		return "", err
+	}
+	if serr != nil {
+		return "", wrapSyscallError("getsockopt", serr)
+	}
+	n := 0
+	for n < int(l) && b[n] != 0 {
+		n++
+	}
+	return string(b[:n]), nil
+}
+
+func setTCPCongestionControl(fd *netFD, name string) error {
+	var serr error
+	err := fd.pfd.RawControl(func(s uintptr) {
+		serr = syscall.SetsockoptString(int(s), syscall.IPPROTO_TCP, sysTCP_CONGESTION, name)
+	})
+	if err != nil {
+		return err
+	}
+	return wrapSyscallError("setsockopt", serr)
+}
@@ -0,0 +1,16 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly netbsd openbsd solaris windows
+
+package net
+
+func tcpCongestionControl(fd *netFD) (string, error) {
+	return "", ErrUnsupportedOption
+}
+
+func setTCPCongestionControl(fd *netFD, name string) error {
+	return ErrUnsupportedOption
+}
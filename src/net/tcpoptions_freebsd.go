@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "syscall"
+
+const (
+	sysTCP_QUICKACK      = 0 // not supported
+	sysTCP_MAXSEG        = syscall.TCP_MAXSEG
+	sysTCP_NOTSENT_LOWAT = 0 // not supported
+	sysTCP_CONGESTION    = 0x40
+
+	// sysENOPROTOOPT is the error for options that the system
+	// does not support.
+	sysENOPROTOOPT = syscall.ENOPROTOOPT
+)
@@ -0,0 +1,102 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package net
+
+import (
+	"runtime"
+	"syscall"
+)
+
+func tcpOptions(fd *netFD) (TCPOptions, error) {
+	var o TCPOptions
+	noDelay, err := tcpOptionInt(fd, syscall.TCP_NODELAY)
+	if err != nil {
+		return o, err
+	}
+	o.NoDelay = noDelay != 0
+	quickAck, err := tcpOptionInt(fd, sysTCP_QUICKACK)
+	if err != nil {
+		return o, err
+	}
+	o.QuickAck = quickAck != 0
+	if o.MaxSegment, err = tcpOptionInt(fd, sysTCP_MAXSEG); err != nil {
+		return o, err
+	}
+	if o.NotSentLowat, err = tcpOptionInt(fd, sysTCP_NOTSENT_LOWAT); err != nil {
+		return o, err
+	}
+	if sysTCP_CONGESTION != 0 {
+		if o.CongestionControl, err = tcpCongestionControl(fd); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+func setTCPOptions(fd *netFD, o TCPOptions) error {
+	old, err := tcpOptions(fd)
+	if err != nil {
+		return err
+	}
+	if o.NoDelay != old.NoDelay {
+		if err := setNoDelay(fd, o.NoDelay); err != nil {
+			return err
+		}
+	}
+	if o.QuickAck != old.QuickAck {
+		if err := setTCPOptionInt(fd, sysTCP_QUICKACK, boolint(o.QuickAck)); err != nil {
+			return err
+		}
+	}
+	if o.MaxSegment != old.MaxSegment {
+		if err := setTCPOptionInt(fd, sysTCP_MAXSEG, o.MaxSegment); err != nil {
+			return err
+		}
+	}
+	if o.NotSentLowat != old.NotSentLowat {
+		if err := setTCPOptionInt(fd, sysTCP_NOTSENT_LOWAT, o.NotSentLowat); err != nil {
+			return err
+		}
+	}
+	if o.CongestionControl != old.CongestionControl {
+		if sysTCP_CONGESTION == 0 {
+			return ErrUnsupportedOption
+		}
+		if err := setTCPCongestionControl(fd, o.CongestionControl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tcpOptionInt returns the value of the TCP option name, or zero
+// if the system does not support the option: name is zero, or the
+// running version of the system rejects it.
+func tcpOptionInt(fd *netFD, name int) (int, error) {
+	if name == 0 {
+		return 0, nil
+	}
+	v, err := fd.pfd.GetsockoptInt(syscall.IPPROTO_TCP, name)
+	runtime.KeepAlive(fd)
+	if err == sysENOPROTOOPT {
+		return 0, nil
+	}
+	return v, wrapSyscallError("getsockopt", err)
+}
+
+func setTCPOptionInt(fd *netFD, name, v int) error {
+	if name == 0 {
+		return ErrUnsupportedOption
+	}
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_TCP, name, v)
+	runtime.KeepAlive(fd)
+	if err == sysENOPROTOOPT {
+		return ErrUnsupportedOption
+	}
+	return wrapSyscallError("setsockopt", err)
+}
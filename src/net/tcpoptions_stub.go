@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build js && wasm
+// +build js,wasm
+
+package net
+
+import "syscall"
+
+func tcpOptions(fd *netFD) (TCPOptions, error) {
+	return TCPOptions{}, syscall.ENOPROTOOPT
+}
+
+func setTCPOptions(fd *netFD, o TCPOptions) error {
+	return syscall.ENOPROTOOPT
+}
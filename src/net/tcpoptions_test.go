@@ -0,0 +1,47 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !js && !plan9
+// +build !js,!plan9
+
+package net
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestTCPConnSetOptionsUnsupported(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := c.(*TCPConn)
+
+	o, err := tc.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.NoDelay {
+		t.Error("NoDelay is not set by default")
+	}
+	// Setting the options unchanged succeeds everywhere.
+	if err := tc.SetOptions(o); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "linux" {
+		o.QuickAck = !o.QuickAck
+		if err := tc.SetOptions(o); !errors.Is(err, ErrUnsupportedOption) {
+			t.Errorf("got %v; want ErrUnsupportedOption", err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "syscall"
+
+const (
+	sysTCP_QUICKACK      = 0 // not supported
+	sysTCP_MAXSEG        = 0x4
+	sysTCP_NOTSENT_LOWAT = 0 // not supported
+	sysTCP_CONGESTION    = 0 // not supported
+
+	// sysENOPROTOOPT is WSAENOPROTOOPT, the error for options
+	// that the system does not support, such as TCP_MAXSEG before
+	// Windows 10 version 1607.
+	sysENOPROTOOPT = syscall.Errno(10042)
+)
@@ -108,6 +108,32 @@ func (c *TCPConn) ReadFrom(r io.Reader) (int64, error) {
 	return n, err
 }
 
+// WriteTo implements the io.WriterTo WriteTo method.
+//
+// On Linux, when w is a TCP or a stream-oriented Unix connection,
+// the data is moved between the sockets with the splice system call
+// and does not pass through user space. So is it when w is an *os.File
+// for a regular file not opened with O_APPEND, which is written at its
+// current offset. A w that implements io.WriterUnwrapper is unwrapped
+// first. Otherwise, the data is copied as by io.Copy, which passes c
+// to the ReadFrom method of w if it has one, so that w may still
+// splice from c. Errors from writing to w are returned as they are.
+func (c *TCPConn) WriteTo(w io.Writer) (int64, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err, handled := spliceTo(w, c.fd)
+	if !handled {
+		// Errors from reading c are *OpErrors already,
+		// and those from writing to w are returned as they are.
+		return genericWriteTo(c, w)
+	}
+	if err != nil && err != io.EOF {
+		err = &OpError{Op: "writeto", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
 // CloseRead shuts down the reading side of the TCP connection.
 // Most callers should just use Close.
 func (c *TCPConn) CloseRead() error {
@@ -177,6 +203,51 @@ func (c *TCPConn) SetKeepAlivePeriod(d time.Duration) error {
 	return nil
 }
 
+// SetKeepAliveInterval sets the time between keep-alive probes once
+// probing has begun, leaving the idle time before the first probe as
+// set by SetKeepAlivePeriod, which sets both.
+func (c *TCPConn) SetKeepAliveInterval(d time.Duration) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setKeepAliveInterval(c.fd, d); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetKeepAliveCount sets the number of unacknowledged keep-alive
+// probes after which the operating system considers the connection
+// dead and closes it.
+func (c *TCPConn) SetKeepAliveCount(count int) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setKeepAliveCount(c.fd, count); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetUserTimeout sets how long data written to the connection may
+// remain unacknowledged by the peer before the operating system
+// closes the connection, as with the TCP_USER_TIMEOUT option of
+// RFC 5482. Unlike keep-alives, it detects a dead peer while there
+// is data in flight.
+//
+// SetUserTimeout is supported on Linux, macOS, Solaris and Windows.
+// On all but Solaris, a zero duration restores the system default.
+// macOS and Windows round the timeout up to whole seconds.
+func (c *TCPConn) SetUserTimeout(d time.Duration) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setUserTimeout(c.fd, d); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
 // SetNoDelay controls whether the operating system should delay
 // packet transmission in hopes of sending fewer packets (Nagle's
 // algorithm).  The default is true (no delay), meaning that data is
@@ -191,6 +262,28 @@ func (c *TCPConn) SetNoDelay(noDelay bool) error {
 	return nil
 }
 
+// SetZeroCopyWrites controls whether large writes on the connection
+// are sent without copying the data into the kernel. The default is
+// false.
+//
+// With zero-copy writes on, a Write of a large buffer does not return
+// until the kernel has finished transmitting from the buffer, which
+// for TCP is when the peer has acknowledged the data. If such a Write
+// fails, for example because the write deadline passes, the kernel may
+// still read the buffer, so the caller should not change it.
+//
+// Zero-copy writes use MSG_ZEROCOPY and are only supported on Linux
+// 4.14 and later.
+func (c *TCPConn) SetZeroCopyWrites(on bool) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setZeroCopyWrites(c.fd, on); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
 func newTCPConn(fd *netFD) *TCPConn {
 	c := &TCPConn{conn{fd}}
 	setNoDelay(c.fd, true)
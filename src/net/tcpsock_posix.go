@@ -12,6 +12,7 @@ import (
 	"io"
 	"os"
 	"syscall"
+	"time"
 )
 
 func sockaddrToTCP(sa syscall.Sockaddr) Addr {
@@ -63,7 +64,14 @@ func (sd *sysDialer) dialTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCPCo
 }
 
 func (sd *sysDialer) doDialTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCPConn, error) {
-	fd, err := internetSocket(ctx, sd.network, laddr, raddr, syscall.SOCK_STREAM, 0, "dial", sd.Dialer.Control)
+	if sd.MultipathTCP {
+		return sd.dialMPTCP(ctx, laddr, raddr)
+	}
+	return sd.doDialTCPProto(ctx, laddr, raddr, 0)
+}
+
+func (sd *sysDialer) doDialTCPProto(ctx context.Context, laddr, raddr *TCPAddr, proto int) (*TCPConn, error) {
+	fd, err := internetSocket(ctx, sd.network, laddr, raddr, syscall.SOCK_STREAM, proto, "dial", sd.control())
 
 	// TCP has a rarely used mechanism called a 'simultaneous connection' in
 	// which Dial("tcp", addr1, addr2) run on the machine at addr1 can
@@ -93,7 +101,7 @@ func (sd *sysDialer) doDialTCP(ctx context.Context, laddr, raddr *TCPAddr) (*TCP
 		if err == nil {
 			fd.Close()
 		}
-		fd, err = internetSocket(ctx, sd.network, laddr, raddr, syscall.SOCK_STREAM, 0, "dial", sd.Dialer.Control)
+		fd, err = internetSocket(ctx, sd.network, laddr, raddr, syscall.SOCK_STREAM, proto, "dial", sd.control())
 	}
 
 	if err != nil {
@@ -150,6 +158,10 @@ func (ln *TCPListener) accept() (*TCPConn, error) {
 		}
 		setKeepAlivePeriod(fd, ka)
 	}
+	if err := ln.lc.connControl(fd); err != nil {
+		fd.Close()
+		return nil, err
+	}
 	return tc, nil
 }
 
@@ -166,9 +178,41 @@ func (ln *TCPListener) file() (*os.File, error) {
 }
 
 func (sl *sysListener) listenTCP(ctx context.Context, laddr *TCPAddr) (*TCPListener, error) {
-	fd, err := internetSocket(ctx, sl.network, laddr, nil, syscall.SOCK_STREAM, 0, "listen", sl.ListenConfig.Control)
+	if sl.MultipathTCP {
+		return sl.listenMPTCP(ctx, laddr)
+	}
+	return sl.listenTCPProto(ctx, laddr, 0)
+}
+
+func (sl *sysListener) listenTCPProto(ctx context.Context, laddr *TCPAddr, proto int) (*TCPListener, error) {
+	fd, err := internetSocket(ctx, sl.network, laddr, nil, syscall.SOCK_STREAM, proto, "listen", sl.control())
 	if err != nil {
 		return nil, err
 	}
+	if sl.DeferAccept > 0 || sl.AcceptFilter != "" {
+		if err := sl.setDeferAccept(fd); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
 	return &TCPListener{fd: fd, lc: sl.ListenConfig}, nil
 }
+
+// setDeferAccept applies the DeferAccept and AcceptFilter options
+// to the listening socket fd. Unlike the options set by control, they
+// are set after listen, as accept filters can only be installed on
+// listening sockets.
+func (sl *sysListener) setDeferAccept(fd *netFD) error {
+	var serr error
+	err := fd.pfd.RawControl(func(s uintptr) {
+		if sl.AcceptFilter != "" {
+			serr = setAcceptFilter(s, sl.AcceptFilter)
+		} else {
+			serr = setDeferAccept(s, int((sl.DeferAccept+time.Second-1)/time.Second))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return serr
+}
@@ -8,6 +8,8 @@
 package net
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"internal/testenv"
 	"io"
@@ -824,3 +826,130 @@ func BenchmarkSetReadDeadline(b *testing.B) {
 		deadline = deadline.Add(1)
 	}
 }
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestTCPReadFromReportsFailedSide(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err := Dial(ln.Addr().Network(), ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	errRead := errors.New("source failed")
+	_, err = client.(*TCPConn).ReadFrom(failingReader{errRead})
+	var ce *io.CopyError
+	if !errors.As(err, &ce) || ce.Op != "read" || !errors.Is(err, errRead) {
+		t.Errorf("ReadFrom error = %v; want read error wrapping %v", err, errRead)
+	}
+}
+
+func TestTCPCopyDeadline(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	peers := make(chan Conn, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			peers <- c
+		}
+	}()
+	src, err := Dial(ln.Addr().Network(), ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := Dial(ln.Addr().Network(), ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	for i := 0; i < 2; i++ {
+		defer (<-peers).Close()
+	}
+
+	// The copy, which may splice, must report the deadline of src
+	// as a timeout, even though it says which side failed.
+	src.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err = io.Copy(dst, src)
+	if ne, ok := err.(Error); !ok || !ne.Timeout() {
+		t.Errorf("io.Copy error = %v; want a timeout net.Error", err)
+	}
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("io.Copy error = %v; want one wrapping os.ErrDeadlineExceeded", err)
+	}
+}
+
+func TestDialFastOpen(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	lc := ListenConfig{FastOpen: true}
+	ln, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// The first connection fetches a Fast Open cookie, where
+	// supported, and the second may send its data in the SYN.
+	for i := 0; i < 2; i++ {
+		msg := fmt.Sprintf("hello %d", i)
+		errc := make(chan error, 1)
+		go func() {
+			c, err := ln.Accept()
+			if err != nil {
+				errc <- err
+				return
+			}
+			defer c.Close()
+			b := make([]byte, len(msg))
+			if _, err := io.ReadFull(c, b); err != nil {
+				errc <- err
+				return
+			}
+			if string(b) != msg {
+				errc <- fmt.Errorf("got %q; want %q", b, msg)
+				return
+			}
+			_, err = c.Write(b)
+			errc <- err
+		}()
+
+		var d Dialer
+		c, err := d.DialFastOpen(context.Background(), "tcp", ln.Addr().String(), []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, len(msg))
+		if _, err := io.ReadFull(c, b); err != nil {
+			t.Error(err)
+		} else if string(b) != msg {
+			t.Errorf("got %q; want %q", b, msg)
+		}
+		c.Close()
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+	}
+
+	var d Dialer
+	if _, err := d.DialFastOpen(context.Background(), "udp", ln.Addr().String(), []byte("x")); err == nil {
+		t.Error("DialFastOpen succeeded on udp network")
+	}
+}
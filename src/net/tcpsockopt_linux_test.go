@@ -0,0 +1,112 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTCPConnTimeoutOptions(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := c.(*TCPConn)
+
+	if err := tc.SetKeepAlivePeriod(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.SetKeepAliveInterval(1500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.SetKeepAliveCount(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.SetUserTimeout(2500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := tc.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		opt  int
+		want int
+	}{
+		{"TCP_KEEPIDLE", syscall.TCP_KEEPIDLE, 30},
+		{"TCP_KEEPINTVL", syscall.TCP_KEEPINTVL, 2},
+		{"TCP_KEEPCNT", syscall.TCP_KEEPCNT, 4},
+		{"TCP_USER_TIMEOUT", sysTCP_USER_TIMEOUT, 2500},
+	} {
+		var got int
+		var serr error
+		if err := rc.Control(func(s uintptr) {
+			got, serr = syscall.GetsockoptInt(int(s), syscall.IPPROTO_TCP, tt.opt)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if serr != nil {
+			t.Errorf("getsockopt %s: %v", tt.name, serr)
+		} else if got != tt.want {
+			t.Errorf("%s = %d; want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTCPConnOptions(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := c.(*TCPConn)
+
+	o, err := tc.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.NoDelay || o.MaxSegment == 0 || o.CongestionControl == "" {
+		t.Fatalf("got %+v; want NoDelay, MaxSegment and CongestionControl set", o)
+	}
+	o.NoDelay = false
+	o.MaxSegment = 1200
+	o.NotSentLowat = 16384
+	o.CongestionControl = "reno"
+	if err := tc.SetOptions(o); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tc.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The connection reports its current segment size, which
+	// only becomes the one set with the next segments.
+	got.MaxSegment = o.MaxSegment
+	if got != o {
+		t.Errorf("got %+v; want %+v", got, o)
+	}
+
+	o.CongestionControl = "no such algorithm"
+	if err := tc.SetOptions(o); err == nil {
+		t.Error("SetOptions succeeded with an unknown congestion control algorithm")
+	}
+}
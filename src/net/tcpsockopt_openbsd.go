@@ -14,3 +14,15 @@ func setKeepAlivePeriod(fd *netFD, d time.Duration) error {
 	// options.
 	return syscall.ENOPROTOOPT
 }
+
+func setKeepAliveInterval(fd *netFD, d time.Duration) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setKeepAliveCount(fd *netFD, count int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setUserTimeout(fd *netFD, d time.Duration) error {
+	return syscall.ENOPROTOOPT
+}
@@ -16,9 +16,57 @@ func setNoDelay(fd *netFD, noDelay bool) error {
 	return syscall.EPLAN9
 }
 
+func setZeroCopyWrites(fd *netFD, on bool) error {
+	return syscall.EPLAN9
+}
+
 // Set keep alive period.
 func setKeepAlivePeriod(fd *netFD, d time.Duration) error {
 	cmd := "keepalive " + itoa.Itoa(int(d/time.Millisecond))
 	_, e := fd.ctl.WriteAt([]byte(cmd), 0)
 	return e
 }
+
+func setKeepAliveInterval(fd *netFD, d time.Duration) error {
+	return syscall.EPLAN9
+}
+
+func setKeepAliveCount(fd *netFD, count int) error {
+	return syscall.EPLAN9
+}
+
+func setUserTimeout(fd *netFD, d time.Duration) error {
+	return syscall.EPLAN9
+}
+
+func tcpInfo(fd *netFD) (TCPInfo, error) {
+	return TCPInfo{}, syscall.EPLAN9
+}
+
+func mptcpEnabled(fd *netFD) (bool, error) {
+	return false, nil
+}
+
+func mptcpInfo(fd *netFD) (MultipathTCPInfo, error) {
+	return MultipathTCPInfo{}, syscall.EPLAN9
+}
+
+func mptcpSubflows(fd *netFD) ([]MultipathSubflow, error) {
+	return nil, syscall.EPLAN9
+}
+
+func mptcpAddSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return syscall.EPLAN9
+}
+
+func mptcpRemoveSubflow(fd *netFD, laddr, raddr *TCPAddr) error {
+	return syscall.EPLAN9
+}
+
+func tcpOptions(fd *netFD) (TCPOptions, error) {
+	return TCPOptions{}, syscall.EPLAN9
+}
+
+func setTCPOptions(fd *netFD, o TCPOptions) error {
+	return syscall.EPLAN9
+}
@@ -19,3 +19,15 @@ func setNoDelay(fd *netFD, noDelay bool) error {
 func setKeepAlivePeriod(fd *netFD, d time.Duration) error {
 	return syscall.ENOPROTOOPT
 }
+
+func setKeepAliveInterval(fd *netFD, d time.Duration) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setKeepAliveCount(fd *netFD, count int) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setUserTimeout(fd *netFD, d time.Duration) error {
+	return syscall.ENOPROTOOPT
+}
@@ -27,3 +27,36 @@ func setKeepAlivePeriod(fd *netFD, d time.Duration) error {
 	runtime.KeepAlive(fd)
 	return os.NewSyscallError("wsaioctl", err)
 }
+
+// Socket options from ws2ipdef.h, which package syscall does not
+// define. TCP_KEEPCNT and TCP_KEEPINTVL need Windows 10 1709.
+const (
+	sysTCP_MAXRT     = 5
+	sysTCP_KEEPCNT   = 16
+	sysTCP_KEEPINTVL = 17
+)
+
+func setKeepAliveInterval(fd *netFD, d time.Duration) error {
+	// The kernel expects seconds so round to next highest second.
+	secs := int(roundDurationUp(d, time.Second))
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_TCP, sysTCP_KEEPINTVL, secs)
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+func setKeepAliveCount(fd *netFD, count int) error {
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_TCP, sysTCP_KEEPCNT, count)
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+func setUserTimeout(fd *netFD, d time.Duration) error {
+	// TCP_MAXRT expects seconds, and -1 for the system default.
+	secs := int(roundDurationUp(d, time.Second))
+	if d == 0 {
+		secs = -1
+	}
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_TCP, sysTCP_MAXRT, secs)
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// udpControlSpace is enough room for the ancillary data
+// of all the fields of a UDPControl.
+const udpControlSpace = 256
+
+// udpControlOptions are the socket options that turn on
+// reporting of the fields of UDPControl.
+var udpControlOptions = []struct {
+	flag         UDPControlFlags
+	name4, name6 int
+}{
+	{UDPControlDst, syscall.IP_PKTINFO, syscall.IPV6_RECVPKTINFO},
+	{UDPControlHopLimit, syscall.IP_RECVTTL, syscall.IPV6_RECVHOPLIMIT},
+	{UDPControlTrafficClass, syscall.IP_RECVTOS, syscall.IPV6_RECVTCLASS},
+}
+
+func setReadControl(fd *netFD, flags UDPControlFlags, on bool) error {
+	defer runtime.KeepAlive(fd)
+	for _, o := range udpControlOptions {
+		if flags&o.flag == 0 {
+			continue
+		}
+		// An IPv6 socket takes the IPv4 options too, which
+		// apply to the datagrams it receives over IPv4.
+		if fd.family == syscall.AF_INET6 {
+			if err := fd.pfd.SetsockoptInt(syscall.IPPROTO_IPV6, o.name6, boolint(on)); err != nil {
+				return wrapSyscallError("setsockopt", err)
+			}
+		}
+		if err := fd.pfd.SetsockoptInt(syscall.IPPROTO_IP, o.name4, boolint(on)); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	return nil
+}
+
+func (c *UDPConn) readMsgControl(b []byte, cm *UDPControl) (int, *UDPAddr, error) {
+	// The buffer is allocated rather than declared as an array so
+	// that the control messages in it are suitably aligned.
+	oob := make([]byte, udpControlSpace)
+	n, oobn, _, addr, err := c.readMsg(b, oob)
+	if cm != nil {
+		parseUDPControl(oob[:oobn], cm)
+	}
+	return n, addr, err
+}
+
+func (c *UDPConn) writeMsgControl(b []byte, cm *UDPControl, addr *UDPAddr) (int, error) {
+	var oob []byte
+	if cm != nil {
+		var dst *UDPAddr
+		if addr != nil {
+			dst = addr
+		} else if raddr, ok := c.fd.raddr.(*UDPAddr); ok {
+			dst = raddr
+		}
+		var err error
+		oob, err = appendUDPControl(make([]byte, 0, udpControlSpace), c.fd, cm, dst)
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, _, err := c.writeMsg(b, oob, addr)
+	return n, err
+}
+
+// appendUDPControl appends to b the control messages setting the
+// non-zero fields of cm for a datagram sent on fd to dst.
+func appendUDPControl(b []byte, fd *netFD, cm *UDPControl, dst *UDPAddr) ([]byte, error) {
+	var data []byte
+	if cm.Src != nil || cm.IfIndex != 0 {
+		switch fd.family {
+		case syscall.AF_INET:
+			src := IPv4zero.To4()
+			if cm.Src != nil {
+				if src = cm.Src.To4(); src == nil {
+					return nil, &AddrError{Err: "non-IPv4 address", Addr: cm.Src.String()}
+				}
+			}
+			b, data = appendCmsg(b, syscall.IPPROTO_IP, syscall.IP_PKTINFO, syscall.SizeofInet4Pktinfo)
+			pi := (*syscall.Inet4Pktinfo)(unsafe.Pointer(&data[0]))
+			pi.Ifindex = int32(cm.IfIndex)
+			copy(pi.Spec_dst[:], src)
+		case syscall.AF_INET6:
+			// The kernel takes the source of a datagram to an
+			// IPv4 destination from an IPv4-mapped address.
+			src := IPv6zero
+			if dst != nil && dst.IP.To4() != nil {
+				src = IPv4zero
+			}
+			if cm.Src != nil {
+				if src = cm.Src.To16(); src == nil {
+					return nil, &AddrError{Err: "non-IPv6 address", Addr: cm.Src.String()}
+				}
+			}
+			b, data = appendCmsg(b, syscall.IPPROTO_IPV6, syscall.IPV6_PKTINFO, syscall.SizeofInet6Pktinfo)
+			pi := (*syscall.Inet6Pktinfo)(unsafe.Pointer(&data[0]))
+			pi.Ifindex = uint32(cm.IfIndex)
+			copy(pi.Addr[:], src.To16())
+		}
+	}
+	if cm.HopLimit != 0 {
+		// As with the TOS, the kernel ignores the IPv4 option for
+		// IPv6 destinations and the IPv6 one for IPv4 destinations.
+		if fd.family == syscall.AF_INET6 {
+			b, data = appendCmsg(b, syscall.IPPROTO_IPV6, syscall.IPV6_HOPLIMIT, 4)
+			*(*int32)(unsafe.Pointer(&data[0])) = int32(cm.HopLimit)
+		}
+		b, data = appendCmsg(b, syscall.IPPROTO_IP, syscall.IP_TTL, 4)
+		*(*int32)(unsafe.Pointer(&data[0])) = int32(cm.HopLimit)
+	}
+	if cm.TrafficClass != 0 {
+		b = appendTOSCmsg(b, fd, cm.TrafficClass)
+	}
+	return b, nil
+}
+
+// parseUDPControl stores in cm the fields of the control messages
+// in oob, and zeroes the other fields.
+func parseUDPControl(oob []byte, cm *UDPControl) {
+	*cm = UDPControl{}
+	// An IPv6 socket may report the destination of a datagram
+	// received over IPv4 both ways.
+	if data := findCmsg(oob, syscall.IPPROTO_IPV6, syscall.IPV6_PKTINFO); len(data) >= syscall.SizeofInet6Pktinfo {
+		pi := (*syscall.Inet6Pktinfo)(unsafe.Pointer(&data[0]))
+		cm.Dst = make(IP, IPv6len)
+		copy(cm.Dst, pi.Addr[:])
+		cm.IfIndex = int(pi.Ifindex)
+	} else if data := findCmsg(oob, syscall.IPPROTO_IP, syscall.IP_PKTINFO); len(data) >= syscall.SizeofInet4Pktinfo {
+		pi := (*syscall.Inet4Pktinfo)(unsafe.Pointer(&data[0]))
+		cm.Dst = IPv4(pi.Addr[0], pi.Addr[1], pi.Addr[2], pi.Addr[3])
+		cm.IfIndex = int(pi.Ifindex)
+	}
+	if data := findCmsg(oob, syscall.IPPROTO_IP, syscall.IP_TTL); len(data) >= 4 {
+		cm.HopLimit = int(*(*int32)(unsafe.Pointer(&data[0])))
+	} else if data := findCmsg(oob, syscall.IPPROTO_IPV6, syscall.IPV6_HOPLIMIT); len(data) >= 4 {
+		cm.HopLimit = int(*(*int32)(unsafe.Pointer(&data[0])))
+	}
+	// The IPv4 TOS is reported as a single byte
+	// and the IPv6 traffic class as an int.
+	if data := findCmsg(oob, syscall.IPPROTO_IP, syscall.IP_TOS); len(data) >= 1 {
+		cm.TrafficClass = int(data[0])
+	} else if data := findCmsg(oob, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS); len(data) >= 4 {
+		cm.TrafficClass = int(*(*int32)(unsafe.Pointer(&data[0])))
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUDPControl(t *testing.T) {
+	for _, tt := range []struct {
+		lnet, dnet string
+		laddr, dst IP
+	}{
+		{"udp4", "udp4", IPv4(127, 0, 0, 1), IPv4(127, 0, 0, 1)},
+		{"udp6", "udp6", IPv6loopback, IPv6loopback},
+		{"udp", "udp4", IPv6unspecified, IPv4(127, 0, 0, 1)},
+	} {
+		t.Run(tt.lnet+"-"+tt.dnet, func(t *testing.T) {
+			if !testableNetwork(tt.lnet) || !testableNetwork(tt.dnet) {
+				t.Skipf("%s or %s not supported", tt.lnet, tt.dnet)
+			}
+			if tt.lnet == "udp" && !supportsIPv4map() {
+				t.Skip("IPv4-mapped IPv6 addresses not supported")
+			}
+			c1, err := ListenUDP(tt.lnet, &UDPAddr{IP: tt.laddr})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c1.Close()
+			c2, err := DialUDP(tt.dnet, nil, &UDPAddr{IP: tt.dst, Port: c1.LocalAddr().(*UDPAddr).Port})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c2.Close()
+			c1.SetReadDeadline(time.Now().Add(30 * time.Second))
+			c2.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+			if err := c1.SetReadControl(UDPControlDst|UDPControlHopLimit|UDPControlTrafficClass, true); err != nil {
+				t.Fatal(err)
+			}
+			out := &UDPControl{HopLimit: 7, TrafficClass: 0x20}
+			if _, err := c2.WriteMsgUDPControl([]byte("request"), out, nil); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 64)
+			var cm UDPControl
+			n, addr, err := c1.ReadMsgUDPControl(b, &cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b[:n]) != "request" {
+				t.Errorf("read %q, want %q", b[:n], "request")
+			}
+			if !cm.Dst.Equal(tt.dst) {
+				t.Errorf("Dst = %v, want %v", cm.Dst, tt.dst)
+			}
+			if cm.IfIndex == 0 {
+				t.Error("IfIndex = 0, want the index of the loopback interface")
+			}
+			if cm.HopLimit != out.HopLimit {
+				t.Errorf("HopLimit = %d, want %d", cm.HopLimit, out.HopLimit)
+			}
+			if cm.TrafficClass != out.TrafficClass {
+				t.Errorf("TrafficClass = %#x, want %#x", cm.TrafficClass, out.TrafficClass)
+			}
+
+			// Reply from the address the request was sent to.
+			reply := &UDPControl{Src: cm.Dst, IfIndex: cm.IfIndex}
+			if _, err := c1.WriteMsgUDPControl([]byte("reply"), reply, addr); err != nil {
+				t.Fatal(err)
+			}
+			n, err = c2.Read(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b[:n]) != "reply" {
+				t.Errorf("read %q, want %q", b[:n], "reply")
+			}
+
+			if err := c1.SetReadControl(UDPControlDst|UDPControlHopLimit|UDPControlTrafficClass, false); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c2.Write([]byte("plain")); err != nil {
+				t.Fatal(err)
+			}
+			cm.IfIndex = -1
+			if _, _, err := c1.ReadMsgUDPControl(b, &cm); err != nil {
+				t.Fatal(err)
+			}
+			if cm.Dst != nil || cm.IfIndex != 0 || cm.HopLimit != 0 || cm.TrafficClass != 0 {
+				t.Errorf("got %+v after turning reporting off, want zero UDPControl", cm)
+			}
+		})
+	}
+}
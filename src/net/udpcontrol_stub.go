@@ -0,0 +1,22 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !plan9
+// +build !linux,!plan9
+
+package net
+
+import "syscall"
+
+func setReadControl(fd *netFD, flags UDPControlFlags, on bool) error {
+	return syscall.ENOPROTOOPT
+}
+
+func (c *UDPConn) readMsgControl(b []byte, cm *UDPControl) (int, *UDPAddr, error) {
+	return 0, nil, syscall.ENOPROTOOPT
+}
+
+func (c *UDPConn) writeMsgControl(b []byte, cm *UDPControl, addr *UDPAddr) (int, error) {
+	return 0, syscall.ENOPROTOOPT
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// udpSegment is UDP_SEGMENT from linux/udp.h. As a socket option
+	// it sets the segment size of every send, and as a control message
+	// that of one send.
+	udpSegment = 103
+
+	// udpGRO is UDP_GRO from linux/udp.h. As a socket option it turns
+	// on receive offload, and as a control message it reports the
+	// segment size of a coalesced message.
+	udpGRO = 104
+)
+
+func setSegmentSize(fd *netFD, size int) error {
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_UDP, udpSegment, size)
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+func setReceiveOffload(fd *netFD, on bool) error {
+	err := fd.pfd.SetsockoptInt(syscall.IPPROTO_UDP, udpGRO, boolint(on))
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+// segmentCmsgSpace is the space taken by a UDP_SEGMENT control message.
+var segmentCmsgSpace = syscall.CmsgSpace(2)
+
+// appendSegmentCmsg appends to b a UDP_SEGMENT control message
+// setting the segment size of a send to size.
+func appendSegmentCmsg(b []byte, size int) []byte {
+	b, data := appendCmsg(b, syscall.IPPROTO_UDP, udpSegment, 2)
+	*(*uint16)(unsafe.Pointer(&data[0])) = uint16(size)
+	return b
+}
+
+// appendCmsg appends to b, after padding it to the alignment of
+// control messages, a control message of the given level and type
+// with room for n bytes of data. It returns b and the data.
+func appendCmsg(b []byte, level, typ int32, n int) ([]byte, []byte) {
+	for len(b) < cmsgAlign(len(b)) {
+		b = append(b, 0)
+	}
+	start := len(b)
+	for i := 0; i < syscall.CmsgSpace(n); i++ {
+		b = append(b, 0)
+	}
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&b[start]))
+	h.Level = level
+	h.Type = typ
+	h.SetLen(syscall.CmsgLen(n))
+	data := b[start+syscall.CmsgLen(0):]
+	return b, data[:n]
+}
+
+// cmsgAlign rounds n up to the alignment of control messages.
+func cmsgAlign(n int) int {
+	const align = int(unsafe.Sizeof(uintptr(0)))
+	return (n + align - 1) &^ (align - 1)
+}
+
+// findCmsg returns the data of the first control message in oob of
+// the given level and type, or nil if there is none.
+func findCmsg(oob []byte, level, typ int32) []byte {
+	for len(oob) >= syscall.SizeofCmsghdr {
+		h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+		if int(h.Len) < syscall.SizeofCmsghdr || int(h.Len) > len(oob) {
+			return nil
+		}
+		if h.Level == level && h.Type == typ {
+			return oob[syscall.CmsgLen(0):h.Len]
+		}
+		next := syscall.CmsgSpace(int(h.Len) - syscall.CmsgLen(0))
+		if next > len(oob) {
+			return nil
+		}
+		oob = oob[next:]
+	}
+	return nil
+}
+
+// groSegmentSize returns the segment size reported by a UDP_GRO
+// control message in oob, or 0 if there is none.
+func groSegmentSize(oob []byte) int {
+	data := findCmsg(oob, syscall.IPPROTO_UDP, udpGRO)
+	if len(data) < 4 {
+		return 0
+	}
+	return int(*(*int32)(unsafe.Pointer(&data[0])))
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func skipIfNoUDPOffload(t *testing.T, err error) {
+	t.Helper()
+	if errors.Is(err, syscall.ENOPROTOOPT) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.EIO) {
+		t.Skipf("UDP offload not supported: %v", err)
+	}
+}
+
+func TestUDPSegmentation(t *testing.T) {
+	c1, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := DialUDP("udp4", nil, c1.LocalAddr().(*UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	c1.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+	payload := bytes.Repeat([]byte("0123456789"), 250)
+	ms := []Message{{Buffers: [][]byte{payload}, SegmentSize: 1000}}
+	if n, err := c2.WriteBatch(ms, 0); n != 1 || err != nil {
+		skipIfNoUDPOffload(t, err)
+		t.Fatalf("WriteBatch = %d, %v, want 1, nil", n, err)
+	}
+	if ms[0].N != len(payload) {
+		t.Errorf("N = %d, want %d", ms[0].N, len(payload))
+	}
+	b := make([]byte, 4096)
+	for _, want := range []int{1000, 1000, 500} {
+		n, err := c1.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("read datagram of %d bytes, want %d", n, want)
+		}
+	}
+
+	// The socket option splits plain writes.
+	if err := c2.SetSegmentSize(1000); err != nil {
+		skipIfNoUDPOffload(t, err)
+		t.Fatal(err)
+	}
+	if _, err := c2.Write(payload[:1500]); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{1000, 500} {
+		n, err := c1.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("read datagram of %d bytes, want %d", n, want)
+		}
+	}
+	if err := c2.SetSegmentSize(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// With receive offload, the datagrams may arrive joined
+	// and ReadBatch reports the segment size.
+	if err := c1.SetReceiveOffload(true); err != nil {
+		skipIfNoUDPOffload(t, err)
+		t.Fatal(err)
+	}
+	ms[0].N = 0
+	if _, err := c2.WriteBatch(ms, 0); err != nil {
+		t.Fatal(err)
+	}
+	in := make([]Message, 4)
+	for i := range in {
+		in[i].Buffers = [][]byte{make([]byte, 1<<16)}
+		in[i].OOB = make([]byte, 64)
+	}
+	total := 0
+	for total < len(payload) {
+		n, err := c1.ReadBatch(in, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range in[:n] {
+			if m.N > 1000 && m.SegmentSize != 1000 {
+				t.Errorf("read %d bytes with SegmentSize %d, want 1000", m.N, m.SegmentSize)
+			}
+			total += m.N
+		}
+	}
+	if total != len(payload) {
+		t.Errorf("read %d bytes, want %d", total, len(payload))
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+func setPMTUDiscovery(fd *netFD, mode PMTUDiscovery) error {
+	var v int
+	switch mode {
+	case PMTUDiscoveryDont:
+		v = syscall.IP_PMTUDISC_DONT
+	case PMTUDiscoveryWant:
+		v = syscall.IP_PMTUDISC_WANT
+	case PMTUDiscoveryDo:
+		v = syscall.IP_PMTUDISC_DO
+	case PMTUDiscoveryProbe:
+		v = syscall.IP_PMTUDISC_PROBE
+	default:
+		return syscall.EINVAL
+	}
+	defer runtime.KeepAlive(fd)
+	// The IPV6_PMTUDISC_* values are the same as the IP_PMTUDISC_*
+	// ones. An IPv6 socket takes the IPv4 option too, which applies
+	// to IPv4-mapped destinations.
+	if fd.family == syscall.AF_INET6 {
+		if err := fd.pfd.SetsockoptInt(syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, v); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	return wrapSyscallError("setsockopt", fd.pfd.SetsockoptInt(syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, v))
+}
+
+func pathMTU(fd *netFD) (int, error) {
+	level, name := syscall.IPPROTO_IP, syscall.IP_MTU
+	if fd.family == syscall.AF_INET6 {
+		level, name = syscall.IPPROTO_IPV6, syscall.IPV6_MTU
+	}
+	mtu, err := fd.pfd.GetsockoptInt(level, name)
+	runtime.KeepAlive(fd)
+	if err != nil {
+		return 0, wrapSyscallError("getsockopt", err)
+	}
+	return mtu, nil
+}
+
+// ecnMask selects the ECN codepoint in a TOS or traffic class.
+const ecnMask = 0x3
+
+func setECN(fd *netFD, ecn ECN) error {
+	if fd.family == syscall.AF_INET6 {
+		if err := setTOSECN(fd, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, ecn); err != nil {
+			return err
+		}
+	}
+	return setTOSECN(fd, syscall.IPPROTO_IP, syscall.IP_TOS, ecn)
+}
+
+// setTOSECN replaces the ECN codepoint in the TOS or traffic class
+// option of fd at level and name.
+func setTOSECN(fd *netFD, level, name int, ecn ECN) error {
+	tos, err := fd.pfd.GetsockoptInt(level, name)
+	if err != nil {
+		return wrapSyscallError("getsockopt", err)
+	}
+	err = fd.pfd.SetsockoptInt(level, name, tos&^ecnMask|int(ecn))
+	runtime.KeepAlive(fd)
+	return wrapSyscallError("setsockopt", err)
+}
+
+func setReceiveECN(fd *netFD, on bool) error {
+	defer runtime.KeepAlive(fd)
+	if fd.family == syscall.AF_INET6 {
+		if err := fd.pfd.SetsockoptInt(syscall.IPPROTO_IPV6, syscall.IPV6_RECVTCLASS, boolint(on)); err != nil {
+			return wrapSyscallError("setsockopt", err)
+		}
+	}
+	return wrapSyscallError("setsockopt", fd.pfd.SetsockoptInt(syscall.IPPROTO_IP, syscall.IP_RECVTOS, boolint(on)))
+}
+
+// tosCmsgSpace returns the space taken by the control messages
+// that set the TOS or traffic class of a send on fd.
+func tosCmsgSpace(fd *netFD) int {
+	if fd.family == syscall.AF_INET6 {
+		return 2 * syscall.CmsgSpace(4)
+	}
+	return syscall.CmsgSpace(4)
+}
+
+// appendTOSCmsg appends to b the control messages setting the TOS or
+// traffic class of a send on fd to tos. The kernel ignores the IPv4
+// one for IPv6 destinations and the IPv6 one for IPv4 destinations,
+// so an IPv6 socket gets both.
+func appendTOSCmsg(b []byte, fd *netFD, tos int) []byte {
+	var data []byte
+	if fd.family == syscall.AF_INET6 {
+		b, data = appendCmsg(b, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, 4)
+		*(*int32)(unsafe.Pointer(&data[0])) = int32(tos)
+	}
+	b, data = appendCmsg(b, syscall.IPPROTO_IP, syscall.IP_TOS, 4)
+	*(*int32)(unsafe.Pointer(&data[0])) = int32(tos)
+	return b
+}
+
+// socketTOS returns the TOS or traffic class set on fd, whose DSCP
+// bits are kept when setting the ECN codepoint of a single send.
+func socketTOS(fd *netFD) (int, error) {
+	level, name := syscall.IPPROTO_IP, syscall.IP_TOS
+	if fd.family == syscall.AF_INET6 {
+		level, name = syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS
+	}
+	tos, err := fd.pfd.GetsockoptInt(level, name)
+	runtime.KeepAlive(fd)
+	if err != nil {
+		return 0, wrapSyscallError("getsockopt", err)
+	}
+	return tos, nil
+}
+
+// receivedECN returns the ECN codepoint reported by a TOS or traffic
+// class control message in oob, or 0 if there is none.
+func receivedECN(oob []byte) ECN {
+	// The IPv4 TOS is reported as a single byte
+	// and the IPv6 traffic class as an int.
+	if data := findCmsg(oob, syscall.IPPROTO_IP, syscall.IP_TOS); len(data) >= 1 {
+		return ECN(data[0] & ecnMask)
+	}
+	if data := findCmsg(oob, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS); len(data) >= 4 {
+		return ECN(*(*int32)(unsafe.Pointer(&data[0])) & ecnMask)
+	}
+	return 0
+}
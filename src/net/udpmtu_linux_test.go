@@ -0,0 +1,101 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUDPPathMTU(t *testing.T) {
+	c1, err := ListenUDP("udp4", &UDPAddr{IP: IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := DialUDP("udp4", nil, c1.LocalAddr().(*UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	for _, mode := range []PMTUDiscovery{PMTUDiscoveryDont, PMTUDiscoveryWant, PMTUDiscoveryProbe, PMTUDiscoveryDo} {
+		if err := c2.SetPMTUDiscovery(mode); err != nil {
+			t.Fatalf("SetPMTUDiscovery(%d): %v", mode, err)
+		}
+	}
+	if err := c2.SetPMTUDiscovery(PMTUDiscovery(-1)); err == nil {
+		t.Error("SetPMTUDiscovery(-1) succeeded, want error")
+	}
+	if err := c2.SetDontFragment(true); err != nil {
+		t.Fatal(err)
+	}
+	mtu, err := c2.PathMTU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mtu < 576 {
+		t.Errorf("PathMTU = %d, want at least 576", mtu)
+	}
+
+	// The path MTU is only known for connected sockets.
+	if _, err := c1.PathMTU(); err == nil {
+		t.Error("PathMTU on an unconnected socket succeeded, want error")
+	}
+}
+
+func TestUDPECN(t *testing.T) {
+	for _, tt := range []struct {
+		network string
+		ip      IP
+	}{
+		{"udp4", IPv4(127, 0, 0, 1)},
+		{"udp6", IPv6loopback},
+	} {
+		t.Run(tt.network, func(t *testing.T) {
+			if !testableNetwork(tt.network) {
+				t.Skipf("%s not supported", tt.network)
+			}
+			c1, err := ListenUDP(tt.network, &UDPAddr{IP: tt.ip})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c1.Close()
+			c2, err := DialUDP(tt.network, nil, c1.LocalAddr().(*UDPAddr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c2.Close()
+			c1.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+			if err := c1.SetReceiveECN(true); err != nil {
+				t.Fatal(err)
+			}
+			if err := c2.SetECN(ECNECT0); err != nil {
+				t.Fatal(err)
+			}
+			if err := c2.SetECN(ECN(4)); err == nil {
+				t.Error("SetECN(4) succeeded, want error")
+			}
+			if _, err := c2.Write([]byte("ect0")); err != nil {
+				t.Fatal(err)
+			}
+			out := []Message{{Buffers: [][]byte{[]byte("ce")}, ECN: ECNCE}}
+			if _, err := c2.WriteBatch(out, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			in := []Message{{Buffers: [][]byte{make([]byte, 64)}, OOB: make([]byte, 64)}}
+			for _, want := range []ECN{ECNECT0, ECNCE} {
+				if _, err := c1.ReadBatch(in, 0); err != nil {
+					t.Fatal(err)
+				}
+				if in[0].ECN != want {
+					t.Errorf("read %q with ECN %v, want %v", in[0].Buffers[0][:in[0].N], in[0].ECN, want)
+				}
+			}
+		})
+	}
+}
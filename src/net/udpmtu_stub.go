@@ -0,0 +1,26 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !plan9
+// +build !linux,!plan9
+
+package net
+
+import "syscall"
+
+func setPMTUDiscovery(fd *netFD, mode PMTUDiscovery) error {
+	return syscall.ENOPROTOOPT
+}
+
+func pathMTU(fd *netFD) (int, error) {
+	return 0, syscall.ENOPROTOOPT
+}
+
+func setECN(fd *netFD, ecn ECN) error {
+	return syscall.ENOPROTOOPT
+}
+
+func setReceiveECN(fd *netFD, on bool) error {
+	return syscall.ENOPROTOOPT
+}
@@ -195,6 +195,316 @@ func (c *UDPConn) WriteMsgUDP(b, oob []byte, addr *UDPAddr) (n, oobn int, err er
 	return
 }
 
+// WriteMsgBuffersUDP is like WriteMsgUDP, but gathers the payload of
+// the datagram from the buffers in v, so that a header and a body, say,
+// need not be copied together first. On Unix systems the buffers are
+// sent with a single sendmsg system call; elsewhere they are
+// concatenated. v is not modified.
+func (c *UDPConn) WriteMsgBuffersUDP(v Buffers, oob []byte, addr *UDPAddr) (n, oobn int, err error) {
+	if !c.ok() {
+		return 0, 0, syscall.EINVAL
+	}
+	n, oobn, err = c.writeMsgBuffers(v, oob, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return
+}
+
+// SetSegmentSize sets the size of the datagrams that the payload of
+// each write on c is split into, so that one write can send many
+// datagrams. A size of 0 turns splitting off. The size of a single
+// write can be set instead with Message.SegmentSize and WriteBatch.
+//
+// SetSegmentSize uses UDP generic segmentation offload (UDP_SEGMENT)
+// and is only supported on Linux 4.18 and later.
+func (c *UDPConn) SetSegmentSize(size int) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setSegmentSize(c.fd, size); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetReceiveOffload controls whether the kernel may join datagrams
+// received on c from the same sender into a single larger message.
+// The size of the joined datagrams is reported in the ancillary data,
+// and in Message.SegmentSize by ReadBatch, so with receive offload on
+// c should only be read with ReadBatch or ReadMsgUDP.
+//
+// SetReceiveOffload uses UDP generic receive offload (UDP_GRO)
+// and is only supported on Linux 5.0 and later.
+func (c *UDPConn) SetReceiveOffload(on bool) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setReceiveOffload(c.fd, on); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// A PMTUDiscovery is a path MTU discovery mode, which decides whether
+// datagrams are sent with the don't-fragment bit set and how the
+// kernel's estimate of the path MTU is used.
+type PMTUDiscovery int
+
+const (
+	// PMTUDiscoveryDont sends datagrams without the don't-fragment
+	// bit, so that routers may fragment them.
+	PMTUDiscoveryDont PMTUDiscovery = iota
+
+	// PMTUDiscoveryWant fragments datagrams larger than the path MTU
+	// locally and sets the don't-fragment bit on the others.
+	PMTUDiscoveryWant
+
+	// PMTUDiscoveryDo sets the don't-fragment bit on all datagrams,
+	// and fails writes larger than the path MTU with EMSGSIZE.
+	PMTUDiscoveryDo
+
+	// PMTUDiscoveryProbe sets the don't-fragment bit on all datagrams
+	// and ignores the path MTU, failing only writes larger than the
+	// MTU of the interface. Applications that search for the path
+	// MTU themselves send their probes in this mode.
+	PMTUDiscoveryProbe
+)
+
+// SetPMTUDiscovery sets the path MTU discovery mode of c. On an IPv6
+// socket it sets the mode for IPv6 destinations as well as for
+// IPv4-mapped ones.
+//
+// SetPMTUDiscovery uses IP_MTU_DISCOVER and IPV6_MTU_DISCOVER and is
+// only supported on Linux.
+func (c *UDPConn) SetPMTUDiscovery(mode PMTUDiscovery) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setPMTUDiscovery(c.fd, mode); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetDontFragment controls whether datagrams sent on c have the
+// don't-fragment bit set. It is the same as SetPMTUDiscovery with
+// PMTUDiscoveryDo if dontFragment is true and PMTUDiscoveryDont if it
+// is false.
+func (c *UDPConn) SetDontFragment(dontFragment bool) error {
+	mode := PMTUDiscoveryDont
+	if dontFragment {
+		mode = PMTUDiscoveryDo
+	}
+	return c.SetPMTUDiscovery(mode)
+}
+
+// PathMTU returns the kernel's current estimate of the MTU of the path
+// to the remote address of c, which must be connected. The estimate
+// is kept up to date from ICMP messages when the path MTU discovery
+// mode is PMTUDiscoveryWant or PMTUDiscoveryDo.
+//
+// PathMTU uses IP_MTU and IPV6_MTU and is only supported on Linux.
+func (c *UDPConn) PathMTU() (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	mtu, err := pathMTU(c.fd)
+	if err != nil {
+		return 0, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return mtu, nil
+}
+
+// An ECN is an Explicit Congestion Notification codepoint, the two
+// low-order bits of the IPv4 TOS or IPv6 traffic class field.
+type ECN uint8
+
+const (
+	ECNNotECT ECN = 0 // Not-ECT, the transport does not support ECN
+	ECNECT1   ECN = 1 // ECT(1), ECN-capable transport, as used by L4S
+	ECNECT0   ECN = 2 // ECT(0), ECN-capable transport
+	ECNCE     ECN = 3 // CE, congestion experienced
+)
+
+func (e ECN) String() string {
+	switch e {
+	case ECNNotECT:
+		return "Not-ECT"
+	case ECNECT1:
+		return "ECT(1)"
+	case ECNECT0:
+		return "ECT(0)"
+	case ECNCE:
+		return "CE"
+	}
+	return "ECN(" + itoa.Uitoa(uint(e)) + ")"
+}
+
+// SetECN sets the ECN codepoint of the datagrams sent on c, leaving
+// the DSCP bits of the TOS or traffic class as they are. The codepoint
+// of a single datagram can be set instead with Message.ECN and
+// WriteBatch.
+//
+// SetECN is only supported on Linux.
+func (c *UDPConn) SetECN(ecn ECN) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if ecn > ECNCE {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: syscall.EINVAL}
+	}
+	if err := setECN(c.fd, ecn); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// SetReceiveECN controls whether the ECN codepoint of the datagrams
+// received on c is reported in the ancillary data, and in Message.ECN
+// by ReadBatch.
+//
+// SetReceiveECN uses IP_RECVTOS and IPV6_RECVTCLASS and is only
+// supported on Linux.
+func (c *UDPConn) SetReceiveECN(on bool) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setReceiveECN(c.fd, on); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// A UDPControl holds the IP-level information that travels with a UDP
+// datagram as ancillary data, as read by ReadMsgUDPControl and written
+// by WriteMsgUDPControl.
+//
+// A server listening on a wildcard address can reply from the address
+// a request was sent to by copying Dst and IfIndex of the request into
+// Src and IfIndex of the reply.
+type UDPControl struct {
+	// Src is the source address to send from, or nil to let the
+	// system choose. It is not set by reads.
+	Src IP
+
+	// Dst is the destination address of a received datagram.
+	// It is ignored by writes.
+	Dst IP
+
+	// IfIndex is the index of the interface a datagram was received
+	// on, or the interface to send it on, or 0 to let the system
+	// choose.
+	IfIndex int
+
+	// HopLimit is the IPv4 time-to-live or IPv6 hop limit. When
+	// writing, 0 leaves the one set for the socket.
+	HopLimit int
+
+	// TrafficClass is the IPv4 type of service or IPv6 traffic class,
+	// including the ECN codepoint. When writing, 0 leaves the one set
+	// for the socket.
+	TrafficClass int
+}
+
+// UDPControlFlags select the fields of UDPControl that reads report.
+type UDPControlFlags uint
+
+const (
+	UDPControlDst          UDPControlFlags = 1 << iota // Dst and IfIndex
+	UDPControlHopLimit                                 // HopLimit
+	UDPControlTrafficClass                             // TrafficClass
+)
+
+// SetReadControl controls whether the fields of UDPControl selected by
+// flags are reported for the datagrams received on c. Only reads with
+// ReadMsgUDPControl, or with ReadMsgUDP or ReadBatch and room in their
+// ancillary data, should be used on c while any are on.
+//
+// SetReadControl is only supported on Linux.
+func (c *UDPConn) SetReadControl(flags UDPControlFlags, on bool) error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := setReadControl(c.fd, flags, on); err != nil {
+		return &OpError{Op: "set", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// ReadMsgUDPControl reads a datagram from c, copying the payload into
+// b and storing in cm the fields turned on with SetReadControl. It
+// returns the number of bytes copied into b and the source address of
+// the datagram. The other fields of cm are zeroed.
+//
+// ReadMsgUDPControl is only supported on Linux.
+func (c *UDPConn) ReadMsgUDPControl(b []byte, cm *UDPControl) (n int, addr *UDPAddr, err error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, addr, err = c.readMsgControl(b, cm)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return
+}
+
+// WriteMsgUDPControl writes a datagram with the payload b to addr via
+// c if c isn't connected, or to c's remote address if c is connected
+// (in which case addr must be nil). The non-zero fields of cm, which
+// may be nil, apply to this datagram only.
+//
+// WriteMsgUDPControl is only supported on Linux.
+func (c *UDPConn) WriteMsgUDPControl(b []byte, cm *UDPControl, addr *UDPAddr) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeMsgControl(b, cm, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return n, err
+}
+
+// ReadBatch reads up to len(ms) datagrams from c into ms and returns
+// the number of messages read. It blocks until at least one datagram
+// is available and then reads those that are ready, without waiting
+// for more. flags are the MSG_* flags of the recvmsg system call.
+//
+// On Linux, ReadBatch reads all the messages with a single recvmmsg
+// system call. Elsewhere it reads one message per call and flags
+// must be zero.
+func (c *UDPConn) ReadBatch(ms []Message, flags int) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.readBatch(ms, flags)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
+// WriteBatch writes the datagrams in ms to c and returns the number
+// of messages written, which is less than len(ms) only if err is not
+// nil. Each message is sent to its Addr if c isn't connected, or to
+// c's remote address if c is connected (in which case Addr must be
+// nil). flags are the MSG_* flags of the sendmsg system call.
+//
+// On Linux, WriteBatch writes as many messages as the system allows
+// with each sendmmsg system call. Elsewhere it writes one message at a
+// time and flags must be zero.
+func (c *UDPConn) WriteBatch(ms []Message, flags int) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.writeBatch(ms, flags)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
 func newUDPConn(fd *netFD) *UDPConn { return &UDPConn{conn{fd}} }
 
 // DialUDP acts like Dial for UDP networks.
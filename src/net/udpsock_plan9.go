@@ -56,6 +56,10 @@ func (c *UDPConn) writeMsg(b, oob []byte, addr *UDPAddr) (n, oobn int, err error
 	return 0, 0, syscall.EPLAN9
 }
 
+func (c *UDPConn) writeMsgBuffers(v Buffers, oob []byte, addr *UDPAddr) (n, oobn int, err error) {
+	return 0, 0, syscall.EPLAN9
+}
+
 func (sd *sysDialer) dialUDP(ctx context.Context, laddr, raddr *UDPAddr) (*UDPConn, error) {
 	fd, err := dialPlan9(ctx, sd.network, laddr, raddr)
 	if err != nil {
@@ -152,3 +156,39 @@ func (sl *sysListener) listenMulticastUDP(ctx context.Context, ifi *Interface, g
 	}
 	return newUDPConn(fd), nil
 }
+
+func setSegmentSize(fd *netFD, size int) error {
+	return syscall.EPLAN9
+}
+
+func setReceiveOffload(fd *netFD, on bool) error {
+	return syscall.EPLAN9
+}
+
+func setPMTUDiscovery(fd *netFD, mode PMTUDiscovery) error {
+	return syscall.EPLAN9
+}
+
+func pathMTU(fd *netFD) (int, error) {
+	return 0, syscall.EPLAN9
+}
+
+func setECN(fd *netFD, ecn ECN) error {
+	return syscall.EPLAN9
+}
+
+func setReceiveECN(fd *netFD, on bool) error {
+	return syscall.EPLAN9
+}
+
+func setReadControl(fd *netFD, flags UDPControlFlags, on bool) error {
+	return syscall.EPLAN9
+}
+
+func (c *UDPConn) readMsgControl(b []byte, cm *UDPControl) (int, *UDPAddr, error) {
+	return 0, nil, syscall.EPLAN9
+}
+
+func (c *UDPConn) writeMsgControl(b []byte, cm *UDPControl, addr *UDPAddr) (int, error) {
+	return 0, syscall.EPLAN9
+}
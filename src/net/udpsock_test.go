@@ -495,3 +495,139 @@ func BenchmarkWriteToReadFromUDP(b *testing.B) {
 		}
 	}
 }
+
+func TestUDPBatch(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	c1, err := newLocalPacketListener("udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := Dial("udp", c1.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	uc1, uc2 := c1.(*UDPConn), c2.(*UDPConn)
+
+	out := []Message{
+		{Buffers: [][]byte{[]byte("one")}},
+		{Buffers: [][]byte{[]byte("tw"), []byte("o")}},
+		{Buffers: [][]byte{[]byte("three")}},
+	}
+	if n, err := uc2.WriteBatch(out, 0); n != len(out) || err != nil {
+		t.Fatalf("WriteBatch = %d, %v, want %d, nil", n, err, len(out))
+	}
+	for i, m := range out {
+		want := 0
+		for _, b := range m.Buffers {
+			want += len(b)
+		}
+		if m.N != want {
+			t.Errorf("message %d: N = %d, want %d", i, m.N, want)
+		}
+	}
+
+	uc1.SetReadDeadline(time.Now().Add(30 * time.Second))
+	in := make([]Message, 4)
+	for i := range in {
+		in[i].Buffers = [][]byte{make([]byte, 2), make([]byte, 8)}
+	}
+	var got []string
+	var from Addr
+	for len(got) < len(out) {
+		n, err := uc1.ReadBatch(in, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range in[:n] {
+			var b []byte
+			for _, buf := range m.Buffers {
+				b = append(b, buf...)
+			}
+			got = append(got, string(b[:m.N]))
+			from = m.Addr
+		}
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBatch read %q, want %q", got, want)
+	}
+	if from == nil || from.String() != c2.LocalAddr().String() {
+		t.Errorf("ReadBatch from %v, want %v", from, c2.LocalAddr())
+	}
+
+	// An unconnected socket needs an address, and a connected one
+	// must not be given one.
+	if _, err := uc1.WriteBatch([]Message{{Buffers: [][]byte{[]byte("x")}}}, 0); err == nil {
+		t.Error("WriteBatch without address on unconnected socket succeeded")
+	}
+	if _, err := uc2.WriteBatch([]Message{{Buffers: [][]byte{[]byte("x")}, Addr: c1.LocalAddr()}}, 0); err == nil {
+		t.Error("WriteBatch with address on connected socket succeeded")
+	}
+	reply := []Message{{Buffers: [][]byte{[]byte("reply")}, Addr: from}}
+	if n, err := uc1.WriteBatch(reply, 0); n != 1 || err != nil {
+		t.Fatalf("WriteBatch(reply) = %d, %v, want 1, nil", n, err)
+	}
+	c2.SetReadDeadline(time.Now().Add(30 * time.Second))
+	b := make([]byte, 16)
+	n, err := c2.Read(b)
+	if err != nil || string(b[:n]) != "reply" {
+		t.Errorf("Read = %q, %v, want %q, nil", b[:n], err, "reply")
+	}
+}
+
+func TestUDPConnWriteMsgBuffers(t *testing.T) {
+	switch runtime.GOOS {
+	case "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	c1, err := newLocalPacketListener("udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	c2, err := newLocalPacketListener("udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	uc1, uc2 := c1.(*UDPConn), c2.(*UDPConn)
+
+	v := Buffers{[]byte("head"), nil, []byte("er|"), []byte("body")}
+	n, _, err := uc2.WriteMsgBuffersUDP(v, nil, uc1.LocalAddr().(*UDPAddr))
+	if err != nil || n != len("header|body") {
+		t.Fatalf("WriteMsgBuffersUDP = %d, %v, want %d, nil", n, err, len("header|body"))
+	}
+	if len(v) != 4 {
+		t.Errorf("WriteMsgBuffersUDP modified its buffers")
+	}
+
+	// Buffers written to a connected socket also make up a single
+	// datagram.
+	c3, err := Dial("udp", c1.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c3.Close()
+	v = Buffers{[]byte("one"), []byte("+"), []byte("two")}
+	if n, err := v.WriteTo(c3); err != nil || n != int64(len("one+two")) {
+		t.Fatalf("Buffers.WriteTo = %d, %v, want %d, nil", n, err, len("one+two"))
+	}
+
+	uc1.SetReadDeadline(time.Now().Add(30 * time.Second))
+	b := make([]byte, 64)
+	for _, want := range []string{"header|body", "one+two"} {
+		n, _, err := uc1.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b[:n]); got != want {
+			t.Errorf("read datagram %q, want %q", got, want)
+		}
+	}
+}
@@ -102,6 +102,49 @@ func (c *UnixConn) CloseWrite() error {
 	return nil
 }
 
+// UnixCredentials identifies the process at the other end of a Unix
+// domain connection.
+type UnixCredentials struct {
+	PID int // process ID, or -1 if the system does not report it
+	UID int // effective user ID
+	GID int // effective group ID
+}
+
+// PeerCredentials returns the credentials of the peer process of c,
+// as they were when the connection was established. The peer must be
+// connected, as it is for stream connections and for datagram
+// connections made with Dial or socketpair.
+//
+// It reads SO_PEERCRED on Linux and OpenBSD, LOCAL_PEERCRED on macOS,
+// FreeBSD and DragonFly BSD, and LOCAL_PEEREID on NetBSD, and returns
+// an error on other systems. DragonFly BSD and FreeBSD before 13 do
+// not report the process ID.
+func (c *UnixConn) PeerCredentials() (UnixCredentials, error) {
+	if !c.ok() {
+		return UnixCredentials{}, syscall.EINVAL
+	}
+	cred, err := peerCredentials(c.fd)
+	if err != nil {
+		return UnixCredentials{}, &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return cred, nil
+}
+
+// PeerSecurityContext returns the security context of the peer
+// process of c, such as an SELinux or AppArmor label, as reported by
+// SO_PEERSEC on Linux. It returns an error on other systems, and on
+// Linux when no security module labels the socket.
+func (c *UnixConn) PeerSecurityContext() (string, error) {
+	if !c.ok() {
+		return "", syscall.EINVAL
+	}
+	label, err := peerSecurityContext(c.fd)
+	if err != nil {
+		return "", &OpError{Op: "get", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return label, nil
+}
+
 // ReadFromUnix acts like ReadFrom but returns a UnixAddr.
 func (c *UnixConn) ReadFromUnix(b []byte) (int, *UnixAddr, error) {
 	if !c.ok() {
@@ -192,6 +235,61 @@ func (c *UnixConn) WriteMsgUnix(b, oob []byte, addr *UnixAddr) (n, oobn int, err
 	return
 }
 
+// WriteMsgBuffersUnix is like WriteMsgUnix, but gathers the payload
+// of the message from the buffers in v, so that a header and a body,
+// say, need not be copied together first. On Unix systems the buffers
+// are sent with a single sendmsg system call; elsewhere they are
+// concatenated. v is not modified.
+func (c *UnixConn) WriteMsgBuffersUnix(v Buffers, oob []byte, addr *UnixAddr) (n, oobn int, err error) {
+	if !c.ok() {
+		return 0, 0, syscall.EINVAL
+	}
+	n, oobn, err = c.writeMsgBuffers(v, oob, addr)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: addr.opAddr(), Err: err}
+	}
+	return
+}
+
+// SendFDs sends the open files fds to the peer of c, along with
+// payload, in a single message using SCM_RIGHTS. It returns the number
+// of payload bytes written. The files stay open in the calling
+// process; the peer receives new descriptors for them.
+//
+// On stream connections the payload may be written only in part, in
+// which case the files go with the part written. If payload is empty,
+// one zero byte is written in its place, as with WriteMsgUnix.
+func (c *UnixConn) SendFDs(fds []*os.File, payload []byte) (int, error) {
+	if !c.ok() {
+		return 0, syscall.EINVAL
+	}
+	n, err := c.sendFDs(fds, payload)
+	if err != nil {
+		err = &OpError{Op: "write", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, err
+}
+
+// ReceiveFDs reads a message sent with SendFDs, or any message with
+// SCM_RIGHTS control data, copying the payload into b. It returns the
+// number of bytes copied into b and the files received, which are
+// close-on-exec. Files in non-blocking mode are added to the runtime
+// poller, as with os.NewFile.
+//
+// ReceiveFDs accepts up to maxFDs files. If the message carries more,
+// the system discards the rest; ReceiveFDs then closes the files it
+// did receive and returns an error, so that none leak.
+func (c *UnixConn) ReceiveFDs(b []byte, maxFDs int) (int, []*os.File, error) {
+	if !c.ok() {
+		return 0, nil, syscall.EINVAL
+	}
+	n, files, err := c.receiveFDs(b, maxFDs)
+	if err != nil {
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return n, files, err
+}
+
 func newUnixConn(fd *netFD) *UnixConn { return &UnixConn{conn{fd}} }
 
 // DialUnix acts like Dial for Unix networks.
@@ -222,6 +320,7 @@ type UnixListener struct {
 	path       string
 	unlink     bool
 	unlinkOnce sync.Once
+	lc         ListenConfig
 }
 
 func (ln *UnixListener) ok() bool { return ln != nil && ln.fd != nil }
@@ -0,0 +1,48 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysSOL_LOCAL      = 0x0
+	sysLOCAL_PEERCRED = 0x1
+	sysLOCAL_PEERPID  = 0x2
+)
+
+// sysXucred is struct xucred from sys/ucred.h.
+type sysXucred struct {
+	version uint32
+	uid     uint32
+	ngroups int16
+	groups  [16]uint32
+}
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	var cred sysXucred
+	var pid int
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		size := uint32(unsafe.Sizeof(cred))
+		if err = unix.Getsockopt(int(s), sysSOL_LOCAL, sysLOCAL_PEERCRED, unsafe.Pointer(&cred), &size); err != nil {
+			return
+		}
+		pid, err = syscall.GetsockoptInt(int(s), sysSOL_LOCAL, sysLOCAL_PEERPID)
+	}); cerr != nil {
+		return UnixCredentials{}, cerr
+	}
+	if err != nil {
+		return UnixCredentials{}, wrapSyscallError("getsockopt", err)
+	}
+	return UnixCredentials{PID: pid, UID: int(cred.uid), GID: int(cred.groups[0])}, nil
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.ENOPROTOOPT
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	var ucred *syscall.Ucred
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		ucred, err = syscall.GetsockoptUcred(int(s), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); cerr != nil {
+		return UnixCredentials{}, cerr
+	}
+	if err != nil {
+		return UnixCredentials{}, wrapSyscallError("getsockopt", err)
+	}
+	return UnixCredentials{PID: int(ucred.Pid), UID: int(ucred.Uid), GID: int(ucred.Gid)}, nil
+}
+
+// sysSO_PEERSEC is missing from package syscall on some
+// architectures.
+const sysSO_PEERSEC = 0x1f
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	b := make([]byte, 256)
+	for {
+		var err error
+		size := uint32(len(b))
+		if cerr := fd.pfd.RawControl(func(s uintptr) {
+			err = unix.Getsockopt(int(s), syscall.SOL_SOCKET, sysSO_PEERSEC, unsafe.Pointer(&b[0]), &size)
+		}); cerr != nil {
+			return "", cerr
+		}
+		// The kernel sets size to what the label needs when b is
+		// too short for it.
+		if err == syscall.ERANGE && int(size) > len(b) {
+			b = make([]byte, size)
+			continue
+		}
+		if err != nil {
+			return "", wrapSyscallError("getsockopt", err)
+		}
+		b = b[:size]
+		for len(b) > 0 && b[len(b)-1] == 0 {
+			b = b[:len(b)-1]
+		}
+		return string(b), nil
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysSOL_LOCAL     = 0x0
+	sysLOCAL_PEEREID = 0x3
+)
+
+// sysUnpcbid is struct unpcbid from sys/un.h.
+type sysUnpcbid struct {
+	pid  int32
+	euid uint32
+	egid uint32
+}
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	var id sysUnpcbid
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		size := uint32(unsafe.Sizeof(id))
+		err = unix.Getsockopt(int(s), sysSOL_LOCAL, sysLOCAL_PEEREID, unsafe.Pointer(&id), &size)
+	}); cerr != nil {
+		return UnixCredentials{}, cerr
+	}
+	if err != nil {
+		return UnixCredentials{}, wrapSyscallError("getsockopt", err)
+	}
+	return UnixCredentials{PID: int(id.pid), UID: int(id.euid), GID: int(id.egid)}, nil
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.ENOPROTOOPT
+}
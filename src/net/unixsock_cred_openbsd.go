@@ -0,0 +1,37 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+// sysSockpeercred is struct sockpeercred from sys/socket.h.
+type sysSockpeercred struct {
+	uid uint32
+	gid uint32
+	pid int32
+}
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	var cred sysSockpeercred
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		size := uint32(unsafe.Sizeof(cred))
+		err = unix.Getsockopt(int(s), syscall.SOL_SOCKET, syscall.SO_PEERCRED, unsafe.Pointer(&cred), &size)
+	}); cerr != nil {
+		return UnixCredentials{}, cerr
+	}
+	if err != nil {
+		return UnixCredentials{}, wrapSyscallError("getsockopt", err)
+	}
+	return UnixCredentials{PID: int(cred.pid), UID: int(cred.uid), GID: int(cred.gid)}, nil
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.ENOPROTOOPT
+}
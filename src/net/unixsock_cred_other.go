@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !plan9
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!plan9
+
+package net
+
+import "syscall"
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	return UnixCredentials{}, syscall.ENOPROTOOPT
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.ENOPROTOOPT
+}
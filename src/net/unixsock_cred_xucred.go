@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build dragonfly || freebsd
+// +build dragonfly freebsd
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysSOL_LOCAL      = 0x0
+	sysLOCAL_PEERCRED = 0x1
+)
+
+// sysXucred is struct xucred from sys/ucred.h. On FreeBSD 13 and
+// later, the union at its end holds the process ID; DragonFly BSD and
+// older versions of FreeBSD leave it zero.
+type sysXucred struct {
+	version uint32
+	uid     uint32
+	ngroups int16
+	groups  [16]uint32
+	pid     uintptr
+}
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	var cred sysXucred
+	var err error
+	if cerr := fd.pfd.RawControl(func(s uintptr) {
+		size := uint32(unsafe.Sizeof(cred))
+		err = unix.Getsockopt(int(s), sysSOL_LOCAL, sysLOCAL_PEERCRED, unsafe.Pointer(&cred), &size)
+	}); cerr != nil {
+		return UnixCredentials{}, cerr
+	}
+	if err != nil {
+		return UnixCredentials{}, wrapSyscallError("getsockopt", err)
+	}
+	pid := -1
+	if runtime.GOOS == "freebsd" {
+		if p := *(*int32)(unsafe.Pointer(&cred.pid)); p != 0 {
+			pid = int(p)
+		}
+	}
+	return UnixCredentials{PID: pid, UID: int(cred.uid), GID: int(cred.groups[0])}, nil
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.ENOPROTOOPT
+}
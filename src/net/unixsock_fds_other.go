@@ -0,0 +1,25 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package net
+
+import (
+	"errors"
+	"os"
+)
+
+// errFDPassingNotSupported is returned by SendFDs and ReceiveFDs on
+// systems without SCM_RIGHTS.
+var errFDPassingNotSupported = errors.New("file descriptor passing not supported")
+
+func (c *UnixConn) sendFDs(files []*os.File, payload []byte) (int, error) {
+	return 0, errFDPassingNotSupported
+}
+
+func (c *UnixConn) receiveFDs(b []byte, maxFDs int) (int, []*os.File, error) {
+	return 0, nil, errFDPassingNotSupported
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package net
+
+import (
+	"errors"
+	"internal/itoa"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// errTooManyFDs is returned by ReceiveFDs for a message that carries
+// more files than it accepts.
+var errTooManyFDs = errors.New("too many file descriptors in message")
+
+func (c *UnixConn) sendFDs(files []*os.File, payload []byte) (int, error) {
+	// Take the descriptors through SyscallConn rather than Fd,
+	// which would put the files in blocking mode.
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		rc, err := f.SyscallConn()
+		if err != nil {
+			return 0, err
+		}
+		if err := rc.Control(func(fd uintptr) { fds = append(fds, int(fd)) }); err != nil {
+			return 0, err
+		}
+	}
+	n, _, err := c.writeMsg(payload, syscall.UnixRights(fds...), nil)
+	runtime.KeepAlive(files)
+	return n, err
+}
+
+func (c *UnixConn) receiveFDs(b []byte, maxFDs int) (int, []*os.File, error) {
+	if maxFDs < 0 {
+		maxFDs = 0
+	}
+	oob := make([]byte, syscall.CmsgSpace(maxFDs*4))
+	n, oobn, flags, _, err := c.readMsg(b, oob)
+	if err != nil {
+		return n, nil, err
+	}
+	var files []*os.File
+	if oobn > 0 {
+		scms, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return n, nil, os.NewSyscallError("parsesocketcontrolmessage", err)
+		}
+		for _, scm := range scms {
+			if scm.Header.Level != syscall.SOL_SOCKET || scm.Header.Type != syscall.SCM_RIGHTS {
+				continue
+			}
+			fds, err := syscall.ParseUnixRights(&scm)
+			if err != nil {
+				continue
+			}
+			for _, fd := range fds {
+				files = append(files, os.NewFile(uintptr(fd), "fd:"+itoa.Itoa(fd)))
+			}
+		}
+	}
+	// The control buffer is padded for alignment and may have room
+	// for more than maxFDs descriptors; those are refused as well.
+	if flags&syscall.MSG_CTRUNC != 0 || len(files) > maxFDs {
+		for _, f := range files {
+			f.Close()
+		}
+		return n, nil, errTooManyFDs
+	}
+	return n, files, nil
+}
@@ -26,6 +26,10 @@ func (c *UnixConn) writeMsg(b, oob []byte, addr *UnixAddr) (n, oobn int, err err
 	return 0, 0, syscall.EPLAN9
 }
 
+func (c *UnixConn) writeMsgBuffers(v Buffers, oob []byte, addr *UnixAddr) (n, oobn int, err error) {
+	return 0, 0, syscall.EPLAN9
+}
+
 func (sd *sysDialer) dialUnix(ctx context.Context, laddr, raddr *UnixAddr) (*UnixConn, error) {
 	return nil, syscall.EPLAN9
 }
@@ -49,3 +53,11 @@ func (sl *sysListener) listenUnix(ctx context.Context, laddr *UnixAddr) (*UnixLi
 func (sl *sysListener) listenUnixgram(ctx context.Context, laddr *UnixAddr) (*UnixConn, error) {
 	return nil, syscall.EPLAN9
 }
+
+func peerCredentials(fd *netFD) (UnixCredentials, error) {
+	return UnixCredentials{}, syscall.EPLAN9
+}
+
+func peerSecurityContext(fd *netFD) (string, error) {
+	return "", syscall.EPLAN9
+}
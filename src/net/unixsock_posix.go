@@ -155,6 +155,20 @@ func (c *UnixConn) writeMsg(b, oob []byte, addr *UnixAddr) (n, oobn int, err err
 	return c.fd.writeMsg(b, oob, sa)
 }
 
+func (c *UnixConn) writeMsgBuffers(v Buffers, oob []byte, addr *UnixAddr) (n, oobn int, err error) {
+	if c.fd.sotype == syscall.SOCK_DGRAM && c.fd.isConnected && addr != nil {
+		return 0, 0, ErrWriteToConnected
+	}
+	var sa syscall.Sockaddr
+	if addr != nil {
+		if addr.Net != sotypeToNet(c.fd.sotype) {
+			return 0, 0, syscall.EAFNOSUPPORT
+		}
+		sa = &syscall.SockaddrUnix{Name: addr.Name}
+	}
+	return c.fd.writeMsgBuffers(v, oob, sa)
+}
+
 func (sd *sysDialer) dialUnix(ctx context.Context, laddr, raddr *UnixAddr) (*UnixConn, error) {
 	fd, err := unixSocket(ctx, sd.network, laddr, raddr, "dial", sd.Dialer.Control)
 	if err != nil {
@@ -168,6 +182,10 @@ func (ln *UnixListener) accept() (*UnixConn, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := ln.lc.connControl(fd); err != nil {
+		fd.Close()
+		return nil, err
+	}
 	return newUnixConn(fd), nil
 }
 
@@ -216,7 +234,7 @@ func (sl *sysListener) listenUnix(ctx context.Context, laddr *UnixAddr) (*UnixLi
 	if err != nil {
 		return nil, err
 	}
-	return &UnixListener{fd: fd, path: fd.laddr.String(), unlink: true}, nil
+	return &UnixListener{fd: fd, path: fd.laddr.String(), unlink: true, lc: sl.ListenConfig}, nil
 }
 
 func (sl *sysListener) listenUnixgram(ctx context.Context, laddr *UnixAddr) (*UnixConn, error) {
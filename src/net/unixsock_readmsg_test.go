@@ -8,6 +8,7 @@
 package net
 
 import (
+	"io"
 	"os"
 	"syscall"
 	"testing"
@@ -103,3 +104,73 @@ func TestUnixConnReadMsgUnixSCMRightsCloseOnExec(t *testing.T) {
 		t.Fatalf("got flags %#x, want %#x (FD_CLOEXEC) set", flags, syscall.FD_CLOEXEC)
 	}
 }
+
+func TestUnixConnSendFDs(t *testing.T) {
+	if !testableNetwork("unix") {
+		t.Skip("not unix system")
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_LOCAL, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("Socketpair: %v", err)
+	}
+	writeFile := os.NewFile(uintptr(fds[0]), "write-socket")
+	defer writeFile.Close()
+	readFile := os.NewFile(uintptr(fds[1]), "read-socket")
+	defer readFile.Close()
+	cw, err := FileConn(writeFile)
+	if err != nil {
+		t.Fatalf("FileConn: %v", err)
+	}
+	defer cw.Close()
+	cr, err := FileConn(readFile)
+	if err != nil {
+		t.Fatalf("FileConn: %v", err)
+	}
+	defer cr.Close()
+	ucw, ucr := cw.(*UnixConn), cr.(*UnixConn)
+	ucw.SetDeadline(time.Now().Add(5 * time.Second))
+	ucr.SetDeadline(time.Now().Add(5 * time.Second))
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	if _, err := ucw.SendFDs([]*os.File{pr}, []byte("x")); err != nil {
+		t.Fatalf("SendFDs: %v", err)
+	}
+	b := make([]byte, 1)
+	n, files, err := ucr.ReceiveFDs(b, 1)
+	if err != nil {
+		t.Fatalf("ReceiveFDs: %v", err)
+	}
+	if n != 1 || b[0] != 'x' {
+		t.Errorf("got payload %q; want %q", b[:n], "x")
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files; want 1", len(files))
+	}
+	defer files[0].Close()
+	if _, err := pw.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(files[0], buf); err != nil {
+		t.Fatalf("reading received file: %v", err)
+	}
+	if string(buf) != "hello" {
+		t.Errorf("got %q from received file; want %q", buf, "hello")
+	}
+
+	// A message with more files than accepted must fail without
+	// leaking the ones received.
+	if _, err := ucw.SendFDs([]*os.File{pr, pw, pr}, []byte("y")); err != nil {
+		t.Fatalf("SendFDs: %v", err)
+	}
+	if _, files, err := ucr.ReceiveFDs(b, 1); err == nil {
+		t.Errorf("ReceiveFDs with too many files succeeded with %d files", len(files))
+	}
+}
@@ -471,3 +471,93 @@ func TestUnixUnlink(t *testing.T) {
 		l.Close()
 	})
 }
+
+func TestUnixConnPeerCredentials(t *testing.T) {
+	if !testableNetwork("unix") {
+		t.Skip("unix test")
+	}
+	switch runtime.GOOS {
+	case "darwin", "dragonfly", "freebsd", "ios", "linux", "netbsd", "openbsd":
+	default:
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	addr := testUnixAddr()
+	ln, err := ListenUnix("unix", &UnixAddr{Name: addr, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		c, err := ln.AcceptUnix()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer c.Close()
+		cred, err := c.PeerCredentials()
+		if err != nil {
+			done <- err
+			return
+		}
+		if cred.UID != os.Geteuid() || cred.GID != os.Getegid() {
+			t.Errorf("got UID %d and GID %d; want %d and %d", cred.UID, cred.GID, os.Geteuid(), os.Getegid())
+		}
+		if cred.PID != -1 && cred.PID != os.Getpid() {
+			t.Errorf("got PID %d; want %d", cred.PID, os.Getpid())
+		}
+		done <- nil
+	}()
+
+	c, err := DialUnix("unix", nil, &UnixAddr{Name: addr, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.PeerCredentials(); err != nil {
+		t.Error(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnixgramWriteMsgBuffers(t *testing.T) {
+	if !testableNetwork("unixgram") {
+		t.Skip("unixgram test")
+	}
+
+	addr := testUnixAddr()
+	laddr, err := ResolveUnixAddr("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ListenUnixgram("unixgram", laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(addr)
+	defer c.Close()
+
+	c1, err := DialUnix("unixgram", nil, laddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	v := Buffers{[]byte("head"), []byte("er|"), []byte("body")}
+	if n, _, err := c1.WriteMsgBuffersUnix(v, nil, nil); err != nil || n != len("header|body") {
+		t.Fatalf("WriteMsgBuffersUnix = %d, %v, want %d, nil", n, err, len("header|body"))
+	}
+
+	c.SetReadDeadline(time.Now().Add(30 * time.Second))
+	b := make([]byte, 64)
+	n, _, err := c.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b[:n]), "header|body"; got != want {
+		t.Errorf("read datagram %q, want %q", got, want)
+	}
+}
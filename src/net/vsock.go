@@ -0,0 +1,285 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"errors"
+	"internal/itoa"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errVsockNotSupported is returned for VM sockets on systems where
+// package net does not implement them.
+var errVsockNotSupported = errors.New("VM sockets not supported")
+
+// sysAF_VSOCK is AF_VSOCK, which package syscall does not define on
+// all systems.
+const sysAF_VSOCK = 0x28
+
+// Well-known context IDs and ports of VM sockets.
+const (
+	VsockCIDHypervisor = 0         // the hypervisor
+	VsockCIDLocal      = 1         // the local host, for loopback
+	VsockCIDHost       = 2         // the host of the virtual machine
+	VsockCIDAny        = 1<<32 - 1 // any context ID, for listening
+	VsockPortAny       = 1<<32 - 1 // any port, for listening
+)
+
+// VsockAddr represents the address of a VM socket end point, as used
+// between virtual machines and their host.
+type VsockAddr struct {
+	ContextID uint32 // context ID of the virtual machine or host
+	Port      uint32
+}
+
+// Network returns the address's network name, "vsock".
+func (a *VsockAddr) Network() string { return "vsock" }
+
+func (a *VsockAddr) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	return JoinHostPort(itoa.Uitoa(uint(a.ContextID)), itoa.Uitoa(uint(a.Port)))
+}
+
+func (a *VsockAddr) isWildcard() bool {
+	return a == nil || a.ContextID == VsockCIDAny
+}
+
+func (a *VsockAddr) opAddr() Addr {
+	if a == nil {
+		return nil
+	}
+	return a
+}
+
+// ResolveVsockAddr returns an address of a VM socket end point.
+//
+// The network must be "vsock". The address has the form "cid:port",
+// where cid and port are decimal numbers. If cid is empty, as in
+// ":1024", it is VsockCIDAny; if port is empty or "0", as in "2:", it
+// is VsockPortAny.
+func ResolveVsockAddr(network, address string) (*VsockAddr, error) {
+	if network != "vsock" {
+		return nil, UnknownNetworkError(network)
+	}
+	cid, port, err := SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	a := &VsockAddr{ContextID: VsockCIDAny, Port: VsockPortAny}
+	if cid != "" {
+		n, ok := parseVsockUint32(cid)
+		if !ok {
+			return nil, &AddrError{Err: "invalid context ID", Addr: address}
+		}
+		a.ContextID = n
+	}
+	if port != "" {
+		n, ok := parseVsockUint32(port)
+		if !ok {
+			return nil, &AddrError{Err: "invalid port", Addr: address}
+		}
+		if n != 0 {
+			a.Port = n
+		}
+	}
+	return a, nil
+}
+
+// parseVsockUint32 parses the decimal form of a context ID or port,
+// which unlike those dtoi handles can use all 32 bits.
+func parseVsockUint32(s string) (uint32, bool) {
+	if s == "" || len(s) > 10 {
+		return 0, false
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+		n = n*10 + uint64(s[i]-'0')
+	}
+	if n > 1<<32-1 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+// VsockConn is an implementation of the Conn interface for
+// connections over VM sockets (AF_VSOCK).
+type VsockConn struct {
+	conn
+}
+
+func newVsockConn(fd *netFD) *VsockConn { return &VsockConn{conn{fd}} }
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+func (c *VsockConn) SyscallConn() (syscall.RawConn, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawConn(c.fd)
+}
+
+// CloseRead shuts down the reading side of the VM socket connection.
+// Most callers should just use Close.
+func (c *VsockConn) CloseRead() error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := c.fd.closeRead(); err != nil {
+		return &OpError{Op: "close", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// CloseWrite shuts down the writing side of the VM socket connection.
+// Most callers should just use Close.
+func (c *VsockConn) CloseWrite() error {
+	if !c.ok() {
+		return syscall.EINVAL
+	}
+	if err := c.fd.closeWrite(); err != nil {
+		return &OpError{Op: "close", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// DialVsock acts like Dial for VM sockets.
+//
+// The network must be "vsock".
+// If laddr is nil, a local address is automatically chosen.
+func DialVsock(network string, laddr, raddr *VsockAddr) (*VsockConn, error) {
+	if network != "vsock" {
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: UnknownNetworkError(network)}
+	}
+	if raddr == nil {
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: nil, Err: errMissingAddress}
+	}
+	sd := &sysDialer{network: network, address: raddr.String()}
+	c, err := sd.dialVsock(context.Background(), laddr, raddr)
+	if err != nil {
+		return nil, &OpError{Op: "dial", Net: network, Source: laddr.opAddr(), Addr: raddr.opAddr(), Err: err}
+	}
+	return c, nil
+}
+
+// VsockListener is a VM socket listener. Clients should typically
+// use variables of type Listener instead of assuming VM sockets.
+type VsockListener struct {
+	fd *netFD
+	lc ListenConfig
+}
+
+// SyscallConn returns a raw network connection.
+// This implements the syscall.Conn interface.
+//
+// The returned RawConn only supports calling Control. Read and
+// Write return an error.
+func (l *VsockListener) SyscallConn() (syscall.RawConn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	return newRawListener(l.fd)
+}
+
+// AcceptVsock accepts the next incoming call and returns the new
+// connection.
+func (l *VsockListener) AcceptVsock() (*VsockConn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	c, err := l.accept()
+	if err != nil {
+		return nil, &OpError{Op: "accept", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return c, nil
+}
+
+// Accept implements the Accept method in the Listener interface; it
+// waits for the next call and returns a generic Conn.
+func (l *VsockListener) Accept() (Conn, error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	c, err := l.accept()
+	if err != nil {
+		return nil, &OpError{Op: "accept", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return c, nil
+}
+
+// Close stops listening on the VM socket address.
+// Already Accepted connections are not closed.
+func (l *VsockListener) Close() error {
+	if !l.ok() {
+		return syscall.EINVAL
+	}
+	if err := l.close(); err != nil {
+		return &OpError{Op: "close", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// Addr returns the listener's network address, a *VsockAddr.
+// The Addr returned is shared by all invocations of Addr, so
+// do not modify it.
+func (l *VsockListener) Addr() Addr { return l.fd.laddr }
+
+// SetDeadline sets the deadline associated with the listener.
+// A zero time value disables the deadline.
+func (l *VsockListener) SetDeadline(t time.Time) error {
+	if !l.ok() {
+		return syscall.EINVAL
+	}
+	if err := l.fd.pfd.SetDeadline(t); err != nil {
+		return &OpError{Op: "set", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return nil
+}
+
+// File returns a copy of the underlying os.File.
+// It is the caller's responsibility to close f when finished.
+// Closing l does not affect f, and closing f does not affect l.
+//
+// The returned os.File's file descriptor is different from the
+// connection's. Attempting to change properties of the original
+// using this duplicate may or may not have the desired effect.
+func (l *VsockListener) File() (f *os.File, err error) {
+	if !l.ok() {
+		return nil, syscall.EINVAL
+	}
+	f, err = l.file()
+	if err != nil {
+		return nil, &OpError{Op: "file", Net: l.fd.net, Source: nil, Addr: l.fd.laddr, Err: err}
+	}
+	return
+}
+
+// ListenVsock acts like Listen for VM sockets.
+//
+// The network must be "vsock".
+// If laddr is nil, ListenVsock listens on VsockCIDAny and a port
+// number is automatically chosen, as it is if the Port field of
+// laddr is VsockPortAny.
+func ListenVsock(network string, laddr *VsockAddr) (*VsockListener, error) {
+	if network != "vsock" {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: UnknownNetworkError(network)}
+	}
+	if laddr == nil {
+		laddr = &VsockAddr{ContextID: VsockCIDAny, Port: VsockPortAny}
+	}
+	sl := &sysListener{network: network, address: laddr.String()}
+	ln, err := sl.listenVsock(context.Background(), laddr)
+	if err != nil {
+		return nil, &OpError{Op: "listen", Net: network, Source: nil, Addr: laddr.opAddr(), Err: err}
+	}
+	return ln, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"context"
+	"internal/syscall/unix"
+	"os"
+	"syscall"
+)
+
+func sockaddrToVsock(sa syscall.Sockaddr) Addr {
+	if cid, port, ok := unix.SockaddrVMAddr(sa); ok {
+		return &VsockAddr{ContextID: cid, Port: port}
+	}
+	return nil
+}
+
+func (a *VsockAddr) family() int {
+	return sysAF_VSOCK
+}
+
+func (a *VsockAddr) sockaddr(family int) (syscall.Sockaddr, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return unix.NewSockaddrVM(a.ContextID, a.Port), nil
+}
+
+func (a *VsockAddr) toLocal(net string) sockaddr {
+	return &VsockAddr{ContextID: VsockCIDLocal, Port: a.Port}
+}
+
+func vsockSocket(ctx context.Context, net string, laddr, raddr *VsockAddr, ctrlFn func(string, string, syscall.RawConn) error) (*netFD, error) {
+	// A nil *VsockAddr must not be passed to socket as a non-nil
+	// sockaddr interface.
+	var la, ra sockaddr
+	if laddr != nil {
+		la = laddr
+	}
+	if raddr != nil {
+		ra = raddr
+	}
+	return socket(ctx, net, sysAF_VSOCK, syscall.SOCK_STREAM, 0, false, la, ra, ctrlFn)
+}
+
+func (sd *sysDialer) dialVsock(ctx context.Context, laddr, raddr *VsockAddr) (*VsockConn, error) {
+	fd, err := vsockSocket(ctx, sd.network, laddr, raddr, sd.Dialer.Control)
+	if err != nil {
+		return nil, err
+	}
+	return newVsockConn(fd), nil
+}
+
+func (ln *VsockListener) ok() bool { return ln != nil && ln.fd != nil }
+
+func (ln *VsockListener) accept() (*VsockConn, error) {
+	fd, err := ln.fd.accept()
+	if err != nil {
+		return nil, err
+	}
+	if err := ln.lc.connControl(fd); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return newVsockConn(fd), nil
+}
+
+func (ln *VsockListener) close() error {
+	return ln.fd.Close()
+}
+
+func (ln *VsockListener) file() (*os.File, error) {
+	f, err := ln.fd.dup()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (sl *sysListener) listenVsock(ctx context.Context, laddr *VsockAddr) (*VsockListener, error) {
+	fd, err := vsockSocket(ctx, sl.network, laddr, nil, sl.ListenConfig.Control)
+	if err != nil {
+		return nil, err
+	}
+	return &VsockListener{fd: fd, lc: sl.ListenConfig}, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+)
+
+func TestVsockLoopback(t *testing.T) {
+	ln, err := Listen("vsock", ":")
+	if errors.Is(err, syscall.EAFNOSUPPORT) {
+		t.Skipf("VM sockets not supported: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	la := ln.Addr().(*VsockAddr)
+	if la.Port == VsockPortAny {
+		t.Fatalf("listener address %v has no port", la)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer c.Close()
+		_, err = io.Copy(c, c)
+		done <- err
+	}()
+
+	c, err := DialVsock("vsock", nil, &VsockAddr{ContextID: VsockCIDLocal, Port: la.Port})
+	if errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.EADDRNOTAVAIL) || errors.Is(err, syscall.ETIMEDOUT) {
+		// Without the vsock_loopback transport, the connection
+		// goes to the host, which may not answer.
+		t.Skipf("VM socket loopback not available: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if ra := c.RemoteAddr().(*VsockAddr); ra.Port != la.Port {
+		t.Errorf("got remote address %v; want port %d", ra, la.Port)
+	}
+	if _, err := c.Write([]byte("HELLO")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "HELLO" {
+		t.Errorf("got %q; want %q", b, "HELLO")
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
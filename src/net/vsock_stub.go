@@ -0,0 +1,35 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package net
+
+import (
+	"context"
+	"os"
+)
+
+func (sd *sysDialer) dialVsock(ctx context.Context, laddr, raddr *VsockAddr) (*VsockConn, error) {
+	return nil, errVsockNotSupported
+}
+
+func (ln *VsockListener) ok() bool { return ln != nil && ln.fd != nil }
+
+func (ln *VsockListener) accept() (*VsockConn, error) {
+	return nil, errVsockNotSupported
+}
+
+func (ln *VsockListener) close() error {
+	return errVsockNotSupported
+}
+
+func (ln *VsockListener) file() (*os.File, error) {
+	return nil, errVsockNotSupported
+}
+
+func (sl *sysListener) listenVsock(ctx context.Context, laddr *VsockAddr) (*VsockListener, error) {
+	return nil, errVsockNotSupported
+}
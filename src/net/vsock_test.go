@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"reflect"
+	"testing"
+)
+
+var resolveVsockAddrTests = []struct {
+	network string
+	address string
+	addr    *VsockAddr
+	err     error
+}{
+	{"vsock", "2:1024", &VsockAddr{ContextID: VsockCIDHost, Port: 1024}, nil},
+	{"vsock", "3:4294967294", &VsockAddr{ContextID: 3, Port: 1<<32 - 2}, nil},
+	{"vsock", ":1024", &VsockAddr{ContextID: VsockCIDAny, Port: 1024}, nil},
+	{"vsock", "1:0", &VsockAddr{ContextID: VsockCIDLocal, Port: VsockPortAny}, nil},
+	{"vsock", "1:", &VsockAddr{ContextID: VsockCIDLocal, Port: VsockPortAny}, nil},
+
+	{"vsock", "4294967296:1", nil, &AddrError{Err: "invalid context ID", Addr: "4294967296:1"}},
+	{"vsock", "host:1", nil, &AddrError{Err: "invalid context ID", Addr: "host:1"}},
+	{"vsock", "2:-1", nil, &AddrError{Err: "invalid port", Addr: "2:-1"}},
+	{"tcp", "2:1024", nil, UnknownNetworkError("tcp")},
+}
+
+func TestResolveVsockAddr(t *testing.T) {
+	for _, tt := range resolveVsockAddrTests {
+		addr, err := ResolveVsockAddr(tt.network, tt.address)
+		if !reflect.DeepEqual(addr, tt.addr) || !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("ResolveVsockAddr(%q, %q) = %#v, %v, want %#v, %v", tt.network, tt.address, addr, err, tt.addr, tt.err)
+			continue
+		}
+		if err == nil {
+			addr2, err := ResolveVsockAddr(addr.Network(), addr.String())
+			if !reflect.DeepEqual(addr2, tt.addr) || err != nil {
+				t.Errorf("ResolveVsockAddr(%q, %q) = %#v, %v, want %#v, <nil>", addr.Network(), addr.String(), addr2, err, tt.addr)
+			}
+		}
+	}
+}
@@ -226,3 +226,39 @@ func TestWritevError(t *testing.T) {
 		t.Fatal("Buffers.WriteTo(closed conn) succeeded, want error")
 	}
 }
+
+func TestConnWriteVec(t *testing.T) {
+	oldHook := poll.TestHookDidWritev
+	defer func() { poll.TestHookDidWritev = oldHook }()
+	var calls int
+	poll.TestHookDidWritev = func(int) { calls++ }
+
+	bufs := [][]byte{[]byte("header "), nil, []byte("payload")}
+	const want = "header payload"
+	withTCPConnPair(t, func(c *TCPConn) error {
+		var vw io.VecWriter = c
+		n, err := vw.WriteVec(bufs)
+		if err != nil {
+			return err
+		}
+		if n != int64(len(want)) {
+			return fmt.Errorf("WriteVec returned %d; want %d", n, len(want))
+		}
+		if string(bufs[0]) != "header " || string(bufs[2]) != "payload" {
+			return fmt.Errorf("WriteVec modified its argument: %q", bufs)
+		}
+		return nil
+	}, func(c *TCPConn) error {
+		all, err := io.ReadAll(c)
+		if string(all) != want || err != nil {
+			return fmt.Errorf("client read %q, %v; want %q, nil", all, err, want)
+		}
+		switch runtime.GOOS {
+		case "android", "darwin", "ios", "dragonfly", "freebsd", "illumos", "linux", "netbsd", "openbsd", "windows":
+			if calls != 1 {
+				t.Errorf("writev calls = %d; want 1", calls)
+			}
+		}
+		return nil
+	})
+}
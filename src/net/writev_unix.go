@@ -24,6 +24,13 @@ func (c *conn) writeBuffers(v *Buffers) (int64, error) {
 }
 
 func (fd *netFD) writeBuffers(v *Buffers) (n int64, err error) {
+	if fd.sotype == syscall.SOCK_DGRAM || fd.sotype == syscall.SOCK_SEQPACKET {
+		// The buffers make up a single message, which writev
+		// would split if there are more than it takes at once.
+		m, _, err := fd.writeMsgBuffers(*v, nil, nil)
+		v.consume(int64(m))
+		return int64(m), err
+	}
 	n, err = fd.pfd.Writev((*[][]byte)(v))
 	runtime.KeepAlive(fd)
 	return n, wrapSyscallError("writev", err)
@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"runtime"
+	"sync/atomic"
+	"syscall"
+)
+
+const (
+	// soZerocopy is SO_ZEROCOPY from linux/socket.h.
+	soZerocopy = 0x3c
+
+	// zeroCopyThreshold is the smallest write sent with MSG_ZEROCOPY.
+	// Below it, pinning the pages and reading the completion cost
+	// more than copying the data.
+	zeroCopyThreshold = 32 << 10
+)
+
+func setZeroCopyWrites(fd *netFD, on bool) error {
+	err := fd.pfd.SetsockoptInt(syscall.SOL_SOCKET, soZerocopy, boolint(on))
+	runtime.KeepAlive(fd)
+	if err != nil {
+		return wrapSyscallError("setsockopt", err)
+	}
+	atomic.StoreUint32(&fd.zeroCopy, uint32(boolint(on)))
+	return nil
+}
+
+// writeZeroCopy writes p to fd with MSG_ZEROCOPY if that is enabled
+// for fd and p is large enough to benefit.
+//
+// If writeZeroCopy returns handled == false, it has performed no work.
+func writeZeroCopy(fd *netFD, p []byte) (n int, err error, handled bool) {
+	if len(p) < zeroCopyThreshold || atomic.LoadUint32(&fd.zeroCopy) == 0 {
+		return 0, nil, false
+	}
+	n, err = fd.pfd.WriteZeroCopy(p)
+	runtime.KeepAlive(fd)
+	return n, wrapSyscallError("sendmsg", err), true
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+)
+
+func TestZeroCopyWrites(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+		defer c.Close()
+		data, err := io.ReadAll(c)
+		done <- result{data, err}
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tc := c.(*TCPConn)
+	if err := tc.SetZeroCopyWrites(true); err != nil {
+		if errors.Is(err, syscall.ENOPROTOOPT) || errors.Is(err, syscall.EOPNOTSUPP) {
+			t.Skipf("MSG_ZEROCOPY not supported: %v", err)
+		}
+		t.Fatal(err)
+	}
+
+	var want []byte
+	for _, size := range []int{100, zeroCopyThreshold, 1 << 20, 5 << 20} {
+		buf := bytes.Repeat([]byte{byte(size)}, size)
+		n, err := tc.Write(buf)
+		if n != size || err != nil {
+			t.Fatalf("Write(%d bytes) = %d, %v", size, n, err)
+		}
+		// The kernel is done with buf once Write returns.
+		for i := range buf {
+			buf[i] = 0xff
+		}
+		want = append(want, bytes.Repeat([]byte{byte(size)}, size)...)
+	}
+	if err := tc.SetZeroCopyWrites(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.Write(make([]byte, zeroCopyThreshold)); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, make([]byte, zeroCopyThreshold)...)
+	tc.CloseWrite()
+
+	r := <-done
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if !bytes.Equal(r.data, want) {
+		t.Errorf("read %d bytes, want %d bytes as written", len(r.data), len(want))
+	}
+}
+
+func TestZeroCopyReceiver(t *testing.T) {
+	ln, err := newLocalListener("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	want := make([]byte, 8<<20)
+	for i := range want {
+		want[i] = byte(i / 4096)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer c.Close()
+		_, err = c.Write(want)
+		errc <- err
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	z, err := c.(*TCPConn).ZeroCopyReceiver(1 << 20)
+	if err != nil {
+		t.Skipf("zero-copy receive not supported: %v", err)
+	}
+	defer z.Close()
+
+	var got []byte
+	var mapped int
+	for {
+		b, err := z.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if len(got) == 0 && (errors.Is(err, syscall.ENOPROTOOPT) || errors.Is(err, syscall.EINVAL)) {
+				t.Skipf("TCP_ZEROCOPY_RECEIVE not supported: %v", err)
+			}
+			t.Fatal(err)
+		}
+		if z.mapped > 0 {
+			mapped += len(b)
+		}
+		got = append(got, b...)
+	}
+	t.Logf("%d of %d bytes were mapped", mapped, len(got))
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("received %d bytes, want %d bytes as sent", len(got), len(want))
+	}
+	if _, err := z.Next(); err != io.EOF {
+		t.Errorf("Next after the end of the stream: %v; want io.EOF", err)
+	}
+}
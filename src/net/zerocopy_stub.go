@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !plan9
+// +build !linux,!plan9
+
+package net
+
+import "syscall"
+
+func setZeroCopyWrites(fd *netFD, on bool) error {
+	return syscall.ENOPROTOOPT
+}
+
+func writeZeroCopy(fd *netFD, p []byte) (int, error, bool) {
+	return 0, nil, false
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"io"
+	"syscall"
+)
+
+// A ZeroCopyReceiver receives the data of a TCP connection without
+// copying it, where the operating system can map the pages that hold
+// received data into the address space of the program. It needs
+// Linux 5.3 or later.
+//
+// The data are mapped only in whole pages, so zero-copy receive pays
+// off only for large transfers over links whose MTU leaves room for
+// page-sized segments, or with receive offloads that assemble them.
+// Data that cannot be mapped is copied.
+//
+// A ZeroCopyReceiver is not safe for concurrent use, and should not
+// be used along with the Read methods of its connection.
+type ZeroCopyReceiver struct {
+	c   *TCPConn
+	mem []byte // mapping that received pages are mapped into
+	buf []byte // buffer for data that cannot be mapped
+
+	mapped int // length of the data mapped into mem, to release
+}
+
+// ZeroCopyReceiver returns a ZeroCopyReceiver that receives the data
+// of c in regions of up to size bytes, rounded up to a number of
+// pages. It must be closed when no longer needed.
+func (c *TCPConn) ZeroCopyReceiver(size int) (*ZeroCopyReceiver, error) {
+	if !c.ok() {
+		return nil, syscall.EINVAL
+	}
+	if size <= 0 {
+		return nil, &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: syscall.EINVAL}
+	}
+	z, err := newZeroCopyReceiver(c, size)
+	if err != nil {
+		return nil, &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return z, nil
+}
+
+// Next waits for data to arrive on the connection and returns it.
+// The returned bytes are borrowed from the receiver: they must not be
+// modified, and must not be used after the next call to Next, Release
+// or Close. At the end of the stream, Next returns io.EOF.
+func (z *ZeroCopyReceiver) Next() ([]byte, error) {
+	if z == nil || z.mem == nil {
+		return nil, syscall.EINVAL
+	}
+	if err := z.Release(); err != nil {
+		return nil, err
+	}
+	b, err := z.next()
+	if err != nil && err != io.EOF {
+		c := z.c
+		err = &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return b, err
+}
+
+// Release gives back to the operating system the pages of the data
+// last returned by Next, ahead of the next call to Next.
+func (z *ZeroCopyReceiver) Release() error {
+	if z == nil || z.mem == nil {
+		return syscall.EINVAL
+	}
+	if z.mapped == 0 {
+		return nil
+	}
+	n := z.mapped
+	z.mapped = 0
+	if err := z.release(n); err != nil {
+		c := z.c
+		return &OpError{Op: "read", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
+
+// Close releases the resources of the receiver. It does not close the
+// connection.
+func (z *ZeroCopyReceiver) Close() error {
+	if z == nil || z.mem == nil {
+		return syscall.EINVAL
+	}
+	err := z.close()
+	z.mem, z.buf, z.mapped = nil, nil, 0
+	if err != nil {
+		c := z.c
+		return &OpError{Op: "close", Net: c.fd.net, Source: c.fd.laddr, Addr: c.fd.raddr, Err: err}
+	}
+	return nil
+}
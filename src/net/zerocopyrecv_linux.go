@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import (
+	"internal/syscall/unix"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// tcpZeroCopyReceive is TCP_ZEROCOPY_RECEIVE, added in Linux 4.18.
+const tcpZeroCopyReceive = 35
+
+// tcpZeroCopyReceiveArgs is the start of struct tcp_zerocopy_receive,
+// as of Linux 5.3, which added the inq and err fields.
+type tcpZeroCopyReceiveArgs struct {
+	address      uint64 // in: address of the mapping
+	length       uint32 // in/out: bytes to map, bytes mapped
+	recvSkipHint uint32 // out: bytes to read, as they cannot be mapped
+	inq          uint32 // out: bytes left in the receive queue
+	err          int32  // out: pending socket error, negated
+}
+
+func newZeroCopyReceiver(c *TCPConn, size int) (*ZeroCopyReceiver, error) {
+	pagesize := os.Getpagesize()
+	size = (size + pagesize - 1) &^ (pagesize - 1)
+	var mem []byte
+	var serr error
+	err := c.fd.pfd.RawControl(func(s uintptr) {
+		mem, serr = syscall.Mmap(int(s), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if serr != nil {
+		return nil, os.NewSyscallError("mmap", serr)
+	}
+	bufsize := 16 * pagesize
+	if bufsize > size {
+		bufsize = size
+	}
+	return &ZeroCopyReceiver{c: c, mem: mem, buf: make([]byte, bufsize)}, nil
+}
+
+func (z *ZeroCopyReceiver) next() ([]byte, error) {
+	var b []byte
+	var serr error
+	err := z.c.fd.pfd.RawRead(func(s uintptr) bool {
+		for {
+			zc := tcpZeroCopyReceiveArgs{
+				address: uint64(uintptr(unsafe.Pointer(&z.mem[0]))),
+				length:  uint32(len(z.mem)),
+			}
+			l := uint32(unsafe.Sizeof(zc))
+			err := unix.Getsockopt(int(s), syscall.IPPROTO_TCP, tcpZeroCopyReceive, unsafe.Pointer(&zc), &l)
+			if err == syscall.EIO {
+				// The peer has closed the connection, and
+				// nothing is left to read. Let recvfrom
+				// report how it was closed.
+				zc = tcpZeroCopyReceiveArgs{}
+			} else if err != nil {
+				serr = os.NewSyscallError("getsockopt", err)
+				return true
+			}
+			if zc.length > 0 {
+				z.mapped = int(zc.length)
+				b = z.mem[:zc.length]
+				return true
+			}
+			if zc.err != 0 {
+				serr = syscall.Errno(-zc.err)
+				return true
+			}
+			n := len(z.buf)
+			if zc.recvSkipHint > 0 && int(zc.recvSkipHint) < n {
+				n = int(zc.recvSkipHint)
+			}
+			flags := syscall.MSG_DONTWAIT
+			if zc.recvSkipHint == 0 {
+				// Nothing was queued: tell the end of the
+				// stream from data yet to come.
+				n, flags = 1, flags|syscall.MSG_PEEK
+			}
+			n, _, err = syscall.Recvfrom(int(s), z.buf[:n], flags)
+			switch {
+			case err == syscall.EINTR:
+				continue
+			case err == syscall.EAGAIN:
+				return false
+			case err != nil:
+				serr = os.NewSyscallError("recvfrom", err)
+				return true
+			case n == 0:
+				serr = io.EOF
+				return true
+			case flags&syscall.MSG_PEEK != 0:
+				// Data arrived meanwhile.
+				continue
+			}
+			b = z.buf[:n]
+			return true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, serr
+}
+
+func (z *ZeroCopyReceiver) release(n int) error {
+	// Zapping the pages returns them to the socket's memory
+	// accounting.
+	return os.NewSyscallError("madvise", syscall.Madvise(z.mem[:n], syscall.MADV_DONTNEED))
+}
+
+func (z *ZeroCopyReceiver) close() error {
+	return os.NewSyscallError("munmap", syscall.Munmap(z.mem))
+}
@@ -0,0 +1,23 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package net
+
+import "syscall"
+
+func newZeroCopyReceiver(c *TCPConn, size int) (*ZeroCopyReceiver, error) {
+	return nil, syscall.EPLAN9
+}
+
+func (z *ZeroCopyReceiver) next() ([]byte, error) {
+	return nil, syscall.EPLAN9
+}
+
+func (z *ZeroCopyReceiver) release(n int) error {
+	return nil
+}
+
+func (z *ZeroCopyReceiver) close() error {
+	return nil
+}
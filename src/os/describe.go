@@ -0,0 +1,59 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import "io"
+
+// A FileDescription reports how an open File is configured,
+// as returned by File.Describe.
+type FileDescription struct {
+	Name string   // name of the file as presented to Open or NewFile
+	Mode FileMode // file mode bits, as reported by Stat
+
+	// Flag holds the access mode and status flags of the file
+	// (O_RDONLY etc. and O_APPEND, O_SYNC, ...). On Unix systems it is
+	// read back from the descriptor, so it reflects files created by
+	// NewFile and flags changed since the file was opened. Elsewhere it
+	// holds the flag passed to OpenFile, or 0 for other files.
+	Flag int
+
+	Append      bool  // writes go to the end of the file; copy_file_range and similar fast paths are not used
+	Nonblock    bool  // the descriptor is in non-blocking mode
+	Pollable    bool  // the file is registered with the runtime poller, so deadlines work
+	CloseOnExec bool  // the descriptor is not inherited by child processes
+	Offset      int64 // current file offset, or -1 if not seekable or a directory being read
+}
+
+// Describe reports the flags the file was opened with, the mode bits of
+// the underlying file, and the settings the descriptor carries. It is
+// meant for debugging, for example to find out why an I/O fast path such
+// as File.ReadFrom is not taken for a particular file.
+// If there is an error, it will be of type *PathError.
+func (f *File) Describe() (FileDescription, error) {
+	if err := f.checkValid("describe"); err != nil {
+		return FileDescription{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return FileDescription{}, err
+	}
+	d := FileDescription{
+		Name:   f.name,
+		Mode:   fi.Mode(),
+		Flag:   f.flag,
+		Append: f.appendMode,
+		Offset: -1,
+	}
+	// Seeking a directory would discard the state of Readdir.
+	if f.dirinfo == nil {
+		if off, e := f.seek(0, io.SeekCurrent); e == nil {
+			d.Offset = off
+		}
+	}
+	if e := f.describe(&d); e != nil {
+		return FileDescription{}, f.wrapErr("describe", e)
+	}
+	return d, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "os"
+)
+
+func TestDescribe(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "describe")
+	f, err := OpenFile(name, O_RDWR|O_CREATE|O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := f.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != name {
+		t.Errorf("Name = %q; want %q", d.Name, name)
+	}
+	if !d.Mode.IsRegular() {
+		t.Errorf("Mode = %v; want regular file", d.Mode)
+	}
+	if !d.Append || d.Flag&O_APPEND == 0 {
+		t.Errorf("Append = %v, Flag = %#x; want O_APPEND", d.Append, d.Flag)
+	}
+	if d.Flag&(O_RDONLY|O_WRONLY|O_RDWR) != O_RDWR {
+		t.Errorf("Flag = %#x; want O_RDWR", d.Flag)
+	}
+	if d.Offset != 5 {
+		t.Errorf("Offset = %d; want 5", d.Offset)
+	}
+	switch runtime.GOOS {
+	case "js", "plan9":
+	default:
+		if !d.CloseOnExec {
+			t.Errorf("CloseOnExec = false; want true")
+		}
+	}
+
+	f.Close()
+	if _, err := f.Describe(); err == nil {
+		t.Error("Describe of closed file succeeded")
+	}
+}
+
+func TestDescribePipe(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9", "windows":
+		t.Skipf("pipes are not pollable on %s", runtime.GOOS)
+	}
+	r, w, err := Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	d, err := r.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Append {
+		t.Error("Append = true; want false")
+	}
+	if d.Offset != -1 {
+		t.Errorf("Offset = %d; want -1", d.Offset)
+	}
+	if !d.Pollable || !d.Nonblock {
+		t.Errorf("Pollable = %v, Nonblock = %v; want both true", d.Pollable, d.Nonblock)
+	}
+}
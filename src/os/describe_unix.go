@@ -0,0 +1,31 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package os
+
+import (
+	"runtime"
+	"syscall"
+)
+
+func (f *File) describe(d *FileDescription) error {
+	defer runtime.KeepAlive(f)
+	fl, err := f.pfd.Fcntl(syscall.F_GETFL, 0)
+	if err != nil {
+		return err
+	}
+	fd, err := f.pfd.Fcntl(syscall.F_GETFD, 0)
+	if err != nil {
+		return err
+	}
+	d.Flag = fl
+	d.Append = fl&syscall.O_APPEND != 0
+	d.Nonblock = fl&syscall.O_NONBLOCK != 0
+	d.Pollable = f.pfd.Pollable()
+	d.CloseOnExec = fd&syscall.FD_CLOEXEC != 0
+	return nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"runtime"
+	"syscall"
+)
+
+func (f *File) describe(d *FileDescription) error {
+	defer runtime.KeepAlive(f)
+	flags, err := f.pfd.GetHandleInformation()
+	if err != nil {
+		return err
+	}
+	d.Pollable = f.pfd.Pollable()
+	d.CloseOnExec = flags&syscall.HANDLE_FLAG_INHERIT == 0
+	return nil
+}
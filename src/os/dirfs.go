@@ -0,0 +1,153 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"errors"
+	"io/fs"
+	"runtime"
+)
+
+// DirFSSymlinks says how a file system returned by DirFSWithOptions
+// treats symbolic links found in its tree.
+type DirFSSymlinks int
+
+const (
+	// DirFSFollow follows all symbolic links, wherever they point,
+	// as the operating system does. This is what DirFS does.
+	DirFSFollow DirFSSymlinks = iota
+
+	// DirFSFollowInside follows symbolic links that resolve to a file
+	// inside the tree and rejects links that lead out of it, including
+	// all links to absolute paths.
+	DirFSFollowInside
+
+	// DirFSNoFollow rejects every path that would follow a symbolic
+	// link. Links still appear in directory listings, and operations
+	// that act on a link itself, such as Remove and Rename, still work.
+	DirFSNoFollow
+)
+
+// DirFSOptions holds options for DirFSWithOptions.
+type DirFSOptions struct {
+	// Symlinks is the policy for symbolic links in the tree.
+	Symlinks DirFSSymlinks
+}
+
+// DirFSWithOptions is like DirFS but lets opts control how symbolic
+// links in the tree are followed. Paths that the policy rejects fail
+// with a *PathError wrapping ErrPermission.
+//
+// Unless opts.Symlinks is DirFSFollow, each path is resolved one
+// element at a time with Lstat and Readlink before the operation is
+// made on the resolved path. This keeps a server from being led out of
+// the tree by links it did not create, but it does not protect against
+// a tree that is being changed concurrently by an adversary: a link
+// created between the check and the operation is still followed.
+func DirFSWithOptions(dir string, opts DirFSOptions) fs.FS {
+	return dirFS{dir: dir, symlinks: opts.Symlinks}
+}
+
+// errTooManyLinks is reported when resolving a path in a dirFS
+// takes more than maxSymlinks symbolic links.
+var errTooManyLinks = errors.New("too many levels of symbolic links")
+
+// maxSymlinks is the most symbolic links dirFS.resolve follows
+// for a single path, matching Linux.
+const maxSymlinks = 40
+
+// resolve returns the operating system path of the valid path name
+// within dir with all symbolic links replaced by their targets,
+// or a *PathError for op if the links may not be followed under
+// the policy of dir. If followLast is false, a link as the final
+// element of name is left in place.
+//
+// Once an element does not exist, the rest of name is resolved
+// lexically, leaving it to the operation to report the error.
+func (dir dirFS) resolve(op, name string, followLast bool) (string, error) {
+	var elems []string // resolved elements, relative to dir
+	todo := splitDirFSPath(name)
+	links := 0
+	exists := true
+	for len(todo) > 0 {
+		elem := todo[0]
+		todo = todo[1:]
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(elems) == 0 {
+				return "", &PathError{Op: op, Path: name, Err: ErrPermission}
+			}
+			elems = elems[:len(elems)-1]
+			continue
+		}
+		elems = append(elems, elem)
+		if !exists || len(todo) == 0 && !followLast {
+			continue
+		}
+		full := dir.dir + "/" + joinDirFSPath(elems)
+		fi, err := Lstat(full)
+		if err != nil {
+			exists = false
+			continue
+		}
+		if fi.Mode()&ModeSymlink == 0 {
+			continue
+		}
+		if dir.symlinks == DirFSNoFollow {
+			return "", &PathError{Op: op, Path: name, Err: ErrPermission}
+		}
+		if links++; links > maxSymlinks {
+			return "", &PathError{Op: op, Path: name, Err: errTooManyLinks}
+		}
+		target, err := Readlink(full)
+		if err != nil {
+			return "", &PathError{Op: op, Path: name, Err: underlyingError(err)}
+		}
+		if isAbsDirFSLink(target) {
+			return "", &PathError{Op: op, Path: name, Err: ErrPermission}
+		}
+		elems = elems[:len(elems)-1]
+		todo = append(splitDirFSPath(target), todo...)
+	}
+	if len(elems) == 0 {
+		return dir.dir + "/.", nil
+	}
+	return dir.dir + "/" + joinDirFSPath(elems), nil
+}
+
+// splitDirFSPath splits the slash-separated path, or the target
+// of a symbolic link, into its elements.
+func splitDirFSPath(path string) []string {
+	var elems []string
+	start := 0
+	for i := 0; i <= len(path); i++ {
+		if i == len(path) || path[i] == '/' || runtime.GOOS == "windows" && path[i] == '\\' {
+			elems = append(elems, path[start:i])
+			start = i + 1
+		}
+	}
+	return elems
+}
+
+// joinDirFSPath joins elems with slashes.
+func joinDirFSPath(elems []string) string {
+	s := elems[0]
+	for _, e := range elems[1:] {
+		s += "/" + e
+	}
+	return s
+}
+
+// isAbsDirFSLink reports whether the symbolic link target
+// does not resolve relative to the directory holding the link.
+func isAbsDirFSLink(target string) bool {
+	if len(target) > 0 && IsPathSeparator(target[0]) {
+		return true
+	}
+	// A volume name, as in C:foo, is never relative to the link.
+	return runtime.GOOS == "windows" && len(target) >= 2 && target[1] == ':'
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"errors"
+	"internal/testenv"
+	"io/fs"
+	. "os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirFSSymlinks(t *testing.T) {
+	testenv.MustHaveSymlink(t)
+
+	tmp := t.TempDir()
+	root := filepath.Join(tmp, "root")
+	for _, dir := range []string{root, filepath.Join(root, "dir")} {
+		if err := Mkdir(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{filepath.Join(tmp, "outside"), filepath.Join(root, "dir", "file")} {
+		if err := WriteFile(name, []byte("data"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"inside":    filepath.Join("dir", "file"),
+		"dirlink":   "dir",
+		"dir/up":    filepath.Join("..", "inside"),
+		"escape":    filepath.Join("..", "outside"),
+		"absolute":  filepath.Join(tmp, "outside"),
+		"dir/loop1": "loop2",
+		"dir/loop2": "loop1",
+	} {
+		if err := Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const (
+		ok = iota
+		denied
+		failed
+	)
+	tests := []struct {
+		name                   string
+		follow, inside, nofoll int
+	}{
+		{"dir/file", ok, ok, ok},
+		{"inside", ok, ok, denied},
+		{"dirlink/file", ok, ok, denied},
+		{"dir/up", ok, ok, denied},
+		{"escape", ok, denied, denied},
+		{"absolute", ok, denied, denied},
+		{"dir/loop1", failed, failed, denied},
+	}
+	for _, tt := range tests {
+		for _, mode := range []struct {
+			symlinks DirFSSymlinks
+			want     int
+		}{
+			{DirFSFollow, tt.follow},
+			{DirFSFollowInside, tt.inside},
+			{DirFSNoFollow, tt.nofoll},
+		} {
+			fsys := DirFSWithOptions(root, DirFSOptions{Symlinks: mode.symlinks})
+			data, err := fs.ReadFile(fsys, tt.name)
+			switch {
+			case mode.want == ok && (err != nil || string(data) != "data"):
+				t.Errorf("symlinks=%d: ReadFile(%q) = %q, %v, want %q, nil", mode.symlinks, tt.name, data, err, "data")
+			case mode.want == denied && !errors.Is(err, ErrPermission):
+				t.Errorf("symlinks=%d: ReadFile(%q) error = %v, want ErrPermission", mode.symlinks, tt.name, err)
+			case mode.want == failed && (err == nil || errors.Is(err, ErrPermission)):
+				t.Errorf("symlinks=%d: ReadFile(%q) error = %v, want non-permission error", mode.symlinks, tt.name, err)
+			}
+		}
+	}
+
+	// Links still show up, and can be removed, without being followed.
+	fsys := DirFSWithOptions(root, DirFSOptions{Symlinks: DirFSNoFollow})
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range entries {
+		if e.Name() == "escape" {
+			found = e.Type() == fs.ModeSymlink
+		}
+	}
+	if !found {
+		t.Errorf("ReadDir did not list escape as a symbolic link")
+	}
+	if err := fs.Remove(fsys, "escape"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lstat(filepath.Join(tmp, "outside")); err != nil {
+		t.Errorf("Remove of link removed its target: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"internal/iodrain"
+	"internal/poll"
+)
+
+func init() {
+	iodrain.Register(drain)
+}
+
+// drain discards the data of a *File for io.Discard by splicing it
+// into /dev/null.
+func drain(r interface{}, remain int64) (n int64, handled bool, err error) {
+	f, ok := r.(*File)
+	if !ok || f.checkValid("read") != nil {
+		// Leave error handling to the generic read loop.
+		return 0, false, nil
+	}
+	n, handled, _, err = poll.SpliceDiscard(&f.pfd, remain)
+	return n, handled, f.wrapErr("read", err)
+}
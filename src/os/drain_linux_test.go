@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	. "os"
+)
+
+func TestDiscardDrainsPipe(t *testing.T) {
+	r, w, err := Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	const size = 1 << 20
+	go func() {
+		w.Write(bytes.Repeat([]byte("x"), size))
+		w.Close()
+	}()
+
+	n, handled, err := Drain(r, 1000)
+	if err != nil || !handled || n != 1000 {
+		t.Fatalf("Drain = %d, %v, %v; want 1000, true, nil", n, handled, err)
+	}
+	lr := &io.LimitedReader{R: r, N: 4096}
+	n, err = io.Copy(io.Discard, lr)
+	if err != nil || n != 4096 || lr.N != 0 {
+		t.Fatalf("Copy of LimitedReader = %d, %v with N = %d; want 4096, nil with N = 0", n, err, lr.N)
+	}
+	n, err = io.Copy(io.Discard, r)
+	if err != nil || n != size-1000-4096 {
+		t.Fatalf("Copy = %d, %v; want %d, nil", n, err, size-1000-4096)
+	}
+}
+
+func TestDiscardDrainsFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "drain")
+	if err := WriteFile(name, make([]byte, 10000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, f)
+	if err != nil || n != 9900 {
+		t.Fatalf("Copy = %d, %v; want 9900, nil", n, err)
+	}
+	if off, _ := f.Seek(0, io.SeekCurrent); off != 10000 {
+		t.Errorf("offset after Copy = %d; want 10000", off)
+	}
+
+	f.Close()
+	if _, err := io.Copy(io.Discard, f); !errors.Is(err, ErrClosed) {
+		t.Errorf("Copy of closed file: got error %v; want ErrClosed", err)
+	}
+}
@@ -6,6 +6,7 @@ package os
 
 import (
 	"errors"
+	"internal/poll"
 	"internal/testlog"
 	"runtime"
 	"sync"
@@ -23,6 +24,13 @@ type Process struct {
 	handle uintptr      // handle is accessed atomically on Windows
 	isdone uint32       // process has been successfully waited on, non zero if true
 	sigMu  sync.RWMutex // avoid race between wait and signal
+
+	// pidfd refers to the process on Linux, if it was started by
+	// StartProcess and the kernel supports pidfds. Signals are sent
+	// through it, so that they cannot reach another process that
+	// reuses the pid, and Wait uses it to wait for the process to
+	// exit with the runtime poller. It is nil otherwise.
+	pidfd *poll.FD
 }
 
 func newProcess(pid int, handle uintptr) *Process {
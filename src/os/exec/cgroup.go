@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// A Cgroup is a Linux control group, a directory of the cgroup v2
+// file system, usually mounted at /sys/fs/cgroup, in which commands
+// can be started by setting Cmd.Cgroup.
+//
+// The command is created directly in the cgroup, with clone3(2) and
+// CLONE_INTO_CGROUP, so that it never runs in the cgroup of the
+// calling process, which requires Linux 5.7 or later. Processes
+// that the command starts are in the cgroup too, unless they are
+// moved to another one. The cgroup can thus be used to account for
+// the resources used by the command, to limit them, and to kill the
+// command and its descendants.
+//
+// Cgroups are only supported on Linux.
+type Cgroup struct {
+	dir       string
+	f         *os.File
+	transient bool
+}
+
+// Path returns the directory of the cgroup.
+func (g *Cgroup) Path() string {
+	return g.dir
+}
+
+// Kill kills all the processes in the cgroup, by writing to its
+// cgroup.kill file, which requires Linux 5.14 or later. It does not
+// wait for them to exit.
+func (g *Cgroup) Kill() error {
+	return os.WriteFile(filepath.Join(g.dir, "cgroup.kill"), []byte("1"), 0)
+}
+
+// Close closes the cgroup, which cannot be used to start commands
+// afterwards. If the cgroup was created by NewCgroup, Close also
+// removes it, which fails if processes are still in it.
+func (g *Cgroup) Close() error {
+	if g.f == nil {
+		return errors.New("exec: Cgroup already closed")
+	}
+	err := g.f.Close()
+	g.f = nil
+	if g.transient {
+		if err1 := os.Remove(g.dir); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Defined in linux/magic.h.
+const cgroup2SuperMagic = 0x63677270
+
+// OpenCgroup opens the existing cgroup directory dir.
+func OpenCgroup(dir string) (*Cgroup, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Fstatfs(int(f.Fd()), &st); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "fstatfs", Path: dir, Err: err}
+	}
+	if st.Type != cgroup2SuperMagic {
+		f.Close()
+		return nil, &os.PathError{Op: "open", Path: dir, Err: errors.New("not a cgroup v2 directory")}
+	}
+	return &Cgroup{dir: dir, f: f}, nil
+}
+
+// NewCgroup creates a transient cgroup: a new directory with a unique
+// name in the cgroup directory parent, which Close removes.
+func NewCgroup(parent string) (*Cgroup, error) {
+	dir, err := os.MkdirTemp(parent, "exec")
+	if err != nil {
+		return nil, err
+	}
+	g, err := OpenCgroup(dir)
+	if err != nil {
+		os.Remove(dir)
+		return nil, err
+	}
+	g.transient = true
+	return g, nil
+}
+
+// cgroupAttr returns a copy of attr that creates the child in g.
+func cgroupAttr(attr *syscall.SysProcAttr, g *Cgroup) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		if attr.UseCgroupFD {
+			return nil, errors.New("exec: Cgroup conflicts with SysProcAttr.UseCgroupFD")
+		}
+		a = *attr
+	}
+	if g.f == nil {
+		return nil, errors.New("exec: Cgroup is closed")
+	}
+	a.UseCgroupFD = true
+	a.CgroupFD = int(g.f.Fd())
+	return &a, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// cgroup2 returns the mount point of the cgroup v2 file system and
+// the cgroup of the calling process, relative to it.
+func cgroup2(t *testing.T) (mount, cgroup string) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// See proc(5): the file system type follows the separator "-".
+		fields := strings.Fields(s.Text())
+		for i, f := range fields {
+			if f == "-" && i+1 < len(fields) && fields[i+1] == "cgroup2" {
+				mount = fields[4]
+				break
+			}
+		}
+	}
+	if mount == "" {
+		t.Skip("no cgroup v2 file system")
+	}
+	b, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return mount, line[len("0::"):]
+		}
+	}
+	t.Skip("not in a cgroup v2 hierarchy")
+	return "", ""
+}
+
+func TestCgroup(t *testing.T) {
+	mount, cgroup := cgroup2(t)
+	g, err := exec.NewCgroup(filepath.Join(mount, cgroup))
+	if err != nil {
+		t.Skipf("NewCgroup: %v", err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+		if _, err := os.Stat(g.Path()); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Close did not remove %s: %v", g.Path(), err)
+		}
+	}()
+
+	c := helperCommand(t, "cat", "/proc/self/cgroup")
+	c.Cgroup = g
+	out, err := c.Output()
+	if errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.EINVAL) {
+		t.Skipf("clone3 with CLONE_INTO_CGROUP not supported: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0::" + filepath.Join(cgroup, filepath.Base(g.Path()))
+	if !strings.Contains(string(out), want+"\n") {
+		t.Errorf("child cgroups:\n%s\nwant %s", out, want)
+	}
+}
+
+func TestOpenCgroupNotCgroup(t *testing.T) {
+	if _, err := exec.OpenCgroup(t.TempDir()); err == nil {
+		t.Error("OpenCgroup of a temporary directory succeeded")
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+var errCgroupUnsupported = errors.New("exec: cgroups are not supported on this system")
+
+// OpenCgroup opens the existing cgroup directory dir.
+func OpenCgroup(dir string) (*Cgroup, error) {
+	return nil, errCgroupUnsupported
+}
+
+// NewCgroup creates a transient cgroup: a new directory with a unique
+// name in the cgroup directory parent, which Close removes.
+func NewCgroup(parent string) (*Cgroup, error) {
+	return nil, errCgroupUnsupported
+}
+
+func cgroupAttr(attr *syscall.SysProcAttr, g *Cgroup) (*syscall.SysProcAttr, error) {
+	return nil, errCgroupUnsupported
+}
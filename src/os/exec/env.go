@@ -0,0 +1,132 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+)
+
+// An Environ is an environment, a list of variables of the form
+// "key=value", as in Cmd.Env.
+//
+// As for Cmd.Env, when a key appears in several entries, the last one
+// wins: Lookup and Get return its value. Keys are case-insensitive on
+// Windows, as the environment variables of Windows processes are, and
+// case-sensitive elsewhere.
+type Environ []string
+
+// Lookup returns the value of the variable named by key in e, and
+// whether it is present.
+func (e Environ) Lookup(key string) (value string, ok bool) {
+	return envLookup(runtime.GOOS == "windows", e, key)
+}
+
+// Get returns the value of the variable named by key in e, or the
+// empty string if it is not present.
+func (e Environ) Get(key string) string {
+	v, _ := e.Lookup(key)
+	return v
+}
+
+// Set sets the variable named by key to value, replacing all the
+// entries for key in e. It stores a new slice in *e, rather than
+// modify the entries of *e, which may be shared with other Cmds.
+func (e *Environ) Set(key, value string) {
+	*e = append(envUnset(runtime.GOOS == "windows", *e, key), key+"="+value)
+}
+
+// Unset removes all the entries for key from e. Like Set, it stores a
+// new slice in *e.
+func (e *Environ) Unset(key string) {
+	*e = envUnset(runtime.GOOS == "windows", *e, key)
+}
+
+// envKeyEqual reports whether the entry kv, of the form "key=value",
+// is for key.
+func envKeyEqual(caseInsensitive bool, kv, key string) bool {
+	if len(kv) <= len(key) || kv[len(key)] != '=' {
+		return false
+	}
+	if caseInsensitive {
+		return strings.EqualFold(kv[:len(key)], key)
+	}
+	return kv[:len(key)] == key
+}
+
+// envLookup is Environ.Lookup with a case option for testing.
+func envLookup(caseInsensitive bool, env []string, key string) (value string, ok bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if envKeyEqual(caseInsensitive, env[i], key) {
+			return env[i][len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// envUnset returns a copy of env without the entries for key, with
+// room for one more entry.
+func envUnset(caseInsensitive bool, env []string, key string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !envKeyEqual(caseInsensitive, kv, key) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// validEnvKey reports whether key can name an environment variable.
+func validEnvKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "=\x00")
+}
+
+var errInvalidEnv = errors.New("exec: invalid environment variable")
+
+// Setenv sets the environment variable named by key to value in c.Env.
+// If c.Env is nil, Setenv first sets it to the environment that the
+// command would otherwise get, which is usually that of the current
+// process. It returns an error if key is not a valid name, or if that
+// environment cannot be determined.
+func (c *Cmd) Setenv(key, value string) error {
+	if !validEnvKey(key) || strings.IndexByte(value, 0) >= 0 {
+		return errInvalidEnv
+	}
+	env, err := c.envv()
+	if err != nil {
+		return err
+	}
+	e := Environ(env)
+	e.Set(key, value)
+	c.Env = e
+	return nil
+}
+
+// Unsetenv removes the environment variable named by key from c.Env.
+// If c.Env is nil, Unsetenv first sets it as Setenv does.
+func (c *Cmd) Unsetenv(key string) error {
+	if !validEnvKey(key) {
+		return errInvalidEnv
+	}
+	env, err := c.envv()
+	if err != nil {
+		return err
+	}
+	e := Environ(env)
+	e.Unset(key)
+	c.Env = e
+	return nil
+}
+
+// LookupEnv returns the value of the environment variable named by key
+// that the command gets, and whether it is present.
+func (c *Cmd) LookupEnv(key string) (value string, ok bool) {
+	env, err := c.envv()
+	if err != nil {
+		return "", false
+	}
+	return Environ(env).Lookup(key)
+}
@@ -37,3 +37,41 @@ func TestDedupEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestEnviron(t *testing.T) {
+	env := []string{"k1=v1", "K1=v2", "k2=v3", "k1=v4", "k12=v5"}
+	tests := []struct {
+		noCase bool
+		key    string
+		value  string
+		ok     bool
+		unset  []string
+	}{
+		{false, "k1", "v4", true, []string{"K1=v2", "k2=v3", "k12=v5"}},
+		{true, "k1", "v4", true, []string{"k2=v3", "k12=v5"}},
+		{false, "K1", "v2", true, []string{"k1=v1", "k2=v3", "k1=v4", "k12=v5"}},
+		{true, "K2", "v3", true, []string{"k1=v1", "K1=v2", "k1=v4", "k12=v5"}},
+		{false, "K2", "", false, env},
+		{false, "k", "", false, env},
+	}
+	for _, tt := range tests {
+		value, ok := envLookup(tt.noCase, env, tt.key)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("envLookup(%v, %q) = %q, %v; want %q, %v", tt.noCase, tt.key, value, ok, tt.value, tt.ok)
+		}
+		if got := envUnset(tt.noCase, env, tt.key); !reflect.DeepEqual(got, tt.unset) {
+			t.Errorf("envUnset(%v, %q) = %q; want %q", tt.noCase, tt.key, got, tt.unset)
+		}
+	}
+
+	// Set must not modify the entries of a shared slice.
+	shared := []string{"a=1", "b=2"}
+	e := Environ(shared)
+	e.Set("a", "3")
+	if !reflect.DeepEqual(shared, []string{"a=1", "b=2"}) {
+		t.Errorf("Set modified the original slice: %q", shared)
+	}
+	if want := (Environ{"b=2", "a=3"}); !reflect.DeepEqual(e, want) {
+		t.Errorf("after Set, environment is %q; want %q", e, want)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 // Error is returned by LookPath when it fails to classify a file as an
@@ -76,6 +77,8 @@ type Cmd struct {
 	// value in the slice for each duplicate key is used.
 	// As a special case on Windows, SYSTEMROOT is always added if
 	// missing and not explicitly set to the empty string.
+	// The Setenv, Unsetenv and LookupEnv methods manipulate Env with
+	// these rules, as does the Environ type.
 	Env []string
 
 	// Dir specifies the working directory of the command.
@@ -83,6 +86,15 @@ type Cmd struct {
 	// calling process's current directory.
 	Dir string
 
+	// DirFile optionally specifies an open directory to be the working
+	// directory of the command, instead of Dir, which must then be
+	// empty. The working directory is set from the open file rather
+	// than from a path, so that the directory cannot be swapped, for
+	// example by a concurrent rename or through a symbolic link, between
+	// its opening and the start of the command. DirFile is not
+	// supported on Windows, Plan 9, AIX and Solaris.
+	DirFile *os.File
+
 	// Stdin specifies the process's standard input.
 	//
 	// If Stdin is nil, the process reads from the null device (os.DevNull).
@@ -119,13 +131,77 @@ type Cmd struct {
 	// new process. It does not include standard input, standard output, or
 	// standard error. If non-nil, entry i becomes file descriptor 3+i.
 	//
-	// ExtraFiles is not supported on Windows.
+	// On Windows, the files are inherited as handles instead; see
+	// InheritedFiles.
 	ExtraFiles []*os.File
 
+	// InheritedFiles specifies further open files to be inherited by
+	// the new process, keyed by the file descriptor each becomes in it.
+	// The keys must be at least 3+len(ExtraFiles). Descriptors above
+	// those of ExtraFiles that are not keys are closed in the new
+	// process.
+	//
+	// Windows has no file descriptor numbers: there Start makes an
+	// inheritable duplicate of the handle of each file of ExtraFiles
+	// and InheritedFiles, which only the new process inherits, and the
+	// keys are ignored. Only these handles and those of standard input,
+	// output and error are inherited. Their values, which
+	// InheritedHandles reports, must be passed to the program by other
+	// means, such as a pipe to its standard input.
+	InheritedFiles map[int]*os.File
+
 	// SysProcAttr holds optional, operating system-specific attributes.
 	// Run passes it to os.StartProcess as the os.ProcAttr's Sys field.
 	SysProcAttr *syscall.SysProcAttr
 
+	// KillProcessGroup specifies that, when the context passed to
+	// CommandContext is done, the command and all the processes that
+	// it started are killed, rather than only the command. Otherwise,
+	// processes started by the command may keep running, and keep
+	// its output pipes open, after the command is killed.
+	//
+	// On Unix systems, Start runs the command in a new process group,
+	// as if SysProcAttr.Setpgid were set, and the process group is
+	// killed. Processes that leave the group, for example by starting
+	// a new session, are not killed. Start returns an error if
+	// SysProcAttr puts the command in an existing process group.
+	// On Windows, the job object of the command, returned by
+	// JobHandle, is terminated. KillProcessGroup is not supported on
+	// other systems.
+	KillProcessGroup bool
+
+	// KillProcessGroupOnExit specifies that the processes started by
+	// the command that are still running when it exits are killed by
+	// Wait, before it waits for their output. It implies
+	// KillProcessGroup. On Linux, DragonFly BSD, FreeBSD and NetBSD,
+	// Wait kills them before it reaps the command, so that the id of
+	// the process group cannot have been reused. On Windows, the job object of the command is
+	// also marked to kill its processes when it is closed, so that they
+	// are killed as well if the calling process exits first.
+	KillProcessGroupOnExit bool
+
+	// Limits optionally specifies limits on the resources that the
+	// command can use.
+	Limits *ResourceLimits
+
+	// Cgroup optionally specifies the Linux control group in which
+	// the command starts. See Cgroup for details.
+	Cgroup *Cgroup
+
+	// Namespaces optionally specifies the Linux namespaces in which
+	// the command runs. See Namespaces for details.
+	Namespaces *Namespaces
+
+	// Sandbox optionally restricts the file system access and system
+	// calls of the command. See Sandbox for details.
+	Sandbox *Sandbox
+
+	// CancelGracePeriod, if positive, specifies that when the
+	// context passed to CommandContext is done, the command is
+	// terminated with Terminate(CancelGracePeriod) rather than killed
+	// right away.
+	CancelGracePeriod time.Duration
+
 	// Process is the underlying process, once started.
 	Process *os.Process
 
@@ -140,8 +216,20 @@ type Cmd struct {
 	closeAfterStart []io.Closer
 	closeAfterWait  []io.Closer
 	goroutine       []func() error
-	errch           chan error // one send per goroutine
-	waitDone        chan struct{}
+	errch           chan error    // one send per goroutine
+	waitDone        chan struct{} // closed when the process is known to have exited; see Wait
+
+	// handle is the job object of the started process on Windows.
+	// It is created by Start and closed by Wait, unless handleErr
+	// is set. handleMu guards its use by kill against its closing
+	// by Wait.
+	handleMu  sync.Mutex
+	handle    uintptr
+	handleErr error
+
+	// inheritedHandles are the values of the handles that the
+	// started process inherits on Windows; see InheritedHandles.
+	inheritedHandles []uintptr
 }
 
 // Command returns the Cmd struct to execute the named program with
@@ -310,6 +398,9 @@ func (c *Cmd) writerDescriptor(w io.Writer) (f *os.File, err error) {
 	c.goroutine = append(c.goroutine, func() error {
 		_, err := io.Copy(w, pr)
 		pr.Close() // in case io.Copy stopped due to write error
+		if lw, ok := w.(*lineWriter); ok {
+			lw.flush()
+		}
 		return err
 	})
 	return pw, nil
@@ -367,6 +458,34 @@ func lookExtensions(path, dir string) (string, error) {
 	return path + ext, nil
 }
 
+// maxInheritedFd bounds the keys of InheritedFiles, so that a mistaken
+// key does not make Start allocate a huge descriptor table.
+const maxInheritedFd = 1 << 20
+
+// inheritedFiles returns the files to be inherited by the process besides
+// standard input, output and error, with the file that becomes
+// descriptor 3+i at index i.
+func (c *Cmd) inheritedFiles() ([]*os.File, error) {
+	if len(c.InheritedFiles) == 0 {
+		return c.ExtraFiles, nil
+	}
+	n := len(c.ExtraFiles)
+	for fd := range c.InheritedFiles {
+		if fd < 3+len(c.ExtraFiles) || fd >= maxInheritedFd {
+			return nil, errors.New("exec: InheritedFiles descriptor " + strconv.Itoa(fd) + " out of range")
+		}
+		if fd-2 > n {
+			n = fd - 2
+		}
+	}
+	files := make([]*os.File, n)
+	copy(files, c.ExtraFiles)
+	for fd, f := range c.InheritedFiles {
+		files[fd-3] = f
+	}
+	return files, nil
+}
+
 // Start starts the specified command but does not wait for it to complete.
 //
 // If Start returns successfully, the c.Process field will be set.
@@ -412,27 +531,106 @@ func (c *Cmd) Start() error {
 		}
 		c.childFiles = append(c.childFiles, fd)
 	}
-	c.childFiles = append(c.childFiles, c.ExtraFiles...)
+	files, err := c.inheritedFiles()
+	if err != nil {
+		c.closeDescriptors(c.closeAfterStart)
+		c.closeDescriptors(c.closeAfterWait)
+		return err
+	}
 
 	envv, err := c.envv()
 	if err != nil {
 		return err
 	}
 
+	sysattr := c.SysProcAttr
+	if c.killsGroup() {
+		sysattr, err = processGroupAttr(sysattr)
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+	if c.Limits != nil {
+		sysattr, err = limitsAttr(sysattr, c.Limits)
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+	if c.Cgroup != nil {
+		sysattr, err = cgroupAttr(sysattr, c.Cgroup)
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+	var setupErr error
+	if c.Namespaces != nil {
+		sysattr, err = namespacesAttr(sysattr, c.Namespaces, &setupErr)
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+
+	if c.DirFile != nil {
+		if c.Dir != "" {
+			err = errors.New("exec: Dir and DirFile both set")
+		} else {
+			sysattr, err = dirFileAttr(sysattr, c.DirFile)
+		}
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+	if c.Sandbox != nil {
+		sysattr, err = sandboxAttr(sysattr, c.Sandbox)
+		if err != nil {
+			c.closeDescriptors(c.closeAfterStart)
+			c.closeDescriptors(c.closeAfterWait)
+			return err
+		}
+	}
+	sysattr, restoreFiles, err := c.inheritFiles(sysattr, files)
+	if err != nil {
+		c.closeDescriptors(c.closeAfterStart)
+		c.closeDescriptors(c.closeAfterWait)
+		return err
+	}
+
 	c.Process, err = os.StartProcess(c.Path, c.argv(), &os.ProcAttr{
 		Dir:   c.Dir,
 		Files: c.childFiles,
 		Env:   addCriticalEnv(dedupEnv(envv)),
-		Sys:   c.SysProcAttr,
+		Sys:   sysattr,
 	})
+	restoreFiles()
 	if err != nil {
 		c.closeDescriptors(c.closeAfterStart)
 		c.closeDescriptors(c.closeAfterWait)
+		if setupErr != nil {
+			return setupErr
+		}
 		return err
 	}
 
 	c.closeDescriptors(c.closeAfterStart)
 
+	if err := c.startJob(); err != nil {
+		c.Process.Kill()
+		c.Process.Wait()
+		c.closeDescriptors(c.closeAfterWait)
+		return err
+	}
+	c.waitDone = make(chan struct{})
+
 	// Don't allocate the channel unless there are goroutines to fire.
 	if len(c.goroutine) > 0 {
 		c.errch = make(chan error, len(c.goroutine))
@@ -444,11 +642,14 @@ func (c *Cmd) Start() error {
 	}
 
 	if c.ctx != nil {
-		c.waitDone = make(chan struct{})
 		go func() {
 			select {
 			case <-c.ctx.Done():
-				c.Process.Kill()
+				if c.CancelGracePeriod > 0 {
+					c.Terminate(c.CancelGracePeriod)
+				} else {
+					c.kill()
+				}
 			case <-c.waitDone:
 			}
 		}()
@@ -457,6 +658,183 @@ func (c *Cmd) Start() error {
 	return nil
 }
 
+// Terminate asks the started command to exit, and kills it if it has
+// not exited after gracePeriod. On Unix systems, it sends the command
+// SIGTERM. On Windows, it sends the command a CTRL_BREAK_EVENT, which
+// requires that the command was started in a new process group, with
+// the CREATE_NEW_PROCESS_GROUP flag in SysProcAttr.CreationFlags, and
+// that it shares the console of the calling process. If
+// KillProcessGroup is set, the processes that the command started are
+// also asked to exit, and killed.
+//
+// Terminate only sees the command exit when it is waited for, so Wait
+// or Run must be called concurrently, as the command is otherwise
+// killed after gracePeriod even if it exited. Terminate returns once
+// the command is waited for or killed. If the command cannot be asked
+// to exit, Terminate kills it right away and returns the error.
+func (c *Cmd) Terminate(gracePeriod time.Duration) error {
+	if c.Process == nil {
+		return errors.New("exec: not started")
+	}
+	// As in kill, the process group is only signaled while the
+	// process, whose pid is its id, is not reaped.
+	c.handleMu.Lock()
+	select {
+	case <-c.waitDone:
+		c.handleMu.Unlock()
+		return nil
+	default:
+	}
+	err := c.terminate()
+	c.handleMu.Unlock()
+	if err != nil && err != os.ErrProcessDone {
+		c.kill()
+		return err
+	}
+	t := time.NewTimer(gracePeriod)
+	defer t.Stop()
+	select {
+	case <-c.waitDone:
+	case <-t.C:
+		c.kill()
+	}
+	return nil
+}
+
+// markExited closes c.waitDone, so that kill and Terminate no longer
+// signal the process group of c, and kills the processes left in the
+// group if KillProcessGroupOnExit is set.
+func (c *Cmd) markExited() {
+	c.handleMu.Lock()
+	close(c.waitDone)
+	if c.KillProcessGroupOnExit {
+		c.killProcessGroup()
+	}
+	c.handleMu.Unlock()
+}
+
+// killsGroup reports whether c runs in its own process group or job
+// object, whose processes are killed with it.
+func (c *Cmd) killsGroup() bool {
+	return c.KillProcessGroup || c.KillProcessGroupOnExit
+}
+
+// Kill kills the started command, and the processes that it started
+// if KillProcessGroup is set.
+func (c *Cmd) Kill() error {
+	if c.Process == nil {
+		return errors.New("exec: not started")
+	}
+	return c.kill()
+}
+
+// kill kills the command, and the processes that it started if
+// KillProcessGroup is set.
+func (c *Cmd) kill() error {
+	if c.killsGroup() {
+		c.handleMu.Lock()
+		select {
+		case <-c.waitDone:
+			// The process group may be gone.
+		default:
+			c.killProcessGroup()
+		}
+		c.handleMu.Unlock()
+	}
+	return c.Process.Kill()
+}
+
+// ProcessGroupPids returns the pids of the running processes in the
+// process group, on Unix systems, or the job object, on Windows, of the
+// started command, including the command itself. Supervisors can use
+// it to inspect the process tree of the command. On Unix systems, it
+// requires KillProcessGroup, and is only supported on Linux.
+//
+// The processes may exit, and new ones may be started, at any time, so
+// the result is only a snapshot.
+func (c *Cmd) ProcessGroupPids() ([]int, error) {
+	if c.Process == nil {
+		return nil, errors.New("exec: not started")
+	}
+	if runtime.GOOS != "windows" && !c.killsGroup() {
+		return nil, errors.New("exec: ProcessGroupPids requires KillProcessGroup")
+	}
+	c.handleMu.Lock()
+	defer c.handleMu.Unlock()
+	select {
+	case <-c.waitDone:
+		return nil, errors.New("exec: Wait was already called")
+	default:
+	}
+	return c.processGroupPids()
+}
+
+// PidFD returns a pidfd referring to the started process, which is
+// supported on Linux 5.3 and later. Unlike the pid of the process,
+// the pidfd cannot come to refer to another process once the process
+// exits, so supervisors can use it to signal the process, with the
+// pidfd_send_signal system call, or to wait for it to exit, by polling
+// the pidfd, without racing with the reuse of its pid.
+//
+// The pidfd is the one that c.Process uses to signal and wait for the
+// process. It belongs to c.Process: it is closed by Wait, or by
+// Release, and must not be closed or used after Wait returns. PidFD
+// must not be called concurrently with Wait.
+func (c *Cmd) PidFD() (int, error) {
+	if c.Process == nil {
+		return -1, errors.New("exec: not started")
+	}
+	if runtime.GOOS != "linux" {
+		return -1, errors.New("exec: PidFD is only supported on Linux")
+	}
+	fd := c.pidfd()
+	if fd < 0 {
+		return -1, errors.New("exec: the process has no pidfd")
+	}
+	return fd, nil
+}
+
+// JobHandle returns the handle of the Windows job object containing the
+// started process. Start only creates a job object for the command if
+// KillProcessGroup, KillProcessGroupOnExit or Limits is set. It then
+// creates the process suspended and assigns it to the job before
+// resuming it, so that all the processes that it starts are in the job
+// as well, except those created with CREATE_BREAKAWAY_FROM_JOB, which
+// the job allows. Supervisors can use the job object to limit the
+// resources of the processes, or to terminate them all.
+//
+// The handle belongs to c: it is closed by Wait, and must not be
+// closed or used after Wait returns. JobHandle must not be called
+// concurrently with Wait.
+func (c *Cmd) JobHandle() (uintptr, error) {
+	if c.Process == nil {
+		return 0, errors.New("exec: not started")
+	}
+	if runtime.GOOS != "windows" {
+		return 0, errors.New("exec: JobHandle is only supported on Windows")
+	}
+	if c.handleErr != nil {
+		return 0, c.handleErr
+	}
+	return c.handle, nil
+}
+
+// InheritedHandles returns the values of the handles under which the
+// started process inherits the files of ExtraFiles and InheritedFiles on
+// Windows. Entry i is the handle of the file that would be descriptor
+// 3+i on Unix systems, or zero if there is no such file. The handles
+// are valid in the new process only: Start closes them in the calling
+// process.
+func (c *Cmd) InheritedHandles() ([]uintptr, error) {
+	if c.Process == nil {
+		return nil, errors.New("exec: not started")
+	}
+	if runtime.GOOS != "windows" {
+		return nil, errors.New("exec: InheritedHandles is only supported on Windows")
+	}
+	return append([]uintptr(nil), c.inheritedHandles...), nil
+}
+
 // An ExitError reports an unsuccessful exit by a command.
 type ExitError struct {
 	*os.ProcessState
@@ -504,11 +882,25 @@ func (c *Cmd) Wait() error {
 	}
 	c.finished = true
 
+	// Where the system allows, wait for the process to exit without
+	// reaping it, so that its pid, which is the id of its process
+	// group, cannot be reused while the group is killed. Otherwise,
+	// the group is killed right after the process is reaped.
+	exited := c.waitExited()
+	if exited {
+		c.markExited()
+	}
 	state, err := c.Process.Wait()
-	if c.waitDone != nil {
-		close(c.waitDone)
+	if !exited {
+		c.markExited()
 	}
 	c.ProcessState = state
+	c.handleMu.Lock()
+	if c.handleErr == nil {
+		closeProcessHandle(c.handle)
+		c.handleErr = errors.New("exec: Wait was already called")
+	}
+	c.handleMu.Unlock()
 
 	var copyError error
 	for range c.goroutine {
@@ -574,6 +966,11 @@ func (c *Cmd) CombinedOutput() ([]byte, error) {
 // A caller need only call Close to force the pipe to close sooner.
 // For example, if the command being run will not exit until standard input
 // is closed, the caller must close the pipe.
+//
+// The pipe is an *os.File underneath, which io.Copy writes to directly:
+// on Linux, copying to it from a network connection or file moves the
+// data with splice(2) or copy_file_range(2), without copying it through
+// user space.
 func (c *Cmd) StdinPipe() (io.WriteCloser, error) {
 	if c.Stdin != nil {
 		return nil, errors.New("exec: Stdin already set")
@@ -608,6 +1005,11 @@ func (c *closeOnce) close() {
 	c.err = c.File.Close()
 }
 
+// UnwrapWriter returns the pipe, so that io.Copy uses its fast paths.
+func (c *closeOnce) UnwrapWriter() io.Writer {
+	return c.File
+}
+
 // StdoutPipe returns a pipe that will be connected to the command's
 // standard output when the command starts.
 //
@@ -616,6 +1018,9 @@ func (c *closeOnce) close() {
 // before all reads from the pipe have completed.
 // For the same reason, it is incorrect to call Run when using StdoutPipe.
 // See the example for idiomatic usage.
+//
+// The pipe is an *os.File: on Linux, io.Copy from it to a TCP connection
+// moves the data with splice(2), without copying it through user space.
 func (c *Cmd) StdoutPipe() (io.ReadCloser, error) {
 	if c.Stdout != nil {
 		return nil, errors.New("exec: Stdout already set")
@@ -658,6 +1063,93 @@ func (c *Cmd) StderrPipe() (io.ReadCloser, error) {
 	return pr, nil
 }
 
+// StreamOutput arranges for the standard output and standard error of
+// the command to be passed, line by line, to the functions stdout and
+// stderr respectively, as the command writes them. Either function may
+// be nil, to leave the corresponding output unchanged.
+//
+// Each line is passed without its trailing end-of-line marker, "\n" or
+// "\r\n", and is only valid until the function returns. A final line
+// without end-of-line marker is passed once the command closes the
+// output. Lines longer than 64 KiB are passed in pieces of that size.
+//
+// The functions are called from goroutines started by Start, one for
+// each output, and Wait waits for the last call to return. A function
+// that blocks stops the command once its output pipe is full; it must
+// therefore not wait for the command to exit.
+func (c *Cmd) StreamOutput(stdout, stderr func(line []byte)) error {
+	if c.Process != nil {
+		return errors.New("exec: StreamOutput after process started")
+	}
+	if stdout != nil && c.Stdout != nil {
+		return errors.New("exec: Stdout already set")
+	}
+	if stderr != nil && c.Stderr != nil {
+		return errors.New("exec: Stderr already set")
+	}
+	if stdout != nil {
+		c.Stdout = &lineWriter{fn: stdout}
+	}
+	if stderr != nil {
+		c.Stderr = &lineWriter{fn: stderr}
+	}
+	return nil
+}
+
+// maxLineSize is the size of the longest line passed by a lineWriter.
+const maxLineSize = 64 << 10
+
+// lineWriter is an io.Writer which passes the lines written to it to fn.
+// writerDescriptor calls flush to pass the final incomplete line.
+type lineWriter struct {
+	fn  func(line []byte)
+	buf []byte // incomplete line
+}
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 || len(w.buf)+i > maxLineSize {
+			room := maxLineSize - len(w.buf)
+			if len(p) < room {
+				w.buf = append(w.buf, p...)
+				break
+			}
+			w.buf = append(w.buf, p[:room]...)
+			p = p[room:]
+			w.flush()
+			continue
+		}
+		line := p[:i]
+		p = p[i+1:]
+		if len(w.buf) > 0 {
+			w.buf = append(w.buf, line...)
+			line = w.buf
+		}
+		w.buf = w.buf[:0]
+		w.fn(dropCR(line))
+	}
+	return n, nil
+}
+
+// flush passes the incomplete line, if any.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		line := w.buf
+		w.buf = w.buf[:0]
+		w.fn(line)
+	}
+}
+
+// dropCR drops a terminal \r from line.
+func dropCR(line []byte) []byte {
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		return line[:len(line)-1]
+	}
+	return line
+}
+
 // prefixSuffixSaver is an io.Writer which retains the first N bytes
 // and the last N bytes written to it. The Bytes() methods reconstructs
 // it with a pretty error message.
@@ -0,0 +1,18 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || (js && wasm) || plan9 || solaris || windows
+// +build aix js,wasm plan9 solaris windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func dirFileAttr(attr *syscall.SysProcAttr, f *os.File) (*syscall.SysProcAttr, error) {
+	return nil, errors.New("exec: DirFile is not supported on this system")
+}
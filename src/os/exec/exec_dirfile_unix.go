@@ -0,0 +1,30 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// dirFileAttr returns a copy of attr that makes the child change to
+// the directory f with fchdir. f must be kept open until the child is
+// started.
+func dirFileAttr(attr *syscall.SysProcAttr, f *os.File) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	if a.UseDirFD {
+		return nil, errors.New("exec: DirFile conflicts with SysProcAttr.UseDirFD")
+	}
+	a.UseDirFD = true
+	a.DirFD = int(f.Fd())
+	return &a, nil
+}
@@ -0,0 +1,20 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"os"
+	"syscall"
+)
+
+// inheritFiles passes files to the process as the descriptors
+// following standard error.
+func (c *Cmd) inheritFiles(attr *syscall.SysProcAttr, files []*os.File) (*syscall.SysProcAttr, func(), error) {
+	c.childFiles = append(c.childFiles, files...)
+	return attr, func() {}, nil
+}
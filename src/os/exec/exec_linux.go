@@ -0,0 +1,11 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import "internal/osexec"
+
+func (c *Cmd) pidfd() int {
+	return osexec.ProcessPidFD(c.Process)
+}
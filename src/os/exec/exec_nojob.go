@@ -0,0 +1,13 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package exec
+
+// startJob does nothing: job objects only exist on Windows.
+func (c *Cmd) startJob() error { return nil }
+
+func closeProcessHandle(h uintptr) {}
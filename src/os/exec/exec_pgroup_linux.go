@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// processGroupPids lists the processes in the process group of c, whose
+// id is the pid of its process, from /proc. Zombies are left out.
+func (c *Cmd) processGroupPids() ([]int, error) {
+	d, err := os.Open("/proc")
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, name := range names {
+		pid, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile("/proc/" + name + "/stat")
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		// See proc(5): the command name, in parentheses, is followed
+		// by the state, the parent pid and the process group id.
+		i := bytes.LastIndexByte(b, ')')
+		if i < 0 {
+			continue
+		}
+		f := bytes.Fields(b[i+1:])
+		if len(f) < 3 || string(f[0]) == "Z" {
+			continue
+		}
+		if pgrp, err := strconv.Atoi(string(f[2])); err == nil && pgrp == c.Process.Pid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
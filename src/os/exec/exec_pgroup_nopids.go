@@ -0,0 +1,14 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package exec
+
+import "errors"
+
+func (c *Cmd) processGroupPids() ([]int, error) {
+	return nil, errors.New("exec: ProcessGroupPids is not supported on this system")
+}
@@ -0,0 +1,25 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (js && wasm) || plan9
+// +build js,wasm plan9
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+func processGroupAttr(attr *syscall.SysProcAttr) (*syscall.SysProcAttr, error) {
+	return nil, errors.New("exec: KillProcessGroup is not supported on this system")
+}
+
+func (c *Cmd) killProcessGroup() error { return nil }
+
+func (c *Cmd) waitExited() bool { return false }
+
+func (c *Cmd) terminate() error {
+	return errors.New("exec: Terminate is not supported on this system")
+}
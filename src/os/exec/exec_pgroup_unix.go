@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package exec
+
+import (
+	"errors"
+	"internal/osexec"
+	"syscall"
+)
+
+// waitExited blocks until the process of c has exited, without reaping
+// it, where the system allows, and reports whether it did.
+func (c *Cmd) waitExited() bool {
+	return osexec.ProcessBlockUntilWaitable(c.Process)
+}
+
+// processGroupAttr returns a copy of attr that runs the command in a
+// new process group.
+func processGroupAttr(attr *syscall.SysProcAttr) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	if a.Setsid {
+		// A new session is also a new process group.
+		return &a, nil
+	}
+	if a.Setpgid && a.Pgid != 0 {
+		return nil, errors.New("exec: KillProcessGroup conflicts with SysProcAttr.Pgid")
+	}
+	a.Setpgid = true
+	a.Pgid = 0
+	return &a, nil
+}
+
+// killProcessGroup kills the process group of c, whose id is the pid
+// of its process.
+func (c *Cmd) killProcessGroup() error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
+
+// terminate sends SIGTERM to c, or to its process group.
+func (c *Cmd) terminate() error {
+	if c.KillProcessGroup {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGTERM)
+	}
+	return c.Process.Signal(syscall.SIGTERM)
+}
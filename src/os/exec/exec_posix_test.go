@@ -8,7 +8,13 @@
 package exec_test
 
 import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
 	"os/user"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"syscall"
@@ -87,3 +93,247 @@ func TestWaitid(t *testing.T) {
 
 	<-ch
 }
+
+func TestKillProcessGroupConflict(t *testing.T) {
+	c := helperCommand(t, "echo")
+	c.KillProcessGroup = true
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 1}
+	if err := c.Start(); err == nil {
+		c.Wait()
+		t.Fatal("Start succeeded with SysProcAttr.Pgid set")
+	}
+}
+
+// startTrapTerm starts c, which runs the trapterm helper, and waits
+// for it to be ready. It returns the rest of its output, and a channel
+// receiving the result of Wait.
+func startTrapTerm(t *testing.T, c *exec.Cmd) (*bufio.Reader, <-chan error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pr.Close() })
+	c.Stdout = pw
+	err = c.Start()
+	pw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(pr)
+	if line, err := r.ReadString('\n'); err != nil || line != "ready\n" {
+		c.Process.Kill()
+		t.Fatalf("ReadString = %q, %v; want %q", line, err, "ready\n")
+	}
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- c.Wait() }()
+	return r, waitErr
+}
+
+func TestTerminate(t *testing.T) {
+	c := helperCommand(t, "trapterm")
+	r, waitErr := startTrapTerm(t, c)
+	if err := c.Terminate(time.Minute); err != nil {
+		t.Fatalf("Terminate: %v", err)
+	}
+	if err := <-waitErr; err != nil {
+		t.Errorf("Wait = %v; want exit after SIGTERM", err)
+	}
+	if out, _ := io.ReadAll(r); string(out) != "terminated\n" {
+		t.Errorf("output = %q; want %q", out, "terminated\n")
+	}
+	if err := c.Terminate(time.Minute); err != nil {
+		t.Errorf("Terminate after Wait = %v; want nil", err)
+	}
+}
+
+func TestTerminateKill(t *testing.T) {
+	c := helperCommand(t, "trapterm", "ignore")
+	_, waitErr := startTrapTerm(t, c)
+	if err := c.Terminate(10 * time.Millisecond); err != nil {
+		t.Fatalf("Terminate: %v", err)
+	}
+	err := <-waitErr
+	ee, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("Wait = %v; want an ExitError", err)
+	}
+	if ws := ee.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+		t.Errorf("wait status = %v; want killed", ee)
+	}
+}
+
+func TestContextCancelGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := helperCommandContext(t, ctx, "trapterm")
+	c.CancelGracePeriod = time.Minute
+	r, waitErr := startTrapTerm(t, c)
+	cancel()
+	if err := <-waitErr; err != nil {
+		t.Errorf("Wait = %v; want exit after SIGTERM", err)
+	}
+	if out, _ := io.ReadAll(r); string(out) != "terminated\n" {
+		t.Errorf("output = %q; want %q", out, "terminated\n")
+	}
+}
+
+func TestInheritedFiles(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	extra, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+
+	// Descriptor 4 is between ExtraFiles and InheritedFiles.
+	cmd := exec.Command("sh", "-c", "echo hello >&5; test -e /dev/fd/3 && ! echo >&4")
+	cmd.ExtraFiles = []*os.File{extra}
+	cmd.InheritedFiles = map[int]*os.File{5: w}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("descriptors 3 or 4 not as expected: %v", err)
+	}
+	if string(out) != "hello\n" {
+		t.Errorf("read %q from descriptor 5, want %q", out, "hello\n")
+	}
+
+	cmd = exec.Command("true")
+	cmd.ExtraFiles = []*os.File{extra}
+	cmd.InheritedFiles = map[int]*os.File{3: w}
+	if err := cmd.Run(); err == nil {
+		t.Errorf("Run with overlapping ExtraFiles and InheritedFiles succeeded")
+	}
+}
+
+func TestKillProcessGroupOnExit(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	// The background sleep keeps standard output open after sh exits.
+	c := exec.Command("sh", "-c", "sleep 1000 & echo started")
+	c.KillProcessGroupOnExit = true
+	done := make(chan struct{})
+	var out []byte
+	var err error
+	go func() {
+		out, err = c.Output()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("Output did not return after the command exited")
+	}
+	if err != nil || string(out) != "started\n" {
+		t.Errorf("Output = %q, %v; want %q, nil", out, err, "started\n")
+	}
+}
+
+func TestProcessGroupPids(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ProcessGroupPids is only supported on Linux")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	c := exec.Command("sh", "-c", "sleep 1000 & echo $!; wait")
+	if _, err := c.ProcessGroupPids(); err == nil {
+		t.Error("ProcessGroupPids succeeded before Start")
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.KillProcessGroup = true
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Wait()
+	defer c.Kill()
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleepPid, err := strconv.Atoi(line[:len(line)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pids, err := c.ProcessGroupPids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]bool{c.Process.Pid: true, sleepPid: true}
+	for _, pid := range pids {
+		delete(want, pid)
+	}
+	if len(want) > 0 {
+		t.Errorf("ProcessGroupPids = %v; want %d and %d", pids, c.Process.Pid, sleepPid)
+	}
+}
+
+func TestDirFile(t *testing.T) {
+	switch runtime.GOOS {
+	case "aix", "solaris":
+		t.Skipf("DirFile is not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip(err)
+	}
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "dir")
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "marker"), []byte("original"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// Swap the directory for another one once it is open.
+	if err := os.Rename(dir, filepath.Join(tmp, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "marker"), []byte("swapped"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	c := exec.Command("cat", "marker")
+	c.DirFile = d
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "original" {
+		t.Errorf("read %q in DirFile, want %q", out, "original")
+	}
+
+	c = exec.Command("cat", "marker")
+	c.Dir = dir
+	c.DirFile = d
+	if err := c.Run(); err == nil {
+		t.Error("Run with both Dir and DirFile succeeded")
+	}
+}
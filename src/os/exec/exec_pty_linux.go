@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"os/pty"
+	"syscall"
+)
+
+// ptyAttr connects the standard files of c that are not set to the
+// terminal of p, and makes it the controlling terminal of c.
+func (c *Cmd) ptyAttr(p *pty.PTY) error {
+	tty := p.TTY()
+	if c.Stdin == nil {
+		c.Stdin = tty
+	}
+	if c.Stdout == nil {
+		c.Stdout = tty
+	}
+	if c.Stderr == nil {
+		c.Stderr = tty
+	}
+	var a syscall.SysProcAttr
+	if c.SysProcAttr != nil {
+		a = *c.SysProcAttr
+	}
+	a.Setsid = true
+	a.Setctty = true
+	switch tty {
+	case c.Stdin:
+		a.Ctty = 0
+	case c.Stdout:
+		a.Ctty = 1
+	case c.Stderr:
+		a.Ctty = 2
+	default:
+		return errors.New("exec: StartWithPTY with Stdin, Stdout and Stderr all set")
+	}
+	c.SysProcAttr = &a
+	return nil
+}
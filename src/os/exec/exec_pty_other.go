@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package exec
+
+import (
+	"errors"
+	"os/pty"
+)
+
+func (c *Cmd) ptyAttr(p *pty.PTY) error {
+	return errors.New("exec: pseudo-terminals are not supported on this system")
+}
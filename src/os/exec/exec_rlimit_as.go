@@ -0,0 +1,12 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd
+// +build darwin dragonfly freebsd linux netbsd
+
+package exec
+
+import "syscall"
+
+const rlimitMemory = syscall.RLIMIT_AS
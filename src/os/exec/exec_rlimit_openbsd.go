@@ -0,0 +1,10 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import "syscall"
+
+// OpenBSD has no RLIMIT_AS.
+const rlimitMemory = syscall.RLIMIT_DATA
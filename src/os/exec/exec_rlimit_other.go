@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || (js && wasm) || plan9 || solaris
+// +build aix js,wasm plan9 solaris
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+func limitsAttr(attr *syscall.SysProcAttr, l *ResourceLimits) (*syscall.SysProcAttr, error) {
+	return nil, errors.New("exec: resource limits are not supported on this system")
+}
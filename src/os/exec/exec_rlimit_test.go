@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package exec_test
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestResourceLimits(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatal(err)
+	}
+	if rlim.Max < 100 {
+		t.Skipf("RLIMIT_NOFILE hard limit %d too low", rlim.Max)
+	}
+
+	attr := &syscall.SysProcAttr{}
+	cmd := exec.Command(sh, "-c", "ulimit -n; ulimit -c")
+	cmd.SysProcAttr = attr
+	cmd.Limits = &exec.ResourceLimits{OpenFiles: 100, NoCoreDumps: true}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "100\n0\n"; got != want {
+		t.Errorf("limits in child = %q, want %q", got, want)
+	}
+	if len(attr.Rlimits) != 0 {
+		t.Errorf("Start modified SysProcAttr.Rlimits: %v", attr.Rlimits)
+	}
+}
+
+func TestResourceLimitsOpenFilesBelowFds(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// The child moves the inherited file past descriptor 100 before
+	// putting it in place, which must not fail for the limit of 64.
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cmd := exec.Command(sh, "-c", "ulimit -n")
+	cmd.InheritedFiles = map[int]*os.File{100: f}
+	cmd.Limits = &exec.ResourceLimits{OpenFiles: 64}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "64\n"; got != want {
+		t.Errorf("ulimit -n in child = %q, want %q", got, want)
+	}
+}
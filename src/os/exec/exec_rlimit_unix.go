@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package exec
+
+import (
+	"syscall"
+	"time"
+)
+
+// limitsAttr returns a copy of attr that sets the resource limits l in
+// the child process.
+func limitsAttr(attr *syscall.SysProcAttr, l *ResourceLimits) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	a.Rlimits = append([]syscall.SysProcRlimit(nil), a.Rlimits...)
+	set := func(resource int, cur, max uint64) {
+		a.Rlimits = append(a.Rlimits, syscall.SysProcRlimit{Resource: resource, Cur: cur, Max: max})
+	}
+	if l.CPUTime > 0 {
+		sec := uint64((l.CPUTime + time.Second - 1) / time.Second)
+		set(syscall.RLIMIT_CPU, sec, sec+1)
+	}
+	if l.Memory > 0 {
+		set(rlimitMemory, l.Memory, l.Memory)
+	}
+	if l.OpenFiles > 0 {
+		set(syscall.RLIMIT_NOFILE, l.OpenFiles, l.OpenFiles)
+	}
+	if l.NoCoreDumps {
+		set(syscall.RLIMIT_CORE, 0, 0)
+	} else if l.CoreSize > 0 {
+		set(syscall.RLIMIT_CORE, l.CoreSize, l.CoreSize)
+	}
+	return &a, nil
+}
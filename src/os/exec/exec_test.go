@@ -21,7 +21,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -909,13 +911,50 @@ func TestHelperProcess(*testing.T) {
 		}
 		fmt.Print(p)
 		os.Exit(0)
+	case "isatty":
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			fmt.Println("tty")
+		} else {
+			fmt.Println("not a tty")
+		}
+		os.Exit(0)
 	case "stderrfail":
 		fmt.Fprintf(os.Stderr, "some stderr text\n")
 		os.Exit(1)
 	case "sleep":
 		time.Sleep(3 * time.Second)
 		os.Exit(0)
+	case "spawn":
+		// Start a grandchild that shares our standard output.
+		c := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleepecho")
+		c.Stdout = os.Stdout
+		if err := c.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "starting grandchild: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("ready")
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
+	case "sleepecho":
+		time.Sleep(time.Second)
+		fmt.Println("grandchild survived")
+		os.Exit(0)
+	case "trapterm":
+		// Exit when terminated, unless args[0] is "ignore".
+		c := make(chan os.Signal, 1)
+		signal.Notify(c)
+		fmt.Println("ready")
+		for sig := range c {
+			if sig.String() == "terminated" && (len(args) == 0 || args[0] != "ignore") {
+				fmt.Println("terminated")
+				os.Exit(0)
+			}
+		}
 	case "pipehandle":
+		if args[0] == "-" {
+			// The handle is written to standard input.
+			fmt.Scan(&args[0])
+		}
 		handle, _ := strconv.ParseUint(args[0], 16, 64)
 		pipe := os.NewFile(uintptr(handle), "")
 		_, err := fmt.Fprint(pipe, args[1])
@@ -1009,6 +1048,91 @@ func TestOutputStderrCapture(t *testing.T) {
 	}
 }
 
+func TestCmdSetenv(t *testing.T) {
+	testenv.MustHaveExec(t)
+
+	cmd := helperCommand(t, "echoenv", "FOO", "BAR")
+	cmd.Env = append(cmd.Env, "FOO=old", "BAR=bar")
+	if err := cmd.Setenv("FOO", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Unsetenv("BAR"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := cmd.LookupEnv("FOO"); v != "new" || !ok {
+		t.Errorf(`LookupEnv("FOO") = %q, %v; want "new", true`, v, ok)
+	}
+	if _, ok := cmd.LookupEnv("BAR"); ok {
+		t.Error(`LookupEnv("BAR") found unset variable`)
+	}
+	for _, key := range []string{"", "A=B", "A\x00"} {
+		if err := cmd.Setenv(key, "x"); err == nil {
+			t.Errorf("Setenv(%q) succeeded", key)
+		}
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "new\n\n"; got != want {
+		t.Errorf("command environment: got %q; want %q", got, want)
+	}
+
+	// With a nil Env, Setenv starts from the current environment.
+	cmd = helperCommand(t, "echoenv")
+	cmd.Env = nil
+	if err := cmd.Setenv("FOO", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cmd.LookupEnv("PATH"); !ok && os.Getenv("PATH") != "" {
+		t.Error("Setenv on a nil Env dropped the current environment")
+	}
+}
+
+func TestStreamOutput(t *testing.T) {
+	testenv.MustHaveExec(t)
+
+	long := strings.Repeat("x", 70000)
+	cmd := helperCommand(t, "cat")
+	cmd.Stdin = strings.NewReader("a\nb\r\n\n" + long + "\nc")
+	var lines []string
+	err := cmd.StreamOutput(func(line []byte) {
+		lines = append(lines, string(line))
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "", long[:64<<10], long[64<<10:], "c"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %d lines, want %d", len(lines), len(want))
+		for i := 0; i < len(lines) && i < len(want); i++ {
+			if lines[i] != want[i] {
+				t.Errorf("line %d has length %d, want %d", i, len(lines[i]), len(want[i]))
+			}
+		}
+	}
+
+	cmd = helperCommand(t, "stderrfail")
+	var stderr []string
+	if err := cmd.StreamOutput(nil, func(line []byte) {
+		stderr = append(stderr, string(line))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.StreamOutput(nil, func([]byte) {}); err == nil {
+		t.Error("second StreamOutput for stderr succeeded")
+	}
+	if err := cmd.Run(); err == nil {
+		t.Error("Run of stderrfail succeeded")
+	}
+	if want := []string{"some stderr text"}; !reflect.DeepEqual(stderr, want) {
+		t.Errorf("stderr lines = %q, want %q", stderr, want)
+	}
+}
+
 func TestContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	c := helperCommandContext(t, ctx, "pipetest")
@@ -1047,6 +1171,41 @@ func TestContext(t *testing.T) {
 	}
 }
 
+func TestContextKillProcessGroup(t *testing.T) {
+	switch runtime.GOOS {
+	case "js", "plan9":
+		t.Skipf("KillProcessGroup not supported on %s", runtime.GOOS)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := helperCommandContext(t, ctx, "spawn")
+	c.KillProcessGroup = true
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(stdout)
+	if line, err := r.ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("ReadString = %q, %v; want %q", line, err, "ready\n")
+	}
+	cancel()
+	// Reading reaches EOF once the command and the grandchild,
+	// which share the pipe, are both killed.
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) > 0 {
+		t.Errorf("read %q after cancellation; want grandchild killed", rest)
+	}
+	if err := c.Wait(); err == nil {
+		t.Error("expected Wait failure")
+	}
+}
+
 func TestContextCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
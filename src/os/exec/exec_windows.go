@@ -5,8 +5,13 @@
 package exec
 
 import (
+	"errors"
+	"internal/syscall/windows"
 	"io/fs"
+	"os"
+	"os/pty"
 	"syscall"
+	"unsafe"
 )
 
 func init() {
@@ -21,3 +26,214 @@ func init() {
 			(pe.Err == syscall.ERROR_BROKEN_PIPE || pe.Err == _ERROR_NO_DATA)
 	}
 }
+
+func (c *Cmd) pidfd() int { return -1 }
+
+// jobAttr returns a copy of attr that creates the process suspended,
+// so that startJob can assign it to the job object of the command
+// before it runs.
+func jobAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	a.CreationFlags |= windows.CREATE_SUSPENDED
+	return &a
+}
+
+// startJob creates the job object of c, if KillProcessGroup,
+// KillProcessGroupOnExit or Limits is set, and assigns the process,
+// which jobAttr created suspended, to it before resuming it. The
+// process is only left suspended if SysProcAttr asks for it.
+func (c *Cmd) startJob() error {
+	if !c.killsGroup() && c.Limits == nil {
+		c.handleErr = errors.New("exec: the command has no job object: set KillProcessGroup, KillProcessGroupOnExit or Limits")
+		return nil
+	}
+	ph, err := syscall.OpenProcess(windows.PROCESS_SET_QUOTA|syscall.PROCESS_TERMINATE|windows.PROCESS_SUSPEND_RESUME, false, uint32(c.Process.Pid))
+	if err != nil {
+		return os.NewSyscallError("OpenProcess", err)
+	}
+	defer syscall.CloseHandle(ph)
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return os.NewSyscallError("CreateJobObject", err)
+	}
+	if err := setJobLimits(job, c.Limits, c.KillProcessGroupOnExit); err != nil {
+		syscall.CloseHandle(job)
+		return err
+	}
+	if err := windows.AssignProcessToJobObject(job, ph); err != nil {
+		syscall.CloseHandle(job)
+		return os.NewSyscallError("AssignProcessToJobObject", err)
+	}
+	if c.SysProcAttr == nil || c.SysProcAttr.CreationFlags&windows.CREATE_SUSPENDED == 0 {
+		if err := windows.NtResumeProcess(ph); err != nil {
+			syscall.CloseHandle(job)
+			return os.NewSyscallError("NtResumeProcess", err)
+		}
+	}
+	c.handle = uintptr(job)
+	return nil
+}
+
+func closeProcessHandle(h uintptr) {
+	syscall.CloseHandle(syscall.Handle(h))
+}
+
+// processGroupAttr returns a copy of attr that creates the process
+// suspended: the job object of the command contains the processes
+// that it starts.
+func processGroupAttr(attr *syscall.SysProcAttr) (*syscall.SysProcAttr, error) {
+	return jobAttr(attr), nil
+}
+
+// killProcessGroup terminates the job object of c.
+func (c *Cmd) killProcessGroup() error {
+	if c.handleErr != nil {
+		return c.handleErr
+	}
+	return windows.TerminateJobObject(syscall.Handle(c.handle), 1)
+}
+
+// waitExited reports false: the job object of c, unlike a process
+// group id, cannot be reused, so it can be terminated after the
+// process is waited for.
+func (c *Cmd) waitExited() bool { return false }
+
+// terminate sends a CTRL_BREAK_EVENT to the process group of c.
+func (c *Cmd) terminate() error {
+	if c.SysProcAttr == nil || c.SysProcAttr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP == 0 {
+		return errors.New("exec: Terminate requires CREATE_NEW_PROCESS_GROUP")
+	}
+	// The process group id is the pid of the process that created it.
+	return windows.GenerateConsoleCtrlEvent(syscall.CTRL_BREAK_EVENT, uint32(c.Process.Pid))
+}
+
+// limitsAttr returns a copy of attr that creates the process
+// suspended: the limits are set on the job object of the command by
+// startJob.
+func limitsAttr(attr *syscall.SysProcAttr, l *ResourceLimits) (*syscall.SysProcAttr, error) {
+	if l.OpenFiles > 0 {
+		return nil, errors.New("exec: ResourceLimits.OpenFiles is not supported on Windows")
+	}
+	return jobAttr(attr), nil
+}
+
+// setJobLimits sets the limits l on job, and marks it to kill its
+// processes when it is closed if killOnClose is set. The processes of
+// the job may break away from it with CREATE_BREAKAWAY_FROM_JOB, as
+// they could from the job of the calling process, if any.
+func setJobLimits(job syscall.Handle, l *ResourceLimits, killOnClose bool) error {
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_BREAKAWAY_OK
+	if l != nil {
+		if l.CPUTime > 0 {
+			info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_TIME
+			// In 100-nanosecond intervals.
+			info.BasicLimitInformation.PerProcessUserTimeLimit = int64(l.CPUTime / 100)
+		}
+		if l.Memory > 0 {
+			info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+			info.ProcessMemoryLimit = uintptr(l.Memory)
+		}
+	}
+	if killOnClose {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	}
+	err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation, unsafe.Pointer(&info), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		return os.NewSyscallError("SetInformationJobObject", err)
+	}
+	return nil
+}
+
+// processGroupPids lists the processes in the job object of c.
+func (c *Cmd) processGroupPids() ([]int, error) {
+	if c.handleErr != nil {
+		return nil, c.handleErr
+	}
+	var info windows.JOBOBJECT_BASIC_PROCESS_ID_LIST
+	// The ids start at this index of buf.
+	off := int(unsafe.Offsetof(info.ProcessIdList) / unsafe.Sizeof(info.ProcessIdList[0]))
+	for n := 64; ; n *= 2 {
+		buf := make([]uintptr, off+n)
+		err := windows.QueryInformationJobObject(syscall.Handle(c.handle), windows.JobObjectBasicProcessIdList, unsafe.Pointer(&buf[0]), uint32(len(buf))*uint32(unsafe.Sizeof(buf[0])), nil)
+		if err == syscall.ERROR_MORE_DATA {
+			continue
+		}
+		if err != nil {
+			return nil, os.NewSyscallError("QueryInformationJobObject", err)
+		}
+		list := (*windows.JOBOBJECT_BASIC_PROCESS_ID_LIST)(unsafe.Pointer(&buf[0]))
+		pids := make([]int, list.NumberOfProcessIdsInList)
+		for i := range pids {
+			pids[i] = int(buf[off+i])
+		}
+		return pids, nil
+	}
+}
+
+// ptyAttr attaches c to the pseudo console of p.
+func (c *Cmd) ptyAttr(p *pty.PTY) error {
+	if c.Stdin != nil || c.Stdout != nil || c.Stderr != nil {
+		return errors.New("exec: StartWithPTY with Stdin, Stdout or Stderr set")
+	}
+	var a syscall.SysProcAttr
+	if c.SysProcAttr != nil {
+		a = *c.SysProcAttr
+	}
+	a.PseudoConsole = syscall.Handle(p.Fd())
+	c.SysProcAttr = &a
+	return nil
+}
+
+// inheritFiles arranges for files to be inherited by the process: it
+// makes private inheritable duplicates of their handles, which are
+// added to the AdditionalInheritedHandles of attr and so passed in the
+// handle list of the process, and records their values in
+// c.inheritedHandles. The handles of the caller are left untouched.
+// The returned function closes the duplicates once the process is
+// started.
+func (c *Cmd) inheritFiles(attr *syscall.SysProcAttr, files []*os.File) (*syscall.SysProcAttr, func(), error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	// The handles must exist in the parent of the process.
+	self, _ := syscall.GetCurrentProcess()
+	parent := self
+	if a.ParentProcess != 0 {
+		parent = a.ParentProcess
+	}
+	var dups []syscall.Handle
+	closeDups := func() {
+		for _, h := range dups {
+			syscall.DuplicateHandle(parent, h, 0, nil, 0, false, syscall.DUPLICATE_CLOSE_SOURCE)
+		}
+	}
+	c.inheritedHandles = nil
+	handles := append([]syscall.Handle(nil), a.AdditionalInheritedHandles...)
+	for i, f := range files {
+		if f == nil {
+			continue
+		}
+		var h syscall.Handle
+		err := syscall.DuplicateHandle(self, syscall.Handle(f.Fd()), parent, &h, 0, true, syscall.DUPLICATE_SAME_ACCESS)
+		if err != nil {
+			closeDups()
+			return nil, nil, os.NewSyscallError("DuplicateHandle", err)
+		}
+		dups = append(dups, h)
+		handles = append(handles, h)
+		if c.inheritedHandles == nil {
+			c.inheritedHandles = make([]uintptr, len(files))
+		}
+		c.inheritedHandles[i] = uintptr(h)
+	}
+	if len(dups) == 0 {
+		return attr, closeDups, nil
+	}
+	a.AdditionalInheritedHandles = handles
+	return &a, closeDups, nil
+}
@@ -8,6 +8,8 @@
 package exec_test
 
 import (
+	"fmt"
+	"internal/syscall/windows"
 	"io"
 	"os"
 	"strconv"
@@ -41,3 +43,72 @@ func TestPipePassing(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestInheritedHandles(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	const marker = "inherited through a duplicate"
+	c := helperCommand(t, "pipehandle", "-", marker)
+	c.ExtraFiles = []*os.File{w}
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	var flags uint32
+	if err := windows.GetHandleInformation(syscall.Handle(w.Fd()), &flags); err != nil {
+		t.Error(err)
+	} else if flags&syscall.HANDLE_FLAG_INHERIT != 0 {
+		t.Error("Start made the handle of the caller inheritable")
+	}
+	w.Close()
+	hs, err := c.InheritedHandles()
+	if err != nil || len(hs) != 1 || hs[0] == 0 {
+		t.Fatalf("InheritedHandles = %v, %v; want one handle", hs, err)
+	}
+	fmt.Fprintf(stdin, "%x\n", hs[0])
+	stdin.Close()
+	response, err := io.ReadAll(r)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(response) != marker {
+		t.Errorf("got %q; want %q", response, marker)
+	}
+	if err := c.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCmdJobHandle(t *testing.T) {
+	c := helperCommand(t, "echo", "job")
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.JobHandle(); err == nil {
+		t.Error("JobHandle succeeded without KillProcessGroup or Limits")
+	}
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = helperCommand(t, "echo", "job")
+	c.KillProcessGroup = true
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	h, err := c.JobHandle()
+	if err != nil {
+		t.Errorf("JobHandle: %v", err)
+	} else if h == 0 {
+		t.Error("JobHandle returned a zero handle")
+	}
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import "time"
+
+// ResourceLimits are limits on the resources that a command can use.
+// A zero field sets no limit: the command inherits the limit of the
+// calling process.
+//
+// On Unix systems, the limits are resource limits, as set by
+// setrlimit(2), which the child process sets before it executes the
+// command, and which are inherited by the processes that the command
+// starts. Each limit is set as both the soft and hard limit, except
+// for CPUTime. A limit cannot be set above the hard limit of the
+// calling process, unless it is privileged; Start fails otherwise.
+//
+// On Windows, the limits are those of the job object of the command,
+// returned by Cmd.JobHandle, which are set before the process runs.
+// OpenFiles is not supported, and the limits on core dumps
+// have no effect.
+//
+// Resource limits are not supported on AIX, Solaris, Plan 9 and
+// js/wasm.
+type ResourceLimits struct {
+	// CPUTime limits the CPU time used by the command, rounded up to
+	// a second on Unix systems: RLIMIT_CPU is set to the soft limit
+	// CPUTime, at which the command receives SIGXCPU, and to the hard
+	// limit CPUTime plus a second, at which it is killed. On Windows,
+	// CPUTime limits the user-mode CPU time of each process of the
+	// job, which is terminated when it reaches the limit.
+	CPUTime time.Duration
+
+	// Memory limits the memory used by the command, in bytes. It
+	// sets RLIMIT_AS, the size of the address space, on Unix systems
+	// other than OpenBSD, where it sets RLIMIT_DATA. On Windows, it
+	// limits the memory committed by each process of the job.
+	Memory uint64
+
+	// OpenFiles limits the number of files that the command can
+	// open (RLIMIT_NOFILE).
+	OpenFiles uint64
+
+	// CoreSize limits the size of the core files that the command
+	// dumps, in bytes (RLIMIT_CORE).
+	CoreSize uint64
+
+	// NoCoreDumps prevents the command from dumping core files, by
+	// setting RLIMIT_CORE to zero. It overrides CoreSize.
+	NoCoreDumps bool
+}
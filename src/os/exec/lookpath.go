@@ -0,0 +1,108 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// LookPathIn is like LookPath but searches the directories named by path,
+// a list in the format of the PATH environment variable, instead of the
+// directories named by PATH itself.
+//
+// On Windows the current directory is searched before path, as LookPath
+// does; use a Resolver with NoCurrentDir set to disable that.
+func LookPathIn(path, file string) (string, error) {
+	return lookPath(context.Background(), path, file, true)
+}
+
+// A Resolver looks up executables in a fixed list of directories,
+// remembering the result of each lookup.
+//
+// Results, including failures, are cached until Reset is called:
+// a Resolver does not notice executables that are added, removed or
+// renamed after they were looked up, nor changes of the current directory.
+//
+// A Resolver is safe for concurrent use by multiple goroutines.
+// Its fields must not be modified after its first use.
+type Resolver struct {
+	// Path is the list of directories to search, in the format of the
+	// PATH environment variable. Unlike with LookPath, the environment
+	// is not consulted; an empty Path names no directories.
+	Path string
+
+	// NoCurrentDir disables the implicit search of the current directory
+	// that Windows performs before consulting Path. On other systems the
+	// current directory is searched only if Path names it, and
+	// NoCurrentDir has no effect.
+	NoCurrentDir bool
+
+	mu    sync.Mutex
+	cache map[string]lookResult
+}
+
+type lookResult struct {
+	path string
+	err  error
+}
+
+// LookPath searches for an executable named file as the package-level
+// LookPath function does, but in r.Path, answering from the cache when
+// file has been looked up before.
+//
+// If ctx is done before the search completes, LookPath returns an *Error
+// wrapping ctx.Err() without waiting for the file system to respond.
+// The abandoned search stops in the background at its next directory.
+func (r *Resolver) LookPath(ctx context.Context, file string) (string, error) {
+	r.mu.Lock()
+	res, ok := r.cache[file]
+	r.mu.Unlock()
+	if ok {
+		return res.path, res.err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", &Error{file, err}
+	}
+	if ctx.Done() == nil {
+		return r.lookup(ctx, file)
+	}
+
+	c := make(chan lookResult, 1)
+	go func() {
+		path, err := r.lookup(ctx, file)
+		c <- lookResult{path, err}
+	}()
+	select {
+	case res := <-c:
+		return res.path, res.err
+	case <-ctx.Done():
+		return "", &Error{file, ctx.Err()}
+	}
+}
+
+// lookup searches for file and records the result, unless the
+// search was cut short by ctx.
+func (r *Resolver) lookup(ctx context.Context, file string) (string, error) {
+	path, err := lookPath(ctx, r.Path, file, !r.NoCurrentDir)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return path, err
+	}
+	r.mu.Lock()
+	if r.cache == nil {
+		r.cache = make(map[string]lookResult)
+	}
+	r.cache[file] = lookResult{path, err}
+	r.mu.Unlock()
+	return path, err
+}
+
+// Reset discards all cached results.
+func (r *Resolver) Reset() {
+	r.mu.Lock()
+	r.cache = nil
+	r.mu.Unlock()
+}
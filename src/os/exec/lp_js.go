@@ -8,6 +8,7 @@
 package exec
 
 import (
+	"context"
 	"errors"
 )
 
@@ -22,3 +23,7 @@ func LookPath(file string) (string, error) {
 	// Wasm can not execute processes, so act as if there are no executables at all.
 	return "", &Error{file, ErrNotFound}
 }
+
+func lookPath(ctx context.Context, path, file string, cwd bool) (string, error) {
+	return LookPath(file)
+}
@@ -5,6 +5,7 @@
 package exec
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"os"
@@ -32,6 +33,13 @@ func findExecutable(file string) error {
 // directly and the path is not consulted.
 // The result may be an absolute path or a path relative to the current directory.
 func LookPath(file string) (string, error) {
+	return lookPath(context.Background(), os.Getenv("path"), file, true)
+}
+
+// lookPath searches for file in the directories named by path.
+// The current directory is searched only if path names it explicitly,
+// so cwd is ignored.
+func lookPath(ctx context.Context, path, file string, cwd bool) (string, error) {
 	// skip the path lookup for these prefixes
 	skip := []string{"/", "#", "./", "../"}
 
@@ -45,8 +53,10 @@ func LookPath(file string) (string, error) {
 		}
 	}
 
-	path := os.Getenv("path")
 	for _, dir := range filepath.SplitList(path) {
+		if err := ctx.Err(); err != nil {
+			return "", &Error{file, err}
+		}
 		path := filepath.Join(dir, file)
 		if err := findExecutable(path); err == nil {
 			return path, nil
@@ -8,6 +8,7 @@
 package exec
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"os"
@@ -34,6 +35,13 @@ func findExecutable(file string) error {
 // If file contains a slash, it is tried directly and the PATH is not consulted.
 // The result may be an absolute path or a path relative to the current directory.
 func LookPath(file string) (string, error) {
+	return lookPath(context.Background(), os.Getenv("PATH"), file, true)
+}
+
+// lookPath searches for file in the directories named by path.
+// The current directory is searched only if path names it explicitly,
+// so cwd is ignored.
+func lookPath(ctx context.Context, path, file string, cwd bool) (string, error) {
 	// NOTE(rsc): I wish we could use the Plan 9 behavior here
 	// (only bypass the path if file begins with / or ./ or ../)
 	// but that would not match all the Unix shells.
@@ -45,8 +53,10 @@ func LookPath(file string) (string, error) {
 		}
 		return "", &Error{file, err}
 	}
-	path := os.Getenv("PATH")
 	for _, dir := range filepath.SplitList(path) {
+		if err := ctx.Err(); err != nil {
+			return "", &Error{file, err}
+		}
 		if dir == "" {
 			// Unix shell semantics: path element "" means "."
 			dir = "."
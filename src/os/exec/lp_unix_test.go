@@ -8,7 +8,10 @@
 package exec
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -47,3 +50,64 @@ func TestLookPathUnixEmptyPath(t *testing.T) {
 		t.Fatalf("LookPath path == %q when err != nil", path)
 	}
 }
+
+func TestLookPathIn(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	exe := filepath.Join(dir2, "exec_me")
+	if err := os.WriteFile(exe, nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", "")
+
+	path, err := LookPathIn(dir1+string(filepath.ListSeparator)+dir2, "exec_me")
+	if err != nil || path != exe {
+		t.Fatalf("LookPathIn = %q, %v; want %q, nil", path, err, exe)
+	}
+	if _, err := LookPathIn(dir1, "exec_me"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("LookPathIn in %s: got %v, want ErrNotFound", dir1, err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "exec_me")
+	if err := os.WriteFile(exe, nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	r := &Resolver{Path: dir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.LookPath(ctx, "exec_me"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("LookPath with canceled context: got %v, want context.Canceled", err)
+	}
+
+	path, err := r.LookPath(context.Background(), "exec_me")
+	if err != nil || path != exe {
+		t.Fatalf("LookPath = %q, %v; want %q, nil", path, err, exe)
+	}
+	if _, err := r.LookPath(context.Background(), "exec_other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("LookPath(exec_other): got %v, want ErrNotFound", err)
+	}
+
+	// Results are cached until Reset.
+	if err := os.Remove(exe); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "exec_other"), nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := r.LookPath(context.Background(), "exec_me"); err != nil || path != exe {
+		t.Fatalf("cached LookPath = %q, %v; want %q, nil", path, err, exe)
+	}
+	if _, err := r.LookPath(context.Background(), "exec_other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cached LookPath(exec_other): got %v, want ErrNotFound", err)
+	}
+	r.Reset()
+	if _, err := r.LookPath(context.Background(), "exec_me"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("LookPath after Reset: got %v, want ErrNotFound", err)
+	}
+	if _, err := r.LookPath(context.Background(), "exec_other"); err != nil {
+		t.Fatalf("LookPath(exec_other) after Reset: %v", err)
+	}
+}
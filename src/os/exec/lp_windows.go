@@ -5,6 +5,7 @@
 package exec
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"os"
@@ -58,6 +59,13 @@ func findExecutable(file string, exts []string) (string, error) {
 // a suitable candidate.
 // The result may be an absolute path or a path relative to the current directory.
 func LookPath(file string) (string, error) {
+	return lookPath(context.Background(), os.Getenv("path"), file, true)
+}
+
+// lookPath searches for file in the directories named by path.
+// If cwd is set, the current directory is searched first,
+// as the Windows command interpreter does.
+func lookPath(ctx context.Context, path, file string, cwd bool) (string, error) {
 	var exts []string
 	x := os.Getenv(`PATHEXT`)
 	if x != "" {
@@ -81,11 +89,15 @@ func LookPath(file string) (string, error) {
 			return "", &Error{file, err}
 		}
 	}
-	if f, err := findExecutable(filepath.Join(".", file), exts); err == nil {
-		return f, nil
+	if cwd {
+		if f, err := findExecutable(filepath.Join(".", file), exts); err == nil {
+			return f, nil
+		}
 	}
-	path := os.Getenv("path")
 	for _, dir := range filepath.SplitList(path) {
+		if err := ctx.Err(); err != nil {
+			return "", &Error{file, err}
+		}
 		if f, err := findExecutable(filepath.Join(dir, file), exts); err == nil {
 			return f, nil
 		}
@@ -8,6 +8,8 @@
 package exec_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"internal/testenv"
 	"io"
@@ -540,6 +542,30 @@ func buildPrintPathExe(t *testing.T, dir string) string {
 	return filepath.Join(dir, outname)
 }
 
+func TestResolverNoCurrentDir(t *testing.T) {
+	tmp := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.WriteFile("exec_me.exe", nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATHEXT", ".exe")
+
+	if _, err := exec.LookPathIn("", "exec_me"); err != nil {
+		t.Errorf("LookPathIn did not search the current directory: %v", err)
+	}
+	r := &exec.Resolver{NoCurrentDir: true}
+	if _, err := r.LookPath(context.Background(), "exec_me"); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Resolver with NoCurrentDir: got %v, want ErrNotFound", err)
+	}
+}
+
 const printpathSrc = `
 package main
 
@@ -0,0 +1,59 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+// Namespaces are the Linux namespaces, see namespaces(7), in which a
+// command runs. The command runs in a new namespace of each kind that
+// is set, and shares the other namespaces of the calling process.
+//
+// With User set, the command runs in a new user namespace, in which
+// it has all the capabilities needed to set up the other namespaces,
+// so that no privileges are needed, if the system allows unprivileged
+// user namespaces. The calling user and group are mapped to root in
+// the user namespace, unless UIDMap and GIDMap are set, and setgroups
+// is disabled, as unprivileged users must do to write the group
+// mappings.
+//
+// The mount namespace is made after the others, once Setup returns,
+// and all the mounts in it are made private, so that mounts made by
+// the command do not propagate to the namespace of the calling
+// process. In a new PID namespace, the command has process ID 1, but
+// the proc file system, if any, still shows the processes of the
+// calling process's PID namespace, until the command mounts a new
+// one.
+//
+// Namespaces are only supported on Linux, and cannot be combined with
+// the corresponding settings of SysProcAttr.
+type Namespaces struct {
+	User  bool // user namespace
+	Mount bool // mount namespace
+	Net   bool // network namespace
+	PID   bool // PID namespace
+	UTS   bool // host name and NIS domain name
+	IPC   bool // System V IPC and POSIX message queues
+
+	// UIDMap and GIDMap are the mappings of the user and group IDs
+	// of the user namespace to those of the calling process's, if
+	// User is set.
+	UIDMap []IDMap
+	GIDMap []IDMap
+
+	// Setup, if non-nil, is called with the process ID of the command
+	// once it is created in its namespaces, except the mount namespace,
+	// and before it runs the program, for example to move a network
+	// interface into its network namespace, which is found at
+	// /proc/pid/ns/net. If Setup returns an error, the command is not
+	// run, and Start returns the error.
+	Setup func(pid int) error
+}
+
+// An IDMap maps a range of Size user or group IDs in a user namespace,
+// starting at ContainerID, to the IDs starting at HostID in the parent
+// namespace. See user_namespaces(7).
+type IDMap struct {
+	ContainerID int
+	HostID      int
+	Size        int
+}
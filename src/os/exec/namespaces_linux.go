@@ -0,0 +1,82 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// namespacesAttr returns a copy of attr that runs the child in the
+// namespaces ns. If ns.Setup fails, its error is stored in *setupErr.
+func namespacesAttr(attr *syscall.SysProcAttr, ns *Namespaces, setupErr *error) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	var cloneflags uintptr
+	if ns.User {
+		cloneflags |= syscall.CLONE_NEWUSER
+	}
+	if ns.Net {
+		cloneflags |= syscall.CLONE_NEWNET
+	}
+	if ns.PID {
+		cloneflags |= syscall.CLONE_NEWPID
+	}
+	if ns.UTS {
+		cloneflags |= syscall.CLONE_NEWUTS
+	}
+	if ns.IPC {
+		cloneflags |= syscall.CLONE_NEWIPC
+	}
+	flags := a.Cloneflags | a.Unshareflags
+	if flags&cloneflags != 0 || ns.Mount && flags&syscall.CLONE_NEWNS != 0 {
+		return nil, errors.New("exec: Namespaces conflicts with SysProcAttr.Cloneflags or Unshareflags")
+	}
+	a.Cloneflags |= cloneflags
+	if ns.Mount {
+		// Unsharing the mount namespace in the child makes the
+		// mounts private.
+		a.Unshareflags |= syscall.CLONE_NEWNS
+	}
+
+	if ns.User {
+		if a.UidMappings != nil || a.GidMappings != nil {
+			return nil, errors.New("exec: Namespaces conflicts with SysProcAttr.UidMappings or GidMappings")
+		}
+		a.UidMappings = idMappings(ns.UIDMap, os.Getuid())
+		a.GidMappings = idMappings(ns.GIDMap, os.Getgid())
+		a.GidMappingsEnableSetgroups = false
+	} else if ns.UIDMap != nil || ns.GIDMap != nil {
+		return nil, errors.New("exec: Namespaces.UIDMap and GIDMap require User")
+	}
+
+	if ns.Setup != nil {
+		if a.PostClone != nil {
+			return nil, errors.New("exec: Namespaces.Setup conflicts with SysProcAttr.PostClone")
+		}
+		a.PostClone = func(pid int) error {
+			err := ns.Setup(pid)
+			*setupErr = err
+			return err
+		}
+	}
+	return &a, nil
+}
+
+// idMappings returns m as ID mappings for syscall, or, if m is empty,
+// a mapping of root to the host ID id.
+func idMappings(m []IDMap, id int) []syscall.SysProcIDMap {
+	if len(m) == 0 {
+		return []syscall.SysProcIDMap{{ContainerID: 0, HostID: id, Size: 1}}
+	}
+	sm := make([]syscall.SysProcIDMap, len(m))
+	for i, e := range m {
+		sm[i] = syscall.SysProcIDMap{ContainerID: e.ContainerID, HostID: e.HostID, Size: e.Size}
+	}
+	return sm
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	if _, err := os.Stat("/proc/self/ns/user"); err != nil {
+		t.Skip("kernel doesn't support user namespaces")
+	}
+	ownNet, err := os.Readlink("/proc/self/ns/net")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var childNet string
+	c := helperCommand(t, "cat", "/proc/self/uid_map")
+	c.Namespaces = &exec.Namespaces{
+		User:  true,
+		Mount: true,
+		Net:   true,
+		UTS:   true,
+		Setup: func(pid int) error {
+			var err error
+			childNet, err = os.Readlink("/proc/" + strconv.Itoa(pid) + "/ns/net")
+			return err
+		},
+	}
+	out, err := c.Output()
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSPC) {
+		t.Skipf("unprivileged user namespaces unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(strings.Fields(string(out)), " "), fmt.Sprintf("0 %d 1", os.Getuid()); got != want {
+		t.Errorf("uid_map of command = %q, want %q", got, want)
+	}
+	if childNet == "" || childNet == ownNet {
+		t.Errorf("network namespace of command = %q, want other than %q", childNet, ownNet)
+	}
+}
+
+func TestNamespacesSetupError(t *testing.T) {
+	setupErr := errors.New("setup failed")
+	c := helperCommand(t, "echo", "foo")
+	c.Namespaces = &exec.Namespaces{
+		Setup: func(int) error { return setupErr },
+	}
+	if err := c.Start(); err != setupErr {
+		t.Errorf("Start = %v, want %v", err, setupErr)
+		if err == nil {
+			c.Wait()
+		}
+	}
+}
+
+func TestNamespacesConflict(t *testing.T) {
+	c := helperCommand(t, "echo", "foo")
+	c.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNET}
+	c.Namespaces = &exec.Namespaces{Net: true}
+	if err := c.Start(); err == nil {
+		c.Wait()
+		t.Error("Start with Namespaces.Net and CLONE_NEWNET succeeded")
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+func namespacesAttr(attr *syscall.SysProcAttr, ns *Namespaces, setupErr *error) (*syscall.SysProcAttr, error) {
+	return nil, errors.New("exec: namespaces are not supported on this system")
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"internal/syscall/unix"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestCmdPidFD(t *testing.T) {
+	c := helperCommand(t, "sleep")
+	if _, err := c.PidFD(); err == nil {
+		t.Error("PidFD before Start succeeded")
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := c.PidFD()
+	if err != nil {
+		c.Process.Kill()
+		c.Wait()
+		t.Skipf("PidFD: %v", err)
+	}
+	if err := unix.PidFDSendSignal(fd, syscall.SIGKILL); err != nil {
+		t.Errorf("pidfd_send_signal: %v", err)
+		c.Process.Kill()
+	}
+	err = c.Wait()
+	ee, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("Wait = %v; want an ExitError", err)
+	}
+	if ws := ee.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+		t.Errorf("wait status = %v; want killed", ee)
+	}
+	if _, err := c.JobHandle(); err == nil {
+		t.Error("JobHandle succeeded on Linux")
+	}
+}
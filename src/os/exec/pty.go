@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"os/pty"
+)
+
+// StartWithPTY starts the command with a new pseudo-terminal, which it
+// returns. The caller reads the output of the command from the
+// pseudo-terminal and writes its input to it, and must close it once
+// done with it, after Wait, as Wait does not.
+//
+// On Linux, the command runs in a new session, whose controlling
+// terminal is the terminal of the pseudo-terminal, and the terminal is
+// its standard input, output and error, unless Stdin, Stdout or Stderr
+// are set. On Windows, the command is attached to a pseudo console,
+// which is its standard input, output and error: Stdin, Stdout and
+// Stderr must not be set.
+//
+// See package os/pty for the systems that support pseudo-terminals.
+func (c *Cmd) StartWithPTY() (*pty.PTY, error) {
+	if c.Process != nil {
+		return nil, errors.New("exec: already started")
+	}
+	p, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ptyAttr(p); err != nil {
+		p.Close()
+		return nil, err
+	}
+	err = c.Start()
+	if tty := p.TTY(); tty != nil {
+		tty.Close()
+	}
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
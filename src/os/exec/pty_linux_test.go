@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStartWithPTY(t *testing.T) {
+	c := helperCommand(t, "isatty")
+	p, err := c.StartWithPTY()
+	if err != nil {
+		t.Skipf("StartWithPTY: %v", err)
+	}
+	defer p.Close()
+	out, err := io.ReadAll(p)
+	if err != nil {
+		t.Error(err)
+	}
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	// The terminal translates "\n" to "\r\n".
+	if got := strings.TrimSpace(string(out)); got != "tty" {
+		t.Errorf("output = %q, want %q", got, "tty")
+	}
+	if c.SysProcAttr == nil || !c.SysProcAttr.Setsid || !c.SysProcAttr.Setctty {
+		t.Errorf("SysProcAttr = %+v, want Setsid and Setctty", c.SysProcAttr)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import "os"
+
+// A Sandbox restricts what a command and its descendants can do.
+//
+// The restrictions are applied in the new process between fork and exec,
+// together with the no_new_privs flag, so that neither the program nor
+// the programs it executes can gain privileges or lift them.
+// Sandboxes are only supported on Linux.
+type Sandbox struct {
+	// Landlock, if non-nil, restricts access to the file system.
+	// It is applied once the credentials and working directory of
+	// the process are set.
+	Landlock *LandlockRuleset
+
+	// SeccompFilter, if non-empty, is a classic BPF program installed
+	// as the seccomp filter of the process, just before the program is
+	// executed. It is evaluated against struct seccomp_data for each
+	// system call, and must allow execve(2).
+	SeccompFilter []BPFInstruction
+}
+
+// A BPFInstruction is an instruction of a classic BPF program,
+// as in struct sock_filter.
+type BPFInstruction struct {
+	Code uint16
+	Jt   uint8
+	Jf   uint8
+	K    uint32
+}
+
+// LandlockAccess is a set of file system access rights governed
+// by Landlock.
+type LandlockAccess uint64
+
+// The file system access rights of the first Landlock ABI.
+const (
+	LandlockExecute    LandlockAccess = 1 << iota // execute a file
+	LandlockWriteFile                             // open a file for writing
+	LandlockReadFile                              // open a file for reading
+	LandlockReadDir                               // open or list a directory
+	LandlockRemoveDir                             // remove an empty directory or rename one
+	LandlockRemoveFile                            // unlink or rename a file
+	LandlockMakeChar                              // create a character device
+	LandlockMakeDir                               // create a directory
+	LandlockMakeReg                               // create a regular file
+	LandlockMakeSock                              // create a Unix domain socket
+	LandlockMakeFifo                              // create a named pipe
+	LandlockMakeBlock                             // create a block device
+	LandlockMakeSym                               // create a symbolic link
+)
+
+// A LandlockRuleset is a set of Landlock rules. The access rights that
+// the ruleset handles are denied to a sandboxed process except where a
+// rule allows them; other rights are not restricted.
+//
+// A ruleset may be used by several commands, and may be closed once
+// they are started.
+type LandlockRuleset struct {
+	f *os.File
+}
+
+// Close closes the ruleset.
+func (r *LandlockRuleset) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"internal/syscall/unix"
+	"os"
+	"syscall"
+)
+
+// NewLandlockRuleset returns a new ruleset handling the access rights in
+// handled. It requires Linux 5.13 or later with Landlock enabled.
+func NewLandlockRuleset(handled LandlockAccess) (*LandlockRuleset, error) {
+	fd, err := unix.LandlockCreateRuleset(uint64(handled))
+	if err != nil {
+		return nil, os.NewSyscallError("landlock_create_ruleset", err)
+	}
+	return &LandlockRuleset{f: os.NewFile(uintptr(fd), "landlock-ruleset")}, nil
+}
+
+// AllowPath adds a rule allowing the access rights in access to the
+// file or directory hierarchy at path. The rights must be handled by
+// the ruleset.
+func (r *LandlockRuleset) AllowPath(path string, access LandlockAccess) error {
+	sc, err := r.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var err2 error
+	err = sc.Control(func(fd uintptr) {
+		err2 = unix.LandlockAllowPath(int(fd), path, uint64(access))
+	})
+	if err != nil {
+		return err
+	}
+	if err2 != nil {
+		return &os.PathError{Op: "landlock_add_rule", Path: path, Err: err2}
+	}
+	return nil
+}
+
+// sandboxAttr returns a copy of attr that makes the child apply s.
+// The ruleset of s must be kept open until the child is started.
+func sandboxAttr(attr *syscall.SysProcAttr, s *Sandbox) (*syscall.SysProcAttr, error) {
+	var a syscall.SysProcAttr
+	if attr != nil {
+		a = *attr
+	}
+	a.NoNewPrivs = true
+	if s.Landlock != nil {
+		if a.UseLandlockRuleset {
+			return nil, errors.New("exec: Sandbox.Landlock with SysProcAttr.UseLandlockRuleset")
+		}
+		a.UseLandlockRuleset = true
+		a.LandlockRuleset = int(s.Landlock.f.Fd())
+	}
+	if len(s.SeccompFilter) > 0 {
+		if len(a.SeccompFilter) > 0 {
+			return nil, errors.New("exec: Sandbox.SeccompFilter with SysProcAttr.SeccompFilter")
+		}
+		a.SeccompFilter = make([]syscall.SockFilter, len(s.SeccompFilter))
+		for i, ins := range s.SeccompFilter {
+			a.SeccompFilter[i] = syscall.SockFilter{Code: ins.Code, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+		}
+	}
+	return &a, nil
+}
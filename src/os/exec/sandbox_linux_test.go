@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestSandboxSeccomp(t *testing.T) {
+	if _, err := exec.LookPath("uname"); err != nil {
+		t.Skip(err)
+	}
+
+	// Fail uname(2) with EPERM and allow everything else.
+	const (
+		ldAbsW   = 0x20 // BPF_LD | BPF_W | BPF_ABS
+		jeqK     = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+		retK     = 0x06 // BPF_RET | BPF_K
+		retErrno = 0x00050000
+		retAllow = 0x7fff0000
+	)
+	filter := []exec.BPFInstruction{
+		{Code: ldAbsW, K: 0}, // seccomp_data.nr
+		{Code: jeqK, Jt: 0, Jf: 1, K: syscall.SYS_UNAME},
+		{Code: retK, K: retErrno | uint32(syscall.EPERM)},
+		{Code: retK, K: retAllow},
+	}
+
+	if err := exec.Command("uname").Run(); err != nil {
+		t.Fatalf("uname without sandbox: %v", err)
+	}
+	cmd := exec.Command("uname")
+	cmd.Sandbox = &exec.Sandbox{SeccompFilter: filter}
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("uname succeeded in sandbox: %s", out)
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("uname in sandbox: %v", err)
+	}
+}
+
+func TestSandboxLandlock(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	r, err := exec.NewLandlockRuleset(exec.LandlockMakeReg | exec.LandlockWriteFile)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer r.Close()
+
+	allowed, denied := t.TempDir(), t.TempDir()
+	if err := r.AllowPath(allowed, exec.LandlockMakeReg|exec.LandlockWriteFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AllowPath(filepath.Join(allowed, "missing"), exec.LandlockMakeReg); !os.IsNotExist(err) {
+		t.Errorf("AllowPath of a missing file: got %v, want not exist error", err)
+	}
+
+	create := func(dir string) error {
+		cmd := exec.Command("sh", "-c", "echo x >"+filepath.Join(dir, "f"))
+		cmd.Sandbox = &exec.Sandbox{Landlock: r}
+		return cmd.Run()
+	}
+	if err := create(allowed); err != nil {
+		t.Errorf("creating a file in the allowed directory: %v", err)
+	}
+	if err := create(denied); err == nil {
+		t.Errorf("creating a file in the denied directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(denied, "f")); !os.IsNotExist(err) {
+		t.Errorf("file created in the denied directory: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+var errSandboxUnsupported = errors.New("exec: sandboxes are not supported on this system")
+
+// NewLandlockRuleset returns a new ruleset handling the access rights in
+// handled. It requires Linux 5.13 or later with Landlock enabled.
+func NewLandlockRuleset(handled LandlockAccess) (*LandlockRuleset, error) {
+	return nil, errSandboxUnsupported
+}
+
+// AllowPath adds a rule allowing the access rights in access to the
+// file or directory hierarchy at path. The rights must be handled by
+// the ruleset.
+func (r *LandlockRuleset) AllowPath(path string, access LandlockAccess) error {
+	return errSandboxUnsupported
+}
+
+func sandboxAttr(attr *syscall.SysProcAttr, s *Sandbox) (*syscall.SysProcAttr, error) {
+	return nil, errSandboxUnsupported
+}
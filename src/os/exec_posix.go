@@ -61,7 +61,9 @@ func startProcess(name string, argv []string, attr *ProcAttr) (p *Process, err e
 		return nil, &PathError{Op: "fork/exec", Path: name, Err: e}
 	}
 
-	return newProcess(pid, h), nil
+	p = newProcess(pid, h)
+	p.pidfd = pidfdOpen(pid)
+	return p, nil
 }
 
 func (p *Process) kill() error {
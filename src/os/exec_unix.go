@@ -9,11 +9,21 @@ package os
 
 import (
 	"errors"
+	"internal/osexec"
 	"runtime"
 	"syscall"
 	"time"
 )
 
+func init() {
+	// Package os/exec uses this to kill the process group of p
+	// before its pid can be reused.
+	osexec.ProcessBlockUntilWaitable = func(p interface{}) bool {
+		ready, err := p.(*Process).blockUntilWaitable()
+		return ready && err == nil
+	}
+}
+
 func (p *Process) wait() (ps *ProcessState, err error) {
 	if p.Pid == -1 {
 		return nil, syscall.EINVAL
@@ -52,6 +62,11 @@ func (p *Process) wait() (ps *ProcessState, err error) {
 	if pid1 != 0 {
 		p.setDone()
 	}
+	if p.pidfd != nil {
+		// The process is reaped: there is nothing left to
+		// signal or wait for.
+		p.pidfd.Close()
+	}
 	ps = &ProcessState{
 		pid:    pid1,
 		status: status,
@@ -76,6 +91,16 @@ func (p *Process) signal(sig Signal) error {
 	if !ok {
 		return errors.New("os: unsupported signal type")
 	}
+	if p.pidfd != nil {
+		e := p.pidfdSignal(s)
+		if e == syscall.ESRCH {
+			return ErrProcessDone
+		}
+		if e != syscall.ENOSYS {
+			return e
+		}
+		// pidfd_send_signal is not available; fall back to kill.
+	}
 	if e := syscall.Kill(p.Pid, s); e != nil {
 		if e == syscall.ESRCH {
 			return ErrProcessDone
@@ -86,7 +111,9 @@ func (p *Process) signal(sig Signal) error {
 }
 
 func (p *Process) release() error {
-	// NOOP for unix.
+	if p.pidfd != nil {
+		p.pidfd.Close()
+	}
 	p.Pid = -1
 	// no need for a finalizer anymore
 	runtime.SetFinalizer(p, nil)
@@ -5,3 +5,8 @@
 package os
 
 var PollCopyFileRangeP = &pollCopyFileRange
+var PollSpliceP = &pollSplice
+
+var Drain = drain
+
+func HasPidfd(p *Process) bool { return p.pidfd != nil }
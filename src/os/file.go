@@ -48,6 +48,7 @@ import (
 	"io"
 	"io/fs"
 	"runtime"
+	"sort"
 	"syscall"
 	"time"
 	"unsafe"
@@ -190,6 +191,25 @@ func (f *File) Write(b []byte) (n int, err error) {
 	return n, err
 }
 
+// WriteVec writes the contents of bufs to the File, in order, as if they
+// had been concatenated, and implements io.VecWriter. On systems that
+// provide writev, it writes all the buffers with a single system call
+// where possible. It returns the number of bytes written and an error,
+// if any.
+func (f *File) WriteVec(bufs [][]byte) (n int64, err error) {
+	if err := f.checkValid("write"); err != nil {
+		return 0, err
+	}
+	// writev consumes the buffers it writes; leave the caller's intact.
+	v := append([][]byte(nil), bufs...)
+	n, e := f.writev(&v)
+	epipecheck(f, e)
+	if e != nil {
+		err = f.wrapErr("write", e)
+	}
+	return n, err
+}
+
 var errWriteAtInAppendMode = errors.New("os: invalid use of WriteAt on file opened with O_APPEND")
 
 // WriteAt writes len(b) bytes to the File starting at byte offset off.
@@ -340,6 +360,7 @@ func OpenFile(name string, flag int, perm FileMode) (*File, error) {
 		return nil, err
 	}
 	f.appendMode = flag&O_APPEND != 0
+	f.flag = flag
 
 	return f, nil
 }
@@ -623,8 +644,15 @@ func isWindowsNulName(name string) bool {
 // the /prefix tree, then using DirFS does not stop the access any more than using
 // os.Open does. DirFS is therefore not a general substitute for a chroot-style security
 // mechanism when the directory tree contains arbitrary content.
+//
+// The returned file system also implements fs.OpenFileFS, fs.WriteFileFS,
+// fs.MkdirFS, fs.RemoveFS, fs.RenameFS, fs.ChmodFS, fs.ChownFS,
+// fs.ChtimesFS, fs.SymlinkFS and fs.LinkFS, so it can be modified through
+// the helpers in package io/fs. It also implements fs.StatDirEntriesFS.
+//
+// DirFSWithOptions offers control over how symbolic links in the tree are followed.
 func DirFS(dir string) fs.FS {
-	return dirFS(dir)
+	return dirFS{dir: dir}
 }
 
 func containsAny(s, chars string) bool {
@@ -638,13 +666,32 @@ func containsAny(s, chars string) bool {
 	return false
 }
 
-type dirFS string
+type dirFS struct {
+	dir      string
+	symlinks DirFSSymlinks
+}
 
-func (dir dirFS) Open(name string) (fs.File, error) {
+// join returns the operating system path of name within dir,
+// or a *PathError for op if name is not a valid path or, under the
+// symbolic link policy of dir, may not be accessed. If followLast is
+// false, a symbolic link as the final element of name is the operand
+// of op rather than a link to follow.
+func (dir dirFS) join(op, name string, followLast bool) (string, error) {
 	if !fs.ValidPath(name) || runtime.GOOS == "windows" && containsAny(name, `\:`) {
-		return nil, &PathError{Op: "open", Path: name, Err: ErrInvalid}
+		return "", &PathError{Op: op, Path: name, Err: ErrInvalid}
+	}
+	if dir.symlinks != DirFSFollow {
+		return dir.resolve(op, name, followLast)
+	}
+	return dir.dir + "/" + name, nil
+}
+
+func (dir dirFS) Open(name string) (fs.File, error) {
+	fullname, err := dir.join("open", name, true)
+	if err != nil {
+		return nil, err
 	}
-	f, err := Open(string(dir) + "/" + name)
+	f, err := Open(fullname)
 	if err != nil {
 		return nil, err // nil fs.File
 	}
@@ -652,16 +699,134 @@ func (dir dirFS) Open(name string) (fs.File, error) {
 }
 
 func (dir dirFS) Stat(name string) (fs.FileInfo, error) {
-	if !fs.ValidPath(name) || runtime.GOOS == "windows" && containsAny(name, `\:`) {
-		return nil, &PathError{Op: "stat", Path: name, Err: ErrInvalid}
+	fullname, err := dir.join("stat", name, true)
+	if err != nil {
+		return nil, err
 	}
-	f, err := Stat(string(dir) + "/" + name)
+	f, err := Stat(fullname)
 	if err != nil {
 		return nil, err
 	}
 	return f, nil
 }
 
+// StatDirEntries reads the named directory and, on Unix systems,
+// describes each entry relative to the open directory with fstatat
+// instead of resolving the full path of every entry as Lstat would.
+// Elsewhere it uses File.Readdir, which on Windows gets the information
+// along with the directory entries.
+func (dir dirFS) StatDirEntries(name string) ([]fs.FileInfo, error) {
+	fullname, err := dir.join("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Open(fullname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if haveStatAt {
+		return f.statEntries()
+	}
+	infos, err := f.Readdir(-1)
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, err
+}
+
+func (dir dirFS) OpenFile(name string, flag int, perm FileMode) (fs.File, error) {
+	fullname, err := dir.join("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := OpenFile(fullname, flag, perm)
+	if err != nil {
+		return nil, err // nil fs.File
+	}
+	return f, nil
+}
+
+func (dir dirFS) WriteFile(name string, data []byte, perm FileMode) error {
+	fullname, err := dir.join("open", name, true)
+	if err != nil {
+		return err
+	}
+	return WriteFile(fullname, data, perm)
+}
+
+func (dir dirFS) Mkdir(name string, perm FileMode) error {
+	fullname, err := dir.join("mkdir", name, false)
+	if err != nil {
+		return err
+	}
+	return Mkdir(fullname, perm)
+}
+
+func (dir dirFS) Remove(name string) error {
+	fullname, err := dir.join("remove", name, false)
+	if err != nil {
+		return err
+	}
+	return Remove(fullname)
+}
+
+func (dir dirFS) Chmod(name string, mode FileMode) error {
+	fullname, err := dir.join("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	return Chmod(fullname, mode)
+}
+
+func (dir dirFS) Chown(name string, uid, gid int) error {
+	fullname, err := dir.join("chown", name, true)
+	if err != nil {
+		return err
+	}
+	return Chown(fullname, uid, gid)
+}
+
+func (dir dirFS) Chtimes(name string, atime, mtime time.Time) error {
+	fullname, err := dir.join("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	return Chtimes(fullname, atime, mtime)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+// Like the target of any symbolic link, oldname is not confined to dir.
+func (dir dirFS) Symlink(oldname, newname string) error {
+	fullname, err := dir.join("symlink", newname, false)
+	if err != nil {
+		return err
+	}
+	return Symlink(oldname, fullname)
+}
+
+func (dir dirFS) Link(oldname, newname string) error {
+	oldfull, err := dir.join("link", oldname, false)
+	if err != nil {
+		return err
+	}
+	newfull, err := dir.join("link", newname, false)
+	if err != nil {
+		return err
+	}
+	return Link(oldfull, newfull)
+}
+
+func (dir dirFS) Rename(oldname, newname string) error {
+	oldfull, err := dir.join("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	newfull, err := dir.join("rename", newname, false)
+	if err != nil {
+		return err
+	}
+	return Rename(oldfull, newfull)
+}
+
 // ReadFile reads the named file and returns the contents.
 // A successful call returns err == nil, not err == EOF.
 // Because ReadFile reads the whole file, it does not treat an EOF from Read
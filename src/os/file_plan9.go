@@ -26,6 +26,7 @@ type file struct {
 	name       string
 	dirinfo    *dirInfo // nil unless directory being read
 	appendMode bool     // whether file is opened for appending
+	flag       int      // flag passed to OpenFile, if any
 }
 
 // Fd returns the integer Plan 9 file descriptor referencing the open file.
@@ -59,6 +59,7 @@ type file struct {
 	nonblock    bool     // whether we set nonblocking mode
 	stdoutOrErr bool     // whether this is stdout or stderr
 	appendMode  bool     // whether file is opened for appending
+	flag        int      // flag passed to OpenFile, if any
 }
 
 // Fd returns the integer Unix file descriptor referencing the open file.
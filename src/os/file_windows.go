@@ -23,6 +23,7 @@ type file struct {
 	name       string
 	dirinfo    *dirInfo // nil unless directory being read
 	appendMode bool     // whether file is opened for appending
+	flag       int      // flag passed to OpenFile, if any
 }
 
 // Fd returns the Windows handle referencing the open file.
@@ -0,0 +1,261 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"errors"
+	"internal/itoa"
+	"runtime"
+	"time"
+)
+
+// ErrLocked is returned, wrapped in a *PathError, when a lock file
+// is held by another owner.
+var ErrLocked = errors.New("os: file is locked")
+
+// errLockUnsupported is returned by lockFile on systems
+// or file systems without advisory file locks.
+var errLockUnsupported = errors.New("os: file locking not supported")
+
+// A FileLock is an exclusive lock represented by a file in the file system,
+// as used by programs that must run as a single instance or that serialize
+// access to a state directory.
+//
+// Where the operating system provides advisory locks (flock on most Unix
+// systems, LockFileEx on Windows), the lock is released automatically when
+// its owner exits, so a lock file left behind by a crashed process is
+// simply reused. Elsewhere, and on file systems that reject advisory locks,
+// the lock is the existence of the file itself, created with O_EXCL. The
+// file records the process ID and, where known, the start time of its
+// owner, and a lock whose owner is no longer running is considered stale
+// and taken over.
+type FileLock struct {
+	name string
+	f    *File
+}
+
+// TryLockFile attempts to acquire the lock file with the given name,
+// creating it if necessary. If the lock is held by another owner,
+// including another FileLock in the same process, TryLockFile returns
+// a *PathError wrapping ErrLocked.
+func TryLockFile(name string) (*FileLock, error) {
+	// Each retry follows a lost race with another process that removed
+	// or replaced the file; a handful of them means something is
+	// churning the file and it is better to report the lock as held.
+	for i := 0; i < 10; i++ {
+		l, err := tryLockFile(name)
+		if err != errLockRetry {
+			return l, err
+		}
+	}
+	return nil, &PathError{Op: "lock", Path: name, Err: ErrLocked}
+}
+
+// LockFile acquires the lock file with the given name, waiting for it
+// to be released if it is held by another owner. A zero deadline means
+// LockFile waits for as long as it takes. If the deadline passes before
+// the lock is acquired, LockFile returns a *PathError wrapping
+// ErrDeadlineExceeded.
+func LockFile(name string, deadline time.Time) (*FileLock, error) {
+	delay := time.Millisecond
+	for {
+		l, err := TryLockFile(name)
+		if !errors.Is(err, ErrLocked) {
+			return l, err
+		}
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return nil, &PathError{Op: "lock", Path: name, Err: ErrDeadlineExceeded}
+			}
+			if delay > left {
+				delay = left
+			}
+		}
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+var errLockRetry = errors.New("retry")
+
+func tryLockFile(name string) (*FileLock, error) {
+	created := true
+	f, err := OpenFile(name, O_RDWR|O_CREATE|O_EXCL, 0666)
+	if IsExist(err) {
+		created = false
+		f, err = OpenFile(name, O_RDWR, 0)
+		if IsNotExist(err) {
+			return nil, errLockRetry
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockFile(f)
+	switch {
+	case err == nil:
+		// The file may have been removed by its previous owner
+		// between our open and lock; if so, we locked a file that
+		// is no longer the lock file.
+		fi, err1 := f.Stat()
+		di, err2 := Stat(name)
+		if err1 != nil || err2 != nil || !SameFile(fi, di) {
+			f.Close()
+			return nil, errLockRetry
+		}
+	case err == ErrLocked:
+		f.Close()
+		return nil, &PathError{Op: "lock", Path: name, Err: ErrLocked}
+	case err == errLockUnsupported:
+		if !created {
+			if lockOwnerAlive(f) {
+				f.Close()
+				return nil, &PathError{Op: "lock", Path: name, Err: ErrLocked}
+			}
+			// Another contender may have removed the stale lock file
+			// and created a fresh one since we opened it. Only remove
+			// the file we found stale, then race to create a new one.
+			// Only one contender's O_EXCL create can succeed.
+			fi, err1 := f.Stat()
+			di, err2 := Stat(name)
+			f.Close()
+			if err1 != nil || err2 != nil || !SameFile(fi, di) {
+				return nil, errLockRetry
+			}
+			if err := Remove(name); err != nil && !IsNotExist(err) {
+				return nil, err
+			}
+			return nil, errLockRetry
+		}
+	default:
+		f.Close()
+		return nil, err
+	}
+
+	if err := writeLockOwner(f); err != nil {
+		f.Close()
+		if created {
+			Remove(name)
+		}
+		return nil, err
+	}
+	return &FileLock{name: name, f: f}, nil
+}
+
+// Name returns the name of the lock file.
+func (l *FileLock) Name() string {
+	return l.name
+}
+
+// Unlock releases the lock and removes the lock file.
+func (l *FileLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return ErrInvalid
+	}
+	f := l.f
+	l.f = nil
+	if runtime.GOOS == "windows" {
+		// An open file cannot be removed on Windows. Another process
+		// may open the file after we close it, in which case the
+		// removal fails and that process takes over the file.
+		err := f.Close()
+		Remove(l.name)
+		return err
+	}
+	// Remove the file while it is still locked, so that a contender
+	// that opened it before the removal notices that it locked a
+	// file that is no longer the lock file.
+	err := Remove(l.name)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeLockOwner records the current process as the owner of f.
+func writeLockOwner(f *File) error {
+	pid := Getpid()
+	owner := itoa.Itoa(pid) + " " + processStartTime(pid) + "\n"
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(owner), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// lockOwnerAlive reports whether the owner recorded in the lock file f
+// is still running. Lock files that cannot be parsed, for instance
+// because their owner has not yet written to them, are considered held.
+func lockOwnerAlive(f *File) bool {
+	var buf [64]byte
+	n, _ := f.ReadAt(buf[:], 0)
+	line := string(buf[:n])
+	if n == 0 || line[n-1] != '\n' {
+		return true
+	}
+	pid, rest := 0, line[:n-1]
+	for len(rest) > 0 && '0' <= rest[0] && rest[0] <= '9' {
+		pid = pid*10 + int(rest[0]-'0')
+		rest = rest[1:]
+	}
+	if pid <= 0 || len(rest) == 0 || rest[0] != ' ' {
+		return true
+	}
+	start := rest[1:]
+	if pid == Getpid() {
+		// The lock is held by this process, or by the process that
+		// had our process ID before us and has therefore exited.
+		return start == processStartTime(pid)
+	}
+	if !processAlive(pid) {
+		return false
+	}
+	// A different start time means the process ID has been reused.
+	if start != "" {
+		if now := processStartTime(pid); now != "" && now != start {
+			return false
+		}
+	}
+	return true
+}
+
+// processStartTime returns an opaque representation of the time
+// at which the process pid started, or "" if it is unknown.
+func processStartTime(pid int) string {
+	if runtime.GOOS != "linux" && runtime.GOOS != "android" {
+		return ""
+	}
+	data, err := ReadFile("/proc/" + itoa.Itoa(pid) + "/stat")
+	if err != nil {
+		return ""
+	}
+	// The command name in field 2 is parenthesized and may contain
+	// spaces, so count fields after its closing parenthesis.
+	// The start time is field 22.
+	i := len(data) - 1
+	for i >= 0 && data[i] != ')' {
+		i--
+	}
+	field := 2
+	start := -1
+	for i++; i < len(data); i++ {
+		if data[i] != ' ' {
+			continue
+		}
+		field++
+		if field == 22 {
+			start = i + 1
+		} else if field == 23 {
+			return string(data[start:i])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd illumos linux netbsd openbsd
+
+package os
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on f without blocking.
+// The lock is released when f is closed.
+func lockFile(f *File) error {
+	var err error
+	cerr := f.pfd.RawControl(func(fd uintptr) {
+		for {
+			err = syscall.Flock(int(fd), syscall.LOCK_EX|syscall.LOCK_NB)
+			if err != syscall.EINTR {
+				break
+			}
+		}
+	})
+	runtime.KeepAlive(f)
+	if cerr != nil {
+		return cerr
+	}
+	switch err {
+	case nil:
+		return nil
+	case syscall.EWOULDBLOCK:
+		return ErrLocked
+	case syscall.ENOLCK, syscall.EOPNOTSUPP, syscall.ENOSYS:
+		// Some network file systems do not support flock.
+		return errLockUnsupported
+	}
+	return &PathError{Op: "flock", Path: f.name, Err: err}
+}
@@ -0,0 +1,18 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || (js && wasm) || plan9 || (solaris && !illumos)
+// +build aix js,wasm plan9 solaris,!illumos
+
+package os
+
+// lockFile reports that advisory locks are not available,
+// so that lock files rely on O_EXCL creation alone.
+//
+// fcntl locks, which aix and solaris do provide, are owned by the
+// process rather than the open file, and so cannot exclude another
+// FileLock in the same process.
+func lockFile(f *File) error {
+	return errLockUnsupported
+}
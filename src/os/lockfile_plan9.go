@@ -0,0 +1,13 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import "internal/itoa"
+
+// processAlive reports whether a process with the given ID exists.
+func processAlive(pid int) bool {
+	_, err := Stat("/proc/" + itoa.Itoa(pid))
+	return !IsNotExist(err)
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "os"
+)
+
+func TestTryLockFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "lock")
+	l, err := TryLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(name); err != nil {
+		t.Fatalf("lock file missing while held: %v", err)
+	}
+	if l2, err := TryLockFile(name); !errors.Is(err, ErrLocked) {
+		if l2 != nil {
+			l2.Unlock()
+		}
+		t.Fatalf("second TryLockFile = %v; want ErrLocked", err)
+	}
+	if err := l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stat(name); !IsNotExist(err) {
+		t.Fatalf("lock file still present after Unlock: %v", err)
+	}
+	if err := l.Unlock(); err == nil {
+		t.Fatal("second Unlock succeeded")
+	}
+	l, err = TryLockFile(name)
+	if err != nil {
+		t.Fatalf("TryLockFile after Unlock: %v", err)
+	}
+	l.Unlock()
+}
+
+func TestLockFileWait(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "lock")
+	l, err := TryLockFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LockFile(name, time.Now().Add(20*time.Millisecond)); !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("LockFile on held lock = %v; want ErrDeadlineExceeded", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		l2, err := LockFile(name, time.Time{})
+		if err == nil {
+			err = l2.Unlock()
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("waiting LockFile: %v", err)
+	}
+}
+
+func TestLockFileLeftOver(t *testing.T) {
+	// A lock file left behind by a crashed owner must not
+	// prevent the lock from being acquired.
+	name := filepath.Join(t.TempDir(), "lock")
+	if err := WriteFile(name, []byte("999999999 12345\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	l, err := TryLockFile(name)
+	if err != nil {
+		t.Fatalf("TryLockFile with stale lock file: %v", err)
+	}
+	defer l.Unlock()
+	data, err := ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(Getpid())
+	if len(data) <= len(pid) || string(data[:len(pid)+1]) != pid+" " {
+		t.Errorf("lock file contents = %q; want owner %s", data, pid)
+	}
+}
+
+func TestLockOwnerAlive(t *testing.T) {
+	dir := t.TempDir()
+	check := func(contents string, want bool) {
+		t.Helper()
+		name := filepath.Join(dir, "owner")
+		if err := WriteFile(name, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if got := LockOwnerAlive(f); got != want {
+			t.Errorf("LockOwnerAlive(%q) = %v; want %v", contents, got, want)
+		}
+	}
+	check("", true)
+	check("123", true)
+	check("999999999 \n", false)
+	check(strconv.Itoa(Getpid())+" never\n", false)
+}
@@ -0,0 +1,17 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || (js && wasm) || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd js,wasm linux netbsd openbsd solaris
+
+package os
+
+import "syscall"
+
+// processAlive reports whether a process with the given ID exists.
+func processAlive(pid int) bool {
+	// EPERM means the process exists but belongs to someone else.
+	// Where signals are not supported at all, assume it is alive.
+	return syscall.Kill(pid, 0) != syscall.ESRCH
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"internal/syscall/windows"
+	"runtime"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on all of f without blocking.
+// The lock is released when f is closed.
+func lockFile(f *File) error {
+	var err error
+	cerr := f.pfd.RawControl(func(fd uintptr) {
+		// LockFileEx requires an OVERLAPPED structure, which holds the
+		// offset of the start of the locked range; lock from zero.
+		ol := new(syscall.Overlapped)
+		err = windows.LockFileEx(syscall.Handle(fd), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, ^uint32(0), ^uint32(0), ol)
+	})
+	runtime.KeepAlive(f)
+	if cerr != nil {
+		return cerr
+	}
+	switch err {
+	case nil:
+		return nil
+	case windows.ERROR_LOCK_VIOLATION, syscall.ERROR_IO_PENDING:
+		return ErrLocked
+	case windows.ERROR_NOT_SUPPORTED, windows.ERROR_CALL_NOT_IMPLEMENTED:
+		return errLockUnsupported
+	}
+	return &PathError{Op: "LockFileEx", Path: f.name, Err: err}
+}
+
+// processAlive reports whether a process with the given ID is running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process exists if we merely lack the right to open it.
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == windows.STILL_ACTIVE
+}
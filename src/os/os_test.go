@@ -2767,3 +2767,25 @@ func TestWriteStringAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocs for File.WriteString, got %v", allocs)
 	}
 }
+
+func TestWriteVec(t *testing.T) {
+	f := newFile("TestWriteVec", t)
+	defer Remove(f.Name())
+	defer f.Close()
+
+	bufs := [][]byte{[]byte("hello, "), nil, []byte("world"), []byte("\n")}
+	n, err := f.WriteVec(bufs)
+	if err != nil || n != 13 {
+		t.Fatalf("WriteVec = %d, %v; want 13, nil", n, err)
+	}
+	if string(bufs[0]) != "hello, " || string(bufs[2]) != "world" {
+		t.Errorf("WriteVec modified its argument: %q", bufs)
+	}
+	data, err := ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello, world\n" {
+		t.Errorf("file contains %q; want %q", data, "hello, world\n")
+	}
+}
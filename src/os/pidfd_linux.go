@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"internal/osexec"
+	"internal/poll"
+	"internal/syscall/unix"
+	"syscall"
+	"unsafe"
+)
+
+// pidfdOpen returns a pidfd referring to the child process pid, which
+// has not been waited for yet, so that its pid cannot have been reused.
+// It returns nil if pidfds are not supported.
+func pidfdOpen(pid int) *poll.FD {
+	fd, err := unix.PidFDOpen(pid, 0)
+	if err != nil {
+		// pidfd_open is available since Linux 5.3.
+		return nil
+	}
+	pfd := &poll.FD{Sysfd: fd}
+	if err := pfd.Init("pidfd", true); err != nil {
+		// Without the runtime poller, the pidfd
+		// would not save a thread in Wait.
+		syscall.Close(fd)
+		return nil
+	}
+	return pfd
+}
+
+func init() {
+	osexec.ProcessPidFD = func(p interface{}) int {
+		return processPidFD(p.(*Process))
+	}
+}
+
+// processPidFD returns the pidfd of p, or -1 if it has none.
+func processPidFD(p *Process) int {
+	if p.pidfd == nil {
+		return -1
+	}
+	return p.pidfd.Sysfd
+}
+
+// pidfdSignal sends sig to the process through its pidfd.
+func (p *Process) pidfdSignal(sig syscall.Signal) error {
+	var err error
+	if cerr := p.pidfd.RawControl(func(fd uintptr) {
+		err = unix.PidFDSendSignal(int(fd), sig)
+	}); cerr != nil {
+		// The pidfd is closed, so the process is reaped.
+		return syscall.ESRCH
+	}
+	return err
+}
+
+// pidfdWait blocks until the process has exited, waiting for its pidfd
+// to become readable with the runtime poller rather than blocking a
+// thread in waitid.
+func (p *Process) pidfdWait() error {
+	return p.pidfd.RawRead(func(uintptr) bool {
+		return p.exited()
+	})
+}
+
+// exited reports whether the process has exited, without waiting
+// for it. It also reports true if waitid fails, so that the caller
+// waits for the process and reports the error.
+func (p *Process) exited() bool {
+	// As in blockUntilWaitable. waitid leaves si_signo zero if
+	// the process has not exited yet.
+	var siginfo [16]uint64
+	psig := &siginfo[0]
+	for {
+		_, _, e := syscall.Syscall6(syscall.SYS_WAITID, _P_PID, uintptr(p.Pid), uintptr(unsafe.Pointer(psig)), syscall.WEXITED|syscall.WNOWAIT|syscall.WNOHANG, 0, 0)
+		if e == syscall.EINTR {
+			continue
+		}
+		return e != 0 || *(*int32)(unsafe.Pointer(psig)) != 0
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"internal/testenv"
+	. "os"
+	osexec "os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestProcessPidfd(t *testing.T) {
+	testenv.MustHaveExec(t)
+	sleep, err := osexec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+	p, err := StartProcess(sleep, []string{"sleep", "60"}, &ProcAttr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasPidfd(p) {
+		p.Kill()
+		p.Wait()
+		t.Skip("pidfds not supported")
+	}
+	if err := p.Signal(Kill); err != nil {
+		t.Fatalf("Signal: %v", err)
+	}
+	ps, err := p.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if ws := ps.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+		t.Errorf("wait status = %v; want killed", ps)
+	}
+	if err := p.Signal(Kill); err != ErrProcessDone {
+		t.Errorf("Signal after Wait = %v; want %v", err, ErrProcessDone)
+	}
+}
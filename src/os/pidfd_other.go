@@ -0,0 +1,17 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !plan9
+// +build !linux,!plan9
+
+package os
+
+import (
+	"internal/poll"
+	"syscall"
+)
+
+func pidfdOpen(pid int) *poll.FD { return nil }
+
+func (p *Process) pidfdSignal(sig syscall.Signal) error { return syscall.ENOSYS }
@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pty provides pseudo-terminals, through which programs such as
+// terminal emulators and remote shells run interactive commands.
+//
+// A pseudo-terminal is a pair of connected devices: the terminal, which
+// a command uses as its standard input, output and error, and as its
+// controlling terminal, as if it ran in a terminal window; and the
+// controller, through which another program reads what the command
+// writes to the terminal and writes what the command reads from it.
+// See pty(7). The os/exec package starts commands with pseudo-terminals
+// with Cmd.StartWithPTY.
+//
+// Pseudo-terminals are supported on Linux, and on Windows 10 version
+// 1809 or later, where they are pseudo consoles (ConPTY).
+package pty
+
+import "os"
+
+// A PTY is a pseudo-terminal.
+type PTY struct {
+	pty // system-specific
+}
+
+// Open allocates a new pseudo-terminal.
+func Open() (*PTY, error) {
+	return open()
+}
+
+// Read reads up to len(b) bytes of what was written to the terminal.
+// Once all the processes that used the terminal have closed it, Read
+// returns io.EOF.
+func (p *PTY) Read(b []byte) (n int, err error) {
+	return p.read(b)
+}
+
+// Write writes b as input to the terminal, as if typed on a keyboard.
+func (p *PTY) Write(b []byte) (n int, err error) {
+	return p.write(b)
+}
+
+// Resize sets the size of the terminal, in columns and rows of
+// characters. On Unix systems, the foreground process group of the
+// terminal receives a SIGWINCH signal.
+func (p *PTY) Resize(cols, rows int) error {
+	return p.resize(cols, rows)
+}
+
+// Master returns the controller of the pseudo-terminal, as a file for
+// functions that need one. On Windows, where pseudo consoles are driven
+// by a pair of pipes, it returns the pipe from which Read reads.
+func (p *PTY) Master() *os.File {
+	return p.master()
+}
+
+// TTY returns the terminal of the pseudo-terminal, which a command
+// started with it uses, on Unix systems. Once the command is started,
+// the caller must close it, so that Read returns io.EOF when the
+// command closes it too. TTY returns nil on Windows, where processes
+// are attached to pseudo consoles as they are created.
+func (p *PTY) TTY() *os.File {
+	return p.tty()
+}
+
+// Fd returns the system handle of the pseudo-terminal: the file
+// descriptor of the controller on Unix systems, and the handle of the
+// pseudo console on Windows, for syscall.SysProcAttr.PseudoConsole.
+func (p *PTY) Fd() uintptr {
+	return p.fd()
+}
+
+// Close closes the pseudo-terminal. On Windows, the processes attached
+// to the pseudo console are terminated, and Close may block until the
+// output of the pseudo console has been read.
+func (p *PTY) Close() error {
+	return p.close()
+}
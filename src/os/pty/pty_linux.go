@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pty
+
+import (
+	"errors"
+	"internal/itoa"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type pty struct {
+	m *os.File // controller
+	t *os.File // terminal
+}
+
+func open() (*PTY, error) {
+	// The controller is non-blocking, for the runtime poller.
+	fd, err := syscall.Open("/dev/ptmx", syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: "/dev/ptmx", Err: err}
+	}
+	var unlock int32
+	if err := ioctl(fd, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("ioctl", err)
+	}
+	var n uint32
+	if err := ioctl(fd, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("ioctl", err)
+	}
+	m := os.NewFile(uintptr(fd), "/dev/ptmx")
+	t, err := os.OpenFile("/dev/pts/"+itoa.Uitoa(uint(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	return &PTY{pty{m: m, t: t}}, nil
+}
+
+func ioctl(fd int, req uint, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(req), uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (p *pty) read(b []byte) (int, error) {
+	n, err := p.m.Read(b)
+	// Reading the controller fails with EIO once the terminal is
+	// closed by all the processes that opened it.
+	var pe *os.PathError
+	if errors.As(err, &pe) && pe.Err == syscall.EIO {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (p *pty) write(b []byte) (int, error) {
+	return p.m.Write(b)
+}
+
+type winsize struct {
+	row, col       uint16
+	xpixel, ypixel uint16
+}
+
+func (p *pty) resize(cols, rows int) error {
+	ws := winsize{row: uint16(rows), col: uint16(cols)}
+	rc, err := p.m.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ioctlErr error
+	if err := rc.Control(func(fd uintptr) {
+		ioctlErr = ioctl(int(fd), syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+	}); err != nil {
+		return err
+	}
+	return os.NewSyscallError("ioctl", ioctlErr)
+}
+
+func (p *pty) master() *os.File { return p.m }
+
+func (p *pty) tty() *os.File { return p.t }
+
+func (p *pty) fd() uintptr { return p.m.Fd() }
+
+func (p *pty) close() error {
+	p.t.Close() // may be closed already
+	return p.m.Close()
+}
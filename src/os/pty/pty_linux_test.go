@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pty_test
+
+import (
+	"io"
+	"os/pty"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestPTY(t *testing.T) {
+	p, err := pty.Open()
+	if err != nil {
+		t.Skipf("Open: %v", err)
+	}
+	defer p.Close()
+	tty := p.TTY()
+
+	if _, err := io.WriteString(tty, "output"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len("output"))
+	if _, err := io.ReadFull(p, buf); err != nil || string(buf) != "output" {
+		t.Fatalf("read %q, %v from the controller, want %q, nil", buf, err, "output")
+	}
+
+	if err := p.Resize(132, 43); err != nil {
+		t.Fatal(err)
+	}
+	var ws struct{ row, col, x, y uint16 }
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 {
+		t.Fatal(errno)
+	}
+	if ws.col != 132 || ws.row != 43 {
+		t.Errorf("terminal size is %dx%d, want 132x43", ws.col, ws.row)
+	}
+
+	// Once the terminal is closed, reads report EOF.
+	tty.Close()
+	if n, err := p.Read(buf); err != io.EOF {
+		t.Errorf("Read after closing the terminal = %d, %v, want 0, EOF", n, err)
+	}
+}
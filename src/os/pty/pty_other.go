@@ -0,0 +1,29 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package pty
+
+import (
+	"errors"
+	"os"
+)
+
+type pty struct{}
+
+var errUnsupported = errors.New("pty: pseudo-terminals are not supported on this system")
+
+func open() (*PTY, error) {
+	return nil, errUnsupported
+}
+
+func (p *pty) read(b []byte) (int, error)  { return 0, errUnsupported }
+func (p *pty) write(b []byte) (int, error) { return 0, errUnsupported }
+func (p *pty) resize(cols, rows int) error { return errUnsupported }
+func (p *pty) master() *os.File            { return nil }
+func (p *pty) tty() *os.File               { return nil }
+func (p *pty) fd() uintptr                 { return ^uintptr(0) }
+func (p *pty) close() error                { return errUnsupported }
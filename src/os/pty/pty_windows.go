@@ -0,0 +1,83 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pty
+
+import (
+	"errors"
+	"internal/syscall/windows"
+	"os"
+	"sync"
+	"syscall"
+)
+
+type pty struct {
+	console syscall.Handle
+	in      *os.File // write end of the input pipe of the console
+	out     *os.File // read end of the output pipe of the console
+
+	closeOnce sync.Once
+}
+
+// coord returns a COORD structure, as passed by value.
+func coord(cols, rows int) uint32 {
+	return uint32(uint16(cols)) | uint32(uint16(rows))<<16
+}
+
+func open() (*PTY, error) {
+	if err := windows.LoadPseudoConsole(); err != nil {
+		return nil, errors.New("pty: pseudo consoles require Windows 10 version 1809 or later")
+	}
+	var inR, inW, outR, outW syscall.Handle
+	if err := syscall.CreatePipe(&inR, &inW, nil, 0); err != nil {
+		return nil, os.NewSyscallError("CreatePipe", err)
+	}
+	if err := syscall.CreatePipe(&outR, &outW, nil, 0); err != nil {
+		syscall.CloseHandle(inR)
+		syscall.CloseHandle(inW)
+		return nil, os.NewSyscallError("CreatePipe", err)
+	}
+	var console syscall.Handle
+	err := windows.CreatePseudoConsole(coord(80, 25), inR, outW, 0, &console)
+	// The pseudo console holds its own references to its ends of the pipes.
+	syscall.CloseHandle(inR)
+	syscall.CloseHandle(outW)
+	if err != nil {
+		syscall.CloseHandle(inW)
+		syscall.CloseHandle(outR)
+		return nil, os.NewSyscallError("CreatePseudoConsole", err)
+	}
+	return &PTY{pty{
+		console: console,
+		in:      os.NewFile(uintptr(inW), "|0"),
+		out:     os.NewFile(uintptr(outR), "|1"),
+	}}, nil
+}
+
+func (p *pty) read(b []byte) (int, error) {
+	return p.out.Read(b)
+}
+
+func (p *pty) write(b []byte) (int, error) {
+	return p.in.Write(b)
+}
+
+func (p *pty) resize(cols, rows int) error {
+	return os.NewSyscallError("ResizePseudoConsole", windows.ResizePseudoConsole(p.console, coord(cols, rows)))
+}
+
+func (p *pty) master() *os.File { return p.out }
+
+func (p *pty) tty() *os.File { return nil }
+
+func (p *pty) fd() uintptr { return uintptr(p.console) }
+
+func (p *pty) close() error {
+	err := p.in.Close()
+	p.closeOnce.Do(func() { windows.ClosePseudoConsole(p.console) })
+	if err1 := p.out.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
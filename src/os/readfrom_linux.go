@@ -7,9 +7,14 @@ package os
 import (
 	"internal/poll"
 	"io"
+	"runtime"
+	"syscall"
 )
 
-var pollCopyFileRange = poll.CopyFileRange
+var (
+	pollCopyFileRange = poll.CopyFileRange
+	pollSplice        = poll.Splice
+)
 
 func (f *File) readFrom(r io.Reader) (written int64, handled bool, err error) {
 	// copy_file_range(2) does not support destinations opened with
@@ -27,10 +32,15 @@ func (f *File) readFrom(r io.Reader) (written int64, handled bool, err error) {
 			return 0, true, nil
 		}
 	}
+	r = io.UnwrapReader(r)
 
 	src, ok := r.(*File)
 	if !ok {
-		return 0, false, nil
+		written, handled, err = f.spliceFrom(r, remain)
+		if lr != nil {
+			lr.N -= written
+		}
+		return written, handled, err
 	}
 	if src.checkValid("ReadFrom") != nil {
 		// Avoid returning the error as we report handled as false,
@@ -44,3 +54,59 @@ func (f *File) readFrom(r io.Reader) (written int64, handled bool, err error) {
 	}
 	return written, handled, NewSyscallError("copy_file_range", err)
 }
+
+// spliceFrom transfers at most remain bytes from r to f with splice(2),
+// if f is a pipe registered with the runtime poller and r is a
+// stream-oriented network connection, whose syscall.RawConn gives
+// access to its poll.FD.
+func (f *File) spliceFrom(r io.Reader, remain int64) (written int64, handled bool, err error) {
+	// A blocking pipe, such as an inherited standard output,
+	// cannot be waited for by poll.Splice.
+	if !f.pfd.Pollable() {
+		return 0, false, nil
+	}
+	pfd := pollFD(r)
+	if pfd == nil {
+		return 0, false, nil
+	}
+	if fi, err := f.Stat(); err != nil || fi.Mode()&ModeNamedPipe == 0 {
+		return 0, false, nil
+	}
+	written, handled, sc, err := pollSplice(&f.pfd, pfd, remain)
+	runtime.KeepAlive(r)
+	if ce, ok := err.(*io.CopyError); ok {
+		err = &io.CopyError{Op: ce.Op, Err: NewSyscallError(sc, ce.Err)}
+	} else {
+		err = NewSyscallError(sc, err)
+	}
+	return written, handled, err
+}
+
+// pollFD returns the poll.FD of r, if r is a syscall.Conn whose
+// syscall.RawConn has a PollFD method, as those of package net have
+// for stream-oriented connections, or nil.
+func pollFD(r io.Reader) *poll.FD {
+	sc, ok := r.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	p, ok := rc.(interface{ PollFD() *poll.FD })
+	if !ok {
+		return nil
+	}
+	return p.PollFD()
+}
+
+// PollFD returns the poll.FD of the file, for the splice paths of
+// package net, or nil if the file is not registered with the runtime
+// poller, which splice needs to wait for it.
+func (c *rawConn) PollFD() *poll.FD {
+	if c.file.checkValid("SyscallConn") != nil || !c.file.pfd.Pollable() {
+		return nil
+	}
+	return &c.file.pfd
+}
@@ -9,6 +9,7 @@ import (
 	"internal/poll"
 	"io"
 	"math/rand"
+	"net"
 	"os"
 	. "os"
 	"path/filepath"
@@ -362,6 +363,27 @@ func (h *copyFileRangeHook) uninstall() {
 	*PollCopyFileRangeP = h.original
 }
 
+// passThrough wraps a File and declares that it passes data through.
+type passThrough struct {
+	f *File
+}
+
+func (p passThrough) Read(b []byte) (int, error) { return p.f.Read(b) }
+func (p passThrough) UnwrapReader() io.Reader    { return p.f }
+
+func TestCopyFileRangeUnwrapsReader(t *testing.T) {
+	dst, src, data, hook := newCopyFileRangeTest(t, 1024)
+	n, err := io.Copy(dst, &io.LimitedReader{R: passThrough{src}, N: 1024})
+	if err != nil || n != 1024 {
+		t.Fatalf("Copy = %d, %v; want 1024, nil", n, err)
+	}
+	if !hook.called || hook.srcfd != int(src.Fd()) {
+		t.Fatalf("copy_file_range was not used with the wrapped file (called %v, srcfd %d)", hook.called, hook.srcfd)
+	}
+	mustSeekStart(t, dst)
+	mustContainData(t, dst, data)
+}
+
 // On some kernels copy_file_range fails on files in /proc.
 func TestProcCopy(t *testing.T) {
 	const cmdlineFile = "/proc/self/cmdline"
@@ -393,3 +415,120 @@ func TestProcCopy(t *testing.T) {
 		t.Errorf("copy of %q got %q want %q\n", cmdlineFile, copy, cmdline)
 	}
 }
+
+func TestReadFromSpliceFromConn(t *testing.T) {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	msg := "hello, world"
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		io.WriteString(c, msg)
+		c.Close()
+	}()
+	conn, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pr, pw, err := Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	hook := spliceHook{}
+	hook.install()
+	defer hook.uninstall()
+
+	n, err := pw.ReadFrom(conn)
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("ReadFrom = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	if !hook.called {
+		t.Error("ReadFrom did not use splice")
+	}
+	pw.Close()
+	got, err := io.ReadAll(pr)
+	if err != nil || string(got) != msg {
+		t.Errorf("pipe holds %q, %v, want %q, nil", got, err, msg)
+	}
+}
+
+type spliceHook struct {
+	called bool
+
+	original func(dst, src *poll.FD, remain int64) (int64, bool, string, error)
+}
+
+func (h *spliceHook) install() {
+	h.original = *PollSpliceP
+	*PollSpliceP = func(dst, src *poll.FD, remain int64) (int64, bool, string, error) {
+		h.called = true
+		return h.original(dst, src, remain)
+	}
+}
+
+func (h *spliceHook) uninstall() {
+	*PollSpliceP = h.original
+}
+
+func TestReadFromSpliceFromConnBlockingPipe(t *testing.T) {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	msg := "hello, world"
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		io.WriteString(c, msg)
+		c.Close()
+	}()
+	conn, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A pipe not registered with the poller, like an inherited
+	// standard output, must not take the splice path.
+	var p [2]int
+	if err := syscall.Pipe2(p[:], syscall.O_CLOEXEC); err != nil {
+		t.Fatal(err)
+	}
+	pr, pw := NewFile(uintptr(p[0]), "|0"), NewFile(uintptr(p[1]), "|1")
+	defer pr.Close()
+	defer pw.Close()
+
+	hook := spliceHook{}
+	hook.install()
+	defer hook.uninstall()
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(pr)
+		done <- b
+	}()
+	n, err := pw.ReadFrom(conn)
+	pw.Close()
+	if err != nil || n != int64(len(msg)) {
+		t.Fatalf("ReadFrom = %d, %v, want %d, nil", n, err, len(msg))
+	}
+	if hook.called {
+		t.Error("ReadFrom used splice for a blocking pipe")
+	}
+	if got := <-done; string(got) != msg {
+		t.Errorf("pipe holds %q, want %q", got, msg)
+	}
+}
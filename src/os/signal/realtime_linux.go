@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package signal
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// The realtime signals available to programs. The C libraries reserve
+// signals 32 to 34, which the runtime does not relay.
+const (
+	sigRTMin = 35
+	sigRTMax = 64
+)
+
+// Realtime returns the n'th POSIX realtime signal available to the
+// program, counting from 0. Realtime signals have no predefined meaning,
+// and the system queues their occurrences along with a value; they are
+// best received with a Stream.
+//
+// On Linux, Realtime(n) is signal 35+n, and n may be at most 29. As the
+// C libraries reserve some of the first realtime signals, this is
+// SIGRTMIN+n with musl but SIGRTMIN+1+n with glibc. Realtime signals are
+// not supported on other systems.
+func Realtime(n int) (os.Signal, error) {
+	if n < 0 || n > sigRTMax-sigRTMin {
+		return nil, errors.New("os/signal: realtime signal out of range")
+	}
+	return syscall.Signal(sigRTMin + n), nil
+}
@@ -0,0 +1,19 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package signal
+
+import (
+	"errors"
+	"os"
+)
+
+// Realtime returns the n'th POSIX realtime signal available to the
+// program. Realtime signals are only supported on Linux.
+func Realtime(n int) (os.Signal, error) {
+	return nil, errors.New("os/signal: realtime signals not supported on this system")
+}
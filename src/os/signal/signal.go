@@ -8,29 +8,40 @@ import (
 	"context"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var handlers struct {
 	sync.Mutex
 	// Map a channel to the signals that should be sent to it.
 	m map[chan<- os.Signal]*handler
+	// Map a channel passed to NotifyInfo to the signals that
+	// should be sent to it.
+	info map[chan<- Info]*handler
+	// Map a Stream to the signals that should be sent to it.
+	streams map[*Stream]*handler
 	// Map a signal to the number of channels receiving it.
 	ref [numSig]int64
-	// Map channels to signals while the channel is being stopped.
+	// Map a signal to the number of Streams receiving it,
+	// for which the runtime queues its occurrences.
+	qref [numSig]int64
+	// Handlers of channels while the channel is being stopped.
 	// Not a map because entries live here only very briefly.
 	// We need a separate container because we need m to correspond to ref
 	// at all times, and we also need to keep track of the *handler
 	// value for a channel being stopped. See the Stop function.
-	stopping []stopping
-}
-
-type stopping struct {
-	c chan<- os.Signal
-	h *handler
+	stopping []*handler
 }
 
 type handler struct {
 	mask [(numSig + 31) / 32]uint32
+
+	// The channel to send to: c for Notify, ci for NotifyInfo,
+	// or that of s for a Stream.
+	c  chan<- os.Signal
+	ci chan<- Info
+	s  *Stream
 }
 
 func (h *handler) want(sig int) bool {
@@ -45,6 +56,29 @@ func (h *handler) clear(sig int) {
 	h.mask[sig/32] &^= 1 << uint(sig&31)
 }
 
+// send sends info to the channel of h, but does not block for it.
+func (h *handler) send(info *Info) {
+	if h.s != nil {
+		select {
+		case h.s.c <- *info:
+		default:
+			atomic.AddUint64(&h.s.dropped, 1)
+		}
+		return
+	}
+	if h.ci != nil {
+		select {
+		case h.ci <- *info:
+		default:
+		}
+		return
+	}
+	select {
+	case h.c <- info.Signal:
+	default:
+	}
+}
+
 // Stop relaying the signals, sigs, to any channels previously registered to
 // receive them and either reset the signal handlers to their original values
 // (action=disableSignal) or ignore the signals (action=ignoreSignal).
@@ -64,6 +98,28 @@ func cancel(sigs []os.Signal, action func(int)) {
 				}
 			}
 		}
+		for c, h := range handlers.info {
+			if h.want(n) {
+				handlers.ref[n]--
+				h.clear(n)
+				if h.mask == zerohandler.mask {
+					delete(handlers.info, c)
+				}
+			}
+		}
+		for s, h := range handlers.streams {
+			if h.want(n) {
+				handlers.ref[n]--
+				handlers.qref[n]--
+				if handlers.qref[n] == 0 {
+					disableQueued(n)
+				}
+				h.clear(n)
+				if h.mask == zerohandler.mask {
+					delete(handlers.streams, s)
+				}
+			}
+		}
 
 		action(n)
 	}
@@ -131,16 +187,145 @@ func Notify(c chan<- os.Signal, sig ...os.Signal) {
 		if handlers.m == nil {
 			handlers.m = make(map[chan<- os.Signal]*handler)
 		}
-		h = new(handler)
+		h = &handler{c: c}
 		handlers.m[c] = h
 	}
+	h.add(sig)
+}
 
+// Info describes an occurrence of a signal, as relayed by NotifyInfo.
+//
+// Like the signal values sent by Notify, occurrences of a signal that
+// arrive before the previous one is relayed are coalesced; Info then
+// describes the most recent of them. The details other than Signal are
+// only reported on Unix systems, and Pid, Uid, Status and Value only on
+// Linux.
+type Info struct {
+	Signal os.Signal
+
+	// Code is the si_code value giving the cause of the signal,
+	// such as SI_USER for a signal sent with kill(2).
+	Code int
+
+	// Pid and Uid identify the process that sent the signal, with
+	// kill(2), sigqueue(3) or tgkill(2), or, for SIGCHLD, the child
+	// whose state changed. Pid is 0 if the sender is not known,
+	// for example for signals raised by the kernel.
+	Pid int
+	Uid int
+
+	// Status is, for SIGCHLD, the exit status of the child, or the
+	// signal that stopped or killed it, depending on Code.
+	Status int
+
+	// Value is the value sent with a signal queued with sigqueue(3)
+	// or by a message queue.
+	Value uintptr
+}
+
+// NotifyInfo is like Notify, but relays to c the details of each
+// incoming signal, as far as the system reports them.
+// Channels passed to NotifyInfo are stopped with StopInfo.
+func NotifyInfo(c chan<- Info, sig ...os.Signal) {
+	if c == nil {
+		panic("os/signal: NotifyInfo using nil channel")
+	}
+
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	h := handlers.info[c]
+	if h == nil {
+		if handlers.info == nil {
+			handlers.info = make(map[chan<- Info]*handler)
+		}
+		h = &handler{ci: c}
+		handlers.info[c] = h
+	}
+	h.add(sig)
+}
+
+// A Stream relays each occurrence of a set of signals, in the order in
+// which they arrive, with its details as for NotifyInfo.
+//
+// Unlike Notify and NotifyInfo, which coalesce the occurrences of a
+// signal that arrive before the previous one is relayed, the runtime
+// queues the occurrences of the signals of a Stream. This matters for
+// the POSIX realtime signals (see Realtime), which the system queues
+// too, along with the value given to sigqueue(3), reported in
+// Info.Value. Standard signals may still be coalesced by the system.
+//
+// An occurrence is dropped, rather than blocking the program, if the
+// buffer of C is full or if the runtime queue, shared by all Streams,
+// is. Dropped reports how many were. Streams are not supported on
+// Plan 9, where they receive nothing.
+type Stream struct {
+	C <-chan Info // The channel on which the occurrences are delivered.
+
+	c       chan Info
+	dropped uint64 // accessed atomically
+}
+
+// NewStream returns a new Stream relaying the signals sig, or all
+// signals if sig is empty, on a channel with a buffer of size
+// occurrences. It panics if size is not positive.
+func NewStream(size int, sig ...os.Signal) *Stream {
+	if size <= 0 {
+		panic("os/signal: non-positive size for NewStream")
+	}
+	c := make(chan Info, size)
+	s := &Stream{C: c, c: c}
+
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	if handlers.streams == nil {
+		handlers.streams = make(map[*Stream]*handler)
+	}
+	h := &handler{s: s}
+	handlers.streams[s] = h
+	h.add(sig)
+	return s
+}
+
+// Dropped returns the number of occurrences of the signals of s that
+// were dropped so far.
+func (s *Stream) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Stop causes package signal to stop relaying signals to s.
+// C is not closed. When Stop returns, it is guaranteed that C will
+// receive no more occurrences.
+func (s *Stream) Stop() {
+	handlers.Lock()
+
+	h := handlers.streams[s]
+	if h == nil {
+		handlers.Unlock()
+		return
+	}
+	delete(handlers.streams, s)
+	h.stop()
+}
+
+// add adds sig, or all signals if sig is empty, to the signals relayed
+// to the channel of h. handlers must be locked.
+func (h *handler) add(sig []os.Signal) {
 	add := func(n int) {
 		if n < 0 {
 			return
 		}
 		if !h.want(n) {
 			h.set(n)
+			if h.s != nil {
+				// Queue the occurrences before the signal
+				// is enabled, so that none is missed.
+				if handlers.qref[n] == 0 {
+					enableQueued(n)
+				}
+				handlers.qref[n]++
+			}
 			if handlers.ref[n] == 0 {
 				enableSignal(n)
 
@@ -186,13 +371,39 @@ func Stop(c chan<- os.Signal) {
 		return
 	}
 	delete(handlers.m, c)
+	h.stop()
+}
 
+// StopInfo causes package signal to stop relaying incoming signals to c,
+// a channel passed to NotifyInfo. It is otherwise like Stop.
+func StopInfo(c chan<- Info) {
+	handlers.Lock()
+
+	h := handlers.info[c]
+	if h == nil {
+		handlers.Unlock()
+		return
+	}
+	delete(handlers.info, c)
+	h.stop()
+}
+
+// stop stops relaying signals to the channel of h, which was just
+// removed from the handlers. It is called with handlers locked, and
+// unlocks it.
+func (h *handler) stop() {
 	for n := 0; n < numSig; n++ {
 		if h.want(n) {
 			handlers.ref[n]--
 			if handlers.ref[n] == 0 {
 				disableSignal(n)
 			}
+			if h.s != nil {
+				handlers.qref[n]--
+				if handlers.qref[n] == 0 {
+					disableQueued(n)
+				}
+			}
 		}
 	}
 
@@ -207,7 +418,7 @@ func Stop(c chan<- os.Signal) {
 	// channels being stopped and wait for signal delivery to
 	// quiesce before fully removing it.
 
-	handlers.stopping = append(handlers.stopping, stopping{c, h})
+	handlers.stopping = append(handlers.stopping, h)
 
 	handlers.Unlock()
 
@@ -216,7 +427,7 @@ func Stop(c chan<- os.Signal) {
 	handlers.Lock()
 
 	for i, s := range handlers.stopping {
-		if s.c == c {
+		if s == h {
 			handlers.stopping = append(handlers.stopping[:i], handlers.stopping[i+1:]...)
 			break
 		}
@@ -229,8 +440,10 @@ func Stop(c chan<- os.Signal) {
 // Defined by the runtime package.
 func signalWaitUntilIdle()
 
-func process(sig os.Signal) {
-	n := signum(sig)
+// process relays the signal described by info to the channels
+// that want it.
+func process(info *Info) {
+	n := signum(info.Signal)
 	if n < 0 {
 		return
 	}
@@ -238,23 +451,57 @@ func process(sig os.Signal) {
 	handlers.Lock()
 	defer handlers.Unlock()
 
-	for c, h := range handlers.m {
+	for _, h := range handlers.m {
 		if h.want(n) {
-			// send but do not block for it
-			select {
-			case c <- sig:
-			default:
-			}
+			h.send(info)
+		}
+	}
+	for _, h := range handlers.info {
+		if h.want(n) {
+			h.send(info)
 		}
 	}
 
 	// Avoid the race mentioned in Stop.
-	for _, d := range handlers.stopping {
-		if d.h.want(n) {
-			select {
-			case d.c <- sig:
-			default:
-			}
+	for _, h := range handlers.stopping {
+		if h.s == nil && h.want(n) {
+			h.send(info)
+		}
+	}
+}
+
+// processQueued relays the queued occurrence of a signal described by
+// info to the Streams that want it.
+func processQueued(info *Info) {
+	n := signum(info.Signal)
+	if n < 0 {
+		return
+	}
+
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	for _, h := range handlers.streams {
+		if h.want(n) {
+			h.send(info)
+		}
+	}
+	for _, h := range handlers.stopping {
+		if h.s != nil && h.want(n) {
+			h.send(info)
+		}
+	}
+}
+
+// processDropped counts d occurrences of signal n, which the runtime
+// could not queue, as dropped by the Streams that want n.
+func processDropped(n int, d uint64) {
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	for _, h := range handlers.streams {
+		if h.want(n) {
+			atomic.AddUint64(&h.s.dropped, d)
 		}
 	}
 }
@@ -274,20 +521,15 @@ func process(sig os.Signal) {
 // The stop function releases resources associated with it, so code should
 // call stop as soon as the operations running in this Context complete and
 // signals no longer need to be diverted to the context.
+//
+// The signal that marked the context done is reported by ContextSignal.
 func NotifyContext(parent context.Context, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
-	ctx, cancel := context.WithCancel(parent)
-	c := &signalCtx{
-		Context: ctx,
-		cancel:  cancel,
-		signals: signals,
-	}
-	c.ch = make(chan os.Signal, 1)
-	Notify(c.ch, c.signals...)
-	if ctx.Err() == nil {
+	c := newSignalCtx(parent, "NotifyContext", signals)
+	if c.Err() == nil {
 		go func() {
 			select {
-			case <-c.ch:
-				c.cancel()
+			case s := <-c.ch:
+				c.fire(s)
 			case <-c.Done():
 			}
 		}()
@@ -295,17 +537,128 @@ func NotifyContext(parent context.Context, signals ...os.Signal) (ctx context.Co
 	return c, c.stop
 }
 
+// NotifyShutdown is like NotifyContext, but for the two-stage shutdown
+// of a program: the first of the listed signals to arrive marks the
+// returned context done, so that the program can shut down gracefully,
+// and the program then exits with status 1 if another of the signals
+// arrives, or if grace is positive and stop has not been called within
+// grace of the first signal. Once stop is called, signals no longer
+// cause the program to exit.
+//
+// For example, a server may stop accepting requests and finish the
+// ones in progress when it receives os.Interrupt, and still be stopped
+// at once by a second interrupt if that takes too long.
+func NotifyShutdown(parent context.Context, grace time.Duration, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	c := newSignalCtx(parent, "NotifyShutdown", signals)
+	go c.escalate(grace)
+	return c, c.stop
+}
+
+// ContextSignal returns the signal that marked ctx, or a context that
+// ctx is derived from, done, if that context was returned by
+// NotifyContext or NotifyShutdown. Otherwise, it returns nil.
+func ContextSignal(ctx context.Context) os.Signal {
+	s, _ := ctx.Value(signalCtxKey{}).(os.Signal)
+	return s
+}
+
+// exit is os.Exit, replaced by tests of NotifyShutdown.
+var exit = os.Exit
+
 type signalCtx struct {
 	context.Context
 
 	cancel  context.CancelFunc
+	name    string
 	signals []os.Signal
 	ch      chan os.Signal
+
+	stopOnce sync.Once
+	stopped  chan struct{} // closed by stop, with mu held
+
+	mu  sync.Mutex
+	sig os.Signal // the signal that marked the context done
+}
+
+// signalCtxKey is the key for which signalCtx holds the signal that
+// marked it done.
+type signalCtxKey struct{}
+
+func newSignalCtx(parent context.Context, name string, signals []os.Signal) *signalCtx {
+	ctx, cancel := context.WithCancel(parent)
+	c := &signalCtx{
+		Context: ctx,
+		cancel:  cancel,
+		name:    name,
+		signals: signals,
+		stopped: make(chan struct{}),
+	}
+	c.ch = make(chan os.Signal, 1)
+	Notify(c.ch, c.signals...)
+	return c
 }
 
 func (c *signalCtx) stop() {
 	c.cancel()
 	Stop(c.ch)
+	c.mu.Lock()
+	c.stopOnce.Do(func() { close(c.stopped) })
+	c.mu.Unlock()
+}
+
+// fire marks c done because of signal s.
+func (c *signalCtx) fire(s os.Signal) {
+	c.mu.Lock()
+	if c.Err() == nil {
+		c.sig = s
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+// escalate marks c done on the first signal and exits on the second,
+// or grace after the first, unless c is stopped.
+func (c *signalCtx) escalate(grace time.Duration) {
+	select {
+	case s := <-c.ch:
+		c.fire(s)
+	case <-c.stopped:
+		return
+	}
+
+	var deadline <-chan time.Time
+	if grace > 0 {
+		t := time.NewTimer(grace)
+		defer t.Stop()
+		deadline = t.C
+	}
+	select {
+	case <-c.ch:
+	case <-deadline:
+	case <-c.stopped:
+		return
+	}
+
+	// Do not exit if stop is called meanwhile.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.stopped:
+	default:
+		exit(1)
+	}
+}
+
+func (c *signalCtx) Value(key interface{}) interface{} {
+	if key == (signalCtxKey{}) {
+		c.mu.Lock()
+		s := c.sig
+		c.mu.Unlock()
+		if s != nil {
+			return s
+		}
+	}
+	return c.Context.Value(key)
 }
 
 type stringer interface {
@@ -318,7 +671,7 @@ func (c *signalCtx) String() string {
 	// String method of cancelCtx returns a string that ends with ".WithCancel".
 	name := c.Context.(stringer).String()
 	name = name[:len(name)-len(".WithCancel")]
-	buf = append(buf, "signal.NotifyContext("+name...)
+	buf = append(buf, "signal."+c.name+"("+name...)
 	if len(c.signals) != 0 {
 		buf = append(buf, ", ["...)
 		for i, s := range c.signals {
@@ -9,9 +9,11 @@ package signal
 
 import (
 	"os"
+	"runtime"
 	"syscall"
 	"testing"
 	"time"
+	"unsafe"
 )
 
 const prSetKeepCaps = 8
@@ -41,3 +43,147 @@ func TestAllThreadsSyscallSignals(t *testing.T) {
 	}
 	Stop(sig)
 }
+
+func TestNotifyInfo(t *testing.T) {
+	c := make(chan Info, 1)
+	NotifyInfo(c, syscall.SIGUSR1)
+	defer StopInfo(c)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case info := <-c:
+		want := Info{Signal: syscall.SIGUSR1, Pid: os.Getpid(), Uid: os.Getuid()}
+		if info != want {
+			t.Errorf("got %+v, want %+v", info, want)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for SIGUSR1")
+	}
+
+	StopInfo(c)
+	StopInfo(c) // stopping twice is harmless
+	select {
+	case info := <-c:
+		t.Errorf("unexpected signal after StopInfo: %+v", info)
+	default:
+	}
+}
+
+func TestNotifyInfoSIGCHLD(t *testing.T) {
+	c := make(chan Info, 1)
+	NotifyInfo(c, syscall.SIGCHLD)
+	defer StopInfo(c)
+
+	pid, err := syscall.ForkExec("/bin/sh", []string{"sh", "-c", "exit 3"}, nil)
+	if err != nil {
+		t.Skip(err)
+	}
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &ws, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	const _CLD_EXITED = 1
+	select {
+	case info := <-c:
+		if info.Pid != pid || info.Code != _CLD_EXITED || info.Status != 3 {
+			t.Errorf("got %+v, want Pid %d, Code %d and Status 3", info, pid, _CLD_EXITED)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for SIGCHLD")
+	}
+}
+
+// sigqueue sends sig to the process with value, like sigqueue(3).
+func sigqueue(sig os.Signal, value uintptr) error {
+	const _SI_QUEUE = -1
+	var info [128]byte
+	codeOff, unionOff := 8, 16
+	if runtime.GOARCH == "mips" || runtime.GOARCH == "mipsle" || runtime.GOARCH == "mips64" || runtime.GOARCH == "mips64le" {
+		codeOff = 4 // si_code and si_errno are swapped
+	}
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		unionOff = 12
+	}
+	*(*int32)(unsafe.Pointer(&info[0])) = int32(sig.(syscall.Signal))
+	*(*int32)(unsafe.Pointer(&info[codeOff])) = _SI_QUEUE
+	*(*int32)(unsafe.Pointer(&info[unionOff])) = int32(os.Getpid())
+	*(*uint32)(unsafe.Pointer(&info[unionOff+4])) = uint32(os.Getuid())
+	*(*uintptr)(unsafe.Pointer(&info[unionOff+8])) = value
+	_, _, errno := syscall.Syscall(syscall.SYS_RT_SIGQUEUEINFO, uintptr(os.Getpid()), uintptr(sig.(syscall.Signal)), uintptr(unsafe.Pointer(&info)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func TestStreamRealtime(t *testing.T) {
+	sig, err := Realtime(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Realtime(30); err == nil {
+		t.Errorf("Realtime(30) succeeded")
+	}
+
+	const n = 5
+	s := NewStream(n, sig)
+	defer s.Stop()
+
+	for i := 1; i <= n; i++ {
+		if err := sigqueue(sig, uintptr(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= n; i++ {
+		select {
+		case info := <-s.C:
+			want := Info{Signal: sig, Code: -1, Pid: os.Getpid(), Uid: os.Getuid(), Value: uintptr(i)}
+			if info != want {
+				t.Errorf("got %+v, want %+v", info, want)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for occurrence %d", i)
+		}
+	}
+	if d := s.Dropped(); d != 0 {
+		t.Errorf("Dropped() = %d, want 0", d)
+	}
+}
+
+func TestStreamDropped(t *testing.T) {
+	sig, err := Realtime(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStream(1, sig)
+	defer s.Stop()
+
+	const n = 4
+	for i := 0; i < n; i++ {
+		if err := sigqueue(sig, uintptr(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Dropped() < n-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Dropped() = %d, want %d", s.Dropped(), n-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if info := <-s.C; info.Value != 0 {
+		t.Errorf("got value %d, want the first occurrence", info.Value)
+	}
+
+	s.Stop()
+	if err := sigqueue(sig, 0); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case info := <-s.C:
+		t.Errorf("unexpected signal after Stop: %+v", info)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -24,7 +24,7 @@ func init() {
 
 func loop() {
 	for {
-		process(syscall.Note(signal_recv()))
+		process(&Info{Signal: syscall.Note(signal_recv())})
 	}
 }
 
@@ -55,6 +55,10 @@ func disableSignal(sig int) {
 	signal_disable(uint32(sig))
 }
 
+// Notes are not queued on Plan 9, so Streams receive none.
+func enableQueued(sig int)  {}
+func disableQueued(sig int) {}
+
 func ignoreSignal(sig int) {
 	signal_ignore(uint32(sig))
 }
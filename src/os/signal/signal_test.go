@@ -869,6 +869,110 @@ func TestNotifyContextStringer(t *testing.T) {
 	}
 }
 
+func TestNotifyContextSignal(t *testing.T) {
+	c, stop := NotifyContext(context.Background(), syscall.SIGHUP)
+	defer stop()
+	child, cancel := context.WithCancel(c)
+	defer cancel()
+
+	if s := ContextSignal(child); s != nil {
+		t.Errorf("ContextSignal before the signal = %v, want nil", s)
+	}
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	select {
+	case <-child.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for SIGHUP")
+	}
+	if got := c.Err(); got != context.Canceled {
+		t.Errorf("c.Err() = %q, want %q", got, context.Canceled)
+	}
+	if s := ContextSignal(child); s != syscall.SIGHUP {
+		t.Errorf("ContextSignal = %v, want %v", s, syscall.SIGHUP)
+	}
+	if s := ContextSignal(context.Background()); s != nil {
+		t.Errorf("ContextSignal of a background context = %v, want nil", s)
+	}
+}
+
+// fakeExit replaces the exit function of NotifyShutdown with one that
+// sends the exit status on the returned channel.
+func fakeExit(t *testing.T) <-chan int {
+	c := make(chan int, 1)
+	exit = func(code int) { c <- code }
+	t.Cleanup(func() { exit = os.Exit })
+	return c
+}
+
+func TestNotifyShutdown(t *testing.T) {
+	exited := fakeExit(t)
+	c, stop := NotifyShutdown(context.Background(), 0, syscall.SIGHUP)
+	defer stop()
+
+	if want, got := "signal.NotifyShutdown(context.Background, [hangup])", fmt.Sprint(c); want != got {
+		t.Errorf("c.String() = %q, want %q", got, want)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	select {
+	case <-c.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the first SIGHUP")
+	}
+	if s := ContextSignal(c); s != syscall.SIGHUP {
+		t.Errorf("ContextSignal = %v, want %v", s, syscall.SIGHUP)
+	}
+	select {
+	case code := <-exited:
+		t.Fatalf("exited with status %d after the first SIGHUP", code)
+	case <-time.After(settleTime):
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exited with status %d, want 1", code)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the exit after the second SIGHUP")
+	}
+}
+
+func TestNotifyShutdownGrace(t *testing.T) {
+	exited := fakeExit(t)
+
+	c, stop := NotifyShutdown(context.Background(), time.Millisecond, syscall.SIGHUP)
+	defer stop()
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	select {
+	case <-exited:
+		if c.Err() == nil {
+			t.Errorf("exited before the context was done")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the exit after the grace period")
+	}
+	stop()
+
+	// Once stop is called, the program does not exit.
+	c, stop = NotifyShutdown(context.Background(), time.Millisecond, syscall.SIGHUP)
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	<-c.Done()
+	stop()
+	select {
+	case code := <-exited:
+		// The grace period may have ended before stop was called.
+		t.Logf("exited with status %d", code)
+	default:
+	}
+	select {
+	case code := <-exited:
+		t.Errorf("exited with status %d after stop", code)
+	case <-time.After(settleTime):
+	}
+}
+
 // #44193 test signal handling while stopping and starting the world.
 func TestSignalTrace(t *testing.T) {
 	done := make(chan struct{})
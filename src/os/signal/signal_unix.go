@@ -18,10 +18,44 @@ func signal_enable(uint32)
 func signal_ignore(uint32)
 func signal_ignored(uint32) bool
 func signal_recv() uint32
+func signal_recv_info(uint32) (code, pid int32, uid uint32, status int32, value uint64)
+func signal_recv_queued() (s uint32, code, pid int32, uid uint32, status int32, value uint64, ok bool)
+func signal_queue_dropped(uint32) uint32
+func signal_enable_queued(uint32)
+func signal_disable_queued(uint32)
 
 func loop() {
 	for {
-		process(syscall.Signal(signal_recv()))
+		n := signal_recv()
+		code, pid, uid, status, value := signal_recv_info(n)
+		process(&Info{
+			Signal: syscall.Signal(n),
+			Code:   int(code),
+			Pid:    int(pid),
+			Uid:    int(uid),
+			Status: int(status),
+			Value:  uintptr(value),
+		})
+
+		// The runtime sends every queued occurrence, and every
+		// dropped one, with a signal, so receive them now.
+		if d := signal_queue_dropped(n); d > 0 {
+			processDropped(int(n), uint64(d))
+		}
+		for {
+			s, code, pid, uid, status, value, ok := signal_recv_queued()
+			if !ok {
+				break
+			}
+			processQueued(&Info{
+				Signal: syscall.Signal(s),
+				Code:   int(code),
+				Pid:    int(pid),
+				Uid:    int(uid),
+				Status: int(status),
+				Value:  uintptr(value),
+			})
+		}
 	}
 }
 
@@ -54,6 +88,14 @@ func disableSignal(sig int) {
 	signal_disable(uint32(sig))
 }
 
+func enableQueued(sig int) {
+	signal_enable_queued(uint32(sig))
+}
+
+func disableQueued(sig int) {
+	signal_disable_queued(uint32(sig))
+}
+
 func ignoreSignal(sig int) {
 	signal_ignore(uint32(sig))
 }
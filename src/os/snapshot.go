@@ -0,0 +1,62 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import "sort"
+
+// OpenNoAtime opens the named file for reading, like Open, but asks the
+// system not to update the file's access time as it is read. This lets
+// backup and indexing tools read a tree without disturbing the metadata
+// they are meant to preserve.
+//
+// On Linux the file is opened with O_NOATIME, which the kernel only
+// permits for the owner of the file (or a sufficiently privileged
+// process); if that is refused, the file is opened normally. On other
+// systems OpenNoAtime is equivalent to Open.
+func OpenNoAtime(name string) (*File, error) {
+	return openNoAtime(name)
+}
+
+// ReadDirSnapshot reads the named directory and returns the FileInfo of
+// all its entries, sorted by filename. Like Lstat, it describes symbolic
+// links themselves rather than their targets.
+//
+// Unlike calling Lstat on each name returned by ReadDir, ReadDirSnapshot
+// first collects all the names and then describes each of them relative
+// to the directory it already has open (using fstatat where available).
+// A directory renamed or replaced while it is being listed is therefore
+// never mixed with another. Entries removed between the listing and their
+// stat are omitted. The directory is opened with OpenNoAtime.
+func ReadDirSnapshot(name string) ([]FileInfo, error) {
+	f, err := OpenNoAtime(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.statEntries()
+}
+
+// statEntries returns the FileInfo of all the entries of the directory
+// f, sorted by filename, describing each relative to f with statAt.
+// Entries removed before their stat are omitted.
+func (f *File) statEntries() ([]FileInfo, error) {
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]FileInfo, 0, len(names))
+	for _, n := range names {
+		fi, err := f.statAt(n)
+		if IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, fi)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package os
+
+import (
+	"internal/syscall/unix"
+	"runtime"
+)
+
+// haveStatAt reports whether statAt uses fstatat.
+const haveStatAt = true
+
+// statAt returns the FileInfo of the entry name in the directory f,
+// without following symbolic links.
+func (f *File) statAt(name string) (FileInfo, error) {
+	if err := f.checkValid("stat"); err != nil {
+		return nil, err
+	}
+	var fs fileStat
+	var err error
+	cerr := f.pfd.RawControl(func(fd uintptr) {
+		err = ignoringEINTR(func() error {
+			return unix.Fstatat(int(fd), name, &fs.sys, unix.AT_SYMLINK_NOFOLLOW)
+		})
+	})
+	runtime.KeepAlive(f)
+	if cerr != nil {
+		return nil, cerr
+	}
+	if err != nil {
+		return nil, &PathError{Op: "fstatat", Path: f.name + string(PathSeparator) + name, Err: err}
+	}
+	fillFileStatFromSys(&fs, name)
+	return &fs, nil
+}
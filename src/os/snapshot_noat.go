@@ -0,0 +1,20 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package os
+
+// haveStatAt reports whether statAt resolves the full path of the entry.
+const haveStatAt = false
+
+// statAt returns the FileInfo of the entry name in the directory f,
+// without following symbolic links.
+func (f *File) statAt(name string) (FileInfo, error) {
+	if err := f.checkValid("stat"); err != nil {
+		return nil, err
+	}
+	return Lstat(f.name + string(PathSeparator) + name)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"internal/testenv"
+	"io"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	. "os"
+)
+
+func TestReadDirSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"c", "a", "b"} {
+		if err := WriteFile(filepath.Join(dir, name), []byte(name+name), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Mkdir(filepath.Join(dir, "d"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "c", "d"}
+	if testenv.HasSymlink() {
+		if err := Symlink("a", filepath.Join(dir, "e")); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, "e")
+	}
+
+	infos, err := ReadDirSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("ReadDirSnapshot returned %d entries; want %d", len(infos), len(want))
+	}
+	for i, fi := range infos {
+		if fi.Name() != want[i] {
+			t.Errorf("entry %d is %q; want %q", i, fi.Name(), want[i])
+		}
+		switch fi.Name() {
+		case "a", "b", "c":
+			if !fi.Mode().IsRegular() || fi.Size() != 2 {
+				t.Errorf("%s: mode %v, size %d; want regular file of size 2", fi.Name(), fi.Mode(), fi.Size())
+			}
+		case "d":
+			if !fi.IsDir() {
+				t.Errorf("d: mode %v; want directory", fi.Mode())
+			}
+		case "e":
+			if fi.Mode()&ModeSymlink == 0 {
+				t.Errorf("e: mode %v; want symlink", fi.Mode())
+			}
+		}
+	}
+
+	if _, err := ReadDirSnapshot(filepath.Join(dir, "missing")); !IsNotExist(err) {
+		t.Errorf("ReadDirSnapshot of missing directory = %v; want not-exist error", err)
+	}
+}
+
+func TestOpenNoAtime(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+	if err := WriteFile(name, []byte("hello"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := Chtimes(name, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenNoAtime(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("read %q, %v; want %q, nil", data, err, "hello")
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	fi, err := Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at := Atime(fi); !at.Equal(old) {
+		t.Errorf("access time changed from %v to %v", old, at)
+	}
+}
@@ -13,12 +13,14 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"unsafe"
 )
 
 /*
 #cgo solaris CFLAGS: -D_POSIX_PTHREAD_SEMANTICS
+#include <errno.h>
 #include <unistd.h>
 #include <sys/types.h>
 #include <pwd.h>
@@ -44,6 +46,24 @@ static int mygetgrnam_r(const char *name, struct group *grp,
 	char *buf, size_t buflen, struct group **result) {
  return getgrnam_r(name, grp, buf, buflen, result);
 }
+
+static struct passwd *mygetpwent(int *perr) {
+	struct passwd *pwd;
+
+	errno = 0;
+	pwd = getpwent();
+	*perr = errno;
+	return pwd;
+}
+
+static struct group *mygetgrent(int *perr) {
+	struct group *grp;
+
+	errno = 0;
+	grp = getgrent();
+	*perr = errno;
+	return grp;
+}
 */
 import "C"
 
@@ -197,6 +217,51 @@ func buildGroup(grp *C.struct_group) *Group {
 	return g
 }
 
+// entMu serializes the enumerations of users and groups, whose position
+// is kept by the C library for the whole process.
+var entMu sync.Mutex
+
+func allUsers() ([]*User, error) {
+	entMu.Lock()
+	defer entMu.Unlock()
+
+	C.setpwent()
+	defer C.endpwent()
+	var users []*User
+	for {
+		var errno C.int
+		pwd := C.mygetpwent(&errno)
+		if pwd == nil {
+			// Some C libraries report ENOENT at the end.
+			if errno != 0 && syscall.Errno(errno) != syscall.ENOENT {
+				return nil, fmt.Errorf("user: enumerate users: %v", syscall.Errno(errno))
+			}
+			return users, nil
+		}
+		users = append(users, buildUser(pwd))
+	}
+}
+
+func allGroups() ([]*Group, error) {
+	entMu.Lock()
+	defer entMu.Unlock()
+
+	C.setgrent()
+	defer C.endgrent()
+	var groups []*Group
+	for {
+		var errno C.int
+		grp := C.mygetgrent(&errno)
+		if grp == nil {
+			if errno != 0 && syscall.Errno(errno) != syscall.ENOENT {
+				return nil, fmt.Errorf("user: enumerate groups: %v", syscall.Errno(errno))
+			}
+			return groups, nil
+		}
+		groups = append(groups, buildGroup(grp))
+	}
+}
+
 type bufferKind C.int
 
 const (
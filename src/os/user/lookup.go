@@ -57,7 +57,47 @@ func LookupGroupId(gid string) (*Group, error) {
 	return lookupGroupId(gid)
 }
 
+// All returns the users of the system, as far as they can be enumerated.
+//
+// On Unix systems, with cgo, these are the users listed by getpwent(3),
+// which includes those of directory services such as LDAP when the
+// system is configured to enumerate them; without cgo, they are the
+// users of /etc/passwd. On Windows, they are the local accounts of
+// ordinary users, as listed by NetUserEnum.
+func All() ([]*User, error) {
+	return allUsers()
+}
+
+// AllGroups returns the groups of the system, as far as they can be
+// enumerated: with cgo, those listed by getgrent(3) on Unix systems;
+// without cgo, those of /etc/group; and the local groups on Windows.
+func AllGroups() ([]*Group, error) {
+	return allGroups()
+}
+
 // GroupIds returns the list of group IDs that the user is a member of.
 func (u *User) GroupIds() ([]string, error) {
 	return listGroups(u)
 }
+
+// MemberOf reports whether u is a member of the group g, either as its
+// primary group or as one of the groups listed by GroupIds.
+func (u *User) MemberOf(g *Group) (bool, error) {
+	if u.Gid == g.Gid {
+		return true, nil
+	}
+	gids, err := u.GroupIds()
+	if err != nil {
+		return false, err
+	}
+	return containsGid(gids, g.Gid), nil
+}
+
+func containsGid(gids []string, gid string) bool {
+	for _, id := range gids {
+		if id == gid {
+			return true
+		}
+	}
+	return false
+}
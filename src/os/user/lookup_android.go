@@ -24,3 +24,15 @@ func lookupGroup(string) (*Group, error) {
 func lookupGroupId(string) (*Group, error) {
 	return nil, errors.New("user: LookupGroupId not implemented on android")
 }
+
+func listGroups(*User) ([]string, error) {
+	return nil, errors.New("user: GroupIds not implemented on android")
+}
+
+func allUsers() ([]*User, error) {
+	return nil, errors.New("user: All not implemented on android")
+}
+
+func allGroups() ([]*Group, error) {
+	return nil, errors.New("user: AllGroups not implemented on android")
+}
@@ -12,6 +12,7 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strconv"
@@ -108,24 +109,50 @@ func matchGroupIndexValue(value string, idx int) lineFunc {
 	}
 	substr := []byte(leadColon + value + ":")
 	return func(line []byte) (v interface{}, err error) {
-		if !bytes.Contains(line, substr) || bytes.Count(line, colon) < 3 {
+		if !bytes.Contains(line, substr) {
 			return
 		}
-		// wheel:*:0:root
-		parts := strings.SplitN(string(line), ":", 4)
-		if len(parts) < 4 || parts[0] == "" || parts[idx] != value ||
-			// If the file contains +foo and you search for "foo", glibc
-			// returns an "invalid argument" error. Similarly, if you search
-			// for a gid for a row where the group name starts with "+" or "-",
-			// glibc fails to find the record.
-			parts[0][0] == '+' || parts[0][0] == '-' {
-			return
+		if parts := groupFields(line); parts != nil && parts[idx] == value {
+			return &Group{Name: parts[0], Gid: parts[2]}, nil
 		}
-		if _, err := strconv.Atoi(parts[2]); err != nil {
-			return nil, nil
+		return
+	}
+}
+
+// groupFields returns the fields of a valid row of /etc/group, or nil.
+func groupFields(line []byte) []string {
+	if bytes.Count(line, colon) < 3 {
+		return nil
+	}
+	// wheel:*:0:root
+	parts := strings.SplitN(string(line), ":", 4)
+	if len(parts) < 4 || parts[0] == "" ||
+		// If the file contains +foo and you search for "foo", glibc
+		// returns an "invalid argument" error. Similarly, if you search
+		// for a gid for a row where the group name starts with "+" or "-",
+		// glibc fails to find the record.
+		parts[0][0] == '+' || parts[0][0] == '-' {
+		return nil
+	}
+	if _, err := strconv.Atoi(parts[2]); err != nil {
+		return nil
+	}
+	return parts
+}
+
+// findAllGroups returns the groups of the valid rows of r.
+func findAllGroups(r io.Reader) ([]*Group, error) {
+	var groups []*Group
+	_, err := readColonFile(r, func(line []byte) (interface{}, error) {
+		if parts := groupFields(line); parts != nil {
+			groups = append(groups, &Group{Name: parts[0], Gid: parts[2]})
 		}
-		return &Group{Name: parts[0], Gid: parts[2]}, nil
+		return nil, nil
+	}, 3)
+	if err != nil {
+		return nil, err
 	}
+	return groups, nil
 }
 
 func findGroupId(id string, r io.Reader) (*Group, error) {
@@ -155,37 +182,67 @@ func matchUserIndexValue(value string, idx int) lineFunc {
 	}
 	substr := []byte(leadColon + value + ":")
 	return func(line []byte) (v interface{}, err error) {
-		if !bytes.Contains(line, substr) || bytes.Count(line, colon) < 6 {
-			return
-		}
-		// kevin:x:1005:1006::/home/kevin:/usr/bin/zsh
-		parts := strings.SplitN(string(line), ":", 7)
-		if len(parts) < 6 || parts[idx] != value || parts[0] == "" ||
-			parts[0][0] == '+' || parts[0][0] == '-' {
+		if !bytes.Contains(line, substr) {
 			return
 		}
-		if _, err := strconv.Atoi(parts[2]); err != nil {
-			return nil, nil
-		}
-		if _, err := strconv.Atoi(parts[3]); err != nil {
-			return nil, nil
-		}
-		u := &User{
-			Username: parts[0],
-			Uid:      parts[2],
-			Gid:      parts[3],
-			Name:     parts[4],
-			HomeDir:  parts[5],
+		if parts := userFields(line); parts != nil && parts[idx] == value {
+			return userFromFields(parts), nil
 		}
-		// The pw_gecos field isn't quite standardized. Some docs
-		// say: "It is expected to be a comma separated list of
-		// personal data where the first item is the full name of the
-		// user."
-		if i := strings.Index(u.Name, ","); i >= 0 {
-			u.Name = u.Name[:i]
+		return
+	}
+}
+
+// userFields returns the fields of a valid row of /etc/passwd, or nil.
+func userFields(line []byte) []string {
+	if bytes.Count(line, colon) < 6 {
+		return nil
+	}
+	// kevin:x:1005:1006::/home/kevin:/usr/bin/zsh
+	parts := strings.SplitN(string(line), ":", 7)
+	if len(parts) < 6 || parts[0] == "" ||
+		parts[0][0] == '+' || parts[0][0] == '-' {
+		return nil
+	}
+	if _, err := strconv.Atoi(parts[2]); err != nil {
+		return nil
+	}
+	if _, err := strconv.Atoi(parts[3]); err != nil {
+		return nil
+	}
+	return parts
+}
+
+func userFromFields(parts []string) *User {
+	u := &User{
+		Username: parts[0],
+		Uid:      parts[2],
+		Gid:      parts[3],
+		Name:     parts[4],
+		HomeDir:  parts[5],
+	}
+	// The pw_gecos field isn't quite standardized. Some docs
+	// say: "It is expected to be a comma separated list of
+	// personal data where the first item is the full name of the
+	// user."
+	if i := strings.Index(u.Name, ","); i >= 0 {
+		u.Name = u.Name[:i]
+	}
+	return u
+}
+
+// findAllUsers returns the users of the valid rows of r.
+func findAllUsers(r io.Reader) ([]*User, error) {
+	var users []*User
+	_, err := readColonFile(r, func(line []byte) (interface{}, error) {
+		if parts := userFields(line); parts != nil {
+			users = append(users, userFromFields(parts))
 		}
-		return u, nil
+		return nil, nil
+	}, 6)
+	if err != nil {
+		return nil, err
 	}
+	return users, nil
 }
 
 func findUserId(uid string, r io.Reader) (*User, error) {
@@ -245,3 +302,62 @@ func lookupUserId(uid string) (*User, error) {
 	defer f.Close()
 	return findUserId(uid, f)
 }
+
+// findGroupIds returns the primary group of u and the groups of r that
+// list u as a member.
+func findGroupIds(u *User, r io.Reader) ([]string, error) {
+	gids := []string{u.Gid}
+	// Read whole rows: the members are in the last column.
+	_, err := readColonFile(r, func(line []byte) (interface{}, error) {
+		parts := groupFields(line)
+		if parts == nil {
+			return nil, nil
+		}
+		for _, gid := range gids {
+			if gid == parts[2] {
+				return nil, nil
+			}
+		}
+		for _, m := range strings.Split(parts[3], ",") {
+			if m == u.Username {
+				gids = append(gids, parts[2])
+				break
+			}
+		}
+		return nil, nil
+	}, 4)
+	if err != nil {
+		return nil, err
+	}
+	return gids, nil
+}
+
+func listGroups(u *User) ([]string, error) {
+	if _, err := strconv.Atoi(u.Gid); err != nil {
+		return nil, fmt.Errorf("user: list groups for %s: invalid gid %q", u.Username, u.Gid)
+	}
+	f, err := os.Open(groupFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return findGroupIds(u, f)
+}
+
+func allUsers() ([]*User, error) {
+	f, err := os.Open(userFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return findAllUsers(f)
+}
+
+func allGroups() ([]*Group, error) {
+	f, err := os.Open(groupFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return findAllGroups(f)
+}
@@ -162,6 +162,67 @@ struid:x:notanumber:12 # more:colons:after:comment
 struid2:x:30:badgid:struid2name:/home/struid:/usr/sbin/nologin
 `
 
+func TestFindAllUsers(t *testing.T) {
+	users, err := findAllUsers(strings.NewReader(testUserFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, u := range users {
+		got = append(got, u.Username+":"+u.Uid+":"+u.Name)
+	}
+	want := []string{
+		"root:0:root",
+		"daemon:1:daemon",
+		"bin:2:bin",
+		"indented:3:indented",
+		"sync:4:sync",
+		"negative:-5:games",
+		"man:6:man",
+		"allfields:6:mansplit",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findAllUsers = %q, want %q", got, want)
+	}
+}
+
+func TestFindAllGroups(t *testing.T) {
+	groups, err := findAllGroups(strings.NewReader(testGroupFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, g := range groups {
+		got = append(got, g.Name+":"+g.Gid)
+	}
+	want := []string{"nobody:-2", "nogroup:-1", "wheel:0", "daemon:1", "indented:7", "kmem:2", "largegroup:1000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findAllGroups = %q, want %q", got, want)
+	}
+}
+
+func TestFindGroupIds(t *testing.T) {
+	u := &User{Username: "root", Gid: "0"}
+	gids, err := findGroupIds(u, strings.NewReader(testGroupFile+"\nother:*:9:daemon,root\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// wheel lists root too, but is its primary group; plussign
+	// is not a valid row.
+	if want := []string{"0", "1", "2", "9"}; !reflect.DeepEqual(gids, want) {
+		t.Errorf("findGroupIds = %q, want %q", gids, want)
+	}
+
+	u = &User{Username: "user7500", Gid: "100"}
+	gids, err = findGroupIds(u, strings.NewReader(testGroupFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"100", "1000"}; !reflect.DeepEqual(gids, want) {
+		t.Errorf("findGroupIds = %q, want %q", gids, want)
+	}
+}
+
 var userIdTests = []struct {
 	in   string
 	uid  string
@@ -383,3 +383,82 @@ func listGroups(user *User) ([]string, error) {
 	}
 	return append(sids, user.Gid), nil
 }
+
+func allUsers() ([]*User, error) {
+	var names []string
+	var resume uint32
+	for {
+		var p0 *byte
+		var entriesRead, totalEntries uint32
+		err := windows.NetUserEnum(nil, 0, windows.FILTER_NORMAL_ACCOUNT, &p0, windows.MAX_PREFERRED_LENGTH, &entriesRead, &totalEntries, &resume)
+		if err != nil && err != syscall.ERROR_MORE_DATA {
+			return nil, err
+		}
+		if p0 != nil {
+			for _, entry := range unsafe.Slice((*windows.UserInfo0)(unsafe.Pointer(p0)), entriesRead) {
+				if entry.Name != nil {
+					names = append(names, windows.UTF16PtrToString(entry.Name))
+				}
+			}
+			syscall.NetApiBufferFree(p0)
+		}
+		if err == nil {
+			break
+		}
+	}
+	users := make([]*User, 0, len(names))
+	for _, name := range names {
+		u, err := lookupUser(name)
+		if isAccountNotFound(err) {
+			// The account was deleted after it was enumerated.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func allGroups() ([]*Group, error) {
+	var names []string
+	var resume uintptr
+	for {
+		var p0 *byte
+		var entriesRead, totalEntries uint32
+		err := windows.NetLocalGroupEnum(nil, 0, &p0, windows.MAX_PREFERRED_LENGTH, &entriesRead, &totalEntries, &resume)
+		if err != nil && err != syscall.ERROR_MORE_DATA {
+			return nil, err
+		}
+		if p0 != nil {
+			for _, entry := range unsafe.Slice((*windows.LocalGroupInfo0)(unsafe.Pointer(p0)), entriesRead) {
+				if entry.Name != nil {
+					names = append(names, windows.UTF16PtrToString(entry.Name))
+				}
+			}
+			syscall.NetApiBufferFree(p0)
+		}
+		if err == nil {
+			break
+		}
+	}
+	groups := make([]*Group, 0, len(names))
+	for _, name := range names {
+		g, err := lookupGroup(name)
+		if isAccountNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// isAccountNotFound reports whether err is the error of the lookup of
+// an account that does not exist.
+func isAccountNotFound(err error) bool {
+	return err == windows.ERROR_NONE_MAPPED || err == windows.NERR_UserNotFound
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"sync"
+	"time"
+)
+
+// A Resolver looks up users and groups like the package-level functions,
+// remembering the result of each lookup so that repeated ones, such as
+// authorization checks, do not each query the user databases of the
+// system, which may be remote directory services.
+//
+// Only successful lookups are cached, so that looking up arbitrary names
+// that do not exist cannot grow the cache. A Resolver does not notice
+// changes made to the user databases while a result is cached; expired
+// results are discarded as the cache grows.
+//
+// A Resolver is safe for concurrent use by multiple goroutines.
+// Its fields must not be modified after its first use.
+type Resolver struct {
+	// TTL is how long results are cached. If TTL is zero, results are
+	// cached until Reset is called.
+	TTL time.Duration
+
+	mu      sync.Mutex
+	cache   map[resolverKey]resolverResult
+	sweepAt int // cache size at which to discard expired results
+}
+
+// minSweep is the smallest cache size at which a Resolver looks for
+// expired results to discard.
+const minSweep = 64
+
+type resolverKind int
+
+const (
+	resolveUser resolverKind = iota
+	resolveUserId
+	resolveGroup
+	resolveGroupId
+	resolveGroupIds
+)
+
+type resolverKey struct {
+	kind resolverKind
+	key  string
+}
+
+type resolverResult struct {
+	v       interface{} // *User, *Group or []string
+	expires time.Time   // zero if the result does not expire
+}
+
+// lookup returns the cached result for key, or calls fn and caches its
+// result if it succeeds.
+func (r *Resolver) lookup(key resolverKey, fn func() (interface{}, error)) (interface{}, error) {
+	r.mu.Lock()
+	res, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && (res.expires.IsZero() || time.Now().Before(res.expires)) {
+		return res.v, nil
+	}
+
+	v, err := fn()
+	if err != nil {
+		return v, err
+	}
+	res = resolverResult{v: v}
+	if r.TTL > 0 {
+		res.expires = time.Now().Add(r.TTL)
+	}
+	r.mu.Lock()
+	if r.cache == nil {
+		r.cache = make(map[resolverKey]resolverResult)
+	}
+	if r.TTL > 0 && len(r.cache) >= r.sweepAt {
+		r.sweep()
+	}
+	r.cache[key] = res
+	r.mu.Unlock()
+	return v, nil
+}
+
+// sweep discards the expired results from the cache. It runs each time
+// the cache doubles in size, so that its cost is spread over the lookups
+// that grew the cache. r.mu must be held.
+func (r *Resolver) sweep() {
+	now := time.Now()
+	for k, res := range r.cache {
+		if !res.expires.IsZero() && !now.Before(res.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.sweepAt = 2 * len(r.cache)
+	if r.sweepAt < minSweep {
+		r.sweepAt = minSweep
+	}
+}
+
+func (r *Resolver) lookupUser(key resolverKey, fn func(string) (*User, error)) (*User, error) {
+	v, err := r.lookup(key, func() (interface{}, error) { return fn(key.key) })
+	if err != nil {
+		return nil, err
+	}
+	u := *v.(*User) // copy
+	return &u, nil
+}
+
+func (r *Resolver) lookupGroup(key resolverKey, fn func(string) (*Group, error)) (*Group, error) {
+	v, err := r.lookup(key, func() (interface{}, error) { return fn(key.key) })
+	if err != nil {
+		return nil, err
+	}
+	g := *v.(*Group) // copy
+	return &g, nil
+}
+
+// Lookup is like the package-level Lookup, but answers from the cache
+// of r when username has been looked up before.
+func (r *Resolver) Lookup(username string) (*User, error) {
+	return r.lookupUser(resolverKey{resolveUser, username}, Lookup)
+}
+
+// LookupId is like the package-level LookupId, but answers from the
+// cache of r when uid has been looked up before.
+func (r *Resolver) LookupId(uid string) (*User, error) {
+	return r.lookupUser(resolverKey{resolveUserId, uid}, LookupId)
+}
+
+// LookupGroup is like the package-level LookupGroup, but answers from
+// the cache of r when name has been looked up before.
+func (r *Resolver) LookupGroup(name string) (*Group, error) {
+	return r.lookupGroup(resolverKey{resolveGroup, name}, LookupGroup)
+}
+
+// LookupGroupId is like the package-level LookupGroupId, but answers
+// from the cache of r when gid has been looked up before.
+func (r *Resolver) LookupGroupId(gid string) (*Group, error) {
+	return r.lookupGroup(resolverKey{resolveGroupId, gid}, LookupGroupId)
+}
+
+// GroupIds returns the list of group IDs that u is a member of, as
+// u.GroupIds does, answering from the cache of r when the groups of u
+// have been listed before.
+func (r *Resolver) GroupIds(u *User) ([]string, error) {
+	// The groups depend on the name and primary group of u.
+	key := resolverKey{resolveGroupIds, u.Username + ":" + u.Gid}
+	v, err := r.lookup(key, func() (interface{}, error) { return u.GroupIds() })
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), v.([]string)...), nil
+}
+
+// MemberOf reports whether u is a member of the group g, as u.MemberOf
+// does, using the cache of r for the groups of u.
+func (r *Resolver) MemberOf(u *User, g *Group) (bool, error) {
+	if u.Gid == g.Gid {
+		return true, nil
+	}
+	gids, err := r.GroupIds(u)
+	if err != nil {
+		return false, err
+	}
+	return containsGid(gids, g.Gid), nil
+}
+
+// Reset discards all cached results.
+func (r *Resolver) Reset() {
+	r.mu.Lock()
+	r.cache = nil
+	r.sweepAt = 0
+	r.mu.Unlock()
+}
@@ -6,7 +6,9 @@ package user
 
 import (
 	"runtime"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func checkUser(t *testing.T) {
@@ -148,6 +150,147 @@ func TestGroupIds(t *testing.T) {
 	}
 }
 
+func TestAll(t *testing.T) {
+	checkUser(t)
+	if runtime.GOOS == "plan9" || runtime.GOOS == "android" {
+		t.Skipf("All not implemented on %q", runtime.GOOS)
+	}
+	want, err := Current()
+	if err != nil {
+		t.Fatalf("Current(): %v", err)
+	}
+	users, err := All()
+	if err != nil {
+		t.Fatalf("All(): %v", err)
+	}
+	for _, u := range users {
+		if u.Uid == want.Uid {
+			compare(t, want, u)
+			return
+		}
+	}
+	// The current user need not be a local account on Windows,
+	// nor be listed by Unix systems that do not enumerate users
+	// of directory services.
+	t.Logf("All() does not contain the current user %+v", want)
+}
+
+func TestAllGroups(t *testing.T) {
+	checkGroup(t)
+	if runtime.GOOS == "plan9" || runtime.GOOS == "android" {
+		t.Skipf("AllGroups not implemented on %q", runtime.GOOS)
+	}
+	groups, err := AllGroups()
+	if err != nil {
+		t.Fatalf("AllGroups(): %v", err)
+	}
+	for _, g := range groups {
+		g2, err := LookupGroupId(g.Gid)
+		if err != nil {
+			t.Errorf("LookupGroupId(%q): %v", g.Gid, err)
+			continue
+		}
+		// Several groups may share an ID.
+		if g2.Gid != g.Gid {
+			t.Errorf("LookupGroupId(%q) = %+v; want Gid %s", g.Gid, g2, g.Gid)
+		}
+	}
+}
+
+func TestMemberOf(t *testing.T) {
+	checkGroup(t)
+	if runtime.GOOS == "aix" {
+		t.Skip("skipping GroupIds, see golang.org/issue/30563")
+	}
+	if runtime.GOOS == "illumos" {
+		t.Skip("skipping GroupIds, see golang.org/issue/14709")
+	}
+	user, err := Current()
+	if err != nil {
+		t.Fatalf("Current(): %v", err)
+	}
+	gids, err := user.GroupIds()
+	if err != nil {
+		t.Fatalf("%+v.GroupIds(): %v", user, err)
+	}
+	for _, gid := range append(gids, user.Gid) {
+		if ok, err := user.MemberOf(&Group{Gid: gid}); !ok || err != nil {
+			t.Errorf("MemberOf(%s) = %v, %v; want true, nil", gid, ok, err)
+		}
+	}
+	if ok, err := user.MemberOf(&Group{Gid: "invalid"}); ok || err != nil {
+		t.Errorf("MemberOf(invalid) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	checkUser(t)
+	if runtime.GOOS == "plan9" {
+		t.Skipf("Lookup not implemented on %q", runtime.GOOS)
+	}
+	want, err := Current()
+	if err != nil {
+		t.Fatalf("Current(): %v", err)
+	}
+
+	var r Resolver
+	for i := 0; i < 2; i++ {
+		u, err := r.Lookup(want.Username)
+		if err != nil {
+			t.Fatalf("Lookup(%q): %v", want.Username, err)
+		}
+		compare(t, want, u)
+		u.Username = "modified" // must not change the cached user
+		if u, err = r.LookupId(want.Uid); err != nil {
+			t.Fatalf("LookupId(%q): %v", want.Uid, err)
+		}
+		compare(t, want, u)
+	}
+	if n := len(r.cache); n != 2 {
+		t.Errorf("%d cached results, want 2", n)
+	}
+
+	r.Reset()
+	if n := len(r.cache); n != 0 {
+		t.Errorf("%d cached results after Reset, want 0", n)
+	}
+
+	r.TTL = time.Nanosecond
+	if _, err := r.Lookup(want.Username); err != nil {
+		t.Fatalf("Lookup(%q): %v", want.Username, err)
+	}
+	time.Sleep(time.Millisecond)
+	r.cache[resolverKey{resolveUser, want.Username}] = resolverResult{v: &User{Username: "stale"}, expires: time.Now()}
+	u, err := r.Lookup(want.Username)
+	if err != nil {
+		t.Fatalf("Lookup(%q) after the TTL: %v", want.Username, err)
+	}
+	compare(t, want, u)
+
+	// Misses are not cached, and expired results are discarded
+	// as the cache grows.
+	r.Reset()
+	r.TTL = time.Hour
+	const missing = "no-such-user-for-resolver-test"
+	if _, err := r.Lookup(missing); err == nil {
+		t.Skipf("user %q exists", missing)
+	}
+	if _, ok := r.cache[resolverKey{resolveUser, missing}]; ok {
+		t.Errorf("Lookup(%q) cached a miss", missing)
+	}
+	r.cache = make(map[resolverKey]resolverResult)
+	for i := 0; i < 2*minSweep; i++ {
+		r.cache[resolverKey{resolveGroup, strconv.Itoa(i)}] = resolverResult{v: &Group{}, expires: time.Now()}
+	}
+	r.sweepAt = len(r.cache)
+	if _, err := r.LookupId(want.Uid); err != nil {
+		t.Fatalf("LookupId(%q): %v", want.Uid, err)
+	}
+	if n := len(r.cache); n != 1 {
+		t.Errorf("%d cached results after a sweep, want 1", n)
+	}
+}
+
 func containsID(ids []string, id string) bool {
 	for _, x := range ids {
 		if x == id {
@@ -22,6 +22,13 @@ const _P_PID = 1
 // succeed immediately, and reports whether it has done so.
 // It does not actually call p.Wait.
 func (p *Process) blockUntilWaitable() (bool, error) {
+	if p.pidfd != nil {
+		if err := p.pidfdWait(); err == nil {
+			return true, nil
+		}
+		// The pidfd was closed by Release; let
+		// waitid report the error.
+	}
 	// The waitid system call expects a pointer to a siginfo_t,
 	// which is 128 bytes on all Linux systems.
 	// On darwin/amd64, it requires 104 bytes.
@@ -0,0 +1,687 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wal implements a minimal crash-safe write-ahead log.
+//
+// A log is a directory of segment files. Each segment holds a sequence
+// of records, and each record is framed by its length, a CRC-32C
+// (Castagnoli) checksum of the length, and one of the length and
+// payload. Records are identified by a monotonically increasing index,
+// starting at 1.
+//
+// When a log is opened, its segments are scanned. The last record of
+// the last segment is assumed to be the result of a torn write during
+// a crash, and truncated away, if it is incomplete, or if it fails a
+// checksum and either ends the segment or is followed only by zero
+// bytes, as some file systems leave after a crash. A record is only
+// incomplete if the checksum of its length holds and the length runs
+// past the end of the segment. Damage anywhere else is reported as
+// ErrCorrupt, since it cannot have been caused by an interrupted
+// append.
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// SyncPolicy controls when a Log flushes appended records to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes after every call to Write, so that a record
+	// is durable once Write returns.
+	SyncAlways SyncPolicy = iota
+
+	// SyncManual never flushes records implicitly. The caller must call
+	// Sync to make preceding writes durable. Segment files are still
+	// flushed when the log rolls over to a new segment or is closed.
+	SyncManual
+)
+
+// Options configures a Log.
+type Options struct {
+	// SegmentSize is the size in bytes at which the log starts a new
+	// segment file. A segment may exceed this size by at most one record.
+	// If zero, DefaultSegmentSize is used.
+	SegmentSize int64
+
+	// Sync is the flushing policy for appended records.
+	Sync SyncPolicy
+}
+
+// DefaultSegmentSize is the segment size used when Options.SegmentSize is zero.
+const DefaultSegmentSize = 64 << 20
+
+const (
+	headerSize = 12 // uint32 length, uint32 length checksum, uint32 checksum
+	segmentExt = ".wal"
+
+	// maxRecordSize bounds the length field so that a damaged header
+	// cannot cause an enormous allocation.
+	maxRecordSize = 1<<31 - 1
+
+	// unbounded is passed to readRecord for segments that may still
+	// be growing, whose current size is no bound on their records.
+	unbounded = headerSize + maxRecordSize
+)
+
+var (
+	// ErrCorrupt is returned when a log contains damage that cannot be
+	// explained by a torn write at its end.
+	ErrCorrupt = errors.New("wal: corrupt log")
+
+	// ErrClosed is returned when using a log that has been closed.
+	ErrClosed = errors.New("wal: log closed")
+
+	// ErrNotFound is returned when a requested index is not in the log.
+	ErrNotFound = errors.New("wal: index not found")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// A segment is a single file in the log, named after the index
+// of its first record.
+type segment struct {
+	first uint64
+	path  string
+}
+
+// Log is an append-only sequence of records stored in a directory.
+// It is safe to call the methods of a Log from multiple goroutines.
+type Log struct {
+	dir  string
+	opts Options
+
+	mu         sync.Mutex
+	segs       []segment
+	f          *os.File // last segment, opened for appending
+	size       int64    // size of f
+	next       uint64   // index of the next record to be written
+	synced     uint64   // index of the first record not known to be durable
+	syncedSize int64    // size of the durable part of f
+	closed     bool
+	err        error // sticky error left by a write that could not be undone
+	buf        []byte
+}
+
+// Open opens the log in dir, creating the directory if necessary,
+// and recovers from any torn write at the end of the log.
+// If opts is nil, default options are used.
+func Open(dir string, opts *Options) (*Log, error) {
+	l := &Log{dir: dir}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.SegmentSize <= 0 {
+		l.opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	segs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		if err := l.create(1); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	l.segs = segs
+	for i, s := range segs[:len(segs)-1] {
+		n, _, err := scanSegment(s.path, false)
+		if err != nil {
+			return nil, err
+		}
+		if s.first+n != segs[i+1].first {
+			return nil, ErrCorrupt
+		}
+	}
+	last := segs[len(segs)-1]
+	n, valid, err := scanSegment(last.path, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(last.path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() != valid {
+		// Discard the torn tail and make the truncation durable
+		// before accepting new records after it.
+		if err := f.Truncate(valid); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.f = f
+	l.size = valid
+	l.next = last.first + n
+	l.synced = l.next
+	l.syncedSize = valid
+	return l, nil
+}
+
+// listSegments returns the segments in dir, ordered by first index.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segs []segment
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil || first == 0 {
+			continue
+		}
+		segs = append(segs, segment{first: first, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].first < segs[j].first })
+	return segs, nil
+}
+
+func segmentName(first uint64) string {
+	s := strconv.FormatUint(first, 10)
+	return strings.Repeat("0", 20-len(s)) + s + segmentExt
+}
+
+// scanSegment counts the valid records in the segment at path and
+// returns the offset just past the last of them. If tail is true,
+// a torn final record ends the scan: one that is incomplete, or that
+// fails a checksum and is followed only by zero bytes. Any other
+// invalid record is reported as ErrCorrupt.
+func scanSegment(path string, tail bool) (n uint64, valid int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	r := bufio.NewReader(f)
+	var buf []byte
+	for {
+		buf, err = readRecord(r, buf, fi.Size()-valid)
+		if err == io.EOF {
+			return n, valid, nil
+		}
+		if err == io.ErrUnexpectedEOF && tail {
+			// The final record is incomplete: its header is cut
+			// short, or its checked length runs past the end of
+			// the segment, so nothing follows it.
+			return n, valid, nil
+		}
+		if err == ErrCorrupt && tail {
+			// A record that fails a checksum was torn only
+			// if nothing but zeros follow it.
+			zero, err := zeroFrom(f, valid+headerSize+int64(len(buf)))
+			if err != nil {
+				return 0, 0, err
+			}
+			if zero {
+				return n, valid, nil
+			}
+		}
+		if err == ErrCorrupt || err == io.ErrUnexpectedEOF {
+			return 0, 0, ErrCorrupt
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		n++
+		valid += headerSize + int64(len(buf))
+	}
+}
+
+// zeroFrom reports whether the bytes of f from offset off to its end
+// are all zero.
+func zeroFrom(f *os.File, off int64) (bool, error) {
+	b := make([]byte, 32<<10)
+	for {
+		n, err := f.ReadAt(b, off)
+		for _, c := range b[:n] {
+			if c != 0 {
+				return false, nil
+			}
+		}
+		off += int64(n)
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// readRecord reads the next record from r into buf.
+// Remain is the number of bytes left in the segment.
+// It returns io.EOF if r is at the end of a segment,
+// io.ErrUnexpectedEOF if the record is incomplete,
+// and ErrCorrupt if the record fails a checksum.
+// If the length of the record fails its checksum,
+// the returned buf is empty.
+func readRecord(r io.Reader, buf []byte, remain int64) ([]byte, error) {
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return buf, err
+	}
+	// Don't trust the length before checking it; a damaged
+	// length could make a record look incomplete.
+	if checksum(hdr[0:4], nil) != le32(hdr[4:]) {
+		return buf[:0], ErrCorrupt
+	}
+	size := le32(hdr[0:])
+	if size > maxRecordSize {
+		return buf[:0], ErrCorrupt
+	}
+	if int64(size) > remain-headerSize {
+		return buf, io.ErrUnexpectedEOF
+	}
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return buf, err
+	}
+	if checksum(hdr[0:4], buf) != le32(hdr[8:]) {
+		return buf, ErrCorrupt
+	}
+	return buf, nil
+}
+
+// checksum covers the length as well as the payload, so that a
+// zero-filled region (as left by some file systems after a crash)
+// is not mistaken for a sequence of empty records.
+func checksum(length, data []byte) uint32 {
+	crc := crc32.Update(0, castagnoli, length)
+	return crc32.Update(crc, castagnoli, data)
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func putLE32(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}
+
+// create starts a new segment whose first record will have index first.
+// l.mu must be held (or l not yet shared).
+func (l *Log) create(first uint64) error {
+	path := filepath.Join(l.dir, segmentName(first))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	// Make the new directory entry durable, so that records
+	// synced to the segment cannot be lost with the file itself.
+	if err := syncDir(l.dir); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	l.segs = append(l.segs, segment{first: first, path: path})
+	l.f = f
+	l.size = 0
+	l.next = first
+	// The records of earlier segments are durable.
+	l.synced = first
+	l.syncedSize = 0
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	if isSyncUnsupported(err) {
+		err = nil
+	}
+	return err
+}
+
+// isSyncUnsupported reports whether err reports that a directory
+// cannot be synced, which some systems refuse.
+func isSyncUnsupported(err error) bool {
+	var pe *os.PathError
+	if !errors.As(err, &pe) {
+		return false
+	}
+	return errors.Is(pe.Err, syscall.EINVAL) || errors.Is(pe.Err, os.ErrPermission)
+}
+
+// Write appends a record holding data to the log and returns its index.
+// With SyncAlways, the record is durable when Write returns.
+func (l *Log) Write(data []byte) (index uint64, err error) {
+	if len(data) > maxRecordSize {
+		return 0, errors.New("wal: record too large")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.size > 0 && l.size+headerSize+int64(len(data)) > l.opts.SegmentSize {
+		if err := l.roll(); err != nil {
+			return 0, err
+		}
+	}
+	n := headerSize + len(data)
+	if cap(l.buf) < n {
+		l.buf = make([]byte, n)
+	}
+	b := l.buf[:n]
+	putLE32(b[0:], uint32(len(data)))
+	putLE32(b[4:], checksum(b[0:4], nil))
+	putLE32(b[8:], checksum(b[0:4], data))
+	copy(b[headerSize:], data)
+	if _, err := l.f.Write(b); err != nil {
+		l.undoWrite()
+		return 0, err
+	}
+	if l.opts.Sync == SyncAlways {
+		if err := l.f.Sync(); err != nil {
+			// The record may not be durable, so it is not
+			// in the log: its index will be written again.
+			l.undoWrite()
+			return 0, err
+		}
+	}
+	l.size += int64(n)
+	index = l.next
+	l.next++
+	if l.opts.Sync == SyncAlways {
+		l.synced, l.syncedSize = l.next, l.size
+	}
+	return index, nil
+}
+
+// undoWrite cuts off whatever part of a failed write reached the
+// file, so that later records do not follow garbage. If that fails,
+// the log refuses further writes, as the end of the segment is
+// unknown.
+func (l *Log) undoWrite() {
+	if err := l.f.Truncate(l.size); err != nil {
+		l.err = err
+		return
+	}
+	if _, err := l.f.Seek(l.size, io.SeekStart); err != nil {
+		l.err = err
+	}
+}
+
+// roll flushes the current segment and starts a new one. If it fails,
+// the current segment stays in use.
+func (l *Log) roll() error {
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	old := l.f
+	if err := l.create(l.next); err != nil {
+		return err
+	}
+	// The records of the old segment are durable, so an error
+	// closing it does not affect the log.
+	old.Close()
+	return nil
+}
+
+// Sync flushes all records written so far to stable storage.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.synced, l.syncedSize = l.next, l.size
+	return nil
+}
+
+// Close flushes and closes the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+	err := l.f.Sync()
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// FirstIndex returns the index of the oldest record in the log.
+// If the log is empty, FirstIndex returns LastIndex()+1.
+func (l *Log) FirstIndex() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.segs[0].first
+}
+
+// LastIndex returns the index of the most recently written record,
+// or FirstIndex()-1 if the log is empty.
+func (l *Log) LastIndex() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next - 1
+}
+
+// TruncateFront removes segments that contain only records with
+// indexes less than index. Records are removed a whole segment at a
+// time, so records before index may remain afterwards.
+func (l *Log) TruncateFront(index uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	n := 0
+	for n+1 < len(l.segs) && l.segs[n+1].first <= index {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		if err := os.Remove(l.segs[0].path); err != nil {
+			return err
+		}
+		l.segs = l.segs[1:]
+	}
+	if n > 0 {
+		return syncDir(l.dir)
+	}
+	return nil
+}
+
+// A Reader reads records sequentially from a Log.
+// A Reader must not be used concurrently from multiple goroutines,
+// but it may be used concurrently with writes to its Log.
+//
+// A Reader only returns records that have been flushed to stable
+// storage: with SyncManual, records become visible to Readers when
+// Sync, or a roll over to a new segment, has flushed them.
+type Reader struct {
+	l     *Log
+	index uint64 // index of the next record to return
+	seg   uint64 // first index of the open segment
+	f     *os.File
+	sr    segmentReader
+	r     *bufio.Reader
+	buf   []byte
+}
+
+// A segmentReader reads a segment file up to a limit, so that the
+// buffer of a Reader never holds bytes that are not yet durable and
+// might be cut off again by a failed write.
+type segmentReader struct {
+	f     *os.File
+	off   int64
+	limit int64 // or -1 if the segment is complete
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if s.limit >= 0 {
+		if s.off >= s.limit {
+			return 0, io.EOF
+		}
+		if rem := s.limit - s.off; int64(len(p)) > rem {
+			p = p[:rem]
+		}
+	}
+	n, err := s.f.ReadAt(p, s.off)
+	s.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// NewReader returns a Reader positioned at the record with the given index.
+// It returns ErrNotFound if index precedes the oldest record or is beyond
+// the position of the next record to be written.
+func (l *Log) NewReader(index uint64) (*Reader, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	if index < l.segs[0].first || index > l.next {
+		return nil, ErrNotFound
+	}
+	return &Reader{l: l, index: index}, nil
+}
+
+// Next returns the next record and its index. The returned slice is
+// only valid until the next call to Next. At the end of the log, Next
+// returns io.EOF; it may be called again after more records are written.
+func (r *Reader) Next() (index uint64, data []byte, err error) {
+	r.l.mu.Lock()
+	next := r.l.synced
+	var s segment
+	found := false
+	limit := int64(-1)
+	for i := len(r.l.segs) - 1; i >= 0; i-- {
+		if r.l.segs[i].first <= r.index {
+			s, found = r.l.segs[i], true
+			if i == len(r.l.segs)-1 {
+				limit = r.l.syncedSize
+			}
+			break
+		}
+	}
+	r.l.mu.Unlock()
+	if r.index >= next {
+		return 0, nil, io.EOF
+	}
+	if !found {
+		return 0, nil, ErrNotFound
+	}
+	if r.f == nil || r.seg != s.first {
+		r.sr.limit = limit
+		if err := r.open(s); err != nil {
+			return 0, nil, err
+		}
+	}
+	r.sr.limit = limit
+	r.buf, err = readRecord(r.r, r.buf, unbounded)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// Records before next have been completely written.
+		err = ErrCorrupt
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	index = r.index
+	r.index++
+	return index, r.buf, nil
+}
+
+// open opens segment s and skips to the record at r.index.
+func (r *Reader) open(s segment) error {
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	r.seg = s.first
+	r.sr.f = f
+	r.sr.off = 0
+	if r.r == nil {
+		r.r = bufio.NewReader(&r.sr)
+	} else {
+		r.r.Reset(&r.sr)
+	}
+	for i := s.first; i < r.index; i++ {
+		if r.buf, err = readRecord(r.r, r.buf, unbounded); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = ErrCorrupt
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the resources held by the Reader.
+func (r *Reader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
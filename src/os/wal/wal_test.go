@@ -0,0 +1,380 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func record(i int) []byte {
+	return []byte(fmt.Sprintf("record %d %s", i, bytes.Repeat([]byte{'x'}, i%17)))
+}
+
+func writeRecords(t *testing.T, l *Log, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		index, err := l.Write(record(i))
+		if err != nil {
+			t.Fatalf("Write(%d): %v", i, err)
+		}
+		if index != uint64(i) {
+			t.Fatalf("Write(%d) returned index %d", i, index)
+		}
+	}
+}
+
+func checkRecords(t *testing.T, l *Log, from, to int) {
+	t.Helper()
+	r, err := l.NewReader(uint64(from))
+	if err != nil {
+		t.Fatalf("NewReader(%d): %v", from, err)
+	}
+	defer r.Close()
+	for i := from; i <= to; i++ {
+		index, data, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next at %d: %v", i, err)
+		}
+		if index != uint64(i) || !bytes.Equal(data, record(i)) {
+			t.Fatalf("Next = %d, %q; want %d, %q", index, data, i, record(i))
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next at end = %v; want io.EOF", err)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{SegmentSize: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, l, 1, 100)
+	checkRecords(t, l, 1, 100)
+	checkRecords(t, l, 42, 100)
+	segs, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) < 2 {
+		t.Fatalf("got %d segments; want several", len(segs))
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = Open(dir, &Options{SegmentSize: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if first, last := l.FirstIndex(), l.LastIndex(); first != 1 || last != 100 {
+		t.Fatalf("after reopen, indexes = [%d, %d]; want [1, 100]", first, last)
+	}
+	writeRecords(t, l, 101, 120)
+	checkRecords(t, l, 1, 120)
+}
+
+func TestReaderFollowsWrites(t *testing.T) {
+	l, err := Open(t.TempDir(), &Options{SegmentSize: 64, Sync: SyncManual})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	r, err := l.NewReader(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next on empty log = %v; want io.EOF", err)
+	}
+	for i := 1; i <= 20; i++ {
+		writeRecords(t, l, i, i)
+		if i%2 == 0 {
+			// The record is not durable yet.
+			if _, _, err := r.Next(); err != io.EOF {
+				t.Fatalf("Next before Sync = %v; want io.EOF", err)
+			}
+		}
+		if err := l.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		index, data, err := r.Next()
+		if err != nil || index != uint64(i) || !bytes.Equal(data, record(i)) {
+			t.Fatalf("Next = %d, %q, %v; want %d, %q, nil", index, data, err, i, record(i))
+		}
+	}
+}
+
+func lastSegment(t *testing.T, dir string) string {
+	t.Helper()
+	segs, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return segs[len(segs)-1].path
+}
+
+func TestTornWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(f *os.File, size int64) error
+	}{
+		{"partial header", func(f *os.File, size int64) error {
+			_, err := f.WriteAt([]byte{3, 0, 0}, size)
+			return err
+		}},
+		{"partial payload", func(f *os.File, size int64) error {
+			hdr := make([]byte, headerSize, headerSize+2)
+			putLE32(hdr[0:], 100)
+			putLE32(hdr[4:], checksum(hdr[0:4], nil))
+			_, err := f.WriteAt(append(hdr, 'a', 'b'), size)
+			return err
+		}},
+		{"zero fill", func(f *os.File, size int64) error {
+			return f.Truncate(size + 4096)
+		}},
+		{"bad checksum", func(f *os.File, size int64) error {
+			_, err := f.WriteAt([]byte{1}, size-1)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := Open(dir, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeRecords(t, l, 1, 10)
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			path := lastSegment(t, dir)
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fi, err := f.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.damage(f, fi.Size()); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			l, err = Open(dir, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			last := 10
+			if tt.name == "bad checksum" {
+				last = 9
+			}
+			if got := l.LastIndex(); got != uint64(last) {
+				t.Fatalf("LastIndex after recovery = %d; want %d", got, last)
+			}
+			writeRecords(t, l, last+1, 15)
+			checkRecords(t, l, 1, 15)
+		})
+	}
+}
+
+func TestCorruptMiddle(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{SegmentSize: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, l, 1, 30)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	segs, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(segs[0].path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{0xff}, headerSize); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := Open(dir, nil); err != ErrCorrupt {
+		t.Fatalf("Open with damaged first segment = %v; want ErrCorrupt", err)
+	}
+}
+
+func TestTruncateFront(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{SegmentSize: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	writeRecords(t, l, 1, 50)
+	if err := l.TruncateFront(30); err != nil {
+		t.Fatal(err)
+	}
+	first := l.FirstIndex()
+	if first == 1 || first > 30 {
+		t.Fatalf("FirstIndex after TruncateFront(30) = %d", first)
+	}
+	checkRecords(t, l, int(first), 50)
+	if _, err := l.NewReader(first - 1); err != ErrNotFound {
+		t.Fatalf("NewReader before first index = %v; want ErrNotFound", err)
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segs, _ := listSegments(dir); len(matches) != len(segs) || segs[0].first != first {
+		t.Fatalf("segments on disk do not match log")
+	}
+}
+
+func TestClosed(t *testing.T) {
+	l, err := Open(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write after Close = %v; want ErrClosed", err)
+	}
+	if err := l.Close(); err != ErrClosed {
+		t.Errorf("second Close = %v; want ErrClosed", err)
+	}
+}
+
+func TestCorruptLastSegment(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, l, 1, 10)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Damage the payload of the first record, which valid records
+	// follow: it cannot be a torn write.
+	f, err := os.OpenFile(lastSegment(t, dir), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{0xff}, headerSize); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := Open(dir, nil); err != ErrCorrupt {
+		t.Fatalf("Open with damaged last segment = %v; want ErrCorrupt", err)
+	}
+}
+
+func TestReaderStopsAtDurable(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	writeRecords(t, l, 1, 1)
+
+	// Leave the remains of a failed write after the first record, as
+	// undoWrite cuts off, while a Reader reads the first record.
+	f, err := os.OpenFile(lastSegment(t, dir), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xff}, 100), fi.Size()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := l.NewReader(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(fi.Size()); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRecords(t, l, 2, 2)
+	index, data, err := r.Next()
+	if err != nil || index != 2 || !bytes.Equal(data, record(2)) {
+		t.Fatalf("Next = %d, %q, %v; want 2, %q, nil", index, data, err, record(2))
+	}
+}
+
+func TestCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, l, 1, 10)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Make the length of the second record run past the end of the
+	// segment. Valid records follow it, so it is not a torn write.
+	f, err := os.OpenFile(lastSegment(t, dir), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{0, 0, 1}, headerSize+int64(len(record(1)))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := Open(dir, nil); err != ErrCorrupt {
+		t.Fatalf("Open with damaged length = %v; want ErrCorrupt", err)
+	}
+}
+
+func TestRollFailure(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, &Options{SegmentSize: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	writeRecords(t, l, 1, 1)
+
+	// Make the creation of the next segment fail.
+	block := filepath.Join(dir, segmentName(2))
+	if err := os.WriteFile(block, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Write(record(2)); err == nil {
+		t.Fatal("Write succeeded with the next segment blocked")
+	}
+	if err := os.Remove(block); err != nil {
+		t.Fatal(err)
+	}
+	writeRecords(t, l, 2, 10)
+	checkRecords(t, l, 1, 10)
+}
@@ -0,0 +1,16 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd illumos linux netbsd openbsd
+
+package os
+
+import "runtime"
+
+func (f *File) writev(bufs *[][]byte) (int64, error) {
+	n, err := f.pfd.Writev(bufs)
+	runtime.KeepAlive(f)
+	return n, err
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!illumos,!linux,!netbsd,!openbsd
+
+package os
+
+import "io"
+
+func (f *File) writev(bufs *[][]byte) (n int64, err error) {
+	for _, b := range *bufs {
+		if len(b) == 0 {
+			continue
+		}
+		m, err := f.write(b)
+		if m > 0 {
+			n += int64(m)
+		}
+		if err != nil {
+			return n, err
+		}
+		if m != len(b) {
+			return n, io.ErrShortWrite
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filepath
+
+// ToExtendedLength returns the extended-length form of path on Windows:
+// path cleaned, made to use backslashes, and prefixed with \\?\, as in
+// \\?\C:\dir\file, or with \\?\UNC\ for a UNC path, as in
+// \\?\UNC\server\share\file. The extended-length form lifts the 260
+// character MAX_PATH limit, but since Windows does not normalize such
+// paths, it must only be used with absolute, clean paths.
+//
+// Paths that cannot be converted are returned unchanged: relative paths,
+// reserved names such as NUL, and paths already in the \\?\ or \\.\
+// device namespace. On other systems, ToExtendedLength returns path
+// unchanged.
+func ToExtendedLength(path string) string {
+	return toExtendedLength(path)
+}
+
+// FromExtendedLength reverses ToExtendedLength: on Windows, it returns
+// path without its \\?\ prefix if path is the extended-length form of a
+// drive letter or UNC path, as \\?\C:\file or \\?\UNC\server\share\file,
+// and path unchanged otherwise. Programs that do not understand the
+// extended-length form usually accept the result. On other systems,
+// FromExtendedLength returns path unchanged.
+func FromExtendedLength(path string) string {
+	return fromExtendedLength(path)
+}
+
+// SplitUNC splits a Windows UNC path, such as \\server\share\dir\file or
+// its extended-length form \\?\UNC\server\share\dir\file, into the name of
+// the server, the name of the share, and the rest of the path, which is
+// empty or begins with a separator. It reports whether path is a UNC path.
+// On other systems, SplitUNC always reports false.
+func SplitUNC(path string) (server, share, rest string, ok bool) {
+	return splitUNC(path)
+}
+
+// IsDevicePath reports whether path is in one of the Windows device
+// namespaces, that is, whether it begins with \\.\ or \\?\, as
+// \\.\COM1, \\.\pipe\name and \\?\C:\file do. Such paths are passed to
+// the system without normalization. On other systems, IsDevicePath
+// always reports false.
+func IsDevicePath(path string) bool {
+	return isDevicePath(path)
+}
@@ -283,6 +283,120 @@ func Glob(pattern string) (matches []string, err error) {
 	return
 }
 
+// GlobRecursive is like Glob but also accepts "**" as a path element of
+// pattern, matching zero or more path elements. For example, "a/**/*.go"
+// matches a/x.go, a/b/x.go and a/b/c/x.go (assuming the Separator is '/').
+// A "**" element is only special when it makes up a whole path element:
+// "a**" is the same as "a*".
+//
+// To keep the traversal bounded, the part of pattern before the first
+// "**" is expanded with Glob, and "**" only descends into directories;
+// it does not follow symbolic links. The remaining pattern elements
+// prune the traversal as they are matched.
+//
+// The matches are returned in lexical order, without duplicates.
+// Like Glob, GlobRecursive ignores file system errors, and the only
+// possible returned error is ErrBadPattern.
+func GlobRecursive(pattern string) (matches []string, err error) {
+	// Check pattern is well-formed.
+	if _, err := Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	head, tail := splitDoubleStar(pattern)
+	if tail == nil {
+		return Glob(pattern)
+	}
+
+	dirs := []string{""}
+	if head != "" {
+		if dirs, err = Glob(head); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		matches = globTail(dir, tail, matches)
+	}
+	sort.Strings(matches)
+	out := matches[:0]
+	for i, m := range matches {
+		if i == 0 || m != matches[i-1] {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// splitDoubleStar splits pattern before its first "**" element,
+// returning the pattern for the directories above it and the
+// remaining elements. If there is no "**" element, tail is nil.
+func splitDoubleStar(pattern string) (head string, tail []string) {
+	start := 0
+	for i := 0; i <= len(pattern); i++ {
+		if i < len(pattern) && !os.IsPathSeparator(pattern[i]) {
+			continue
+		}
+		if pattern[start:i] == "**" {
+			head = pattern[:start]
+			// Chop off the trailing separator, unless it is the root.
+			if start > 0 && head[:start-1] != VolumeName(head) {
+				head = head[:start-1]
+			}
+			for j := start; j <= len(pattern); j++ {
+				if j == len(pattern) || os.IsPathSeparator(pattern[j]) {
+					tail = append(tail, pattern[start:j])
+					start = j + 1
+				}
+			}
+			return head, tail
+		}
+		start = i + 1
+	}
+	return pattern, nil
+}
+
+// globTail appends to matches the names below dir matching the
+// pattern elements elems, returning the updated slice.
+// An empty dir stands for the current directory.
+func globTail(dir string, elems []string, matches []string) []string {
+	if len(elems) == 0 {
+		if dir != "" {
+			matches = append(matches, dir)
+		}
+		return matches
+	}
+	d := dir
+	if d == "" {
+		d = "."
+	}
+	entries, err := os.ReadDir(d)
+	if elems[0] == "**" {
+		for len(elems) > 1 && elems[1] == "**" {
+			elems = elems[1:]
+		}
+		matches = globTail(dir, elems[1:], matches)
+		for _, e := range entries {
+			switch {
+			case e.IsDir():
+				matches = globTail(Join(dir, e.Name()), elems, matches)
+			case len(elems) == 1:
+				// A trailing "**" also matches files.
+				matches = append(matches, Join(dir, e.Name()))
+			}
+		}
+		return matches
+	}
+	if err != nil {
+		return matches // ignore I/O error
+	}
+	for _, e := range entries {
+		// The pattern was checked by GlobRecursive.
+		if ok, _ := Match(elems[0], e.Name()); ok {
+			matches = globTail(Join(dir, e.Name()), elems[1:], matches)
+		}
+	}
+	return matches
+}
+
 // cleanGlobPath prepares path for glob matching.
 func cleanGlobPath(path string) string {
 	switch path {
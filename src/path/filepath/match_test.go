@@ -210,6 +210,58 @@ func TestGlobSymlink(t *testing.T) {
 	}
 }
 
+func TestGlobRecursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "a/b.go", "a/b/c.go", "a/b/c.txt", "x/a/b/c.go"} {
+		name = Join(tmpDir, FromSlash(name))
+		if err := os.MkdirAll(Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.go", []string{"a.go", "a/b.go", "a/b/c.go", "x/a/b/c.go"}},
+		{"a/**/*.go", []string{"a/b.go", "a/b/c.go"}},
+		{"a/**", []string{"a", "a/b", "a/b.go", "a/b/c.go", "a/b/c.txt"}},
+		{"*/**/b", []string{"a/b", "x/a/b"}},
+		{"**/nothing", nil},
+	}
+	for _, tt := range tests {
+		pattern := Join(tmpDir, FromSlash(tt.pattern))
+		matches, err := GlobRecursive(pattern)
+		if err != nil {
+			t.Errorf("GlobRecursive(%#q) error: %v", pattern, err)
+			continue
+		}
+		var want []string
+		for _, m := range tt.want {
+			want = append(want, Join(tmpDir, FromSlash(m)))
+		}
+		if !reflect.DeepEqual(matches, want) {
+			t.Errorf("GlobRecursive(%#q) = %q, want %q", pattern, matches, want)
+		}
+	}
+
+	// Test a relative pattern starting with "**".
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(Join(tmpDir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := GlobRecursive(FromSlash("**/*.go"))
+	if want := []string{FromSlash("a/b/c.go")}; err != nil || !reflect.DeepEqual(matches, want) {
+		t.Errorf("GlobRecursive(%#q) = %q, %v, want %q, nil", "**/*.go", matches, err, want)
+	}
+}
+
 type globTest struct {
 	pattern string
 	matches []string
@@ -13,6 +13,7 @@ package filepath
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 	"os"
 	"sort"
@@ -474,6 +475,70 @@ func WalkDir(root string, fn fs.WalkDirFunc) error {
 	return err
 }
 
+// WalkDirUnsorted is like WalkDir but visits the entries of each directory
+// in the order the operating system returns them instead of in lexical
+// order. It reads each directory in batches as it walks it, so it neither
+// sorts nor holds all the entries of a large directory in memory, at the
+// cost of keeping one directory open for every level of the tree being
+// walked. The output is therefore not deterministic.
+//
+// SkipDir and other errors returned by fn have the same effect as for
+// WalkDir: in particular, fn can skip a subtree by returning SkipDir for
+// a directory, or stop the walk by returning any other error.
+func WalkDirUnsorted(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDirUnsorted(root, &statDirEntry{info}, fn)
+	}
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walkDirBatch is the number of directory entries
+// walkDirUnsorted reads at a time.
+const walkDirBatch = 256
+
+// walkDirUnsorted recursively descends path, calling walkDirFn.
+func walkDirUnsorted(path string, d fs.DirEntry, walkDirFn fs.WalkDirFunc) error {
+	if err := walkDirFn(path, d, nil); err != nil || !d.IsDir() {
+		if err == SkipDir && d.IsDir() {
+			// Successfully skipped directory.
+			err = nil
+		}
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		// Second call, to report the error.
+		return walkDirFn(path, d, err)
+	}
+	defer f.Close()
+	for {
+		dirs, err := f.ReadDir(walkDirBatch)
+		for _, d1 := range dirs {
+			path1 := Join(path, d1.Name())
+			if err := walkDirUnsorted(path1, d1, walkDirFn); err != nil {
+				if err == SkipDir {
+					return nil
+				}
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Second call, to report ReadDir error.
+			return walkDirFn(path, d, err)
+		}
+	}
+}
+
 type statDirEntry struct {
 	info fs.FileInfo
 }
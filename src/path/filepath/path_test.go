@@ -484,6 +484,50 @@ func TestWalkDir(t *testing.T) {
 	testWalk(t, filepath.WalkDir, 2)
 }
 
+func TestWalkDirUnsorted(t *testing.T) {
+	testWalk(t, filepath.WalkDirUnsorted, 2)
+}
+
+func TestWalkDirUnsortedLargeDir(t *testing.T) {
+	dir := t.TempDir()
+	const n = 600 // more than one batch
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprint(i)), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seen := make(map[string]bool)
+	err := filepath.WalkDirUnsorted(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if seen[path] {
+			t.Errorf("visited %s twice", path)
+		}
+		seen[path] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != n+1 {
+		t.Errorf("visited %d paths, want %d", len(seen), n+1)
+	}
+
+	// SkipDir from a file skips the rest of its directory.
+	visited := 0
+	err = filepath.WalkDirUnsorted(dir, func(path string, d fs.DirEntry, err error) error {
+		visited++
+		if !d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil || visited != 2 {
+		t.Errorf("WalkDirUnsorted with SkipDir visited %d paths, %v, want 2, nil", visited, err)
+	}
+}
+
 func testWalk(t *testing.T, walk func(string, fs.WalkDirFunc) error, errVisit int) {
 	if runtime.GOOS == "ios" {
 		restore := chtmpdir(t)
@@ -52,3 +52,19 @@ func join(elem []string) string {
 func sameWord(a, b string) bool {
 	return a == b
 }
+
+func toExtendedLength(path string) string {
+	return path
+}
+
+func fromExtendedLength(path string) string {
+	return path
+}
+
+func splitUNC(path string) (server, share, rest string, ok bool) {
+	return "", "", "", false
+}
+
+func isDevicePath(path string) bool {
+	return false
+}
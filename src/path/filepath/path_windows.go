@@ -198,3 +198,62 @@ func isUNC(path string) bool {
 func sameWord(a, b string) bool {
 	return strings.EqualFold(a, b)
 }
+
+// isDevicePath reports whether path begins with \\.\ or \\?\.
+func isDevicePath(path string) bool {
+	return len(path) >= 4 && isSlash(path[0]) && isSlash(path[1]) &&
+		(path[2] == '.' || path[2] == '?') && isSlash(path[3])
+}
+
+// extendedUNCPrefix is the prefix of the extended-length form
+// of a UNC path.
+const extendedUNCPrefix = `\\?\UNC\`
+
+func toExtendedLength(path string) string {
+	if isDevicePath(path) || isReservedName(path) {
+		return path
+	}
+	if _, _, _, ok := splitUNC(path); ok {
+		server, share, rest, _ := splitUNC(Clean(path))
+		return extendedUNCPrefix + server + `\` + share + rest
+	}
+	if !IsAbs(path) {
+		return path
+	}
+	return `\\?\` + Clean(path)
+}
+
+func fromExtendedLength(path string) string {
+	if len(path) < 4 || path[:4] != `\\?\` {
+		return path
+	}
+	if len(path) >= len(extendedUNCPrefix) && strings.EqualFold(path[:len(extendedUNCPrefix)], extendedUNCPrefix) {
+		return `\\` + path[len(extendedUNCPrefix):]
+	}
+	if p := path[4:]; len(p) >= 2 && p[1] == ':' && volumeNameLen(p) == 2 {
+		return p
+	}
+	return path
+}
+
+func splitUNC(path string) (server, share, rest string, ok bool) {
+	if len(path) >= len(extendedUNCPrefix) && strings.EqualFold(path[:len(extendedUNCPrefix)], extendedUNCPrefix) {
+		path = `\\` + path[len(extendedUNCPrefix):]
+	} else if isDevicePath(path) {
+		// Other paths in the device namespaces, as \\?\C:\dir
+		// and \\.\pipe\name, would look like shares of the
+		// servers "?" and ".".
+		return "", "", "", false
+	}
+	n := volumeNameLen(path)
+	if n == 0 || !isSlash(path[0]) {
+		return "", "", "", false
+	}
+	vol := path[2:n]
+	for i := 0; i < len(vol); i++ {
+		if isSlash(vol[i]) {
+			return vol[:i], vol[i+1:], path[n:], true
+		}
+	}
+	return "", "", "", false
+}
@@ -452,6 +452,74 @@ func TestUNC(t *testing.T) {
 	filepath.Glob(`\\?\c:\*`)
 }
 
+var extendedLengthTests = []struct {
+	path, extended, back string
+}{
+	{`C:\dir\file`, `\\?\C:\dir\file`, `C:\dir\file`},
+	{`c:/dir/./sub/../file`, `\\?\c:\dir\file`, `c:\dir\file`},
+	{`C:\`, `\\?\C:\`, `C:\`},
+	{`\\server\share\dir\file`, `\\?\UNC\server\share\dir\file`, `\\server\share\dir\file`},
+	{`//server/share`, `\\?\UNC\server\share`, `\\server\share`},
+	{`\\?\C:\dir`, `\\?\C:\dir`, `C:\dir`},
+	{`\\?\UNC\server\share\x`, `\\?\UNC\server\share\x`, `\\server\share\x`},
+	{`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\x`, `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\x`, `\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\x`},
+	{`\\.\COM1`, `\\.\COM1`, `\\.\COM1`},
+	{`dir\file`, `dir\file`, `dir\file`},
+	{`\dir\file`, `\dir\file`, `\dir\file`},
+	{`NUL`, `NUL`, `NUL`},
+}
+
+func TestExtendedLength(t *testing.T) {
+	for _, tt := range extendedLengthTests {
+		if got := filepath.ToExtendedLength(tt.path); got != tt.extended {
+			t.Errorf("ToExtendedLength(%#q) = %#q, want %#q", tt.path, got, tt.extended)
+		}
+		if got := filepath.FromExtendedLength(tt.extended); got != tt.back {
+			t.Errorf("FromExtendedLength(%#q) = %#q, want %#q", tt.extended, got, tt.back)
+		}
+	}
+}
+
+func TestSplitUNC(t *testing.T) {
+	tests := []struct {
+		path, server, share, rest string
+		ok                        bool
+	}{
+		{`\\server\share\dir\file`, "server", "share", `\dir\file`, true},
+		{`//server/share`, "server", "share", "", true},
+		{`\\?\UNC\server\share\x`, "server", "share", `\x`, true},
+		{`\\?\unc\server\share`, "server", "share", "", true},
+		{`C:\dir`, "", "", "", false},
+		{`\\server`, "", "", "", false},
+		{`\\.\pipe\name`, "", "", "", false},
+		{`\\?\C:\dir`, "", "", "", false},
+		{`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\dir`, "", "", "", false},
+		{`\\.\C:\dir`, "", "", "", false},
+		{`//?/C:/dir`, "", "", "", false},
+		{`\\?\UNCX\server\share`, "", "", "", false},
+	}
+	for _, tt := range tests {
+		server, share, rest, ok := filepath.SplitUNC(tt.path)
+		if server != tt.server || share != tt.share || rest != tt.rest || ok != tt.ok {
+			t.Errorf("SplitUNC(%#q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+				tt.path, server, share, rest, ok, tt.server, tt.share, tt.rest, tt.ok)
+		}
+	}
+
+	for path, want := range map[string]bool{
+		`\\.\COM1`:       true,
+		`\\.\pipe\name`:  true,
+		`\\?\C:\file`:    true,
+		`//./COM1`:       true,
+		`\\server\share`: false,
+		`C:\file`:        false,
+	} {
+		if got := filepath.IsDevicePath(path); got != want {
+			t.Errorf("IsDevicePath(%#q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
 func testWalkMklink(t *testing.T, linktype string) {
 	output, _ := exec.Command("cmd", "/c", "mklink", "/?").Output()
 	if !strings.Contains(string(output), fmt.Sprintf(" /%s ", linktype)) {
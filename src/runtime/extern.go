@@ -100,6 +100,10 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	When set to 0 memory profiling is disabled.  Refer to the description of
 	MemProfileRate for the default value.
 
+	netpolluring: setting netpolluring=1 makes the network poller use io_uring
+	instead of epoll on Linux 5.19 and later, on amd64 and arm64. This is
+	experimental. The poller falls back to epoll if the kernel lacks support.
+
 	invalidptr: invalidptr=1 (the default) causes the garbage collector and stack
 	copier to crash the program if an invalid pointer value (for example, 1)
 	is found in a pointer-typed location. Setting invalidptr=0 disables this check.
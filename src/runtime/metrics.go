@@ -19,8 +19,9 @@ var (
 	metricsInit bool
 	metrics     map[string]metricData
 
-	sizeClassBuckets []float64
-	timeHistBuckets  []float64
+	sizeClassBuckets        []float64
+	timeHistBuckets         []float64
+	netpollBatchHistBuckets []float64
 )
 
 type metricData struct {
@@ -64,6 +65,7 @@ func initMetrics() {
 	sizeClassBuckets = append(sizeClassBuckets, float64Inf())
 
 	timeHistBuckets = timeHistogramMetricsBuckets()
+	netpollBatchHistBuckets = netpollBatchMetricsBuckets()
 	metrics = map[string]metricData{
 		"/gc/cycles/automatic:gc-cycles": {
 			deps: makeStatDepSet(sysStatsDep),
@@ -289,6 +291,53 @@ func initMetrics() {
 				}
 			},
 		},
+		"/sched/netpoll/batches:goroutines": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(netpollBatchHistBuckets)
+				for i := range netpollStats.batches {
+					hist.counts[i] = atomic.Load64(&netpollStats.batches[i])
+				}
+			},
+		},
+		"/sched/netpoll/polls:calls": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = atomic.Load64(&netpollStats.polls)
+			},
+		},
+		"/sched/netpoll/registered:descriptors": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = uint64(atomic.Loadint64(&netpollStats.fds))
+			},
+		},
+		"/sched/netpoll/waits:seconds": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				hist := out.float64HistOrInit(timeHistBuckets)
+				hist.counts[0] = atomic.Load64(&netpollStats.waits.underflow)
+				for i := range netpollStats.waits.counts {
+					hist.counts[i+1] = atomic.Load64(&netpollStats.waits.counts[i])
+				}
+			},
+		},
+		"/sched/netpoll/wakeups:calls": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = atomic.Load64(&netpollStats.wakeups)
+			},
+		},
+		"/sched/timers/fired:timers": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = atomic.Load64(&timersFired)
+			},
+		},
+		"/sched/timers:timers": {
+			compute: func(_ *statAggregate, out *metricValue) {
+				out.kind = metricKindUint64
+				out.scalar = timerCount()
+			},
+		},
 	}
 	metricsInit = true
 }
@@ -224,6 +224,46 @@ var allDesc = []Description{
 		Description: "Distribution of the time goroutines have spent in the scheduler in a runnable state before actually running.",
 		Kind:        KindFloat64Histogram,
 	},
+	{
+		Name:        "/sched/netpoll/batches:goroutines",
+		Description: "Distribution of the number of goroutines made runnable by each call to the network poller, including calls that made none runnable.",
+		Kind:        KindFloat64Histogram,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/netpoll/polls:calls",
+		Description: "Count of calls to the network poller, such as epoll_wait or kevent, whether blocking or not.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/netpoll/registered:descriptors",
+		Description: "Count of file descriptors registered with the network poller.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/sched/netpoll/waits:seconds",
+		Description: "Distribution of the time spent in calls to the network poller that block until a descriptor is ready, a timer expires, or the poller is woken up.",
+		Kind:        KindFloat64Histogram,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/netpoll/wakeups:calls",
+		Description: "Count of times the scheduler woke up a blocked network poller, for example to run a new goroutine or an earlier timer.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/timers/fired:timers",
+		Description: "Count of timers that have fired, including each firing of periodic timers such as those of a time.Ticker.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/sched/timers:timers",
+		Description: "Count of pending timers, including those of time.Sleep, time.Timer and time.Ticker, and of network deadlines.",
+		Kind:        KindUint64,
+	},
 }
 
 // All returns a slice of containing metric descriptions for all supported metrics.
@@ -170,5 +170,34 @@ Below is the full list of supported metrics, ordered lexicographically.
 	/sched/latencies:seconds
 		Distribution of the time goroutines have spent in the scheduler
 		in a runnable state before actually running.
+
+	/sched/netpoll/batches:goroutines
+		Distribution of the number of goroutines made runnable by each
+		call to the network poller, including calls that made none
+		runnable.
+
+	/sched/netpoll/polls:calls
+		Count of calls to the network poller, such as epoll_wait or
+		kevent, whether blocking or not.
+
+	/sched/netpoll/registered:descriptors
+		Count of file descriptors registered with the network poller.
+
+	/sched/netpoll/waits:seconds
+		Distribution of the time spent in calls to the network poller
+		that block until a descriptor is ready, a timer expires, or the
+		poller is woken up.
+
+	/sched/netpoll/wakeups:calls
+		Count of times the scheduler woke up a blocked network poller,
+		for example to run a new goroutine or an earlier timer.
+
+	/sched/timers/fired:timers
+		Count of timers that have fired, including each firing of
+		periodic timers such as those of a time.Ticker.
+
+	/sched/timers:timers
+		Count of pending timers, including those of time.Sleep,
+		time.Timer and time.Ticker, and of network deadlines.
 */
 package metrics
@@ -5,6 +5,7 @@
 package runtime_test
 
 import (
+	"os"
 	"runtime"
 	"runtime/metrics"
 	"sort"
@@ -284,6 +285,63 @@ func TestReadMetricsConsistency(t *testing.T) {
 	}
 }
 
+func TestNetpollAndTimerMetrics(t *testing.T) {
+	names := []string{
+		"/sched/netpoll/batches:goroutines",
+		"/sched/netpoll/polls:calls",
+		"/sched/netpoll/registered:descriptors",
+		"/sched/timers/fired:timers",
+		"/sched/timers:timers",
+	}
+	read := func() []metrics.Sample {
+		samples := make([]metrics.Sample, len(names))
+		for i := range samples {
+			samples[i].Name = names[i]
+		}
+		metrics.Read(samples)
+		return samples
+	}
+	before := read()
+
+	// Register descriptors with the poller where pipes use it,
+	// and wait for timers.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	after := read()
+
+	// The poller is only used once a descriptor is registered.
+	if runtime.GOOS != "windows" && runtime.GOOS != "plan9" && runtime.GOOS != "js" {
+		if got := after[2].Value.Uint64(); got < 2 {
+			t.Errorf("%d descriptors registered with the poller, want at least 2", got)
+		}
+		// Each call to the poller is counted in one batch. Other
+		// threads may poll meanwhile, so only compare the number of
+		// calls with that before.
+		var batches uint64
+		for _, c := range after[0].Value.Float64Histogram().Counts {
+			batches += c
+		}
+		if polls := after[1].Value.Uint64(); batches == 0 || polls <= before[1].Value.Uint64() {
+			t.Errorf("%d netpoll batches and %d calls, want more than %d calls", batches, polls, before[1].Value.Uint64())
+		}
+	}
+	if got, min := after[3].Value.Uint64(), before[3].Value.Uint64()+3; got < min {
+		t.Errorf("%d timers fired, want at least %d", got, min)
+	}
+	if got := after[4].Value.Uint64(); got < 1 {
+		t.Errorf("%d pending timers, want at least 1", got)
+	}
+}
+
 func BenchmarkReadMetricsLatency(b *testing.B) {
 	stop := applyGCLoad(b)
 
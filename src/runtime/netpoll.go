@@ -23,8 +23,8 @@ import (
 //     Arm edge-triggered notifications for fd. The pd argument is to pass
 //     back to netpollready when fd is ready. Return an errno value.
 //
-// func netpollclose(fd uintptr) int32
-//     Disable notifications for fd. Return an errno value.
+// func netpollclose(fd uintptr, pd *pollDesc) int32
+//     Disable notifications for fd, which pd was opened for. Return an errno value.
 //
 // func netpoll(delta int64) gList
 //     Poll the network. If delta < 0, block indefinitely. If delta == 0,
@@ -94,6 +94,9 @@ type pollDesc struct {
 	wt      timer     // write deadline timer
 	wd      int64     // write deadline
 	self    *pollDesc // storage for indirect interface. See (*pollDesc).makeArg.
+
+	uringSeq   uint32 // generation of the io_uring poll request, see netpoll_uring_linux.go
+	uringArmed bool   // the io_uring poll request is armed; protected by uring.lock
 }
 
 type pollCache struct {
@@ -170,6 +173,7 @@ func poll_runtime_pollOpen(fd uintptr) (*pollDesc, int) {
 		pollcache.free(pd)
 		return nil, int(errno)
 	}
+	atomic.Xaddint64(&netpollStats.fds, 1)
 	return pd, 0
 }
 
@@ -184,7 +188,8 @@ func poll_runtime_pollClose(pd *pollDesc) {
 	if pd.rg != 0 && pd.rg != pdReady {
 		throw("runtime: blocked read on closing polldesc")
 	}
-	netpollclose(pd.fd)
+	netpollclose(pd.fd, pd)
+	atomic.Xaddint64(&netpollStats.fds, -1)
 	pollcache.free(pd)
 }
 
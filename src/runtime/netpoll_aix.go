@@ -95,7 +95,7 @@ func netpollopen(fd uintptr, pd *pollDesc) int32 {
 	return 0
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
 	lock(&mtxpoll)
 	netpollwakeup()
 
@@ -136,6 +136,7 @@ func netpollarm(pd *pollDesc, mode int) {
 // netpollBreak interrupts a poll.
 func netpollBreak() {
 	if atomic.Cas(&netpollWakeSig, 0, 1) {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		b := [1]byte{0}
 		write(uintptr(wrwake), unsafe.Pointer(&b[0]), 1)
 	}
@@ -31,6 +31,9 @@ var (
 )
 
 func netpollinit() {
+	if debug.netpolluring != 0 && netpollinitUring() {
+		return
+	}
 	epfd = epollcreate1(_EPOLL_CLOEXEC)
 	if epfd < 0 {
 		epfd = epollcreate(1024)
@@ -59,17 +62,23 @@ func netpollinit() {
 }
 
 func netpollIsPollDescriptor(fd uintptr) bool {
-	return fd == uintptr(epfd) || fd == netpollBreakRd || fd == netpollBreakWr
+	return fd == uintptr(epfd) || fd == uintptr(uringfd) || fd == netpollBreakRd || fd == netpollBreakWr
 }
 
 func netpollopen(fd uintptr, pd *pollDesc) int32 {
+	if uringfd >= 0 {
+		return uringopen(fd, pd)
+	}
 	var ev epollevent
 	ev.events = _EPOLLIN | _EPOLLOUT | _EPOLLRDHUP | _EPOLLET
 	*(**pollDesc)(unsafe.Pointer(&ev.data)) = pd
 	return -epollctl(epfd, _EPOLL_CTL_ADD, int32(fd), &ev)
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
+	if uringfd >= 0 {
+		return uringclose(fd, pd)
+	}
 	var ev epollevent
 	return -epollctl(epfd, _EPOLL_CTL_DEL, int32(fd), &ev)
 }
@@ -81,6 +90,7 @@ func netpollarm(pd *pollDesc, mode int) {
 // netpollBreak interrupts an epollwait.
 func netpollBreak() {
 	if atomic.Cas(&netpollWakeSig, 0, 1) {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		for {
 			var b byte
 			n := write(netpollBreakWr, unsafe.Pointer(&b), 1)
@@ -105,6 +115,9 @@ func netpollBreak() {
 // delay == 0: does not block, just polls
 // delay > 0: block for up to that many nanoseconds
 func netpoll(delay int64) gList {
+	if uringfd >= 0 {
+		return uringpoll(delay)
+	}
 	if epfd == -1 {
 		return gList{}
 	}
@@ -21,7 +21,7 @@ func netpollopen(fd uintptr, pd *pollDesc) int32 {
 	return 0
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
 	return 0
 }
 
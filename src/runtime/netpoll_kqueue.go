@@ -72,7 +72,7 @@ func netpollopen(fd uintptr, pd *pollDesc) int32 {
 	return 0
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
 	// Don't need to unregister because calling close()
 	// on fd will remove any kevents that reference the descriptor.
 	return 0
@@ -85,6 +85,7 @@ func netpollarm(pd *pollDesc, mode int) {
 // netpollBreak interrupts a kevent.
 func netpollBreak() {
 	if atomic.Cas(&netpollWakeSig, 0, 1) {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		for {
 			var b byte
 			n := write(netpollBreakWr, unsafe.Pointer(&b), 1)
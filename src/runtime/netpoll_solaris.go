@@ -150,7 +150,7 @@ func netpollopen(fd uintptr, pd *pollDesc) int32 {
 	return r
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
 	return port_dissociate(portfd, _PORT_SOURCE_FD, fd)
 }
 
@@ -192,6 +192,7 @@ func netpollarm(pd *pollDesc, mode int) {
 // netpollBreak interrupts a port_getn wait.
 func netpollBreak() {
 	if atomic.Cas(&netpollWakeSig, 0, 1) {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		// Use port_alert to put portfd into alert mode.
 		// This will wake up all threads sleeping in port_getn on portfd,
 		// and cause their calls to port_getn to return immediately.
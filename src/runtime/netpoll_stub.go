@@ -28,6 +28,7 @@ func netpollBreak() {
 	broken := netpollBroken
 	netpollBroken = true
 	if !broken {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		notewakeup(&netpollNote)
 	}
 	unlock(&netpollBrokenLock)
@@ -0,0 +1,476 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+// An io_uring backend for the network poller, enabled with
+// GODEBUG=netpolluring=1.
+//
+// Rather than one epoll_ctl call per descriptor, pollers queue
+// multishot IORING_OP_POLL_ADD requests on a submission ring and the
+// kernel posts readiness events to a completion ring, which netpoll
+// reaps without a system call when events are already pending. The
+// completions report readiness like epoll in edge-triggered mode,
+// so the rest of the poller and package internal/poll are unchanged.
+//
+// The backend needs Linux 5.13, for multishot polls; on older kernels
+// netpollinit falls back to epoll.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+//go:noescape
+func uringsetup(entries uint32, params *uringParams) int32
+
+//go:noescape
+func uringenter(fd int32, toSubmit, minComplete, flags uint32, arg unsafe.Pointer, argsz uintptr) int32
+
+const (
+	_IORING_SETUP_CQSIZE = 1 << 3
+	_IORING_SETUP_CLAMP  = 1 << 4
+
+	_IORING_FEAT_NODROP  = 1 << 1
+	_IORING_FEAT_EXT_ARG = 1 << 8
+
+	_IORING_OFF_SQ_RING = 0
+	_IORING_OFF_CQ_RING = 0x8000000
+	_IORING_OFF_SQES    = 0x10000000
+
+	_IORING_OP_POLL_ADD     = 6
+	_IORING_OP_ASYNC_CANCEL = 14
+
+	_IORING_POLL_ADD_MULTI = 1 << 0
+
+	_IORING_CQE_F_MORE = 1 << 1
+
+	_IORING_SQ_CQ_OVERFLOW = 1 << 1
+
+	_IORING_ENTER_GETEVENTS = 1 << 0
+	_IORING_ENTER_EXT_ARG   = 1 << 3
+
+	_POLLIN    = 0x1
+	_POLLOUT   = 0x4
+	_POLLERR   = 0x8
+	_POLLHUP   = 0x10
+	_POLLRDHUP = 0x2000
+
+	_EPERM = 1
+	_EBUSY = 16
+	_ETIME = 62
+
+	_MAP_SHARED   = 0x1
+	_MAP_POPULATE = 0x8000
+
+	uringSQEntries = 256
+	uringCQEntries = 4096
+
+	// uringBreak is the user data of the poll request on the read
+	// end of the netpollBreak pipe. The user data of other requests
+	// are a *pollDesc and the generation of its request.
+	uringBreak = 1
+
+	// uringCancel is the user data of the requests that cancel the
+	// poll of a descriptor being closed. Its completion is ignored:
+	// uringclose waits for the last completion of the poll itself.
+	uringCancel = 2
+)
+
+type uringSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	resv2                                                           uint64
+}
+
+type uringCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	resv2                                                           uint64
+}
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  uringSQRingOffsets
+	cqOff                                                                  uringCQRingOffsets
+}
+
+type uringSQE struct {
+	opcode   uint8
+	flags    uint8
+	ioprio   uint16
+	fd       int32
+	off      uint64
+	addr     uint64
+	len      uint32
+	opFlags  uint32 // poll32_events, cancel_flags
+	userData uint64
+	pad      [3]uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+type uringGeteventsArg struct {
+	sigmask   uint64
+	sigmaskSz uint32
+	pad       uint32
+	ts        uint64
+}
+
+var uringfd int32 = -1 // io_uring descriptor, or -1 if epoll is used
+
+var uring struct {
+	// lock protects the submission ring and the consumption of the
+	// completion ring. Pollers wait for completions without it.
+	lock mutex
+
+	sqHead, sqTail, sqFlags *uint32
+	sqMask, sqEntries       uint32
+	sqArray                 unsafe.Pointer
+	sqes                    unsafe.Pointer
+	pending                 uint32 // queued submissions not yet entered
+
+	cqHead, cqTail *uint32
+	cqMask         uint32
+	cqes           unsafe.Pointer
+}
+
+// netpollinitUring sets up the io_uring backend. It reports false,
+// leaving no trace, if the kernel cannot support it.
+func netpollinitUring() bool {
+	var p uringParams
+	p.flags = _IORING_SETUP_CQSIZE | _IORING_SETUP_CLAMP
+	p.cqEntries = uringCQEntries
+	fd := uringsetup(uringSQEntries, &p)
+	if fd < 0 {
+		return false
+	}
+	const need = _IORING_FEAT_NODROP | _IORING_FEAT_EXT_ARG
+	if p.features&need != need {
+		closefd(fd)
+		return false
+	}
+	closeonexec(fd)
+
+	sqSize := uintptr(p.sqOff.array) + uintptr(p.sqEntries)*4
+	cqSize := uintptr(p.cqOff.cqes) + uintptr(p.cqEntries)*unsafe.Sizeof(uringCQE{})
+	sqesSize := uintptr(p.sqEntries) * unsafe.Sizeof(uringSQE{})
+	sq, err := mmap(nil, sqSize, _PROT_READ|_PROT_WRITE, _MAP_SHARED|_MAP_POPULATE, fd, _IORING_OFF_SQ_RING)
+	if err != 0 {
+		closefd(fd)
+		return false
+	}
+	cq, err := mmap(nil, cqSize, _PROT_READ|_PROT_WRITE, _MAP_SHARED|_MAP_POPULATE, fd, _IORING_OFF_CQ_RING)
+	if err != 0 {
+		munmap(sq, sqSize)
+		closefd(fd)
+		return false
+	}
+	sqes, err := mmap(nil, sqesSize, _PROT_READ|_PROT_WRITE, _MAP_SHARED|_MAP_POPULATE, fd, _IORING_OFF_SQES)
+	if err != 0 {
+		munmap(cq, cqSize)
+		munmap(sq, sqSize)
+		closefd(fd)
+		return false
+	}
+	teardown := func() {
+		munmap(sqes, sqesSize)
+		munmap(cq, cqSize)
+		munmap(sq, sqSize)
+		closefd(fd)
+		uringfd = -1
+	}
+
+	uringfd = fd
+	uring.sqHead = (*uint32)(add(sq, uintptr(p.sqOff.head)))
+	uring.sqTail = (*uint32)(add(sq, uintptr(p.sqOff.tail)))
+	uring.sqFlags = (*uint32)(add(sq, uintptr(p.sqOff.flags)))
+	uring.sqMask = *(*uint32)(add(sq, uintptr(p.sqOff.ringMask)))
+	uring.sqEntries = p.sqEntries
+	uring.sqArray = add(sq, uintptr(p.sqOff.array))
+	uring.sqes = sqes
+	uring.cqHead = (*uint32)(add(cq, uintptr(p.cqOff.head)))
+	uring.cqTail = (*uint32)(add(cq, uintptr(p.cqOff.tail)))
+	uring.cqMask = *(*uint32)(add(cq, uintptr(p.cqOff.ringMask)))
+	uring.cqes = add(cq, uintptr(p.cqOff.cqes))
+
+	r, w, errno := nonblockingPipe()
+	if errno != 0 {
+		println("runtime: pipe failed with", -errno)
+		throw("runtime: pipe failed")
+	}
+	uringPush(_IORING_OP_POLL_ADD, r, 0, _POLLIN, _IORING_POLL_ADD_MULTI, uringBreak)
+	if errno := uringSubmit(); errno != 0 {
+		println("runtime: io_uring_enter failed with", errno)
+		throw("runtime: netpollinit failed")
+	}
+	// Kernels without multishot polls reject the flag of the poll
+	// on the pipe, which then completes during the submission.
+	ok := true
+	uringReap(func(cqe *uringCQE) {
+		if cqe.userData == uringBreak && cqe.res < 0 {
+			ok = false
+		}
+	})
+	if !ok {
+		closefd(r)
+		closefd(w)
+		teardown()
+		return false
+	}
+	netpollBreakRd = uintptr(r)
+	netpollBreakWr = uintptr(w)
+	return true
+}
+
+// uringUserData returns the user data of the poll request of pd in
+// its generation seq. The generation is kept in the bits that
+// user-space addresses leave unused, so that completions of the
+// requests of a closed descriptor are not taken for completions of
+// its pollDesc's next use.
+func uringUserData(pd *pollDesc, seq uint32) uint64 {
+	return uint64(uintptr(unsafe.Pointer(pd))) | uint64(seq&0xffff)<<48
+}
+
+// uringPush queues a submission. uring.lock must be held, except
+// during netpollinit.
+func uringPush(opcode uint8, fd int32, addr uint64, opFlags, len uint32, userData uint64) {
+	tail := *uring.sqTail
+	if tail-atomic.Load(uring.sqHead) >= uring.sqEntries {
+		if errno := uringSubmit(); errno != 0 {
+			println("runtime: io_uring_enter failed with", errno)
+			throw("runtime: netpoll failed")
+		}
+	}
+	idx := tail & uring.sqMask
+	sqe := (*uringSQE)(add(uring.sqes, uintptr(idx)*unsafe.Sizeof(uringSQE{})))
+	*sqe = uringSQE{
+		opcode:   opcode,
+		fd:       fd,
+		addr:     addr,
+		len:      len,
+		opFlags:  opFlags,
+		userData: userData,
+	}
+	*(*uint32)(add(uring.sqArray, uintptr(idx)*4)) = idx
+	atomic.Store(uring.sqTail, tail+1)
+	uring.pending++
+}
+
+// uringSubmit enters the queued submissions. It returns 0 or an
+// errno value. uring.lock must be held, except during netpollinit.
+func uringSubmit() int32 {
+	for uring.pending > 0 {
+		n := uringenter(uringfd, uring.pending, 0, 0, nil, 0)
+		if n < 0 {
+			switch -n {
+			case _EINTR:
+				continue
+			case _EAGAIN, _EBUSY:
+				// The completion ring is full and completions
+				// are waiting to be posted. Let the kernel
+				// move what fits and try again.
+				uringenter(uringfd, 0, 0, _IORING_ENTER_GETEVENTS, nil, 0)
+				osyield()
+				continue
+			}
+			return -n
+		}
+		uring.pending -= uint32(n)
+	}
+	return 0
+}
+
+// uringReap passes the completions posted so far to f and consumes
+// them. uring.lock must be held, except during netpollinit.
+func uringReap(f func(cqe *uringCQE)) {
+	head := *uring.cqHead
+	tail := atomic.Load(uring.cqTail)
+	for ; head != tail; head++ {
+		f((*uringCQE)(add(uring.cqes, uintptr(head&uring.cqMask)*unsafe.Sizeof(uringCQE{}))))
+	}
+	atomic.Store(uring.cqHead, head)
+}
+
+func uringopen(fd uintptr, pd *pollDesc) int32 {
+	seq := atomic.Xadd(&pd.uringSeq, 1)
+	ud := uringUserData(pd, seq)
+	lock(&uring.lock)
+	uringPush(_IORING_OP_POLL_ADD, int32(fd), 0, _POLLIN|_POLLOUT|_POLLRDHUP, _IORING_POLL_ADD_MULTI, ud)
+	errno := uringSubmit()
+	if errno == 0 {
+		// Requests that fail, and polls of files that are always
+		// ready, such as regular files, complete during the
+		// submission and do not stay armed. Report those files
+		// as not pollable, as epoll does.
+		head := *uring.cqHead
+		tail := atomic.Load(uring.cqTail)
+		for ; head != tail; head++ {
+			cqe := (*uringCQE)(add(uring.cqes, uintptr(head&uring.cqMask)*unsafe.Sizeof(uringCQE{})))
+			if cqe.userData != ud || cqe.flags&_IORING_CQE_F_MORE != 0 {
+				continue
+			}
+			if cqe.res < 0 {
+				errno = -cqe.res
+			} else {
+				errno = _EPERM
+			}
+			break
+		}
+	}
+	if errno != 0 {
+		// Make netpoll ignore the completion.
+		atomic.Xadd(&pd.uringSeq, 1)
+	}
+	pd.uringArmed = errno == 0
+	unlock(&uring.lock)
+	return errno
+}
+
+func uringclose(fd uintptr, pd *pollDesc) int32 {
+	// The poll request holds a reference to the file, so it must be
+	// gone before the descriptor is closed, or the file would stay
+	// open for a while. Cancel it by its user data, which leaves the
+	// polls of duplicates of the descriptor alone, and wait for its
+	// last completion, which comes on the thread that submitted it.
+	// Polls may also end without being cancelled here, when that
+	// thread exits; uringComplete tells either way by pd.closing.
+	lock(&uring.lock)
+	var errno int32
+	if pd.uringArmed {
+		uringPush(_IORING_OP_ASYNC_CANCEL, -1, uringUserData(pd, atomic.Load(&pd.uringSeq)), 0, 0, uringCancel)
+		errno = uringSubmit()
+	}
+	var toRun gList
+	for errno == 0 && pd.uringArmed {
+		uringReap(func(cqe *uringCQE) {
+			uringComplete(cqe, &toRun, 0)
+		})
+		if !pd.uringArmed {
+			break
+		}
+		unlock(&uring.lock)
+		osyield()
+		lock(&uring.lock)
+	}
+	unlock(&uring.lock)
+	if !toRun.empty() {
+		injectglist(&toRun)
+	}
+	return errno
+}
+
+func uringpoll(delay int64) gList {
+	if atomic.Load(uring.cqTail) == atomic.Load(uring.cqHead) && delay != 0 {
+		var arg uringGeteventsArg
+		var ts timespec
+		if delay > 0 {
+			ts.setNsec(delay)
+			arg.ts = uint64(uintptr(unsafe.Pointer(&ts)))
+		}
+		n := uringenter(uringfd, 0, 1, _IORING_ENTER_GETEVENTS|_IORING_ENTER_EXT_ARG, unsafe.Pointer(&arg), unsafe.Sizeof(arg))
+		if n < 0 && n != -_ETIME && n != -_EINTR {
+			println("runtime: io_uring_enter on fd", uringfd, "failed with", -n)
+			throw("runtime: netpoll failed")
+		}
+		// An interrupted sleep returns to recalculate how long
+		// to sleep, like a timed out one.
+	}
+
+	var toRun gList
+	lock(&uring.lock)
+	if atomic.Load(uring.sqFlags)&_IORING_SQ_CQ_OVERFLOW != 0 {
+		uringenter(uringfd, 0, 0, _IORING_ENTER_GETEVENTS, nil, 0)
+	}
+	uringReap(func(cqe *uringCQE) {
+		uringComplete(cqe, &toRun, delay)
+	})
+	if errno := uringSubmit(); errno != 0 {
+		println("runtime: io_uring_enter on fd", uringfd, "failed with", errno)
+		throw("runtime: netpoll failed")
+	}
+	unlock(&uring.lock)
+	return toRun
+}
+
+// uringComplete handles a completion, adding the goroutines that it
+// makes ready to toRun. delay is that of the netpoll call. uring.lock
+// must be held.
+func uringComplete(cqe *uringCQE, toRun *gList, delay int64) {
+	switch cqe.userData {
+	case uringCancel:
+		return
+	case uringBreak:
+		if cqe.flags&_IORING_CQE_F_MORE == 0 {
+			uringPush(_IORING_OP_POLL_ADD, int32(netpollBreakRd), 0, _POLLIN, _IORING_POLL_ADD_MULTI, uringBreak)
+		}
+		if cqe.res < 0 {
+			return
+		}
+		if delay != 0 {
+			var tmp [16]byte
+			for read(int32(netpollBreakRd), noescape(unsafe.Pointer(&tmp[0])), int32(len(tmp))) > 0 {
+			}
+			atomic.Store(&netpollWakeSig, 0)
+		} else {
+			// netpollBreak could be picked up by a nonblocking
+			// poll, and unlike with epoll a later poll would not
+			// see the pipe ready again. Pass the wakeup on.
+			var b byte
+			write(netpollBreakWr, unsafe.Pointer(&b), 1)
+		}
+		return
+	}
+
+	pd := (*pollDesc)(unsafe.Pointer(uintptr(cqe.userData & (1<<48 - 1))))
+	seq := uint32(cqe.userData >> 48)
+	if seq != atomic.Load(&pd.uringSeq)&0xffff {
+		// A completion for an earlier use of pd.
+		return
+	}
+	if pd.closing {
+		if cqe.flags&_IORING_CQE_F_MORE == 0 {
+			// The last completion of the poll, which
+			// uringclose waits for.
+			pd.uringArmed = false
+		}
+		return
+	}
+	var r, w bool
+	if cqe.flags&_IORING_CQE_F_MORE == 0 {
+		// The poll is no longer armed, because the completion
+		// ring overflowed or because the thread that submitted
+		// it exited. Arm it again and wake both directions,
+		// which may have missed events in the meantime.
+		uringPush(_IORING_OP_POLL_ADD, int32(pd.fd), 0, _POLLIN|_POLLOUT|_POLLRDHUP, _IORING_POLL_ADD_MULTI, cqe.userData)
+		r, w = true, true
+	}
+	if cqe.res > 0 {
+		ev := uint32(cqe.res)
+		if ev&(_POLLIN|_POLLRDHUP|_POLLHUP|_POLLERR) != 0 {
+			r = true
+		}
+		if ev&(_POLLOUT|_POLLHUP|_POLLERR) != 0 {
+			w = true
+		}
+		pd.everr = ev == _POLLERR
+	}
+	var mode int32
+	if r {
+		mode += 'r'
+	}
+	if w {
+		mode += 'w'
+	}
+	if mode != 0 {
+		netpollready(toRun, pd, mode)
+	}
+}
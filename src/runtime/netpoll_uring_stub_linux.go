@@ -0,0 +1,16 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !amd64 && !arm64
+// +build linux,!amd64,!arm64
+
+package runtime
+
+var uringfd int32 = -1
+
+func netpollinitUring() bool { return false }
+
+func uringopen(fd uintptr, pd *pollDesc) int32  { return 0 }
+func uringclose(fd uintptr, pd *pollDesc) int32 { return 0 }
+func uringpoll(delay int64) gList               { return gList{} }
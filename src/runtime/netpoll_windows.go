@@ -57,7 +57,7 @@ func netpollopen(fd uintptr, pd *pollDesc) int32 {
 	return 0
 }
 
-func netpollclose(fd uintptr) int32 {
+func netpollclose(fd uintptr, pd *pollDesc) int32 {
 	// nothing to do
 	return 0
 }
@@ -68,6 +68,7 @@ func netpollarm(pd *pollDesc, mode int) {
 
 func netpollBreak() {
 	if atomic.Cas(&netpollWakeSig, 0, 1) {
+		atomic.Xadd64(&netpollStats.wakeups, 1)
 		if stdcall4(_PostQueuedCompletionStatus, iocphandle, 0, 0, 0) == 0 {
 			println("runtime: netpoll: PostQueuedCompletionStatus failed (errno=", getlasterror(), ")")
 			throw("runtime: netpoll: PostQueuedCompletionStatus failed")
@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"runtime/internal/sys"
+)
+
+// netpollBatchBuckets is the number of buckets of the distribution of
+// the number of goroutines made ready by a call to netpoll. Bucket 0
+// counts the calls that made none ready, bucket i>0 those that made
+// [1<<(i-1), 1<<i) ready, except that the last bucket has no upper
+// bound.
+const netpollBatchBuckets = 12
+
+// netpollStats holds the activity of the network poller, for
+// runtime/metrics. The fields are accessed atomically.
+var netpollStats struct {
+	// waits is the distribution of the durations of the calls to
+	// netpoll that block.
+	waits timeHistogram
+
+	batches [netpollBatchBuckets]uint64
+	polls   uint64 // calls to netpoll
+	wakeups uint64 // calls to netpollBreak that interrupted netpoll
+	fds     int64  // descriptors registered with the poller
+}
+
+// netpollRecorded calls netpoll(delay) and records the call in
+// netpollStats.
+func netpollRecorded(delay int64) gList {
+	var start int64
+	if delay != 0 {
+		start = nanotime()
+	}
+	list := netpoll(delay)
+	if delay != 0 {
+		netpollStats.waits.record(nanotime() - start)
+	}
+
+	n := uint64(0)
+	for gp := list.head.ptr(); gp != nil; gp = gp.schedlink.ptr() {
+		n++
+	}
+	i := sys.Len64(n)
+	if i >= netpollBatchBuckets {
+		i = netpollBatchBuckets - 1
+	}
+	atomic.Xadd64(&netpollStats.batches[i], 1)
+	atomic.Xadd64(&netpollStats.polls, 1)
+	return list
+}
+
+// netpollBatchMetricsBuckets returns the boundaries of the buckets of
+// netpollStats.batches, for runtime/metrics.
+func netpollBatchMetricsBuckets() []float64 {
+	b := make([]float64, netpollBatchBuckets+1)
+	for i := 1; i < netpollBatchBuckets; i++ {
+		b[i] = float64(uint64(1) << (i - 1))
+	}
+	b[netpollBatchBuckets] = float64Inf()
+	return b
+}
@@ -1190,6 +1190,7 @@ func ctrlHandler(_type uint32) uintptr {
 		return 0
 	}
 
+	sigqueuepush(s, 0, 0, 0, 0, 0)
 	if sigsend(s) {
 		if s == _SIGTERM {
 			// Windows terminates the process after this handler returns.
@@ -310,6 +310,7 @@ var debug struct {
 	gctrace            int32
 	invalidptr         int32
 	madvdontneed       int32 // for Linux; issue 28466
+	netpolluring       int32 // for Linux
 	scavtrace          int32
 	scheddetail        int32
 	schedtrace         int32
@@ -337,6 +338,7 @@ var dbgvars = []dbgVar{
 	{"gctrace", &debug.gctrace},
 	{"invalidptr", &debug.invalidptr},
 	{"madvdontneed", &debug.madvdontneed},
+	{"netpolluring", &debug.netpolluring},
 	{"sbrk", &debug.sbrk},
 	{"scavtrace", &debug.scavtrace},
 	{"scheddetail", &debug.scheddetail},
@@ -61,3 +61,14 @@ func TestEpollctlErrorSign(t *testing.T) {
 		t.Errorf("epollctl = %v, want %v", v, -EBADF)
 	}
 }
+
+func TestNetpollUring(t *testing.T) {
+	t.Parallel()
+	// The poller falls back to epoll if the kernel lacks io_uring
+	// support, so this passes either way.
+	output := runTestProg(t, "testprognet", "NetpollUring", "GODEBUG=netpolluring=1")
+	want := "OK\n"
+	if output != want {
+		t.Fatalf("want %q, got %q", want, output)
+	}
+}
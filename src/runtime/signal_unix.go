@@ -592,6 +592,7 @@ func sighandler(sig uint32, info *siginfo, ctxt unsafe.Pointer, gp *g) {
 	}
 
 	if c.sigcode() == _SI_USER || flags&_SigNotify != 0 {
+		sigrecordinfo(sig, c)
 		if sigsend(sig) {
 			return
 		}
@@ -697,6 +698,16 @@ func sighandler(sig uint32, info *siginfo, ctxt unsafe.Pointer, gp *g) {
 	exit(2)
 }
 
+// sigrecordinfo records the details of the signal sig, described by c,
+// for os/signal.NotifyInfo and, if sig is queued, os/signal.Stream.
+//go:nowritebarrierrec
+func sigrecordinfo(sig uint32, c *sigctxt) {
+	code := int32(c.sigcode())
+	pid, uid, status, value := sigsender(sig, code, c.info)
+	sigrecord(sig, code, pid, uid, status, value)
+	sigqueuepush(sig, code, pid, uid, status, value)
+}
+
 // sigpanic turns a synchronous signal into a run-time panic.
 // If the signal handler sees a synchronous panic, it arranges the
 // stack to look like the function where the signal occurred called
@@ -60,6 +60,51 @@ var sig struct {
 	inuse      bool
 }
 
+// sigInfo holds the details of the most recent occurrence of a signal,
+// for os/signal.NotifyInfo. It is written by sigrecord, from the signal
+// handler, and read by signal_recv_info. The fields are accessed
+// atomically, under the sequence lock seq, which is odd while the
+// details are written.
+type sigInfo struct {
+	value  uint64 // first, for 64-bit alignment
+	seq    uint32
+	code   uint32
+	pid    uint32
+	uid    uint32
+	status uint32
+	_      uint32
+}
+
+var siginfos [_NSIG]sigInfo
+
+// sigq holds the occurrences of the signals in queued mode, for
+// os/signal.Stream. Unlike the mask of sig, it keeps each occurrence
+// of a signal, up to the size of the ring, in the order they were
+// handled. Signal handlers reserve a slot by advancing tail and mark it
+// ready once written; signal_recv_queued, the only reader, clears the
+// slot and advances head. The occurrences of a signal that do not fit
+// are counted in dropped. All fields are accessed atomically.
+var sigq struct {
+	slots   [sigQueueSize]sigQueueSlot // first, for 64-bit alignment
+	head    uint32
+	tail    uint32
+	queued  [(_NSIG + 31) / 32]uint32
+	dropped [_NSIG]uint32
+}
+
+// sigQueueSize is the number of occurrences that sigq can hold.
+const sigQueueSize = 256
+
+type sigQueueSlot struct {
+	value  uint64
+	ready  uint32
+	sig    uint32
+	code   uint32
+	pid    uint32
+	uid    uint32
+	status uint32
+}
+
 const (
 	sigIdle = iota
 	sigReceiving
@@ -128,6 +173,133 @@ Send:
 	return true
 }
 
+// sigrecord records the details of an occurrence of signal s before it
+// is sent with sigsend. It runs from the signal handler.
+// If another thread is recording the same signal, its details are kept.
+func sigrecord(s uint32, code, pid int32, uid uint32, status int32, value uint64) {
+	if s >= uint32(len(siginfos)) {
+		return
+	}
+	in := &siginfos[s]
+	seq := atomic.Load(&in.seq)
+	if seq&1 != 0 || !atomic.Cas(&in.seq, seq, seq+1) {
+		return
+	}
+	atomic.Store64(&in.value, value)
+	atomic.Store(&in.code, uint32(code))
+	atomic.Store(&in.pid, uint32(pid))
+	atomic.Store(&in.uid, uid)
+	atomic.Store(&in.status, uint32(status))
+	atomic.Store(&in.seq, seq+2)
+}
+
+// Called to receive the details of the most recent occurrence of
+// signal s, after signal_recv returned it.
+//go:linkname signal_recv_info os/signal.signal_recv_info
+func signal_recv_info(s uint32) (code, pid int32, uid uint32, status int32, value uint64) {
+	if s >= uint32(len(siginfos)) {
+		return
+	}
+	in := &siginfos[s]
+	for {
+		seq := atomic.Load(&in.seq)
+		if seq&1 == 0 {
+			value = atomic.Load64(&in.value)
+			code = int32(atomic.Load(&in.code))
+			pid = int32(atomic.Load(&in.pid))
+			uid = atomic.Load(&in.uid)
+			status = int32(atomic.Load(&in.status))
+			if atomic.Load(&in.seq) == seq {
+				return
+			}
+		}
+		// A signal handler is recording the details.
+		Gosched()
+	}
+}
+
+// sigqueuepush appends an occurrence of signal s to sigq, if s is in
+// queued mode, before it is sent with sigsend. It runs from the signal
+// handler.
+func sigqueuepush(s uint32, code, pid int32, uid uint32, status int32, value uint64) {
+	if s >= uint32(len(sigq.dropped)) || atomic.Load(&sigq.queued[s/32])&(1<<(s&31)) == 0 {
+		return
+	}
+	for {
+		tail := atomic.Load(&sigq.tail)
+		if tail-atomic.Load(&sigq.head) >= uint32(len(sigq.slots)) {
+			atomic.Xadd(&sigq.dropped[s], 1)
+			return
+		}
+		if !atomic.Cas(&sigq.tail, tail, tail+1) {
+			continue
+		}
+		sl := &sigq.slots[tail%uint32(len(sigq.slots))]
+		atomic.Store64(&sl.value, value)
+		atomic.Store(&sl.sig, s)
+		atomic.Store(&sl.code, uint32(code))
+		atomic.Store(&sl.pid, uint32(pid))
+		atomic.Store(&sl.uid, uid)
+		atomic.Store(&sl.status, uint32(status))
+		atomic.Store(&sl.ready, 1)
+		return
+	}
+}
+
+// Called to receive the oldest occurrence of a signal in queued mode.
+// It reports false if there is none. Every occurrence is followed by
+// a signal_recv of its signal, so the receiver need only call it after
+// signal_recv returns.
+//go:linkname signal_recv_queued os/signal.signal_recv_queued
+func signal_recv_queued() (s uint32, code, pid int32, uid uint32, status int32, value uint64, ok bool) {
+	head := atomic.Load(&sigq.head)
+	if head == atomic.Load(&sigq.tail) {
+		return
+	}
+	sl := &sigq.slots[head%uint32(len(sigq.slots))]
+	for atomic.Load(&sl.ready) == 0 {
+		// A signal handler is writing the slot.
+		Gosched()
+	}
+	value = atomic.Load64(&sl.value)
+	s = atomic.Load(&sl.sig)
+	code = int32(atomic.Load(&sl.code))
+	pid = int32(atomic.Load(&sl.pid))
+	uid = atomic.Load(&sl.uid)
+	status = int32(atomic.Load(&sl.status))
+	atomic.Store(&sl.ready, 0)
+	atomic.Store(&sigq.head, head+1)
+	return s, code, pid, uid, status, value, true
+}
+
+// Called to receive and reset the number of occurrences of signal s
+// that did not fit in sigq.
+//go:linkname signal_queue_dropped os/signal.signal_queue_dropped
+func signal_queue_dropped(s uint32) uint32 {
+	if s >= uint32(len(sigq.dropped)) {
+		return 0
+	}
+	return atomic.Xchg(&sigq.dropped[s], 0)
+}
+
+// Must only be called from a single goroutine at a time.
+//go:linkname signal_enable_queued os/signal.signal_enable_queued
+func signal_enable_queued(s uint32) {
+	if s >= uint32(len(sigq.dropped)) {
+		return
+	}
+	atomic.Or(&sigq.queued[s/32], 1<<(s&31))
+}
+
+// Must only be called from a single goroutine at a time.
+//go:linkname signal_disable_queued os/signal.signal_disable_queued
+func signal_disable_queued(s uint32) {
+	if s >= uint32(len(sigq.dropped)) {
+		return
+	}
+	atomic.And(&sigq.queued[s/32], ^uint32(1<<(s&31)))
+}
+
 // sigRecvPrepareForFixup is used to temporarily wake up the
 // signal_recv() running thread while it is blocked waiting for the
 // arrival of a signal. If it causes the thread to wake up, the
@@ -0,0 +1,37 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// Values of si_code for signals sent by processes, in addition to
+// _SI_USER.
+const (
+	_SI_QUEUE = -1
+	_SI_MESGQ = -3
+	_SI_TKILL = -6
+)
+
+// sigsender returns the process that sent the signal sig described by
+// info, or, for SIGCHLD, the child whose state changed and its status.
+// For signals queued with sigqueue(3) or a message queue, it also
+// returns the value sent with the signal.
+// It returns zeros if the sender is not known.
+//go:nosplit
+func sigsender(sig uint32, code int32, info *siginfo) (pid int32, uid uint32, status int32, value uint64) {
+	// The fields are in the union that starts with si_addr:
+	// si_pid, si_uid and either si_status, for SIGCHLD, or si_value.
+	u := unsafe.Pointer(&info.si_addr)
+	switch {
+	case sig == _SIGCHLD && code > 0:
+		status = *(*int32)(add(u, 8))
+	case code == _SI_QUEUE, code == _SI_MESGQ:
+		value = uint64(*(*uintptr)(add(u, 8)))
+	case code == _SI_USER, code == _SI_TKILL:
+	default:
+		return 0, 0, 0, 0
+	}
+	return *(*int32)(u), *(*uint32)(add(u, 4)), status, value
+}
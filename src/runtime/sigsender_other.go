@@ -0,0 +1,15 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd netbsd openbsd solaris
+
+package runtime
+
+// sigsender returns zeros: the sender of signals is only reported on
+// Linux.
+//go:nosplit
+func sigsender(sig uint32, code int32, info *siginfo) (pid int32, uid uint32, status int32, value uint64) {
+	return 0, 0, 0, 0
+}
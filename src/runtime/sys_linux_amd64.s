@@ -50,6 +50,8 @@
 #define SYS_epoll_pwait		281
 #define SYS_epoll_create1	291
 #define SYS_pipe2		293
+#define SYS_io_uring_setup	425
+#define SYS_io_uring_enter	426
 
 TEXT runtime·exit(SB),NOSPLIT,$0-4
 	MOVL	code+0(FP), DI
@@ -666,6 +668,28 @@ TEXT runtime·epollwait(SB),NOSPLIT,$0
 	MOVL	AX, ret+24(FP)
 	RET
 
+// func uringsetup(entries uint32, params *uringParams) int32
+TEXT runtime·uringsetup(SB),NOSPLIT,$0
+	MOVL	entries+0(FP), DI
+	MOVQ	params+8(FP), SI
+	MOVL	$SYS_io_uring_setup, AX
+	SYSCALL
+	MOVL	AX, ret+16(FP)
+	RET
+
+// func uringenter(fd int32, toSubmit, minComplete, flags uint32, arg unsafe.Pointer, argsz uintptr) int32
+TEXT runtime·uringenter(SB),NOSPLIT,$0
+	MOVL	fd+0(FP), DI
+	MOVL	toSubmit+4(FP), SI
+	MOVL	minComplete+8(FP), DX
+	MOVL	flags+12(FP), R10
+	MOVQ	arg+16(FP), R8
+	MOVQ	argsz+24(FP), R9
+	MOVL	$SYS_io_uring_enter, AX
+	SYSCALL
+	MOVL	AX, ret+32(FP)
+	RET
+
 // void runtime·closeonexec(int32 fd);
 TEXT runtime·closeonexec(SB),NOSPLIT,$0
 	MOVL    fd+0(FP), DI  // fd
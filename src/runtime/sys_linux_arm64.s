@@ -49,6 +49,8 @@
 #define SYS_socket		198
 #define SYS_connect		203
 #define SYS_brk			214
+#define SYS_io_uring_setup	425
+#define SYS_io_uring_enter	426
 
 TEXT runtime·exit(SB),NOSPLIT|NOFRAME,$0-4
 	MOVW	code+0(FP), R0
@@ -700,6 +702,28 @@ TEXT runtime·epollwait(SB),NOSPLIT|NOFRAME,$0
 	MOVW	R0, ret+24(FP)
 	RET
 
+// func uringsetup(entries uint32, params *uringParams) int32
+TEXT runtime·uringsetup(SB),NOSPLIT|NOFRAME,$0
+	MOVW	entries+0(FP), R0
+	MOVD	params+8(FP), R1
+	MOVD	$SYS_io_uring_setup, R8
+	SVC
+	MOVW	R0, ret+16(FP)
+	RET
+
+// func uringenter(fd int32, toSubmit, minComplete, flags uint32, arg unsafe.Pointer, argsz uintptr) int32
+TEXT runtime·uringenter(SB),NOSPLIT|NOFRAME,$0
+	MOVW	fd+0(FP), R0
+	MOVW	toSubmit+4(FP), R1
+	MOVW	minComplete+8(FP), R2
+	MOVW	flags+12(FP), R3
+	MOVD	arg+16(FP), R4
+	MOVD	argsz+24(FP), R5
+	MOVD	$SYS_io_uring_enter, R8
+	SVC
+	MOVW	R0, ret+32(FP)
+	RET
+
 // void runtime·closeonexec(int32 fd);
 TEXT runtime·closeonexec(SB),NOSPLIT|NOFRAME,$0
 	MOVW	fd+0(FP), R0  // fd
@@ -0,0 +1,135 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"time"
+)
+
+func init() {
+	register("NetpollUring", NetpollUring)
+}
+
+// NetpollUring exercises the network poller, for running with
+// GODEBUG=netpolluring=1.
+func NetpollUring() {
+	if err := netpollUring(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("OK")
+}
+
+func netpollUring() error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	addr := ln.Addr().String()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(c, c)
+				c.Close()
+			}()
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		if err := ping(c, 100); err != nil {
+			return err
+		}
+		b := make([]byte, 4)
+		c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		if _, err := c.Read(b); !errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("read past deadline: %v", err)
+		}
+		c.Close()
+	}
+
+	// The polls that a thread armed end when it exits. A connection
+	// opened on such a thread must keep working, and closing it must
+	// not wait for a cancellation.
+	cc := make(chan net.Conn)
+	go func() {
+		// Exit the thread along with the goroutine.
+		runtime.LockOSThread()
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			c = nil
+		}
+		cc <- c
+	}()
+	c := <-cc
+	if c == nil {
+		return errors.New("dial on a locked thread failed")
+	}
+	if err := ping(c, 10); err != nil {
+		return err
+	}
+
+	// Closing a descriptor must leave the poll of a duplicate alone.
+	f, err := c.(*net.TCPConn).File()
+	if err != nil {
+		return err
+	}
+	dup, err := net.FileConn(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	c.Close()
+	if err := ping(dup, 10); err != nil {
+		return fmt.Errorf("duplicate after close: %v", err)
+	}
+	dup.Close()
+
+	// Closing the listener must release its address right away.
+	ln.Close()
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	ln.Close()
+
+	// Regular files are not pollable.
+	f, err = os.Open(os.Args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.SetDeadline(time.Now()); !errors.Is(err, os.ErrNoDeadline) {
+		return fmt.Errorf("SetDeadline on regular file: %v", err)
+	}
+	return nil
+}
+
+// ping writes to c, and reads the echo, n times.
+func ping(c net.Conn, n int) error {
+	b := make([]byte, 4)
+	for i := 0; i < n; i++ {
+		if _, err := c.Write([]byte("ping")); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(c, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
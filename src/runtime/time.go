@@ -848,6 +848,31 @@ func runtimer(pp *p, now int64) int64 {
 	}
 }
 
+// timersFired is the number of timers run by runOneTimer, for
+// runtime/metrics. It is accessed atomically.
+var timersFired uint64
+
+// timerCount returns the number of timers in the heaps of all Ps,
+// other than deleted ones, for runtime/metrics.
+func timerCount() uint64 {
+	var n int64
+	// Prevent allp slice changes, as in timeSleepUntil.
+	lock(&allpLock)
+	for _, pp := range allp {
+		if pp == nil {
+			// procresize has grown allp but not yet created new Ps.
+			continue
+		}
+		n += int64(atomic.Load(&pp.numTimers)) - int64(atomic.Load(&pp.deletedTimers))
+	}
+	unlock(&allpLock)
+	if n < 0 {
+		// The counts of a P changed between the loads.
+		n = 0
+	}
+	return uint64(n)
+}
+
 // runOneTimer runs a single timer.
 // The caller must have locked the timers for pp.
 // This will temporarily unlock the timers while running the timer function.
@@ -861,6 +886,8 @@ func runOneTimer(pp *p, t *timer, now int64) {
 		raceacquirectx(ppcur.timerRaceCtx, unsafe.Pointer(t))
 	}
 
+	atomic.Xadd64(&timersFired, 1)
+
 	f := t.f
 	arg := t.arg
 	seq := t.seq
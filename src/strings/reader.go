@@ -152,6 +152,25 @@ func (r *Reader) WriteTo(w io.Writer) (n int64, err error) {
 	return
 }
 
+// WriteStringTo implements the io.StringWriterTo interface.
+func (r *Reader) WriteStringTo(w io.StringWriter) (n int64, err error) {
+	r.prevRune = -1
+	if r.i >= int64(len(r.s)) {
+		return 0, nil
+	}
+	s := r.s[r.i:]
+	m, err := w.WriteString(s)
+	if m > len(s) {
+		panic("strings.Reader.WriteStringTo: invalid WriteString count")
+	}
+	r.i += int64(m)
+	n = int64(m)
+	if m != len(s) && err == nil {
+		err = io.ErrShortWrite
+	}
+	return
+}
+
 // Reset resets the Reader to be reading from s.
 func (r *Reader) Reset(s string) { *r = Reader{s, 0, -1} }
 
@@ -158,6 +158,22 @@ func TestWriteTo(t *testing.T) {
 	}
 }
 
+func TestWriteStringTo(t *testing.T) {
+	r := strings.NewReader("0123456789")
+	r.ReadByte()
+	var b strings.Builder
+	n, err := r.WriteStringTo(&b)
+	if n != 9 || err != nil {
+		t.Errorf("WriteStringTo = %d, %v; want 9, nil", n, err)
+	}
+	if got := b.String(); got != "123456789" {
+		t.Errorf("got string %q; want %q", got, "123456789")
+	}
+	if r.Len() != 0 {
+		t.Errorf("reader contains %v bytes; want 0", r.Len())
+	}
+}
+
 // tests that Len is affected by reads, but Size is not.
 func TestReaderLenSize(t *testing.T) {
 	r := strings.NewReader("abc")
@@ -33,7 +33,18 @@ type SysProcAttr struct {
 	// Unlike Setctty, in this case Ctty must be a descriptor
 	// number in the parent process.
 	Foreground bool
-	Pgid       int // Child's process group ID if Setpgid.
+	Pgid       int             // Child's process group ID if Setpgid.
+	Rlimits    []SysProcRlimit // Resource limits set in the child before exec.
+	// UseDirFD specifies whether to make use of the DirFD field.
+	UseDirFD bool
+	// DirFD specifies the file descriptor, in the parent, of a
+	// directory that the child makes its working directory with
+	// fchdir(2), after applying Chroot and ProcAttr.Dir. Unlike
+	// ProcAttr.Dir, it is not resolved again in the child, so the
+	// directory cannot be swapped, for example through a symbolic
+	// link, between its opening and the exec.
+	// This field is only used if UseDirFD is true.
+	DirFD int
 }
 
 // Implemented in runtime package.
@@ -138,6 +149,18 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 	// having the kernel send a SIGTTOU signal to the process group.
 	runtime_AfterForkInChild()
 
+	// Resource limits, except RLIMIT_NOFILE, which is set once
+	// the fds are in place, as they may exceed it until then.
+	for i := range sys.Rlimits {
+		if sys.Rlimits[i].Resource == RLIMIT_NOFILE {
+			continue
+		}
+		_, _, err1 = rawSyscall(abi.FuncPCABI0(libc_setrlimit_trampoline), uintptr(sys.Rlimits[i].Resource), uintptr(unsafe.Pointer(&sys.Rlimits[i].Cur)), 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Chroot
 	if chroot != nil {
 		_, _, err1 = rawSyscall(abi.FuncPCABI0(libc_chroot_trampoline), uintptr(unsafe.Pointer(chroot)), 0, 0)
@@ -176,6 +199,12 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 			goto childerror
 		}
 	}
+	if sys.UseDirFD {
+		_, _, err1 = rawSyscall(abi.FuncPCABI0(libc_fchdir_trampoline), uintptr(sys.DirFD), 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
 
 	// Pass 1: look for fd[i] < i and move those up above len(fd)
 	// so that pass 2 won't stomp on an fd it needs later.
@@ -250,6 +279,17 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 		}
 	}
 
+	// Limit on open files
+	for i := range sys.Rlimits {
+		if sys.Rlimits[i].Resource != RLIMIT_NOFILE {
+			continue
+		}
+		_, _, err1 = rawSyscall(abi.FuncPCABI0(libc_setrlimit_trampoline), uintptr(sys.Rlimits[i].Resource), uintptr(unsafe.Pointer(&sys.Rlimits[i].Cur)), 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Time to exec.
 	_, _, err1 = rawSyscall(abi.FuncPCABI0(libc_execve_trampoline),
 		uintptr(unsafe.Pointer(argv0)),
@@ -56,7 +56,63 @@ type SysProcAttr struct {
 	// This parameter is no-op if GidMappings == nil. Otherwise for unprivileged
 	// users this should be set to false for mappings work.
 	GidMappingsEnableSetgroups bool
-	AmbientCaps                []uintptr // Ambient capabilities (Linux only)
+	AmbientCaps                []uintptr       // Ambient capabilities (Linux only)
+	Rlimits                    []SysProcRlimit // Resource limits set in the child before exec.
+	// UseCgroupFD specifies whether to make use of the CgroupFD field.
+	UseCgroupFD bool
+	// CgroupFD specifies a file descriptor to a cgroup v2 directory,
+	// in which the child is created, using clone3(2) and
+	// CLONE_INTO_CGROUP, so that it never runs in the cgroup of the
+	// parent. This field is only used if UseCgroupFD is true.
+	// It requires Linux 5.7 or later.
+	CgroupFD int
+	// PostClone, if non-nil, is called in the parent with the pid of
+	// the child once the child is created, in the namespaces given by
+	// Cloneflags, and its ID mappings are written, but before the
+	// child applies the other attributes, such as Unshareflags, and
+	// executes the program. It can set up the namespaces of the child
+	// from outside, for example to move a network interface into its
+	// network namespace. If PostClone returns an error, the child exits
+	// and StartProcess fails with the error if it is an Errno, or with
+	// ECANCELED otherwise.
+	PostClone func(pid int) error
+	// CloseOtherFds closes, in the child, every file descriptor other
+	// than those given in ProcAttr.Files, including descriptors that
+	// are not close-on-exec, such as ones opened by C code. It uses
+	// close_range(2) where available (Linux 5.9 and later) and
+	// otherwise closes the descriptors one at a time, up to the
+	// RLIMIT_NOFILE limit of the parent.
+	CloseOtherFds bool
+	// NoNewPrivs sets the no_new_privs flag of the child, so that
+	// neither it nor its descendants can gain privileges when
+	// executing programs, for example set-user-ID ones.
+	NoNewPrivs bool
+	// UseLandlockRuleset specifies whether to make use of the
+	// LandlockRuleset field.
+	UseLandlockRuleset bool
+	// LandlockRuleset specifies the file descriptor of a Landlock
+	// ruleset that the child enforces on itself, using
+	// landlock_restrict_self(2), once its credentials and working
+	// directory are set. It requires Linux 5.13 or later, and
+	// NoNewPrivs unless the child has CAP_SYS_ADMIN.
+	// This field is only used if UseLandlockRuleset is true.
+	LandlockRuleset int
+	// SeccompFilter, if non-empty, is a BPF program that the child
+	// installs as its seccomp filter just before executing the
+	// program, after all other attributes are applied. The filter
+	// must allow execve(2). It requires NoNewPrivs unless the child
+	// has CAP_SYS_ADMIN.
+	SeccompFilter []SockFilter
+	// UseDirFD specifies whether to make use of the DirFD field.
+	UseDirFD bool
+	// DirFD specifies the file descriptor, in the parent, of a
+	// directory that the child makes its working directory with
+	// fchdir(2), after applying Chroot and ProcAttr.Dir. Unlike
+	// ProcAttr.Dir, it is not resolved again in the child, so the
+	// directory cannot be swapped, for example through a symbolic
+	// link, between its opening and the exec.
+	// This field is only used if UseDirFD is true.
+	DirFD int
 }
 
 var (
@@ -64,6 +120,39 @@ var (
 	slash = [...]byte{'/', 0}
 )
 
+// closeFdsLimit returns the bound on the descriptors closed for
+// CloseOtherFds when close_range is not available.
+func closeFdsLimit() int {
+	var lim Rlimit
+	if err := Getrlimit(RLIMIT_NOFILE, &lim); err != nil || lim.Cur > 1<<20 {
+		return 1 << 20
+	}
+	return int(lim.Cur)
+}
+
+// cloneArgs holds arguments for the clone3 Linux system call.
+type cloneArgs struct {
+	flags      uint64 // Flags bit mask
+	pidFD      uint64 // Where to store PID file descriptor (int *)
+	childTID   uint64 // Where to store child TID, in child's memory (pid_t *)
+	parentTID  uint64 // Where to store child TID, in parent's memory (pid_t *)
+	exitSignal uint64 // Signal to deliver to parent on child termination
+	stack      uint64 // Pointer to lowest byte of stack
+	stackSize  uint64 // Size of stack
+	tls        uint64 // Location of new TLS
+	setTID     uint64 // Pointer to a pid_t array (since Linux 5.5)
+	setTIDSize uint64 // Number of elements in set_tid (since Linux 5.5)
+	cgroup     uint64 // File descriptor for target cgroup of child (since Linux 5.7)
+}
+
+// Defined in linux/sched.h starting with Linux 5.7.
+const _CLONE_INTO_CGROUP = 0x200000000
+
+// _CSIGNAL masks the termination signal in the flags of clone(2).
+// clone3(2) takes the signal in exitSignal instead and rejects flags
+// with these bits set.
+const _CSIGNAL = 0xff
+
 // Implemented in runtime package.
 func runtime_BeforeFork()
 func runtime_AfterFork()
@@ -93,7 +182,7 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 	// parent; return PID
 	pid = int(r1)
 
-	if sys.UidMappings != nil || sys.GidMappings != nil {
+	if sys.needsSync() {
 		Close(p[0])
 		var err2 Errno
 		// uid/gid mappings will be written after fork and unshare(2) for user
@@ -103,6 +192,22 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 				err2 = err.(Errno)
 			}
 		}
+		if err2 == 0 && sys.PostClone != nil {
+			// The child has its own copy of the file descriptor
+			// table, so descriptors created from now on cannot leak
+			// into it: release ForkLock so that PostClone can start
+			// processes itself.
+			ForkLock.Unlock()
+			err := sys.PostClone(pid)
+			ForkLock.Lock()
+			if err != nil {
+				if errno, ok := err.(Errno); ok {
+					err2 = errno
+				} else {
+					err2 = ECANCELED
+				}
+			}
+		}
 		RawSyscall(SYS_WRITE, uintptr(p[1]), uintptr(unsafe.Pointer(&err2)), unsafe.Sizeof(err2))
 		Close(p[1])
 	}
@@ -110,6 +215,12 @@ func forkAndExecInChild(argv0 *byte, argv, envv []*byte, chroot, dir *byte, attr
 	return pid, 0
 }
 
+// needsSync reports whether the child must wait for the parent to
+// write its ID mappings or run PostClone before going on.
+func (sys *SysProcAttr) needsSync() bool {
+	return sys.UidMappings != nil || sys.GidMappings != nil || sys.PostClone != nil
+}
+
 const _LINUX_CAPABILITY_VERSION_3 = 0x20080522
 
 type capHeader struct {
@@ -149,6 +260,12 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		PR_CAP_AMBIENT       = 0x2f
 		PR_CAP_AMBIENT_RAISE = 0x2
 	)
+	// Defined in linux/prctl.h and linux/seccomp.h.
+	const (
+		PR_SET_NO_NEW_PRIVS = 0x26
+		SECCOMP_MODE_FILTER = 0x2
+		BPF_MAXINSNS        = 4096
+	)
 
 	// vfork requires that the child not touch any of the parent's
 	// active stack frames. Hence, the child does all post-fork
@@ -165,8 +282,35 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		fd1                       uintptr
 		puid, psetgroups, pgid    []byte
 		uidmap, setgroups, gidmap []byte
+		clone3                    *cloneArgs
+		needsSync                 = sys.needsSync()
+		maxfd                     int
+		seccomp                   *SockFprog
 	)
 
+	if sys.CloseOtherFds {
+		maxfd = closeFdsLimit()
+	}
+
+	if len(sys.SeccompFilter) > 0 {
+		if len(sys.SeccompFilter) > BPF_MAXINSNS {
+			err1 = EINVAL
+			return
+		}
+		seccomp = &SockFprog{
+			Len:    uint16(len(sys.SeccompFilter)),
+			Filter: &sys.SeccompFilter[0],
+		}
+	}
+
+	if sys.UseCgroupFD {
+		clone3 = &cloneArgs{
+			flags:      uint64(sys.Cloneflags&^_CSIGNAL) | _CLONE_INTO_CGROUP,
+			exitSignal: uint64(SIGCHLD) | uint64(sys.Cloneflags&_CSIGNAL),
+			cgroup:     uint64(sys.CgroupFD),
+		}
+	}
+
 	if sys.UidMappings != nil {
 		puid = []byte("/proc/self/uid_map\000")
 		uidmap = formatIDMappings(sys.UidMappings)
@@ -201,8 +345,8 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 	nextfd++
 
 	// Allocate another pipe for parent to child communication for
-	// synchronizing writing of User ID/Group ID mappings.
-	if sys.UidMappings != nil || sys.GidMappings != nil {
+	// synchronizing writing of User ID/Group ID mappings and PostClone.
+	if needsSync {
 		if err := forkExecPipe(p[:]); err != nil {
 			err1 = err.(Errno)
 			return
@@ -214,7 +358,12 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 	runtime_BeforeFork()
 	locked = true
 	switch {
-	case sys.Cloneflags&CLONE_NEWUSER == 0 && sys.Unshareflags&CLONE_NEWUSER == 0:
+	case clone3 != nil:
+		// Like the CLONE_NEWUSER cases below, this does not use
+		// CLONE_VFORK|CLONE_VM: the child runs on a copy of the
+		// parent's memory.
+		r1, _, err1 = RawSyscall(_SYS_clone3, uintptr(unsafe.Pointer(clone3)), unsafe.Sizeof(*clone3), 0)
+	case sys.Cloneflags&CLONE_NEWUSER == 0 && sys.Unshareflags&CLONE_NEWUSER == 0 && sys.PostClone == nil:
 		r1, err1 = rawVforkSyscall(SYS_CLONE, uintptr(SIGCHLD|CLONE_VFORK|CLONE_VM)|sys.Cloneflags)
 	case runtime.GOARCH == "s390x":
 		r1, _, err1 = RawSyscall6(SYS_CLONE, 0, uintptr(SIGCHLD)|sys.Cloneflags, 0, 0, 0, 0)
@@ -241,8 +390,9 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
-	// Wait for User ID/Group ID mappings to be written.
-	if sys.UidMappings != nil || sys.GidMappings != nil {
+	// Wait for User ID/Group ID mappings to be written and PostClone
+	// to return.
+	if needsSync {
 		if _, _, err1 = RawSyscall(SYS_CLOSE, uintptr(p[1]), 0, 0); err1 != 0 {
 			goto childerror
 		}
@@ -357,6 +507,18 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Resource limits, except RLIMIT_NOFILE, which is set once
+	// the fds are in place, as they may exceed it until then.
+	for i := range sys.Rlimits {
+		if sys.Rlimits[i].Resource == RLIMIT_NOFILE {
+			continue
+		}
+		_, _, err1 = RawSyscall6(SYS_PRLIMIT64, 0, uintptr(sys.Rlimits[i].Resource), uintptr(unsafe.Pointer(&sys.Rlimits[i].Cur)), 0, 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Chroot
 	if chroot != nil {
 		_, _, err1 = RawSyscall(SYS_CHROOT, uintptr(unsafe.Pointer(chroot)), 0, 0)
@@ -423,6 +585,12 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 			goto childerror
 		}
 	}
+	if sys.UseDirFD {
+		_, _, err1 = RawSyscall(SYS_FCHDIR, uintptr(sys.DirFD), 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
 
 	// Parent death signal
 	if sys.Pdeathsig != 0 {
@@ -444,6 +612,21 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Restrict privileges and file system access before the
+	// descriptors are shuffled, which may close the ruleset.
+	if sys.NoNewPrivs {
+		_, _, err1 = RawSyscall6(SYS_PRCTL, PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+	if sys.UseLandlockRuleset {
+		_, _, err1 = RawSyscall(_SYS_landlock_restrict_self, uintptr(sys.LandlockRuleset), 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Pass 1: look for fd[i] < i and move those up above len(fd)
 	// so that pass 2 won't stomp on an fd it needs later.
 	if pipe < nextfd {
@@ -511,6 +694,29 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		RawSyscall(SYS_CLOSE, uintptr(i), 0, 0)
 	}
 
+	// Close everything else but the pipe, which is close-on-exec.
+	if sys.CloseOtherFds {
+		i = len(fd)
+		if i < 3 {
+			i = 3
+		}
+		if i < pipe {
+			_, _, err1 = RawSyscall(_SYS_close_range, uintptr(i), uintptr(pipe-1), 0)
+			if err1 == ENOSYS {
+				for ; i < pipe; i++ {
+					RawSyscall(SYS_CLOSE, uintptr(i), 0, 0)
+				}
+			}
+		}
+		i = pipe + 1
+		_, _, err1 = RawSyscall(_SYS_close_range, uintptr(i), ^uintptr(0), 0)
+		if err1 == ENOSYS {
+			for ; i < maxfd; i++ {
+				RawSyscall(SYS_CLOSE, uintptr(i), 0, 0)
+			}
+		}
+	}
+
 	// Detach fd 0 from tty
 	if sys.Noctty {
 		_, _, err1 = RawSyscall(SYS_IOCTL, 0, uintptr(TIOCNOTTY), 0)
@@ -527,6 +733,17 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Limit on open files
+	for i := range sys.Rlimits {
+		if sys.Rlimits[i].Resource != RLIMIT_NOFILE {
+			continue
+		}
+		_, _, err1 = RawSyscall6(SYS_PRLIMIT64, 0, uintptr(sys.Rlimits[i].Resource), uintptr(unsafe.Pointer(&sys.Rlimits[i].Cur)), 0, 0, 0)
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Enable tracing if requested.
 	// Do this right before exec so that we don't unnecessarily trace the runtime
 	// setting up after the fork. See issue #21428.
@@ -537,6 +754,15 @@ func forkAndExecInChild1(argv0 *byte, argv, envv []*byte, chroot, dir *byte, att
 		}
 	}
 
+	// Install the seccomp filter last, so that it does not apply
+	// to the setup above.
+	if seccomp != nil {
+		_, _, err1 = RawSyscall(SYS_PRCTL, PR_SET_SECCOMP, SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(seccomp)))
+		if err1 != 0 {
+			goto childerror
+		}
+	}
+
 	// Time to exec.
 	_, _, err1 = RawSyscall(SYS_EXECVE,
 		uintptr(unsafe.Pointer(argv0)),
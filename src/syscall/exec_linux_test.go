@@ -8,6 +8,7 @@
 package syscall_test
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"internal/testenv"
@@ -259,6 +260,84 @@ func TestUnshare(t *testing.T) {
 	}
 }
 
+func TestPostClone(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip(err)
+	}
+
+	var clonePid int
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		PostClone: func(pid int) error {
+			clonePid = pid
+			// ForkLock is not held: starting a process must not deadlock.
+			return exec.Command("true").Run()
+		},
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if clonePid != cmd.Process.Pid {
+		t.Errorf("PostClone called with pid %d, want %d", clonePid, cmd.Process.Pid)
+	}
+
+	for _, tt := range []struct {
+		err, want error
+	}{
+		{syscall.EACCES, syscall.EACCES},
+		{errors.New("setup failed"), syscall.ECANCELED},
+	} {
+		cmd := exec.Command("true")
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			PostClone: func(int) error { return tt.err },
+		}
+		if err := cmd.Start(); !errors.Is(err, tt.want) {
+			t.Errorf("Start with PostClone returning %v: got %v, want %v", tt.err, err, tt.want)
+			if err == nil {
+				cmd.Wait()
+			}
+		}
+	}
+}
+
+func TestCloseOtherFds(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+
+	// syscall.Open does not set close-on-exec.
+	fd, err := syscall.Open("/dev/null", syscall.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fd)
+	extra, err := os.Open("/dev/null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+
+	isOpen := func(closeOther bool, fd int) bool {
+		cmd := exec.Command("sh", "-c", fmt.Sprintf("test -e /proc/$$/fd/%d", fd))
+		cmd.ExtraFiles = []*os.File{extra}
+		cmd.SysProcAttr = &syscall.SysProcAttr{CloseOtherFds: closeOther}
+		err := cmd.Run()
+		if _, ok := err.(*exec.ExitError); err != nil && !ok {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+	if !isOpen(false, fd) {
+		t.Fatalf("descriptor %d not inherited without CloseOtherFds", fd)
+	}
+	if isOpen(true, fd) {
+		t.Errorf("descriptor %d inherited with CloseOtherFds", fd)
+	}
+	if !isOpen(true, 3) {
+		t.Errorf("ExtraFiles descriptor 3 closed with CloseOtherFds")
+	}
+}
+
 func TestGroupCleanup(t *testing.T) {
 	if os.Getuid() != 0 {
 		t.Skip("we need root for credential")
@@ -0,0 +1,20 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package syscall
+
+// A SysProcRlimit is a resource limit that StartProcess sets in the
+// child process, after fork and before exec, as with setrlimit(2).
+// Resource is one of the RLIMIT constants, and Cur and Max are the soft
+// and hard limits, laid out as the fields of Rlimit. RLIMIT_NOFILE is
+// set last, once the file descriptors of the child are in place; the
+// other limits are set before its credentials are changed.
+type SysProcRlimit struct {
+	Resource int
+	Cur      uint64
+	Max      uint64
+}
@@ -245,6 +245,7 @@ type SysProcAttr struct {
 	NoInheritHandles           bool                // if set, each inheritable handle in the calling process is not inherited by the new process
 	AdditionalInheritedHandles []Handle            // a list of additional handles, already marked as inheritable, that will be inherited by the new process
 	ParentProcess              Handle              // if non-zero, the new process regards the process given by this handle as its parent process, and AdditionalInheritedHandles, if set, should exist in this parent process
+	PseudoConsole              Handle              // if non-zero, the new process is attached to this pseudo console, created by CreatePseudoConsole, and uses it as standard input, output and error instead of the files in ProcAttr.Files
 }
 
 var zeroProcAttr ProcAttr
@@ -331,7 +332,7 @@ func StartProcess(argv0 string, argv []string, attr *ProcAttr) (pid int, handle
 	}
 	fd := make([]Handle, len(attr.Files))
 	for i := range attr.Files {
-		if attr.Files[i] > 0 {
+		if attr.Files[i] > 0 && sys.PseudoConsole == 0 {
 			destinationProcessHandle := parentProcess
 
 			// On Windows 7, console handles aren't real handles, and can only be duplicated
@@ -348,13 +349,15 @@ func StartProcess(argv0 string, argv []string, attr *ProcAttr) (pid int, handle
 		}
 	}
 	si := new(_STARTUPINFOEXW)
-	si.ProcThreadAttributeList, err = newProcThreadAttributeList(2)
+	si.ProcThreadAttributeList, err = newProcThreadAttributeList(3)
 	if err != nil {
 		return 0, 0, err
 	}
 	defer deleteProcThreadAttributeList(si.ProcThreadAttributeList)
 	si.Cb = uint32(unsafe.Sizeof(*si))
-	si.Flags = STARTF_USESTDHANDLES
+	if sys.PseudoConsole == 0 {
+		si.Flags = STARTF_USESTDHANDLES
+	}
 	if sys.HideWindow {
 		si.Flags |= STARTF_USESHOWWINDOW
 		si.ShowWindow = SW_HIDE
@@ -365,6 +368,14 @@ func StartProcess(argv0 string, argv []string, attr *ProcAttr) (pid int, handle
 			return 0, 0, err
 		}
 	}
+	if sys.PseudoConsole != 0 {
+		// The attribute value is the pseudo console handle itself,
+		// not a pointer to it.
+		err = updateProcThreadAttributeValue(si.ProcThreadAttributeList, 0, _PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, uintptr(sys.PseudoConsole), unsafe.Sizeof(sys.PseudoConsole), nil, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
 	si.StdInput = fd[0]
 	si.StdOutput = fd[1]
 	si.StdErr = fd[2]
@@ -450,6 +450,52 @@ func SendmsgN(fd int, p, oob []byte, to Sockaddr, flags int) (n int, err error)
 	return n, nil
 }
 
+// sendmsgBuffers is like SendmsgN, but gathers the data to send from
+// the buffers in bufs. Package internal/syscall/unix uses it.
+func sendmsgBuffers(fd int, bufs [][]byte, oob []byte, to Sockaddr, flags int) (n int, err error) {
+	var msg Msghdr
+	if to != nil {
+		ptr, salen, err := to.sockaddr()
+		if err != nil {
+			return 0, err
+		}
+		msg.Name = (*byte)(unsafe.Pointer(ptr))
+		msg.Namelen = uint32(salen)
+	}
+	iov := make([]Iovec, 0, len(bufs))
+	size := 0
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		iov = append(iov, Iovec{Base: (*byte)(unsafe.Pointer(&b[0]))})
+		iov[len(iov)-1].SetLen(len(b))
+		size += len(b)
+	}
+	var dummy byte
+	if len(oob) > 0 {
+		// send at least one normal byte
+		if size == 0 {
+			iov = append(iov, Iovec{Base: &dummy})
+			iov[0].SetLen(1)
+		}
+		msg.Control = (*byte)(unsafe.Pointer(&oob[0]))
+		msg.SetControllen(len(oob))
+	}
+	if len(iov) > 0 {
+		msg.Iov = &iov[0]
+		// Iovlen is an int, or an unsigned int on OpenBSD.
+		*(*int32)(unsafe.Pointer(&msg.Iovlen)) = int32(len(iov))
+	}
+	if n, err = sendmsg(fd, &msg, flags); err != nil {
+		return 0, err
+	}
+	if len(oob) > 0 && size == 0 {
+		n = 0
+	}
+	return n, nil
+}
+
 //sys	kevent(kq int, change unsafe.Pointer, nchange int, event unsafe.Pointer, nevent int, timeout *Timespec) (n int, err error)
 
 func Kevent(kq int, changes, events []Kevent_t, timeout *Timespec) (n int, err error) {
@@ -461,6 +461,56 @@ func (sa *SockaddrLinklayer) sockaddr() (unsafe.Pointer, _Socklen, error) {
 	return unsafe.Pointer(&sa.raw), SizeofSockaddrLinklayer, nil
 }
 
+// sockaddrVM implements the Sockaddr interface for AF_VSOCK type
+// sockets, which let virtual machines and their host communicate.
+// It is unexported because package syscall is frozen; package net
+// reaches it through internal/syscall/unix.
+type sockaddrVM struct {
+	// CID and Port specify a context ID and port address for a VM
+	// socket. Guests have a unique CID, and hosts may have a
+	// well-known CID of 0 (the hypervisor), 1 (the local host) or
+	// 2 (the host of the virtual machine).
+	CID  uint32
+	Port uint32
+	raw  rawSockaddrVM
+}
+
+// rawSockaddrVM is struct sockaddr_vm.
+type rawSockaddrVM struct {
+	Family    uint16
+	Reserved1 uint16
+	Port      uint32
+	Cid       uint32
+	Zero      [4]uint8
+}
+
+// _AF_VSOCK is AF_VSOCK, which zerrors only has on some
+// architectures.
+const _AF_VSOCK = 0x28
+
+func (sa *sockaddrVM) sockaddr() (unsafe.Pointer, _Socklen, error) {
+	sa.raw.Family = _AF_VSOCK
+	sa.raw.Port = sa.Port
+	sa.raw.Cid = sa.CID
+	return unsafe.Pointer(&sa.raw), _Socklen(unsafe.Sizeof(sa.raw)), nil
+}
+
+// newSockaddrVM returns an AF_VSOCK socket address.
+// It is used by internal/syscall/unix via linkname.
+func newSockaddrVM(cid, port uint32) Sockaddr {
+	return &sockaddrVM{CID: cid, Port: port}
+}
+
+// sockaddrVMAddr reports the context ID and port of sa,
+// and whether sa is an AF_VSOCK socket address.
+// It is used by internal/syscall/unix via linkname.
+func sockaddrVMAddr(sa Sockaddr) (cid, port uint32, ok bool) {
+	if sa, ok := sa.(*sockaddrVM); ok {
+		return sa.CID, sa.Port, true
+	}
+	return 0, 0, false
+}
+
 type SockaddrNetlink struct {
 	Family uint16
 	Pad    uint16
@@ -501,6 +551,13 @@ func anyToSockaddr(rsa *RawSockaddrAny) (Sockaddr, error) {
 		}
 		return sa, nil
 
+	case _AF_VSOCK:
+		pp := (*rawSockaddrVM)(unsafe.Pointer(rsa))
+		sa := new(sockaddrVM)
+		sa.CID = pp.Cid
+		sa.Port = pp.Port
+		return sa, nil
+
 	case AF_UNIX:
 		pp := (*RawSockaddrUnix)(unsafe.Pointer(rsa))
 		sa := new(SockaddrUnix)
@@ -740,6 +797,59 @@ func SendmsgN(fd int, p, oob []byte, to Sockaddr, flags int) (n int, err error)
 	return n, nil
 }
 
+// sendmsgBuffers is like SendmsgN, but gathers the data to send from
+// the buffers in bufs. Package internal/syscall/unix uses it.
+func sendmsgBuffers(fd int, bufs [][]byte, oob []byte, to Sockaddr, flags int) (n int, err error) {
+	var msg Msghdr
+	if to != nil {
+		ptr, salen, err := to.sockaddr()
+		if err != nil {
+			return 0, err
+		}
+		msg.Name = (*byte)(ptr)
+		msg.Namelen = uint32(salen)
+	}
+	iov := make([]Iovec, 0, len(bufs))
+	size := 0
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		iov = append(iov, Iovec{Base: &b[0]})
+		iov[len(iov)-1].SetLen(len(b))
+		size += len(b)
+	}
+	var dummy byte
+	if len(oob) > 0 {
+		if size == 0 {
+			var sockType int
+			sockType, err = GetsockoptInt(fd, SOL_SOCKET, SO_TYPE)
+			if err != nil {
+				return 0, err
+			}
+			// send at least one normal byte
+			if sockType != SOCK_DGRAM {
+				iov = append(iov, Iovec{Base: &dummy})
+				iov[0].SetLen(1)
+			}
+		}
+		msg.Control = &oob[0]
+		msg.SetControllen(len(oob))
+	}
+	if len(iov) > 0 {
+		msg.Iov = &iov[0]
+		// Iovlen is a size_t, which is the size of a uintptr.
+		*(*uintptr)(unsafe.Pointer(&msg.Iovlen)) = uintptr(len(iov))
+	}
+	if n, err = sendmsg(fd, &msg, flags); err != nil {
+		return 0, err
+	}
+	if len(oob) > 0 && size == 0 {
+		n = 0
+	}
+	return n, nil
+}
+
 // BindToDevice binds the socket associated with fd to device.
 func BindToDevice(fd int, device string) (err error) {
 	return SetsockoptString(fd, SOL_SOCKET, SO_BINDTODEVICE, device)
@@ -13,6 +13,11 @@ import "unsafe"
 const archHonorsR2 = true
 
 const _SYS_setgroups = SYS_SETGROUPS32
+const (
+	_SYS_clone3                 = 435
+	_SYS_close_range            = 436
+	_SYS_landlock_restrict_self = 446
+)
 
 func setTimespec(sec, nsec int64) Timespec {
 	return Timespec{Sec: int32(sec), Nsec: int32(nsec)}
@@ -13,6 +13,11 @@ import "unsafe"
 const archHonorsR2 = true
 
 const _SYS_setgroups = SYS_SETGROUPS
+const (
+	_SYS_clone3                 = 435
+	_SYS_close_range            = 436
+	_SYS_landlock_restrict_self = 446
+)
 
 func EpollCreate(size int) (fd int, err error) {
 	if size <= 0 {
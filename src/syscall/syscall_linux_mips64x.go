@@ -15,6 +15,11 @@ package syscall
 const archHonorsR2 = true
 
 const _SYS_setgroups = SYS_SETGROUPS
+const (
+	_SYS_clone3                 = 5435
+	_SYS_close_range            = 5436
+	_SYS_landlock_restrict_self = 5446
+)
 
 //sys	Dup2(oldfd int, newfd int) (err error)
 //sysnb	EpollCreate(size int) (fd int, err error)
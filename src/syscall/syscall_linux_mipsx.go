@@ -17,6 +17,11 @@ import "unsafe"
 const archHonorsR2 = true
 
 const _SYS_setgroups = SYS_SETGROUPS
+const (
+	_SYS_clone3                 = 4435
+	_SYS_close_range            = 4436
+	_SYS_landlock_restrict_self = 4446
+)
 
 func Syscall9(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9 uintptr) (r1, r2 uintptr, err Errno)
 
@@ -15,6 +15,11 @@ package syscall
 const archHonorsR2 = false
 
 const _SYS_setgroups = SYS_SETGROUPS
+const (
+	_SYS_clone3                 = 435
+	_SYS_close_range            = 436
+	_SYS_landlock_restrict_self = 446
+)
 
 //sys	Dup2(oldfd int, newfd int) (err error)
 //sysnb	EpollCreate(size int) (fd int, err error)
@@ -497,6 +497,7 @@ type _PROC_THREAD_ATTRIBUTE_LIST struct {
 const (
 	_PROC_THREAD_ATTRIBUTE_PARENT_PROCESS = 0x00020000
 	_PROC_THREAD_ATTRIBUTE_HANDLE_LIST    = 0x00020002
+	_PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE  = 0x00020016
 )
 
 type _STARTUPINFOEXW struct {
@@ -10,6 +10,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -25,11 +26,18 @@ import (
 //
 // File system operations read directly from the map,
 // so that the file system can be changed by editing the map as needed.
+// The file system can also be changed through the writable file system
+// interfaces of package io/fs, such as fs.OpenFileFS and fs.MkdirFS,
+// which MapFS implements by editing the map.
 // An implication is that file system operations must not run concurrently
 // with changes to the map, which would be a race.
 // Another implication is that opening or reading a directory requires
 // iterating over the entire map, so a MapFS should typically be used with not more
 // than a few hundred entries or directory reads.
+//
+// Symbolic links are represented by entries whose Mode has the ModeSymlink
+// bit set and whose Data holds the link target. MapFS does not follow
+// symbolic links: opening one reads its target as file content.
 type MapFS map[string]*MapFile
 
 // A MapFile describes a single file in a MapFS.
@@ -51,7 +59,7 @@ func (fsys MapFS) Open(name string) (fs.File, error) {
 	file := fsys[name]
 	if file != nil && file.Mode&fs.ModeDir == 0 {
 		// Ordinary file
-		return &openMapFile{name, mapFileInfo{path.Base(name), file}, 0}, nil
+		return &openMapFile{name, mapFileInfo{path.Base(name), file}, 0, syscall.O_RDONLY}, nil
 	}
 
 	// Directory, possibly synthesized.
@@ -132,6 +140,44 @@ func (fsys MapFS) Glob(pattern string) ([]string, error) {
 	return fs.Glob(fsOnly{fsys}, pattern)
 }
 
+// Symlink adds newname to the map as a symbolic link to oldname.
+func (fsys MapFS) Symlink(oldname, newname string) error {
+	if err := fsys.checkNew("symlink", newname); err != nil {
+		return err
+	}
+	fsys[newname] = &MapFile{Data: []byte(oldname), Mode: fs.ModeSymlink | 0777}
+	return nil
+}
+
+// Link adds newname to the map as a hard link to the oldname file:
+// both names refer to the same *MapFile.
+func (fsys MapFS) Link(oldname, newname string) error {
+	file := fsys[oldname]
+	if file == nil || !fs.ValidPath(oldname) {
+		return &fs.PathError{Op: "link", Path: oldname, Err: fs.ErrNotExist}
+	}
+	if file.Mode.IsDir() {
+		return &fs.PathError{Op: "link", Path: oldname, Err: fs.ErrPermission}
+	}
+	if err := fsys.checkNew("link", newname); err != nil {
+		return err
+	}
+	fsys[newname] = file
+	return nil
+}
+
+// checkNew reports an error for op if name is not a valid path
+// or already exists in fsys.
+func (fsys MapFS) checkNew(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := fs.Stat(fsOnly{fsys}, name); err == nil {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrExist}
+	}
+	return nil
+}
+
 type noSub struct {
 	MapFS
 }
@@ -157,11 +203,13 @@ func (i *mapFileInfo) IsDir() bool                { return i.f.Mode&fs.ModeDir !
 func (i *mapFileInfo) Sys() interface{}           { return i.f.Sys }
 func (i *mapFileInfo) Info() (fs.FileInfo, error) { return i, nil }
 
-// An openMapFile is a regular (non-directory) fs.File open for reading.
+// An openMapFile is a regular (non-directory) fs.File open for reading
+// or, when opened by OpenFile, for writing.
 type openMapFile struct {
 	path string
 	mapFileInfo
 	offset int64
+	flag   int // flag passed to OpenFile
 }
 
 func (f *openMapFile) Stat() (fs.FileInfo, error) { return &f.mapFileInfo, nil }
@@ -169,6 +217,9 @@ func (f *openMapFile) Stat() (fs.FileInfo, error) { return &f.mapFileInfo, nil }
 func (f *openMapFile) Close() error { return nil }
 
 func (f *openMapFile) Read(b []byte) (int, error) {
+	if !f.readable() {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrPermission}
+	}
 	if f.offset >= int64(len(f.f.Data)) {
 		return 0, io.EOF
 	}
@@ -189,7 +240,8 @@ func (f *openMapFile) Seek(offset int64, whence int) (int64, error) {
 	case 2:
 		offset += int64(len(f.f.Data))
 	}
-	if offset < 0 || offset > int64(len(f.f.Data)) {
+	if offset < 0 || offset > int64(len(f.f.Data)) && !f.writable() {
+		// Files open for writing can seek past the end, to write a hole.
 		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrInvalid}
 	}
 	f.offset = offset
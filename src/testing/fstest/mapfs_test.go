@@ -5,6 +5,11 @@
 package fstest
 
 import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
 	"testing"
 )
 
@@ -17,3 +22,103 @@ func TestMapFS(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMapFSLinks(t *testing.T) {
+	m := MapFS{
+		"dir/a": {Data: []byte("a")},
+	}
+	if err := fs.Symlink(m, "a", "dir/sym"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Link(m, "dir/a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if f := m["dir/sym"]; f == nil || f.Mode.Type() != fs.ModeSymlink || string(f.Data) != "a" {
+		t.Errorf("after Symlink, dir/sym = %+v, want symbolic link to a", f)
+	}
+	if m["b"] != m["dir/a"] {
+		t.Errorf("after Link, b and dir/a are different files")
+	}
+	if err := TestFS(m, "dir/a", "dir/sym", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"Symlink existing", fs.Symlink(m, "x", "b"), fs.ErrExist},
+		{"Symlink synthesized dir", fs.Symlink(m, "x", "dir"), fs.ErrExist},
+		{"Symlink invalid", fs.Symlink(m, "x", "../x"), fs.ErrInvalid},
+		{"Link missing", fs.Link(m, "missing", "c"), fs.ErrNotExist},
+		{"Link existing", fs.Link(m, "b", "dir/sym"), fs.ErrExist},
+	} {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+}
+
+func TestMapFSWrite(t *testing.T) {
+	m := MapFS{
+		"hello": {Data: []byte("hello, world\n")},
+	}
+	if err := TestWriteFS(m, "."); err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m["hello"] == nil {
+		t.Errorf("TestWriteFS left changes behind: %v", m)
+	}
+
+	// Renaming a directory moves its contents, including
+	// synthesized subdirectories.
+	m = MapFS{
+		"a/b/c": {Data: []byte("c")},
+	}
+	if err := fs.Rename(m, "a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TestFS(m, "x/b/c"); err != nil {
+		t.Fatal(err)
+	}
+	if m["a"] != nil || m["a/b/c"] != nil {
+		t.Errorf("Rename left old names behind: %v", m)
+	}
+}
+
+func TestMapFSHole(t *testing.T) {
+	m := MapFS{}
+	f, err := m.OpenFile("f", syscall.O_RDWR|syscall.O_CREAT, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := f.(interface {
+		io.WriterAt
+		Truncate(int64) error
+	})
+	if _, err := w.WriteAt([]byte("end"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["f"].Data); got != "\x00\x00\x00\x00\x00end" {
+		t.Errorf("after WriteAt past end, data = %q", got)
+	}
+	if err := w.Truncate(10); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Truncate(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Truncate(4); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["f"].Data); got != "\x00\x00\x00\x00" {
+		t.Errorf("after Truncate, data = %q", got)
+	}
+}
+
+func TestDirFSWrite(t *testing.T) {
+	if err := TestWriteFS(os.DirFS(t.TempDir()), "."); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fstest
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// The methods in this file let a MapFS be modified through the writable
+// file system interfaces of package io/fs. Writes go directly to the map
+// and to the MapFile.Data slices in it.
+
+// errNotEmpty is returned when removing a directory that has children.
+var errNotEmpty = errors.New("directory not empty")
+
+// chmodMask is the set of mode bits that Chmod changes.
+const chmodMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// writeFlags are the OpenFile flags that need a writable file system.
+const writeFlags = syscall.O_WRONLY | syscall.O_RDWR | syscall.O_CREAT | syscall.O_TRUNC | syscall.O_APPEND
+
+// stat returns information about name, which may be a synthesized
+// directory, or nil if name does not exist.
+func (fsys MapFS) stat(name string) fs.FileInfo {
+	info, err := fs.Stat(fsOnly{fsys}, name)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// checkParent reports an error for op if the parent directory
+// of name does not exist.
+func (fsys MapFS) checkParent(op, name string) error {
+	if dir := path.Dir(name); dir != "." {
+		if info := fsys.stat(dir); info == nil || !info.IsDir() {
+			return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	return nil
+}
+
+// entry returns the map entry for name, adding one if name is
+// a synthesized directory, or nil if name does not exist.
+func (fsys MapFS) entry(name string) *MapFile {
+	if file := fsys[name]; file != nil {
+		return file
+	}
+	if info := fsys.stat(name); info != nil && info.IsDir() {
+		file := &MapFile{Mode: fs.ModeDir}
+		fsys[name] = file
+		return file
+	}
+	return nil
+}
+
+// OpenFile opens the named file with the specified flag, which takes the
+// values of package os. If the file does not exist and O_CREATE is passed,
+// it is added to the map with mode perm.
+func (fsys MapFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	if flag&writeFlags == 0 {
+		return fsys.Open(name)
+	}
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	file := fsys[name]
+	switch {
+	case file == nil && fsys.stat(name) != nil, file != nil && file.Mode.IsDir():
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	case file == nil && flag&syscall.O_CREAT == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case file == nil:
+		if err := fsys.checkParent("open", name); err != nil {
+			return nil, err
+		}
+		file = &MapFile{Mode: perm & chmodMask, ModTime: time.Now()}
+		fsys[name] = file
+	case flag&(syscall.O_CREAT|syscall.O_EXCL) == syscall.O_CREAT|syscall.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case flag&syscall.O_TRUNC != 0:
+		file.Data = nil
+		file.ModTime = time.Now()
+	}
+	return &openMapFile{name, mapFileInfo{path.Base(name), file}, 0, flag}, nil
+}
+
+// WriteFile sets the content of the named file to a copy of data,
+// adding the file to the map with mode perm if it does not exist.
+func (fsys MapFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	f, err := fsys.OpenFile(name, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	file := f.(*openMapFile).f
+	file.Data = append([]byte(nil), data...)
+	return nil
+}
+
+// Mkdir adds the named directory to the map with mode perm.
+func (fsys MapFS) Mkdir(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	if fsys.stat(name) != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	if err := fsys.checkParent("mkdir", name); err != nil {
+		return err
+	}
+	fsys[name] = &MapFile{Mode: fs.ModeDir | perm&chmodMask, ModTime: time.Now()}
+	return nil
+}
+
+// Remove removes the named file or empty directory from the map.
+func (fsys MapFS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	info := fsys.stat(name)
+	if info == nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if info.IsDir() {
+		prefix := name + "/"
+		for fname := range fsys {
+			if strings.HasPrefix(fname, prefix) {
+				return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+			}
+		}
+	}
+	delete(fsys, name)
+	return nil
+}
+
+// Rename renames oldname to newname, moving everything in it
+// if it is a directory. If newname exists and neither name is
+// a directory, Rename replaces it.
+func (fsys MapFS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || !fs.ValidPath(newname) || oldname == "." || newname == "." ||
+		strings.HasPrefix(newname, oldname+"/") {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+	oldinfo := fsys.stat(oldname)
+	if oldinfo == nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrNotExist}
+	}
+	if oldname == newname {
+		return nil
+	}
+	if newinfo := fsys.stat(newname); newinfo != nil && (newinfo.IsDir() || oldinfo.IsDir()) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	}
+	if err := fsys.checkParent("rename", newname); err != nil {
+		return err
+	}
+	if oldinfo.IsDir() {
+		fsys.entry(oldname)
+		prefix := oldname + "/"
+		for fname, file := range fsys {
+			if strings.HasPrefix(fname, prefix) {
+				delete(fsys, fname)
+				fsys[newname+"/"+fname[len(prefix):]] = file
+			}
+		}
+	}
+	fsys[newname] = fsys[oldname]
+	delete(fsys, oldname)
+	return nil
+}
+
+// Chmod changes the mode of the named file to mode.
+func (fsys MapFS) Chmod(name string, mode fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	file := fsys.entry(name)
+	if file == nil {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	file.Mode = file.Mode&^chmodMask | mode&chmodMask
+	return nil
+}
+
+// Chtimes changes the modification time of the named file to mtime.
+// A MapFile has no access time, so atime is ignored.
+func (fsys MapFS) Chtimes(name string, atime, mtime time.Time) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	file := fsys.entry(name)
+	if file == nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	file.ModTime = mtime
+	return nil
+}
+
+// writable reports whether f was opened for writing.
+func (f *openMapFile) writable() bool {
+	return f.flag&(syscall.O_WRONLY|syscall.O_RDWR) != 0
+}
+
+// readable reports whether f was opened for reading.
+func (f *openMapFile) readable() bool {
+	return f.flag&syscall.O_WRONLY == 0
+}
+
+func (f *openMapFile) Write(b []byte) (int, error) {
+	if !f.writable() {
+		return 0, &fs.PathError{Op: "write", Path: f.path, Err: fs.ErrPermission}
+	}
+	if f.flag&syscall.O_APPEND != 0 {
+		f.offset = int64(len(f.f.Data))
+	}
+	n := f.writeAt(b, f.offset)
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *openMapFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *openMapFile) WriteAt(b []byte, offset int64) (int, error) {
+	if !f.writable() {
+		return 0, &fs.PathError{Op: "write", Path: f.path, Err: fs.ErrPermission}
+	}
+	if f.flag&syscall.O_APPEND != 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: f.path, Err: errors.New("invalid use of WriteAt on file opened with O_APPEND")}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: f.path, Err: fs.ErrInvalid}
+	}
+	return f.writeAt(b, offset), nil
+}
+
+// writeAt writes b at offset, first growing the file
+// with zeros if offset is beyond its end.
+func (f *openMapFile) writeAt(b []byte, offset int64) int {
+	f.resize(offset + int64(len(b)))
+	f.f.ModTime = time.Now()
+	return copy(f.f.Data[offset:], b)
+}
+
+// resize makes the file at least size bytes long,
+// reading as zeros where it is extended.
+func (f *openMapFile) resize(size int64) {
+	data := f.f.Data
+	if size <= int64(len(data)) {
+		return
+	}
+	if size <= int64(cap(data)) {
+		data = data[:size]
+		for i := len(f.f.Data); i < len(data); i++ {
+			data[i] = 0
+		}
+	} else {
+		data = append(data, make([]byte, size-int64(len(data)))...)
+	}
+	f.f.Data = data
+}
+
+// Truncate changes the size of the file. Extending the file
+// leaves a hole that reads as zeros.
+func (f *openMapFile) Truncate(size int64) error {
+	if !f.writable() {
+		return &fs.PathError{Op: "truncate", Path: f.path, Err: fs.ErrPermission}
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: f.path, Err: fs.ErrInvalid}
+	}
+	if size < int64(len(f.f.Data)) {
+		f.f.Data = f.f.Data[:size]
+	} else {
+		f.resize(size)
+	}
+	f.f.ModTime = time.Now()
+	return nil
+}
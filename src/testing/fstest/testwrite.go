@@ -0,0 +1,331 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fstest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"syscall"
+	"time"
+)
+
+// TestWriteFS tests the writable file system interfaces implemented by fsys,
+// such as fs.OpenFileFS, fs.MkdirFS and fs.RenameFS. It works in a new
+// directory that it creates, and then removes, inside the existing
+// directory dir of fsys; it does not touch any other files. Interfaces that
+// fsys does not implement are not tested, but fsys must implement at least
+// fs.MkdirFS. Symbolic links are not tested. After making its changes,
+// TestWriteFS checks the result with TestFS, so that the read side of fsys
+// must agree with what was written.
+//
+// If TestWriteFS finds any misbehaviors, it returns an error reporting all
+// of them. The error text spans multiple lines, one per detected misbehavior.
+//
+// Typical usage inside a test is:
+//
+//	if err := fstest.TestWriteFS(myFS, "."); err != nil {
+//		t.Fatal(err)
+//	}
+//
+func TestWriteFS(fsys fs.FS, dir string) error {
+	if _, ok := fsys.(fs.MkdirFS); !ok {
+		return errors.New("TestWriteFS: file system does not implement fs.MkdirFS")
+	}
+	t := writeTester{fsys: fsys, dir: path.Join(dir, "fstest-write")}
+	if err := fs.Mkdir(fsys, t.dir, 0777); err != nil {
+		return errors.New("TestWriteFS: " + err.Error())
+	}
+
+	t.checkMkdir()
+	expected := []string{"sub"}
+	_, canOpen := fsys.(fs.OpenFileFS)
+	if canOpen {
+		t.checkOpenFile()
+		expected = append(expected, "sub/open", "sub/hole")
+	}
+	if _, ok := fsys.(fs.WriteFileFS); ok || canOpen {
+		t.checkWriteFile()
+		expected = append(expected, "write")
+	}
+	if t.has("write") {
+		if _, ok := fsys.(fs.RenameFS); ok {
+			t.checkRename()
+			expected[len(expected)-1] = "renamed"
+		}
+		t.checkMetadata()
+	}
+	if t.has("renamed") {
+		if _, ok := fsys.(fs.LinkFS); ok {
+			t.checkLink()
+			expected = append(expected, "sub/link")
+		}
+	}
+	if len(t.errText) == 0 {
+		sub, err := fs.Sub(fsys, t.dir)
+		if err != nil {
+			t.errorf("Sub: %v", err)
+		} else if err := TestFS(sub, expected...); err != nil {
+			t.errorf("after writing, %v", err)
+		}
+	}
+	if _, ok := fsys.(fs.RemoveFS); ok {
+		t.checkRemove(".")
+	}
+
+	if len(t.errText) == 0 {
+		return nil
+	}
+	return errors.New("TestWriteFS found errors:\n" + string(t.errText))
+}
+
+// A writeTester holds state for running TestWriteFS.
+type writeTester struct {
+	fsys    fs.FS
+	dir     string // directory holding all test files
+	errText []byte
+}
+
+// errorf adds an error line to errText.
+func (t *writeTester) errorf(format string, args ...interface{}) {
+	if len(t.errText) > 0 {
+		t.errText = append(t.errText, '\n')
+	}
+	t.errText = append(t.errText, fmt.Sprintf(format, args...)...)
+}
+
+// name returns the name in fsys of the test file rel.
+func (t *writeTester) name(rel string) string {
+	return path.Join(t.dir, rel)
+}
+
+// has reports whether the test file rel exists.
+func (t *writeTester) has(rel string) bool {
+	_, err := fs.Stat(t.fsys, t.name(rel))
+	return err == nil
+}
+
+// checkContent checks that the test file rel holds data.
+func (t *writeTester) checkContent(rel string, data string) {
+	name := t.name(rel)
+	got, err := fs.ReadFile(t.fsys, name)
+	if err != nil {
+		t.errorf("%s: ReadFile: %v", name, err)
+		return
+	}
+	if string(got) != data {
+		t.errorf("%s: ReadFile = %q, want %q", name, got, data)
+	}
+	if info, err := fs.Stat(t.fsys, name); err != nil {
+		t.errorf("%s: Stat: %v", name, err)
+	} else if info.Size() != int64(len(data)) {
+		t.errorf("%s: Stat: Size() = %d, want %d", name, info.Size(), len(data))
+	}
+}
+
+func (t *writeTester) checkMkdir() {
+	name := t.name("sub")
+	if err := fs.Mkdir(t.fsys, name, 0777); err != nil {
+		t.errorf("%s: Mkdir: %v", name, err)
+		return
+	}
+	if info, err := fs.Stat(t.fsys, name); err != nil || !info.IsDir() {
+		t.errorf("%s: Stat after Mkdir = %v, %v, want directory", name, info, err)
+	}
+	if err := fs.Mkdir(t.fsys, name, 0777); !errors.Is(err, fs.ErrExist) {
+		t.errorf("%s: second Mkdir = %v, want ErrExist", name, err)
+	}
+	missing := t.name("missing/sub")
+	if err := fs.Mkdir(t.fsys, missing, 0777); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s: Mkdir without parent = %v, want ErrNotExist", missing, err)
+	}
+}
+
+// openWritable opens the test file rel with flag and returns it
+// as a fs.WritableFile, or reports an error and returns nil.
+func (t *writeTester) openWritable(rel string, flag int) fs.WritableFile {
+	name := t.name(rel)
+	f, err := fs.OpenFile(t.fsys, name, flag, 0666)
+	if err != nil {
+		t.errorf("%s: OpenFile(%#x): %v", name, flag, err)
+		return nil
+	}
+	w, ok := f.(fs.WritableFile)
+	if !ok {
+		f.Close()
+		t.errorf("%s: OpenFile(%#x) returned File type %T, not a fs.WritableFile", name, flag, f)
+		return nil
+	}
+	return w
+}
+
+// write writes data to f, reporting any error.
+func (t *writeTester) write(f fs.WritableFile, rel, data string) {
+	if n, err := f.Write([]byte(data)); n != len(data) || err != nil {
+		t.errorf("%s: Write(%q) = %d, %v, want %d, nil", t.name(rel), data, n, err, len(data))
+	}
+}
+
+// close closes f, reporting any error.
+func (t *writeTester) close(f fs.File, rel string) {
+	if err := f.Close(); err != nil {
+		t.errorf("%s: Close: %v", t.name(rel), err)
+	}
+}
+
+func (t *writeTester) checkOpenFile() {
+	const rel = "sub/open"
+	if f := t.openWritable(rel, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_EXCL); f != nil {
+		t.write(f, rel, "hello, ")
+		t.close(f, rel)
+	}
+	t.checkContent(rel, "hello, ")
+
+	name := t.name(rel)
+	if _, err := fs.OpenFile(t.fsys, name, syscall.O_WRONLY|syscall.O_CREAT|syscall.O_EXCL, 0666); !errors.Is(err, fs.ErrExist) {
+		t.errorf("%s: OpenFile(O_CREATE|O_EXCL) of existing file = %v, want ErrExist", name, err)
+	}
+	missing := t.name("missing")
+	if _, err := fs.OpenFile(t.fsys, missing, syscall.O_WRONLY, 0666); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s: OpenFile(O_WRONLY) of missing file = %v, want ErrNotExist", missing, err)
+	}
+
+	if f := t.openWritable(rel, syscall.O_WRONLY|syscall.O_APPEND); f != nil {
+		t.write(f, rel, "world")
+		t.close(f, rel)
+	}
+	t.checkContent(rel, "hello, world")
+
+	if f := t.openWritable(rel, syscall.O_RDWR); f != nil {
+		t.write(f, rel, "HELLO")
+		buf := make([]byte, 2)
+		if n, err := io.ReadFull(f, buf); n != 2 || err != nil || string(buf) != ", " {
+			t.errorf("%s: Read after Write = %q, %v, want %q, nil", name, buf[:n], err, ", ")
+		}
+		t.close(f, rel)
+	}
+	t.checkContent(rel, "HELLO, world")
+
+	if f := t.openWritable(rel, syscall.O_WRONLY|syscall.O_TRUNC); f != nil {
+		t.write(f, rel, "truncated")
+		t.close(f, rel)
+	}
+	t.checkContent(rel, "truncated")
+
+	// Seeking past the end and writing leaves a hole that reads as zeros.
+	const hole = "sub/hole"
+	if f := t.openWritable(hole, syscall.O_WRONLY|syscall.O_CREAT); f != nil {
+		if s, ok := f.(io.Seeker); ok {
+			if _, err := s.Seek(4, io.SeekStart); err != nil {
+				t.errorf("%s: Seek(4, 0) past end: %v", t.name(hole), err)
+			}
+			t.write(f, hole, "x")
+			t.close(f, hole)
+			t.checkContent(hole, "\x00\x00\x00\x00x")
+		} else {
+			t.write(f, hole, "\x00\x00\x00\x00x")
+			t.close(f, hole)
+		}
+	}
+}
+
+func (t *writeTester) checkWriteFile() {
+	const rel = "write"
+	name := t.name(rel)
+	if err := fs.WriteFile(t.fsys, name, []byte("long content"), 0666); err != nil {
+		t.errorf("%s: WriteFile: %v", name, err)
+		return
+	}
+	t.checkContent(rel, "long content")
+	if err := fs.WriteFile(t.fsys, name, []byte("short"), 0666); err != nil {
+		t.errorf("%s: second WriteFile: %v", name, err)
+	}
+	t.checkContent(rel, "short")
+}
+
+func (t *writeTester) checkRename() {
+	oldname, newname := t.name("write"), t.name("renamed")
+	if err := fs.Rename(t.fsys, oldname, newname); err != nil {
+		t.errorf("%s: Rename to %s: %v", oldname, newname, err)
+		return
+	}
+	if _, err := fs.Stat(t.fsys, oldname); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s: Stat after Rename = %v, want ErrNotExist", oldname, err)
+	}
+	t.checkContent("renamed", "short")
+}
+
+func (t *writeTester) checkMetadata() {
+	rel := "write"
+	if t.has("renamed") {
+		rel = "renamed"
+	}
+	name := t.name(rel)
+	if _, ok := t.fsys.(fs.ChmodFS); ok {
+		if err := fs.Chmod(t.fsys, name, 0444); err != nil {
+			t.errorf("%s: Chmod: %v", name, err)
+		} else if info, err := fs.Stat(t.fsys, name); err != nil {
+			t.errorf("%s: Stat after Chmod: %v", name, err)
+		} else if info.Mode()&0222 != 0 {
+			t.errorf("%s: Stat after Chmod(0444): Mode() = %v, want read-only", name, info.Mode())
+		}
+		if err := fs.Chmod(t.fsys, name, 0666); err != nil {
+			t.errorf("%s: Chmod: %v", name, err)
+		}
+	}
+	if _, ok := t.fsys.(fs.ChtimesFS); ok {
+		mtime := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+		if err := fs.Chtimes(t.fsys, name, mtime, mtime); err != nil {
+			t.errorf("%s: Chtimes: %v", name, err)
+		} else if info, err := fs.Stat(t.fsys, name); err != nil {
+			t.errorf("%s: Stat after Chtimes: %v", name, err)
+		} else if !info.ModTime().Equal(mtime) {
+			t.errorf("%s: Stat after Chtimes: ModTime() = %v, want %v", name, info.ModTime(), mtime)
+		}
+	}
+}
+
+func (t *writeTester) checkLink() {
+	oldname, newname := t.name("renamed"), t.name("sub/link")
+	if err := fs.Link(t.fsys, oldname, newname); err != nil {
+		t.errorf("%s: Link to %s: %v", oldname, newname, err)
+		return
+	}
+	t.checkContent("sub/link", "short")
+}
+
+// checkRemove removes the test file rel and everything in it,
+// checking that non-empty directories cannot be removed.
+func (t *writeTester) checkRemove(rel string) {
+	name := t.name(rel)
+	info, err := fs.Stat(t.fsys, name)
+	if err != nil {
+		t.errorf("%s: Stat: %v", name, err)
+		return
+	}
+	if info.IsDir() {
+		list, err := fs.ReadDir(t.fsys, name)
+		if err != nil {
+			t.errorf("%s: ReadDir: %v", name, err)
+			return
+		}
+		if len(list) > 0 && fs.Remove(t.fsys, name) == nil {
+			t.errorf("%s: Remove of non-empty directory succeeded", name)
+			return
+		}
+		for _, d := range list {
+			t.checkRemove(path.Join(rel, d.Name()))
+		}
+	}
+	if err := fs.Remove(t.fsys, name); err != nil {
+		t.errorf("%s: Remove: %v", name, err)
+		return
+	}
+	if _, err := fs.Stat(t.fsys, name); !errors.Is(err, fs.ErrNotExist) {
+		t.errorf("%s: Stat after Remove = %v, want ErrNotExist", name, err)
+	}
+}